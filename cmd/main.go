@@ -13,11 +13,14 @@ import (
 
 	"inbox451/internal/api"
 	"inbox451/internal/assets"
+	"inbox451/internal/auth"
 	"inbox451/internal/config"
 	"inbox451/internal/core"
 	"inbox451/internal/imap"
+	"inbox451/internal/pop3"
 	"inbox451/internal/smtp/msa"
 	"inbox451/internal/smtp/mta"
+	"inbox451/internal/smtp/outbound"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/knadh/koanf/providers/posflag"
@@ -77,10 +80,6 @@ type ServerInstance struct {
 }
 
 func startServers(core *core.Core, db *sql.DB) error {
-	// Create error channel for servers
-	const numServers = 4
-	errChan := make(chan serverError, numServers)
-
 	// Create a channel to listen for interrupt signals
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
@@ -91,14 +90,54 @@ func startServers(core *core.Core, db *sql.DB) error {
 		return fmt.Errorf("failed to create IMAP server: %w", err)
 	}
 
+	// Initialize POP3 server
+	pop3Server, err := pop3.NewServer(core)
+	if err != nil {
+		return fmt.Errorf("failed to create POP3 server: %w", err)
+	}
+
+	// Initialize SMTP servers
+	mtaServer, err := mta.NewServer(core)
+	if err != nil {
+		return fmt.Errorf("failed to create MTA server: %w", err)
+	}
+	msaServer, err := msa.NewServer(core)
+	if err != nil {
+		return fmt.Errorf("failed to create MSA server: %w", err)
+	}
+
 	// Initialize all servers
 	servers := []ServerInstance{
 		{server: api.NewServer(context.Background(), core, db), name: "HTTP"},
 		{server: imapServer, name: "IMAP"},
-		{server: mta.NewServer(core), name: "SMTP: Mail Transfer Agent (MTA)"},
-		{server: msa.NewServer(core), name: "SMTP Mail Submission Agent (MSA)"},
+		{server: pop3Server, name: "POP3"},
+		{server: mtaServer, name: "SMTP: Mail Transfer Agent (MTA)"},
+		{server: msaServer, name: "SMTP Mail Submission Agent (MSA)"},
+	}
+
+	// Reload the TLS certificates when they are renewed
+	if core.Certificates != nil {
+		servers = append(servers, ServerInstance{server: core.Certificates, name: "TLS certificate watcher"})
+	}
+
+	// Answer ACME challenges on plain HTTP when the HTTP server isn't on port 80
+	if core.ACME != nil && core.Config.Server.TLS.ACME.ChallengeAddress != "" {
+		challenges := &http.Server{
+			Addr:              core.Config.Server.TLS.ACME.ChallengeAddress,
+			Handler:           core.ACME.HTTPHandler(nil),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		servers = append(servers, ServerInstance{server: challenges, name: "ACME challenge"})
+	}
+
+	// Relay mail submitted to external recipients through the smarthost
+	if core.Config.Server.SMTP.Outbound.Enabled {
+		servers = append(servers, ServerInstance{server: outbound.NewRelay(core), name: "SMTP outbound relay"})
 	}
 
+	// Create error channel for servers
+	errChan := make(chan serverError, len(servers))
+
 	// Start all servers
 	for _, s := range servers {
 		go func(s ServerInstance) {
@@ -238,6 +277,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Let mail clients authenticate with their OIDC access tokens
+	if cfg.OIDC.Enabled {
+		verifier, err := auth.NewBearerVerifier(context.Background(), cfg.OIDC)
+		if err != nil {
+			core.Logger.Error("OIDC bearer tokens will not be accepted by mail clients: %v", err)
+		} else {
+			core.BearerVerifier = verifier
+		}
+	}
+
 	// Log version information at startup
 	core.Logger.Info("Starting inbox451 version %s (commit: %s, built: %s)", version, commit, date)
 
@@ -48,11 +48,12 @@ func (s *Server) login(c echo.Context) error {
 	s.core.Logger.Info("User %s (ID: %s) logged in successfully via password.", user.Username, user.ID)
 	// Return only non-sensitive user info
 	userInfo := map[string]interface{}{
-		"id":       user.ID,
-		"username": user.Username,
-		"name":     user.Name,
-		"email":    user.Email,
-		"role":     user.Role,
+		"id":               user.ID,
+		"username":         user.Username,
+		"name":             user.Name,
+		"email":            user.Email,
+		"role":             user.Role,
+		"default_inbox_id": user.DefaultInboxID,
 	}
 	return c.JSON(http.StatusOK, userInfo)
 }
@@ -77,11 +78,12 @@ func (s *Server) profile(c echo.Context) error {
 
 	// Return non-sensitive user info
 	userInfo := map[string]interface{}{
-		"id":       user.ID,
-		"username": user.Username,
-		"name":     user.Name,
-		"email":    user.Email,
-		"role":     user.Role,
+		"id":               user.ID,
+		"username":         user.Username,
+		"name":             user.Name,
+		"email":            user.Email,
+		"role":             user.Role,
+		"default_inbox_id": user.DefaultInboxID,
 	}
 	return c.JSON(http.StatusOK, userInfo)
 }
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+
+	"inbox451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) createDKIMKey(c echo.Context) error {
+	var req models.DKIMKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	key, err := s.core.DKIMService.Create(c.Request().Context(), &req)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+func (s *Server) getDKIMKeys(c echo.Context) error {
+	var query models.PaginationQuery
+	if err := c.Bind(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if err := c.Validate(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	response, err := s.core.DKIMService.List(c.Request().Context(), query.Limit, query.Offset)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) getDKIMKey(c echo.Context) error {
+	key, err := s.core.DKIMService.Get(c.Request().Context(), c.Param("keyId"))
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, key)
+}
+
+func (s *Server) deleteDKIMKey(c echo.Context) error {
+	if err := s.core.DKIMService.Delete(c.Request().Context(), c.Param("keyId")); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+
+	"inbox451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) getDMARCReports(c echo.Context) error {
+	var query models.DMARCQuery
+	if err := c.Bind(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if err := c.Validate(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	response, err := s.core.DMARCService.List(c.Request().Context(), c.Param("projectId"), &query)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) getDMARCSummary(c echo.Context) error {
+	var query models.DMARCQuery
+	if err := c.Bind(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if err := c.Validate(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	response, err := s.core.DMARCService.Summary(c.Request().Context(), c.Param("projectId"), &query)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) getDMARCReport(c echo.Context) error {
+	report, err := s.core.DMARCService.Get(c.Request().Context(), c.Param("projectId"), c.Param("reportId"))
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+func (s *Server) deleteDMARCReport(c echo.Context) error {
+	if err := s.core.DMARCService.Delete(c.Request().Context(), c.Param("projectId"), c.Param("reportId")); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
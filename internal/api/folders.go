@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+
+	"inbox451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) createFolder(c echo.Context) error {
+	inboxID := c.Param("inboxId")
+	var folder models.Folder
+	if err := c.Bind(&folder); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+	folder.InboxID = inboxID
+
+	if err := c.Validate(&folder); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	created, err := s.core.FolderService.CreateWithParents(c.Request().Context(), inboxID, folder.Name)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, created)
+}
+
+func (s *Server) getFolders(c echo.Context) error {
+	inboxID := c.Param("inboxId")
+
+	var query models.PaginationQuery
+	if err := c.Bind(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if err := c.Validate(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	response, err := s.core.FolderService.ListByInbox(c.Request().Context(), inboxID, query.Limit, query.Offset)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) getFolder(c echo.Context) error {
+	folderID := c.Param("folderId")
+	folder, err := s.core.FolderService.Get(c.Request().Context(), folderID)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	if folder == nil || folder.InboxID != c.Param("inboxId") {
+		return s.core.HandleError(nil, http.StatusNotFound)
+	}
+	return c.JSON(http.StatusOK, folder)
+}
+
+func (s *Server) updateFolder(c echo.Context) error {
+	folderID := c.Param("folderId")
+	inboxID := c.Param("inboxId")
+
+	var folder models.Folder
+	if err := c.Bind(&folder); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	folder.ID = folderID
+	folder.InboxID = inboxID
+
+	if err := c.Validate(&folder); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if err := s.core.FolderService.Update(c.Request().Context(), &folder); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) deleteFolder(c echo.Context) error {
+	folderID := c.Param("folderId")
+	if err := s.core.FolderService.Delete(c.Request().Context(), folderID); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -3,6 +3,8 @@ package api
 import (
 	"net/http"
 
+	"inbox451/internal/certs"
+
 	"github.com/labstack/echo/v4"
 )
 
@@ -10,12 +12,18 @@ type HealthResponse struct {
 	Status    string `json:"status"`
 	Version   string `json:"version"`
 	CommitSHA string `json:"commitSha"`
+	// Certificates lists the TLS certificates in use and when they expire
+	Certificates []certs.Info `json:"certificates,omitempty"`
 }
 
 func (s *Server) healthCheck(c echo.Context) error {
-	return c.JSON(http.StatusOK, HealthResponse{
+	response := HealthResponse{
 		Status:    "ok",
 		Version:   s.core.Version,
 		CommitSHA: s.core.Commit,
-	})
+	}
+	if s.core.Certificates != nil {
+		response.Certificates = s.core.Certificates.Certificates()
+	}
+	return c.JSON(http.StatusOK, response)
 }
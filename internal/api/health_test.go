@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"inbox451/internal/config"
@@ -79,7 +80,7 @@ func TestHealthCheck(t *testing.T) {
 				t.Errorf("failed to unmarshal response: %v", err)
 			}
 
-			if got != tt.expectedBody {
+			if !reflect.DeepEqual(got, tt.expectedBody) {
 				t.Errorf("healthCheck() body = %v, want %v", got, tt.expectedBody)
 			}
 		})
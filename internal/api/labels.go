@@ -0,0 +1,167 @@
+package api
+
+import (
+	"net/http"
+
+	"inbox451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) createLabel(c echo.Context) error {
+	projectID := c.Param("projectId")
+	var label models.Label
+	if err := c.Bind(&label); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+	label.ProjectID = projectID
+
+	if err := c.Validate(&label); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if err := s.core.LabelService.Create(c.Request().Context(), &label); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, label)
+}
+
+func (s *Server) getLabels(c echo.Context) error {
+	projectID := c.Param("projectId")
+
+	var query models.PaginationQuery
+	if err := c.Bind(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if err := c.Validate(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	response, err := s.core.LabelService.ListByProject(c.Request().Context(), projectID, query.Limit, query.Offset)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) getLabel(c echo.Context) error {
+	labelID := c.Param("labelId")
+	label, err := s.core.LabelService.Get(c.Request().Context(), labelID)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	if label == nil || label.ProjectID != c.Param("projectId") {
+		return s.core.HandleError(nil, http.StatusNotFound)
+	}
+	return c.JSON(http.StatusOK, label)
+}
+
+func (s *Server) updateLabel(c echo.Context) error {
+	labelID := c.Param("labelId")
+	projectID := c.Param("projectId")
+
+	var label models.Label
+	if err := c.Bind(&label); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	label.ID = labelID
+	label.ProjectID = projectID
+
+	if err := c.Validate(&label); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if err := s.checkLabelProject(c); err != nil {
+		return err
+	}
+
+	if err := s.core.LabelService.Update(c.Request().Context(), &label); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) deleteLabel(c echo.Context) error {
+	labelID := c.Param("labelId")
+	if err := s.checkLabelProject(c); err != nil {
+		return err
+	}
+	if err := s.core.LabelService.Delete(c.Request().Context(), labelID); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) addMessageLabel(c echo.Context) error {
+	projectID := c.Param("projectId")
+	messageID := c.Param("messageId")
+	labelID := c.Param("labelId")
+
+	if err := s.checkMessageInbox(c); err != nil {
+		return err
+	}
+
+	if err := s.core.LabelService.AddToMessage(c.Request().Context(), projectID, messageID, labelID); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) removeMessageLabel(c echo.Context) error {
+	projectID := c.Param("projectId")
+	messageID := c.Param("messageId")
+	labelID := c.Param("labelId")
+
+	if err := s.checkMessageInbox(c); err != nil {
+		return err
+	}
+
+	if err := s.core.LabelService.RemoveFromMessage(c.Request().Context(), projectID, messageID, labelID); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// checkLabelProject answers 404 unless the label of the route belongs to the
+// route's project
+func (s *Server) checkLabelProject(c echo.Context) error {
+	label, err := s.core.LabelService.Get(c.Request().Context(), c.Param("labelId"))
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	if label == nil || label.ProjectID != c.Param("projectId") {
+		return s.core.HandleError(nil, http.StatusNotFound)
+	}
+	return nil
+}
+
+// checkMessageInbox answers 404 unless the message of the route belongs to the
+// route's inbox, and that inbox to the route's project
+func (s *Server) checkMessageInbox(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	message, err := s.core.MessageService.Get(ctx, c.Param("messageId"))
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	if message == nil || message.InboxID != c.Param("inboxId") {
+		return s.core.HandleError(nil, http.StatusNotFound)
+	}
+
+	inbox, err := s.core.InboxService.Get(ctx, message.InboxID)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	if inbox == nil || inbox.ProjectID != c.Param("projectId") {
+		return s.core.HandleError(nil, http.StatusNotFound)
+	}
+	return nil
+}
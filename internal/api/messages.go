@@ -26,7 +26,9 @@ func (s *Server) getMessages(c echo.Context) error {
 	}
 
 	filters := models.MessageFilters{
-		IsRead: query.IsRead,
+		FolderID: query.FolderID,
+		IsRead:   query.IsRead,
+		LabelID:  query.LabelID,
 		// IsDeleted is not exposed via API, defaulting to showing non-deleted messages
 		IsDeleted: nil,
 	}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+
+	"inbox451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// mtaSTSPolicy serves the MTA-STS policy of the domain whose mta-sts host the
+// request was made to
+func (s *Server) mtaSTSPolicy(c echo.Context) error {
+	policy, err := s.core.MTASTSService.PolicyForHost(c.Request().Host)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusNotFound)
+	}
+	return c.String(http.StatusOK, policy.Policy)
+}
+
+func (s *Server) getMTASTSPolicies(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.core.MTASTSService.List())
+}
+
+func (s *Server) getTLSReports(c echo.Context) error {
+	var query models.TLSReportQuery
+	if err := c.Bind(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if err := c.Validate(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	response, err := s.core.TLSReportService.List(c.Request().Context(), query.Domain, query.Limit, query.Offset)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) getTLSReport(c echo.Context) error {
+	report, err := s.core.TLSReportService.Get(c.Request().Context(), c.Param("reportId"))
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+func (s *Server) deleteTLSReport(c echo.Context) error {
+	if err := s.core.TLSReportService.Delete(c.Request().Context(), c.Param("reportId")); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -17,6 +17,23 @@ func (s *Server) routes(api *echo.Group) {
 		authGroup.GET("/oidc/callback", s.oidcCallback)
 	}
 
+	// ACME HTTP-01 challenges
+	if s.core.ACME != nil {
+		s.echo.GET("/.well-known/acme-challenge/*", echo.WrapHandler(s.core.ACME.HTTPHandler(nil)))
+	}
+
+	// MTA-STS policy, served from mta-sts.<domain>
+	s.echo.GET("/.well-known/mta-sts.txt", s.mtaSTSPolicy)
+
+	// JMAP routes
+	s.echo.GET("/.well-known/jmap", s.jmap.WellKnown)
+	jmapGroup := s.echo.Group("/jmap", s.auth.Middleware)
+	jmapGroup.GET("/session", s.jmap.Session)
+	jmapGroup.POST("/api", s.jmap.API)
+	jmapGroup.GET("/download/:accountId/:blobId/:name", s.jmap.Download)
+	jmapGroup.POST("/upload/:accountId/", s.jmap.Upload)
+	jmapGroup.GET("/eventsource", s.jmap.EventSource)
+
 	// Protect API routes
 	// Apply the authentication middleware to all routes in the API group
 	api.Use(s.auth.Middleware)
@@ -28,6 +45,7 @@ func (s *Server) routes(api *echo.Group) {
 	api.GET("/users/:userId/projects", s.getProjectsByUser)
 	api.POST("/users", s.createUser)
 	api.PUT("/users/:userId", s.updateUser)
+	api.PUT("/users/:userId/default-inbox", s.setDefaultInbox)
 	api.DELETE("/users/:userId", s.deleteUser)
 
 	// ProjectUser routes
@@ -60,6 +78,22 @@ func (s *Server) routes(api *echo.Group) {
 	api.POST("/projects/:projectId/inboxes/:inboxId/rules", s.createRule)
 	api.PUT("/projects/:projectId/inboxes/:inboxId/rules/:ruleId", s.updateRule)
 	api.DELETE("/projects/:projectId/inboxes/:inboxId/rules/:ruleId", s.deleteRule)
+	api.POST("/projects/:projectId/inboxes/:inboxId/rules/:ruleId/test", s.testRule)
+	api.POST("/projects/:projectId/inboxes/:inboxId/rules/simulate", s.simulateRules)
+
+	// Folder routes
+	api.GET("/projects/:projectId/inboxes/:inboxId/folders", s.getFolders)
+	api.GET("/projects/:projectId/inboxes/:inboxId/folders/:folderId", s.getFolder)
+	api.POST("/projects/:projectId/inboxes/:inboxId/folders", s.createFolder)
+	api.PUT("/projects/:projectId/inboxes/:inboxId/folders/:folderId", s.updateFolder)
+	api.DELETE("/projects/:projectId/inboxes/:inboxId/folders/:folderId", s.deleteFolder)
+
+	// Label routes
+	api.GET("/projects/:projectId/labels", s.getLabels)
+	api.GET("/projects/:projectId/labels/:labelId", s.getLabel)
+	api.POST("/projects/:projectId/labels", s.createLabel)
+	api.PUT("/projects/:projectId/labels/:labelId", s.updateLabel)
+	api.DELETE("/projects/:projectId/labels/:labelId", s.deleteLabel)
 
 	// Message routes
 	api.GET("/projects/:projectId/inboxes/:inboxId/messages", s.getMessages)
@@ -67,4 +101,33 @@ func (s *Server) routes(api *echo.Group) {
 	api.PUT("/projects/:projectId/inboxes/:inboxId/messages/:messageId/read", s.markMessageRead)
 	api.PUT("/projects/:projectId/inboxes/:inboxId/messages/:messageId/unread", s.markMessageUnread)
 	api.DELETE("/projects/:projectId/inboxes/:inboxId/messages/:messageId", s.deleteMessage)
+	api.PUT("/projects/:projectId/inboxes/:inboxId/messages/:messageId/labels/:labelId", s.addMessageLabel)
+	api.DELETE("/projects/:projectId/inboxes/:inboxId/messages/:messageId/labels/:labelId", s.removeMessageLabel)
+
+	// Thread routes
+	api.GET("/projects/:projectId/inboxes/:inboxId/threads", s.getThreads)
+	api.GET("/projects/:projectId/inboxes/:inboxId/threads/:threadId", s.getThread)
+
+	// Sender address routes
+	api.GET("/projects/:projectId/senders", s.getSenders)
+	api.POST("/projects/:projectId/senders", s.createSender)
+	api.DELETE("/projects/:projectId/senders/:senderId", s.deleteSender)
+
+	// DMARC report routes
+	api.GET("/projects/:projectId/dmarc-reports", s.getDMARCReports)
+	api.GET("/projects/:projectId/dmarc-reports/summary", s.getDMARCSummary)
+	api.GET("/projects/:projectId/dmarc-reports/:reportId", s.getDMARCReport)
+	api.DELETE("/projects/:projectId/dmarc-reports/:reportId", s.deleteDMARCReport)
+
+	// DKIM key routes
+	api.GET("/dkim-keys", s.getDKIMKeys)
+	api.GET("/dkim-keys/:keyId", s.getDKIMKey)
+	api.POST("/dkim-keys", s.createDKIMKey)
+	api.DELETE("/dkim-keys/:keyId", s.deleteDKIMKey)
+
+	// MTA-STS and TLS report routes
+	api.GET("/mta-sts", s.getMTASTSPolicies)
+	api.GET("/tls-reports", s.getTLSReports)
+	api.GET("/tls-reports/:reportId", s.getTLSReport)
+	api.DELETE("/tls-reports/:reportId", s.deleteTLSReport)
 }
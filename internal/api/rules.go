@@ -1,8 +1,12 @@
 package api
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 
+	"inbox451/internal/core"
 	"inbox451/internal/models"
 
 	"github.com/labstack/echo/v4"
@@ -93,3 +97,69 @@ func (s *Server) deleteRule(c echo.Context) error {
 	}
 	return c.NoContent(http.StatusNoContent)
 }
+
+func (s *Server) testRule(c echo.Context) error {
+	ctx := c.Request().Context()
+	ruleID := c.Param("ruleId")
+
+	var req models.RuleTestRequest
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), "message/rfc822") {
+		raw, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return s.core.HandleError(err, http.StatusBadRequest)
+		}
+		req.Raw = string(raw)
+	} else if err := c.Bind(&req); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	var message *models.Message
+	switch {
+	case req.Raw != "":
+		parsed, err := core.ParseRawMessage([]byte(req.Raw))
+		if err != nil {
+			return s.core.HandleError(err, http.StatusBadRequest)
+		}
+		message = parsed
+	case req.MessageID != "":
+		stored, err := s.core.MessageService.Get(ctx, req.MessageID)
+		if err != nil {
+			return s.core.HandleError(err, http.StatusInternalServerError)
+		}
+		if stored == nil || stored.InboxID != c.Param("inboxId") {
+			return s.core.HandleError(nil, http.StatusNotFound)
+		}
+		message = stored
+	default:
+		return s.core.HandleError(errors.New("either raw or message_id is required"), http.StatusBadRequest)
+	}
+
+	match, err := s.core.RuleService.Test(ctx, ruleID, message)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, match)
+}
+
+func (s *Server) simulateRules(c echo.Context) error {
+	inboxID := c.Param("inboxId")
+
+	var req models.RuleSimulateRequest
+	if err := c.Bind(&req); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	results, err := s.core.RuleService.Simulate(c.Request().Context(), inboxID, req.Limit)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+
+	"inbox451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) createSender(c echo.Context) error {
+	projectID := c.Param("projectId")
+	var sender models.SenderAddress
+	if err := c.Bind(&sender); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+	sender.ProjectID = projectID
+
+	if err := c.Validate(&sender); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if err := s.core.SenderService.Create(c.Request().Context(), &sender); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, sender)
+}
+
+func (s *Server) getSenders(c echo.Context) error {
+	projectID := c.Param("projectId")
+
+	var query models.PaginationQuery
+	if err := c.Bind(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if err := c.Validate(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	response, err := s.core.SenderService.ListByProject(c.Request().Context(), projectID, query.Limit, query.Offset)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) deleteSender(c echo.Context) error {
+	senderID := c.Param("senderId")
+	sender, err := s.core.SenderService.Get(c.Request().Context(), senderID)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	if sender.ProjectID != c.Param("projectId") {
+		return s.core.HandleError(nil, http.StatusNotFound)
+	}
+
+	if err := s.core.SenderService.Delete(c.Request().Context(), senderID); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
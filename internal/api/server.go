@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"mime"
 	"net/http"
 	"path/filepath"
@@ -14,6 +15,7 @@ import (
 
 	"inbox451/internal/assets"
 	"inbox451/internal/core"
+	"inbox451/internal/jmap"
 	"inbox451/internal/middleware"
 
 	"github.com/go-playground/validator/v10"
@@ -33,6 +35,7 @@ type Server struct {
 	core *core.Core
 	echo *echo.Echo
 	auth *auth.Auth
+	jmap *jmap.Handler
 }
 
 func NewServer(ctx context.Context, core *core.Core, db *sql.DB) *Server {
@@ -41,6 +44,7 @@ func NewServer(ctx context.Context, core *core.Core, db *sql.DB) *Server {
 	s := &Server{
 		core: core,
 		echo: e,
+		jmap: jmap.NewHandler(core),
 	}
 
 	// Add timeout middleware with a 30-second timeout
@@ -145,6 +149,15 @@ func (s *Server) assetHandler(c echo.Context) error {
 }
 
 func (s *Server) ListenAndServe() error {
+	if s.core.Config.Server.HTTP.EnableTLS {
+		config, err := s.core.TLSConfig()
+		if err != nil {
+			return fmt.Errorf("HTTP: Failed to load TLS configuration: %w", err)
+		}
+		s.echo.TLSServer.Addr = s.core.Config.Server.HTTP.Port
+		s.echo.TLSServer.TLSConfig = config
+		return s.echo.StartServer(s.echo.TLSServer)
+	}
 	return s.echo.Start(s.core.Config.Server.HTTP.Port)
 }
 
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"inbox451/internal/core"
+	"inbox451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) getThreads(c echo.Context) error {
+	inboxID := c.Param("inboxId")
+
+	var query models.PaginationQuery
+	if err := c.Bind(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = 10
+	}
+
+	if err := c.Validate(&query); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	response, err := s.core.ThreadService.ListByInbox(c.Request().Context(), inboxID, query.Limit, query.Offset)
+	if err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// getThread returns the messages of a thread, oldest first
+func (s *Server) getThread(c echo.Context) error {
+	inboxID := c.Param("inboxId")
+	threadID := c.Param("threadId")
+
+	messages, err := s.core.ThreadService.Messages(c.Request().Context(), inboxID, threadID)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return s.core.HandleError(err, http.StatusNotFound)
+		}
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, messages)
+}
@@ -81,6 +81,25 @@ func (s *Server) updateUser(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// PUT /users/:userId/default-inbox
+func (s *Server) setDefaultInbox(c echo.Context) error {
+	userID := c.Param("userId")
+	var req models.DefaultInboxRequest
+	if err := c.Bind(&req); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return s.core.HandleError(err, http.StatusBadRequest)
+	}
+
+	if err := s.core.UserService.SetDefaultInbox(c.Request().Context(), userID, req.InboxID); err != nil {
+		return s.core.HandleError(err, http.StatusInternalServerError)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (s *Server) deleteUser(c echo.Context) error {
 	userID := c.Param("userId")
 	if err := s.core.UserService.Delete(c.Request().Context(), userID); err != nil {
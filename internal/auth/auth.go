@@ -319,6 +319,12 @@ func (a *Auth) ExchangeOIDCToken(code, nonce string) (string, OIDCClaim, error)
 // Helper for API token authentication
 func (a *Auth) authenticateAPIToken(c echo.Context) (*models.User, error) {
 	authHeader := strings.TrimSpace(c.Request().Header.Get("x-api-key"))
+	if authHeader == "" {
+		// Clients of standard protocols such as JMAP send the token as a bearer token
+		if token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer "); ok {
+			authHeader = strings.TrimSpace(token)
+		}
+	}
 	if authHeader == "" {
 		return nil, nil // Not an API token request
 	}
@@ -0,0 +1,111 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"inbox451/internal/config"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACME obtains and renews certificates for a fixed set of host names over the
+// HTTP-01 challenge. Account keys, certificates and pending challenge tokens
+// live in the cache, so any replica can answer a challenge another one started.
+type ACME struct {
+	manager *autocert.Manager
+	hosts   []string
+	allowed map[string]bool
+}
+
+// NewACME prepares certificates for the given host names. Empty names,
+// duplicates and localhost are skipped, as no public CA issues for them.
+func NewACME(cfg config.ACMEConfig, cache autocert.Cache, hosts []string) (*ACME, error) {
+	a := &ACME{allowed: make(map[string]bool)}
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+		if host == "" || host == "localhost" || a.allowed[host] {
+			continue
+		}
+		a.allowed[host] = true
+		a.hosts = append(a.hosts, host)
+	}
+	if len(a.hosts) == 0 {
+		return nil, errors.New("no host name to request an ACME certificate for")
+	}
+
+	client := &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	if client.DirectoryURL == "" {
+		client.DirectoryURL = acme.LetsEncryptURL
+	}
+	if cfg.RootCA != "" {
+		pem, err := os.ReadFile(cfg.RootCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ACME root CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in ACME root CA %s", cfg.RootCA)
+		}
+		client.HTTPClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+			},
+		}
+	}
+
+	a.manager = &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      cache,
+		HostPolicy: autocert.HostWhitelist(a.hosts...),
+		Email:      cfg.Email,
+		Client:     client,
+	}
+	return a, nil
+}
+
+// Hosts lists the names certificates are requested for
+func (a *ACME) Hosts() []string {
+	return a.hosts
+}
+
+// HTTPHandler answers HTTP-01 challenges under /.well-known/acme-challenge/
+// and passes every other request to fallback. A nil fallback redirects to
+// HTTPS.
+func (a *ACME) HTTPHandler(fallback http.Handler) http.Handler {
+	return a.manager.HTTPHandler(fallback)
+}
+
+// covers reports whether the certificate for name comes from ACME
+func (a *ACME) covers(name string) bool {
+	return a.allowed[name]
+}
+
+// getCertificate obtains, renews or loads from the cache the certificate for
+// name
+func (a *ACME) getCertificate(hello *tls.ClientHelloInfo, name string) (*tls.Certificate, error) {
+	// autocert picks the certificate by SNI, so clients that send none get
+	// the first host's
+	if hello.ServerName != name {
+		clone := *hello
+		clone.ServerName = name
+		hello = &clone
+	}
+	return a.manager.GetCertificate(hello)
+}
+
+// helloFor stands in for a modern client asking for name, so that certificates
+// requested ahead of a handshake use ECDSA keys like the ones clients get
+func helloFor(name string) *tls.ClientHelloInfo {
+	return &tls.ClientHelloInfo{
+		ServerName:   name,
+		CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+	}
+}
@@ -0,0 +1,173 @@
+package certs
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"inbox451/internal/config"
+	"inbox451/internal/logger"
+	"inbox451/internal/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// memoryCache is an autocert.Cache for tests
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: make(map[string][]byte)}
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.entries[key]
+	if !ok {
+		return nil, autocert.ErrCacheMiss
+	}
+	return data, nil
+}
+
+func (c *memoryCache) Put(ctx context.Context, key string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = data
+	return nil
+}
+
+func (c *memoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return nil
+}
+
+// cacheCertificate stores an ECDSA certificate the way autocert does, so that
+// it is served without asking the directory
+func cacheCertificate(t *testing.T, cache *memoryCache, name string) {
+	dir := t.TempDir()
+	certFile, keyFile := test.WriteCertificate(t, dir, time.Now().Add(90*24*time.Hour), name)
+	key, err := os.ReadFile(keyFile)
+	require.NoError(t, err)
+	cert, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+	cache.entries[name] = append(key, cert...)
+}
+
+// acmeNames is servedNames for a client that accepts ECDSA certificates
+func acmeNames(t *testing.T, m *Manager, serverName string) []string {
+	cert, err := m.GetCertificate(helloFor(serverName))
+	require.NoError(t, err)
+	return cert.Leaf.DNSNames
+}
+
+func TestNewACME(t *testing.T) {
+	a, err := NewACME(config.ACMEConfig{}, newMemoryCache(), []string{
+		"SMTP.example.com.", "", "localhost", "smtp.example.com", "inbox.example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"smtp.example.com", "inbox.example.com"}, a.Hosts())
+
+	_, err = NewACME(config.ACMEConfig{}, newMemoryCache(), []string{"localhost", ""})
+	assert.Error(t, err)
+
+	_, err = NewACME(config.ACMEConfig{RootCA: "/does/not/exist.pem"}, newMemoryCache(), []string{"smtp.example.com"})
+	assert.Error(t, err)
+}
+
+func TestManager_ACME(t *testing.T) {
+	cache := newMemoryCache()
+	cacheCertificate(t, cache, "mail.example.com")
+	acme, err := NewACME(config.ACMEConfig{}, cache, []string{"mail.example.com"})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := test.WriteCertificate(t, dir, time.Now().Add(time.Hour), "files.example.com")
+	m, err := NewManager(logger.New(io.Discard, logger.DEBUG),
+		[]config.TLSCertificateConfig{{Cert: certFile, Key: keyFile}}, acme)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"mail.example.com"}, acmeNames(t, m, "mail.example.com"))
+	assert.Equal(t, []string{"files.example.com"}, servedNames(t, m, "files.example.com"))
+	assert.Equal(t, []string{"files.example.com"}, servedNames(t, m, ""))
+
+	infos := m.Certificates()
+	require.Len(t, infos, 2)
+	assert.Equal(t, "acme", infos[1].File)
+	assert.Equal(t, []string{"mail.example.com"}, infos[1].Names)
+}
+
+func TestManager_ACMEOnly(t *testing.T) {
+	cache := newMemoryCache()
+	cacheCertificate(t, cache, "mail.example.com")
+	acme, err := NewACME(config.ACMEConfig{}, cache, []string{"mail.example.com", "inbox.example.com"})
+	require.NoError(t, err)
+
+	m, err := NewManager(logger.New(io.Discard, logger.DEBUG), nil, acme)
+	require.NoError(t, err)
+
+	// Clients without SNI get the first host's certificate
+	assert.Equal(t, []string{"mail.example.com"}, acmeNames(t, m, ""))
+	assert.Equal(t, []string{"mail.example.com"}, acmeNames(t, m, "unknown.example.com"))
+}
+
+// TestACME_Pebble requests a certificate from a Pebble test CA. Start one with
+// `make test-acme`, which runs Pebble and its DNS server from docker-compose.yml
+// and resolves every name to this host, where the challenge is answered.
+func TestACME_Pebble(t *testing.T) {
+	directory := os.Getenv("PEBBLE_DIRECTORY_URL")
+	if directory == "" {
+		t.Skip("PEBBLE_DIRECTORY_URL is not set")
+	}
+	host := os.Getenv("PEBBLE_HOST")
+	if host == "" {
+		host = "mail.inbox451.test"
+	}
+	address := os.Getenv("PEBBLE_HTTP_ADDRESS")
+	if address == "" {
+		address = ":5002"
+	}
+
+	cache := newMemoryCache()
+	acme, err := NewACME(config.ACMEConfig{
+		DirectoryURL: directory,
+		RootCA:       os.Getenv("PEBBLE_ROOT_CA"),
+		Email:        "postmaster@inbox451.test",
+	}, cache, []string{host})
+	require.NoError(t, err)
+
+	server := &http.Server{Addr: address, Handler: acme.HTTPHandler(nil), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("challenge server: %v", err)
+		}
+	}()
+	t.Cleanup(func() { _ = server.Close() })
+
+	m, err := NewManager(logger.New(io.Discard, logger.DEBUG), nil, acme)
+	require.NoError(t, err)
+
+	cert, err := m.GetCertificate(helloFor(host))
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{host}, leaf.DNSNames)
+	assert.Contains(t, cache.entries, host, "the certificate is stored in the cache")
+
+	// Later handshakes are served from memory
+	again, err := m.GetCertificate(helloFor(host))
+	require.NoError(t, err)
+	assert.Equal(t, cert.Certificate, again.Certificate)
+}
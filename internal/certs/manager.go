@@ -0,0 +1,324 @@
+// Package certs loads the TLS certificates shared by the HTTP, SMTP, IMAP and
+// POP3 servers, picks one per connection by SNI and reloads them when the
+// files change or on SIGHUP, so renewed certificates are used without a
+// restart. Certificates can also be obtained and renewed over ACME.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"inbox451/internal/config"
+	"inbox451/internal/logger"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay batches the burst of events a renewal causes into one reload
+const reloadDelay = 500 * time.Millisecond
+
+var ErrNoCertificates = errors.New("no TLS certificate configured")
+
+// Info describes a loaded certificate, for the health endpoint
+type Info struct {
+	File      string    `json:"file"`
+	Names     []string  `json:"names"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+}
+
+type certificate struct {
+	tls  *tls.Certificate
+	info Info
+}
+
+// Manager holds the configured certificates. The first one is served to
+// clients that don't send SNI or ask for a name no certificate covers; without
+// certificate files, that is the certificate of the first ACME host.
+type Manager struct {
+	files  []config.TLSCertificateConfig
+	acme   *ACME
+	logger *logger.Logger
+
+	mu       sync.RWMutex
+	certs    []*certificate
+	byName   map[string]*certificate
+	acmeInfo map[string]Info
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewManager loads every certificate and key pair. It fails if any of them
+// can't be loaded. acme may be nil when certificates are only read from files.
+func NewManager(log *logger.Logger, files []config.TLSCertificateConfig, acme *ACME) (*Manager, error) {
+	if len(files) == 0 && acme == nil {
+		return nil, ErrNoCertificates
+	}
+	m := &Manager{
+		files:    files,
+		acme:     acme,
+		logger:   log,
+		acmeInfo: make(map[string]Info),
+		stop:     make(chan struct{}),
+	}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload loads the certificates again. When one of them fails to load, the
+// ones in use are kept.
+func (m *Manager) Reload() error {
+	certs := make([]*certificate, 0, len(m.files))
+	byName := make(map[string]*certificate)
+
+	for _, files := range m.files {
+		m.logger.Info("Loading TLS certificate from cert=%s and key=%s", files.Cert, files.Key)
+		cert, err := load(files)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+		for _, name := range cert.info.Names {
+			// The first certificate for a name wins
+			if _, ok := byName[name]; !ok {
+				byName[name] = cert
+			}
+		}
+	}
+
+	m.mu.Lock()
+	m.certs = certs
+	m.byName = byName
+	m.mu.Unlock()
+	return nil
+}
+
+func load(files config.TLSCertificateConfig) (*certificate, error) {
+	pair, err := tls.LoadX509KeyPair(files.Cert, files.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate %s: %w", files.Cert, err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate %s: %w", files.Cert, err)
+	}
+	pair.Leaf = leaf
+
+	names := make([]string, 0, len(leaf.DNSNames)+len(leaf.IPAddresses)+1)
+	for _, name := range leaf.DNSNames {
+		names = append(names, strings.ToLower(name))
+	}
+	for _, ip := range leaf.IPAddresses {
+		names = append(names, ip.String())
+	}
+	if len(names) == 0 && leaf.Subject.CommonName != "" {
+		names = append(names, strings.ToLower(leaf.Subject.CommonName))
+	}
+
+	return &certificate{
+		tls: &pair,
+		info: Info{
+			File:      files.Cert,
+			Names:     names,
+			NotBefore: leaf.NotBefore,
+			NotAfter:  leaf.NotAfter,
+		},
+	}, nil
+}
+
+// GetCertificate picks the certificate for the server name a client asked
+// for, matching wildcard certificates one label deep. ACME hosts are served
+// the certificate issued for them. It can be used as tls.Config.GetCertificate.
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if m.acme != nil && m.acme.covers(name) {
+		return m.acmeCertificate(hello, name)
+	}
+
+	if cert := m.fileCertificate(name); cert != nil {
+		return cert, nil
+	}
+	if m.acme != nil {
+		return m.acmeCertificate(hello, m.acme.Hosts()[0])
+	}
+	return nil, ErrNoCertificates
+}
+
+func (m *Manager) fileCertificate(name string) *tls.Certificate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if name != "" {
+		if cert, ok := m.byName[name]; ok {
+			return cert.tls
+		}
+		if _, parent, ok := strings.Cut(name, "."); ok {
+			if cert, ok := m.byName["*."+parent]; ok {
+				return cert.tls
+			}
+		}
+	}
+	if len(m.certs) > 0 {
+		return m.certs[0].tls
+	}
+	return nil
+}
+
+// acmeCertificate returns the certificate of an ACME host, which may have to
+// be obtained first, and remembers it for the health endpoint
+func (m *Manager) acmeCertificate(hello *tls.ClientHelloInfo, name string) (*tls.Certificate, error) {
+	cert, err := m.acme.getCertificate(hello, name)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Leaf != nil {
+		m.mu.Lock()
+		m.acmeInfo[name] = Info{
+			File:      "acme",
+			Names:     cert.Leaf.DNSNames,
+			NotBefore: cert.Leaf.NotBefore,
+			NotAfter:  cert.Leaf.NotAfter,
+		}
+		m.mu.Unlock()
+	}
+	return cert, nil
+}
+
+// TLSConfig returns a configuration that always serves the current
+// certificates. Pin minimum version to TLS 1.3 to avoid weak protocols.
+func (m *Manager) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: m.GetCertificate,
+		MinVersion:     tls.VersionTLS13,
+	}
+}
+
+// Certificates describes the certificates in use
+func (m *Manager) Certificates() []Info {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	infos := make([]Info, 0, len(m.certs)+len(m.acmeInfo))
+	for _, cert := range m.certs {
+		infos = append(infos, cert.info)
+	}
+	if m.acme != nil {
+		for _, host := range m.acme.Hosts() {
+			if info, ok := m.acmeInfo[host]; ok {
+				infos = append(infos, info)
+			}
+		}
+	}
+	return infos
+}
+
+// ListenAndServe reloads the certificates whenever their files change or the
+// process receives SIGHUP, until Shutdown is called. The directories are
+// watched rather than the files, so that files replaced by a rename or a
+// symlink swap, as certbot and Kubernetes do, are noticed. ACME certificates
+// are requested up front rather than on the first handshake.
+func (m *Manager) ListenAndServe() error {
+	if m.acme != nil {
+		go m.obtain()
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch TLS certificates: %w", err)
+	}
+	defer watcher.Close()
+
+	dirs := make(map[string]bool)
+	for _, files := range m.files {
+		for _, file := range []string{files.Cert, files.Key} {
+			dir := filepath.Dir(file)
+			if dirs[dir] {
+				continue
+			}
+			if err := watcher.Add(dir); err != nil {
+				return fmt.Errorf("failed to watch %s: %w", dir, err)
+			}
+			dirs[dir] = true
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	timer := time.NewTimer(0)
+	<-timer.C
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return nil
+		case <-hup:
+			m.logger.Info("Received SIGHUP, reloading TLS certificates")
+			m.reload()
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			m.logger.Debug("TLS certificate directory changed: %s", event)
+			timer.Reset(reloadDelay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			m.logger.Error("Error watching TLS certificates: %v", err)
+		case <-timer.C:
+			m.reload()
+		}
+	}
+}
+
+func (m *Manager) reload() {
+	if err := m.Reload(); err != nil {
+		m.logger.Error("Failed to reload TLS certificates, keeping the current ones: %v", err)
+		return
+	}
+	for _, info := range m.Certificates() {
+		m.logger.Info("TLS certificate %s for %s expires on %s", info.File, strings.Join(info.Names, ", "), info.NotAfter.Format(time.RFC3339))
+	}
+}
+
+// obtain loads or requests the certificate of every ACME host. autocert
+// renews them on later handshakes once they get close to expiring.
+func (m *Manager) obtain() {
+	for _, host := range m.acme.Hosts() {
+		select {
+		case <-m.stop:
+			return
+		default:
+		}
+		cert, err := m.acmeCertificate(helloFor(host), host)
+		if err != nil {
+			m.logger.Error("Failed to obtain an ACME certificate for %s: %v", host, err)
+			continue
+		}
+		if cert.Leaf != nil {
+			m.logger.Info("ACME certificate for %s expires on %s", host, cert.Leaf.NotAfter.Format(time.RFC3339))
+		}
+	}
+}
+
+// Shutdown stops watching the certificates
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.once.Do(func() { close(m.stop) })
+	return nil
+}
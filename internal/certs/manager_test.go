@@ -0,0 +1,100 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"inbox451/internal/config"
+	"inbox451/internal/logger"
+	"inbox451/internal/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T, files ...config.TLSCertificateConfig) *Manager {
+	m, err := NewManager(logger.New(io.Discard, logger.DEBUG), files, nil)
+	require.NoError(t, err)
+	return m
+}
+
+func servedNames(t *testing.T, m *Manager, serverName string) []string {
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+	require.NoError(t, err)
+	return cert.Leaf.DNSNames
+}
+
+func TestManager_GetCertificate(t *testing.T) {
+	dir := t.TempDir()
+	expiry := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	mailCert, mailKey := test.WriteCertificate(t, dir, expiry, "mail.example.com")
+	wildcardCert, wildcardKey := test.WriteCertificate(t, dir, expiry, "*.example.org", "example.org")
+
+	m := newTestManager(t,
+		config.TLSCertificateConfig{Cert: mailCert, Key: mailKey},
+		config.TLSCertificateConfig{Cert: wildcardCert, Key: wildcardKey},
+	)
+
+	assert.Equal(t, []string{"mail.example.com"}, servedNames(t, m, "MAIL.example.com."))
+	assert.Equal(t, []string{"*.example.org", "example.org"}, servedNames(t, m, "imap.example.org"))
+	assert.Equal(t, []string{"*.example.org", "example.org"}, servedNames(t, m, "example.org"))
+	assert.Equal(t, []string{"mail.example.com"}, servedNames(t, m, "a.b.example.org"), "wildcards match one label")
+	assert.Equal(t, []string{"mail.example.com"}, servedNames(t, m, ""), "the first certificate is the default")
+
+	infos := m.Certificates()
+	require.Len(t, infos, 2)
+	assert.Equal(t, mailCert, infos[0].File)
+	assert.Equal(t, []string{"mail.example.com"}, infos[0].Names)
+	assert.True(t, expiry.Equal(infos[0].NotAfter))
+}
+
+func TestNewManager(t *testing.T) {
+	_, err := NewManager(logger.New(io.Discard, logger.DEBUG), nil, nil)
+	assert.ErrorIs(t, err, ErrNoCertificates)
+
+	_, err = NewManager(logger.New(io.Discard, logger.DEBUG), []config.TLSCertificateConfig{
+		{Cert: "/does/not/exist.crt", Key: "/does/not/exist.key"},
+	}, nil)
+	assert.Error(t, err)
+}
+
+func TestManager_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := test.WriteCertificate(t, dir, time.Now().Add(time.Hour), "mail.example.com")
+	m := newTestManager(t, config.TLSCertificateConfig{Cert: certFile, Key: keyFile})
+	firstExpiry := m.Certificates()[0].NotAfter
+
+	// A broken file keeps the current certificate in use
+	require.NoError(t, os.WriteFile(certFile, []byte("garbage"), 0o600))
+	assert.Error(t, m.Reload())
+	assert.Equal(t, firstExpiry, m.Certificates()[0].NotAfter)
+
+	renewedExpiry := time.Now().Add(90 * 24 * time.Hour).Truncate(time.Second)
+	test.WriteCertificate(t, dir, renewedExpiry, "mail.example.com")
+	require.NoError(t, m.Reload())
+	assert.True(t, renewedExpiry.Equal(m.Certificates()[0].NotAfter))
+}
+
+func TestManager_Watch(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := test.WriteCertificate(t, dir, time.Now().Add(time.Hour), "mail.example.com")
+	m := newTestManager(t, config.TLSCertificateConfig{Cert: certFile, Key: keyFile})
+
+	done := make(chan error, 1)
+	go func() { done <- m.ListenAndServe() }()
+	// Give the watcher time to start
+	time.Sleep(100 * time.Millisecond)
+
+	renewedExpiry := time.Now().Add(90 * 24 * time.Hour).Truncate(time.Second)
+	test.WriteCertificate(t, dir, renewedExpiry, "mail.example.com")
+	assert.Eventually(t, func() bool {
+		return renewedExpiry.Equal(m.Certificates()[0].NotAfter)
+	}, 5*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, m.Shutdown(context.Background()))
+	assert.NoError(t, <-done)
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"net"
 	"strings"
 	"time"
 
@@ -33,18 +34,154 @@ type OIDCConfig struct {
 type TLSFilesConfig struct {
 	Cert string `koanf:"cert_file"` // Path to the TLS certificate file
 	Key  string `koanf:"key_file"`  // Path to the TLS key file
+	// Certificates are the certificates of other domains, picked by SNI
+	Certificates []TLSCertificateConfig `koanf:"certificates"`
+	ACME         ACMEConfig             `koanf:"acme"`
 }
+
+// ACMEConfig obtains and renews certificates from an ACME directory such as
+// Let's Encrypt, using the HTTP-01 challenge
+type ACMEConfig struct {
+	Enabled          bool     `koanf:"enabled"`
+	Email            string   `koanf:"email"`             // Contact address of the ACME account
+	DirectoryURL     string   `koanf:"directory_url"`     // Defaults to Let's Encrypt production
+	Hosts            []string `koanf:"hosts"`             // Names besides smtp.domain and imap.hostname, e.g. the web UI
+	ChallengeAddress string   `koanf:"challenge_address"` // Plain HTTP listener for challenges, e.g. ":80"; empty to rely on the HTTP server
+	RootCA           string   `koanf:"root_ca"`           // PEM bundle trusted for the directory, e.g. Pebble's
+}
+
+// TLSCertificateConfig is a certificate and key pair
+type TLSCertificateConfig struct {
+	Cert string `koanf:"cert_file"`
+	Key  string `koanf:"key_file"`
+}
+
+// Files lists every configured certificate, the default one first
+func (c TLSFilesConfig) Files() []TLSCertificateConfig {
+	var files []TLSCertificateConfig
+	if c.Cert != "" || c.Key != "" {
+		files = append(files, TLSCertificateConfig{Cert: c.Cert, Key: c.Key})
+	}
+	return append(files, c.Certificates...)
+}
+
+// TLSListenAddr returns the address an implicit TLS port listens on, or "" when
+// the port is unset. Ports are bare numbers, e.g. "993", a leading colon being
+// tolerated.
+func TLSListenAddr(host, port string) string {
+	if port == "" {
+		return ""
+	}
+	return net.JoinHostPort(host, strings.TrimPrefix(port, ":"))
+}
+
 type SMTPAgentConfig struct {
-	EnableTLS bool   `koanf:"tls"`
-	Port      string `koanf:"port"`
+	EnableTLS      bool   `koanf:"tls"`
+	Port           string `koanf:"port"`
+	TLSPort        string `koanf:"tls_port"`         // Implicit TLS (SMTPS) port, e.g. "465"; MSA only
+	RequireTLSAuth bool   `koanf:"require_tls_auth"` // Refuse AUTH before TLS, even with allow_insecure_auth; MSA only
+}
+
+// MTAConfig is the MTA listener and the limits protecting it from abusive
+// clients
+type MTAConfig struct {
+	EnableTLS       bool           `koanf:"tls"`
+	Port            string         `koanf:"port"`
+	ReadTimeout     time.Duration  `koanf:"read_timeout"`
+	WriteTimeout    time.Duration  `koanf:"write_timeout"`
+	MaxMessageBytes int64          `koanf:"max_message_bytes"`
+	MaxRecipients   int            `koanf:"max_recipients"`
+	MaxSessions     int            `koanf:"max_sessions"` // Concurrent sessions, 0 for no limit
+	RateLimit       MTARateLimit   `koanf:"rate_limit"`
+	Greylist        GreylistConfig `koanf:"greylist"`
+	DNSBL           DNSBLConfig    `koanf:"dnsbl"`
+	Allow           []string       `koanf:"allow"` // IPs and CIDRs exempt from rate limits, greylisting and DNSBL lookups
+	Deny            []string       `koanf:"deny"`  // IPs and CIDRs refused at connection, unless also allowed
+}
+
+// MTARateLimit caps what a single client IP (an IPv6 /64) may do per interval
+type MTARateLimit struct {
+	Connections int           `koanf:"connections"` // 0 for no limit
+	Messages    int           `koanf:"messages"`    // 0 for no limit
+	Interval    time.Duration `koanf:"interval"`
+}
+
+// GreylistConfig makes unknown senders retry before their mail is accepted
+type GreylistConfig struct {
+	Enabled bool          `koanf:"enabled"`
+	Delay   time.Duration `koanf:"delay"`   // How long a new client network, sender and recipient triplet is deferred
+	MaxAge  time.Duration `koanf:"max_age"` // How long an unseen triplet is remembered
+}
+
+// DNSBLConfig looks clients up on DNS blocklists when their session starts
+type DNSBLConfig struct {
+	Zones    []string      `koanf:"zones"`     // e.g. ["zen.spamhaus.org"]; empty to skip the lookups
+	Mode     string        `koanf:"mode"`      // "reject" listed clients, or "tag" their messages
+	CacheTTL time.Duration `koanf:"cache_ttl"` // How long the result for an address is reused
+	Timeout  time.Duration `koanf:"timeout"`   // How long lookups may take before the client is let in
+}
+
+// OutboundConfig is the smarthost that mail submitted to external recipients is
+// relayed through
+type OutboundConfig struct {
+	Enabled       bool          `koanf:"enabled"`
+	Host          string        `koanf:"host"`
+	Port          string        `koanf:"port"`
+	Username      string        `koanf:"username"`
+	Password      string        `koanf:"password"`
+	TLS           string        `koanf:"tls"`            // "starttls", "tls" (implicit) or "none"
+	MaxAttempts   int           `koanf:"max_attempts"`   // Delivery attempts before a message is bounced
+	RetryInterval time.Duration `koanf:"retry_interval"` // Delay before the first retry, doubled after each attempt
+	PollInterval  time.Duration `koanf:"poll_interval"`  // How often the queue is checked for due messages
+}
+
+// DKIMConfig controls how outgoing mail is signed. The keys themselves are
+// managed through the API.
+type DKIMConfig struct {
+	Headers                []string `koanf:"headers"`                 // Header fields to sign, "From" is always included
+	HeaderCanonicalization string   `koanf:"header_canonicalization"` // "relaxed" or "simple"
+	BodyCanonicalization   string   `koanf:"body_canonicalization"`   // "relaxed" or "simple"
+}
+
+// SRSConfig controls the rewriting of the envelope sender of forwarded mail
+type SRSConfig struct {
+	Enabled bool          `koanf:"enabled"`
+	Domain  string        `koanf:"domain"`  // Domain of the rewritten addresses, email_domain by default
+	Secrets []string      `koanf:"secrets"` // The first one signs, all of them are accepted on bounces
+	MaxAge  time.Duration `koanf:"max_age"` // How long bounces to a rewritten address are accepted
+}
+
+// MTASTSConfig is the MTA-STS policy (RFC 8461) served for the managed domains
+type MTASTSConfig struct {
+	Enabled bool          `koanf:"enabled"`
+	Mode    string        `koanf:"mode"`    // "testing", "enforce" or "none"
+	MaxAge  time.Duration `koanf:"max_age"` // How long senders cache the policy, at most a year
+	MX      []string      `koanf:"mx"`      // MX hosts senders may deliver to, smtp.domain by default
+	Domains []string      `koanf:"domains"` // Domains served besides email_domain
+}
+
+// TLSRPTConfig is where SMTP TLS reports (RFC 8460) are received
+type TLSRPTConfig struct {
+	Address string `koanf:"address"` // e.g. "tlsrpt@example.com"; empty to refuse reports
 }
 
 type SMTPConfig struct {
 	Domain            string          `koanf:"domain"`
 	Hostname          string          `koanf:"hostname"`
 	AllowInsecureAuth bool            `koanf:"allow_insecure_auth"` // Allow insecure authentication methods
+	SpamThreshold     float64         `koanf:"spam_threshold"`      // X-Spam-Score at which mail goes to Junk, 0 to ignore the score
 	MSA               SMTPAgentConfig `koanf:"msa"`
-	MTA               SMTPAgentConfig `koanf:"mta"`
+	MTA               MTAConfig       `koanf:"mta"`
+	Outbound          OutboundConfig  `koanf:"outbound"`
+	DKIM              DKIMConfig      `koanf:"dkim"`
+	SRS               SRSConfig       `koanf:"srs"`
+	MTASTS            MTASTSConfig    `koanf:"mta_sts"`
+	TLSRPT            TLSRPTConfig    `koanf:"tlsrpt"`
+}
+
+// SecretsConfig holds the secrets used to protect data stored in the database
+type SecretsConfig struct {
+	EncryptionKey string `koanf:"encryption_key"` // Encrypts private keys at rest, e.g. DKIM keys
 }
 
 type IMAPConfig struct {
@@ -52,16 +189,27 @@ type IMAPConfig struct {
 	Hostname          string `koanf:"hostname"`
 	Address           string `koanf:"address"`
 	EnableTLS         bool   `koanf:"tls"`
+	TLSPort           string `koanf:"tls_port"` // Implicit TLS (IMAPS) port, e.g. "993"
+	AllowInsecureAuth bool   `koanf:"allow_insecure_auth"`
+	RequireTLSAuth    bool   `koanf:"require_tls_auth"` // Refuse authentication before TLS, even with allow_insecure_auth
+}
+
+type POP3Config struct {
+	Port              string `koanf:"port"`
+	Address           string `koanf:"address"`
+	EnableTLS         bool   `koanf:"tls"`
 	AllowInsecureAuth bool   `koanf:"allow_insecure_auth"`
 }
 
 type Config struct {
 	Server struct {
 		HTTP struct {
-			Port string `koanf:"port"`
+			Port      string `koanf:"port"`
+			EnableTLS bool   `koanf:"tls"` // Serve HTTPS with the certificates under server.tls
 		} `koanf:"http"`
 		SMTP        SMTPConfig     `koanf:"smtp"`
 		IMAP        IMAPConfig     `koanf:"imap"`
+		POP3        POP3Config     `koanf:"pop3"`
 		TLS         TLSFilesConfig `koanf:"tls"`
 		EmailDomain string         `koanf:"email_domain"`
 	} `koanf:"server"`
@@ -70,7 +218,8 @@ type Config struct {
 		Level  logger.Level `koanf:"level"`
 		Format string       `koanf:"format"`
 	} `koanf:"logging"`
-	OIDC OIDCConfig `koanf:"oidc"`
+	OIDC    OIDCConfig    `koanf:"oidc"`
+	Secrets SecretsConfig `koanf:"secrets"`
 }
 
 func LoadConfig(configFile string, ko *koanf.Koanf) (*Config, error) {
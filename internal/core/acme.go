@@ -0,0 +1,60 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	"inbox451/internal/encryption"
+	"inbox451/internal/storage"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMECache keeps the ACME account key, the certificates and the pending
+// HTTP-01 tokens in the database, encrypted, so that every replica serves the
+// same certificates and can answer a challenge another replica started
+type ACMECache struct {
+	core   *Core
+	cipher *encryption.Cipher
+}
+
+// NewACMECache needs secrets.encryption_key, as the cache holds private keys
+func NewACMECache(core *Core) (*ACMECache, error) {
+	cipher, err := encryption.New(core.Config.Secrets.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return &ACMECache{core: core, cipher: cipher}, nil
+}
+
+func (c *ACMECache) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := c.core.Repository.GetACMECacheEntry(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, autocert.ErrCacheMiss
+	}
+	if err != nil {
+		c.core.Logger.Error("Failed to read ACME cache entry %s: %v", key, err)
+		return nil, err
+	}
+	return c.cipher.Decrypt(data)
+}
+
+func (c *ACMECache) Put(ctx context.Context, key string, data []byte) error {
+	sealed, err := c.cipher.Encrypt(data)
+	if err != nil {
+		return err
+	}
+	if err := c.core.Repository.PutACMECacheEntry(ctx, key, sealed); err != nil {
+		c.core.Logger.Error("Failed to store ACME cache entry %s: %v", key, err)
+		return err
+	}
+	return nil
+}
+
+func (c *ACMECache) Delete(ctx context.Context, key string) error {
+	if err := c.core.Repository.DeleteACMECacheEntry(ctx, key); err != nil {
+		c.core.Logger.Error("Failed to delete ACME cache entry %s: %v", key, err)
+		return err
+	}
+	return nil
+}
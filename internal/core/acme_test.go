@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"inbox451/internal/config"
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func setupACMETestCore(t *testing.T) (*Core, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	logger := logger.New(io.Discard, logger.DEBUG)
+
+	core := &Core{
+		Config:     &config.Config{},
+		Logger:     logger,
+		Repository: mockRepo,
+	}
+	core.Config.Secrets.EncryptionKey = "test encryption key"
+
+	return core, mockRepo
+}
+
+func TestNewACMECache(t *testing.T) {
+	core, _ := setupACMETestCore(t)
+	core.Config.Secrets.EncryptionKey = ""
+
+	_, err := NewACMECache(core)
+	assert.Error(t, err)
+}
+
+func TestACMECache(t *testing.T) {
+	ctx := context.Background()
+	core, mockRepo := setupACMETestCore(t)
+	cache, err := NewACMECache(core)
+	require.NoError(t, err)
+
+	var stored []byte
+	mockRepo.On("PutACMECacheEntry", ctx, "mail.example.com", mock.Anything).
+		Run(func(args mock.Arguments) { stored = args.Get(2).([]byte) }).
+		Return(nil).Once()
+	require.NoError(t, cache.Put(ctx, "mail.example.com", []byte("certificate")))
+	assert.NotContains(t, string(stored), "certificate", "entries are stored encrypted")
+
+	mockRepo.On("GetACMECacheEntry", ctx, "mail.example.com").Return(stored, nil).Once()
+	data, err := cache.Get(ctx, "mail.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("certificate"), data)
+
+	mockRepo.On("GetACMECacheEntry", ctx, "acme_account+key").Return(nil, storage.ErrNotFound).Once()
+	_, err = cache.Get(ctx, "acme_account+key")
+	assert.ErrorIs(t, err, autocert.ErrCacheMiss)
+
+	mockRepo.On("GetACMECacheEntry", ctx, "broken").Return(nil, errors.New("connection refused")).Once()
+	_, err = cache.Get(ctx, "broken")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, autocert.ErrCacheMiss)
+
+	mockRepo.On("DeleteACMECacheEntry", ctx, "mail.example.com").Return(nil).Once()
+	assert.NoError(t, cache.Delete(ctx, "mail.example.com"))
+}
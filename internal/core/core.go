@@ -2,12 +2,16 @@ package core
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"os"
 
+	"inbox451/internal/certs"
 	"inbox451/internal/config"
+	"inbox451/internal/dnsbl"
 	"inbox451/internal/logger"
 	"inbox451/internal/models"
+	"inbox451/internal/srs"
 	"inbox451/internal/storage"
 
 	"github.com/jmoiron/sqlx"
@@ -22,12 +26,35 @@ type Core struct {
 	Commit     string
 	BuildDate  string
 
-	UserService    UserService
-	TokenService   TokenService
-	ProjectService ProjectService
-	InboxService   InboxService
-	RuleService    RuleService
-	MessageService MessageService
+	UserService      UserService
+	TokenService     TokenService
+	ProjectService   ProjectService
+	InboxService     InboxService
+	RuleService      RuleService
+	MessageService   MessageService
+	LabelService     LabelService
+	FolderService    FolderService
+	ThreadService    ThreadService
+	SenderService    SenderService
+	OutboundService  OutboundService
+	DKIMService      DKIMService
+	MTASTSService    MTASTSService
+	TLSReportService TLSReportService
+	DMARCService     DMARCService
+	GreylistService  GreylistService
+	QuotaService     QuotaService
+
+	// SRS rewrites the envelope sender of forwarded mail, nil when disabled
+	SRS *srs.Rewriter
+	// DNSBL looks MTA clients up on DNS blocklists, nil without zones
+	DNSBL *dnsbl.Checker
+	// BearerVerifier validates the OAuth tokens of mail clients, nil without OIDC
+	BearerVerifier BearerVerifier
+	// Certificates are the TLS certificates shared by all servers, nil when
+	// none is configured
+	Certificates *certs.Manager
+	// ACME obtains certificates from an ACME directory, nil when disabled
+	ACME *certs.ACME
 }
 
 func NewCore(cfg *config.Config, db *sqlx.DB, version, commit, date string) (*Core, error) {
@@ -47,16 +74,83 @@ func NewCore(cfg *config.Config, db *sqlx.DB, version, commit, date string) (*Co
 		BuildDate:  date,
 	}
 
+	if cfg.Server.SMTP.SRS.Enabled {
+		domain := cfg.Server.SMTP.SRS.Domain
+		if domain == "" {
+			domain = cfg.Server.EmailDomain
+		}
+		core.SRS, err = srs.New(domain, cfg.Server.SMTP.SRS.Secrets, cfg.Server.SMTP.SRS.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure SRS: %w", err)
+		}
+	}
+
+	if dnsblCfg := cfg.Server.SMTP.MTA.DNSBL; len(dnsblCfg.Zones) > 0 {
+		if dnsblCfg.Mode != dnsbl.ModeReject && dnsblCfg.Mode != dnsbl.ModeTag {
+			return nil, fmt.Errorf("mta.dnsbl.mode must be %q or %q", dnsbl.ModeReject, dnsbl.ModeTag)
+		}
+		core.DNSBL, err = dnsbl.New(dnsblCfg.Zones, nil, dnsblCfg.CacheTTL, dnsblCfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure DNSBL: %w", err)
+		}
+	}
+
+	if err := validateMTASTS(core); err != nil {
+		return nil, err
+	}
+
+	if cfg.Server.TLS.ACME.Enabled {
+		cache, err := NewACMECache(core)
+		if err != nil {
+			return nil, fmt.Errorf("secrets.encryption_key must be configured to store ACME certificates: %w", err)
+		}
+		hosts := append([]string{cfg.Server.SMTP.Domain, cfg.Server.IMAP.Hostname}, cfg.Server.TLS.ACME.Hosts...)
+		// MTA-STS policies must be served over HTTPS from mta-sts.<domain>
+		mtaSTS := NewMTASTSService(core)
+		hosts = append(hosts, mtaSTS.Hosts()...)
+		core.ACME, err = certs.NewACME(cfg.Server.TLS.ACME, cache, hosts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure ACME: %w", err)
+		}
+	}
+
+	if files := cfg.Server.TLS.Files(); len(files) > 0 || core.ACME != nil {
+		core.Certificates, err = certs.NewManager(baseLogger, files, core.ACME)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificates: %w", err)
+		}
+	}
+
 	core.UserService = NewUserService(core)
 	core.ProjectService = NewProjectService(core)
 	core.InboxService = NewInboxService(core)
 	core.RuleService = NewRuleService(core)
 	core.MessageService = NewMessageService(core)
+	core.LabelService = NewLabelService(core)
+	core.FolderService = NewFolderService(core)
+	core.ThreadService = NewThreadService(core)
+	core.SenderService = NewSenderService(core)
+	core.OutboundService = NewOutboundService(core)
+	core.DKIMService = NewDKIMService(core)
+	core.MTASTSService = NewMTASTSService(core)
+	core.TLSReportService = NewTLSReportService(core)
+	core.DMARCService = NewDMARCService(core)
+	core.GreylistService = NewGreylistService(core)
+	core.QuotaService = NewQuotaService(core)
 	core.TokenService = NewTokensService(core)
 
 	return core, nil
 }
 
+// TLSConfig returns the TLS configuration shared by all servers, which follows
+// certificate renewals
+func (c *Core) TLSConfig() (*tls.Config, error) {
+	if c.Certificates == nil {
+		return nil, certs.ErrNoCertificates
+	}
+	return c.Certificates.TLSConfig(), nil
+}
+
 func (c *Core) StoreMessage(message *models.Message) error {
 	ctx := context.Background()
 	return c.MessageService.Store(ctx, message)
@@ -0,0 +1,371 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"inbox451/internal/encryption"
+	"inbox451/internal/models"
+	"inbox451/internal/storage"
+
+	gomessage "github.com/emersion/go-message"
+	"github.com/emersion/go-message/mail"
+	"github.com/emersion/go-message/textproto"
+	"github.com/emersion/go-msgauth/dkim"
+)
+
+// Algorithms of DKIM keys
+const (
+	DKIMAlgorithmRSA     = "rsa"
+	DKIMAlgorithmEd25519 = "ed25519"
+)
+
+const (
+	defaultDKIMRSABits = 2048
+	minDKIMRSABits     = 1024
+)
+
+// defaultDKIMHeaders are the header fields signed unless configured otherwise,
+// following RFC 6376 section 5.4.1
+var defaultDKIMHeaders = []string{
+	"From", "Reply-To", "Subject", "Date", "To", "Cc", "Message-ID",
+	"In-Reply-To", "References", "MIME-Version", "Content-Type", "Content-Transfer-Encoding",
+}
+
+var dkimSelector = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+type DKIMService struct {
+	core *Core
+}
+
+func NewDKIMService(core *Core) DKIMService {
+	return DKIMService{core: core}
+}
+
+// Create generates a DKIM key for a domain, or imports the PEM encoded key of
+// the request. The private key is encrypted before it is stored.
+func (s *DKIMService) Create(ctx context.Context, req *models.DKIMKeyRequest) (*models.DKIMKey, error) {
+	domain := strings.TrimSuffix(strings.ToLower(req.Domain), ".")
+	selector := strings.ToLower(req.Selector)
+	if !dkimSelector.MatchString(selector) {
+		return nil, &APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Selector must be a valid DNS label",
+		}
+	}
+
+	var signer crypto.Signer
+	var err error
+	if req.PrivateKey != "" {
+		signer, err = parseDKIMPrivateKey(req.PrivateKey)
+	} else {
+		signer, err = generateDKIMKey(req.Algorithm, req.Bits)
+	}
+	if err != nil {
+		return nil, &APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	algorithm, publicKey, err := dkimPublicKey(signer.Public())
+	if err != nil {
+		return nil, &APIError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	if req.Algorithm != "" && req.Algorithm != algorithm {
+		return nil, &APIError{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("Private key is not an %s key", req.Algorithm),
+		}
+	}
+
+	privateKey, err := s.seal(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.DKIMKey{
+		Domain:     domain,
+		Selector:   selector,
+		Algorithm:  algorithm,
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+	}
+
+	s.core.Logger.Info("Creating %s DKIM key %s for domain %s", algorithm, selector, domain)
+	if err := s.core.Repository.CreateDKIMKey(ctx, key); err != nil {
+		s.core.Logger.Error("Failed to create DKIM key: %v", err)
+		return nil, err
+	}
+
+	s.core.Logger.Info("Successfully created DKIM key with ID: %s", key.ID)
+	return withDNSRecord(key), nil
+}
+
+func (s *DKIMService) Get(ctx context.Context, id string) (*models.DKIMKey, error) {
+	s.core.Logger.Debug("Fetching DKIM key with ID: %s", id)
+
+	key, err := s.core.Repository.GetDKIMKey(ctx, id)
+	if err != nil {
+		s.core.Logger.Error("Failed to fetch DKIM key: %v", err)
+		return nil, err
+	}
+	return withDNSRecord(key), nil
+}
+
+func (s *DKIMService) Delete(ctx context.Context, id string) error {
+	s.core.Logger.Info("Deleting DKIM key with ID: %s", id)
+
+	if err := s.core.Repository.DeleteDKIMKey(ctx, id); err != nil {
+		s.core.Logger.Error("Failed to delete DKIM key: %v", err)
+		return err
+	}
+
+	s.core.Logger.Info("Successfully deleted DKIM key with ID: %s", id)
+	return nil
+}
+
+func (s *DKIMService) List(ctx context.Context, limit, offset int) (*models.PaginatedResponse, error) {
+	s.core.Logger.Info("Listing DKIM keys with limit: %d and offset: %d", limit, offset)
+
+	keys, total, err := s.core.Repository.ListDKIMKeys(ctx, limit, offset)
+	if err != nil {
+		s.core.Logger.Error("Failed to list DKIM keys: %v", err)
+		return nil, err
+	}
+	for _, key := range keys {
+		withDNSRecord(key)
+	}
+
+	response := &models.PaginatedResponse{
+		Data: keys,
+	}
+	response.Pagination.Total = total
+	response.Pagination.Limit = limit
+	response.Pagination.Offset = offset
+
+	s.core.Logger.Info("Successfully retrieved %d DKIM keys (total: %d)", len(keys), total)
+	return response, nil
+}
+
+// Sign returns the message with a DKIM-Signature header prepended, using the
+// newest key of the domain of its From address or of the closest parent
+// domain with a key. Messages without a key for their domain are returned
+// unchanged.
+func (s *DKIMService) Sign(ctx context.Context, raw []byte) ([]byte, error) {
+	domain := fromDomain(raw)
+	if domain == "" {
+		return raw, nil
+	}
+
+	key, err := s.keyForDomain(ctx, domain)
+	if err != nil || key == nil {
+		return raw, err
+	}
+
+	signer, err := s.open(key)
+	if err != nil {
+		s.core.Logger.Error("Failed to decrypt DKIM key %s: %v", key.ID, err)
+		return nil, err
+	}
+
+	cfg := s.core.Config.Server.SMTP.DKIM
+	options := &dkim.SignOptions{
+		Domain:                 key.Domain,
+		Selector:               key.Selector,
+		Signer:                 signer,
+		HeaderCanonicalization: dkimCanonicalization(cfg.HeaderCanonicalization),
+		BodyCanonicalization:   dkimCanonicalization(cfg.BodyCanonicalization),
+		HeaderKeys:             dkimHeaderKeys(cfg.Headers),
+	}
+
+	var signed bytes.Buffer
+	if err := dkim.Sign(&signed, bytes.NewReader(raw), options); err != nil {
+		s.core.Logger.Error("Failed to DKIM sign message from %s: %v", domain, err)
+		return nil, err
+	}
+	return signed.Bytes(), nil
+}
+
+// keyForDomain returns the key of a domain or of its closest parent with one,
+// or nil when there is none
+func (s *DKIMService) keyForDomain(ctx context.Context, domain string) (*models.DKIMKey, error) {
+	for {
+		key, err := s.core.Repository.GetDKIMKeyByDomain(ctx, domain)
+		if err == nil {
+			return key, nil
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			s.core.Logger.Error("Failed to fetch DKIM key for %s: %v", domain, err)
+			return nil, err
+		}
+
+		// Stop at the registered domain rather than asking for a TLD
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok || !strings.Contains(parent, ".") {
+			return nil, nil
+		}
+		domain = parent
+	}
+}
+
+func (s *DKIMService) seal(signer crypto.Signer) ([]byte, error) {
+	cipher, err := encryption.New(s.core.Config.Secrets.EncryptionKey)
+	if err != nil {
+		return nil, &APIError{
+			Code:    http.StatusInternalServerError,
+			Message: "secrets.encryption_key must be configured to store DKIM keys",
+		}
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.Encrypt(der)
+}
+
+func (s *DKIMService) open(key *models.DKIMKey) (crypto.Signer, error) {
+	cipher, err := encryption.New(s.core.Config.Secrets.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	der, err := cipher.Decrypt(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported DKIM key type")
+	}
+	return signer, nil
+}
+
+// withDNSRecord fills in the TXT record that publishes the public key
+func withDNSRecord(key *models.DKIMKey) *models.DKIMKey {
+	key.DNSName = key.Selector + "._domainkey." + key.Domain
+	key.DNSRecord = fmt.Sprintf("v=DKIM1; k=%s; p=%s", key.Algorithm, key.PublicKey)
+	return key
+}
+
+func generateDKIMKey(algorithm string, bits int) (crypto.Signer, error) {
+	switch algorithm {
+	case DKIMAlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case "", DKIMAlgorithmRSA:
+		if bits == 0 {
+			bits = defaultDKIMRSABits
+		}
+		return rsa.GenerateKey(rand.Reader, bits)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+}
+
+// parseDKIMPrivateKey reads a PKCS #8 or PKCS #1 PEM encoded private key
+func parseDKIMPrivateKey(data string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("private key must be PEM encoded")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	default:
+		return nil, errors.New("private key must be an RSA or Ed25519 key")
+	}
+}
+
+// dkimPublicKey returns the algorithm of a public key and its encoding in the
+// p= tag: a SubjectPublicKeyInfo for RSA (RFC 6376), the raw key for Ed25519
+// (RFC 8463)
+func dkimPublicKey(public crypto.PublicKey) (string, string, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minDKIMRSABits {
+			return "", "", fmt.Errorf("RSA keys must have at least %d bits", minDKIMRSABits)
+		}
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			return "", "", err
+		}
+		return DKIMAlgorithmRSA, base64.StdEncoding.EncodeToString(der), nil
+	case ed25519.PublicKey:
+		return DKIMAlgorithmEd25519, base64.StdEncoding.EncodeToString(key), nil
+	default:
+		return "", "", errors.New("private key must be an RSA or Ed25519 key")
+	}
+}
+
+func dkimCanonicalization(value string) dkim.Canonicalization {
+	if strings.EqualFold(value, string(dkim.CanonicalizationSimple)) {
+		return dkim.CanonicalizationSimple
+	}
+	return dkim.CanonicalizationRelaxed
+}
+
+// dkimHeaderKeys returns the configured header fields to sign, which must
+// include From
+func dkimHeaderKeys(headers []string) []string {
+	if len(headers) == 0 {
+		return defaultDKIMHeaders
+	}
+	for _, header := range headers {
+		if strings.EqualFold(header, "From") {
+			return headers
+		}
+	}
+	return append([]string{"From"}, headers...)
+}
+
+// fromDomain returns the lower-cased domain of the first From address of a
+// message, or an empty string
+func fromDomain(raw []byte) string {
+	fields, err := textproto.ReadHeader(bufio.NewReader(bytes.NewReader(raw)))
+	if err != nil {
+		return ""
+	}
+	header := mail.Header{Header: gomessage.Header{Header: fields}}
+	from, err := header.AddressList("From")
+	if err != nil || len(from) == 0 {
+		return ""
+	}
+	_, domain, ok := strings.Cut(from[0].Address, "@")
+	if !ok {
+		return ""
+	}
+	return strings.ToLower(domain)
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"inbox451/internal/config"
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+	"inbox451/internal/storage"
+	"inbox451/internal/test"
+
+	"github.com/emersion/go-msgauth/dkim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupDKIMTestCore(t *testing.T) (*Core, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	logger := logger.New(io.Discard, logger.DEBUG)
+
+	core := &Core{
+		Config:     &config.Config{},
+		Logger:     logger,
+		Repository: mockRepo,
+	}
+	core.Config.Secrets.EncryptionKey = "test encryption key"
+	core.DKIMService = NewDKIMService(core)
+
+	return core, mockRepo
+}
+
+func TestDKIMService_Create(t *testing.T) {
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(ed25519Key)
+	require.NoError(t, err)
+	imported := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	tests := []struct {
+		name          string
+		req           *models.DKIMKeyRequest
+		wantAlgorithm string
+		wantErr       bool
+	}{
+		{
+			name:          "generate RSA by default",
+			req:           &models.DKIMKeyRequest{Domain: "Example.com", Selector: "mail", Bits: 1024},
+			wantAlgorithm: DKIMAlgorithmRSA,
+		},
+		{
+			name:          "generate Ed25519",
+			req:           &models.DKIMKeyRequest{Domain: "example.com", Selector: "mail", Algorithm: DKIMAlgorithmEd25519},
+			wantAlgorithm: DKIMAlgorithmEd25519,
+		},
+		{
+			name:          "import",
+			req:           &models.DKIMKeyRequest{Domain: "example.com", Selector: "mail", PrivateKey: imported},
+			wantAlgorithm: DKIMAlgorithmEd25519,
+		},
+		{
+			name:    "imported key of another algorithm",
+			req:     &models.DKIMKeyRequest{Domain: "example.com", Selector: "mail", Algorithm: DKIMAlgorithmRSA, PrivateKey: imported},
+			wantErr: true,
+		},
+		{
+			name:    "not PEM",
+			req:     &models.DKIMKeyRequest{Domain: "example.com", Selector: "mail", PrivateKey: "not a key"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid selector",
+			req:     &models.DKIMKeyRequest{Domain: "example.com", Selector: "mail_2024"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupDKIMTestCore(t)
+			if !tt.wantErr {
+				mockRepo.On("CreateDKIMKey", mock.Anything, mock.AnythingOfType("*models.DKIMKey")).Return(nil)
+			}
+
+			key, err := core.DKIMService.Create(context.Background(), tt.req)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "example.com", key.Domain)
+			assert.Equal(t, tt.wantAlgorithm, key.Algorithm)
+			assert.Equal(t, "mail._domainkey.example.com", key.DNSName)
+			assert.Equal(t, "v=DKIM1; k="+tt.wantAlgorithm+"; p="+key.PublicKey, key.DNSRecord)
+			assert.False(t, bytes.Contains(key.PrivateKey, der), "private key must be stored encrypted")
+		})
+	}
+
+	t.Run("no encryption key", func(t *testing.T) {
+		core, _ := setupDKIMTestCore(t)
+		core.Config.Secrets.EncryptionKey = ""
+
+		_, err := core.DKIMService.Create(context.Background(), &models.DKIMKeyRequest{Domain: "example.com", Selector: "mail", Algorithm: DKIMAlgorithmEd25519})
+		assert.Error(t, err)
+	})
+}
+
+func TestDKIMService_Sign(t *testing.T) {
+	core, mockRepo := setupDKIMTestCore(t)
+
+	var stored *models.DKIMKey
+	mockRepo.On("CreateDKIMKey", mock.Anything, mock.AnythingOfType("*models.DKIMKey")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*models.DKIMKey) }).
+		Return(nil)
+	key, err := core.DKIMService.Create(context.Background(), &models.DKIMKeyRequest{
+		Domain: "example.com", Selector: "mail", Algorithm: DKIMAlgorithmEd25519,
+	})
+	require.NoError(t, err)
+	stored.ID = test.RandomTestUUID()
+
+	// The subdomain has no key of its own, so the parent domain signs
+	mockRepo.On("GetDKIMKeyByDomain", mock.Anything, "news.example.com").Return(nil, storage.ErrNotFound)
+	mockRepo.On("GetDKIMKeyByDomain", mock.Anything, "example.com").Return(stored, nil)
+
+	raw := []byte("From: Support <support@news.example.com>\r\nTo: alice@remote.test\r\nSubject: Hello\r\n\r\nHi there\r\n")
+	signed, err := core.DKIMService.Sign(context.Background(), raw)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(signed), "DKIM-Signature:"))
+
+	verifications, err := dkim.VerifyWithOptions(bytes.NewReader(signed), &dkim.VerifyOptions{
+		LookupTXT: func(domain string) ([]string, error) {
+			if domain != key.DNSName {
+				return nil, errors.New("no such record")
+			}
+			return []string{key.DNSRecord}, nil
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, verifications, 1)
+	assert.NoError(t, verifications[0].Err)
+	assert.Equal(t, "example.com", verifications[0].Domain)
+}
+
+func TestDKIMService_SignWithoutKey(t *testing.T) {
+	core, mockRepo := setupDKIMTestCore(t)
+	mockRepo.On("GetDKIMKeyByDomain", mock.Anything, "example.com").Return(nil, storage.ErrNotFound)
+
+	raw := []byte("From: support@example.com\r\nSubject: Hello\r\n\r\nHi there\r\n")
+	signed, err := core.DKIMService.Sign(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Equal(t, raw, signed)
+}
@@ -0,0 +1,422 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"inbox451/internal/models"
+
+	gomessage "github.com/emersion/go-message"
+	null "github.com/volatiletech/null/v9"
+)
+
+const (
+	// maxDMARCReportSize caps a report before and after decompression. Reports
+	// of large senders run to a few megabytes.
+	maxDMARCReportSize = 32 * 1024 * 1024
+	// maxDMARCDocuments and maxDMARCUnpackedSize cap the reports taken from a
+	// message and their combined size, as a small archive can unpack to
+	// gigabytes
+	maxDMARCDocuments    = 100
+	maxDMARCUnpackedSize = 64 * 1024 * 1024
+)
+
+var errInvalidDMARCReport = errors.New("invalid DMARC report")
+
+// dmarcFeedback is the XML of an aggregate report, RFC 7489 appendix C
+type dmarcFeedback struct {
+	Metadata struct {
+		OrgName   string `xml:"org_name"`
+		Email     string `xml:"email"`
+		ReportID  string `xml:"report_id"`
+		DateRange struct {
+			Begin int64 `xml:"begin"`
+			End   int64 `xml:"end"`
+		} `xml:"date_range"`
+	} `xml:"report_metadata"`
+	Policy struct {
+		Domain string `xml:"domain"`
+		ADKIM  string `xml:"adkim"`
+		ASPF   string `xml:"aspf"`
+		P      string `xml:"p"`
+		SP     string `xml:"sp"`
+		Pct    *int   `xml:"pct"`
+	} `xml:"policy_published"`
+	Records []struct {
+		Row struct {
+			SourceIP        string `xml:"source_ip"`
+			Count           int64  `xml:"count"`
+			PolicyEvaluated struct {
+				Disposition string `xml:"disposition"`
+				DKIM        string `xml:"dkim"`
+				SPF         string `xml:"spf"`
+			} `xml:"policy_evaluated"`
+		} `xml:"row"`
+		Identifiers struct {
+			HeaderFrom   string `xml:"header_from"`
+			EnvelopeFrom string `xml:"envelope_from"`
+		} `xml:"identifiers"`
+		AuthResults dmarcAuthResults `xml:"auth_results"`
+	} `xml:"record"`
+}
+
+// dmarcAuthResults are the raw DKIM and SPF results of a record, stored as
+// JSON
+type dmarcAuthResults struct {
+	DKIM []struct {
+		Domain   string `xml:"domain" json:"domain"`
+		Selector string `xml:"selector" json:"selector,omitempty"`
+		Result   string `xml:"result" json:"result"`
+	} `xml:"dkim" json:"dkim,omitempty"`
+	SPF []struct {
+		Domain string `xml:"domain" json:"domain"`
+		Scope  string `xml:"scope" json:"scope,omitempty"`
+		Result string `xml:"result" json:"result"`
+	} `xml:"spf" json:"spf,omitempty"`
+}
+
+type DMARCService struct {
+	core *Core
+}
+
+func NewDMARCService(core *Core) DMARCService {
+	return DMARCService{core: core}
+}
+
+// Ingest stores the aggregate reports attached to a message delivered to an
+// inbox flagged as a report sink, as zip, gzip or plain XML parts. Parts that
+// aren't valid reports are logged and skipped, so only storage failures are
+// returned. It returns how many reports were stored.
+func (s *DMARCService) Ingest(ctx context.Context, inbox *models.Inbox, raw []byte) (int, error) {
+	if !inbox.DMARCReports {
+		return 0, nil
+	}
+
+	entity, err := gomessage.Read(bytes.NewReader(raw))
+	if err != nil && !gomessage.IsUnknownCharset(err) {
+		s.core.Logger.Warn("Ignoring unreadable DMARC report message: %v", err)
+		return 0, nil
+	}
+
+	var reports []*models.DMARCReport
+	unpacked := &dmarcUnpacked{}
+	err = entity.Walk(func(_ []int, part *gomessage.Entity, err error) error {
+		if err != nil {
+			return err
+		}
+		err = dmarcDocuments(part, unpacked, func(document []byte) {
+			report, err := parseDMARCReport(document)
+			if err != nil {
+				s.core.Logger.Warn("Ignoring DMARC report: %v", err)
+				return
+			}
+			report.InboxID = inbox.ID
+			reports = append(reports, report)
+		})
+		if err != nil {
+			s.core.Logger.Warn("Ignoring DMARC report attachment: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		s.core.Logger.Warn("Ignoring the rest of a malformed DMARC report message: %v", err)
+	}
+
+	for i, report := range reports {
+		s.core.Logger.Info("Storing DMARC report %s from %s for %s", report.ReportID, report.OrgName, report.Domain)
+		if err := s.core.Repository.CreateDMARCReport(ctx, report); err != nil {
+			s.core.Logger.Error("Failed to store DMARC report: %v", err)
+			return i, err
+		}
+	}
+	return len(reports), nil
+}
+
+// dmarcDocuments hands the XML documents of a message part to handle as they
+// are read, unpacking zip and gzip attachments. Parts of other types have
+// none. Reporters label attachments inconsistently, so generic types are told
+// apart by file name.
+func dmarcDocuments(part *gomessage.Entity, unpacked *dmarcUnpacked, handle func(document []byte)) error {
+	mediaType, _, _ := part.Header.ContentType()
+	_, params, _ := part.Header.ContentDisposition()
+	filename := strings.ToLower(params["filename"])
+	if filename == "" {
+		_, params, _ := part.Header.ContentType()
+		filename = strings.ToLower(params["name"])
+	}
+
+	kind := ""
+	switch mediaType {
+	case "application/zip", "application/x-zip-compressed", "application/x-zip":
+		kind = "zip"
+	case "application/gzip", "application/x-gzip":
+		kind = "gzip"
+	case "text/xml", "application/xml":
+		kind = "xml"
+	case "application/octet-stream":
+		switch path.Ext(filename) {
+		case ".zip":
+			kind = "zip"
+		case ".gz":
+			kind = "gzip"
+		case ".xml":
+			kind = "xml"
+		}
+	}
+
+	switch kind {
+	case "xml":
+		document, err := unpacked.read(part.Body)
+		if err != nil {
+			return err
+		}
+		handle(document)
+	case "gzip":
+		zr, err := gzip.NewReader(part.Body)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		document, err := unpacked.read(zr)
+		if err != nil {
+			return err
+		}
+		handle(document)
+	case "zip":
+		// The central directory is at the end, so the archive is read whole
+		data, err := io.ReadAll(io.LimitReader(part.Body, maxDMARCReportSize+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > maxDMARCReportSize {
+			return fmt.Errorf("report larger than %d bytes", maxDMARCReportSize)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return err
+		}
+		for _, file := range zr.File {
+			if !strings.EqualFold(path.Ext(file.Name), ".xml") {
+				continue
+			}
+			f, err := file.Open()
+			if err != nil {
+				return err
+			}
+			document, err := unpacked.read(f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+			handle(document)
+		}
+	}
+	return nil
+}
+
+// dmarcUnpacked counts the reports read from a message so far and their size
+type dmarcUnpacked struct {
+	documents int
+	size      int64
+}
+
+// read reads a report, within the limits of a single report and of what is
+// left of those of the message
+func (u *dmarcUnpacked) read(r io.Reader) ([]byte, error) {
+	if u.documents >= maxDMARCDocuments {
+		return nil, fmt.Errorf("more than %d reports in a message", maxDMARCDocuments)
+	}
+	limit := min(int64(maxDMARCReportSize), maxDMARCUnpackedSize-u.size)
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		if limit < maxDMARCReportSize {
+			return nil, fmt.Errorf("reports of a message larger than %d bytes", maxDMARCUnpackedSize)
+		}
+		return nil, fmt.Errorf("report larger than %d bytes", maxDMARCReportSize)
+	}
+	u.documents++
+	u.size += int64(len(data))
+	return data, nil
+}
+
+// parseDMARCReport decodes an aggregate report and its records
+func parseDMARCReport(data []byte) (*models.DMARCReport, error) {
+	var feedback dmarcFeedback
+	if err := xml.Unmarshal(data, &feedback); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidDMARCReport, err)
+	}
+
+	metadata := feedback.Metadata
+	policy := feedback.Policy
+	report := &models.DMARCReport{
+		OrgName:     strings.TrimSpace(metadata.OrgName),
+		OrgEmail:    strings.TrimSpace(metadata.Email),
+		ReportID:    strings.TrimSpace(metadata.ReportID),
+		Domain:      strings.TrimSuffix(strings.ToLower(strings.TrimSpace(policy.Domain)), "."),
+		DateBegin:   null.TimeFrom(time.Unix(metadata.DateRange.Begin, 0).UTC()),
+		DateEnd:     null.TimeFrom(time.Unix(metadata.DateRange.End, 0).UTC()),
+		PolicyADKIM: strings.ToLower(strings.TrimSpace(policy.ADKIM)),
+		PolicyASPF:  strings.ToLower(strings.TrimSpace(policy.ASPF)),
+		PolicyP:     strings.ToLower(strings.TrimSpace(policy.P)),
+		PolicySP:    strings.ToLower(strings.TrimSpace(policy.SP)),
+		PolicyPct:   100,
+		Records:     make([]*models.DMARCRecord, 0, len(feedback.Records)),
+	}
+	if policy.Pct != nil {
+		report.PolicyPct = *policy.Pct
+	}
+	if report.OrgName == "" || report.ReportID == "" || report.Domain == "" {
+		return nil, fmt.Errorf("%w: missing org_name, report_id or domain", errInvalidDMARCReport)
+	}
+	if metadata.DateRange.Begin <= 0 || metadata.DateRange.End < metadata.DateRange.Begin {
+		return nil, fmt.Errorf("%w: invalid date_range", errInvalidDMARCReport)
+	}
+
+	for _, record := range feedback.Records {
+		authResults, err := json.Marshal(record.AuthResults)
+		if err != nil {
+			return nil, err
+		}
+		evaluated := record.Row.PolicyEvaluated
+		report.Records = append(report.Records, &models.DMARCRecord{
+			SourceIP:     strings.TrimSpace(record.Row.SourceIP),
+			Count:        record.Row.Count,
+			Disposition:  strings.ToLower(strings.TrimSpace(evaluated.Disposition)),
+			DKIM:         strings.ToLower(strings.TrimSpace(evaluated.DKIM)),
+			SPF:          strings.ToLower(strings.TrimSpace(evaluated.SPF)),
+			HeaderFrom:   strings.ToLower(strings.TrimSpace(record.Identifiers.HeaderFrom)),
+			EnvelopeFrom: strings.ToLower(strings.TrimSpace(record.Identifiers.EnvelopeFrom)),
+			AuthResults:  authResults,
+		})
+	}
+	return report, nil
+}
+
+// dmarcFilter turns the query of a project into a storage filter
+func dmarcFilter(projectID string, query *models.DMARCQuery) (models.DMARCFilter, error) {
+	filter := models.DMARCFilter{
+		ProjectID: projectID,
+		Domain:    strings.TrimSuffix(strings.ToLower(query.Domain), "."),
+		Source:    query.Source,
+		GroupBy:   query.GroupBy,
+	}
+	if filter.GroupBy == "" {
+		filter.GroupBy = "domain"
+	}
+
+	var err error
+	if filter.Start, err = parseDMARCTime(query.Start, false); err != nil {
+		return filter, err
+	}
+	if filter.End, err = parseDMARCTime(query.End, true); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// parseDMARCTime parses a date or an RFC 3339 time. A date as the end of a
+// range includes the whole day.
+func parseDMARCTime(value string, end bool) (null.Time, error) {
+	if value == "" {
+		return null.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return null.TimeFrom(t), nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return null.Time{}, &APIError{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("Invalid date %q, expected YYYY-MM-DD or an RFC 3339 time", value),
+		}
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return null.TimeFrom(t), nil
+}
+
+func (s *DMARCService) Get(ctx context.Context, projectID, id string) (*models.DMARCReport, error) {
+	s.core.Logger.Debug("Fetching DMARC report with ID: %s", id)
+
+	report, err := s.core.Repository.GetDMARCReport(ctx, projectID, id)
+	if err != nil {
+		s.core.Logger.Error("Failed to fetch DMARC report: %v", err)
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *DMARCService) Delete(ctx context.Context, projectID, id string) error {
+	s.core.Logger.Info("Deleting DMARC report with ID: %s", id)
+
+	if err := s.core.Repository.DeleteDMARCReport(ctx, projectID, id); err != nil {
+		s.core.Logger.Error("Failed to delete DMARC report: %v", err)
+		return err
+	}
+	return nil
+}
+
+// List returns the reports of a project matching the query, newest first
+func (s *DMARCService) List(ctx context.Context, projectID string, query *models.DMARCQuery) (*models.PaginatedResponse, error) {
+	s.core.Logger.Info("Listing DMARC reports for project %s with limit: %d and offset: %d", projectID, query.Limit, query.Offset)
+
+	filter, err := dmarcFilter(projectID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, total, err := s.core.Repository.ListDMARCReports(ctx, filter, query.Limit, query.Offset)
+	if err != nil {
+		s.core.Logger.Error("Failed to list DMARC reports: %v", err)
+		return nil, err
+	}
+
+	response := &models.PaginatedResponse{
+		Data: reports,
+	}
+	response.Pagination.Total = total
+	response.Pagination.Limit = query.Limit
+	response.Pagination.Offset = query.Offset
+
+	return response, nil
+}
+
+// Summary sums the records of a project's reports by domain, source IP or
+// day, the largest groups first
+func (s *DMARCService) Summary(ctx context.Context, projectID string, query *models.DMARCQuery) (*models.PaginatedResponse, error) {
+	filter, err := dmarcFilter(projectID, query)
+	if err != nil {
+		return nil, err
+	}
+	s.core.Logger.Info("Summarizing DMARC records of project %s by %s", projectID, filter.GroupBy)
+
+	summaries, total, err := s.core.Repository.SummarizeDMARCRecords(ctx, filter, query.Limit, query.Offset)
+	if err != nil {
+		s.core.Logger.Error("Failed to summarize DMARC records: %v", err)
+		return nil, err
+	}
+
+	response := &models.PaginatedResponse{
+		Data: summaries,
+	}
+	response.Pagination.Total = total
+	response.Pagination.Limit = query.Limit
+	response.Pagination.Offset = query.Offset
+
+	return response, nil
+}
@@ -0,0 +1,344 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"inbox451/internal/config"
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testDMARCReport is an aggregate report in the shape of RFC 7489 appendix C
+const testDMARCReport = `<?xml version="1.0" encoding="UTF-8" ?>
+<feedback>
+  <report_metadata>
+    <org_name>google.com</org_name>
+    <email>noreply-dmarc-support@google.com</email>
+    <report_id>12598866915817748661</report_id>
+    <date_range>
+      <begin>1700006400</begin>
+      <end>1700092799</end>
+    </date_range>
+  </report_metadata>
+  <policy_published>
+    <domain>Example.com</domain>
+    <adkim>r</adkim>
+    <aspf>r</aspf>
+    <p>quarantine</p>
+    <sp>none</sp>
+    <pct>100</pct>
+  </policy_published>
+  <record>
+    <row>
+      <source_ip>192.0.2.1</source_ip>
+      <count>12</count>
+      <policy_evaluated>
+        <disposition>none</disposition>
+        <dkim>pass</dkim>
+        <spf>pass</spf>
+      </policy_evaluated>
+    </row>
+    <identifiers>
+      <header_from>example.com</header_from>
+    </identifiers>
+    <auth_results>
+      <dkim>
+        <domain>example.com</domain>
+        <selector>mail</selector>
+        <result>pass</result>
+      </dkim>
+      <spf>
+        <domain>example.com</domain>
+        <result>pass</result>
+      </spf>
+    </auth_results>
+  </record>
+  <record>
+    <row>
+      <source_ip>198.51.100.7</source_ip>
+      <count>3</count>
+      <policy_evaluated>
+        <disposition>quarantine</disposition>
+        <dkim>fail</dkim>
+        <spf>fail</spf>
+      </policy_evaluated>
+    </row>
+    <identifiers>
+      <header_from>example.com</header_from>
+    </identifiers>
+    <auth_results>
+      <spf>
+        <domain>spammer.example</domain>
+        <result>softfail</result>
+      </spf>
+    </auth_results>
+  </record>
+</feedback>`
+
+func setupDMARCTestCore(t *testing.T) (*Core, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	logger := logger.New(io.Discard, logger.DEBUG)
+
+	core := &Core{
+		Config:     &config.Config{},
+		Logger:     logger,
+		Repository: mockRepo,
+	}
+	core.DMARCService = NewDMARCService(core)
+
+	return core, mockRepo
+}
+
+// dmarcReportMessage mails an attachment the way reporters do
+func dmarcReportMessage(contentType, filename string, attachment []byte) []byte {
+	return []byte("From: noreply-dmarc-support@google.com\r\n" +
+		"To: dmarc@example.com\r\n" +
+		"Subject: Report domain: example.com Submitter: google.com Report-ID: 12598866915817748661\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"report\"\r\n" +
+		"\r\n" +
+		"--report\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"This is an aggregate report from google.com.\r\n" +
+		"--report\r\n" +
+		"Content-Type: " + contentType + "; name=\"" + filename + "\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"Content-Disposition: attachment; filename=\"" + filename + "\"\r\n" +
+		"\r\n" +
+		base64.StdEncoding.EncodeToString(attachment) + "\r\n" +
+		"--report--\r\n")
+}
+
+func gzipDMARCReport(t *testing.T, report string) []byte {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, err := zw.Write([]byte(report))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return compressed.Bytes()
+}
+
+func zipDMARCReport(t *testing.T, report string) []byte {
+	var compressed bytes.Buffer
+	zw := zip.NewWriter(&compressed)
+	w, err := zw.Create("google.com!example.com!1700006400!1700092799.xml")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(report))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return compressed.Bytes()
+}
+
+// zipDMARCReports packs count copies of a report, each under its own name
+func zipDMARCReports(t *testing.T, report string, count int) []byte {
+	var compressed bytes.Buffer
+	zw := zip.NewWriter(&compressed)
+	for i := 0; i < count; i++ {
+		w, err := zw.Create(fmt.Sprintf("report-%d.xml", i))
+		require.NoError(t, err)
+		_, err = w.Write([]byte(report))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return compressed.Bytes()
+}
+
+func TestDMARCService_Ingest(t *testing.T) {
+	ctx := context.Background()
+	sink := &models.Inbox{Base: models.Base{ID: "inbox-1"}, DMARCReports: true}
+
+	isTestReport := func(r *models.DMARCReport) bool {
+		return r.InboxID == "inbox-1" &&
+			r.OrgName == "google.com" &&
+			r.ReportID == "12598866915817748661" &&
+			r.Domain == "example.com" &&
+			r.PolicyP == "quarantine" && r.PolicyPct == 100 &&
+			r.DateBegin.Time.Equal(time.Date(2023, 11, 15, 0, 0, 0, 0, time.UTC)) &&
+			len(r.Records) == 2 &&
+			r.Records[0].SourceIP == "192.0.2.1" && r.Records[0].Count == 12 &&
+			r.Records[0].DKIM == "pass" && r.Records[0].SPF == "pass" &&
+			strings.Contains(string(r.Records[0].AuthResults), `"selector":"mail"`) &&
+			r.Records[1].Disposition == "quarantine" &&
+			strings.Contains(string(r.Records[1].AuthResults), "softfail")
+	}
+
+	tests := []struct {
+		name      string
+		inbox     *models.Inbox
+		raw       []byte
+		mockFn    func(*mocks.Repository)
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			name:  "zip attachment",
+			inbox: sink,
+			raw:   dmarcReportMessage("application/zip", "google.com!example.com!1700006400!1700092799.zip", zipDMARCReport(t, testDMARCReport)),
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateDMARCReport", ctx, mock.MatchedBy(isTestReport)).Return(nil).Once()
+			},
+			wantCount: 1,
+		},
+		{
+			name:  "gzip attachment",
+			inbox: sink,
+			raw:   dmarcReportMessage("application/gzip", "google.com!example.com!1700006400!1700092799.xml.gz", gzipDMARCReport(t, testDMARCReport)),
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateDMARCReport", ctx, mock.MatchedBy(isTestReport)).Return(nil).Once()
+			},
+			wantCount: 1,
+		},
+		{
+			name:  "octet-stream named after its type",
+			inbox: sink,
+			raw:   dmarcReportMessage("application/octet-stream", "report.xml.gz", gzipDMARCReport(t, testDMARCReport)),
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateDMARCReport", ctx, mock.MatchedBy(isTestReport)).Return(nil).Once()
+			},
+			wantCount: 1,
+		},
+		{
+			name:  "xml body",
+			inbox: sink,
+			raw:   []byte("Content-Type: text/xml\r\n\r\n" + testDMARCReport),
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateDMARCReport", ctx, mock.MatchedBy(isTestReport)).Return(nil).Once()
+			},
+			wantCount: 1,
+		},
+		{
+			name:  "zip of many entries stops at the limit",
+			inbox: sink,
+			raw:   dmarcReportMessage("application/zip", "reports.zip", zipDMARCReports(t, testDMARCReport, maxDMARCDocuments+50)),
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateDMARCReport", ctx, mock.MatchedBy(isTestReport)).Return(nil).Times(maxDMARCDocuments)
+			},
+			wantCount: maxDMARCDocuments,
+		},
+		{
+			name:   "inbox is not a report sink",
+			inbox:  &models.Inbox{Base: models.Base{ID: "inbox-2"}},
+			raw:    []byte("Content-Type: text/xml\r\n\r\n" + testDMARCReport),
+			mockFn: func(m *mocks.Repository) {},
+		},
+		{
+			name:   "invalid report is skipped",
+			inbox:  sink,
+			raw:    dmarcReportMessage("application/gzip", "report.xml.gz", gzipDMARCReport(t, "<feedback><report_metadata/></feedback>")),
+			mockFn: func(m *mocks.Repository) {},
+		},
+		{
+			name:   "corrupt zip is skipped",
+			inbox:  sink,
+			raw:    dmarcReportMessage("application/zip", "report.zip", []byte("not a zip")),
+			mockFn: func(m *mocks.Repository) {},
+		},
+		{
+			name:   "no report",
+			inbox:  sink,
+			raw:    []byte("Content-Type: text/plain\r\n\r\nHello"),
+			mockFn: func(m *mocks.Repository) {},
+		},
+		{
+			name:  "storage error",
+			inbox: sink,
+			raw:   dmarcReportMessage("application/zip", "report.zip", zipDMARCReport(t, testDMARCReport)),
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateDMARCReport", ctx, mock.Anything).Return(errors.New("database error")).Once()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupDMARCTestCore(t)
+			tt.mockFn(mockRepo)
+
+			count, err := core.DMARCService.Ingest(ctx, tt.inbox, tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCount, count)
+		})
+	}
+}
+
+func TestDMARCService_List(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("filters by domain and dates", func(t *testing.T) {
+		core, mockRepo := setupDMARCTestCore(t)
+
+		reports := []*models.DMARCReport{{OrgName: "google.com"}}
+		mockRepo.On("ListDMARCReports", ctx, mock.MatchedBy(func(f models.DMARCFilter) bool {
+			return f.ProjectID == "project-1" && f.Domain == "example.com" &&
+				f.Start.Time.Equal(time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC)) &&
+				f.End.Time.Equal(time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC))
+		}), 10, 0).Return(reports, 1, nil).Once()
+
+		response, err := core.DMARCService.List(ctx, "project-1", &models.DMARCQuery{
+			PaginationQuery: models.PaginationQuery{Limit: 10},
+			Domain:          "Example.com",
+			Start:           "2023-11-01",
+			End:             "2023-11-30",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, reports, response.Data)
+		assert.Equal(t, 1, response.Pagination.Total)
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		core, _ := setupDMARCTestCore(t)
+
+		_, err := core.DMARCService.List(ctx, "project-1", &models.DMARCQuery{Start: "yesterday"})
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusBadRequest, apiErr.Code)
+	})
+}
+
+func TestDMARCService_Summary(t *testing.T) {
+	ctx := context.Background()
+	core, mockRepo := setupDMARCTestCore(t)
+
+	summaries := []*models.DMARCSummary{{Key: "192.0.2.1", Messages: 12, DKIMPass: 12, SPFPass: 12, DMARCPass: 12}}
+	mockRepo.On("SummarizeDMARCRecords", ctx, mock.MatchedBy(func(f models.DMARCFilter) bool {
+		return f.ProjectID == "project-1" && f.GroupBy == "source" && !f.Start.Valid
+	}), 10, 0).Return(summaries, 1, nil).Once()
+	mockRepo.On("SummarizeDMARCRecords", ctx, mock.MatchedBy(func(f models.DMARCFilter) bool {
+		return f.GroupBy == "domain"
+	}), 10, 0).Return(summaries, 1, nil).Once()
+
+	response, err := core.DMARCService.Summary(ctx, "project-1", &models.DMARCQuery{
+		PaginationQuery: models.PaginationQuery{Limit: 10},
+		GroupBy:         "source",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, summaries, response.Data)
+
+	// The summary is by domain unless asked otherwise
+	_, err = core.DMARCService.Summary(ctx, "project-1", &models.DMARCQuery{
+		PaginationQuery: models.PaginationQuery{Limit: 10},
+	})
+	require.NoError(t, err)
+}
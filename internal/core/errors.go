@@ -39,6 +39,13 @@ var (
 		Message: "bad request",
 	}
 
+	// ErrQuotaExceeded is returned when a message doesn't fit in the storage
+	// quota of its inbox or of its project
+	ErrQuotaExceeded = &APIError{
+		Code:    http.StatusInsufficientStorage,
+		Message: "storage quota exceeded",
+	}
+
 	ErrAuthFailed = errors.New("invalid credentials")
 
 	ErrAccountInactive = errors.New("user account is inactive")
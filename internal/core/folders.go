@@ -0,0 +1,274 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"inbox451/internal/models"
+	"inbox451/internal/storage"
+
+	null "github.com/volatiletech/null/v9"
+)
+
+// FolderDelimiter separates the levels of a folder path
+const FolderDelimiter = "/"
+
+// RFC 6154 special-use attributes
+const (
+	SpecialUseSent    = `\Sent`
+	SpecialUseTrash   = `\Trash`
+	SpecialUseJunk    = `\Junk`
+	SpecialUseArchive = `\Archive`
+)
+
+// SpecialUseFolders are created in every inbox
+var SpecialUseFolders = []struct {
+	Name       string
+	SpecialUse string
+}{
+	{"Sent", SpecialUseSent},
+	{"Trash", SpecialUseTrash},
+	{"Junk", SpecialUseJunk},
+	{"Archive", SpecialUseArchive},
+}
+
+type FolderService struct {
+	core *Core
+}
+
+func NewFolderService(core *Core) FolderService {
+	return FolderService{core: core}
+}
+
+// ValidateFolderName checks that a folder path has no empty levels, does not
+// clash with INBOX and contains no IMAP wildcards.
+func ValidateFolderName(name string) error {
+	invalid := func(reason string) error {
+		return &APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid folder name: " + reason,
+		}
+	}
+
+	if name == "" {
+		return invalid("name is empty")
+	}
+	if strings.EqualFold(name, "INBOX") {
+		return invalid("INBOX is reserved")
+	}
+	if strings.ContainsAny(name, "*%") {
+		return invalid("wildcards are not allowed")
+	}
+	for _, level := range strings.Split(name, FolderDelimiter) {
+		if level == "" {
+			return invalid("empty hierarchy level")
+		}
+	}
+	return nil
+}
+
+// FolderParents returns the paths of all ancestors of a folder, outermost first
+func FolderParents(name string) []string {
+	var parents []string
+	for i, r := range name {
+		if string(r) == FolderDelimiter {
+			parents = append(parents, name[:i])
+		}
+	}
+	return parents
+}
+
+func (s *FolderService) Create(ctx context.Context, folder *models.Folder) error {
+	s.core.Logger.Info("Creating folder %q in inbox %s", folder.Name, folder.InboxID)
+
+	if err := ValidateFolderName(folder.Name); err != nil {
+		return err
+	}
+
+	if err := s.core.Repository.CreateFolder(ctx, folder); err != nil {
+		s.core.Logger.Error("Failed to create folder: %v", err)
+		return err
+	}
+
+	s.core.Logger.Info("Successfully created folder with ID: %s", folder.ID)
+	return nil
+}
+
+// CreateWithParents creates a folder together with any missing parent folders
+func (s *FolderService) CreateWithParents(ctx context.Context, inboxID, name string) (*models.Folder, error) {
+	if err := ValidateFolderName(name); err != nil {
+		return nil, err
+	}
+
+	if err := s.CreateParents(ctx, inboxID, name); err != nil {
+		return nil, err
+	}
+
+	folder := &models.Folder{InboxID: inboxID, Name: name, Subscribed: true}
+	if err := s.Create(ctx, folder); err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+// CreateSpecialUse creates the special-use folders of a new inbox
+func (s *FolderService) CreateSpecialUse(ctx context.Context, inboxID string) error {
+	for _, f := range SpecialUseFolders {
+		folder := &models.Folder{
+			InboxID:    inboxID,
+			Name:       f.Name,
+			Subscribed: true,
+			SpecialUse: null.StringFrom(f.SpecialUse),
+		}
+		if err := s.Create(ctx, folder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateParents creates the missing parent folders of a folder path
+func (s *FolderService) CreateParents(ctx context.Context, inboxID, name string) error {
+	for _, parent := range FolderParents(name) {
+		if _, err := s.GetByName(ctx, inboxID, parent); err == nil {
+			continue
+		} else if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err := s.Create(ctx, &models.Folder{InboxID: inboxID, Name: parent, Subscribed: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FolderService) Get(ctx context.Context, id string) (*models.Folder, error) {
+	s.core.Logger.Debug("Fetching folder with ID: %s", id)
+
+	folder, err := s.core.Repository.GetFolder(ctx, id)
+	if err != nil {
+		s.core.Logger.Error("Failed to fetch folder: %v", err)
+		return nil, err
+	}
+
+	if folder == nil {
+		s.core.Logger.Info("Folder not found with ID: %s", id)
+		return nil, ErrNotFound
+	}
+
+	return folder, nil
+}
+
+// GetByName returns the folder with the given path, or ErrNotFound
+func (s *FolderService) GetByName(ctx context.Context, inboxID, name string) (*models.Folder, error) {
+	s.core.Logger.Debug("Fetching folder %q in inbox %s", name, inboxID)
+
+	folder, err := s.core.Repository.GetFolderByName(ctx, inboxID, name)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.core.Logger.Error("Failed to fetch folder: %v", err)
+		return nil, err
+	}
+
+	return folder, nil
+}
+
+// GetBySpecialUse returns the folder of an inbox with the given special-use attribute, or ErrNotFound
+func (s *FolderService) GetBySpecialUse(ctx context.Context, inboxID, specialUse string) (*models.Folder, error) {
+	s.core.Logger.Debug("Fetching %s folder in inbox %s", specialUse, inboxID)
+
+	folder, err := s.core.Repository.GetFolderBySpecialUse(ctx, inboxID, specialUse)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		s.core.Logger.Error("Failed to fetch folder: %v", err)
+		return nil, err
+	}
+
+	return folder, nil
+}
+
+// Update renames a folder, together with its subfolders, and updates its subscription
+func (s *FolderService) Update(ctx context.Context, folder *models.Folder) error {
+	s.core.Logger.Info("Updating folder with ID: %s", folder.ID)
+
+	if err := ValidateFolderName(folder.Name); err != nil {
+		return err
+	}
+
+	if err := s.core.Repository.UpdateFolder(ctx, folder); err != nil {
+		s.core.Logger.Error("Failed to update folder: %v", err)
+		return err
+	}
+
+	s.core.Logger.Info("Successfully updated folder with ID: %s", folder.ID)
+	return nil
+}
+
+// Delete removes a folder and the messages in it. Subfolders are kept.
+// Special-use folders cannot be deleted.
+func (s *FolderService) Delete(ctx context.Context, id string) error {
+	s.core.Logger.Info("Deleting folder with ID: %s", id)
+
+	folder, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if folder.SpecialUse.Valid {
+		return &APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Special-use folders cannot be deleted",
+		}
+	}
+
+	if err := s.core.Repository.DeleteFolder(ctx, id); err != nil {
+		s.core.Logger.Error("Failed to delete folder: %v", err)
+		return err
+	}
+
+	s.core.Logger.Info("Successfully deleted folder with ID: %s", id)
+	return nil
+}
+
+func (s *FolderService) ListByInbox(ctx context.Context, inboxID string, limit, offset int) (*models.PaginatedResponse, error) {
+	s.core.Logger.Info("Listing folders for inbox %s with limit: %d and offset: %d", inboxID, limit, offset)
+
+	folders, total, err := s.core.Repository.ListFoldersByInbox(ctx, inboxID, limit, offset)
+	if err != nil {
+		s.core.Logger.Error("Failed to list folders: %v", err)
+		return nil, err
+	}
+
+	response := &models.PaginatedResponse{
+		Data: folders,
+	}
+	response.Pagination.Total = total
+	response.Pagination.Limit = limit
+	response.Pagination.Offset = offset
+
+	s.core.Logger.Info("Successfully retrieved %d folders (total: %d)", len(folders), total)
+	return response, nil
+}
+
+// ListAllByInbox returns every folder of an inbox, ordered by name
+func (s *FolderService) ListAllByInbox(ctx context.Context, inboxID string) ([]*models.Folder, error) {
+	const batchSize = 100
+	var folders []*models.Folder
+
+	for offset := 0; ; offset += batchSize {
+		batch, total, err := s.core.Repository.ListFoldersByInbox(ctx, inboxID, batchSize, offset)
+		if err != nil {
+			s.core.Logger.Error("Failed to list folders: %v", err)
+			return nil, err
+		}
+		folders = append(folders, batch...)
+		if len(folders) >= total || len(batch) < batchSize {
+			return folders, nil
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"inbox451/internal/test"
+
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+	"inbox451/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	null "github.com/volatiletech/null/v9"
+)
+
+func setupFolderTestCore(t *testing.T) (*Core, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	logger := logger.New(io.Discard, logger.DEBUG)
+
+	core := &Core{
+		Logger:     logger,
+		Repository: mockRepo,
+	}
+	core.FolderService = NewFolderService(core)
+
+	return core, mockRepo
+}
+
+func TestValidateFolderName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "Archive", wantErr: false},
+		{name: "Archive/2024", wantErr: false},
+		{name: "", wantErr: true},
+		{name: "inbox", wantErr: true},
+		{name: "/Archive", wantErr: true},
+		{name: "Archive/", wantErr: true},
+		{name: "Archive//2024", wantErr: true},
+		{name: "Archive*", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFolderName(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFolderParents(t *testing.T) {
+	assert.Nil(t, FolderParents("Archive"))
+	assert.Equal(t, []string{"Archive", "Archive/2024"}, FolderParents("Archive/2024/Q1"))
+}
+
+func TestFolderService_CreateWithParents(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	tests := []struct {
+		name       string
+		folderName string
+		mockFn     func(*mocks.Repository)
+		wantErr    bool
+	}{
+		{
+			name:       "creates missing parents",
+			folderName: "Archive/2024/Q1",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetFolderByName", mock.Anything, testInboxID, "Archive").
+					Return(&models.Folder{InboxID: testInboxID, Name: "Archive"}, nil)
+				m.On("GetFolderByName", mock.Anything, testInboxID, "Archive/2024").
+					Return(nil, storage.ErrNotFound)
+				m.On("CreateFolder", mock.Anything, mock.MatchedBy(func(f *models.Folder) bool {
+					return f.Name == "Archive/2024" && f.Subscribed
+				})).Return(nil)
+				m.On("CreateFolder", mock.Anything, mock.MatchedBy(func(f *models.Folder) bool {
+					return f.Name == "Archive/2024/Q1" && f.Subscribed
+				})).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:       "invalid name",
+			folderName: "Archive//Q1",
+			mockFn:     func(m *mocks.Repository) {},
+			wantErr:    true,
+		},
+		{
+			name:       "repository error",
+			folderName: "Archive",
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateFolder", mock.Anything, mock.AnythingOfType("*models.Folder")).
+					Return(errors.New("database error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupFolderTestCore(t)
+			tt.mockFn(mockRepo)
+
+			folder, err := core.FolderService.CreateWithParents(context.Background(), testInboxID, tt.folderName)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.folderName, folder.Name)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFolderService_GetByName(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+
+	core, mockRepo := setupFolderTestCore(t)
+	mockRepo.On("GetFolderByName", mock.Anything, testInboxID, "Missing").Return(nil, storage.ErrNotFound)
+
+	folder, err := core.FolderService.GetByName(context.Background(), testInboxID, "Missing")
+
+	assert.Nil(t, folder)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFolderService_GetBySpecialUse(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+
+	core, mockRepo := setupFolderTestCore(t)
+	mockRepo.On("GetFolderBySpecialUse", mock.Anything, testInboxID, SpecialUseJunk).Return(nil, storage.ErrNotFound)
+
+	folder, err := core.FolderService.GetBySpecialUse(context.Background(), testInboxID, SpecialUseJunk)
+
+	assert.Nil(t, folder)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFolderService_Update(t *testing.T) {
+	testFolderID := test.RandomTestUUID()
+	tests := []struct {
+		name    string
+		folder  *models.Folder
+		mockFn  func(*mocks.Repository)
+		wantErr bool
+	}{
+		{
+			name:   "successful rename",
+			folder: &models.Folder{Base: models.Base{ID: testFolderID}, Name: "Old/Archive", Subscribed: true},
+			mockFn: func(m *mocks.Repository) {
+				m.On("UpdateFolder", mock.Anything, mock.AnythingOfType("*models.Folder")).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:    "reserved name",
+			folder:  &models.Folder{Base: models.Base{ID: testFolderID}, Name: "INBOX"},
+			mockFn:  func(m *mocks.Repository) {},
+			wantErr: true,
+		},
+		{
+			name:   "non-existent folder",
+			folder: &models.Folder{Base: models.Base{ID: testFolderID}, Name: "Archive"},
+			mockFn: func(m *mocks.Repository) {
+				m.On("UpdateFolder", mock.Anything, mock.AnythingOfType("*models.Folder")).Return(storage.ErrNoRowsAffected)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupFolderTestCore(t)
+			tt.mockFn(mockRepo)
+
+			err := core.FolderService.Update(context.Background(), tt.folder)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFolderService_Delete(t *testing.T) {
+	testFolderID := test.RandomTestUUID()
+	tests := []struct {
+		name    string
+		mockFn  func(*mocks.Repository)
+		wantErr bool
+	}{
+		{
+			name: "successful deletion",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetFolder", mock.Anything, testFolderID).
+					Return(&models.Folder{Base: models.Base{ID: testFolderID}, Name: "Archive/2024"}, nil)
+				m.On("DeleteFolder", mock.Anything, testFolderID).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "special-use folder",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetFolder", mock.Anything, testFolderID).
+					Return(&models.Folder{Base: models.Base{ID: testFolderID}, Name: "Trash", SpecialUse: null.StringFrom(SpecialUseTrash)}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-existent folder",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetFolder", mock.Anything, testFolderID).Return(nil, storage.ErrNotFound)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupFolderTestCore(t)
+			tt.mockFn(mockRepo)
+
+			err := core.FolderService.Delete(context.Background(), testFolderID)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
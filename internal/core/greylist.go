@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"inbox451/internal/models"
+)
+
+const (
+	defaultGreylistDelay  = 5 * time.Minute
+	defaultGreylistMaxAge = 35 * 24 * time.Hour
+)
+
+type GreylistService struct {
+	core *Core
+}
+
+func NewGreylistService(core *Core) GreylistService {
+	return GreylistService{core: core}
+}
+
+// Enabled reports whether deliveries are greylisted
+func (s *GreylistService) Enabled() bool {
+	return s.core.Config.Server.SMTP.MTA.Greylist.Enabled
+}
+
+// greylistTimes returns the configured delay and max_age, defaulted when unset.
+// A max_age of 0 would have every retry restart the delay, deferring the
+// sender forever.
+func (s *GreylistService) greylistTimes() (delay, maxAge time.Duration) {
+	cfg := s.core.Config.Server.SMTP.MTA.Greylist
+	delay, maxAge = cfg.Delay, cfg.MaxAge
+	if delay <= 0 {
+		delay = defaultGreylistDelay
+	}
+	if maxAge <= 0 {
+		maxAge = defaultGreylistMaxAge
+	}
+	return delay, maxAge
+}
+
+// greylistNetwork is what a client is greylisted by. Large senders retry from
+// other addresses of the same pool, so it is the /24 of an IPv4 address and
+// the /64 of an IPv6 one.
+func greylistNetwork(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{IP: ip4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
+
+// Check records an attempt of ip to deliver mail from sender to recipient and
+// reports whether it may go through, which it may once the triplet was first
+// seen at least the delay ago. It always may when greylisting is disabled.
+func (s *GreylistService) Check(ctx context.Context, ip net.IP, sender, recipient string) (bool, error) {
+	if !s.Enabled() {
+		return true, nil
+	}
+	delay, maxAge := s.greylistTimes()
+
+	triplet := &models.GreylistTriplet{
+		Network:   greylistNetwork(ip),
+		Sender:    strings.ToLower(sender),
+		Recipient: strings.ToLower(recipient),
+	}
+	if err := s.core.Repository.TouchGreylistTriplet(ctx, triplet, maxAge); err != nil {
+		s.core.Logger.Error("Failed to record greylist triplet: %v", err)
+		return false, err
+	}
+
+	// Both times come from the database, so the clocks of the replicas don't
+	// matter
+	passed := triplet.LastSeen.Time.Sub(triplet.FirstSeen.Time) >= delay
+	if !passed {
+		s.core.Logger.Debug("Greylisting %s from %s to %s", triplet.Network, triplet.Sender, triplet.Recipient)
+	}
+	return passed, nil
+}
+
+// Prune forgets the triplets unseen for longer than max_age
+func (s *GreylistService) Prune(ctx context.Context) error {
+	_, maxAge := s.greylistTimes()
+	deleted, err := s.core.Repository.DeleteExpiredGreylistTriplets(ctx, maxAge)
+	if err != nil {
+		s.core.Logger.Error("Failed to prune greylist: %v", err)
+		return err
+	}
+	if deleted > 0 {
+		s.core.Logger.Info("Pruned %d greylist triplets", deleted)
+	}
+	return nil
+}
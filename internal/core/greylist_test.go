@@ -0,0 +1,120 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"inbox451/internal/config"
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	null "github.com/volatiletech/null/v9"
+)
+
+func setupGreylistTestCore(t *testing.T) (*Core, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	logger := logger.New(io.Discard, logger.DEBUG)
+
+	core := &Core{
+		Config:     &config.Config{},
+		Logger:     logger,
+		Repository: mockRepo,
+	}
+	core.Config.Server.SMTP.MTA.Greylist = config.GreylistConfig{
+		Enabled: true,
+		Delay:   5 * time.Minute,
+		MaxAge:  840 * time.Hour,
+	}
+	core.GreylistService = NewGreylistService(core)
+
+	return core, mockRepo
+}
+
+func TestGreylistNetwork(t *testing.T) {
+	assert.Equal(t, "192.0.2.0/24", greylistNetwork(net.ParseIP("192.0.2.57")))
+	assert.Equal(t, "192.0.2.0/24", greylistNetwork(net.ParseIP("::ffff:192.0.2.57")))
+	assert.Equal(t, "2001:db8:1:2::/64", greylistNetwork(net.ParseIP("2001:db8:1:2:3:4:5:6")))
+}
+
+func TestGreylistService_Check(t *testing.T) {
+	ctx := context.Background()
+	firstSeen := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		lastSeen time.Time
+		err      error
+		want     bool
+		wantErr  bool
+	}{
+		{name: "first attempt", lastSeen: firstSeen, want: false},
+		{name: "retried too soon", lastSeen: firstSeen.Add(time.Minute), want: false},
+		{name: "retried after the delay", lastSeen: firstSeen.Add(5 * time.Minute), want: true},
+		{name: "database error", err: errors.New("database error"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupGreylistTestCore(t)
+
+			mockRepo.On("TouchGreylistTriplet", ctx, mock.MatchedBy(func(triplet *models.GreylistTriplet) bool {
+				return triplet.Network == "192.0.2.0/24" &&
+					triplet.Sender == "sender@example.org" &&
+					triplet.Recipient == "user@example.com"
+			}), 840*time.Hour).Run(func(args mock.Arguments) {
+				triplet := args.Get(1).(*models.GreylistTriplet)
+				triplet.FirstSeen = null.TimeFrom(firstSeen)
+				triplet.LastSeen = null.TimeFrom(tt.lastSeen)
+			}).Return(tt.err).Once()
+
+			passed, err := core.GreylistService.Check(ctx, net.ParseIP("192.0.2.57"), "Sender@example.org", "user@example.com")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, passed)
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		core, _ := setupGreylistTestCore(t)
+		core.Config.Server.SMTP.MTA.Greylist.Enabled = false
+
+		passed, err := core.GreylistService.Check(ctx, net.ParseIP("192.0.2.57"), "sender@example.org", "user@example.com")
+		require.NoError(t, err)
+		assert.True(t, passed)
+	})
+
+	t.Run("unset times are defaulted", func(t *testing.T) {
+		core, mockRepo := setupGreylistTestCore(t)
+		core.Config.Server.SMTP.MTA.Greylist.Delay = 0
+		core.Config.Server.SMTP.MTA.Greylist.MaxAge = 0
+
+		mockRepo.On("TouchGreylistTriplet", ctx, mock.Anything, defaultGreylistMaxAge).Run(func(args mock.Arguments) {
+			triplet := args.Get(1).(*models.GreylistTriplet)
+			triplet.FirstSeen = null.TimeFrom(firstSeen)
+			triplet.LastSeen = null.TimeFrom(firstSeen.Add(time.Minute))
+		}).Return(nil).Once()
+
+		passed, err := core.GreylistService.Check(ctx, net.ParseIP("192.0.2.57"), "sender@example.org", "user@example.com")
+		require.NoError(t, err)
+		assert.False(t, passed)
+	})
+}
+
+func TestGreylistService_Prune(t *testing.T) {
+	ctx := context.Background()
+	core, mockRepo := setupGreylistTestCore(t)
+
+	mockRepo.On("DeleteExpiredGreylistTriplets", ctx, 840*time.Hour).Return(int64(2), nil).Once()
+	assert.NoError(t, core.GreylistService.Prune(ctx))
+}
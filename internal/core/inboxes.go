@@ -45,6 +45,11 @@ func (s *InboxService) Create(ctx context.Context, inbox *models.Inbox) error {
 		return err
 	}
 
+	if err := s.core.FolderService.CreateSpecialUse(ctx, inbox.ID); err != nil {
+		s.core.Logger.Error("Failed to create special-use folders: %v", err)
+		return err
+	}
+
 	s.core.Logger.Info("Successfully created inbox with ID: %s", inbox.ID)
 	return nil
 }
@@ -142,6 +147,36 @@ func (s *InboxService) ListByUser(ctx context.Context, userID string) ([]*models
 	return inboxes, nil
 }
 
+// DefaultForUser returns the inbox the user sees as INBOX over IMAP: the
+// configured default inbox, or the first inbox of the user when none is set
+func (s *InboxService) DefaultForUser(ctx context.Context, user *models.User) (*models.Inbox, error) {
+	inboxes, err := s.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(inboxes) == 0 {
+		return nil, ErrNotFound
+	}
+
+	if inbox := findInbox(inboxes, user.DefaultInboxID.String); inbox != nil {
+		return inbox, nil
+	}
+	return inboxes[0], nil
+}
+
+// findInbox returns the inbox with the given ID, or nil
+func findInbox(inboxes []*models.Inbox, id string) *models.Inbox {
+	if id == "" {
+		return nil
+	}
+	for _, inbox := range inboxes {
+		if inbox.ID == id {
+			return inbox
+		}
+	}
+	return nil
+}
+
 func (s *InboxService) GetByEmailAndUser(ctx context.Context, email string, userID string) (*models.Inbox, error) {
 	s.core.Logger.Debug("Fetching inbox with email %s for user %s", email, userID)
 
@@ -31,6 +31,7 @@ func setupInboxTestCore(t *testing.T) (*Core, *mocks.Repository) {
 		Repository: mockRepo,
 	}
 	core.InboxService = NewInboxService(core)
+	core.FolderService = NewFolderService(core)
 
 	return core, mockRepo
 }
@@ -57,9 +58,26 @@ func TestInboxService_Create(t *testing.T) {
 			mockFn: func(m *mocks.Repository) {
 				m.On("CreateInbox", mock.Anything, mock.AnythingOfType("*models.Inbox")).
 					Return(nil)
+				m.On("CreateFolder", mock.Anything, mock.MatchedBy(func(folder *models.Folder) bool {
+					return folder.SpecialUse.Valid && folder.Subscribed
+				})).Return(nil).Times(len(SpecialUseFolders))
 			},
 			wantErr: false,
 		},
+		{
+			name: "special-use folder error",
+			inbox: &models.Inbox{
+				ProjectID: test.StaticTestUUID(),
+				Email:     "test@example.com",
+			},
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateInbox", mock.Anything, mock.AnythingOfType("*models.Inbox")).
+					Return(nil)
+				m.On("CreateFolder", mock.Anything, mock.AnythingOfType("*models.Folder")).
+					Return(errors.New("database error")).Once()
+			},
+			wantErr: true,
+		},
 		{
 			name: "repository error",
 			inbox: &models.Inbox{
@@ -389,6 +407,7 @@ func TestInboxService_Create_WithEmailDomain(t *testing.T) {
 				m.On("CreateInbox", mock.Anything, mock.MatchedBy(func(inbox *models.Inbox) bool {
 					return inbox.Email == "testinbox@example.com"
 				})).Return(nil)
+				m.On("CreateFolder", mock.Anything, mock.AnythingOfType("*models.Folder")).Return(nil)
 			},
 			wantEmail: "testinbox@example.com",
 			wantErr:   false,
@@ -404,6 +423,7 @@ func TestInboxService_Create_WithEmailDomain(t *testing.T) {
 				m.On("CreateInbox", mock.Anything, mock.MatchedBy(func(inbox *models.Inbox) bool {
 					return inbox.Email == "test@example.com"
 				})).Return(nil)
+				m.On("CreateFolder", mock.Anything, mock.AnythingOfType("*models.Folder")).Return(nil)
 			},
 			wantEmail: "test@example.com",
 			wantErr:   false,
@@ -430,6 +450,7 @@ func TestInboxService_Create_WithEmailDomain(t *testing.T) {
 				m.On("CreateInbox", mock.Anything, mock.MatchedBy(func(inbox *models.Inbox) bool {
 					return inbox.Email == "test@anydomain.com"
 				})).Return(nil)
+				m.On("CreateFolder", mock.Anything, mock.AnythingOfType("*models.Folder")).Return(nil)
 			},
 			wantEmail: "test@anydomain.com",
 			wantErr:   false,
@@ -445,6 +466,7 @@ func TestInboxService_Create_WithEmailDomain(t *testing.T) {
 				m.On("CreateInbox", mock.Anything, mock.MatchedBy(func(inbox *models.Inbox) bool {
 					return inbox.Email == "user+tag@sub.example.com"
 				})).Return(nil)
+				m.On("CreateFolder", mock.Anything, mock.AnythingOfType("*models.Folder")).Return(nil)
 			},
 			wantEmail: "user+tag@sub.example.com",
 			wantErr:   false,
@@ -670,6 +692,42 @@ func TestInboxService_ListByUser(t *testing.T) {
 	}
 }
 
+func TestInboxService_DefaultForUser(t *testing.T) {
+	testUserID := test.RandomTestUUID()
+	first := &models.Inbox{Base: models.Base{ID: test.RandomTestUUID()}, Email: "first@example.com"}
+	second := &models.Inbox{Base: models.Base{ID: test.RandomTestUUID()}, Email: "second@example.com"}
+	tests := []struct {
+		name           string
+		defaultInboxID null.String
+		inboxes        []*models.Inbox
+		want           *models.Inbox
+		wantErr        error
+	}{
+		{name: "configured default", defaultInboxID: null.StringFrom(second.ID), inboxes: []*models.Inbox{first, second}, want: second},
+		{name: "first inbox when not configured", inboxes: []*models.Inbox{first, second}, want: first},
+		{name: "first inbox when the default is gone", defaultInboxID: null.StringFrom(test.RandomTestUUID()), inboxes: []*models.Inbox{first}, want: first},
+		{name: "no inboxes", inboxes: []*models.Inbox{}, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupInboxTestCore(t)
+			mockRepo.On("ListInboxesByUser", mock.Anything, testUserID).Return(tt.inboxes, nil)
+
+			user := &models.User{Base: models.Base{ID: testUserID}, DefaultInboxID: tt.defaultInboxID}
+			got, err := core.InboxService.DefaultForUser(context.Background(), user)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestInboxService_GetByEmailAndUser(t *testing.T) {
 	now := time.Now()
 	testUserID1 := test.RandomTestUUID()
@@ -0,0 +1,270 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"inbox451/internal/models"
+	"inbox451/internal/storage"
+)
+
+// DefaultLabelColor is used when a label is created without a color,
+// e.g. when it originates from an IMAP keyword.
+const DefaultLabelColor = "#808080"
+
+type LabelService struct {
+	core *Core
+}
+
+func NewLabelService(core *Core) LabelService {
+	return LabelService{core: core}
+}
+
+func (s *LabelService) Create(ctx context.Context, label *models.Label) error {
+	s.core.Logger.Info("Creating new label %q for project %s", label.Name, label.ProjectID)
+
+	if label.Color == "" {
+		label.Color = DefaultLabelColor
+	}
+
+	if err := s.core.Repository.CreateLabel(ctx, label); err != nil {
+		s.core.Logger.Error("Failed to create label: %v", err)
+		return err
+	}
+
+	s.core.Logger.Info("Successfully created label with ID: %s", label.ID)
+	return nil
+}
+
+func (s *LabelService) Get(ctx context.Context, id string) (*models.Label, error) {
+	s.core.Logger.Debug("Fetching label with ID: %s", id)
+
+	label, err := s.core.Repository.GetLabel(ctx, id)
+	if err != nil {
+		s.core.Logger.Error("Failed to fetch label: %v", err)
+		return nil, err
+	}
+
+	if label == nil {
+		s.core.Logger.Info("Label not found with ID: %s", id)
+		return nil, ErrNotFound
+	}
+
+	return label, nil
+}
+
+func (s *LabelService) Update(ctx context.Context, label *models.Label) error {
+	s.core.Logger.Info("Updating label with ID: %s", label.ID)
+
+	if label.Color == "" {
+		label.Color = DefaultLabelColor
+	}
+
+	if err := s.core.Repository.UpdateLabel(ctx, label); err != nil {
+		s.core.Logger.Error("Failed to update label: %v", err)
+		return err
+	}
+
+	s.core.Logger.Info("Successfully updated label with ID: %s", label.ID)
+	return nil
+}
+
+func (s *LabelService) Delete(ctx context.Context, id string) error {
+	s.core.Logger.Info("Deleting label with ID: %s", id)
+
+	if err := s.core.Repository.DeleteLabel(ctx, id); err != nil {
+		s.core.Logger.Error("Failed to delete label: %v", err)
+		return err
+	}
+
+	s.core.Logger.Info("Successfully deleted label with ID: %s", id)
+	return nil
+}
+
+func (s *LabelService) ListByProject(ctx context.Context, projectID string, limit, offset int) (*models.PaginatedResponse, error) {
+	s.core.Logger.Info("Listing labels for project %s with limit: %d and offset: %d", projectID, limit, offset)
+
+	labels, total, err := s.core.Repository.ListLabelsByProject(ctx, projectID, limit, offset)
+	if err != nil {
+		s.core.Logger.Error("Failed to list labels: %v", err)
+		return nil, err
+	}
+
+	response := &models.PaginatedResponse{
+		Data: labels,
+	}
+	response.Pagination.Total = total
+	response.Pagination.Limit = limit
+	response.Pagination.Offset = offset
+
+	s.core.Logger.Info("Successfully retrieved %d labels (total: %d)", len(labels), total)
+	return response, nil
+}
+
+// GetOrCreateByName returns the project label with the given name, creating it
+// when it does not exist yet.
+func (s *LabelService) GetOrCreateByName(ctx context.Context, projectID, name string) (*models.Label, error) {
+	label, err := s.core.Repository.GetLabelByName(ctx, projectID, name)
+	if err == nil {
+		return label, nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		s.core.Logger.Error("Failed to fetch label %q: %v", name, err)
+		return nil, err
+	}
+
+	label = &models.Label{ProjectID: projectID, Name: name}
+	if err := s.Create(ctx, label); err != nil {
+		return nil, err
+	}
+	return label, nil
+}
+
+// ListAllByProject returns all labels of a project
+func (s *LabelService) ListAllByProject(ctx context.Context, projectID string) ([]*models.Label, error) {
+	const batchSize = 100
+	var labels []*models.Label
+
+	for offset := 0; ; offset += batchSize {
+		batch, total, err := s.core.Repository.ListLabelsByProject(ctx, projectID, batchSize, offset)
+		if err != nil {
+			s.core.Logger.Error("Failed to list labels for project %s: %v", projectID, err)
+			return nil, err
+		}
+		labels = append(labels, batch...)
+		if len(labels) >= total || len(batch) < batchSize {
+			return labels, nil
+		}
+	}
+}
+
+// ResolveKeywords returns the project labels of the given keywords. Keywords are
+// compared case-insensitively with the keywords of the labels; unknown keywords
+// are created as labels when create is set and left out otherwise.
+func (s *LabelService) ResolveKeywords(ctx context.Context, projectID string, keywords []string, create bool) ([]*models.Label, error) {
+	if len(keywords) == 0 {
+		return nil, nil
+	}
+
+	labels, err := s.ListAllByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	byKeyword := make(map[string]*models.Label, len(labels))
+	for _, label := range labels {
+		byKeyword[strings.ToLower(LabelKeyword(label.Name))] = label
+	}
+
+	var result []*models.Label
+	for _, keyword := range keywords {
+		label, ok := byKeyword[strings.ToLower(keyword)]
+		if !ok {
+			if !create {
+				continue
+			}
+			label, err = s.GetOrCreateByName(ctx, projectID, keyword)
+			if err != nil {
+				return nil, err
+			}
+			byKeyword[strings.ToLower(keyword)] = label
+		}
+		result = append(result, label)
+	}
+	return result, nil
+}
+
+// AddToMessage assigns a label to a message. The label must belong to the given project.
+func (s *LabelService) AddToMessage(ctx context.Context, projectID, messageID, labelID string) error {
+	s.core.Logger.Debug("Adding label %s to message %s", labelID, messageID)
+
+	if err := s.checkProject(ctx, projectID, labelID); err != nil {
+		return err
+	}
+
+	if err := s.core.Repository.AddMessageLabel(ctx, messageID, labelID); err != nil {
+		s.core.Logger.Error("Failed to add label to message: %v", err)
+		return err
+	}
+
+	s.core.Logger.Info("Successfully added label %s to message %s", labelID, messageID)
+	return nil
+}
+
+// RemoveFromMessage unassigns a label from a message.
+func (s *LabelService) RemoveFromMessage(ctx context.Context, projectID, messageID, labelID string) error {
+	s.core.Logger.Debug("Removing label %s from message %s", labelID, messageID)
+
+	if err := s.checkProject(ctx, projectID, labelID); err != nil {
+		return err
+	}
+
+	if err := s.core.Repository.RemoveMessageLabel(ctx, messageID, labelID); err != nil {
+		s.core.Logger.Error("Failed to remove label from message: %v", err)
+		return err
+	}
+
+	s.core.Logger.Info("Successfully removed label %s from message %s", labelID, messageID)
+	return nil
+}
+
+// AttachToMessages loads the labels of all given messages with a single query
+// and stores them in each message's Labels field.
+func (s *LabelService) AttachToMessages(ctx context.Context, messages []*models.Message) error {
+	ids := make([]string, 0, len(messages))
+	byID := make(map[string]*models.Message, len(messages))
+	for _, message := range messages {
+		message.Labels = []*models.Label{}
+		ids = append(ids, message.ID)
+		byID[message.ID] = message
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	labels, err := s.core.Repository.ListLabelsByMessages(ctx, ids)
+	if err != nil {
+		s.core.Logger.Error("Failed to load message labels: %v", err)
+		return err
+	}
+
+	for _, ml := range labels {
+		if message, ok := byID[ml.MessageID]; ok {
+			label := ml.Label
+			message.Labels = append(message.Labels, &label)
+		}
+	}
+	return nil
+}
+
+func (s *LabelService) checkProject(ctx context.Context, projectID, labelID string) error {
+	if projectID == "" {
+		return nil
+	}
+
+	label, err := s.Get(ctx, labelID)
+	if err != nil {
+		return err
+	}
+
+	if label.ProjectID != projectID {
+		return &APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Label does not belong to this project",
+		}
+	}
+	return nil
+}
+
+// LabelKeyword returns the IMAP keyword for a label name. Keywords are atoms,
+// so characters that are not allowed in an atom are replaced by underscores.
+func LabelKeyword(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune(`(){%*"\]`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,287 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"inbox451/internal/test"
+
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+	"inbox451/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupLabelTestCore(t *testing.T) (*Core, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	logger := logger.New(io.Discard, logger.DEBUG)
+
+	core := &Core{
+		Logger:     logger,
+		Repository: mockRepo,
+	}
+	core.LabelService = NewLabelService(core)
+
+	return core, mockRepo
+}
+
+func TestLabelService_Create(t *testing.T) {
+	testProjectID := test.RandomTestUUID()
+	tests := []struct {
+		name      string
+		label     *models.Label
+		mockFn    func(*mocks.Repository)
+		wantColor string
+		wantErr   bool
+	}{
+		{
+			name:  "default color",
+			label: &models.Label{ProjectID: testProjectID, Name: "Important"},
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateLabel", mock.Anything, mock.AnythingOfType("*models.Label")).Return(nil)
+			},
+			wantColor: DefaultLabelColor,
+		},
+		{
+			name:  "custom color",
+			label: &models.Label{ProjectID: testProjectID, Name: "Important", Color: "#ff0000"},
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateLabel", mock.Anything, mock.AnythingOfType("*models.Label")).Return(nil)
+			},
+			wantColor: "#ff0000",
+		},
+		{
+			name:  "repository error",
+			label: &models.Label{ProjectID: testProjectID, Name: "Important"},
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateLabel", mock.Anything, mock.AnythingOfType("*models.Label")).
+					Return(errors.New("database error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupLabelTestCore(t)
+			tt.mockFn(mockRepo)
+
+			err := core.LabelService.Create(context.Background(), tt.label)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantColor, tt.label.Color)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLabelService_GetOrCreateByName(t *testing.T) {
+	testProjectID := test.RandomTestUUID()
+	testLabelID := test.RandomTestUUID()
+	tests := []struct {
+		name    string
+		mockFn  func(*mocks.Repository)
+		wantErr bool
+	}{
+		{
+			name: "existing label",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetLabelByName", mock.Anything, testProjectID, "Important").
+					Return(&models.Label{Base: models.Base{ID: testLabelID}, ProjectID: testProjectID, Name: "Important"}, nil)
+			},
+		},
+		{
+			name: "missing label is created",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetLabelByName", mock.Anything, testProjectID, "Important").
+					Return(nil, storage.ErrNotFound)
+				m.On("CreateLabel", mock.Anything, mock.AnythingOfType("*models.Label")).
+					Run(func(args mock.Arguments) {
+						args.Get(1).(*models.Label).ID = testLabelID
+					}).
+					Return(nil)
+			},
+		},
+		{
+			name: "repository error",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetLabelByName", mock.Anything, testProjectID, "Important").
+					Return(nil, errors.New("database error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupLabelTestCore(t)
+			tt.mockFn(mockRepo)
+
+			got, err := core.LabelService.GetOrCreateByName(context.Background(), testProjectID, "Important")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testLabelID, got.ID)
+				assert.Equal(t, "Important", got.Name)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLabelService_ResolveKeywords(t *testing.T) {
+	testProjectID := test.RandomTestUUID()
+	existing := &models.Label{Base: models.Base{ID: test.RandomTestUUID()}, ProjectID: testProjectID, Name: "Needs Review"}
+
+	tests := []struct {
+		name     string
+		create   bool
+		mockFn   func(*mocks.Repository)
+		wantName []string
+	}{
+		{
+			name:     "unknown keywords are skipped",
+			mockFn:   func(m *mocks.Repository) {},
+			wantName: []string{"Needs Review"},
+		},
+		{
+			name:   "unknown keywords are created",
+			create: true,
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetLabelByName", mock.Anything, testProjectID, "urgent").Return(nil, storage.ErrNotFound)
+				m.On("CreateLabel", mock.Anything, mock.AnythingOfType("*models.Label")).Return(nil)
+			},
+			wantName: []string{"Needs Review", "urgent"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupLabelTestCore(t)
+			mockRepo.On("ListLabelsByProject", mock.Anything, testProjectID, 100, 0).
+				Return([]*models.Label{existing}, 1, nil)
+			tt.mockFn(mockRepo)
+
+			// Keywords match the keyword form of label names case-insensitively
+			got, err := core.LabelService.ResolveKeywords(context.Background(), testProjectID, []string{"needs_review", "urgent"}, tt.create)
+
+			assert.NoError(t, err)
+			var names []string
+			for _, label := range got {
+				names = append(names, label.Name)
+			}
+			assert.Equal(t, tt.wantName, names)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLabelService_AddToMessage(t *testing.T) {
+	testProjectID := test.RandomTestUUID()
+	otherProjectID := test.RandomTestUUID()
+	testMessageID := test.RandomTestUUID()
+	testLabelID := test.RandomTestUUID()
+	tests := []struct {
+		name      string
+		projectID string
+		mockFn    func(*mocks.Repository)
+		wantErr   bool
+	}{
+		{
+			name:      "label of the same project",
+			projectID: testProjectID,
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetLabel", mock.Anything, testLabelID).
+					Return(&models.Label{Base: models.Base{ID: testLabelID}, ProjectID: testProjectID}, nil)
+				m.On("AddMessageLabel", mock.Anything, testMessageID, testLabelID).Return(nil)
+			},
+		},
+		{
+			name:      "label of another project",
+			projectID: otherProjectID,
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetLabel", mock.Anything, testLabelID).
+					Return(&models.Label{Base: models.Base{ID: testLabelID}, ProjectID: testProjectID}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:      "without project check",
+			projectID: "",
+			mockFn: func(m *mocks.Repository) {
+				m.On("AddMessageLabel", mock.Anything, testMessageID, testLabelID).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupLabelTestCore(t)
+			tt.mockFn(mockRepo)
+
+			err := core.LabelService.AddToMessage(context.Background(), tt.projectID, testMessageID, testLabelID)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLabelService_AttachToMessages(t *testing.T) {
+	testMessageID1 := test.RandomTestUUID()
+	testMessageID2 := test.RandomTestUUID()
+	testLabelID := test.RandomTestUUID()
+
+	core, mockRepo := setupLabelTestCore(t)
+	mockRepo.On("ListLabelsByMessages", mock.Anything, []string{testMessageID1, testMessageID2}).
+		Return([]*models.MessageLabel{
+			{Label: models.Label{Base: models.Base{ID: testLabelID}, Name: "Important"}, MessageID: testMessageID2},
+		}, nil)
+
+	messages := []*models.Message{
+		{Base: models.Base{ID: testMessageID1}},
+		{Base: models.Base{ID: testMessageID2}},
+	}
+	err := core.LabelService.AttachToMessages(context.Background(), messages)
+
+	assert.NoError(t, err)
+	assert.Empty(t, messages[0].Labels)
+	assert.NotNil(t, messages[0].Labels)
+	if assert.Len(t, messages[1].Labels, 1) {
+		assert.Equal(t, "Important", messages[1].Labels[0].Name)
+	}
+}
+
+func TestLabelKeyword(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Important", want: "Important"},
+		{name: "To do", want: "To_do"},
+		{name: "(urgent)", want: "_urgent_"},
+		{name: "50%", want: "50_"},
+		{name: "Ärger", want: "_rger"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, LabelKeyword(tt.name))
+		})
+	}
+}
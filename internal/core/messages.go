@@ -1,9 +1,18 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
 
 	"inbox451/internal/models"
+
+	"github.com/emersion/go-message"
+	null "github.com/volatiletech/null/v9"
 )
 
 type MessageService struct {
@@ -17,6 +26,15 @@ func NewMessageService(core *Core) MessageService {
 func (s *MessageService) Store(ctx context.Context, message *models.Message) error {
 	s.core.Logger.Info("Storing new message for inbox %s from %s", message.InboxID, message.Sender)
 
+	message.Size = int64(len(message.Raw))
+	if message.Size == 0 {
+		message.Size = int64(len(message.Body))
+	}
+	if err := s.core.QuotaService.Check(ctx, message.InboxID, message.Size); err != nil {
+		return err
+	}
+
+	s.assignThread(ctx, message)
 	if err := s.core.Repository.CreateMessage(ctx, message); err != nil {
 		s.core.Logger.Error("Failed to store message: %v", err)
 		return err
@@ -26,6 +44,22 @@ func (s *MessageService) Store(ctx context.Context, message *models.Message) err
 	return nil
 }
 
+// StoreInSpecialUse stores a message in the special-use folder of its inbox.
+// Inboxes without such a folder get the message in the inbox itself.
+func (s *MessageService) StoreInSpecialUse(ctx context.Context, message *models.Message, specialUse string) error {
+	folder, err := s.core.FolderService.GetBySpecialUse(ctx, message.InboxID, specialUse)
+	switch {
+	case err == nil:
+		message.FolderID = null.StringFrom(folder.ID)
+	case errors.Is(err, ErrNotFound):
+		s.core.Logger.Info("Inbox %s has no %s folder, storing message in the inbox", message.InboxID, specialUse)
+	default:
+		return err
+	}
+
+	return s.Store(ctx, message)
+}
+
 func (s *MessageService) Get(ctx context.Context, id string) (*models.Message, error) {
 	s.core.Logger.Debug("Fetching message with ID: %s", id)
 
@@ -40,9 +74,73 @@ func (s *MessageService) Get(ctx context.Context, id string) (*models.Message, e
 		return nil, ErrNotFound
 	}
 
+	if err := s.core.LabelService.AttachToMessages(ctx, []*models.Message{message}); err != nil {
+		return nil, err
+	}
+
 	return message, nil
 }
 
+// GetMany returns the messages with the given IDs together with their labels.
+// Unknown IDs are left out.
+func (s *MessageService) GetMany(ctx context.Context, ids []string) ([]*models.Message, error) {
+	messages, err := s.core.Repository.ListMessagesByIDs(ctx, ids)
+	if err != nil {
+		s.core.Logger.Error("Failed to fetch messages: %v", err)
+		return nil, err
+	}
+
+	if err := s.core.LabelService.AttachToMessages(ctx, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// HighestModSeq returns the mod-sequence of the last change to the messages of
+// the given inboxes, 0 when they never had any
+func (s *MessageService) HighestModSeq(ctx context.Context, inboxIDs []string) (uint64, error) {
+	if len(inboxIDs) == 0 {
+		return 0, nil
+	}
+
+	modSeq, err := s.core.Repository.GetHighestModSeqForInboxes(ctx, inboxIDs)
+	if err != nil {
+		s.core.Logger.Error("Failed to fetch highest mod-sequence: %v", err)
+		return 0, err
+	}
+	return modSeq, nil
+}
+
+// ListChanges returns up to limit messages of the given inboxes that were
+// created, updated or removed after the given mod-sequence, oldest change first
+func (s *MessageService) ListChanges(ctx context.Context, inboxIDs []string, sinceModSeq uint64, limit int) ([]*models.MessageChange, error) {
+	if len(inboxIDs) == 0 {
+		return []*models.MessageChange{}, nil
+	}
+
+	changes, err := s.core.Repository.ListMessageChanges(ctx, inboxIDs, sinceModSeq, limit)
+	if err != nil {
+		s.core.Logger.Error("Failed to list message changes: %v", err)
+		return nil, err
+	}
+	return changes, nil
+}
+
+// CountByMailbox returns the number of messages in each of the given inboxes and
+// their folders. Empty mailboxes are left out.
+func (s *MessageService) CountByMailbox(ctx context.Context, inboxIDs []string) ([]*models.MailboxCount, error) {
+	if len(inboxIDs) == 0 {
+		return []*models.MailboxCount{}, nil
+	}
+
+	counts, err := s.core.Repository.CountMessagesByMailbox(ctx, inboxIDs)
+	if err != nil {
+		s.core.Logger.Error("Failed to count messages: %v", err)
+		return nil, err
+	}
+	return counts, nil
+}
+
 func (s *MessageService) ListByInbox(ctx context.Context, inboxID string, limit, offset int, filters models.MessageFilters) (*models.PaginatedResponse, error) {
 	s.core.Logger.Info("Listing messages for inbox %s with limit: %d, offset: %d, filters: %+v",
 		inboxID, limit, offset, filters)
@@ -53,6 +151,10 @@ func (s *MessageService) ListByInbox(ctx context.Context, inboxID string, limit,
 		return nil, err
 	}
 
+	if err := s.core.LabelService.AttachToMessages(ctx, messages); err != nil {
+		return nil, err
+	}
+
 	response := &models.PaginatedResponse{
 		Data: messages,
 	}
@@ -64,6 +166,26 @@ func (s *MessageService) ListByInbox(ctx context.Context, inboxID string, limit,
 	return response, nil
 }
 
+// ListAll returns all messages of an inbox matching the filters, in UID order,
+// loading them in batches
+func (s *MessageService) ListAll(ctx context.Context, inboxID string, filters models.MessageFilters) ([]*models.Message, error) {
+	const batchSize = 100
+
+	var all []*models.Message
+	for offset := 0; ; offset += batchSize {
+		messages, total, err := s.core.Repository.ListMessagesByInboxWithFilters(ctx, inboxID, filters, batchSize, offset)
+		if err != nil {
+			s.core.Logger.Error("Failed to list messages: %v", err)
+			return nil, err
+		}
+		all = append(all, messages...)
+		if len(messages) == 0 || offset+len(messages) >= total {
+			break
+		}
+	}
+	return all, nil
+}
+
 func (s *MessageService) MarkAsRead(ctx context.Context, messageID string) error {
 	s.core.Logger.Debug("Marking message %s as read", messageID)
 
@@ -112,6 +234,89 @@ func (s *MessageService) MarkAsUndeleted(ctx context.Context, messageID string)
 	return nil
 }
 
+// UpdateFlags applies a flag update to the messages with the given UIDs of an inbox folder at once
+func (s *MessageService) UpdateFlags(ctx context.Context, inboxID, folderID string, uids []uint32, op models.FlagOperation, flags models.MessageFlags) error {
+	s.core.Logger.Debug("Updating flags of %d messages in inbox %s", len(uids), inboxID)
+
+	if err := s.core.Repository.UpdateMessageFlags(ctx, inboxID, folderID, uids, op, flags); err != nil {
+		s.core.Logger.Error("Failed to update message flags: %v", err)
+		return err
+	}
+
+	s.core.Logger.Info("Successfully updated flags of %d messages in inbox %s", len(uids), inboxID)
+	return nil
+}
+
+// Copy copies messages into another inbox or folder and returns the UIDs of the copies.
+// It returns ErrQuotaExceeded when the copies don't fit in the quotas of the destination inbox.
+func (s *MessageService) Copy(ctx context.Context, inboxID, folderID string, uids []uint32, destInboxID, destFolderID string) ([]uint32, error) {
+	s.core.Logger.Debug("Copying %d messages from inbox %s to inbox %s", len(uids), inboxID, destInboxID)
+
+	messages, err := s.core.Repository.GetMessagesByUIDs(ctx, inboxID, folderID, uids)
+	if err != nil {
+		s.core.Logger.Error("Failed to fetch messages to copy: %v", err)
+		return nil, err
+	}
+	if err := s.core.QuotaService.CheckCopy(ctx, destInboxID, messages); err != nil {
+		return nil, err
+	}
+
+	destUIDs, err := s.core.Repository.CopyMessages(ctx, inboxID, folderID, uids, destInboxID, destFolderID)
+	if err != nil {
+		s.core.Logger.Error("Failed to copy messages: %v", err)
+		return nil, err
+	}
+
+	s.core.Logger.Info("Successfully copied %d messages to inbox %s", len(destUIDs), destInboxID)
+	return destUIDs, nil
+}
+
+// Move moves messages into another inbox or folder and returns their new UIDs.
+// It returns ErrQuotaExceeded when the destination is another inbox the messages don't fit in.
+func (s *MessageService) Move(ctx context.Context, inboxID, folderID string, uids []uint32, destInboxID, destFolderID string) ([]uint32, error) {
+	s.core.Logger.Debug("Moving %d messages from inbox %s to inbox %s", len(uids), inboxID, destInboxID)
+
+	// Moving within an inbox doesn't change its usage
+	if destInboxID != inboxID {
+		messages, err := s.core.Repository.GetMessagesByUIDs(ctx, inboxID, folderID, uids)
+		if err != nil {
+			s.core.Logger.Error("Failed to fetch messages to move: %v", err)
+			return nil, err
+		}
+		if err := s.core.QuotaService.CheckMove(ctx, inboxID, destInboxID, messages); err != nil {
+			return nil, err
+		}
+	}
+
+	destUIDs, err := s.core.Repository.MoveMessages(ctx, inboxID, folderID, uids, destInboxID, destFolderID)
+	if err != nil {
+		s.core.Logger.Error("Failed to move messages: %v", err)
+		return nil, err
+	}
+
+	s.core.Logger.Info("Successfully moved %d messages to inbox %s", len(destUIDs), destInboxID)
+	return destUIDs, nil
+}
+
+// Trash moves messages into the Trash folder of their inbox and clears their \Deleted flag there.
+// It returns ErrNotFound when the inbox has no Trash folder.
+func (s *MessageService) Trash(ctx context.Context, inboxID, folderID string, uids []uint32) error {
+	trash, err := s.core.FolderService.GetBySpecialUse(ctx, inboxID, SpecialUseTrash)
+	if err != nil {
+		return err
+	}
+
+	destUIDs, err := s.Move(ctx, inboxID, folderID, uids, inboxID, trash.ID)
+	if err != nil {
+		return err
+	}
+	if len(destUIDs) == 0 {
+		return nil
+	}
+
+	return s.UpdateFlags(ctx, inboxID, trash.ID, destUIDs, models.FlagsRemove, models.MessageFlags{Deleted: true})
+}
+
 func (s *MessageService) Delete(ctx context.Context, messageID string) error {
 	s.core.Logger.Debug("Deleting message with ID: %s", messageID)
 
@@ -123,3 +328,88 @@ func (s *MessageService) Delete(ctx context.Context, messageID string) error {
 	s.core.Logger.Info("Successfully deleted message with ID: %s", messageID)
 	return nil
 }
+
+// AttachRaw loads the raw RFC 822 form of all given messages with a single query
+// and stores it in each message's Raw field. Messages stored without it keep a
+// nil Raw.
+func (s *MessageService) AttachRaw(ctx context.Context, messages []*models.Message) error {
+	ids := make([]string, 0, len(messages))
+	byID := make(map[string]*models.Message, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+		byID[message.ID] = message
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	raws, err := s.core.Repository.ListRawMessages(ctx, ids)
+	if err != nil {
+		s.core.Logger.Error("Failed to load raw messages: %v", err)
+		return err
+	}
+
+	for _, raw := range raws {
+		if message, ok := byID[raw.ID]; ok {
+			message.Raw = raw.Raw
+		}
+	}
+	return nil
+}
+
+// RawMessage returns a message in RFC 822 form: as received when it was stored
+// that way, otherwise a minimal message rebuilt from its columns
+func RawMessage(message *models.Message) []byte {
+	if len(message.Raw) > 0 {
+		return message.Raw
+	}
+
+	var sb strings.Builder
+
+	// Headers
+	sb.WriteString("From: " + message.Sender + "\r\n")
+	sb.WriteString("To: " + message.Receiver + "\r\n")
+	sb.WriteString("Subject: " + message.Subject + "\r\n")
+	sb.WriteString("Date: " + message.CreatedAt.Time.Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("\r\n")
+
+	// Body
+	sb.WriteString(message.Body)
+
+	return []byte(sb.String())
+}
+
+// ParseRawMessage builds an unsaved message from a raw RFC 822 message.
+// Sender and receiver are taken from the From and To headers.
+func ParseRawMessage(raw []byte) (*models.Message, error) {
+	msg, err := message.Read(bytes.NewReader(raw))
+	if err != nil && !message.IsUnknownCharset(err) {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	body := new(bytes.Buffer)
+	if _, err := body.ReadFrom(msg.Body); err != nil {
+		return nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+
+	return &models.Message{
+		Sender:   headerAddress(msg.Header.Get("From")),
+		Receiver: headerAddress(msg.Header.Get("To")),
+		Subject:  msg.Header.Get("Subject"),
+		Body:     body.String(),
+		Raw:      raw,
+	}, nil
+}
+
+// headerAddress returns the first bare address of an address list header,
+// falling back to the raw value when it cannot be parsed.
+func headerAddress(value string) string {
+	addrs, err := mail.ParseAddressList(value)
+	if err != nil || len(addrs) == 0 {
+		return value
+	}
+	return addrs[0].Address
+}
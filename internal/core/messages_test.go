@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,10 +29,21 @@ func setupMessageTestCore(t *testing.T) (*Core, *mocks.Repository) {
 		Repository: mockRepo,
 	}
 	core.MessageService = NewMessageService(core)
+	core.LabelService = NewLabelService(core)
+	core.FolderService = NewFolderService(core)
+	core.QuotaService = NewQuotaService(core)
 
 	return core, mockRepo
 }
 
+// unlimitedQuotas are the quotas of an inbox and project without limits
+func unlimitedQuotas(inboxID string) []*models.Quota {
+	return []*models.Quota{
+		{Kind: "inbox", ID: inboxID, Name: "inbox@example.com"},
+		{Kind: "project", ID: "project-1", Name: "Project"},
+	}
+}
+
 func TestMessageService_Store(t *testing.T) {
 	testInboxID := test.RandomTestUUID()
 	tests := []struct {
@@ -50,11 +62,30 @@ func TestMessageService_Store(t *testing.T) {
 				Body:     "Test Body",
 			},
 			mockFn: func(m *mocks.Repository) {
-				m.On("CreateMessage", mock.Anything, mock.AnythingOfType("*models.Message")).
-					Return(nil)
+				m.On("GetInboxQuotas", mock.Anything, testInboxID).Return(unlimitedQuotas(testInboxID), nil)
+				m.On("CreateMessage", mock.Anything, mock.MatchedBy(func(msg *models.Message) bool {
+					return msg.Size == int64(len("Test Body"))
+				})).Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name: "over project quota",
+			message: &models.Message{
+				InboxID:  testInboxID,
+				Sender:   "sender@example.com",
+				Receiver: "inbox@example.com",
+				Subject:  "Test Subject",
+				Body:     "Test Body",
+			},
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetInboxQuotas", mock.Anything, testInboxID).Return([]*models.Quota{
+					{Kind: "inbox", ID: testInboxID, Usage: models.Usage{MaxMessages: 100, MessageCount: 10}},
+					{Kind: "project", ID: "project-1", Usage: models.Usage{MaxBytes: 1024, StorageBytes: 1020}},
+				}, nil)
+			},
+			wantErr: true,
+		},
 		{
 			name: "repository error",
 			message: &models.Message{
@@ -65,6 +96,7 @@ func TestMessageService_Store(t *testing.T) {
 				Body:     "Test Body",
 			},
 			mockFn: func(m *mocks.Repository) {
+				m.On("GetInboxQuotas", mock.Anything, testInboxID).Return(unlimitedQuotas(testInboxID), nil)
 				m.On("CreateMessage", mock.Anything, mock.AnythingOfType("*models.Message")).
 					Return(errors.New("database error"))
 			},
@@ -93,6 +125,7 @@ func TestMessageService_Get(t *testing.T) {
 	testInboxID := test.RandomTestUUID()
 	testMessageID := test.RandomTestUUID()
 	nonExistingMessageID := test.RandomTestUUID()
+	testLabelID := test.RandomTestUUID()
 	now := time.Now()
 	tests := []struct {
 		name    string
@@ -118,6 +151,12 @@ func TestMessageService_Get(t *testing.T) {
 					Subject:  "Test Subject",
 					Body:     "Test Body",
 				}, nil)
+				m.On("ListLabelsByMessages", mock.Anything, []string{testMessageID}).Return([]*models.MessageLabel{
+					{
+						Label:     models.Label{Base: models.Base{ID: testLabelID}, Name: "important", Color: "#ff0000"},
+						MessageID: testMessageID,
+					},
+				}, nil)
 			},
 			want: &models.Message{
 				Base: models.Base{
@@ -130,6 +169,9 @@ func TestMessageService_Get(t *testing.T) {
 				Receiver: "inbox@example.com",
 				Subject:  "Test Subject",
 				Body:     "Test Body",
+				Labels: []*models.Label{
+					{Base: models.Base{ID: testLabelID}, Name: "important", Color: "#ff0000"},
+				},
 			},
 			wantErr: false,
 		},
@@ -201,6 +243,8 @@ func TestMessageService_ListByInbox(t *testing.T) {
 				}
 				m.On("ListMessagesByInboxWithFilters", mock.Anything, testInboxID1, models.MessageFilters{IsRead: &isRead}, 10, 0).
 					Return(messages, 1, nil)
+				m.On("ListLabelsByMessages", mock.Anything, []string{testMessageID1}).
+					Return([]*models.MessageLabel{}, nil)
 			},
 			want: &models.PaginatedResponse{
 				Data: []*models.Message{
@@ -212,6 +256,7 @@ func TestMessageService_ListByInbox(t *testing.T) {
 						Subject:  "Subject 1",
 						Body:     "Body 1",
 						IsRead:   true,
+						Labels:   []*models.Label{},
 					},
 				},
 				Pagination: models.Pagination{
@@ -251,6 +296,8 @@ func TestMessageService_ListByInbox(t *testing.T) {
 				}
 				m.On("ListMessagesByInboxWithFilters", mock.Anything, testInboxID1, models.MessageFilters{}, 10, 0).
 					Return(messages, 2, nil)
+				m.On("ListLabelsByMessages", mock.Anything, []string{testMessageID1, testMessageID2}).
+					Return([]*models.MessageLabel{}, nil)
 			},
 			want: &models.PaginatedResponse{
 				Data: []*models.Message{
@@ -262,6 +309,7 @@ func TestMessageService_ListByInbox(t *testing.T) {
 						Subject:  "Subject 1",
 						Body:     "Body 1",
 						IsRead:   true,
+						Labels:   []*models.Label{},
 					},
 					{
 						Base:     models.Base{ID: testMessageID2},
@@ -271,6 +319,7 @@ func TestMessageService_ListByInbox(t *testing.T) {
 						Subject:  "Subject 2",
 						Body:     "Body 2",
 						IsRead:   false,
+						Labels:   []*models.Label{},
 					},
 				},
 				Pagination: models.Pagination{
@@ -533,3 +582,417 @@ func TestMessageService_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestMessageService_UpdateFlags(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	uids := []uint32{1, 2, 3}
+	flags := models.MessageFlags{Seen: true, Flagged: true}
+	tests := []struct {
+		name    string
+		mockFn  func(*mocks.Repository)
+		wantErr bool
+	}{
+		{
+			name: "successful update",
+			mockFn: func(m *mocks.Repository) {
+				m.On("UpdateMessageFlags", mock.Anything, testInboxID, "", uids, models.FlagsAdd, flags).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "repository error",
+			mockFn: func(m *mocks.Repository) {
+				m.On("UpdateMessageFlags", mock.Anything, testInboxID, "", uids, models.FlagsAdd, flags).
+					Return(errors.New("database error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupMessageTestCore(t)
+			tt.mockFn(mockRepo)
+
+			err := core.MessageService.UpdateFlags(context.Background(), testInboxID, "", uids, models.FlagsAdd, flags)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMessageService_StoreInSpecialUse(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	testFolderID := test.RandomTestUUID()
+	tests := []struct {
+		name         string
+		mockFn       func(*mocks.Repository)
+		wantFolderID null.String
+		wantErr      bool
+	}{
+		{
+			name: "stored in folder",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetFolderBySpecialUse", mock.Anything, testInboxID, SpecialUseJunk).
+					Return(&models.Folder{Base: models.Base{ID: testFolderID}, SpecialUse: null.StringFrom(SpecialUseJunk)}, nil)
+				m.On("GetInboxQuotas", mock.Anything, testInboxID).Return(unlimitedQuotas(testInboxID), nil)
+				m.On("CreateMessage", mock.Anything, mock.AnythingOfType("*models.Message")).Return(nil)
+			},
+			wantFolderID: null.StringFrom(testFolderID),
+		},
+		{
+			name: "inbox without folder",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetFolderBySpecialUse", mock.Anything, testInboxID, SpecialUseJunk).Return(nil, storage.ErrNotFound)
+				m.On("GetInboxQuotas", mock.Anything, testInboxID).Return(unlimitedQuotas(testInboxID), nil)
+				m.On("CreateMessage", mock.Anything, mock.AnythingOfType("*models.Message")).Return(nil)
+			},
+		},
+		{
+			name: "repository error",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetFolderBySpecialUse", mock.Anything, testInboxID, SpecialUseJunk).Return(nil, errors.New("database error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupMessageTestCore(t)
+			tt.mockFn(mockRepo)
+
+			msg := &models.Message{InboxID: testInboxID, Sender: "spam@example.com", Subject: "Offer"}
+			err := core.MessageService.StoreInSpecialUse(context.Background(), msg, SpecialUseJunk)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantFolderID, msg.FolderID)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMessageService_Copy(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	testDestInboxID := test.RandomTestUUID()
+	uids := []uint32{4, 7}
+	messages := []*models.Message{{UID: 4, Size: 600}, {UID: 7, Size: 500}}
+	tests := []struct {
+		name    string
+		mockFn  func(*mocks.Repository)
+		wantErr error
+	}{
+		{
+			name: "copied",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetMessagesByUIDs", mock.Anything, testInboxID, "", uids).Return(messages, nil)
+				m.On("GetInboxQuotas", mock.Anything, testDestInboxID).Return(unlimitedQuotas(testDestInboxID), nil)
+				m.On("CopyMessages", mock.Anything, testInboxID, "", uids, testDestInboxID, "").Return([]uint32{1, 2}, nil)
+			},
+		},
+		{
+			name: "copies over quota",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetMessagesByUIDs", mock.Anything, testInboxID, "", uids).Return(messages, nil)
+				m.On("GetInboxQuotas", mock.Anything, testDestInboxID).Return([]*models.Quota{
+					{Kind: "inbox", ID: testDestInboxID, Usage: models.Usage{MaxBytes: 1000}},
+					{Kind: "project", ID: "project-1"},
+				}, nil)
+			},
+			wantErr: ErrQuotaExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupMessageTestCore(t)
+			tt.mockFn(mockRepo)
+
+			destUIDs, err := core.MessageService.Copy(context.Background(), testInboxID, "", uids, testDestInboxID, "")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, []uint32{1, 2}, destUIDs)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMessageService_Move(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	testDestInboxID := test.RandomTestUUID()
+	uids := []uint32{4, 7}
+	messages := []*models.Message{{UID: 4, Size: 600}, {UID: 7, Size: 500}}
+	tests := []struct {
+		name    string
+		mockFn  func(*mocks.Repository)
+		wantErr error
+	}{
+		{
+			name: "moved",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetMessagesByUIDs", mock.Anything, testInboxID, "", uids).Return(messages, nil)
+				m.On("GetInboxQuotas", mock.Anything, testInboxID).Return(unlimitedQuotas(testInboxID), nil)
+				m.On("GetInboxQuotas", mock.Anything, testDestInboxID).Return(unlimitedQuotas(testDestInboxID), nil)
+				m.On("MoveMessages", mock.Anything, testInboxID, "", uids, testDestInboxID, "").Return([]uint32{1, 2}, nil)
+			},
+		},
+		{
+			name: "too many messages for the destination",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetMessagesByUIDs", mock.Anything, testInboxID, "", uids).Return(messages, nil)
+				m.On("GetInboxQuotas", mock.Anything, testInboxID).Return(unlimitedQuotas(testInboxID), nil)
+				m.On("GetInboxQuotas", mock.Anything, testDestInboxID).Return([]*models.Quota{
+					{Kind: "inbox", ID: testDestInboxID, Usage: models.Usage{MaxMessages: 10, MessageCount: 9}},
+					{Kind: "project", ID: "project-1"},
+				}, nil)
+			},
+			wantErr: ErrQuotaExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupMessageTestCore(t)
+			tt.mockFn(mockRepo)
+
+			destUIDs, err := core.MessageService.Move(context.Background(), testInboxID, "", uids, testDestInboxID, "")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, []uint32{1, 2}, destUIDs)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMessageService_Trash(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	testTrashID := test.RandomTestUUID()
+	uids := []uint32{4, 7}
+	trash := &models.Folder{Base: models.Base{ID: testTrashID}, Name: "Trash", SpecialUse: null.StringFrom(SpecialUseTrash)}
+	tests := []struct {
+		name    string
+		mockFn  func(*mocks.Repository)
+		wantErr error
+	}{
+		{
+			name: "moved to trash",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetFolderBySpecialUse", mock.Anything, testInboxID, SpecialUseTrash).Return(trash, nil)
+				m.On("MoveMessages", mock.Anything, testInboxID, "", uids, testInboxID, testTrashID).Return([]uint32{1, 2}, nil)
+				m.On("UpdateMessageFlags", mock.Anything, testInboxID, testTrashID, []uint32{1, 2}, models.FlagsRemove, models.MessageFlags{Deleted: true}).Return(nil)
+			},
+		},
+		{
+			name: "inbox without trash",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetFolderBySpecialUse", mock.Anything, testInboxID, SpecialUseTrash).Return(nil, storage.ErrNotFound)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupMessageTestCore(t)
+			tt.mockFn(mockRepo)
+
+			err := core.MessageService.Trash(context.Background(), testInboxID, "", uids)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMessageService_AttachRaw(t *testing.T) {
+	testMessageID1 := test.RandomTestUUID()
+	testMessageID2 := test.RandomTestUUID()
+
+	core, mockRepo := setupMessageTestCore(t)
+	mockRepo.On("ListRawMessages", mock.Anything, []string{testMessageID1, testMessageID2}).
+		Return([]*models.Message{
+			{Base: models.Base{ID: testMessageID2}, Raw: []byte("Subject: Hi\r\n\r\nHello\r\n")},
+		}, nil)
+
+	messages := []*models.Message{
+		{Base: models.Base{ID: testMessageID1}},
+		{Base: models.Base{ID: testMessageID2}},
+	}
+	err := core.MessageService.AttachRaw(context.Background(), messages)
+
+	assert.NoError(t, err)
+	assert.Nil(t, messages[0].Raw)
+	assert.Equal(t, []byte("Subject: Hi\r\n\r\nHello\r\n"), messages[1].Raw)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMessageService_ListAll(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	filters := models.MessageFilters{}
+
+	firstBatch := make([]*models.Message, 100)
+	for i := range firstBatch {
+		firstBatch[i] = &models.Message{UID: uint32(i + 1)}
+	}
+	secondBatch := []*models.Message{{UID: 101}, {UID: 102}}
+
+	core, mockRepo := setupMessageTestCore(t)
+	mockRepo.On("ListMessagesByInboxWithFilters", mock.Anything, testInboxID, filters, 100, 0).
+		Return(firstBatch, 102, nil)
+	mockRepo.On("ListMessagesByInboxWithFilters", mock.Anything, testInboxID, filters, 100, 100).
+		Return(secondBatch, 102, nil)
+
+	got, err := core.MessageService.ListAll(context.Background(), testInboxID, filters)
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 102)
+	assert.Equal(t, uint32(102), got[101].UID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMessageService_GetMany(t *testing.T) {
+	testMessageID := test.RandomTestUUID()
+	missingID := test.RandomTestUUID()
+
+	core, mockRepo := setupMessageTestCore(t)
+	mockRepo.On("ListMessagesByIDs", mock.Anything, []string{testMessageID, missingID}).
+		Return([]*models.Message{{Base: models.Base{ID: testMessageID}}}, nil)
+	mockRepo.On("ListLabelsByMessages", mock.Anything, []string{testMessageID}).
+		Return([]*models.MessageLabel{{MessageID: testMessageID, Label: models.Label{Name: "Work"}}}, nil)
+
+	got, err := core.MessageService.GetMany(context.Background(), []string{testMessageID, missingID})
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Len(t, got[0].Labels, 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMessageService_HighestModSeq(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+
+	core, mockRepo := setupMessageTestCore(t)
+	mockRepo.On("GetHighestModSeqForInboxes", mock.Anything, []string{testInboxID}).Return(uint64(42), nil)
+
+	got, err := core.MessageService.HighestModSeq(context.Background(), []string{testInboxID})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), got)
+
+	// Users without inboxes never had any changes
+	got, err = core.MessageService.HighestModSeq(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Zero(t, got)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMessageService_ListChanges(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	changes := []*models.MessageChange{
+		{MessageID: test.RandomTestUUID(), ModSeq: 11, Created: true},
+		{MessageID: test.RandomTestUUID(), ModSeq: 12, Destroyed: true},
+	}
+
+	core, mockRepo := setupMessageTestCore(t)
+	mockRepo.On("ListMessageChanges", mock.Anything, []string{testInboxID}, uint64(10), 50).Return(changes, nil)
+
+	got, err := core.MessageService.ListChanges(context.Background(), []string{testInboxID}, 10, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, changes, got)
+
+	got, err = core.MessageService.ListChanges(context.Background(), nil, 10, 50)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMessageService_CountByMailbox(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	counts := []*models.MailboxCount{{InboxID: testInboxID, Total: 3, Unread: 1}}
+
+	core, mockRepo := setupMessageTestCore(t)
+	mockRepo.On("CountMessagesByMailbox", mock.Anything, []string{testInboxID}).Return(counts, nil)
+
+	got, err := core.MessageService.CountByMailbox(context.Background(), []string{testInboxID})
+	assert.NoError(t, err)
+	assert.Equal(t, counts, got)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestParseRawMessage(t *testing.T) {
+	raw := "From: \"Ops Team\" <ops@example.com>\r\n" +
+		"To: inbox@example.com\r\n" +
+		"Subject: Disk alert\r\n" +
+		"\r\n" +
+		"Disk is full\r\n"
+
+	got, err := ParseRawMessage([]byte(raw))
+	assert.NoError(t, err)
+	assert.Equal(t, "ops@example.com", got.Sender)
+	assert.Equal(t, "inbox@example.com", got.Receiver)
+	assert.Equal(t, "Disk alert", got.Subject)
+	assert.Equal(t, "Disk is full\r\n", got.Body)
+}
+
+func TestRawMessage(t *testing.T) {
+	now := time.Now()
+	message := &models.Message{
+		Base: models.Base{
+			ID:        "test-message-1",
+			CreatedAt: null.TimeFrom(now),
+		},
+		InboxID:  "test-inbox-1",
+		Sender:   "sender@example.com",
+		Receiver: "receiver@example.com",
+		Subject:  "Test Subject",
+		Body:     "This is a test message body.",
+	}
+
+	result := string(RawMessage(message))
+
+	// Check that the RFC822 message contains expected headers
+	assert.Contains(t, result, "From: sender@example.com")
+	assert.Contains(t, result, "To: receiver@example.com")
+	assert.Contains(t, result, "Subject: Test Subject")
+	assert.Contains(t, result, "Content-Type: text/plain; charset=UTF-8")
+	assert.Contains(t, result, "MIME-Version: 1.0")
+	assert.Contains(t, result, "This is a test message body.")
+
+	// Check that date is formatted properly
+	expectedDate := now.Format(time.RFC1123Z)
+	assert.Contains(t, result, "Date: "+expectedDate)
+
+	// Check that headers and body are separated by empty line
+	assert.Contains(t, result, "\r\n\r\n")
+
+	// Check that the body comes after the headers
+	parts := strings.Split(result, "\r\n\r\n")
+	assert.Len(t, parts, 2)
+	assert.Equal(t, "This is a test message body.", parts[1])
+}
+
+func TestRawMessage_Stored(t *testing.T) {
+	raw := []byte("Subject: Stored\r\n\r\nAs received\r\n")
+
+	assert.Equal(t, raw, RawMessage(&models.Message{Subject: "Columns", Raw: raw}))
+}
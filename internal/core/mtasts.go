@@ -0,0 +1,155 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"inbox451/internal/models"
+)
+
+// Modes of an MTA-STS policy
+const (
+	MTASTSModeEnforce = "enforce"
+	MTASTSModeTesting = "testing"
+	MTASTSModeNone    = "none"
+)
+
+// mtaSTSMaxAge is the longest max_age RFC 8461 allows, a year
+const mtaSTSMaxAge = 31557600 * time.Second
+
+// mtaSTSHostPrefix is the label of the host a domain's policy is served from
+const mtaSTSHostPrefix = "mta-sts."
+
+type MTASTSService struct {
+	core *Core
+}
+
+func NewMTASTSService(core *Core) MTASTSService {
+	return MTASTSService{core: core}
+}
+
+// validateMTASTS checks the MTA-STS configuration when it is enabled
+func validateMTASTS(core *Core) error {
+	cfg := core.Config.Server.SMTP.MTASTS
+	if !cfg.Enabled {
+		return nil
+	}
+	switch cfg.Mode {
+	case MTASTSModeEnforce, MTASTSModeTesting, MTASTSModeNone:
+	default:
+		return fmt.Errorf("mta_sts.mode must be %q, %q or %q", MTASTSModeEnforce, MTASTSModeTesting, MTASTSModeNone)
+	}
+	if cfg.MaxAge <= 0 || cfg.MaxAge > mtaSTSMaxAge {
+		return fmt.Errorf("mta_sts.max_age must be between 1s and %s", mtaSTSMaxAge)
+	}
+	if len(cfg.MX) == 0 && core.Config.Server.SMTP.Domain == "" {
+		return fmt.Errorf("mta_sts.mx or smtp.domain must be set")
+	}
+	return nil
+}
+
+// Domains lists the domains a policy is served for: email_domain and the
+// ones configured under mta_sts.domains
+func (s *MTASTSService) Domains() []string {
+	cfg := s.core.Config.Server.SMTP.MTASTS
+	if !cfg.Enabled {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var domains []string
+	for _, domain := range append([]string{s.core.Config.Server.EmailDomain}, cfg.Domains...) {
+		domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+		if domain == "" || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+	return domains
+}
+
+// Hosts lists the mta-sts.<domain> hosts the policies are served from, which
+// need a certificate
+func (s *MTASTSService) Hosts() []string {
+	domains := s.Domains()
+	hosts := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		hosts = append(hosts, mtaSTSHostPrefix+domain)
+	}
+	return hosts
+}
+
+// List returns the policy of every domain
+func (s *MTASTSService) List() []*models.MTASTSPolicy {
+	domains := s.Domains()
+	policies := make([]*models.MTASTSPolicy, 0, len(domains))
+	for _, domain := range domains {
+		policies = append(policies, s.policy(domain))
+	}
+	return policies
+}
+
+// Policy returns the policy of a managed domain
+func (s *MTASTSService) Policy(domain string) (*models.MTASTSPolicy, error) {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	for _, managed := range s.Domains() {
+		if managed == domain {
+			return s.policy(domain), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// PolicyForHost returns the policy served from a mta-sts.<domain> host, as
+// found in the Host header of a request
+func (s *MTASTSService) PolicyForHost(host string) (*models.MTASTSPolicy, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if !strings.HasPrefix(host, mtaSTSHostPrefix) {
+		return nil, ErrNotFound
+	}
+	return s.Policy(strings.TrimPrefix(host, mtaSTSHostPrefix))
+}
+
+func (s *MTASTSService) policy(domain string) *models.MTASTSPolicy {
+	cfg := s.core.Config.Server.SMTP.MTASTS
+	mx := cfg.MX
+	if len(mx) == 0 {
+		mx = []string{s.core.Config.Server.SMTP.Domain}
+	}
+	maxAge := int64(cfg.MaxAge / time.Second)
+
+	var text strings.Builder
+	text.WriteString("version: STSv1\r\n")
+	fmt.Fprintf(&text, "mode: %s\r\n", cfg.Mode)
+	for _, host := range mx {
+		fmt.Fprintf(&text, "mx: %s\r\n", host)
+	}
+	fmt.Fprintf(&text, "max_age: %d\r\n", maxAge)
+
+	// The id only has to change with the policy, so it is derived from it
+	sum := sha256.Sum256([]byte(text.String()))
+
+	policy := &models.MTASTSPolicy{
+		Domain:    domain,
+		Mode:      cfg.Mode,
+		MX:        mx,
+		MaxAge:    maxAge,
+		Policy:    text.String(),
+		PolicyURL: "https://" + mtaSTSHostPrefix + domain + "/.well-known/mta-sts.txt",
+		DNSName:   "_mta-sts." + domain,
+		DNSRecord: "v=STSv1; id=" + hex.EncodeToString(sum[:10]),
+	}
+	if address := s.core.Config.Server.SMTP.TLSRPT.Address; address != "" {
+		policy.TLSRPTDNSName = "_smtp._tls." + domain
+		policy.TLSRPTDNSRecord = "v=TLSRPTv1; rua=mailto:" + address
+	}
+	return policy
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"inbox451/internal/config"
+	"inbox451/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMTASTSTestCore(t *testing.T) *Core {
+	core := &Core{
+		Config: &config.Config{},
+		Logger: logger.New(io.Discard, logger.DEBUG),
+	}
+	core.Config.Server.EmailDomain = "example.com"
+	core.Config.Server.SMTP.Domain = "mx.example.com"
+	core.Config.Server.SMTP.MTASTS = config.MTASTSConfig{
+		Enabled: true,
+		Mode:    MTASTSModeEnforce,
+		MaxAge:  168 * time.Hour,
+		Domains: []string{"Example.org.", "example.com"},
+	}
+	core.MTASTSService = NewMTASTSService(core)
+
+	return core
+}
+
+func TestMTASTSService_Policy(t *testing.T) {
+	core := setupMTASTSTestCore(t)
+
+	policy, err := core.MTASTSService.Policy("example.org")
+	require.NoError(t, err)
+	assert.Equal(t, "version: STSv1\r\nmode: enforce\r\nmx: mx.example.com\r\nmax_age: 604800\r\n", policy.Policy)
+	assert.Equal(t, "https://mta-sts.example.org/.well-known/mta-sts.txt", policy.PolicyURL)
+	assert.Equal(t, "_mta-sts.example.org", policy.DNSName)
+	assert.Regexp(t, `^v=STSv1; id=[0-9a-f]{20}$`, policy.DNSRecord)
+	assert.Empty(t, policy.TLSRPTDNSRecord)
+
+	_, err = core.MTASTSService.Policy("example.net")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// The id follows the policy
+	core.Config.Server.SMTP.MTASTS.Mode = MTASTSModeTesting
+	core.Config.Server.SMTP.TLSRPT.Address = "tlsrpt@example.com"
+	changed, err := core.MTASTSService.Policy("example.org")
+	require.NoError(t, err)
+	assert.NotEqual(t, policy.DNSRecord, changed.DNSRecord)
+	assert.Equal(t, "_smtp._tls.example.org", changed.TLSRPTDNSName)
+	assert.Equal(t, "v=TLSRPTv1; rua=mailto:tlsrpt@example.com", changed.TLSRPTDNSRecord)
+}
+
+func TestMTASTSService_PolicyForHost(t *testing.T) {
+	core := setupMTASTSTestCore(t)
+
+	tests := []struct {
+		host       string
+		wantDomain string
+	}{
+		{host: "mta-sts.example.com", wantDomain: "example.com"},
+		{host: "MTA-STS.example.org:8443", wantDomain: "example.org"},
+		{host: "example.com"},
+		{host: "mta-sts.example.net"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			policy, err := core.MTASTSService.PolicyForHost(tt.host)
+			if tt.wantDomain == "" {
+				assert.ErrorIs(t, err, ErrNotFound)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDomain, policy.Domain)
+		})
+	}
+
+	core.Config.Server.SMTP.MTASTS.Enabled = false
+	_, err := core.MTASTSService.PolicyForHost("mta-sts.example.com")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMTASTSService_Hosts(t *testing.T) {
+	core := setupMTASTSTestCore(t)
+	assert.Equal(t, []string{"mta-sts.example.com", "mta-sts.example.org"}, core.MTASTSService.Hosts())
+	assert.Len(t, core.MTASTSService.List(), 2)
+}
+
+func TestValidateMTASTS(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*config.MTASTSConfig)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(*config.MTASTSConfig) {}},
+		{name: "unknown mode", modify: func(c *config.MTASTSConfig) { c.Mode = "strict" }, wantErr: true},
+		{name: "no max age", modify: func(c *config.MTASTSConfig) { c.MaxAge = 0 }, wantErr: true},
+		{name: "max age over a year", modify: func(c *config.MTASTSConfig) { c.MaxAge = 366 * 24 * time.Hour }, wantErr: true},
+		{name: "disabled", modify: func(c *config.MTASTSConfig) { c.Enabled = false; c.Mode = "" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := setupMTASTSTestCore(t)
+			tt.modify(&core.Config.Server.SMTP.MTASTS)
+			err := validateMTASTS(core)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"inbox451/internal/models"
+
+	"github.com/lib/pq"
+	null "github.com/volatiletech/null/v9"
+)
+
+// Statuses of outbound messages
+const (
+	OutboundQueued = "queued"
+	OutboundSent   = "sent"
+	OutboundFailed = "failed"
+)
+
+const (
+	defaultOutboundMaxAttempts   = 10
+	defaultOutboundRetryInterval = 5 * time.Minute
+	maxOutboundRetryInterval     = 12 * time.Hour
+)
+
+type OutboundService struct {
+	core *Core
+}
+
+func NewOutboundService(core *Core) OutboundService {
+	return OutboundService{core: core}
+}
+
+// Enqueue queues a submitted message for delivery to external recipients
+// through the smarthost
+func (s *OutboundService) Enqueue(ctx context.Context, userID, mailFrom string, recipients []string, raw []byte) (*models.OutboundMessage, error) {
+	message := &models.OutboundMessage{
+		UserID:     null.NewString(userID, userID != ""),
+		MailFrom:   mailFrom,
+		Recipients: pq.StringArray(recipients),
+		Raw:        raw,
+	}
+
+	if err := s.core.Repository.EnqueueOutboundMessage(ctx, message); err != nil {
+		s.core.Logger.Error("Failed to enqueue outbound message from %s: %v", mailFrom, err)
+		return nil, err
+	}
+
+	s.core.Logger.Info("Queued outbound message %s from %s to %d recipients", message.ID, mailFrom, len(recipients))
+	return message, nil
+}
+
+// Claim returns up to limit messages that are due for delivery, leasing them to
+// the caller for the given duration
+func (s *OutboundService) Claim(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboundMessage, error) {
+	messages, err := s.core.Repository.ClaimOutboundMessages(ctx, limit, lease)
+	if err != nil {
+		s.core.Logger.Error("Failed to claim outbound messages: %v", err)
+		return nil, err
+	}
+	return messages, nil
+}
+
+// MarkSent records that a message was accepted by the smarthost
+func (s *OutboundService) MarkSent(ctx context.Context, message *models.OutboundMessage) error {
+	message.Status = OutboundSent
+	message.LastError = ""
+	return s.update(ctx, message)
+}
+
+// MarkFailed records a failed delivery attempt. The message is retried later
+// unless the failure is permanent or it has run out of attempts.
+func (s *OutboundService) MarkFailed(ctx context.Context, message *models.OutboundMessage, deliveryErr error, permanent bool) error {
+	message.LastError = deliveryErr.Error()
+	if permanent || message.Attempts >= s.maxAttempts() {
+		message.Status = OutboundFailed
+		s.core.Logger.Warn("Giving up on outbound message %s after %d attempts: %v", message.ID, message.Attempts, deliveryErr)
+	} else {
+		message.Status = OutboundQueued
+		message.NextAttemptAt = null.TimeFrom(time.Now().Add(s.RetryDelay(message.Attempts)))
+		s.core.Logger.Info("Outbound message %s will be retried at %s: %v", message.ID, message.NextAttemptAt.Time, deliveryErr)
+	}
+	return s.update(ctx, message)
+}
+
+// RetryDelay returns how long to wait after the given number of failed attempts.
+// The retry interval doubles after each attempt, up to a maximum.
+func (s *OutboundService) RetryDelay(attempts int) time.Duration {
+	delay := s.core.Config.Server.SMTP.Outbound.RetryInterval
+	if delay <= 0 {
+		delay = defaultOutboundRetryInterval
+	}
+	for i := 1; i < attempts && delay < maxOutboundRetryInterval; i++ {
+		delay *= 2
+	}
+	return min(delay, maxOutboundRetryInterval)
+}
+
+func (s *OutboundService) maxAttempts() int {
+	if attempts := s.core.Config.Server.SMTP.Outbound.MaxAttempts; attempts > 0 {
+		return attempts
+	}
+	return defaultOutboundMaxAttempts
+}
+
+func (s *OutboundService) update(ctx context.Context, message *models.OutboundMessage) error {
+	if err := s.core.Repository.UpdateOutboundMessage(ctx, message); err != nil {
+		s.core.Logger.Error("Failed to update outbound message %s: %v", message.ID, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"inbox451/internal/config"
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+	"inbox451/internal/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupOutboundTestCore(t *testing.T) (*Core, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	logger := logger.New(io.Discard, logger.DEBUG)
+
+	core := &Core{
+		Config:     &config.Config{},
+		Logger:     logger,
+		Repository: mockRepo,
+	}
+	core.Config.Server.SMTP.Outbound.MaxAttempts = 3
+	core.Config.Server.SMTP.Outbound.RetryInterval = time.Minute
+	core.OutboundService = NewOutboundService(core)
+
+	return core, mockRepo
+}
+
+func TestOutboundService_RetryDelay(t *testing.T) {
+	core, _ := setupOutboundTestCore(t)
+
+	assert.Equal(t, time.Minute, core.OutboundService.RetryDelay(1))
+	assert.Equal(t, 2*time.Minute, core.OutboundService.RetryDelay(2))
+	assert.Equal(t, 8*time.Minute, core.OutboundService.RetryDelay(4))
+	assert.Equal(t, maxOutboundRetryInterval, core.OutboundService.RetryDelay(100))
+}
+
+func TestOutboundService_MarkFailed(t *testing.T) {
+	tests := []struct {
+		name       string
+		attempts   int
+		permanent  bool
+		wantStatus string
+	}{
+		{name: "temporary failure", attempts: 1, wantStatus: OutboundQueued},
+		{name: "permanent failure", attempts: 1, permanent: true, wantStatus: OutboundFailed},
+		{name: "out of attempts", attempts: 3, wantStatus: OutboundFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupOutboundTestCore(t)
+			mockRepo.On("UpdateOutboundMessage", mock.Anything, mock.AnythingOfType("*models.OutboundMessage")).Return(nil)
+
+			message := &models.OutboundMessage{
+				Base:     models.Base{ID: test.RandomTestUUID()},
+				Status:   OutboundQueued,
+				Attempts: tt.attempts,
+			}
+			before := time.Now()
+			err := core.OutboundService.MarkFailed(context.Background(), message, errors.New("451 try again later"), tt.permanent)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, message.Status)
+			assert.Equal(t, "451 try again later", message.LastError)
+			if tt.wantStatus == OutboundQueued {
+				assert.True(t, message.NextAttemptAt.Time.After(before))
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+
+	"inbox451/internal/models"
+)
+
+type QuotaService struct {
+	core *Core
+}
+
+func NewQuotaService(core *Core) QuotaService {
+	return QuotaService{core: core}
+}
+
+// Get returns the quotas a message stored in the inbox counts against, the
+// inbox's first and then its project's
+func (s *QuotaService) Get(ctx context.Context, inboxID string) ([]*models.Quota, error) {
+	quotas, err := s.core.Repository.GetInboxQuotas(ctx, inboxID)
+	if err != nil {
+		s.core.Logger.Error("Failed to fetch quotas of inbox %s: %v", inboxID, err)
+		return nil, err
+	}
+	if len(quotas) == 0 {
+		return nil, ErrNotFound
+	}
+	return quotas, nil
+}
+
+// Check returns ErrQuotaExceeded when a message of size bytes doesn't fit in
+// the quotas of the inbox. A size of 0 checks whether the inbox still takes
+// messages at all.
+//
+// The usage isn't locked between the check and the store, so concurrent
+// deliveries may overshoot a quota by a message each.
+func (s *QuotaService) Check(ctx context.Context, inboxID string, size int64) error {
+	quotas, err := s.Get(ctx, inboxID)
+	if err != nil {
+		return err
+	}
+	return s.check(quotas, 1, size)
+}
+
+// CheckCopy returns ErrQuotaExceeded when copies of the messages don't fit in
+// the quotas of the inbox
+func (s *QuotaService) CheckCopy(ctx context.Context, inboxID string, messages []*models.Message) error {
+	quotas, err := s.Get(ctx, inboxID)
+	if err != nil {
+		return err
+	}
+	count, size := totalSize(messages)
+	return s.check(quotas, count, size)
+}
+
+// CheckMove returns ErrQuotaExceeded when the messages, moved out of one inbox,
+// don't fit in the quotas of another. The quota of a project both inboxes
+// belong to is left out, as the messages already count against it.
+func (s *QuotaService) CheckMove(ctx context.Context, fromInboxID, inboxID string, messages []*models.Message) error {
+	from, err := s.Get(ctx, fromInboxID)
+	if err != nil {
+		return err
+	}
+	quotas, err := s.Get(ctx, inboxID)
+	if err != nil {
+		return err
+	}
+
+	var gained []*models.Quota
+	for _, quota := range quotas {
+		if !containsQuota(from, quota) {
+			gained = append(gained, quota)
+		}
+	}
+	count, size := totalSize(messages)
+	return s.check(gained, count, size)
+}
+
+// check returns ErrQuotaExceeded when count messages of size bytes in all
+// don't fit in one of the quotas
+func (s *QuotaService) check(quotas []*models.Quota, count, size int64) error {
+	for _, quota := range quotas {
+		if !quota.AllowsMany(count, size) {
+			s.core.Logger.Info("Quota of %s %s exceeded: %d/%d messages, %d/%d bytes, %d messages of %d bytes",
+				quota.Kind, quota.Name, quota.MessageCount, quota.MaxMessages, quota.StorageBytes, quota.MaxBytes, count, size)
+			return ErrQuotaExceeded
+		}
+	}
+	return nil
+}
+
+func containsQuota(quotas []*models.Quota, quota *models.Quota) bool {
+	for _, q := range quotas {
+		if q.Kind == quota.Kind && q.ID == quota.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// totalSize returns the number of messages and their summed size
+func totalSize(messages []*models.Message) (count, size int64) {
+	for _, message := range messages {
+		size += message.Size
+	}
+	return int64(len(messages)), size
+}
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"inbox451/internal/config"
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupQuotaTestCore(t *testing.T) (*Core, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	logger := logger.New(io.Discard, logger.DEBUG)
+
+	core := &Core{
+		Config:     &config.Config{},
+		Logger:     logger,
+		Repository: mockRepo,
+	}
+	core.QuotaService = NewQuotaService(core)
+
+	return core, mockRepo
+}
+
+func TestQuotaService_Check(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		size    int64
+		quotas  []*models.Quota
+		repoErr error
+		wantErr error
+	}{
+		{
+			name: "no limits",
+			size: 1 << 20,
+			quotas: []*models.Quota{
+				{Kind: "inbox", Usage: models.Usage{MessageCount: 1000, StorageBytes: 1 << 30}},
+				{Kind: "project", Usage: models.Usage{MessageCount: 5000, StorageBytes: 1 << 32}},
+			},
+		},
+		{
+			name: "fits",
+			size: 100,
+			quotas: []*models.Quota{
+				{Kind: "inbox", Usage: models.Usage{MaxMessages: 10, MaxBytes: 1000, MessageCount: 9, StorageBytes: 900}},
+				{Kind: "project", Usage: models.Usage{MaxBytes: 1000, StorageBytes: 900}},
+			},
+		},
+		{
+			name: "inbox message count reached",
+			quotas: []*models.Quota{
+				{Kind: "inbox", Usage: models.Usage{MaxMessages: 10, MessageCount: 10}},
+				{Kind: "project"},
+			},
+			wantErr: ErrQuotaExceeded,
+		},
+		{
+			name: "message too large for the project",
+			size: 101,
+			quotas: []*models.Quota{
+				{Kind: "inbox"},
+				{Kind: "project", Usage: models.Usage{MaxBytes: 1000, StorageBytes: 900}},
+			},
+			wantErr: ErrQuotaExceeded,
+		},
+		{
+			name:    "unknown inbox",
+			quotas:  []*models.Quota{},
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "storage error",
+			repoErr: errors.New("database error"),
+			wantErr: errors.New("database error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupQuotaTestCore(t)
+			mockRepo.On("GetInboxQuotas", ctx, "inbox-1").Return(tt.quotas, tt.repoErr).Once()
+
+			err := core.QuotaService.Check(ctx, "inbox-1", tt.size)
+			switch {
+			case tt.wantErr == nil:
+				assert.NoError(t, err)
+			case tt.repoErr != nil:
+				assert.EqualError(t, err, tt.wantErr.Error())
+			default:
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuotaService_CheckCopy(t *testing.T) {
+	ctx := context.Background()
+	messages := []*models.Message{{Size: 60}, {Size: 50}}
+
+	tests := []struct {
+		name    string
+		inbox   models.Usage
+		wantErr error
+	}{
+		{name: "fits", inbox: models.Usage{MaxMessages: 10, MaxBytes: 1000, MessageCount: 8, StorageBytes: 890}},
+		{name: "too many messages", inbox: models.Usage{MaxMessages: 10, MessageCount: 9}, wantErr: ErrQuotaExceeded},
+		{name: "too large", inbox: models.Usage{MaxBytes: 1000, StorageBytes: 900}, wantErr: ErrQuotaExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupQuotaTestCore(t)
+			mockRepo.On("GetInboxQuotas", ctx, "inbox-2").Return([]*models.Quota{
+				{Kind: "inbox", ID: "inbox-2", Usage: tt.inbox},
+				{Kind: "project", ID: "project-1"},
+			}, nil).Once()
+
+			err := core.QuotaService.CheckCopy(ctx, "inbox-2", messages)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuotaService_CheckMove(t *testing.T) {
+	ctx := context.Background()
+	messages := []*models.Message{{Size: 60}, {Size: 50}}
+	fullProject := models.Usage{MaxBytes: 1000, StorageBytes: 1000}
+
+	tests := []struct {
+		name          string
+		destProjectID string
+		wantErr       error
+	}{
+		{name: "within the project", destProjectID: "project-1"},
+		{name: "into another project", destProjectID: "project-2", wantErr: ErrQuotaExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupQuotaTestCore(t)
+			mockRepo.On("GetInboxQuotas", ctx, "inbox-1").Return([]*models.Quota{
+				{Kind: "inbox", ID: "inbox-1"},
+				{Kind: "project", ID: "project-1", Usage: fullProject},
+			}, nil).Once()
+			mockRepo.On("GetInboxQuotas", ctx, "inbox-2").Return([]*models.Quota{
+				{Kind: "inbox", ID: "inbox-2"},
+				{Kind: "project", ID: tt.destProjectID, Usage: fullProject},
+			}, nil).Once()
+
+			err := core.QuotaService.CheckMove(ctx, "inbox-1", "inbox-2", messages)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,8 +2,12 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"inbox451/internal/models"
+
+	null "github.com/volatiletech/null/v9"
 )
 
 type RuleService struct {
@@ -86,3 +90,161 @@ func (s *RuleService) ListByInbox(ctx context.Context, inboxID string, limit, of
 	s.core.Logger.Info("Successfully retrieved %d rules (total: %d)", len(rules), total)
 	return response, nil
 }
+
+// Test evaluates a single rule against a message without forwarding anything.
+func (s *RuleService) Test(ctx context.Context, ruleID string, message *models.Message) (*models.RuleMatch, error) {
+	s.core.Logger.Debug("Testing rule %s against message from %s", ruleID, message.Sender)
+
+	rule, err := s.Get(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	match := MatchRule(rule, message)
+	s.core.Logger.Info("Rule %s dry-run finished (matched: %t)", ruleID, match.Matched)
+	return match, nil
+}
+
+// Simulate runs every rule of an inbox against its most recent messages and
+// reports the first rule that would have matched each of them.
+func (s *RuleService) Simulate(ctx context.Context, inboxID string, limit int) ([]*models.RuleSimulation, error) {
+	s.core.Logger.Info("Simulating rules for inbox %s against the last %d messages", inboxID, limit)
+
+	rules, err := s.listAllByInbox(ctx, inboxID)
+	if err != nil {
+		s.core.Logger.Error("Failed to list rules: %v", err)
+		return nil, err
+	}
+
+	messages, err := s.core.Repository.ListRecentMessagesByInbox(ctx, inboxID, limit)
+	if err != nil {
+		s.core.Logger.Error("Failed to list recent messages: %v", err)
+		return nil, err
+	}
+
+	results := make([]*models.RuleSimulation, 0, len(messages))
+	for _, message := range messages {
+		result := &models.RuleSimulation{
+			MessageID: message.ID,
+			Sender:    message.Sender,
+			Receiver:  message.Receiver,
+			Subject:   message.Subject,
+			Reasons:   []string{"no rule matched"},
+		}
+		for _, rule := range rules {
+			if match := MatchRule(rule, message); match.Matched {
+				result.MatchedRuleID = null.StringFrom(rule.ID)
+				result.Reasons = match.Reasons
+				break
+			}
+		}
+		results = append(results, result)
+	}
+
+	s.core.Logger.Info("Successfully simulated %d rules against %d messages", len(rules), len(results))
+	return results, nil
+}
+
+// Forward queues a message for every forwarding address of the inbox rules it
+// matches. The envelope sender is rewritten with SRS when enabled, so that the
+// receiving side's SPF checks pass and bounces find their way back.
+func (s *RuleService) Forward(ctx context.Context, message *models.Message) error {
+	if !s.core.Config.Server.SMTP.Outbound.Enabled {
+		return nil
+	}
+
+	rules, err := s.listAllByInbox(ctx, message.InboxID)
+	if err != nil {
+		s.core.Logger.Error("Failed to list rules: %v", err)
+		return err
+	}
+
+	var recipients []string
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.ForwardTo == "" || !MatchRule(rule, message).Matched {
+			continue
+		}
+		address := strings.ToLower(rule.ForwardTo)
+		if !seen[address] {
+			seen[address] = true
+			recipients = append(recipients, rule.ForwardTo)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	mailFrom := message.Sender
+	if s.core.SRS != nil {
+		rewritten, err := s.core.SRS.Forward(message.Sender)
+		if err != nil {
+			// Bounces for a sender we can't rewrite have nowhere to go
+			s.core.Logger.Warn("Forwarding message %s with a null sender: %v", message.ID, err)
+		}
+		mailFrom = rewritten
+	}
+
+	if _, err := s.core.OutboundService.Enqueue(ctx, "", mailFrom, recipients, message.Raw); err != nil {
+		return err
+	}
+
+	s.core.Logger.Info("Forwarding message %s to %d recipients", message.ID, len(recipients))
+	return nil
+}
+
+// listAllByInbox pages through every rule of an inbox.
+func (s *RuleService) listAllByInbox(ctx context.Context, inboxID string) ([]*models.ForwardRule, error) {
+	const batchSize = 100
+	var all []*models.ForwardRule
+
+	for offset := 0; ; offset += batchSize {
+		rules, total, err := s.core.Repository.ListRulesByInbox(ctx, inboxID, batchSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, rules...)
+		if len(all) >= total || len(rules) < batchSize {
+			return all, nil
+		}
+	}
+}
+
+// MatchRule checks a message against every condition set on a rule. Sender and
+// receiver must match exactly (case-insensitive), the subject condition
+// matches as a case-insensitive substring. Empty conditions are ignored, and
+// a rule without any condition never matches.
+func MatchRule(rule *models.ForwardRule, message *models.Message) *models.RuleMatch {
+	match := &models.RuleMatch{RuleID: rule.ID, Matched: true, Reasons: []string{}}
+	conditions := 0
+
+	check := func(ok bool, reason string) {
+		conditions++
+		if ok {
+			match.Reasons = append(match.Reasons, reason)
+		} else {
+			match.Matched = false
+			match.Reasons = append(match.Reasons, "not "+reason)
+		}
+	}
+
+	if rule.Sender != "" {
+		check(strings.EqualFold(rule.Sender, message.Sender),
+			fmt.Sprintf("sender equals %q", rule.Sender))
+	}
+	if rule.Receiver != "" {
+		check(strings.EqualFold(rule.Receiver, message.Receiver),
+			fmt.Sprintf("receiver equals %q", rule.Receiver))
+	}
+	if rule.Subject != "" {
+		check(strings.Contains(strings.ToLower(message.Subject), strings.ToLower(rule.Subject)),
+			fmt.Sprintf("subject contains %q", rule.Subject))
+	}
+
+	if conditions == 0 {
+		match.Matched = false
+		match.Reasons = append(match.Reasons, "rule has no conditions")
+	}
+
+	return match
+}
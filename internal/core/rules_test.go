@@ -4,14 +4,17 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
 	"inbox451/internal/test"
 
+	"inbox451/internal/config"
 	"inbox451/internal/logger"
 	"inbox451/internal/mocks"
 	"inbox451/internal/models"
+	"inbox451/internal/srs"
 	"inbox451/internal/storage"
 
 	"github.com/stretchr/testify/assert"
@@ -378,3 +381,278 @@ func TestRuleService_ListByInbox(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchRule(t *testing.T) {
+	message := &models.Message{
+		Sender:   "Alerts@Example.com",
+		Receiver: "inbox@example.com",
+		Subject:  "Weekly Report: all systems nominal",
+	}
+
+	tests := []struct {
+		name        string
+		rule        *models.ForwardRule
+		wantMatched bool
+		wantReasons []string
+	}{
+		{
+			name:        "sender matches case-insensitively",
+			rule:        &models.ForwardRule{Sender: "alerts@example.com"},
+			wantMatched: true,
+			wantReasons: []string{`sender equals "alerts@example.com"`},
+		},
+		{
+			name:        "all conditions match",
+			rule:        &models.ForwardRule{Sender: "alerts@example.com", Receiver: "inbox@example.com", Subject: "weekly report"},
+			wantMatched: true,
+			wantReasons: []string{
+				`sender equals "alerts@example.com"`,
+				`receiver equals "inbox@example.com"`,
+				`subject contains "weekly report"`,
+			},
+		},
+		{
+			name:        "one failing condition rejects the rule",
+			rule:        &models.ForwardRule{Sender: "alerts@example.com", Subject: "invoice"},
+			wantMatched: false,
+			wantReasons: []string{
+				`sender equals "alerts@example.com"`,
+				`not subject contains "invoice"`,
+			},
+		},
+		{
+			name:        "rule without conditions never matches",
+			rule:        &models.ForwardRule{},
+			wantMatched: false,
+			wantReasons: []string{"rule has no conditions"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchRule(tt.rule, message)
+			assert.Equal(t, tt.wantMatched, got.Matched)
+			assert.Equal(t, tt.wantReasons, got.Reasons)
+		})
+	}
+}
+
+func TestRuleService_Test(t *testing.T) {
+	testRuleID := test.RandomTestUUID()
+	message := &models.Message{Sender: "sender@example.com", Subject: "Hello"}
+
+	tests := []struct {
+		name        string
+		mockFn      func(*mocks.Repository)
+		wantMatched bool
+		wantErr     bool
+	}{
+		{
+			name: "matching rule",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetRule", mock.Anything, testRuleID).
+					Return(&models.ForwardRule{Base: models.Base{ID: testRuleID}, Sender: "sender@example.com"}, nil)
+			},
+			wantMatched: true,
+		},
+		{
+			name: "non-matching rule",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetRule", mock.Anything, testRuleID).
+					Return(&models.ForwardRule{Base: models.Base{ID: testRuleID}, Subject: "invoice"}, nil)
+			},
+			wantMatched: false,
+		},
+		{
+			name: "rule not found",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetRule", mock.Anything, testRuleID).Return(nil, storage.ErrNotFound)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupRuleTestCore(t)
+			tt.mockFn(mockRepo)
+
+			got, err := core.RuleService.Test(context.Background(), testRuleID, message)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testRuleID, got.RuleID)
+				assert.Equal(t, tt.wantMatched, got.Matched)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRuleService_Simulate(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	subjectRuleID := test.RandomTestUUID()
+	senderRuleID := test.RandomTestUUID()
+	firstMessageID := test.RandomTestUUID()
+	secondMessageID := test.RandomTestUUID()
+	thirdMessageID := test.RandomTestUUID()
+
+	tests := []struct {
+		name    string
+		mockFn  func(*mocks.Repository)
+		want    []null.String
+		wantErr bool
+	}{
+		{
+			name: "first matching rule wins",
+			mockFn: func(m *mocks.Repository) {
+				m.On("ListRulesByInbox", mock.Anything, testInboxID, 100, 0).Return([]*models.ForwardRule{
+					{Base: models.Base{ID: subjectRuleID}, Subject: "alert"},
+					{Base: models.Base{ID: senderRuleID}, Sender: "ops@example.com"},
+				}, 2, nil)
+				m.On("ListRecentMessagesByInbox", mock.Anything, testInboxID, 3).Return([]*models.Message{
+					{Base: models.Base{ID: firstMessageID}, Sender: "ops@example.com", Subject: "ALERT: disk full"},
+					{Base: models.Base{ID: secondMessageID}, Sender: "ops@example.com", Subject: "Daily digest"},
+					{Base: models.Base{ID: thirdMessageID}, Sender: "someone@example.com", Subject: "Hi"},
+				}, nil)
+			},
+			want: []null.String{
+				null.StringFrom(subjectRuleID),
+				null.StringFrom(senderRuleID),
+				{},
+			},
+		},
+		{
+			name: "repository error",
+			mockFn: func(m *mocks.Repository) {
+				m.On("ListRulesByInbox", mock.Anything, testInboxID, 100, 0).
+					Return(nil, 0, errors.New("database error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupRuleTestCore(t)
+			tt.mockFn(mockRepo)
+
+			got, err := core.RuleService.Simulate(context.Background(), testInboxID, 3)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got, len(tt.want))
+				for i, want := range tt.want {
+					assert.Equal(t, want, got[i].MatchedRuleID)
+				}
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRuleService_Forward(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	message := &models.Message{
+		Base:     models.Base{ID: test.RandomTestUUID()},
+		InboxID:  testInboxID,
+		Sender:   "alice@example.org",
+		Receiver: "ops@inbox451.dev",
+		Subject:  "ALERT: disk full",
+		Raw:      []byte("Subject: ALERT: disk full\r\n\r\nbody\r\n"),
+	}
+
+	tests := []struct {
+		name     string
+		outbound bool
+		srs      bool
+		mockFn   func(*mocks.Repository)
+		wantErr  bool
+	}{
+		{
+			name:     "outbound disabled",
+			outbound: false,
+			mockFn:   func(m *mocks.Repository) {},
+		},
+		{
+			name:     "no rule with a forwarding address matches",
+			outbound: true,
+			mockFn: func(m *mocks.Repository) {
+				m.On("ListRulesByInbox", mock.Anything, testInboxID, 100, 0).Return([]*models.ForwardRule{
+					{Subject: "alert"},
+					{Subject: "digest", ForwardTo: "bob@example.net"},
+				}, 2, nil)
+			},
+		},
+		{
+			name:     "matched rules are forwarded once per address",
+			outbound: true,
+			mockFn: func(m *mocks.Repository) {
+				m.On("ListRulesByInbox", mock.Anything, testInboxID, 100, 0).Return([]*models.ForwardRule{
+					{Subject: "alert", ForwardTo: "bob@example.net"},
+					{Sender: "alice@example.org", ForwardTo: "BOB@example.net"},
+					{Sender: "alice@example.org", ForwardTo: "carol@example.net"},
+				}, 3, nil)
+				m.On("EnqueueOutboundMessage", mock.Anything, mock.MatchedBy(func(msg *models.OutboundMessage) bool {
+					return msg.MailFrom == "alice@example.org" &&
+						assert.ObjectsAreEqual([]string{"bob@example.net", "carol@example.net"}, []string(msg.Recipients)) &&
+						!msg.UserID.Valid
+				})).Return(nil)
+			},
+		},
+		{
+			name:     "sender is rewritten with SRS",
+			outbound: true,
+			srs:      true,
+			mockFn: func(m *mocks.Repository) {
+				m.On("ListRulesByInbox", mock.Anything, testInboxID, 100, 0).Return([]*models.ForwardRule{
+					{Subject: "alert", ForwardTo: "bob@example.net"},
+				}, 1, nil)
+				m.On("EnqueueOutboundMessage", mock.Anything, mock.MatchedBy(func(msg *models.OutboundMessage) bool {
+					return strings.HasPrefix(msg.MailFrom, "SRS0=") &&
+						strings.HasSuffix(msg.MailFrom, "=example.org=alice@inbox451.dev")
+				})).Return(nil)
+			},
+		},
+		{
+			name:     "enqueue error",
+			outbound: true,
+			mockFn: func(m *mocks.Repository) {
+				m.On("ListRulesByInbox", mock.Anything, testInboxID, 100, 0).Return([]*models.ForwardRule{
+					{Subject: "alert", ForwardTo: "bob@example.net"},
+				}, 1, nil)
+				m.On("EnqueueOutboundMessage", mock.Anything, mock.Anything).
+					Return(errors.New("database error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupRuleTestCore(t)
+			core.Config = &config.Config{}
+			core.Config.Server.SMTP.Outbound.Enabled = tt.outbound
+			core.OutboundService = NewOutboundService(core)
+			if tt.srs {
+				rewriter, err := srs.New("inbox451.dev", []string{"secret"}, 0)
+				assert.NoError(t, err)
+				core.SRS = rewriter
+			}
+			tt.mockFn(mockRepo)
+
+			err := core.RuleService.Forward(context.Background(), message)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
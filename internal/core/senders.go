@@ -0,0 +1,183 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"inbox451/internal/models"
+)
+
+type SenderService struct {
+	core *Core
+}
+
+func NewSenderService(core *Core) SenderService {
+	return SenderService{core: core}
+}
+
+// Create adds an address the members of a project may submit mail from. The
+// address is either a full address or "@domain" for a whole domain.
+func (s *SenderService) Create(ctx context.Context, sender *models.SenderAddress) error {
+	address, ok := normalizeSenderAddress(sender.Address)
+	if !ok {
+		return &APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Sender address must be an email address or @domain",
+		}
+	}
+	sender.Address = address
+
+	managed, err := s.isManaged(ctx, sender.ProjectID, address)
+	if err != nil {
+		return err
+	}
+	if !managed {
+		return &APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Sender address must be on the email domain, a domain with a DKIM key or an inbox of the project",
+		}
+	}
+
+	s.core.Logger.Info("Creating sender address %s for project %s", sender.Address, sender.ProjectID)
+
+	if err := s.core.Repository.CreateSenderAddress(ctx, sender); err != nil {
+		s.core.Logger.Error("Failed to create sender address: %v", err)
+		return err
+	}
+
+	s.core.Logger.Info("Successfully created sender address with ID: %s", sender.ID)
+	return nil
+}
+
+func (s *SenderService) Get(ctx context.Context, id string) (*models.SenderAddress, error) {
+	s.core.Logger.Debug("Fetching sender address with ID: %s", id)
+
+	sender, err := s.core.Repository.GetSenderAddress(ctx, id)
+	if err != nil {
+		s.core.Logger.Error("Failed to fetch sender address: %v", err)
+		return nil, err
+	}
+	return sender, nil
+}
+
+func (s *SenderService) Delete(ctx context.Context, id string) error {
+	s.core.Logger.Info("Deleting sender address with ID: %s", id)
+
+	if err := s.core.Repository.DeleteSenderAddress(ctx, id); err != nil {
+		s.core.Logger.Error("Failed to delete sender address: %v", err)
+		return err
+	}
+
+	s.core.Logger.Info("Successfully deleted sender address with ID: %s", id)
+	return nil
+}
+
+func (s *SenderService) ListByProject(ctx context.Context, projectID string, limit, offset int) (*models.PaginatedResponse, error) {
+	s.core.Logger.Info("Listing sender addresses for project %s with limit: %d and offset: %d", projectID, limit, offset)
+
+	senders, total, err := s.core.Repository.ListSenderAddressesByProject(ctx, projectID, limit, offset)
+	if err != nil {
+		s.core.Logger.Error("Failed to list sender addresses: %v", err)
+		return nil, err
+	}
+
+	response := &models.PaginatedResponse{
+		Data: senders,
+	}
+	response.Pagination.Total = total
+	response.Pagination.Limit = limit
+	response.Pagination.Offset = offset
+
+	s.core.Logger.Info("Successfully retrieved %d sender addresses (total: %d)", len(senders), total)
+	return response, nil
+}
+
+// IsAllowed reports whether a user may submit mail from an address: one of the
+// inboxes of their projects, including its dotted wildcard variants, or a sender
+// address of their projects
+func (s *SenderService) IsAllowed(ctx context.Context, userID, address string) (bool, error) {
+	candidates := senderCandidates(address)
+	if len(candidates) == 0 {
+		return false, nil
+	}
+
+	allowed, err := s.core.Repository.IsSenderAllowed(ctx, userID, candidates)
+	if err != nil {
+		s.core.Logger.Error("Failed to check sender address %s: %v", address, err)
+		return false, err
+	}
+	return allowed, nil
+}
+
+// isManaged reports whether a normalized sender address is one this server may
+// send from: on the email domain, on a domain with a DKIM key, or an inbox of
+// the project. Outside domains would let a project send as anyone there.
+func (s *SenderService) isManaged(ctx context.Context, projectID, address string) (bool, error) {
+	local, domain, _ := strings.Cut(address, "@")
+	if emailDomain := strings.ToLower(s.core.Config.Server.EmailDomain); emailDomain != "" && domain == emailDomain {
+		return true, nil
+	}
+
+	if local != "" {
+		const batchSize = 100
+		for offset := 0; ; offset += batchSize {
+			inboxes, total, err := s.core.Repository.ListInboxesByProject(ctx, projectID, batchSize, offset)
+			if err != nil {
+				s.core.Logger.Error("Failed to list inboxes of project %s: %v", projectID, err)
+				return false, err
+			}
+			for _, inbox := range inboxes {
+				if strings.EqualFold(inbox.Email, address) {
+					return true, nil
+				}
+			}
+			if offset+len(inboxes) >= total || len(inboxes) < batchSize {
+				break
+			}
+		}
+	}
+
+	key, err := s.core.DKIMService.keyForDomain(ctx, domain)
+	if err != nil {
+		return false, err
+	}
+	return key != nil, nil
+}
+
+// normalizeSenderAddress lower-cases a sender address and reports whether it is
+// a valid address or "@domain"
+func normalizeSenderAddress(address string) (string, bool) {
+	address = strings.ToLower(strings.TrimSpace(address))
+	if domain, ok := strings.CutPrefix(address, "@"); ok {
+		if domain == "" || strings.ContainsAny(domain, "@ ") || !strings.Contains(domain, ".") {
+			return "", false
+		}
+		return address, true
+	}
+
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Address != address {
+		return "", false
+	}
+	return address, true
+}
+
+// senderCandidates returns the lower-cased addresses an address is allowed
+// through: the address itself, the inbox its dotted local part is delivered to
+// and its domain
+func senderCandidates(address string) []string {
+	address = strings.ToLower(strings.TrimSpace(address))
+	at := strings.LastIndex(address, "@")
+	if at <= 0 || at == len(address)-1 {
+		return nil
+	}
+
+	local, domain := address[:at], address[at:]
+	candidates := []string{address}
+	if dot := strings.Index(local, "."); dot > 0 {
+		candidates = append(candidates, local[:dot]+domain)
+	}
+	return append(candidates, domain)
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"inbox451/internal/config"
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+	"inbox451/internal/storage"
+	"inbox451/internal/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupSenderTestCore(t *testing.T) (*Core, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	logger := logger.New(io.Discard, logger.DEBUG)
+
+	core := &Core{
+		Config:     &config.Config{},
+		Logger:     logger,
+		Repository: mockRepo,
+	}
+	core.Config.Server.EmailDomain = "example.com"
+	core.SenderService = NewSenderService(core)
+	core.DKIMService = NewDKIMService(core)
+
+	return core, mockRepo
+}
+
+func TestSenderService_Create(t *testing.T) {
+	testProjectID := test.RandomTestUUID()
+	tests := []struct {
+		name        string
+		address     string
+		mockFn      func(*mocks.Repository)
+		wantAddress string
+		wantErr     bool
+	}{
+		{
+			name:    "address",
+			address: " Support@Example.com ",
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateSenderAddress", mock.Anything, mock.AnythingOfType("*models.SenderAddress")).Return(nil)
+			},
+			wantAddress: "support@example.com",
+		},
+		{
+			name:    "domain",
+			address: "@Example.com",
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateSenderAddress", mock.Anything, mock.AnythingOfType("*models.SenderAddress")).Return(nil)
+			},
+			wantAddress: "@example.com",
+		},
+		{
+			name:    "domain with a DKIM key",
+			address: "@mail.example.org",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetDKIMKeyByDomain", mock.Anything, "mail.example.org").Return(nil, storage.ErrNotFound)
+				m.On("GetDKIMKeyByDomain", mock.Anything, "example.org").Return(&models.DKIMKey{Domain: "example.org"}, nil)
+				m.On("CreateSenderAddress", mock.Anything, mock.AnythingOfType("*models.SenderAddress")).Return(nil)
+			},
+			wantAddress: "@mail.example.org",
+		},
+		{
+			name:    "inbox of the project",
+			address: "team@example.net",
+			mockFn: func(m *mocks.Repository) {
+				m.On("ListInboxesByProject", mock.Anything, testProjectID, 100, 0).
+					Return([]*models.Inbox{{Email: "Team@example.net"}}, 1, nil)
+				m.On("CreateSenderAddress", mock.Anything, mock.AnythingOfType("*models.SenderAddress")).Return(nil)
+			},
+			wantAddress: "team@example.net",
+		},
+		{
+			name:    "outside domain",
+			address: "@gmail.com",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetDKIMKeyByDomain", mock.Anything, "gmail.com").Return(nil, storage.ErrNotFound)
+			},
+			wantErr: true,
+		},
+		{
+			name:    "outside address",
+			address: "someone@gmail.com",
+			mockFn: func(m *mocks.Repository) {
+				m.On("ListInboxesByProject", mock.Anything, testProjectID, 100, 0).
+					Return([]*models.Inbox{{Email: "team@example.net"}}, 1, nil)
+				m.On("GetDKIMKeyByDomain", mock.Anything, "gmail.com").Return(nil, storage.ErrNotFound)
+			},
+			wantErr: true,
+		},
+		{name: "display name", address: "Support <support@example.com>", mockFn: func(m *mocks.Repository) {}, wantErr: true},
+		{name: "no domain", address: "support", mockFn: func(m *mocks.Repository) {}, wantErr: true},
+		{name: "bare at", address: "@", mockFn: func(m *mocks.Repository) {}, wantErr: true},
+		{
+			name:    "repository error",
+			address: "support@example.com",
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateSenderAddress", mock.Anything, mock.AnythingOfType("*models.SenderAddress")).
+					Return(errors.New("database error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupSenderTestCore(t)
+			tt.mockFn(mockRepo)
+
+			sender := &models.SenderAddress{ProjectID: testProjectID, Address: tt.address}
+			err := core.SenderService.Create(context.Background(), sender)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAddress, sender.Address)
+		})
+	}
+}
+
+func TestSenderService_IsAllowed(t *testing.T) {
+	testUserID := test.RandomTestUUID()
+	tests := []struct {
+		name           string
+		address        string
+		wantCandidates []string
+		allowed        bool
+	}{
+		{
+			name:           "inbox address",
+			address:        "Support@Example.com",
+			wantCandidates: []string{"support@example.com", "@example.com"},
+			allowed:        true,
+		},
+		{
+			name:           "dotted wildcard address",
+			address:        "support.billing@example.com",
+			wantCandidates: []string{"support.billing@example.com", "support@example.com", "@example.com"},
+			allowed:        true,
+		},
+		{
+			name:           "unknown address",
+			address:        "ceo@example.com",
+			wantCandidates: []string{"ceo@example.com", "@example.com"},
+			allowed:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupSenderTestCore(t)
+			mockRepo.On("IsSenderAllowed", mock.Anything, testUserID, tt.wantCandidates).Return(tt.allowed, nil)
+
+			allowed, err := core.SenderService.IsAllowed(context.Background(), testUserID, tt.address)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.allowed, allowed)
+		})
+	}
+
+	t.Run("not an address", func(t *testing.T) {
+		core, _ := setupSenderTestCore(t)
+
+		allowed, err := core.SenderService.IsAllowed(context.Background(), testUserID, "postmaster")
+		assert.NoError(t, err)
+		assert.False(t, allowed)
+	})
+}
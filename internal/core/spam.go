@@ -0,0 +1,31 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/emersion/go-message"
+)
+
+// IsSpam reports whether an upstream filter such as SpamAssassin or Rspamd marked
+// a message as spam, based on its X-Spam-Flag, X-Spam-Status and X-Spam-Score
+// headers. A threshold of zero ignores the score.
+func IsSpam(header message.Header, threshold float64) bool {
+	if strings.EqualFold(strings.TrimSpace(header.Get("X-Spam-Flag")), "yes") {
+		return true
+	}
+
+	status := strings.TrimSpace(header.Get("X-Spam-Status"))
+	if len(status) >= 3 && strings.EqualFold(status[:3], "yes") {
+		return true
+	}
+
+	if threshold > 0 {
+		score, err := strconv.ParseFloat(strings.TrimSpace(header.Get("X-Spam-Score")), 64)
+		if err == nil && score >= threshold {
+			return true
+		}
+	}
+
+	return false
+}
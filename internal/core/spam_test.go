@@ -0,0 +1,37 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/emersion/go-message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSpam(t *testing.T) {
+	tests := []struct {
+		name      string
+		headers   map[string]string
+		threshold float64
+		want      bool
+	}{
+		{name: "no spam headers", headers: map[string]string{}, threshold: 5, want: false},
+		{name: "spam flag", headers: map[string]string{"X-Spam-Flag": "YES"}, threshold: 5, want: true},
+		{name: "spam flag no", headers: map[string]string{"X-Spam-Flag": "NO"}, threshold: 5, want: false},
+		{name: "spam status", headers: map[string]string{"X-Spam-Status": "Yes, score=7.1 required=5.0"}, threshold: 5, want: true},
+		{name: "ham status", headers: map[string]string{"X-Spam-Status": "No, score=0.3 required=5.0"}, threshold: 5, want: false},
+		{name: "score above threshold", headers: map[string]string{"X-Spam-Score": "6.5"}, threshold: 5, want: true},
+		{name: "score below threshold", headers: map[string]string{"X-Spam-Score": "2.0"}, threshold: 5, want: false},
+		{name: "score ignored", headers: map[string]string{"X-Spam-Score": "12"}, threshold: 0, want: false},
+		{name: "invalid score", headers: map[string]string{"X-Spam-Score": "high"}, threshold: 5, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header message.Header
+			for k, v := range tt.headers {
+				header.Set(k, v)
+			}
+			assert.Equal(t, tt.want, IsSpam(header, tt.threshold))
+		})
+	}
+}
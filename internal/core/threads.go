@@ -0,0 +1,198 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"mime"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"inbox451/internal/models"
+
+	gomessage "github.com/emersion/go-message"
+	"github.com/emersion/go-message/mail"
+	"github.com/emersion/go-message/textproto"
+)
+
+// subjectThreadWindow is how far back a reply is matched by subject when its
+// references do not lead to a thread
+const subjectThreadWindow = 30 * 24 * time.Hour
+
+type ThreadService struct {
+	core *Core
+}
+
+func NewThreadService(core *Core) ThreadService {
+	return ThreadService{core: core}
+}
+
+// ListByInbox returns the threads of an inbox, the most recently active first
+func (s *ThreadService) ListByInbox(ctx context.Context, inboxID string, limit, offset int) (*models.PaginatedResponse, error) {
+	s.core.Logger.Info("Listing threads for inbox %s with limit: %d and offset: %d", inboxID, limit, offset)
+
+	threads, total, err := s.core.Repository.ListThreadsByInbox(ctx, inboxID, limit, offset)
+	if err != nil {
+		s.core.Logger.Error("Failed to list threads: %v", err)
+		return nil, err
+	}
+
+	response := &models.PaginatedResponse{
+		Data: threads,
+	}
+	response.Pagination.Total = total
+	response.Pagination.Limit = limit
+	response.Pagination.Offset = offset
+
+	s.core.Logger.Info("Successfully retrieved %d threads (total: %d)", len(threads), total)
+	return response, nil
+}
+
+// Messages returns the messages of a thread of an inbox with their labels, oldest
+// first
+func (s *ThreadService) Messages(ctx context.Context, inboxID, threadID string) ([]*models.Message, error) {
+	s.core.Logger.Debug("Fetching messages of thread %s", threadID)
+
+	all, err := s.core.Repository.ListMessagesByThreads(ctx, []string{threadID})
+	if err != nil {
+		s.core.Logger.Error("Failed to fetch thread messages: %v", err)
+		return nil, err
+	}
+
+	messages := make([]*models.Message, 0, len(all))
+	for _, message := range all {
+		if message.InboxID == inboxID {
+			messages = append(messages, message)
+		}
+	}
+	if len(messages) == 0 {
+		s.core.Logger.Info("Thread not found with ID: %s", threadID)
+		return nil, ErrNotFound
+	}
+
+	if err := s.core.LabelService.AttachToMessages(ctx, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ListMessages returns the messages of the given threads without their labels,
+// oldest first
+func (s *ThreadService) ListMessages(ctx context.Context, threadIDs []string) ([]*models.Message, error) {
+	messages, err := s.core.Repository.ListMessagesByThreads(ctx, threadIDs)
+	if err != nil {
+		s.core.Logger.Error("Failed to fetch thread messages: %v", err)
+		return nil, err
+	}
+	return messages, nil
+}
+
+// assignThread links a new message to the thread it belongs to. Messages are
+// linked through their Message-ID, In-Reply-To and References headers. A reply
+// whose references are unknown joins the latest recent thread with the same base
+// subject. A message that continues no thread is left to start one of its own.
+func (s *MessageService) assignThread(ctx context.Context, message *models.Message) {
+	subject := message.Subject
+	if len(message.Raw) > 0 && message.HeaderMessageID == "" && message.References == nil {
+		fields, err := textproto.ReadHeader(bufio.NewReader(bytes.NewReader(message.Raw)))
+		if err == nil {
+			header := mail.Header{Header: gomessage.Header{Header: fields}}
+			message.HeaderMessageID, message.References = threadHeaders(header)
+			if decoded, err := header.Subject(); err == nil {
+				subject = decoded
+			}
+		}
+	} else if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err == nil {
+		subject = decoded
+	}
+
+	baseSubject, isReply := BaseSubject(subject)
+	message.BaseSubject = baseSubject
+	if message.ThreadID != "" {
+		return
+	}
+
+	var threadID string
+	if message.HeaderMessageID != "" || len(message.References) > 0 {
+		var err error
+		threadID, err = s.core.Repository.FindThreadByMessageIDs(ctx, message.InboxID, message.References, message.HeaderMessageID)
+		if err != nil {
+			s.core.Logger.Error("Failed to look up thread by references: %v", err)
+			return
+		}
+	}
+	if threadID == "" && baseSubject != "" && (isReply || len(message.References) > 0) {
+		since := time.Now().Add(-subjectThreadWindow)
+		var err error
+		threadID, err = s.core.Repository.FindThreadBySubject(ctx, message.InboxID, baseSubject, since)
+		if err != nil {
+			s.core.Logger.Error("Failed to look up thread by subject: %v", err)
+			return
+		}
+	}
+	message.ThreadID = threadID
+}
+
+// threadHeaders returns the Message-ID of a message and the message IDs it
+// refers to: its References followed by its In-Reply-To, the parent last
+func threadHeaders(header mail.Header) (string, []string) {
+	messageID, _ := header.MessageID()
+
+	references, _ := header.MsgIDList("References")
+	inReplyTo, _ := header.MsgIDList("In-Reply-To")
+	if len(inReplyTo) > 0 {
+		parent := inReplyTo[0]
+		references = slices.DeleteFunc(references, func(id string) bool { return id == parent })
+		references = append(references, parent)
+	}
+	references = slices.DeleteFunc(references, func(id string) bool { return id == messageID })
+	if references == nil {
+		references = []string{}
+	}
+	return messageID, references
+}
+
+var (
+	subjectTrailer = regexp.MustCompile(`(?i)\s*\(fwd\)\s*$`)
+	subjectLeader  = regexp.MustCompile(`(?i)^\s*(re|fwd?)\s*(\[[^\[\]]*\]\s*)?:\s*`)
+	subjectBlob    = regexp.MustCompile(`^\s*\[[^\[\]]*\]\s*`)
+	subjectFwd     = regexp.MustCompile(`(?i)^\[fwd:\s*(.*)\]$`)
+	subjectSpace   = regexp.MustCompile(`\s+`)
+)
+
+// BaseSubject returns the base subject of a message as defined by RFC 5256: the
+// subject without its reply and forward prefixes and trailers, folded to lower
+// case for comparison. isReply reports whether any of them were removed.
+func BaseSubject(subject string) (base string, isReply bool) {
+	base = strings.TrimSpace(subjectSpace.ReplaceAllString(subject, " "))
+	for {
+		for subjectTrailer.MatchString(base) {
+			base = subjectTrailer.ReplaceAllString(base, "")
+			isReply = true
+		}
+
+		for {
+			if loc := subjectLeader.FindStringIndex(base); loc != nil {
+				base = base[loc[1]:]
+				isReply = true
+				continue
+			}
+			// A leading blob is only removed when something remains after it
+			if loc := subjectBlob.FindStringIndex(base); loc != nil && loc[1] < len(base) {
+				base = base[loc[1]:]
+				continue
+			}
+			break
+		}
+
+		match := subjectFwd.FindStringSubmatch(base)
+		if match == nil {
+			break
+		}
+		base = strings.TrimSpace(match[1])
+		isReply = true
+	}
+	return strings.ToLower(base), isReply
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"inbox451/internal/test"
+
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	null "github.com/volatiletech/null/v9"
+)
+
+func setupThreadTestCore(t *testing.T) (*Core, *mocks.Repository) {
+	core, mockRepo := setupMessageTestCore(t)
+	core.ThreadService = NewThreadService(core)
+	return core, mockRepo
+}
+
+func TestBaseSubject(t *testing.T) {
+	tests := []struct {
+		subject     string
+		wantBase    string
+		wantIsReply bool
+	}{
+		{"Order confirmation", "order confirmation", false},
+		{"Re: Order confirmation", "order confirmation", true},
+		{"RE: re:  Fwd: Order   confirmation", "order confirmation", true},
+		{"Fw: Order confirmation (fwd)", "order confirmation", true},
+		{"Re[2]: Order confirmation", "order confirmation", true},
+		{"[shop] Re: Order confirmation", "order confirmation", true},
+		{"[Fwd: Order confirmation]", "order confirmation", true},
+		{"[shop]", "[shop]", false},
+		{"Recipe: pancakes", "recipe: pancakes", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			base, isReply := BaseSubject(tt.subject)
+			assert.Equal(t, tt.wantBase, base)
+			assert.Equal(t, tt.wantIsReply, isReply)
+		})
+	}
+}
+
+func TestMessageService_StoreThreading(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	testThreadID := test.RandomTestUUID()
+
+	tests := []struct {
+		name           string
+		raw            string
+		mockFn         func(*mocks.Repository)
+		wantThreadID   string
+		wantReferences []string
+	}{
+		{
+			name: "reply to a known message",
+			raw: "Message-ID: <reply@example.com>\r\n" +
+				"In-Reply-To: <original@example.com>\r\n" +
+				"References: <root@example.com> <original@example.com>\r\n" +
+				"Subject: Re: Order confirmation\r\n\r\nThanks",
+			mockFn: func(m *mocks.Repository) {
+				m.On("FindThreadByMessageIDs", mock.Anything, testInboxID,
+					[]string{"root@example.com", "original@example.com"}, "reply@example.com").
+					Return(testThreadID, nil)
+			},
+			wantThreadID:   testThreadID,
+			wantReferences: []string{"root@example.com", "original@example.com"},
+		},
+		{
+			name: "reply to an unknown message",
+			raw: "Message-ID: <reply@example.com>\r\n" +
+				"In-Reply-To: <original@example.com>\r\n" +
+				"Subject: Re: Order confirmation\r\n\r\nThanks",
+			mockFn: func(m *mocks.Repository) {
+				m.On("FindThreadByMessageIDs", mock.Anything, testInboxID,
+					[]string{"original@example.com"}, "reply@example.com").
+					Return("", nil)
+				m.On("FindThreadBySubject", mock.Anything, testInboxID, "order confirmation", mock.AnythingOfType("time.Time")).
+					Return(testThreadID, nil)
+			},
+			wantThreadID:   testThreadID,
+			wantReferences: []string{"original@example.com"},
+		},
+		{
+			name: "new conversation",
+			raw: "Message-ID: <original@example.com>\r\n" +
+				"Subject: Order confirmation\r\n\r\nHello",
+			mockFn: func(m *mocks.Repository) {
+				m.On("FindThreadByMessageIDs", mock.Anything, testInboxID, []string{}, "original@example.com").
+					Return("", nil)
+			},
+			wantThreadID:   "",
+			wantReferences: []string{},
+		},
+		{
+			name: "lookup error starts a new thread",
+			raw: "Message-ID: <reply@example.com>\r\n" +
+				"In-Reply-To: <original@example.com>\r\n" +
+				"Subject: Re: Order confirmation\r\n\r\nThanks",
+			mockFn: func(m *mocks.Repository) {
+				m.On("FindThreadByMessageIDs", mock.Anything, testInboxID,
+					[]string{"original@example.com"}, "reply@example.com").
+					Return("", errors.New("database error"))
+			},
+			wantThreadID:   "",
+			wantReferences: []string{"original@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupMessageTestCore(t)
+			tt.mockFn(mockRepo)
+			mockRepo.On("GetInboxQuotas", mock.Anything, testInboxID).Return(unlimitedQuotas(testInboxID), nil)
+			mockRepo.On("CreateMessage", mock.Anything, mock.AnythingOfType("*models.Message")).Return(nil)
+
+			message, err := ParseRawMessage([]byte(tt.raw))
+			require.NoError(t, err)
+			message.InboxID = testInboxID
+
+			err = core.MessageService.Store(context.Background(), message)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantThreadID, message.ThreadID)
+			assert.Equal(t, tt.wantReferences, []string(message.References))
+			assert.Equal(t, "order confirmation", message.BaseSubject)
+		})
+	}
+}
+
+func TestThreadService_ListByInbox(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	testThreadID := test.RandomTestUUID()
+
+	core, mockRepo := setupThreadTestCore(t)
+	threads := []*models.Thread{{
+		ID:           testThreadID,
+		InboxID:      testInboxID,
+		Subject:      "Order confirmation",
+		MessageCount: 2,
+		LastActivity: null.TimeFrom(time.Now()),
+	}}
+	mockRepo.On("ListThreadsByInbox", mock.Anything, testInboxID, 10, 0).Return(threads, 1, nil)
+
+	response, err := core.ThreadService.ListByInbox(context.Background(), testInboxID, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, threads, response.Data)
+	assert.Equal(t, 1, response.Pagination.Total)
+	assert.Equal(t, 10, response.Pagination.Limit)
+}
+
+func TestThreadService_Messages(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	otherInboxID := test.RandomTestUUID()
+	testThreadID := test.RandomTestUUID()
+	testMessageID := test.RandomTestUUID()
+
+	t.Run("messages of the inbox", func(t *testing.T) {
+		core, mockRepo := setupThreadTestCore(t)
+		mockRepo.On("ListMessagesByThreads", mock.Anything, []string{testThreadID}).Return([]*models.Message{
+			{Base: models.Base{ID: testThreadID}, InboxID: testInboxID, ThreadID: testThreadID},
+			{Base: models.Base{ID: testMessageID}, InboxID: otherInboxID, ThreadID: testThreadID},
+		}, nil)
+		mockRepo.On("ListLabelsByMessages", mock.Anything, []string{testThreadID}).Return([]*models.MessageLabel{}, nil)
+
+		messages, err := core.ThreadService.Messages(context.Background(), testInboxID, testThreadID)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Equal(t, testThreadID, messages[0].ID)
+	})
+
+	t.Run("unknown thread", func(t *testing.T) {
+		core, mockRepo := setupThreadTestCore(t)
+		mockRepo.On("ListMessagesByThreads", mock.Anything, []string{testThreadID}).Return([]*models.Message{}, nil)
+
+		_, err := core.ThreadService.Messages(context.Background(), testInboxID, testThreadID)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"inbox451/internal/models"
+
+	gomessage "github.com/emersion/go-message"
+	null "github.com/volatiletech/null/v9"
+)
+
+// maxTLSReportSize caps a decompressed report, reports are a few kilobytes
+const maxTLSReportSize = 10 * 1024 * 1024
+
+var errInvalidTLSReport = errors.New("invalid TLS report")
+
+// tlsReport is the JSON of an SMTP TLS report, RFC 8460 section 4.4. Only the
+// fields stored in their own columns are decoded, the policies are kept as
+// sent.
+type tlsReport struct {
+	OrganizationName string `json:"organization-name"`
+	DateRange        struct {
+		Start time.Time `json:"start-datetime"`
+		End   time.Time `json:"end-datetime"`
+	} `json:"date-range"`
+	ContactInfo string          `json:"contact-info"`
+	ReportID    string          `json:"report-id"`
+	Policies    json.RawMessage `json:"policies"`
+}
+
+type tlsReportPolicy struct {
+	Policy struct {
+		PolicyDomain string `json:"policy-domain"`
+	} `json:"policy"`
+	Summary struct {
+		Successful int64 `json:"total-successful-session-count"`
+		Failed     int64 `json:"total-failure-session-count"`
+	} `json:"summary"`
+}
+
+type TLSReportService struct {
+	core *Core
+}
+
+func NewTLSReportService(core *Core) TLSReportService {
+	return TLSReportService{core: core}
+}
+
+// IsReportAddress reports whether mail to address carries TLS reports
+func (s *TLSReportService) IsReportAddress(address string) bool {
+	reportAddress := s.core.Config.Server.SMTP.TLSRPT.Address
+	return reportAddress != "" && strings.EqualFold(address, reportAddress)
+}
+
+// Ingest stores the reports attached to a message mailed to the report address,
+// as application/tlsrpt+gzip or application/tlsrpt+json parts. Parts that
+// aren't valid reports are logged and skipped, so only storage failures are
+// returned. It returns how many reports were stored.
+func (s *TLSReportService) Ingest(ctx context.Context, raw []byte) (int, error) {
+	entity, err := gomessage.Read(bytes.NewReader(raw))
+	if err != nil && !gomessage.IsUnknownCharset(err) {
+		s.core.Logger.Warn("Ignoring unreadable TLS report message: %v", err)
+		return 0, nil
+	}
+
+	var reports []*models.TLSReport
+	err = entity.Walk(func(path []int, part *gomessage.Entity, err error) error {
+		if err != nil {
+			return err
+		}
+		mediaType, _, _ := part.Header.ContentType()
+		var body io.Reader
+		switch mediaType {
+		case "application/tlsrpt+gzip", "application/gzip", "application/x-gzip":
+			zr, err := gzip.NewReader(part.Body)
+			if err != nil {
+				s.core.Logger.Warn("Ignoring TLS report that is not gzip compressed: %v", err)
+				return nil
+			}
+			defer zr.Close()
+			body = zr
+		case "application/tlsrpt+json", "application/json":
+			body = part.Body
+		default:
+			return nil
+		}
+
+		data, err := io.ReadAll(io.LimitReader(body, maxTLSReportSize+1))
+		if err != nil || len(data) > maxTLSReportSize {
+			s.core.Logger.Warn("Ignoring TLS report that could not be read: %v", err)
+			return nil
+		}
+		report, err := parseTLSReport(data)
+		if err != nil {
+			s.core.Logger.Warn("Ignoring TLS report: %v", err)
+			return nil
+		}
+		reports = append(reports, report)
+		return nil
+	})
+	if err != nil {
+		s.core.Logger.Warn("Ignoring the rest of a malformed TLS report message: %v", err)
+	}
+
+	for i, report := range reports {
+		s.core.Logger.Info("Storing TLS report %s from %s for %s", report.ReportID, report.OrganizationName, strings.Join(report.Domains, ", "))
+		if err := s.core.Repository.CreateTLSReport(ctx, report); err != nil {
+			s.core.Logger.Error("Failed to store TLS report: %v", err)
+			return i, err
+		}
+	}
+	return len(reports), nil
+}
+
+// parseTLSReport decodes a report and sums up its policies
+func parseTLSReport(data []byte) (*models.TLSReport, error) {
+	var report tlsReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidTLSReport, err)
+	}
+	if report.OrganizationName == "" || report.ReportID == "" {
+		return nil, fmt.Errorf("%w: missing organization-name or report-id", errInvalidTLSReport)
+	}
+	if report.DateRange.Start.IsZero() || report.DateRange.End.IsZero() {
+		return nil, fmt.Errorf("%w: missing date-range", errInvalidTLSReport)
+	}
+
+	var policies []tlsReportPolicy
+	if len(report.Policies) > 0 {
+		if err := json.Unmarshal(report.Policies, &policies); err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidTLSReport, err)
+		}
+	} else {
+		report.Policies = json.RawMessage(`[]`)
+	}
+
+	result := &models.TLSReport{
+		OrganizationName: report.OrganizationName,
+		ReportID:         report.ReportID,
+		ContactInfo:      report.ContactInfo,
+		StartDate:        null.TimeFrom(report.DateRange.Start),
+		EndDate:          null.TimeFrom(report.DateRange.End),
+		Domains:          []string{},
+		Policies:         report.Policies,
+	}
+	seen := make(map[string]bool)
+	for _, policy := range policies {
+		result.SuccessfulSessions += policy.Summary.Successful
+		result.FailedSessions += policy.Summary.Failed
+
+		domain := strings.TrimSuffix(strings.ToLower(policy.Policy.PolicyDomain), ".")
+		if domain != "" && !seen[domain] {
+			seen[domain] = true
+			result.Domains = append(result.Domains, domain)
+		}
+	}
+	sort.Strings(result.Domains)
+	return result, nil
+}
+
+func (s *TLSReportService) Get(ctx context.Context, id string) (*models.TLSReport, error) {
+	s.core.Logger.Debug("Fetching TLS report with ID: %s", id)
+
+	report, err := s.core.Repository.GetTLSReport(ctx, id)
+	if err != nil {
+		s.core.Logger.Error("Failed to fetch TLS report: %v", err)
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *TLSReportService) Delete(ctx context.Context, id string) error {
+	s.core.Logger.Info("Deleting TLS report with ID: %s", id)
+
+	if err := s.core.Repository.DeleteTLSReport(ctx, id); err != nil {
+		s.core.Logger.Error("Failed to delete TLS report: %v", err)
+		return err
+	}
+	return nil
+}
+
+// List returns the newest reports first, only those about domain unless it is
+// empty
+func (s *TLSReportService) List(ctx context.Context, domain string, limit, offset int) (*models.PaginatedResponse, error) {
+	s.core.Logger.Info("Listing TLS reports for domain %q with limit: %d and offset: %d", domain, limit, offset)
+
+	reports, total, err := s.core.Repository.ListTLSReports(ctx, strings.ToLower(domain), limit, offset)
+	if err != nil {
+		s.core.Logger.Error("Failed to list TLS reports: %v", err)
+		return nil, err
+	}
+
+	response := &models.PaginatedResponse{
+		Data: reports,
+	}
+	response.Pagination.Total = total
+	response.Pagination.Limit = limit
+	response.Pagination.Offset = offset
+
+	return response, nil
+}
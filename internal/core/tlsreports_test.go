@@ -0,0 +1,188 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"inbox451/internal/config"
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testTLSReport is the example report of RFC 8460 section 4.5, trimmed
+const testTLSReport = `{
+  "organization-name": "Company-X",
+  "date-range": {
+    "start-datetime": "2016-04-01T00:00:00Z",
+    "end-datetime": "2016-04-01T23:59:59Z"
+  },
+  "contact-info": "sts-reporting@company-x.example",
+  "report-id": "5065427c-23d3-47ca-b6e0-946ea0e8c4be",
+  "policies": [{
+    "policy": {
+      "policy-type": "sts",
+      "policy-string": ["version: STSv1", "mode: testing", "mx: *.mail.company-y.example", "max_age: 86400"],
+      "policy-domain": "Company-Y.example",
+      "mx-host": ["*.mail.company-y.example"]
+    },
+    "summary": {
+      "total-successful-session-count": 5326,
+      "total-failure-session-count": 303
+    },
+    "failure-details": [{
+      "result-type": "certificate-expired",
+      "sending-mta-ip": "2001:db8:abcd:0012::1",
+      "receiving-mx-hostname": "mx1.mail.company-y.example",
+      "failed-session-count": 100
+    }]
+  }]
+}`
+
+func setupTLSReportTestCore(t *testing.T) (*Core, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	logger := logger.New(io.Discard, logger.DEBUG)
+
+	core := &Core{
+		Config:     &config.Config{},
+		Logger:     logger,
+		Repository: mockRepo,
+	}
+	core.Config.Server.SMTP.TLSRPT.Address = "tlsrpt@example.com"
+	core.TLSReportService = NewTLSReportService(core)
+
+	return core, mockRepo
+}
+
+// tlsReportMessage mails report as an application/tlsrpt+gzip attachment
+func tlsReportMessage(t *testing.T, report string) []byte {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, err := zw.Write([]byte(report))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	return []byte("From: tlsrpt-noreply@company-x.example\r\n" +
+		"To: tlsrpt@example.com\r\n" +
+		"Subject: Report Domain: company-y.example\r\n" +
+		"TLS-Report-Domain: company-y.example\r\n" +
+		"TLS-Report-Submitter: company-x.example\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/report; report-type=\"tlsrpt\"; boundary=\"report\"\r\n" +
+		"\r\n" +
+		"--report\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"This is an aggregate TLS report from company-x.example\r\n" +
+		"--report\r\n" +
+		"Content-Type: application/tlsrpt+gzip\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"Content-Disposition: attachment; filename=\"company-x.example!company-y.example!1459468800!1459555199.json.gz\"\r\n" +
+		"\r\n" +
+		base64.StdEncoding.EncodeToString(compressed.Bytes()) + "\r\n" +
+		"--report--\r\n")
+}
+
+func TestTLSReportService_IsReportAddress(t *testing.T) {
+	core, _ := setupTLSReportTestCore(t)
+
+	assert.True(t, core.TLSReportService.IsReportAddress("TLSRPT@example.com"))
+	assert.False(t, core.TLSReportService.IsReportAddress("dmarc@example.com"))
+
+	core.Config.Server.SMTP.TLSRPT.Address = ""
+	assert.False(t, core.TLSReportService.IsReportAddress(""))
+}
+
+func TestTLSReportService_Ingest(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		raw       []byte
+		mockFn    func(*mocks.Repository)
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			name: "gzip attachment",
+			raw:  tlsReportMessage(t, testTLSReport),
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateTLSReport", ctx, mock.MatchedBy(func(r *models.TLSReport) bool {
+					return r.OrganizationName == "Company-X" &&
+						r.ReportID == "5065427c-23d3-47ca-b6e0-946ea0e8c4be" &&
+						r.StartDate.Time.Equal(time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)) &&
+						assert.ObjectsAreEqual(pq.StringArray{"company-y.example"}, r.Domains) &&
+						r.SuccessfulSessions == 5326 && r.FailedSessions == 303 &&
+						strings.Contains(string(r.Policies), "certificate-expired")
+				})).Return(nil).Once()
+			},
+			wantCount: 1,
+		},
+		{
+			name: "json body",
+			raw:  []byte("Content-Type: application/tlsrpt+json\r\n\r\n" + testTLSReport),
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateTLSReport", ctx, mock.Anything).Return(nil).Once()
+			},
+			wantCount: 1,
+		},
+		{
+			name:   "invalid report is skipped",
+			raw:    tlsReportMessage(t, `{"organization-name": "Company-X"}`),
+			mockFn: func(m *mocks.Repository) {},
+		},
+		{
+			name:   "no report",
+			raw:    []byte("Content-Type: text/plain\r\n\r\nHello"),
+			mockFn: func(m *mocks.Repository) {},
+		},
+		{
+			name: "storage error",
+			raw:  tlsReportMessage(t, testTLSReport),
+			mockFn: func(m *mocks.Repository) {
+				m.On("CreateTLSReport", ctx, mock.Anything).Return(errors.New("database error")).Once()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupTLSReportTestCore(t)
+			tt.mockFn(mockRepo)
+
+			count, err := core.TLSReportService.Ingest(ctx, tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCount, count)
+		})
+	}
+}
+
+func TestTLSReportService_List(t *testing.T) {
+	ctx := context.Background()
+	core, mockRepo := setupTLSReportTestCore(t)
+
+	reports := []*models.TLSReport{{OrganizationName: "Company-X"}}
+	mockRepo.On("ListTLSReports", ctx, "example.com", 10, 0).Return(reports, 1, nil).Once()
+
+	response, err := core.TLSReportService.List(ctx, "Example.com", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, reports, response.Data)
+	assert.Equal(t, 1, response.Pagination.Total)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 
 	"inbox451/internal/storage"
 
@@ -89,6 +91,33 @@ func (s *UserService) Update(ctx context.Context, user *models.User) error {
 	return nil
 }
 
+// SetDefaultInbox sets the inbox IMAP clients of the user see as INBOX. The
+// inbox must belong to one of the user's projects. An empty inboxID goes back
+// to the first inbox of the user.
+func (s *UserService) SetDefaultInbox(ctx context.Context, userID string, inboxID string) error {
+	s.core.Logger.Info("Setting default inbox of user %s to %q", userID, inboxID)
+
+	if inboxID != "" {
+		inboxes, err := s.core.Repository.ListInboxesByUser(ctx, userID)
+		if err != nil {
+			s.core.Logger.Error("Failed to list inboxes by user: %v", err)
+			return err
+		}
+		if findInbox(inboxes, inboxID) == nil {
+			return &APIError{
+				Code:    http.StatusBadRequest,
+				Message: "Inbox is not in any project of the user",
+			}
+		}
+	}
+
+	if err := s.core.Repository.UpdateUserDefaultInbox(ctx, userID, inboxID); err != nil {
+		s.core.Logger.Error("Failed to update default inbox: %v", err)
+		return err
+	}
+	return nil
+}
+
 func (s *UserService) Delete(ctx context.Context, id string) error {
 	s.core.Logger.Info("Deleting user with ID: %s", id)
 
@@ -200,3 +229,47 @@ func (s *UserService) LoginWithToken(ctx context.Context, username, tokenValue s
 	s.core.Logger.Info("Login successful for username: %s with token", username)
 	return user, nil
 }
+
+// BearerVerifier checks an OAuth 2.0 bearer token, such as an OIDC access
+// token, and returns the email address of the user it was issued to.
+type BearerVerifier interface {
+	VerifyBearer(ctx context.Context, token string) (string, error)
+}
+
+// LoginWithBearer authenticates a mail client presenting a bearer token with
+// SASL OAUTHBEARER or XOAUTH2. The username the client sent, if any, must be
+// the username or the email address of the token's user.
+func (s *UserService) LoginWithBearer(ctx context.Context, username, token string) (*models.User, error) {
+	if s.core.BearerVerifier == nil {
+		s.core.Logger.Warn("Login failed: bearer tokens are not accepted without OIDC")
+		return nil, ErrAuthFailed
+	}
+
+	email, err := s.core.BearerVerifier.VerifyBearer(ctx, token)
+	if err != nil {
+		s.core.Logger.Warn("Login failed: invalid bearer token for username %s: %v", username, err)
+		return nil, ErrAuthFailed
+	}
+
+	user, err := s.core.Repository.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			s.core.Logger.Warn("Login failed: no user with email %s", email)
+			return nil, ErrAuthFailed
+		}
+		s.core.Logger.Error("Database error during login for email %s: %v", email, err)
+		return nil, err
+	}
+
+	if username != "" && !strings.EqualFold(username, user.Username) && !strings.EqualFold(username, user.Email) {
+		s.core.Logger.Warn("Login failed: bearer token of %s presented for username %s", email, username)
+		return nil, ErrAuthFailed
+	}
+	if user.Status != "active" {
+		s.core.Logger.Warn("Login failed: User account is inactive for email: %s", email)
+		return nil, ErrAccountInactive
+	}
+
+	s.core.Logger.Info("Login successful for username: %s with bearer token", user.Username)
+	return user, nil
+}
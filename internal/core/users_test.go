@@ -319,6 +319,60 @@ func TestUserService_Delete(t *testing.T) {
 	}
 }
 
+func TestUserService_SetDefaultInbox(t *testing.T) {
+	testUserID := test.RandomTestUUID()
+	testInboxID := test.RandomTestUUID()
+	testOtherInboxID := test.RandomTestUUID()
+	tests := []struct {
+		name    string
+		inboxID string
+		mockFn  func(*mocks.Repository)
+		wantErr bool
+	}{
+		{
+			name:    "inbox of the user",
+			inboxID: testInboxID,
+			mockFn: func(m *mocks.Repository) {
+				m.On("ListInboxesByUser", mock.Anything, testUserID).
+					Return([]*models.Inbox{{Base: models.Base{ID: testInboxID}}}, nil)
+				m.On("UpdateUserDefaultInbox", mock.Anything, testUserID, testInboxID).Return(nil)
+			},
+		},
+		{
+			name:    "inbox outside the projects of the user",
+			inboxID: testOtherInboxID,
+			mockFn: func(m *mocks.Repository) {
+				m.On("ListInboxesByUser", mock.Anything, testUserID).
+					Return([]*models.Inbox{{Base: models.Base{ID: testInboxID}}}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:    "reset",
+			inboxID: "",
+			mockFn: func(m *mocks.Repository) {
+				m.On("UpdateUserDefaultInbox", mock.Anything, testUserID, "").Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupTestCore(t)
+			tt.mockFn(mockRepo)
+
+			err := core.UserService.SetDefaultInbox(context.Background(), testUserID, tt.inboxID)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestUserService_LoginWithPassword(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -385,3 +439,117 @@ func TestUserService_LoginWithPassword(t *testing.T) {
 		})
 	}
 }
+
+type stubBearerVerifier map[string]string
+
+func (v stubBearerVerifier) VerifyBearer(ctx context.Context, token string) (string, error) {
+	email, ok := v[token]
+	if !ok {
+		return "", errors.New("token is not valid")
+	}
+	return email, nil
+}
+
+func TestUserService_LoginWithBearer(t *testing.T) {
+	activeUser := &models.User{
+		Base:     models.Base{ID: "test-user-id-1"},
+		Username: "testuser",
+		Email:    "test@example.com",
+		Status:   "active",
+	}
+	verifier := stubBearerVerifier{"valid-token": "test@example.com", "stranger-token": "nobody@example.com"}
+
+	tests := []struct {
+		name     string
+		verifier BearerVerifier
+		username string
+		token    string
+		mockFn   func(*mocks.Repository)
+		wantErr  error
+	}{
+		{
+			name:     "successful login",
+			verifier: verifier,
+			username: "testuser",
+			token:    "valid-token",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(activeUser, nil)
+			},
+		},
+		{
+			name:     "username may be the email",
+			verifier: verifier,
+			username: "TEST@example.com",
+			token:    "valid-token",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(activeUser, nil)
+			},
+		},
+		{
+			name:     "OIDC disabled",
+			username: "testuser",
+			token:    "valid-token",
+			mockFn:   func(m *mocks.Repository) {},
+			wantErr:  ErrAuthFailed,
+		},
+		{
+			name:     "invalid token",
+			verifier: verifier,
+			username: "testuser",
+			token:    "expired-token",
+			mockFn:   func(m *mocks.Repository) {},
+			wantErr:  ErrAuthFailed,
+		},
+		{
+			name:     "unknown user",
+			verifier: verifier,
+			token:    "stranger-token",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(nil, storage.ErrNotFound)
+			},
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:     "token of another user",
+			verifier: verifier,
+			username: "someoneelse",
+			token:    "valid-token",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(activeUser, nil)
+			},
+			wantErr: ErrAuthFailed,
+		},
+		{
+			name:     "inactive user",
+			verifier: verifier,
+			username: "testuser",
+			token:    "valid-token",
+			mockFn: func(m *mocks.Repository) {
+				m.On("GetUserByEmail", mock.Anything, "test@example.com").Return(&models.User{
+					Username: "testuser",
+					Email:    "test@example.com",
+					Status:   "inactive",
+				}, nil)
+			},
+			wantErr: ErrAccountInactive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, mockRepo := setupTestCore(t)
+			core.BearerVerifier = tt.verifier
+			tt.mockFn(mockRepo)
+
+			got, err := core.UserService.LoginWithBearer(context.Background(), tt.username, tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, activeUser, got)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,221 @@
+// Package dnsbl looks up client addresses on DNS blocklists (RFC 5782).
+//
+// An address a.b.c.d is listed on zone when d.c.b.a.zone resolves to an
+// address in 127.0.0.0/8, the code telling why; a TXT record at the same name
+// usually gives a reason. IPv6 addresses are looked up by their nibbles in
+// reverse.
+package dnsbl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Modes of the MTA when a client is listed
+const (
+	ModeReject = "reject"
+	ModeTag    = "tag"
+)
+
+// Defaults of the cache TTL and of the time a check may take, when unset
+const (
+	DefaultCacheTTL = time.Hour
+	DefaultTimeout  = 5 * time.Second
+)
+
+var ErrNoZones = errors.New("no DNS blocklist zone configured")
+
+// Resolver is the part of *net.Resolver lookups go through, so that tests can
+// serve a zone of their own
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Listing is a blocklist an address is on
+type Listing struct {
+	Zone string
+	// Codes are the 127.0.0.0/8 addresses the lookup returned
+	Codes []string
+	// Reason is the TXT record of the listing, if there is one
+	Reason string
+}
+
+// String is the zone with its codes, e.g. "zen.spamhaus.org=127.0.0.2"
+func (l Listing) String() string {
+	return l.Zone + "=" + strings.Join(l.Codes, ",")
+}
+
+type cacheEntry struct {
+	listings []Listing
+	expires  time.Time
+}
+
+// Checker looks addresses up on a set of zones and remembers the results for
+// a while
+type Checker struct {
+	zones    []string
+	resolver Resolver
+	ttl      time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	cache     map[string]cacheEntry
+	lastPrune time.Time
+}
+
+// New returns a checker of the given zones, which caches results for ttl and
+// gives up on lookups after timeout, both defaulted when not positive. A nil
+// resolver uses the system one.
+func New(zones []string, resolver Resolver, ttl, timeout time.Duration) (*Checker, error) {
+	c := &Checker{
+		resolver: resolver,
+		ttl:      ttl,
+		timeout:  timeout,
+		now:      time.Now,
+		cache:    make(map[string]cacheEntry),
+	}
+	if c.resolver == nil {
+		c.resolver = net.DefaultResolver
+	}
+	// A zero TTL would disable the cache and a zero timeout every lookup
+	if c.ttl <= 0 {
+		c.ttl = DefaultCacheTTL
+	}
+	if c.timeout <= 0 {
+		c.timeout = DefaultTimeout
+	}
+	for _, zone := range zones {
+		zone = strings.Trim(strings.ToLower(strings.TrimSpace(zone)), ".")
+		if zone != "" {
+			c.zones = append(c.zones, zone)
+		}
+	}
+	if len(c.zones) == 0 {
+		return nil, ErrNoZones
+	}
+	c.lastPrune = c.now()
+	return c, nil
+}
+
+// Zones lists the zones addresses are looked up on
+func (c *Checker) Zones() []string {
+	return c.zones
+}
+
+// Check returns the zones ip is listed on, looking them up concurrently.
+// Loopback, private and link-local addresses are never listed. When some
+// lookups fail or time out, the listings found by the others are returned
+// with the error and nothing is cached.
+func (c *Checker) Check(ctx context.Context, ip net.IP) ([]Listing, error) {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return nil, nil
+	}
+
+	key := ip.String()
+	c.mu.Lock()
+	entry, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.listings, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	name := reverseName(ip)
+	listings := make([]*Listing, len(c.zones))
+	errs := make([]error, len(c.zones))
+	var wg sync.WaitGroup
+	for i, zone := range c.zones {
+		wg.Add(1)
+		go func(i int, zone string) {
+			defer wg.Done()
+			listings[i], errs[i] = c.lookup(ctx, name+"."+zone, zone)
+		}(i, zone)
+	}
+	wg.Wait()
+
+	var found []Listing
+	for _, listing := range listings {
+		if listing != nil {
+			found = append(found, *listing)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return found, err
+	}
+
+	c.store(key, found)
+	return found, nil
+}
+
+// lookup queries one zone, a nil listing meaning the address isn't on it
+func (c *Checker) lookup(ctx context.Context, name, zone string) (*Listing, error) {
+	addrs, err := c.resolver.LookupHost(ctx, name)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", zone, err)
+	}
+
+	listing := &Listing{Zone: zone}
+	for _, addr := range addrs {
+		// Answers outside 127.0.0.0/8 aren't listings, and 127.255.255.0/24
+		// is how some lists refuse to answer, e.g. public resolvers
+		ip := net.ParseIP(addr).To4()
+		if ip == nil || ip[0] != 127 || (ip[1] == 255 && ip[2] == 255) {
+			continue
+		}
+		listing.Codes = append(listing.Codes, ip.String())
+	}
+	if len(listing.Codes) == 0 {
+		return nil, nil
+	}
+
+	// The reason is a courtesy, a listing stands without it
+	if txts, err := c.resolver.LookupTXT(ctx, name); err == nil && len(txts) > 0 {
+		listing.Reason = strings.Join(txts, " ")
+	}
+	return listing, nil
+}
+
+// store caches the listings of key, dropping expired entries now and then
+func (c *Checker) store(key string, listings []Listing) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if now.Sub(c.lastPrune) > c.ttl {
+		for k, entry := range c.cache {
+			if !now.Before(entry.expires) {
+				delete(c.cache, k)
+			}
+		}
+		c.lastPrune = now
+	}
+	c.cache[key] = cacheEntry{listings: listings, expires: now.Add(c.ttl)}
+}
+
+// reverseName is the name ip is looked up under, without the zone
+func reverseName(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d", ip4[3], ip4[2], ip4[1], ip4[0])
+	}
+
+	const hex = "0123456789abcdef"
+	ip16 := ip.To16()
+	nibbles := make([]string, 0, 32)
+	for i := len(ip16) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, string(hex[ip16[i]&0x0f]), string(hex[ip16[i]>>4]))
+	}
+	return strings.Join(nibbles, ".")
+}
@@ -0,0 +1,148 @@
+package dnsbl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeZone answers from its records, every other name doesn't exist
+type fakeZone struct {
+	mu      sync.Mutex
+	hosts   map[string][]string
+	txts    map[string][]string
+	fail    map[string]bool
+	lookups int
+}
+
+func (z *fakeZone) LookupHost(ctx context.Context, host string) ([]string, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.lookups++
+	if z.fail[host] {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: host, IsTemporary: true}
+	}
+	if addrs, ok := z.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (z *fakeZone) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if txts, ok := z.txts[name]; ok {
+		return txts, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func TestReverseName(t *testing.T) {
+	assert.Equal(t, "2.0.0.127", reverseName(net.ParseIP("127.0.0.2")))
+	assert.Equal(t, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2",
+		reverseName(net.ParseIP("2001:db8::1")))
+}
+
+func TestNew(t *testing.T) {
+	_, err := New([]string{"", " "}, nil, time.Hour, time.Second)
+	assert.ErrorIs(t, err, ErrNoZones)
+
+	c, err := New([]string{" Zen.Spamhaus.org. ", "bl.example"}, nil, time.Hour, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zen.spamhaus.org", "bl.example"}, c.Zones())
+
+	c, err = New([]string{"bl.example"}, nil, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCacheTTL, c.ttl)
+	assert.Equal(t, DefaultTimeout, c.timeout)
+}
+
+func TestChecker_Check(t *testing.T) {
+	ctx := context.Background()
+	zone := &fakeZone{
+		hosts: map[string][]string{
+			"7.100.51.198.other.example": {"127.0.0.4", "127.0.0.10"},
+			"7.100.51.198.bl.example":    {"127.0.0.3"},
+			"9.100.51.198.bl.example":    {"127.255.255.254"},
+			"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.bl.example": {"127.0.0.2"},
+		},
+		txts: map[string][]string{
+			"7.100.51.198.bl.example": {"https://bl.example/lookup?ip=198.51.100.7"},
+		},
+		fail: map[string]bool{"8.100.51.198.other.example": true},
+	}
+	c, err := New([]string{"bl.example", "other.example"}, zone, time.Hour, time.Second)
+	require.NoError(t, err)
+
+	t.Run("listed", func(t *testing.T) {
+		listings, err := c.Check(ctx, net.ParseIP("198.51.100.7"))
+		require.NoError(t, err)
+		require.Len(t, listings, 2)
+		assert.Equal(t, "bl.example", listings[0].Zone)
+		assert.Equal(t, []string{"127.0.0.3"}, listings[0].Codes)
+		assert.Equal(t, "https://bl.example/lookup?ip=198.51.100.7", listings[0].Reason)
+		assert.Equal(t, "other.example=127.0.0.4,127.0.0.10", listings[1].String())
+		assert.Empty(t, listings[1].Reason)
+	})
+
+	t.Run("IPv6", func(t *testing.T) {
+		listings, err := c.Check(ctx, net.ParseIP("2001:db8::1"))
+		require.NoError(t, err)
+		require.Len(t, listings, 1)
+		assert.Equal(t, "bl.example", listings[0].Zone)
+	})
+
+	t.Run("not listed", func(t *testing.T) {
+		listings, err := c.Check(ctx, net.ParseIP("203.0.113.1"))
+		require.NoError(t, err)
+		assert.Empty(t, listings)
+	})
+
+	t.Run("refusals are not listings", func(t *testing.T) {
+		listings, err := c.Check(ctx, net.ParseIP("198.51.100.9"))
+		require.NoError(t, err)
+		assert.Empty(t, listings)
+	})
+
+	t.Run("private addresses are not looked up", func(t *testing.T) {
+		before := zone.lookups
+		listings, err := c.Check(ctx, net.ParseIP("127.0.0.2"))
+		require.NoError(t, err)
+		assert.Empty(t, listings)
+		listings, err = c.Check(ctx, net.ParseIP("10.1.2.3"))
+		require.NoError(t, err)
+		assert.Empty(t, listings)
+		assert.Equal(t, before, zone.lookups)
+	})
+
+	t.Run("results are cached", func(t *testing.T) {
+		before := zone.lookups
+		listings, err := c.Check(ctx, net.ParseIP("198.51.100.7"))
+		require.NoError(t, err)
+		assert.Len(t, listings, 2)
+		assert.Equal(t, before, zone.lookups)
+
+		c.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		defer func() { c.now = time.Now }()
+		_, err = c.Check(ctx, net.ParseIP("198.51.100.7"))
+		require.NoError(t, err)
+		assert.Equal(t, before+2, zone.lookups)
+	})
+
+	t.Run("failed lookups are not cached", func(t *testing.T) {
+		ip := net.ParseIP("198.51.100.8")
+		_, err := c.Check(ctx, ip)
+		var dnsErr *net.DNSError
+		assert.True(t, errors.As(err, &dnsErr))
+		assert.ErrorContains(t, err, "other.example")
+
+		before := zone.lookups
+		_, err = c.Check(ctx, ip)
+		assert.Error(t, err)
+		assert.Equal(t, before+2, zone.lookups)
+	})
+}
@@ -0,0 +1,70 @@
+// Package encryption protects secrets stored in the database with AES-256-GCM
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+)
+
+// version prefixes every ciphertext, so that the format can change later
+const version byte = 1
+
+var (
+	ErrNoKey      = errors.New("no encryption key configured")
+	ErrCiphertext = errors.New("invalid or tampered ciphertext")
+)
+
+// Cipher encrypts and decrypts data with a key derived from a configured secret
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// New returns a cipher for the given secret, which can be any string. The
+// encryption key is its SHA-256 hash.
+func New(secret string) (*Cipher, error) {
+	if secret == "" {
+		return nil, ErrNoKey
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt returns the version, a random nonce and the sealed plaintext
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, 1+len(nonce)+len(plaintext)+c.aead.Overhead())
+	out = append(out, version)
+	out = append(out, nonce...)
+	return c.aead.Seal(out, nonce, plaintext, nil), nil
+}
+
+// Decrypt opens data sealed by Encrypt with the same secret
+func (c *Cipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	nonceSize := c.aead.NonceSize()
+	if len(ciphertext) < 1+nonceSize || ciphertext[0] != version {
+		return nil, ErrCiphertext
+	}
+
+	nonce, sealed := ciphertext[1:1+nonceSize], ciphertext[1+nonceSize:]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrCiphertext
+	}
+	return plaintext, nil
+}
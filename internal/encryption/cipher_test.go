@@ -0,0 +1,50 @@
+package encryption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCipher(t *testing.T) {
+	c, err := New("correct horse battery staple")
+	require.NoError(t, err)
+
+	plaintext := []byte("private key material")
+	ciphertext, err := c.Encrypt(plaintext)
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), string(plaintext))
+
+	again, err := c.Encrypt(plaintext)
+	require.NoError(t, err)
+	assert.NotEqual(t, ciphertext, again, "nonces must differ")
+
+	decrypted, err := c.Decrypt(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	t.Run("wrong secret", func(t *testing.T) {
+		other, err := New("another secret")
+		require.NoError(t, err)
+		_, err = other.Decrypt(ciphertext)
+		assert.ErrorIs(t, err, ErrCiphertext)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		tampered := append([]byte(nil), ciphertext...)
+		tampered[len(tampered)-1] ^= 1
+		_, err := c.Decrypt(tampered)
+		assert.ErrorIs(t, err, ErrCiphertext)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		_, err := c.Decrypt(ciphertext[:5])
+		assert.ErrorIs(t, err, ErrCiphertext)
+	})
+}
+
+func TestNew_NoKey(t *testing.T) {
+	_, err := New("")
+	assert.ErrorIs(t, err, ErrNoKey)
+}
@@ -15,7 +15,7 @@ type ImapBackend struct {
 }
 
 // NewBackend creates a new IMAP backend
-func NewBackend(core *core.Core) backend.Backend {
+func NewBackend(core *core.Core) *ImapBackend {
 	return &ImapBackend{core: core}
 }
 
@@ -33,3 +33,19 @@ func (be *ImapBackend) Login(connInfo *imap.ConnInfo, username string, password
 	be.core.Logger.Info("IMAP Token Login successful for username: %s", username)
 	return NewImapUser(ctx, user, be.core), nil
 }
+
+// LoginWithBearer authenticates with an OIDC access token sent over SASL
+// OAUTHBEARER or XOAUTH2, the username is optional
+func (be *ImapBackend) LoginWithBearer(connInfo *imap.ConnInfo, username string, token string) (backend.User, error) {
+	be.core.Logger.Info("IMAP Bearer Login attempt for username: %s", username)
+
+	ctx := context.Background()
+	user, err := be.core.UserService.LoginWithBearer(ctx, username, token)
+	if err != nil {
+		be.core.Logger.Warn("IMAP Bearer Login failed for username: %s, error: %v", username, err)
+		return nil, backend.ErrInvalidCredentials
+	}
+
+	be.core.Logger.Info("IMAP Bearer Login successful for username: %s", user.Username)
+	return NewImapUser(ctx, user, be.core), nil
+}
@@ -0,0 +1,604 @@
+package imap
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/responses"
+	"github.com/emersion/go-imap/server"
+)
+
+const (
+	capCondstore = "CONDSTORE"
+	capQresync   = "QRESYNC"
+
+	statusHighestModSeq imap.StatusItem = "HIGHESTMODSEQ"
+	fetchModSeq         imap.FetchItem  = "MODSEQ"
+)
+
+// formatModSeq formats a mod-sequence. go-imap only writes 32-bit numbers,
+// while mod-sequences are 63-bit.
+func formatModSeq(modSeq uint64) imap.RawString {
+	return imap.RawString(strconv.FormatUint(modSeq, 10))
+}
+
+// parseModSeq parses a mod-sequence value sent by the client
+func parseModSeq(f interface{}) (uint64, error) {
+	var s string
+	switch f := f.(type) {
+	case uint32:
+		return uint64(f), nil
+	case string:
+		s = f
+	case imap.RawString:
+		s = string(f)
+	default:
+		return 0, errors.New("Mod-sequence must be a number")
+	}
+	return strconv.ParseUint(s, 10, 63)
+}
+
+// condstoreMailbox is implemented by mailboxes that track mod-sequences (RFC 7162)
+type condstoreMailbox interface {
+	UIDValidity() uint32
+	HighestModSeq() (uint64, error)
+	ExpungedSince(modSeq uint64) ([]uint32, error)
+	ListMessagesChangedSince(uid bool, seqSet *imap.SeqSet, items []imap.FetchItem, changedSince uint64, ch chan<- *imap.Message) error
+	SearchMessagesModSeq(uid bool, criteria *imap.SearchCriteria, modSeq uint64) ([]uint32, uint64, error)
+	UpdateMessagesFlagsUnchangedSince(uid bool, seqSet *imap.SeqSet, op imap.FlagsOp, flags []string, unchangedSince uint64) ([]uint32, error)
+}
+
+// extensionEnabler is implemented by users that remember the extensions the
+// client turned on during the session
+type extensionEnabler interface {
+	Enable(capability string)
+	Enabled(capability string) bool
+}
+
+// enable turns on an extension for the session. QRESYNC implies CONDSTORE.
+func enable(conn server.Conn, capability string) {
+	user, ok := conn.Context().User.(extensionEnabler)
+	if !ok {
+		return
+	}
+	user.Enable(capability)
+	if capability == capQresync {
+		user.Enable(capCondstore)
+	}
+}
+
+// enabled reports whether an extension is turned on for the session
+func enabled(conn server.Conn, capability string) bool {
+	user, ok := conn.Context().User.(extensionEnabler)
+	return ok && user.Enabled(capability)
+}
+
+// writeMessages sends the messages list produces as FETCH responses. list must
+// close the channel, like backend.Mailbox.ListMessages does.
+func writeMessages(conn server.Conn, list func(ch chan<- *imap.Message) error) error {
+	ch := make(chan *imap.Message)
+	done := make(chan error, 1)
+	go func() {
+		done <- conn.WriteResp(&responses.Fetch{Messages: ch})
+		// Drain the channel if writing failed early
+		for range ch {
+		}
+	}()
+
+	if err := list(ch); err != nil {
+		return err
+	}
+	return <-done
+}
+
+// writeVanished sends a VANISHED response for the given UIDs. EARLIER marks
+// messages removed before the current command, as opposed to by it.
+func writeVanished(conn server.Conn, uids []uint32, earlier bool) error {
+	if len(uids) == 0 {
+		return nil
+	}
+
+	set := new(imap.SeqSet)
+	set.AddNum(uids...)
+
+	fields := []interface{}{imap.RawString("VANISHED")}
+	if earlier {
+		fields = append(fields, []interface{}{imap.RawString("EARLIER")})
+	}
+	fields = append(fields, set)
+	return conn.WriteResp(imap.NewUntaggedResp(fields))
+}
+
+// condstoreExtension implements RFC 7162 CONDSTORE and QRESYNC, together with
+// the RFC 5161 ENABLE command QRESYNC has to be turned on with. Mod-sequences
+// are stored per message; removed messages leave their UID behind so that
+// VANISHED responses can be sent for them.
+type condstoreExtension struct{}
+
+func (condstoreExtension) Capabilities(c server.Conn) []string {
+	return []string{"ENABLE", capCondstore, capQresync}
+}
+
+func (condstoreExtension) Command(name string) server.HandlerFactory {
+	switch name {
+	case "ENABLE":
+		return func() server.Handler { return &enableCommand{} }
+	case "SELECT":
+		return func() server.Handler { return &condstoreSelect{} }
+	case "EXAMINE":
+		return func() server.Handler {
+			hdlr := &condstoreSelect{}
+			hdlr.ReadOnly = true
+			return hdlr
+		}
+	case "FETCH":
+		return func() server.Handler { return &condstoreFetch{} }
+	case "STORE":
+		return func() server.Handler { return &condstoreStore{} }
+	case "SEARCH":
+		return func() server.Handler { return &condstoreSearch{} }
+	}
+	return nil
+}
+
+type enableCommand struct {
+	Capabilities []string
+}
+
+func (cmd *enableCommand) Parse(fields []interface{}) error {
+	if len(fields) == 0 {
+		return errors.New("No enough arguments")
+	}
+	for _, f := range fields {
+		capability, ok := f.(string)
+		if !ok {
+			return errors.New("Capability must be an atom")
+		}
+		cmd.Capabilities = append(cmd.Capabilities, strings.ToUpper(capability))
+	}
+	return nil
+}
+
+func (cmd *enableCommand) Handle(conn server.Conn) error {
+	if conn.Context().User == nil {
+		return server.ErrNotAuthenticated
+	}
+
+	// Only the extensions that are actually turned on are listed
+	fields := []interface{}{imap.RawString("ENABLED")}
+	for _, capability := range cmd.Capabilities {
+		if capability == capCondstore || capability == capQresync {
+			enable(conn, capability)
+			fields = append(fields, imap.RawString(capability))
+		}
+	}
+	return conn.WriteResp(imap.NewUntaggedResp(fields))
+}
+
+// qresyncParams are the parameters of SELECT (QRESYNC (...))
+type qresyncParams struct {
+	uidValidity uint32
+	modSeq      uint64
+	// knownUIDs is nil when the client did not send them
+	knownUIDs *imap.SeqSet
+}
+
+type condstoreSelect struct {
+	server.Select
+	condstore bool
+	qresync   *qresyncParams
+}
+
+func (cmd *condstoreSelect) Parse(fields []interface{}) error {
+	if len(fields) > 1 {
+		params, ok := fields[1].([]interface{})
+		if !ok {
+			return errors.New("SELECT parameters must be a list")
+		}
+		for i := 0; i < len(params); i++ {
+			name, _ := params[i].(string)
+			switch strings.ToUpper(name) {
+			case capCondstore:
+				cmd.condstore = true
+			case capQresync:
+				if i+1 >= len(params) {
+					return errors.New("Missing QRESYNC parameters")
+				}
+				i++
+				args, ok := params[i].([]interface{})
+				if !ok || len(args) < 2 {
+					return errors.New("QRESYNC parameters must be a list")
+				}
+				qresync, err := parseQresyncParams(args)
+				if err != nil {
+					return err
+				}
+				cmd.qresync = qresync
+			default:
+				return errors.New("Unknown SELECT parameter")
+			}
+		}
+		fields = fields[:1]
+	}
+	return cmd.Select.Parse(fields)
+}
+
+func parseQresyncParams(args []interface{}) (*qresyncParams, error) {
+	uidValidity, err := imap.ParseNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	modSeq, err := parseModSeq(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	params := &qresyncParams{uidValidity: uidValidity, modSeq: modSeq}
+	// The optional sequence match data is a list; only the known UIDs are used
+	if len(args) > 2 {
+		if known, ok := args[2].(string); ok {
+			if params.knownUIDs, err = imap.ParseSeqSet(known); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return params, nil
+}
+
+func (cmd *condstoreSelect) Handle(conn server.Conn) error {
+	if cmd.qresync != nil && !enabled(conn, capQresync) {
+		return errors.New("QRESYNC is not enabled")
+	}
+	if cmd.condstore {
+		enable(conn, capCondstore)
+	}
+
+	// The built-in SELECT returns its tagged OK response as an error, so the
+	// selected mailbox tells whether it succeeded
+	res := cmd.Select.Handle(conn)
+	mbox, ok := conn.Context().Mailbox.(condstoreMailbox)
+	if !ok {
+		return res
+	}
+
+	highestModSeq, err := mbox.HighestModSeq()
+	if err != nil {
+		return err
+	}
+	if err := conn.WriteResp(&imap.StatusResp{
+		Type:      imap.StatusRespOk,
+		Code:      "HIGHESTMODSEQ",
+		Arguments: []interface{}{formatModSeq(highestModSeq)},
+		Info:      "Highest",
+	}); err != nil {
+		return err
+	}
+
+	if cmd.qresync != nil {
+		if err := cmd.resync(conn, mbox); err != nil {
+			return err
+		}
+	}
+	return res
+}
+
+// resync tells a QRESYNC client what changed since the mod-sequence it knows
+// about: the messages removed since, then the flags of the messages changed since
+func (cmd *condstoreSelect) resync(conn server.Conn, mbox condstoreMailbox) error {
+	// With a new UIDVALIDITY nothing the client knows is valid anymore
+	if mbox.UIDValidity() != cmd.qresync.uidValidity {
+		return nil
+	}
+
+	expunged, err := mbox.ExpungedSince(cmd.qresync.modSeq)
+	if err != nil {
+		return err
+	}
+	var vanished []uint32
+	for _, uid := range expunged {
+		if cmd.qresync.knownUIDs == nil || cmd.qresync.knownUIDs.Contains(uid) {
+			vanished = append(vanished, uid)
+		}
+	}
+	if err := writeVanished(conn, vanished, true); err != nil {
+		return err
+	}
+
+	all, _ := imap.ParseSeqSet("1:*")
+	items := []imap.FetchItem{imap.FetchUid, imap.FetchFlags, fetchModSeq}
+	return writeMessages(conn, func(ch chan<- *imap.Message) error {
+		return mbox.ListMessagesChangedSince(true, all, items, cmd.qresync.modSeq, ch)
+	})
+}
+
+type condstoreFetch struct {
+	server.Fetch
+	changedSince uint64
+	vanished     bool
+}
+
+func (cmd *condstoreFetch) Parse(fields []interface{}) error {
+	if len(fields) > 2 {
+		modifiers, ok := fields[2].([]interface{})
+		if !ok {
+			return errors.New("FETCH modifiers must be a list")
+		}
+		for i := 0; i < len(modifiers); i++ {
+			name, _ := modifiers[i].(string)
+			switch strings.ToUpper(name) {
+			case "CHANGEDSINCE":
+				if i+1 >= len(modifiers) {
+					return errors.New("Missing CHANGEDSINCE value")
+				}
+				i++
+				changedSince, err := parseModSeq(modifiers[i])
+				if err != nil {
+					return err
+				}
+				cmd.changedSince = changedSince
+			case "VANISHED":
+				cmd.vanished = true
+			default:
+				return errors.New("Unknown FETCH modifier")
+			}
+		}
+		fields = fields[:2]
+	}
+	return cmd.Fetch.Parse(fields)
+}
+
+func (cmd *condstoreFetch) handle(uid bool, conn server.Conn) error {
+	ctx := conn.Context()
+	if ctx.Mailbox == nil {
+		return server.ErrNoMailboxSelected
+	}
+	mbox, ok := ctx.Mailbox.(condstoreMailbox)
+	if !ok {
+		if uid {
+			return cmd.Fetch.UidHandle(conn)
+		}
+		return cmd.Fetch.Handle(conn)
+	}
+
+	if cmd.vanished && (!uid || cmd.changedSince == 0 || !enabled(conn, capQresync)) {
+		return errors.New("VANISHED requires UID FETCH with CHANGEDSINCE and QRESYNC enabled")
+	}
+
+	items := cmd.Items
+	hasUID, hasModSeq := false, false
+	for _, item := range items {
+		hasUID = hasUID || item == imap.FetchUid
+		hasModSeq = hasModSeq || item == fetchModSeq
+	}
+	if uid && !hasUID {
+		items = append(items, imap.FetchUid)
+	}
+	if cmd.changedSince > 0 && !hasModSeq {
+		items = append(items, fetchModSeq)
+		hasModSeq = true
+	}
+	if hasModSeq {
+		enable(conn, capCondstore)
+	}
+
+	if cmd.vanished {
+		expunged, err := mbox.ExpungedSince(cmd.changedSince)
+		if err != nil {
+			return err
+		}
+		var vanished []uint32
+		for _, uid := range expunged {
+			if cmd.SeqSet.Contains(uid) {
+				vanished = append(vanished, uid)
+			}
+		}
+		if err := writeVanished(conn, vanished, true); err != nil {
+			return err
+		}
+	}
+
+	return writeMessages(conn, func(ch chan<- *imap.Message) error {
+		return mbox.ListMessagesChangedSince(uid, cmd.SeqSet, items, cmd.changedSince, ch)
+	})
+}
+
+func (cmd *condstoreFetch) Handle(conn server.Conn) error {
+	return cmd.handle(false, conn)
+}
+
+func (cmd *condstoreFetch) UidHandle(conn server.Conn) error {
+	return cmd.handle(true, conn)
+}
+
+type condstoreStore struct {
+	server.Store
+	unchangedSince uint64
+}
+
+func (cmd *condstoreStore) Parse(fields []interface{}) error {
+	if len(fields) > 1 {
+		if modifiers, ok := fields[1].([]interface{}); ok {
+			if len(modifiers) != 2 || !strings.EqualFold(stringField(modifiers[0]), "UNCHANGEDSINCE") {
+				return errors.New("Unknown STORE modifier")
+			}
+			unchangedSince, err := parseModSeq(modifiers[1])
+			if err != nil {
+				return err
+			}
+			cmd.unchangedSince = unchangedSince
+			fields = append([]interface{}{fields[0]}, fields[2:]...)
+		}
+	}
+	return cmd.Store.Parse(fields)
+}
+
+func (cmd *condstoreStore) handle(uid bool, conn server.Conn) error {
+	ctx := conn.Context()
+	if ctx.Mailbox == nil {
+		return server.ErrNoMailboxSelected
+	}
+	if ctx.MailboxReadOnly {
+		return server.ErrMailboxReadOnly
+	}
+	mbox, ok := ctx.Mailbox.(condstoreMailbox)
+	if !ok {
+		if uid {
+			return cmd.Store.UidHandle(conn)
+		}
+		return cmd.Store.Handle(conn)
+	}
+
+	op, silent, err := imap.ParseFlagsOp(cmd.Item)
+	if err != nil {
+		return err
+	}
+
+	var flags []string
+	if flagsList, ok := cmd.Value.([]interface{}); ok {
+		if flags, err = imap.ParseStringList(flagsList); err != nil {
+			return err
+		}
+	} else {
+		flag, err := imap.ParseString(cmd.Value)
+		if err != nil {
+			return err
+		}
+		flags = []string{flag}
+	}
+	for i, flag := range flags {
+		flags[i] = imap.CanonicalFlag(flag)
+	}
+
+	if cmd.unchangedSince > 0 {
+		enable(conn, capCondstore)
+	}
+
+	modified, err := mbox.UpdateMessagesFlagsUnchangedSince(uid, cmd.SeqSet, op, flags, cmd.unchangedSince)
+	if err != nil {
+		return err
+	}
+
+	// Like the built-in STORE, report the new flags unless asked not to. CONDSTORE
+	// clients always learn the new mod-sequences.
+	var items []imap.FetchItem
+	if !silent {
+		items = append(items, imap.FetchFlags)
+	}
+	if enabled(conn, capCondstore) {
+		items = append(items, fetchModSeq)
+	}
+	if len(items) > 0 {
+		if uid {
+			items = append(items, imap.FetchUid)
+		}
+		if err := writeMessages(conn, func(ch chan<- *imap.Message) error {
+			return mbox.ListMessagesChangedSince(uid, cmd.SeqSet, items, 0, ch)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(modified) > 0 {
+		set := new(imap.SeqSet)
+		set.AddNum(modified...)
+		return server.ErrStatusResp(&imap.StatusResp{
+			Type:      imap.StatusRespOk,
+			Code:      "MODIFIED",
+			Arguments: []interface{}{set},
+			Info:      "Conditional STORE failed",
+		})
+	}
+	return nil
+}
+
+func (cmd *condstoreStore) Handle(conn server.Conn) error {
+	return cmd.handle(false, conn)
+}
+
+func (cmd *condstoreStore) UidHandle(conn server.Conn) error {
+	return cmd.handle(true, conn)
+}
+
+type condstoreSearch struct {
+	server.Search
+	modSeq uint64
+}
+
+func (cmd *condstoreSearch) Parse(fields []interface{}) error {
+	// go-imap does not know the MODSEQ criterion, so it is taken out of the
+	// top-level criteria before they are parsed
+	rest := make([]interface{}, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		if !strings.EqualFold(stringField(fields[i]), "MODSEQ") {
+			rest = append(rest, fields[i])
+			continue
+		}
+		// MODSEQ [<entry-name> <entry-type>] <mod-sequence>; entries are not
+		// tracked, so the value applies to all flags
+		if i+1 < len(fields) {
+			if _, err := parseModSeq(fields[i+1]); err != nil {
+				i += 2
+			}
+		}
+		if i+1 >= len(fields) {
+			return errors.New("Missing MODSEQ value")
+		}
+		i++
+		modSeq, err := parseModSeq(fields[i])
+		if err != nil {
+			return err
+		}
+		cmd.modSeq = modSeq
+	}
+	if len(rest) == 0 {
+		rest = append(rest, "ALL")
+	}
+	return cmd.Search.Parse(rest)
+}
+
+func (cmd *condstoreSearch) handle(uid bool, conn server.Conn) error {
+	ctx := conn.Context()
+	if ctx.Mailbox == nil {
+		return server.ErrNoMailboxSelected
+	}
+	mbox, ok := ctx.Mailbox.(condstoreMailbox)
+	if !ok || cmd.modSeq == 0 {
+		if uid {
+			return cmd.Search.UidHandle(conn)
+		}
+		return cmd.Search.Handle(conn)
+	}
+
+	enable(conn, capCondstore)
+
+	ids, highestModSeq, err := mbox.SearchMessagesModSeq(uid, cmd.Criteria, cmd.modSeq)
+	if err != nil {
+		return err
+	}
+
+	// The response ends with the highest mod-sequence of the matching messages
+	fields := []interface{}{imap.RawString("SEARCH")}
+	for _, id := range ids {
+		fields = append(fields, id)
+	}
+	if len(ids) > 0 {
+		fields = append(fields, []interface{}{imap.RawString("MODSEQ"), formatModSeq(highestModSeq)})
+	}
+	return conn.WriteResp(imap.NewUntaggedResp(fields))
+}
+
+func (cmd *condstoreSearch) Handle(conn server.Conn) error {
+	return cmd.handle(false, conn)
+}
+
+func (cmd *condstoreSearch) UidHandle(conn server.Conn) error {
+	return cmd.handle(true, conn)
+}
+
+// stringField returns a field as a string, or an empty string for other types
+func stringField(f interface{}) string {
+	s, _ := f.(string)
+	return s
+}
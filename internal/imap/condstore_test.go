@@ -0,0 +1,108 @@
+package imap
+
+import (
+	"testing"
+
+	"inbox451/internal/models"
+
+	"github.com/emersion/go-imap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModSeq(t *testing.T) {
+	modSeq, err := parseModSeq("917162500")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(917162500), modSeq)
+
+	modSeq, err = parseModSeq("9223372036854775807")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9223372036854775807), modSeq)
+
+	_, err = parseModSeq("9223372036854775808")
+	assert.Error(t, err, "mod-sequences are 63-bit")
+
+	_, err = parseModSeq([]interface{}{})
+	assert.Error(t, err)
+}
+
+func TestCondstoreSelect_Parse(t *testing.T) {
+	t.Run("plain", func(t *testing.T) {
+		cmd := &condstoreSelect{}
+		require.NoError(t, cmd.Parse([]interface{}{"INBOX"}))
+		assert.Equal(t, "INBOX", cmd.Mailbox)
+		assert.False(t, cmd.condstore)
+		assert.Nil(t, cmd.qresync)
+	})
+
+	t.Run("condstore", func(t *testing.T) {
+		cmd := &condstoreSelect{}
+		require.NoError(t, cmd.Parse([]interface{}{"INBOX", []interface{}{"CONDSTORE"}}))
+		assert.True(t, cmd.condstore)
+	})
+
+	t.Run("qresync", func(t *testing.T) {
+		cmd := &condstoreSelect{}
+		fields := []interface{}{"INBOX", []interface{}{"QRESYNC", []interface{}{"67890007", "20050715194045000", "41,43:211"}}}
+		require.NoError(t, cmd.Parse(fields))
+		require.NotNil(t, cmd.qresync)
+		assert.Equal(t, uint32(67890007), cmd.qresync.uidValidity)
+		assert.Equal(t, uint64(20050715194045000), cmd.qresync.modSeq)
+		require.NotNil(t, cmd.qresync.knownUIDs)
+		assert.True(t, cmd.qresync.knownUIDs.Contains(100))
+		assert.False(t, cmd.qresync.knownUIDs.Contains(42))
+	})
+
+	t.Run("unknown parameter", func(t *testing.T) {
+		cmd := &condstoreSelect{}
+		assert.Error(t, cmd.Parse([]interface{}{"INBOX", []interface{}{"FOO"}}))
+	})
+}
+
+func TestCondstoreFetch_Parse(t *testing.T) {
+	cmd := &condstoreFetch{}
+	fields := []interface{}{"1:*", []interface{}{"FLAGS"}, []interface{}{"CHANGEDSINCE", "12345", "VANISHED"}}
+	require.NoError(t, cmd.Parse(fields))
+	assert.Equal(t, uint64(12345), cmd.changedSince)
+	assert.True(t, cmd.vanished)
+	assert.Equal(t, []imap.FetchItem{imap.FetchFlags}, cmd.Items)
+
+	cmd = &condstoreFetch{}
+	require.NoError(t, cmd.Parse([]interface{}{"1", []interface{}{"FLAGS", "MODSEQ"}}))
+	assert.Zero(t, cmd.changedSince)
+	assert.Contains(t, cmd.Items, fetchModSeq)
+}
+
+func TestCondstoreStore_Parse(t *testing.T) {
+	cmd := &condstoreStore{}
+	fields := []interface{}{"7,9", []interface{}{"UNCHANGEDSINCE", "320162338"}, "+FLAGS.SILENT", []interface{}{`\Deleted`}}
+	require.NoError(t, cmd.Parse(fields))
+	assert.Equal(t, uint64(320162338), cmd.unchangedSince)
+	assert.Equal(t, imap.StoreItem("+FLAGS.SILENT"), cmd.Item)
+
+	cmd = &condstoreStore{}
+	require.NoError(t, cmd.Parse([]interface{}{"1", "FLAGS", []interface{}{`\Seen`}}))
+	assert.Zero(t, cmd.unchangedSince)
+}
+
+func TestCondstoreSearch_Parse(t *testing.T) {
+	cmd := &condstoreSearch{}
+	require.NoError(t, cmd.Parse([]interface{}{"MODSEQ", "620162338"}))
+	assert.Equal(t, uint64(620162338), cmd.modSeq)
+
+	cmd = &condstoreSearch{}
+	require.NoError(t, cmd.Parse([]interface{}{"UNSEEN", "MODSEQ", "/flags/\\draft", "all", "620162338"}))
+	assert.Equal(t, uint64(620162338), cmd.modSeq)
+	assert.Contains(t, cmd.Criteria.WithoutFlags, imap.SeenFlag)
+
+	cmd = &condstoreSearch{}
+	assert.Error(t, cmd.Parse([]interface{}{"MODSEQ"}))
+}
+
+func TestBuildImapMessage_ModSeq(t *testing.T) {
+	msg := &models.Message{UID: 3, ModSeq: 1234567890123}
+
+	imapMsg, err := buildImapMessage(msg, 1, []imap.FetchItem{fetchModSeq})
+	require.NoError(t, err)
+	assert.Equal(t, []any{imap.RawString("1234567890123")}, imapMsg.Items[fetchModSeq])
+}
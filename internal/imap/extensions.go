@@ -0,0 +1,316 @@
+package imap
+
+import (
+	"errors"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/responses"
+	"github.com/emersion/go-imap/server"
+)
+
+// specialUseExtension advertises RFC 6154 SPECIAL-USE. The attributes themselves
+// are returned by ImapMailbox.Info, so no additional commands are needed.
+type specialUseExtension struct{}
+
+func (specialUseExtension) Capabilities(c server.Conn) []string {
+	return []string{"SPECIAL-USE"}
+}
+
+func (specialUseExtension) Command(name string) server.HandlerFactory {
+	return nil
+}
+
+// uidAppender is implemented by mailboxes that report the UID of appended messages
+type uidAppender interface {
+	CreateMessageUID(flags []string, date time.Time, body imap.Literal) (uidValidity uint32, uid uint32, err error)
+}
+
+// uidExpunger is implemented by mailboxes that can expunge a subset of their messages
+type uidExpunger interface {
+	ExpungeMessages(uids []uint32) error
+}
+
+// uidplusExtension implements RFC 4315 UIDPLUS: APPEND answers with APPENDUID
+// and UID EXPUNGE only removes the deleted messages among the given UIDs.
+type uidplusExtension struct{}
+
+func (uidplusExtension) Capabilities(c server.Conn) []string {
+	return []string{"UIDPLUS"}
+}
+
+func (uidplusExtension) Command(name string) server.HandlerFactory {
+	switch name {
+	case "APPEND":
+		return func() server.Handler { return &appendUID{} }
+	case "EXPUNGE":
+		return func() server.Handler { return &uidExpunge{} }
+	}
+	return nil
+}
+
+type appendUID struct {
+	server.Append
+}
+
+func (cmd *appendUID) Handle(conn server.Conn) error {
+	ctx := conn.Context()
+	if ctx.User == nil {
+		return server.ErrNotAuthenticated
+	}
+
+	mbox, err := ctx.User.GetMailbox(cmd.Mailbox)
+	if err == backend.ErrNoSuchMailbox {
+		return server.ErrStatusResp(&imap.StatusResp{
+			Type: imap.StatusRespNo,
+			Code: imap.CodeTryCreate,
+			Info: err.Error(),
+		})
+	} else if err != nil {
+		return err
+	}
+
+	appender, ok := mbox.(uidAppender)
+	if !ok {
+		return cmd.Append.Handle(conn)
+	}
+
+	uidValidity, uid, err := appender.CreateMessageUID(cmd.Flags, cmd.Date, cmd.Message)
+	if err != nil {
+		if err == backend.ErrTooBig {
+			return server.ErrStatusResp(&imap.StatusResp{
+				Type: imap.StatusRespNo,
+				Code: "TOOBIG",
+				Info: "Message size exceeding limit",
+			})
+		}
+		return err
+	}
+
+	// Like the built-in APPEND, announce the new message when it was appended
+	// to the selected mailbox
+	if ctx.Mailbox != nil && sameMailbox(ctx.Mailbox, mbox) {
+		if err := notifySequence(conn); err != nil {
+			return err
+		}
+	}
+
+	return server.ErrStatusResp(&imap.StatusResp{
+		Type:      imap.StatusRespOk,
+		Code:      "APPENDUID",
+		Arguments: []interface{}{uidValidity, uid},
+		Info:      "APPEND completed",
+	})
+}
+
+type uidExpunge struct {
+	server.Expunge
+	SeqSet *imap.SeqSet
+}
+
+func (cmd *uidExpunge) Parse(fields []interface{}) error {
+	// A plain EXPUNGE has no arguments
+	if len(fields) == 0 {
+		return nil
+	}
+
+	seqSet, ok := fields[0].(string)
+	if !ok {
+		return errors.New("Invalid sequence set")
+	}
+	var err error
+	cmd.SeqSet, err = imap.ParseSeqSet(seqSet)
+	return err
+}
+
+func (cmd *uidExpunge) UidHandle(conn server.Conn) error {
+	ctx := conn.Context()
+	if ctx.Mailbox == nil {
+		return server.ErrNoMailboxSelected
+	}
+	if ctx.MailboxReadOnly {
+		return server.ErrMailboxReadOnly
+	}
+	if cmd.SeqSet == nil {
+		return errors.New("No sequence set specified")
+	}
+
+	expunger, ok := ctx.Mailbox.(uidExpunger)
+	if !ok {
+		return errors.New("UID EXPUNGE is not supported by this mailbox")
+	}
+
+	criteria := &imap.SearchCriteria{
+		WithFlags: []string{imap.DeletedFlag},
+		Uid:       cmd.SeqSet,
+	}
+	seqNums, err := ctx.Mailbox.SearchMessages(false, criteria)
+	if err != nil {
+		return err
+	}
+	uids, err := ctx.Mailbox.SearchMessages(true, criteria)
+	if err != nil {
+		return err
+	}
+	if len(uids) == 0 {
+		return nil
+	}
+
+	if err := expunger.ExpungeMessages(uids); err != nil {
+		return err
+	}
+
+	// Send sequence numbers from the last one to the first one, as each
+	// expunge shifts the numbers of the messages after it
+	descending := make([]uint32, 0, len(seqNums))
+	for i := len(seqNums) - 1; i >= 0; i-- {
+		descending = append(descending, seqNums[i])
+	}
+	return writeExpunged(conn, descending, uids)
+}
+
+func (cmd *uidExpunge) Handle(conn server.Conn) error {
+	if !enabled(conn, capQresync) {
+		return cmd.Expunge.Handle(conn)
+	}
+
+	// The built-in EXPUNGE only knows about EXPUNGE responses
+	ctx := conn.Context()
+	if ctx.Mailbox == nil {
+		return server.ErrNoMailboxSelected
+	}
+	if ctx.MailboxReadOnly {
+		return server.ErrMailboxReadOnly
+	}
+
+	uids, err := ctx.Mailbox.SearchMessages(true, &imap.SearchCriteria{WithFlags: []string{imap.DeletedFlag}})
+	if err != nil {
+		return err
+	}
+	if err := ctx.Mailbox.Expunge(); err != nil {
+		return err
+	}
+	return writeVanished(conn, uids, false)
+}
+
+// sequenceRefresher is implemented by mailboxes that keep the sequence numbers
+// of a session and can pick up changes made by other sessions
+type sequenceRefresher interface {
+	RefreshSequence() (*sequenceUpdate, error)
+}
+
+// notifySequence tells the client about messages added to or removed from the
+// selected mailbox since it last heard about it, with EXPUNGE (or VANISHED) and
+// EXISTS responses
+func notifySequence(conn server.Conn) error {
+	mbox, ok := conn.Context().Mailbox.(sequenceRefresher)
+	if !ok {
+		return nil
+	}
+
+	update, err := mbox.RefreshSequence()
+	if err != nil {
+		return err
+	}
+
+	if err := writeExpunged(conn, update.Expunged, update.Vanished); err != nil {
+		return err
+	}
+
+	if update.Exists > 0 {
+		status := imap.NewMailboxStatus(conn.Context().Mailbox.Name(), []imap.StatusItem{imap.StatusMessages})
+		status.Messages = update.Exists
+		return conn.WriteResp(&responses.Select{Mailbox: status})
+	}
+	return nil
+}
+
+// writeExpunged announces removed messages: by their sequence numbers, given in
+// descending order, or by their UIDs once the client enabled QRESYNC (RFC 7162
+// section 3.2.10)
+func writeExpunged(conn server.Conn, seqNums []uint32, uids []uint32) error {
+	if enabled(conn, capQresync) {
+		return writeVanished(conn, uids, false)
+	}
+	if len(seqNums) == 0 {
+		return nil
+	}
+
+	ch := make(chan uint32, len(seqNums))
+	for _, seqNum := range seqNums {
+		ch <- seqNum
+	}
+	close(ch)
+	return conn.WriteResp(&responses.Expunge{SeqNums: ch})
+}
+
+// sessionExtension keeps the sequence numbers of a session in line with the
+// mailbox: NOOP and CHECK report changes made by other sessions, and MOVE sends
+// EXPUNGE responses for the messages it moved away (RFC 6851 section 3.3).
+type sessionExtension struct {
+	// handleMove is only set once the extension is enabled, as Server.Enable
+	// skips extensions that handle MOVE, which go-imap considers built-in
+	handleMove bool
+}
+
+func (*sessionExtension) Capabilities(c server.Conn) []string {
+	return nil
+}
+
+func (ext *sessionExtension) Command(name string) server.HandlerFactory {
+	switch name {
+	case "NOOP":
+		return func() server.Handler { return &noopSession{} }
+	case "CHECK":
+		return func() server.Handler { return &checkSession{} }
+	case "MOVE":
+		if ext.handleMove {
+			return func() server.Handler { return &moveSession{} }
+		}
+	}
+	return nil
+}
+
+type noopSession struct {
+	server.Noop
+}
+
+func (cmd *noopSession) Handle(conn server.Conn) error {
+	if err := cmd.Noop.Handle(conn); err != nil {
+		return err
+	}
+	if conn.Context().Mailbox == nil {
+		return nil
+	}
+	return notifySequence(conn)
+}
+
+type checkSession struct {
+	server.Check
+}
+
+func (cmd *checkSession) Handle(conn server.Conn) error {
+	if err := cmd.Check.Handle(conn); err != nil {
+		return err
+	}
+	return notifySequence(conn)
+}
+
+type moveSession struct {
+	server.Move
+}
+
+func (cmd *moveSession) Handle(conn server.Conn) error {
+	if err := cmd.Move.Handle(conn); err != nil {
+		return err
+	}
+	return notifySequence(conn)
+}
+
+func (cmd *moveSession) UidHandle(conn server.Conn) error {
+	if err := cmd.Move.UidHandle(conn); err != nil {
+		return err
+	}
+	return notifySequence(conn)
+}
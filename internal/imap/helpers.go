@@ -1,16 +1,45 @@
 package imap
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"net/mail"
 	"strings"
-	"time"
 
+	"inbox451/internal/core"
 	"inbox451/internal/models"
 
 	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend/backendutil"
+	"github.com/emersion/go-message/textproto"
 )
 
+// mailboxName returns the IMAP name of an inbox, or of one of its folders:
+// Projects/<project name>/<inbox email>[/<folder path>]
+func mailboxName(inbox *models.Inbox, folder *models.Folder) string {
+	if folder == nil {
+		return inboxPath(inbox)
+	}
+	return inboxPath(inbox) + core.FolderDelimiter + folder.Name
+}
+
+// splitMailboxName splits a mailbox name of the form <inbox email>[/<folder path>],
+// used before inboxes were grouped by project, into the inbox email and the folder path
+func splitMailboxName(name string) (email, folder string) {
+	email, folder, _ = strings.Cut(name, core.FolderDelimiter)
+	return email, folder
+}
+
+// folderIDOf returns the ID of a folder, or an empty string for the inbox itself
+func folderIDOf(folder *models.Folder) string {
+	if folder == nil {
+		return ""
+	}
+	return folder.ID
+}
+
 // parseEmailToAddress converts email string to IMAP address
 func parseEmailToAddress(email string) (*imap.Address, error) {
 	addr, err := mail.ParseAddress(email)
@@ -41,27 +70,12 @@ func parseEmailToAddress(email string) (*imap.Address, error) {
 	}, nil
 }
 
-// reconstructRFC822 builds a minimal RFC822 message from database data
-func reconstructRFC822(dbMsg *models.Message) string {
-	var sb strings.Builder
-
-	// Headers
-	sb.WriteString("From: " + dbMsg.Sender + "\r\n")
-	sb.WriteString("To: " + dbMsg.Receiver + "\r\n")
-	sb.WriteString("Subject: " + dbMsg.Subject + "\r\n")
-	sb.WriteString("Date: " + dbMsg.CreatedAt.Time.Format(time.RFC1123Z) + "\r\n")
-	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
-	sb.WriteString("MIME-Version: 1.0\r\n")
-	sb.WriteString("\r\n")
-
-	// Body
-	sb.WriteString(dbMsg.Body)
-
-	return sb.String()
-}
-
 // matchesSearchCriteria checks if message matches additional search criteria
 func matchesSearchCriteria(msg *models.Message, criteria *imap.SearchCriteria) bool {
+	if criteria.Uid != nil && !criteria.Uid.Contains(msg.UID) {
+		return false
+	}
+
 	// Handle header criteria
 	for key, values := range criteria.Header {
 		keyLower := strings.ToLower(key)
@@ -116,6 +130,97 @@ func matchesSearchCriteria(msg *models.Message, criteria *imap.SearchCriteria) b
 	return true
 }
 
+// systemFlags are the IMAP system flags stored for every message
+var systemFlags = []string{imap.SeenFlag, imap.AnsweredFlag, imap.FlaggedFlag, imap.DeletedFlag, imap.DraftFlag}
+
+// isKeyword reports whether flag is a keyword rather than a system flag
+func isKeyword(flag string) bool {
+	return flag != "" && !strings.HasPrefix(flag, "\\")
+}
+
+// messageFlags returns the system flags and keywords of a message
+func messageFlags(dbMsg *models.Message) []string {
+	flags := []string{}
+	if dbMsg.IsRead {
+		flags = append(flags, imap.SeenFlag)
+	}
+	if dbMsg.IsAnswered {
+		flags = append(flags, imap.AnsweredFlag)
+	}
+	if dbMsg.IsFlagged {
+		flags = append(flags, imap.FlaggedFlag)
+	}
+	if dbMsg.IsDeleted {
+		flags = append(flags, imap.DeletedFlag)
+	}
+	if dbMsg.IsDraft {
+		flags = append(flags, imap.DraftFlag)
+	}
+	for _, label := range dbMsg.Labels {
+		flags = append(flags, core.LabelKeyword(label.Name))
+	}
+	return flags
+}
+
+// parseFlags converts the system flags of a STORE command. Keywords are resolved
+// to labels separately and \Recent cannot be stored, so both are skipped.
+func parseFlags(flags []string) models.MessageFlags {
+	var result models.MessageFlags
+	for _, flag := range flags {
+		switch imap.CanonicalFlag(flag) {
+		case imap.SeenFlag:
+			result.Seen = true
+		case imap.AnsweredFlag:
+			result.Answered = true
+		case imap.FlaggedFlag:
+			result.Flagged = true
+		case imap.DeletedFlag:
+			result.Deleted = true
+		case imap.DraftFlag:
+			result.Draft = true
+		}
+	}
+	return result
+}
+
+// flagOperation converts a go-imap flag operation to its storage counterpart
+func flagOperation(op imap.FlagsOp) models.FlagOperation {
+	switch op {
+	case imap.RemoveFlags:
+		return models.FlagsRemove
+	case imap.SetFlags:
+		return models.FlagsSet
+	default:
+		return models.FlagsAdd
+	}
+}
+
+// matchesFlags checks the flag parts of the search criteria that are not
+// handled by the database filters: \Answered, \Flagged, \Draft and keywords
+func matchesFlags(msg *models.Message, criteria *imap.SearchCriteria) bool {
+	flags := messageFlags(msg)
+	has := func(flag string) bool {
+		for _, f := range flags {
+			if strings.EqualFold(f, flag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, flag := range criteria.WithFlags {
+		if !has(flag) && flag != imap.RecentFlag {
+			return false
+		}
+	}
+	for _, flag := range criteria.WithoutFlags {
+		if has(flag) {
+			return false
+		}
+	}
+	return true
+}
+
 // buildEnvelope creates IMAP envelope from database message
 func buildEnvelope(dbMsg *models.Message) (*imap.Envelope, error) {
 	env := &imap.Envelope{
@@ -137,6 +242,31 @@ func buildEnvelope(dbMsg *models.Message) (*imap.Envelope, error) {
 	return env, nil
 }
 
+// needsContent reports whether any of the items is served from the message
+// content rather than from the database columns
+func needsContent(items []imap.FetchItem) bool {
+	for _, item := range items {
+		switch item {
+		case imap.FetchRFC822Size, imap.FetchBody, imap.FetchBodyStructure:
+			return true
+		}
+		if _, err := imap.ParseBodySectionName(item); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// readHeader splits message content into its header and a reader for the body
+func readHeader(content []byte) (textproto.Header, io.Reader, error) {
+	body := bufio.NewReader(bytes.NewReader(content))
+	header, err := textproto.ReadHeader(body)
+	if err != nil {
+		return textproto.Header{}, nil, fmt.Errorf("failed to read message header: %w", err)
+	}
+	return header, body, nil
+}
+
 // buildImapMessage converts database message to IMAP message
 func buildImapMessage(dbMsg *models.Message, seqNum uint32, items []imap.FetchItem) (*imap.Message, error) {
 	imapMsg := &imap.Message{
@@ -145,30 +275,35 @@ func buildImapMessage(dbMsg *models.Message, seqNum uint32, items []imap.FetchIt
 		Body:   make(map[*imap.BodySectionName]imap.Literal),
 	}
 
+	var content []byte
+	if needsContent(items) {
+		content = core.RawMessage(dbMsg)
+	}
+
 	for _, item := range items {
 		switch item {
 		case imap.FetchFlags:
-			flags := []string{}
-			if dbMsg.IsRead {
-				flags = append(flags, imap.SeenFlag)
-			}
-			if dbMsg.IsDeleted {
-				flags = append(flags, imap.DeletedFlag)
-			}
+			flags := messageFlags(dbMsg)
 			// Store flags in both the Items map and the Flags field
 			imapMsg.Items[item] = flags
 			imapMsg.Flags = flags
 
+		// go-imap formats these items from the typed fields, not from Items
 		case imap.FetchUid:
 			imapMsg.Items[item] = dbMsg.UID
+			imapMsg.Uid = dbMsg.UID
 
 		case imap.FetchInternalDate:
 			imapMsg.Items[item] = dbMsg.CreatedAt.Time
+			imapMsg.InternalDate = dbMsg.CreatedAt.Time
+
+		case fetchModSeq:
+			imapMsg.Items[item] = []any{formatModSeq(dbMsg.ModSeq)}
 
 		case imap.FetchRFC822Size:
-			// Estimate size (headers + body)
-			size := len(dbMsg.Subject) + len(dbMsg.Sender) + len(dbMsg.Receiver) + len(dbMsg.Body) + 200 // headers overhead
-			imapMsg.Items[item] = uint32(size)
+			// The exact number of octets sent for RFC822 and BODY[]
+			imapMsg.Items[item] = uint32(len(content))
+			imapMsg.Size = uint32(len(content))
 
 		case imap.FetchEnvelope:
 			env, err := buildEnvelope(dbMsg)
@@ -178,40 +313,40 @@ func buildImapMessage(dbMsg *models.Message, seqNum uint32, items []imap.FetchIt
 			imapMsg.Items[item] = env
 			imapMsg.Envelope = env
 
-		case imap.FetchBodyStructure:
-			bodyStructure := &imap.BodyStructure{
-				MIMEType:    "text",
-				MIMESubType: "plain",
-				Params:      map[string]string{"charset": "UTF-8"},
-				Size:        uint32(len(dbMsg.Body)),
+		case imap.FetchBody, imap.FetchBodyStructure:
+			header, body, err := readHeader(content)
+			if err != nil {
+				return nil, err
+			}
+			// BODY is BODYSTRUCTURE without the extension data
+			bodyStructure, err := backendutil.FetchBodyStructure(header, body, item == imap.FetchBodyStructure)
+			if err != nil {
+				return nil, fmt.Errorf("failed to build body structure: %w", err)
 			}
 			imapMsg.Items[item] = bodyStructure
-
-		case imap.FetchBody:
-			// Return the plain text body
-			imapMsg.Items[item] = strings.NewReader(dbMsg.Body)
-
-		case imap.FetchRFC822:
-			// Reconstruct full RFC822 message
-			rfc822 := reconstructRFC822(dbMsg)
-			imapMsg.Items[item] = strings.NewReader(rfc822)
+			imapMsg.BodyStructure = bodyStructure
 
 		default:
-			// Handle body section requests
-			itemStr := string(item)
-			if strings.HasPrefix(itemStr, "BODY[") || strings.HasPrefix(itemStr, "BODY.PEEK[") {
-				// Parse the body section request
-				section := &imap.BodySectionName{}
-				if strings.HasPrefix(itemStr, "BODY.PEEK[") {
-					section.Peek = true
-				}
+			// RFC822, RFC822.HEADER, RFC822.TEXT and BODY[section]<partial>
+			section, err := imap.ParseBodySectionName(item)
+			if err != nil {
+				continue
+			}
 
-				rfc822 := reconstructRFC822(dbMsg)
+			header, body, err := readHeader(content)
+			if err != nil {
+				return nil, err
+			}
 
-				// Add to both Body map and Items map for proper IMAP response
-				imapMsg.Body[section] = strings.NewReader(rfc822)
-				imapMsg.Items[item] = strings.NewReader(rfc822)
+			// A part that does not exist is returned as an empty string
+			literal, err := backendutil.FetchBodySection(header, body, section)
+			if err != nil {
+				literal = bytes.NewReader(nil)
 			}
+
+			// go-imap matches the section to the item when writing the response
+			imapMsg.Body[section] = literal
+			imapMsg.Items[item] = literal
 		}
 	}
 
@@ -1,14 +1,17 @@
 package imap
 
 import (
-	"strings"
+	"context"
+	"io"
 	"testing"
 	"time"
 
+	"inbox451/internal/core"
 	"inbox451/internal/models"
 
 	"github.com/emersion/go-imap"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	null "github.com/volatiletech/null/v9"
 )
 
@@ -120,43 +123,6 @@ func TestParseEmailToAddress(t *testing.T) {
 	}
 }
 
-func TestReconstructRFC822(t *testing.T) {
-	now := time.Now()
-	message := &models.Message{
-		Base: models.Base{
-			ID:        "test-message-1",
-			CreatedAt: null.TimeFrom(now),
-		},
-		InboxID:  "test-inbox-1",
-		Sender:   "sender@example.com",
-		Receiver: "receiver@example.com",
-		Subject:  "Test Subject",
-		Body:     "This is a test message body.",
-	}
-
-	result := reconstructRFC822(message)
-
-	// Check that the RFC822 message contains expected headers
-	assert.Contains(t, result, "From: sender@example.com")
-	assert.Contains(t, result, "To: receiver@example.com")
-	assert.Contains(t, result, "Subject: Test Subject")
-	assert.Contains(t, result, "Content-Type: text/plain; charset=UTF-8")
-	assert.Contains(t, result, "MIME-Version: 1.0")
-	assert.Contains(t, result, "This is a test message body.")
-
-	// Check that date is formatted properly
-	expectedDate := now.Format(time.RFC1123Z)
-	assert.Contains(t, result, "Date: "+expectedDate)
-
-	// Check that headers and body are separated by empty line
-	assert.Contains(t, result, "\r\n\r\n")
-
-	// Check that the body comes after the headers
-	parts := strings.Split(result, "\r\n\r\n")
-	assert.Len(t, parts, 2)
-	assert.Equal(t, "This is a test message body.", parts[1])
-}
-
 func TestMatchesSearchCriteria(t *testing.T) {
 	now := time.Now()
 	pastTime := now.Add(-24 * time.Hour)
@@ -168,17 +134,33 @@ func TestMatchesSearchCriteria(t *testing.T) {
 			CreatedAt: null.TimeFrom(now),
 		},
 		InboxID:  "test-inbox-1",
+		UID:      42,
 		Sender:   "sender@example.com",
 		Receiver: "receiver@example.com",
 		Subject:  "Important Subject",
 		Body:     "This is an important message body with keyword test.",
 	}
 
+	uidSet := func(set string) *imap.SeqSet {
+		seqSet, _ := imap.ParseSeqSet(set)
+		return seqSet
+	}
+
 	tests := []struct {
 		name     string
 		criteria *imap.SearchCriteria
 		expected bool
 	}{
+		{
+			name:     "matches uid set",
+			criteria: &imap.SearchCriteria{Uid: uidSet("40:45")},
+			expected: true,
+		},
+		{
+			name:     "outside uid set",
+			criteria: &imap.SearchCriteria{Uid: uidSet("1:10,50")},
+			expected: false,
+		},
 		{
 			name: "matches header from",
 			criteria: &imap.SearchCriteria{
@@ -416,6 +398,7 @@ func TestBuildImapMessage(t *testing.T) {
 				uid, ok := msg.Items[imap.FetchUid].(uint32)
 				assert.True(t, ok)
 				assert.Equal(t, uint32(42), uid) // Test UID value
+				assert.Equal(t, uint32(42), msg.Uid)
 			},
 		},
 		{
@@ -425,6 +408,7 @@ func TestBuildImapMessage(t *testing.T) {
 				date, ok := msg.Items[imap.FetchInternalDate].(time.Time)
 				assert.True(t, ok)
 				assert.Equal(t, now, date)
+				assert.Equal(t, now, msg.InternalDate)
 			},
 		},
 		{
@@ -433,7 +417,7 @@ func TestBuildImapMessage(t *testing.T) {
 			validate: func(t *testing.T, msg *imap.Message) {
 				size, ok := msg.Items[imap.FetchRFC822Size].(uint32)
 				assert.True(t, ok)
-				assert.Greater(t, size, uint32(0))
+				assert.Equal(t, uint32(len(core.RawMessage(message))), size)
 			},
 		},
 		{
@@ -461,31 +445,25 @@ func TestBuildImapMessage(t *testing.T) {
 			name:  "fetch body",
 			items: []imap.FetchItem{imap.FetchBody},
 			validate: func(t *testing.T, msg *imap.Message) {
-				body, ok := msg.Items[imap.FetchBody].(*strings.Reader)
+				bodyStructure, ok := msg.Items[imap.FetchBody].(*imap.BodyStructure)
 				assert.True(t, ok)
-
-				// Read the body content
-				content := make([]byte, len("Test message body"))
-				n, err := body.Read(content)
-				assert.NoError(t, err)
-				assert.Equal(t, len("Test message body"), n)
-				assert.Equal(t, "Test message body", string(content))
+				assert.Equal(t, "text", bodyStructure.MIMEType)
+				assert.Equal(t, uint32(len("Test message body")), bodyStructure.Size)
+				assert.Same(t, bodyStructure, msg.BodyStructure)
 			},
 		},
 		{
 			name:  "fetch RFC822",
 			items: []imap.FetchItem{imap.FetchRFC822},
 			validate: func(t *testing.T, msg *imap.Message) {
-				rfc822, ok := msg.Items[imap.FetchRFC822].(*strings.Reader)
+				rfc822, ok := msg.Items[imap.FetchRFC822].(imap.Literal)
 				assert.True(t, ok)
 
-				// Read some content to verify it's a valid RFC822 message
-				content := make([]byte, 1024)
-				n, err := rfc822.Read(content)
+				// Read the content to verify it's a valid RFC822 message
+				content, err := io.ReadAll(rfc822)
 				assert.NoError(t, err)
-				assert.Greater(t, n, 0)
 
-				rfc822Content := string(content[:n])
+				rfc822Content := string(content)
 				assert.Contains(t, rfc822Content, "From: sender@example.com")
 				assert.Contains(t, rfc822Content, "To: receiver@example.com")
 				assert.Contains(t, rfc822Content, "Subject: Test Subject")
@@ -526,6 +504,105 @@ func TestBuildImapMessage(t *testing.T) {
 	}
 }
 
+func TestBuildImapMessage_MIME(t *testing.T) {
+	raw := "From: sender@example.com\r\n" +
+		"To: receiver@example.com\r\n" +
+		"Subject: Report\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=outer\r\n" +
+		"\r\n" +
+		"--outer\r\n" +
+		"Content-Type: multipart/alternative; boundary=inner\r\n" +
+		"\r\n" +
+		"--inner\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"Hello\r\n" +
+		"--inner\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"\r\n" +
+		"<p>Hello</p>\r\n" +
+		"--inner--\r\n" +
+		"--outer\r\n" +
+		"Content-Type: application/pdf; name=report.pdf\r\n" +
+		"Content-Disposition: attachment; filename=report.pdf\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"JVBERi0xLjQK\r\n" +
+		"--outer--\r\n"
+
+	message := &models.Message{
+		Base:    models.Base{CreatedAt: null.TimeFrom(time.Now())},
+		UID:     7,
+		Subject: "Report",
+		Body:    "Hello",
+		Raw:     []byte(raw),
+	}
+
+	fetchSection := func(t *testing.T, item imap.FetchItem) string {
+		msg, err := buildImapMessage(message, 1, []imap.FetchItem{item})
+		require.NoError(t, err)
+		literal, ok := msg.Items[item].(imap.Literal)
+		require.True(t, ok)
+		content, err := io.ReadAll(literal)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("size is the raw message size", func(t *testing.T) {
+		msg, err := buildImapMessage(message, 1, []imap.FetchItem{imap.FetchRFC822Size})
+		require.NoError(t, err)
+		assert.Equal(t, uint32(len(raw)), msg.Size)
+	})
+
+	t.Run("body structure", func(t *testing.T) {
+		msg, err := buildImapMessage(message, 1, []imap.FetchItem{imap.FetchBodyStructure})
+		require.NoError(t, err)
+
+		bs := msg.BodyStructure
+		require.NotNil(t, bs)
+		assert.Equal(t, "multipart", bs.MIMEType)
+		assert.Equal(t, "mixed", bs.MIMESubType)
+		require.Len(t, bs.Parts, 2)
+
+		alternative := bs.Parts[0]
+		assert.Equal(t, "alternative", alternative.MIMESubType)
+		require.Len(t, alternative.Parts, 2)
+		assert.Equal(t, "html", alternative.Parts[1].MIMESubType)
+
+		attachment := bs.Parts[1]
+		assert.Equal(t, "application", attachment.MIMEType)
+		assert.Equal(t, "pdf", attachment.MIMESubType)
+		assert.Equal(t, "base64", attachment.Encoding)
+		assert.Equal(t, "attachment", attachment.Disposition)
+		assert.Equal(t, "report.pdf", attachment.DispositionParams["filename"])
+	})
+
+	t.Run("nested part", func(t *testing.T) {
+		assert.Equal(t, "<p>Hello</p>", fetchSection(t, "BODY.PEEK[1.2]"))
+	})
+
+	t.Run("part header", func(t *testing.T) {
+		assert.Equal(t, "Content-Type: text/html; charset=utf-8\r\n\r\n", fetchSection(t, "BODY.PEEK[1.2.MIME]"))
+	})
+
+	t.Run("header fields", func(t *testing.T) {
+		assert.Equal(t, "Subject: Report\r\n\r\n", fetchSection(t, "BODY.PEEK[HEADER.FIELDS (SUBJECT)]"))
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		assert.Equal(t, "To: rec", fetchSection(t, "BODY.PEEK[]<26.7>"))
+	})
+
+	t.Run("entire message", func(t *testing.T) {
+		assert.Equal(t, raw, fetchSection(t, imap.FetchRFC822))
+	})
+
+	t.Run("missing part is empty", func(t *testing.T) {
+		assert.Empty(t, fetchSection(t, "BODY.PEEK[3]"))
+	})
+}
+
 func TestBuildImapMessage_DeletedFlags(t *testing.T) {
 	message := &models.Message{
 		Base: models.Base{
@@ -549,3 +626,141 @@ func TestBuildImapMessage_DeletedFlags(t *testing.T) {
 	assert.Contains(t, flags, imap.DeletedFlag)
 	assert.NotContains(t, flags, imap.SeenFlag)
 }
+
+func TestBuildImapMessage_KeywordFlags(t *testing.T) {
+	message := &models.Message{
+		Base: models.Base{
+			ID:        "test-message-1",
+			CreatedAt: null.TimeFrom(time.Now()),
+		},
+		InboxID: "test-inbox-123",
+		UID:     1,
+		IsRead:  true,
+		Labels: []*models.Label{
+			{Name: "Important"},
+			{Name: "To do"},
+		},
+	}
+
+	result, err := buildImapMessage(message, 1, []imap.FetchItem{imap.FetchFlags})
+
+	assert.NoError(t, err)
+	flags := result.Items[imap.FetchFlags].([]string)
+	assert.Equal(t, []string{imap.SeenFlag, "Important", "To_do"}, flags)
+}
+
+func TestMatchesFlags(t *testing.T) {
+	message := &models.Message{
+		IsFlagged: true,
+		Labels: []*models.Label{
+			{Name: "Important"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		criteria *imap.SearchCriteria
+		expected bool
+	}{
+		{
+			name:     "keyword present",
+			criteria: &imap.SearchCriteria{WithFlags: []string{"important"}},
+			expected: true,
+		},
+		{
+			name:     "keyword missing",
+			criteria: &imap.SearchCriteria{WithFlags: []string{"Later"}},
+			expected: false,
+		},
+		{
+			name:     "unkeyword present",
+			criteria: &imap.SearchCriteria{WithoutFlags: []string{"Important"}},
+			expected: false,
+		},
+		{
+			name:     "flagged",
+			criteria: &imap.SearchCriteria{WithFlags: []string{imap.FlaggedFlag}},
+			expected: true,
+		},
+		{
+			name:     "unanswered",
+			criteria: &imap.SearchCriteria{WithoutFlags: []string{imap.AnsweredFlag}},
+			expected: true,
+		},
+		{
+			name:     "draft",
+			criteria: &imap.SearchCriteria{WithFlags: []string{imap.DraftFlag}},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matchesFlags(message, tt.criteria))
+		})
+	}
+}
+
+func TestBuildImapMessage_SystemFlags(t *testing.T) {
+	message := &models.Message{
+		Base:       models.Base{ID: "test-message-1"},
+		UID:        1,
+		IsAnswered: true,
+		IsFlagged:  true,
+		IsDraft:    true,
+	}
+
+	result, err := buildImapMessage(message, 1, []imap.FetchItem{imap.FetchFlags})
+
+	assert.NoError(t, err)
+	flags := result.Items[imap.FetchFlags].([]string)
+	assert.Equal(t, []string{imap.AnsweredFlag, imap.FlaggedFlag, imap.DraftFlag}, flags)
+}
+
+func TestParseFlags(t *testing.T) {
+	flags := parseFlags([]string{"\\seen", imap.FlaggedFlag, imap.RecentFlag, "Important"})
+
+	assert.Equal(t, models.MessageFlags{Seen: true, Flagged: true}, flags)
+}
+
+func TestMailboxName(t *testing.T) {
+	inbox := &models.Inbox{Email: "inbox@example.com", ProjectName: "Ops"}
+
+	assert.Equal(t, "Projects/Ops/inbox@example.com", mailboxName(inbox, nil))
+	assert.Equal(t, "Projects/Ops/inbox@example.com/Archive/2024", mailboxName(inbox, &models.Folder{Name: "Archive/2024"}))
+}
+
+func TestSplitMailboxName(t *testing.T) {
+	tests := []struct {
+		name   string
+		email  string
+		folder string
+	}{
+		{name: "inbox@example.com", email: "inbox@example.com", folder: ""},
+		{name: "inbox@example.com/Archive", email: "inbox@example.com", folder: "Archive"},
+		{name: "inbox@example.com/Archive/2024", email: "inbox@example.com", folder: "Archive/2024"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email, folder := splitMailboxName(tt.name)
+			assert.Equal(t, tt.email, email)
+			assert.Equal(t, tt.folder, folder)
+		})
+	}
+}
+
+func TestImapMailbox_InfoSpecialUse(t *testing.T) {
+	inbox := &models.Inbox{Email: "inbox@example.com", ProjectName: "Ops"}
+
+	info, err := NewImapMailbox(context.Background(), inbox, nil, nil).Info()
+	assert.NoError(t, err)
+	assert.Empty(t, info.Attributes)
+
+	trash := &models.Folder{Name: "Trash", SpecialUse: null.StringFrom(core.SpecialUseTrash)}
+	info, err = NewImapMailbox(context.Background(), inbox, trash, nil).Info()
+	assert.NoError(t, err)
+	assert.Equal(t, "Projects/Ops/inbox@example.com/Trash", info.Name)
+	assert.Equal(t, []string{imap.TrashAttr}, info.Attributes)
+	assert.True(t, (&ImapMailbox{folder: trash}).isTrash())
+}
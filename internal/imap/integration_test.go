@@ -1,9 +1,12 @@
 package imap
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"strconv"
 	"testing"
 	"time"
 
@@ -348,15 +351,31 @@ func (suite *IMAPIntegrationTestSuite) TestMailboxOperations() {
 		// Should find at least our test inbox
 		assert.True(t, len(foundInboxes) > 0, "Should find at least one mailbox")
 
-		// Check if our test inbox is in the list
-		found := false
+		// Check if our test inbox is in the list, grouped under its project
+		names := make(map[string][]string)
 		for _, mailbox := range foundInboxes {
-			if mailbox.Name == suite.testInbox.Email {
-				found = true
-				break
-			}
+			names[mailbox.Name] = mailbox.Attributes
 		}
-		assert.True(t, found, "Test inbox should be in the mailbox list")
+		projectName := "Projects/" + suite.testProject.Name
+		assert.Contains(t, names, "INBOX", "INBOX should be in the mailbox list")
+		assert.Contains(t, names, projectName+"/"+suite.testInbox.Email, "Test inbox should be in the mailbox list")
+		assert.Contains(t, names[projectName], imap.NoSelectAttr, "Project level should not be selectable")
+	})
+
+	suite.T().Run("SelectProjectPath", func(t *testing.T) {
+		name := "Projects/" + suite.testProject.Name + "/" + suite.testInbox.Email
+		mbox, err := suite.client.Select(name, false)
+		assert.NoError(t, err, "SELECT by project path should succeed")
+		assert.Equal(t, name, mbox.Name)
+
+		_, err = suite.client.Select("Projects/"+suite.testProject.Name, false)
+		assert.Error(t, err, "Project level should not be selectable")
+	})
+
+	suite.T().Run("Namespace", func(t *testing.T) {
+		ok, err := suite.client.Support("NAMESPACE")
+		assert.NoError(t, err)
+		assert.True(t, ok, "NAMESPACE should be advertised")
 	})
 
 	suite.T().Run("SelectMailbox", func(t *testing.T) {
@@ -580,6 +599,113 @@ func (suite *IMAPIntegrationTestSuite) TestExpunge() {
 	assert.Less(suite.T(), mbox.Messages, initialCount, "Message count should decrease after expunge")
 }
 
+func (suite *IMAPIntegrationTestSuite) TestAppend() {
+	err := suite.client.Login(suite.testUser.Username, suite.testToken.Token)
+	require.NoError(suite.T(), err)
+
+	mbox, err := suite.client.Select(suite.testInbox.Email, false)
+	require.NoError(suite.T(), err)
+	initialCount := mbox.Messages
+
+	raw := "From: Archive <archive@example.com>\r\n" +
+		"To: " + suite.testInbox.Email + "\r\n" +
+		"Subject: Imported message\r\n" +
+		"\r\n" +
+		"Imported body\r\n"
+	date := time.Date(2020, 5, 17, 10, 0, 0, 0, time.UTC)
+
+	err = suite.client.Append(suite.testInbox.Email, []string{imap.SeenFlag, imap.FlaggedFlag}, date, bytes.NewBufferString(raw))
+	require.NoError(suite.T(), err, "APPEND should succeed")
+
+	mbox, err = suite.client.Select(suite.testInbox.Email, false)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), initialCount+1, mbox.Messages, "Message count should increase after append")
+
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(mbox.Messages)
+	messages := make(chan *imap.Message, 1)
+	section := &imap.BodySectionName{Peek: true}
+	err = suite.client.Fetch(seqset, []imap.FetchItem{imap.FetchEnvelope, imap.FetchFlags, imap.FetchInternalDate, imap.FetchRFC822Size, section.FetchItem()}, messages)
+	require.NoError(suite.T(), err)
+
+	msg := <-messages
+	require.NotNil(suite.T(), msg)
+	assert.Equal(suite.T(), "Imported message", msg.Envelope.Subject)
+	assert.Contains(suite.T(), msg.Flags, imap.SeenFlag)
+	assert.Contains(suite.T(), msg.Flags, imap.FlaggedFlag)
+	assert.True(suite.T(), date.Equal(msg.InternalDate), "Internal date should come from APPEND")
+
+	// The message is served exactly as it was appended
+	assert.Equal(suite.T(), uint32(len(raw)), msg.Size)
+	body := msg.GetBody(section)
+	require.NotNil(suite.T(), body)
+	content, err := io.ReadAll(body)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), raw, string(content))
+}
+
+func (suite *IMAPIntegrationTestSuite) TestSequenceNotifications() {
+	err := suite.client.Login(suite.testUser.Username, suite.testToken.Token)
+	require.NoError(suite.T(), err)
+
+	mbox, err := suite.client.Select(suite.testInbox.Email, false)
+	require.NoError(suite.T(), err)
+	initialCount := mbox.Messages
+
+	// A message delivered by another session is only announced on NOOP
+	msg := &models.Message{
+		InboxID:  suite.testInbox.ID,
+		Sender:   "other@example.com",
+		Receiver: suite.testInbox.Email,
+		Subject:  "Delivered meanwhile",
+		Body:     "Body",
+	}
+	require.NoError(suite.T(), suite.core.MessageService.Store(context.Background(), msg))
+	assert.Equal(suite.T(), initialCount, suite.client.Mailbox().Messages)
+
+	require.NoError(suite.T(), suite.client.Noop())
+	assert.Equal(suite.T(), initialCount+1, suite.client.Mailbox().Messages, "NOOP should announce the new message")
+
+	// Removing it elsewhere is announced with an EXPUNGE response
+	require.NoError(suite.T(), suite.core.MessageService.Delete(context.Background(), msg.ID))
+	require.NoError(suite.T(), suite.client.Noop())
+	assert.Equal(suite.T(), initialCount, suite.client.Mailbox().Messages, "NOOP should announce the expunged message")
+}
+
+func (suite *IMAPIntegrationTestSuite) TestCondstore() {
+	err := suite.client.Login(suite.testUser.Username, suite.testToken.Token)
+	require.NoError(suite.T(), err)
+
+	highestModSeq := func() uint64 {
+		status, err := suite.client.Status(suite.testInbox.Email, []imap.StatusItem{statusHighestModSeq})
+		require.NoError(suite.T(), err)
+		value, _ := status.Items[statusHighestModSeq].(string)
+		modSeq, err := strconv.ParseUint(value, 10, 64)
+		require.NoError(suite.T(), err)
+		return modSeq
+	}
+
+	before := highestModSeq()
+
+	_, err = suite.client.Select(suite.testInbox.Email, false)
+	require.NoError(suite.T(), err)
+
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(1)
+	err = suite.client.Store(seqset, imap.FormatFlagsOp(imap.AddFlags, true), []any{imap.FlaggedFlag}, nil)
+	require.NoError(suite.T(), err)
+
+	after := highestModSeq()
+	assert.Greater(suite.T(), after, before, "STORE should raise HIGHESTMODSEQ")
+
+	messages := make(chan *imap.Message, 1)
+	err = suite.client.Fetch(seqset, []imap.FetchItem{fetchModSeq}, messages)
+	require.NoError(suite.T(), err)
+	msg := <-messages
+	require.NotNil(suite.T(), msg)
+	assert.Contains(suite.T(), msg.Items, fetchModSeq)
+}
+
 // Benchmark tests for performance
 func (suite *IMAPIntegrationTestSuite) TestPerformance() {
 	// Login
@@ -3,69 +3,168 @@ package imap
 import (
 	"context"
 	"errors"
+	"io"
+	"sort"
 	"time"
 
+	"inbox451/internal/core"
 	"inbox451/internal/models"
 
 	"github.com/emersion/go-imap"
 	"github.com/emersion/go-imap/backend"
-)
-
-const (
-	// MaxSequenceRange defines the maximum allowed range size to prevent performance issues
-	MaxSequenceRange = 10000
-	// MaxUID defines the maximum allowed UID value (2^32 - 1)
-	MaxUID = 4294967295
+	null "github.com/volatiletech/null/v9"
 )
 
 // ImapMailbox implements go-imap/backend.Mailbox interface
 type ImapMailbox struct {
 	inboxModel *models.Inbox
-	user       *ImapUser
-	ctx        context.Context
+	// folder is nil for the inbox itself
+	folder *models.Folder
+	user   *ImapUser
+	ctx    context.Context
+	// name is the name the mailbox was opened under when it is not its own, as
+	// for INBOX
+	name string
+	// seq holds the sequence numbers of the session. It is loaded on first use,
+	// which for the selected mailbox is the SELECT itself.
+	seq *sequenceMap
+}
+
+// NewImapMailbox creates a new IMAP mailbox for an inbox, or for one of its folders
+func NewImapMailbox(ctx context.Context, inbox *models.Inbox, folder *models.Folder, user *ImapUser) backend.Mailbox {
+	return &ImapMailbox{
+		inboxModel: inbox,
+		folder:     folder,
+		user:       user,
+		ctx:        ctx,
+	}
 }
 
-// NewImapMailbox creates a new IMAP mailbox
-func NewImapMailbox(ctx context.Context, inbox *models.Inbox, user *ImapUser) backend.Mailbox {
+// newInboxMailbox creates the mailbox of the user's default inbox, named INBOX
+func newInboxMailbox(ctx context.Context, inbox *models.Inbox, user *ImapUser) backend.Mailbox {
 	return &ImapMailbox{
 		inboxModel: inbox,
 		user:       user,
 		ctx:        ctx,
+		name:       inboxName,
 	}
 }
 
-// Name returns mailbox name (inbox email)
+// Name returns mailbox name (INBOX, or the inbox path followed by the folder path for folders)
 func (m *ImapMailbox) Name() string {
-	return m.inboxModel.Email
+	if m.name != "" {
+		return m.name
+	}
+	return mailboxName(m.inboxModel, m.folder)
+}
+
+// sameMailbox reports whether both mailboxes hold the same messages, even when
+// opened under different names such as INBOX and the path of the inbox
+func sameMailbox(a, b backend.Mailbox) bool {
+	ma, ok := a.(*ImapMailbox)
+	if !ok {
+		return a.Name() == b.Name()
+	}
+	mb, ok := b.(*ImapMailbox)
+	if !ok {
+		return false
+	}
+	return ma.inboxModel.ID == mb.inboxModel.ID && ma.folderID() == mb.folderID()
 }
 
 // Info returns mailbox info
 func (m *ImapMailbox) Info() (*imap.MailboxInfo, error) {
 	info := &imap.MailboxInfo{
 		Attributes: []string{},
-		Delimiter:  "/",
-		Name:       m.inboxModel.Email,
+		Delimiter:  core.FolderDelimiter,
+		Name:       m.Name(),
+	}
+	if m.folder != nil && m.folder.SpecialUse.Valid {
+		info.Attributes = append(info.Attributes, m.folder.SpecialUse.String)
 	}
 	return info, nil
 }
 
+// folderID returns the ID of the folder, or an empty string for the inbox itself
+func (m *ImapMailbox) folderID() string {
+	return folderIDOf(m.folder)
+}
+
+// UIDValidity returns the UIDVALIDITY of the mailbox
+func (m *ImapMailbox) UIDValidity() uint32 {
+	if m.folder != nil {
+		return m.folder.UIDValidity
+	}
+	return m.inboxModel.UIDValidity
+}
+
+// uidNext returns the UID the next message stored in the mailbox will get
+func (m *ImapMailbox) uidNext() uint32 {
+	if m.folder != nil {
+		return m.folder.UIDNext
+	}
+	return m.inboxModel.UIDNext
+}
+
+// sequence returns the sequence map of the session, loading it on first use
+func (m *ImapMailbox) sequence(ctx context.Context) (*sequenceMap, error) {
+	if m.seq == nil {
+		uids, err := m.user.core.Repository.GetAllMessageUIDsForInboxIncludingDeleted(ctx, m.inboxModel.ID, m.folderID())
+		if err != nil {
+			m.user.core.Logger.Error("Failed to load message UIDs for inbox %s: %v", m.inboxModel.ID, err)
+			return nil, err
+		}
+		m.seq = &sequenceMap{uids: uids}
+	}
+	return m.seq, nil
+}
+
+// RefreshSequence picks up the messages other sessions added to or removed from the mailbox
+func (m *ImapMailbox) RefreshSequence() (*sequenceUpdate, error) {
+	ctx := m.ctx
+
+	seq, err := m.sequence(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := m.user.core.Repository.GetAllMessageUIDsForInboxIncludingDeleted(ctx, m.inboxModel.ID, m.folderID())
+	if err != nil {
+		m.user.core.Logger.Error("Failed to load message UIDs for inbox %s: %v", m.inboxModel.ID, err)
+		return nil, err
+	}
+
+	update := &sequenceUpdate{}
+	var grew bool
+	update.Expunged, update.Vanished, grew = seq.sync(current)
+	if grew {
+		update.Exists = uint32(len(seq.uids))
+	}
+	return update, nil
+}
+
+// forget removes expunged messages from the sequence map of the session
+func (m *ImapMailbox) forget(uids []uint32) {
+	if m.seq != nil {
+		m.seq.remove(uids)
+	}
+}
+
 // Status returns mailbox status
 func (m *ImapMailbox) Status(items []imap.StatusItem) (*imap.MailboxStatus, error) {
 	ctx := m.ctx
-	status := imap.NewMailboxStatus(m.inboxModel.Email, items)
+	status := imap.NewMailboxStatus(m.Name(), items)
 
-	// Get total message count (non-deleted)
-	falseVal := false
-	filters := models.MessageFilters{IsDeleted: &falseVal}
-	_, total, err := m.user.core.Repository.ListMessagesByInboxWithFilters(ctx, m.inboxModel.ID, filters, 1, 0)
+	// Messages flagged \Deleted are still in the mailbox until they are expunged
+	seq, err := m.sequence(ctx)
 	if err != nil {
-		m.user.core.Logger.Error("Failed to get message count for inbox %s: %v", m.inboxModel.ID, err)
 		return nil, err
 	}
-	status.Messages = uint32(total)
+	status.Messages = uint32(len(seq.uids))
 
-	// Get unread message count (non-deleted and unread)
-	filters.IsRead = &falseVal
+	// Get unread message count
+	falseVal := false
+	filters := models.MessageFilters{FolderID: m.folderID(), IsRead: &falseVal}
 	_, unreadTotal, err := m.user.core.Repository.ListMessagesByInboxWithFilters(ctx, m.inboxModel.ID, filters, 1, 0)
 	if err != nil {
 		m.user.core.Logger.Error("Failed to get unread message count for inbox %s: %v", m.inboxModel.ID, err)
@@ -76,26 +175,41 @@ func (m *ImapMailbox) Status(items []imap.StatusItem) (*imap.MailboxStatus, erro
 	// Set recent messages count (for simplicity, assume all unseen are recent)
 	status.Recent = status.Unseen
 
-	// Get next UID
-	maxUID, err := m.user.core.Repository.GetMaxMessageUID(ctx, m.inboxModel.ID)
+	status.UidValidity = m.UIDValidity()
+	status.UidNext = m.uidNext()
+
+	if _, ok := status.Items[statusHighestModSeq]; ok {
+		modSeq, err := m.HighestModSeq()
+		if err != nil {
+			return nil, err
+		}
+		status.Items[statusHighestModSeq] = formatModSeq(modSeq)
+	}
+
+	// Project labels are exposed as keywords next to the system flags
+	labels, err := m.projectLabels(ctx)
 	if err != nil {
-		m.user.core.Logger.Error("Failed to get max UID for inbox %s: %v", m.inboxModel.ID, err)
 		return nil, err
 	}
-	status.UidNext = maxUID + 1
-
-	// Set UID validity (use inbox creation timestamp)
-	if m.inboxModel.CreatedAt.Valid {
-		status.UidValidity = uint32(m.inboxModel.CreatedAt.Time.Unix())
-	} else {
-		status.UidValidity = 1
+	status.Flags = append([]string{}, systemFlags...)
+	for _, label := range labels {
+		status.Flags = append(status.Flags, core.LabelKeyword(label.Name))
 	}
+	// All flags are stored, and clients may create new keywords
+	status.PermanentFlags = append(append([]string{}, status.Flags...), imap.TryCreateFlag)
 
 	return status, nil
 }
 
 // ListMessages returns a list of messages
 func (m *ImapMailbox) ListMessages(uid bool, seqSet *imap.SeqSet, items []imap.FetchItem, ch chan<- *imap.Message) error {
+	return m.ListMessagesChangedSince(uid, seqSet, items, 0, ch)
+}
+
+// ListMessagesChangedSince is ListMessages restricted to the messages whose
+// mod-sequence is above changedSince (RFC 7162 CHANGEDSINCE). A changedSince
+// of 0 lists all messages.
+func (m *ImapMailbox) ListMessagesChangedSince(uid bool, seqSet *imap.SeqSet, items []imap.FetchItem, changedSince uint64, ch chan<- *imap.Message) error {
 	defer close(ch)
 	ctx := m.ctx
 
@@ -111,15 +225,34 @@ func (m *ImapMailbox) ListMessages(uid bool, seqSet *imap.SeqSet, items []imap.F
 	}
 
 	// Get messages by UIDs
-	dbMessages, err := m.user.core.Repository.GetMessagesByUIDs(ctx, m.inboxModel.ID, uids)
+	dbMessages, err := m.user.core.Repository.GetMessagesByUIDs(ctx, m.inboxModel.ID, m.folderID(), uids)
 	if err != nil {
 		m.user.core.Logger.Error("Failed to get messages by UIDs: %v", err)
 		return err
 	}
 
+	if err := m.user.core.LabelService.AttachToMessages(ctx, dbMessages); err != nil {
+		return err
+	}
+
+	if needsContent(items) {
+		if err := m.user.core.MessageService.AttachRaw(ctx, dbMessages); err != nil {
+			return err
+		}
+	}
+
+	seq, err := m.sequence(ctx)
+	if err != nil {
+		return err
+	}
+
 	// Map messages to IMAP format and send to channel
-	for seqNum, dbMsg := range dbMessages {
-		imapMsg, err := buildImapMessage(dbMsg, uint32(seqNum+1), items)
+	for _, dbMsg := range dbMessages {
+		seqNum := seq.seqNum(dbMsg.UID)
+		if seqNum == 0 || dbMsg.ModSeq <= changedSince {
+			continue
+		}
+		imapMsg, err := buildImapMessage(dbMsg, seqNum, items)
 		if err != nil {
 			m.user.core.Logger.Error("Failed to build IMAP message: %v", err)
 			continue
@@ -132,24 +265,49 @@ func (m *ImapMailbox) ListMessages(uid bool, seqSet *imap.SeqSet, items []imap.F
 
 // SearchMessages searches for messages matching the given criteria
 func (m *ImapMailbox) SearchMessages(uid bool, criteria *imap.SearchCriteria) ([]uint32, error) {
-	ctx := m.ctx
+	results, _, err := m.SearchMessagesModSeq(uid, criteria, 0)
+	return results, err
+}
 
-	// For basic implementation, handle common flag searches
-	filters := models.MessageFilters{}
+// SearchMessagesModSeq is SearchMessages with the RFC 7162 MODSEQ criterion: only
+// messages whose mod-sequence is at least modSeq match. It also returns the
+// highest mod-sequence among the matching messages.
+func (m *ImapMailbox) SearchMessagesModSeq(uid bool, criteria *imap.SearchCriteria, modSeq uint64) ([]uint32, uint64, error) {
+	matches, err := m.search(criteria, modSeq)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	// Always exclude deleted messages by default (unless specifically searching for them)
-	searchingDeleted := false
-	for _, flag := range criteria.WithFlags {
-		if flag == imap.DeletedFlag {
-			searchingDeleted = true
-			break
-		}
+	results := make([]uint32, 0, len(matches))
+	var highestModSeq uint64
+	for _, match := range matches {
+		results = append(results, match.id(uid))
+		highestModSeq = max(highestModSeq, match.message.ModSeq)
 	}
+	return results, highestModSeq, nil
+}
+
+// searchMatch is a message matching search criteria, with its sequence number
+type searchMatch struct {
+	message *models.Message
+	seqNum  uint32
+}
 
-	if !searchingDeleted {
-		falseVal := false
-		filters.IsDeleted = &falseVal
+// id returns the UID or the sequence number of the message
+func (sm searchMatch) id(uid bool) uint32 {
+	if uid {
+		return sm.message.UID
 	}
+	return sm.seqNum
+}
+
+// search returns the messages of the session matching the criteria whose
+// mod-sequence is at least modSeq, in sequence number order
+func (m *ImapMailbox) search(criteria *imap.SearchCriteria, modSeq uint64) ([]searchMatch, error) {
+	ctx := m.ctx
+
+	// For basic implementation, handle common flag searches
+	filters := models.MessageFilters{FolderID: m.folderID()}
 
 	// Handle flag-based searches
 	for _, flag := range criteria.WithFlags {
@@ -198,21 +356,103 @@ func (m *ImapMailbox) SearchMessages(uid bool, criteria *imap.SearchCriteria) ([
 
 	messages := allMessages
 
+	// Keyword criteria are matched against the message labels
+	searchingKeywords := false
+	for _, flag := range append(criteria.WithFlags, criteria.WithoutFlags...) {
+		if isKeyword(flag) {
+			searchingKeywords = true
+			break
+		}
+	}
+	if searchingKeywords {
+		if err := m.user.core.LabelService.AttachToMessages(ctx, messages); err != nil {
+			return nil, err
+		}
+	}
+
+	seq, err := m.sequence(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sequence numbers and UIDs are resolved against the session, which also settles "*"
+	var bySeqNum, byUID map[uint32]bool
+	if criteria.SeqNum != nil {
+		bySeqNum = uidSet(seq.resolve(criteria.SeqNum, false))
+	}
+	if criteria.Uid != nil {
+		byUID = uidSet(seq.resolve(criteria.Uid, true))
+	}
+	rest := *criteria
+	rest.SeqNum = nil
+	rest.Uid = nil
+
 	// Handle search criteria based on header fields, body, etc.
-	results := []uint32{}
-	for i, msg := range messages {
+	var matches []searchMatch
+	for _, msg := range messages {
+		// Messages the session does not know about yet cannot be addressed
+		seqNum := seq.seqNum(msg.UID)
+		if seqNum == 0 {
+			continue
+		}
+		if (bySeqNum != nil && !bySeqNum[msg.UID]) || (byUID != nil && !byUID[msg.UID]) || msg.ModSeq < modSeq {
+			continue
+		}
+
 		// Apply additional search criteria
-		if matchesSearchCriteria(msg, criteria) {
-			if uid {
-				results = append(results, msg.UID)
-			} else {
-				// For sequence numbers, use 1-based indexing
-				results = append(results, uint32(i+1))
-			}
+		if matchesSearchCriteria(msg, &rest) && matchesFlags(msg, criteria) {
+			matches = append(matches, searchMatch{message: msg, seqNum: seqNum})
 		}
 	}
 
-	return results, nil
+	sort.Slice(matches, func(i, j int) bool { return matches[i].seqNum < matches[j].seqNum })
+	return matches, nil
+}
+
+// sortMessages returns the messages matching the search criteria with the values
+// SORT and THREAD compare
+func (m *ImapMailbox) sortMessages(uid bool, criteria *imap.SearchCriteria) ([]*sortMessage, error) {
+	matches, err := m.search(criteria, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*models.Message, 0, len(matches))
+	for _, match := range matches {
+		messages = append(messages, match.message)
+	}
+	if err := m.user.core.MessageService.AttachRaw(m.ctx, messages); err != nil {
+		return nil, err
+	}
+
+	sorted := make([]*sortMessage, 0, len(matches))
+	for _, match := range matches {
+		sorted = append(sorted, newSortMessage(match, uid))
+	}
+	return sorted, nil
+}
+
+// SortMessages returns the messages matching the search criteria sorted by the
+// RFC 5256 sort criteria
+func (m *ImapMailbox) SortMessages(uid bool, criteria []sortCriterion, search *imap.SearchCriteria) ([]uint32, error) {
+	messages, err := m.sortMessages(uid, search)
+	if err != nil {
+		return nil, err
+	}
+	return sortMessages(messages, criteria), nil
+}
+
+// ThreadMessages returns the messages matching the search criteria grouped into
+// threads by an RFC 5256 threading algorithm
+func (m *ImapMailbox) ThreadMessages(uid bool, algorithm string, search *imap.SearchCriteria) ([]*threadNode, error) {
+	messages, err := m.sortMessages(uid, search)
+	if err != nil {
+		return nil, err
+	}
+	if algorithm == threadOrderedSubject {
+		return threadByOrderedSubject(messages), nil
+	}
+	return threadByReferences(messages), nil
 }
 
 // Check does nothing for this implementation
@@ -220,15 +460,23 @@ func (m *ImapMailbox) Check() error {
 	return nil
 }
 
-// ExpungeMessages permanently deletes messages with given UIDs
+// ExpungeMessages removes messages with given UIDs, moving them to Trash when
+// the inbox has one and they are not already there
 func (m *ImapMailbox) ExpungeMessages(uids []uint32) error {
 	ctx := m.ctx
 	var failedUIDs []uint32
 
+	if moved, err := m.moveToTrash(ctx, uids); err != nil || moved {
+		if moved {
+			m.forget(uids)
+		}
+		return err
+	}
+
 	// Delete each message
 	for _, uid := range uids {
 		// Get the message UUID from the UID
-		messageID, err := m.user.core.Repository.GetMessageIDFromUID(ctx, m.inboxModel.ID, uid)
+		messageID, err := m.user.core.Repository.GetMessageIDFromUID(ctx, m.inboxModel.ID, m.folderID(), uid)
 		if err != nil {
 			m.user.core.Logger.Error("Failed to find message ID for UID %d in inbox %s: %v", uid, m.inboxModel.ID, err)
 			failedUIDs = append(failedUIDs, uid)
@@ -243,6 +491,7 @@ func (m *ImapMailbox) ExpungeMessages(uids []uint32) error {
 	}
 
 	successCount := len(uids) - len(failedUIDs)
+	m.forget(uids)
 
 	if len(failedUIDs) > 0 {
 		m.user.core.Logger.Error("Failed to expunge %d messages (UIDs: %v) from inbox %s", len(failedUIDs), failedUIDs, m.inboxModel.ID)
@@ -252,237 +501,299 @@ func (m *ImapMailbox) ExpungeMessages(uids []uint32) error {
 	return nil
 }
 
-// CopyMessages is not supported
+// CopyMessages copies messages into another mailbox of the user
 func (m *ImapMailbox) CopyMessages(uid bool, seqSet *imap.SeqSet, dest string) error {
-	return errors.New("copy not supported")
+	ctx := m.ctx
+
+	destInbox, destFolder, err := m.user.resolveMailbox(ctx, dest)
+	if err != nil {
+		return err
+	}
+
+	uids, err := m.resolveSeqSetToUIDs(ctx, seqSet, uid)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.user.core.MessageService.Copy(ctx, m.inboxModel.ID, m.folderID(), uids, destInbox.ID, folderIDOf(destFolder))
+	return quotaError(err)
 }
 
-// MoveMessages is not supported
+// MoveMessages moves messages into another mailbox of the user (RFC 6851)
 func (m *ImapMailbox) MoveMessages(uid bool, seqSet *imap.SeqSet, dest string) error {
-	return errors.New("move not supported")
+	ctx := m.ctx
+
+	destInbox, destFolder, err := m.user.resolveMailbox(ctx, dest)
+	if err != nil {
+		return err
+	}
+
+	uids, err := m.resolveSeqSetToUIDs(ctx, seqSet, uid)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.user.core.MessageService.Move(ctx, m.inboxModel.ID, m.folderID(), uids, destInbox.ID, folderIDOf(destFolder))
+	return quotaError(err)
 }
 
-// CreateMessage is not supported
+// CreateMessage appends a message to the mailbox (APPEND)
 func (m *ImapMailbox) CreateMessage(flags []string, date time.Time, body imap.Literal) error {
-	return errors.New("message creation not supported")
+	_, _, err := m.CreateMessageUID(flags, date, body)
+	return err
+}
+
+// CreateMessageUID appends a message to the mailbox and returns the UIDVALIDITY of
+// the mailbox and the UID of the new message, for the APPENDUID response (RFC 4315).
+// The message is parsed like mail received over SMTP; the flags are stored with it
+// and date, when given, becomes its internal date.
+func (m *ImapMailbox) CreateMessageUID(flags []string, date time.Time, body imap.Literal) (uint32, uint32, error) {
+	ctx := m.ctx
+
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	msg, err := core.ParseRawMessage(raw)
+	if err != nil {
+		m.user.core.Logger.Error("Failed to parse appended message for inbox %s: %v", m.inboxModel.ID, err)
+		return 0, 0, err
+	}
+	msg.InboxID = m.inboxModel.ID
+	if m.folder != nil {
+		msg.FolderID = null.StringFrom(m.folder.ID)
+	}
+	if !date.IsZero() {
+		msg.CreatedAt = null.TimeFrom(date)
+	}
+
+	messageFlags := parseFlags(flags)
+	msg.IsRead = messageFlags.Seen
+	msg.IsAnswered = messageFlags.Answered
+	msg.IsFlagged = messageFlags.Flagged
+	msg.IsDeleted = messageFlags.Deleted
+	msg.IsDraft = messageFlags.Draft
+
+	keywordLabels, err := m.keywordLabels(ctx, flags, true)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := m.user.core.MessageService.Store(ctx, msg); err != nil {
+		return 0, 0, quotaError(err)
+	}
+
+	if len(keywordLabels) > 0 {
+		labelFlags := models.MessageFlags{}
+		for _, label := range keywordLabels {
+			labelFlags.LabelIDs = append(labelFlags.LabelIDs, label.ID)
+		}
+		if err := m.user.core.MessageService.UpdateFlags(ctx, m.inboxModel.ID, m.folderID(), []uint32{msg.UID}, models.FlagsAdd, labelFlags); err != nil {
+			// The APPEND fails, so the client will send the message again
+			// and it mustn't be kept without its keywords meanwhile
+			if deleteErr := m.user.core.MessageService.Delete(ctx, msg.ID); deleteErr != nil {
+				m.user.core.Logger.Error("Failed to remove appended message %s: %v", msg.ID, deleteErr)
+			}
+			return 0, 0, err
+		}
+	}
+
+	return m.UIDValidity(), msg.UID, nil
 }
 
-// UpdateMessagesFlags handles flag updates for messages
+// UpdateMessagesFlags applies a STORE command to all addressed messages in one transaction
 func (m *ImapMailbox) UpdateMessagesFlags(uid bool, seqSet *imap.SeqSet, operation imap.FlagsOp, flags []string) error {
+	_, err := m.UpdateMessagesFlagsUnchangedSince(uid, seqSet, operation, flags, 0)
+	return err
+}
+
+// UpdateMessagesFlagsUnchangedSince is UpdateMessagesFlags for RFC 7162
+// UNCHANGEDSINCE: messages whose mod-sequence is above unchangedSince are left
+// alone and returned, as UIDs or sequence numbers, for the MODIFIED response.
+// An unchangedSince of 0 updates all messages.
+func (m *ImapMailbox) UpdateMessagesFlagsUnchangedSince(uid bool, seqSet *imap.SeqSet, operation imap.FlagsOp, flags []string, unchangedSince uint64) ([]uint32, error) {
 	ctx := m.ctx
 
 	// Resolve sequence set to UIDs
 	uids, err := m.resolveSeqSetToUIDs(ctx, seqSet, uid)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Track errors for logging summary
-	var failedUpdates int
-
-	// Update flags for each message
-	for _, messageUID := range uids {
-		// Get the message UUID from the UID
-		messageID, err := m.user.core.Repository.GetMessageIDFromUID(ctx, m.inboxModel.ID, messageUID)
+	var modified []uint32
+	if unchangedSince > 0 && len(uids) > 0 {
+		messages, err := m.user.core.Repository.GetMessagesByUIDs(ctx, m.inboxModel.ID, m.folderID(), uids)
 		if err != nil {
-			m.user.core.Logger.Error("Failed to find message ID for UID %d in inbox %s: %v", messageUID, m.inboxModel.ID, err)
-			failedUpdates++
-			continue // Skip to the next UID
+			return nil, err
 		}
-
-		// For SetFlags operation, first clear all flags
-		if operation == imap.SetFlags {
-			// Clear Seen flag if not in the new flags list
-			seenInFlags := false
-			deletedInFlags := false
-			for _, flag := range flags {
-				if flag == imap.SeenFlag {
-					seenInFlags = true
-				}
-				if flag == imap.DeletedFlag {
-					deletedInFlags = true
-				}
-			}
-
-			// Clear flags that are not in the new set
-			if !seenInFlags {
-				if err := m.user.core.MessageService.MarkAsUnread(ctx, messageID); err != nil {
-					m.user.core.Logger.Error("Failed to mark message %d as unread: %v", messageUID, err)
-					failedUpdates++
-				}
-			}
-			if !deletedInFlags {
-				if err := m.user.core.MessageService.MarkAsUndeleted(ctx, messageID); err != nil {
-					m.user.core.Logger.Error("Failed to mark message %d as undeleted: %v", messageUID, err)
-					failedUpdates++
-				}
-			}
+		seq, err := m.sequence(ctx)
+		if err != nil {
+			return nil, err
 		}
 
-		// Now apply the requested flags
-		for _, flag := range flags {
-			switch flag {
-			case imap.SeenFlag:
-				switch operation {
-				case imap.AddFlags, imap.SetFlags:
-					if err := m.user.core.MessageService.MarkAsRead(ctx, messageID); err != nil {
-						m.user.core.Logger.Error("Failed to mark message %d as read: %v", messageUID, err)
-						failedUpdates++
-					}
-				case imap.RemoveFlags:
-					if err := m.user.core.MessageService.MarkAsUnread(ctx, messageID); err != nil {
-						m.user.core.Logger.Error("Failed to mark message %d as unread: %v", messageUID, err)
-						failedUpdates++
-					}
-				}
-			case imap.DeletedFlag:
-				switch operation {
-				case imap.AddFlags, imap.SetFlags:
-					if err := m.user.core.MessageService.MarkAsDeleted(ctx, messageID); err != nil {
-						m.user.core.Logger.Error("Failed to mark message %d as deleted: %v", messageUID, err)
-						failedUpdates++
-					}
-				case imap.RemoveFlags:
-					if err := m.user.core.MessageService.MarkAsUndeleted(ctx, messageID); err != nil {
-						m.user.core.Logger.Error("Failed to mark message %d as undeleted: %v", messageUID, err)
-						failedUpdates++
-					}
-				}
+		uids = uids[:0]
+		for _, msg := range messages {
+			if msg.ModSeq <= unchangedSince {
+				uids = append(uids, msg.UID)
+			} else if uid {
+				modified = append(modified, msg.UID)
+			} else {
+				modified = append(modified, seq.seqNum(msg.UID))
 			}
 		}
 	}
 
-	// Log summary if there were any failures
-	if failedUpdates > 0 {
-		m.user.core.Logger.Warn("STORE operation completed with %d failed updates out of %d messages", failedUpdates, len(uids))
+	// Keywords map to project labels; unknown keywords create a label unless they are being removed
+	keywordLabels, err := m.keywordLabels(ctx, flags, operation != imap.RemoveFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	messageFlags := parseFlags(flags)
+	for _, label := range keywordLabels {
+		messageFlags.LabelIDs = append(messageFlags.LabelIDs, label.ID)
 	}
+	messageFlags.UnchangedSince = unchangedSince
 
-	return nil
+	return modified, m.user.core.MessageService.UpdateFlags(ctx, m.inboxModel.ID, m.folderID(), uids, flagOperation(operation), messageFlags)
+}
+
+// HighestModSeq returns the highest mod-sequence of the mailbox (RFC 7162)
+func (m *ImapMailbox) HighestModSeq() (uint64, error) {
+	modSeq, err := m.user.core.Repository.GetHighestModSeq(m.ctx, m.inboxModel.ID, m.folderID())
+	if err != nil {
+		m.user.core.Logger.Error("Failed to get highest mod-sequence for inbox %s: %v", m.inboxModel.ID, err)
+	}
+	return modSeq, err
+}
+
+// ExpungedSince returns the UIDs of the messages removed from the mailbox after
+// the given mod-sequence, for RFC 7162 VANISHED (EARLIER) responses
+func (m *ImapMailbox) ExpungedSince(modSeq uint64) ([]uint32, error) {
+	uids, err := m.user.core.Repository.ListExpungedMessageUIDs(m.ctx, m.inboxModel.ID, m.folderID(), modSeq)
+	if err != nil {
+		m.user.core.Logger.Error("Failed to list expunged messages for inbox %s: %v", m.inboxModel.ID, err)
+	}
+	return uids, err
 }
 
-// Expunge permanently deletes messages marked as deleted
+// Expunge removes messages marked as deleted. Outside of Trash they are moved
+// to Trash when the inbox has one, otherwise they are deleted permanently.
 func (m *ImapMailbox) Expunge() error {
 	ctx := m.ctx
 
 	// Get messages marked as deleted in batches to prevent memory issues
 	trueVal := true
-	filters := models.MessageFilters{IsDeleted: &trueVal}
+	filters := models.MessageFilters{FolderID: m.folderID(), IsDeleted: &trueVal}
 
 	const batchSize = 100
-	offset := 0
-	totalExpunged := 0
 
-	for {
+	var deleted []*models.Message
+	for offset := 0; ; offset += batchSize {
 		messages, _, err := m.user.core.Repository.ListMessagesByInboxWithFilters(ctx, m.inboxModel.ID, filters, batchSize, offset)
 		if err != nil {
 			m.user.core.Logger.Error("Failed to get deleted messages for expunge: %v", err)
 			return err
 		}
-
-		// If no messages returned, we're done
-		if len(messages) == 0 {
+		deleted = append(deleted, messages...)
+		if len(messages) < batchSize {
 			break
 		}
+	}
 
-		// Permanently delete each message in this batch
-		for _, msg := range messages {
-			if err := m.user.core.MessageService.Delete(ctx, msg.ID); err != nil {
-				m.user.core.Logger.Error("Failed to expunge message %s: %v", msg.ID, err)
-				// Continue with other messages even if one fails
-			} else {
-				totalExpunged++
-			}
-		}
+	uids := make([]uint32, 0, len(deleted))
+	for _, msg := range deleted {
+		uids = append(uids, msg.UID)
+	}
 
-		// If we got fewer messages than the batch size, we're done
-		if len(messages) < batchSize {
-			break
+	if moved, err := m.moveToTrash(ctx, uids); err != nil || moved {
+		if moved {
+			m.forget(uids)
 		}
+		return err
+	}
 
-		offset += batchSize
+	// Permanently delete each message
+	totalExpunged := 0
+	for _, msg := range deleted {
+		if err := m.user.core.MessageService.Delete(ctx, msg.ID); err != nil {
+			m.user.core.Logger.Error("Failed to expunge message %s: %v", msg.ID, err)
+			// Continue with other messages even if one fails
+		} else {
+			totalExpunged++
+		}
 	}
+	m.forget(uids)
 
 	m.user.core.Logger.Info("Expunged %d messages from inbox %s", totalExpunged, m.inboxModel.ID)
 	return nil
 }
 
-// SetSubscribed is not supported
-func (m *ImapMailbox) SetSubscribed(subscribed bool) error {
-	return errors.New("subscription changes not supported")
+// isTrash reports whether the mailbox is the Trash folder of its inbox
+func (m *ImapMailbox) isTrash() bool {
+	return m.folder != nil && m.folder.SpecialUse.String == core.SpecialUseTrash
 }
 
-// resolveSeqSetToUIDs converts sequence set to UIDs
-func (m *ImapMailbox) resolveSeqSetToUIDs(ctx context.Context, seqSet *imap.SeqSet, uid bool) ([]uint32, error) {
-	if uid {
-		// If already UIDs, extract them from sequence set
-		var uids []uint32
-		for _, seq := range seqSet.Set {
-			if seq.Stop == 0 {
-				// Single number
-				if seq.Start > 0 && seq.Start <= MaxUID {
-					uids = append(uids, seq.Start)
-				}
-			} else {
-				// Range - validate bounds before iterating
-				start := seq.Start
-				stop := seq.Stop
-
-				// Skip invalid ranges
-				if start == 0 || stop == 0 || start > stop || start > MaxUID || stop > MaxUID {
-					continue
-				}
-
-				// Limit range size to prevent performance issues
-				if stop-start+1 > MaxSequenceRange {
-					stop = start + MaxSequenceRange - 1
-				}
-
-				for i := start; i <= stop; i++ {
-					uids = append(uids, i)
-				}
-			}
-		}
-		return uids, nil
+// moveToTrash moves messages into the Trash folder of the inbox. It reports false,
+// without moving anything, when this mailbox is Trash or the inbox has no Trash folder.
+func (m *ImapMailbox) moveToTrash(ctx context.Context, uids []uint32) (bool, error) {
+	if m.isTrash() {
+		return false, nil
+	}
+	if len(uids) == 0 {
+		return true, nil
 	}
 
-	// If sequence numbers, we need to get all UIDs including deleted messages for proper sequence mapping
-	allUIDs, err := m.user.core.Repository.GetAllMessageUIDsForInboxIncludingDeleted(ctx, m.inboxModel.ID)
+	err := m.user.core.MessageService.Trash(ctx, m.inboxModel.ID, m.folderID(), uids)
+	if errors.Is(err, core.ErrNotFound) {
+		return false, nil
+	}
 	if err != nil {
-		return nil, err
+		m.user.core.Logger.Error("Failed to move messages to Trash in inbox %s: %v", m.inboxModel.ID, err)
+		return false, err
 	}
 
-	var uids []uint32
-	for _, seq := range seqSet.Set {
-		if seq.Stop == 0 {
-			// Single sequence number
-			if seq.Start > 0 && int(seq.Start-1) < len(allUIDs) {
-				uids = append(uids, allUIDs[seq.Start-1])
-			}
-		} else {
-			// Range of sequence numbers - validate bounds before iterating
-			start := seq.Start
-			stop := seq.Stop
+	m.user.core.Logger.Info("Moved %d messages to Trash in inbox %s", len(uids), m.inboxModel.ID)
+	return true, nil
+}
 
-			// Skip invalid ranges
-			if start == 0 || stop == 0 || start > stop {
-				continue
-			}
+// SetSubscribed subscribes to or unsubscribes from a folder. Inboxes are always subscribed.
+func (m *ImapMailbox) SetSubscribed(subscribed bool) error {
+	if m.folder == nil {
+		if !subscribed {
+			return errors.New("inboxes cannot be unsubscribed")
+		}
+		return nil
+	}
 
-			// Limit range size to prevent performance issues
-			if stop-start+1 > MaxSequenceRange {
-				stop = start + MaxSequenceRange - 1
-			}
+	m.folder.Subscribed = subscribed
+	return m.user.core.FolderService.Update(m.ctx, m.folder)
+}
 
-			// Ensure we don't exceed available UIDs
-			if int(stop) > len(allUIDs) {
-				stop = uint32(len(allUIDs))
-			}
+// projectLabels returns all labels of the project the mailbox belongs to
+func (m *ImapMailbox) projectLabels(ctx context.Context) ([]*models.Label, error) {
+	return m.user.core.LabelService.ListAllByProject(ctx, m.inboxModel.ProjectID)
+}
 
-			for i := start; i <= stop && int(i-1) < len(allUIDs); i++ {
-				uids = append(uids, allUIDs[i-1])
-			}
+// keywordLabels resolves the keywords among flags to project labels. Unknown
+// keywords are created as labels when create is set.
+func (m *ImapMailbox) keywordLabels(ctx context.Context, flags []string, create bool) ([]*models.Label, error) {
+	var keywords []string
+	for _, flag := range flags {
+		if isKeyword(flag) {
+			keywords = append(keywords, flag)
 		}
 	}
+	return m.user.core.LabelService.ResolveKeywords(ctx, m.inboxModel.ProjectID, keywords, create)
+}
 
-	return uids, nil
+// resolveSeqSetToUIDs converts a sequence set to the UIDs of the messages it
+// addresses in this session
+func (m *ImapMailbox) resolveSeqSetToUIDs(ctx context.Context, seqSet *imap.SeqSet, uid bool) ([]uint32, error) {
+	seq, err := m.sequence(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return seq.resolve(seqSet, uid), nil
 }
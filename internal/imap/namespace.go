@@ -0,0 +1,145 @@
+package imap
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"inbox451/internal/core"
+	"inbox451/internal/models"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/server"
+)
+
+const (
+	// inboxName is the name of the user's default inbox (RFC 3501 section 5.1).
+	// It is case-insensitive.
+	inboxName = "INBOX"
+	// projectsNamespace is the RFC 2342 shared namespace holding the inboxes of
+	// the user's projects, as Projects/<project name>/<inbox email>
+	projectsNamespace = "Projects"
+)
+
+var errNoSelect = errors.New("mailbox only groups other mailboxes and cannot be selected")
+
+// isInboxName reports whether name refers to INBOX
+func isInboxName(name string) bool {
+	return strings.EqualFold(name, inboxName)
+}
+
+// inboxPath returns the IMAP name of an inbox in the projects namespace
+func inboxPath(inbox *models.Inbox) string {
+	return projectsNamespace + core.FolderDelimiter + inbox.ProjectName + core.FolderDelimiter + inbox.Email
+}
+
+// cutInboxPath matches a name in the projects namespace against an inbox. It
+// returns the folder path below the inbox, empty for the inbox itself.
+func cutInboxPath(name string, inbox *models.Inbox) (folder string, ok bool) {
+	path := inboxPath(inbox)
+	if name == path {
+		return "", true
+	}
+	folder, ok = strings.CutPrefix(name, path+core.FolderDelimiter)
+	if !ok {
+		return "", false
+	}
+	return folder, true
+}
+
+// parentNames returns the names of the levels above an inbox in the projects
+// namespace, outermost first. Project names may contain the delimiter
+// themselves, which adds levels.
+func parentNames(inbox *models.Inbox) []string {
+	names := []string{projectsNamespace}
+	for _, level := range strings.Split(inbox.ProjectName, core.FolderDelimiter) {
+		names = append(names, names[len(names)-1]+core.FolderDelimiter+level)
+	}
+	return names
+}
+
+// namespaceExtension implements RFC 2342 NAMESPACE. INBOX is in the personal
+// namespace, the inboxes of all projects of the user in the shared one.
+type namespaceExtension struct{}
+
+func (namespaceExtension) Capabilities(c server.Conn) []string {
+	return []string{"NAMESPACE"}
+}
+
+func (namespaceExtension) Command(name string) server.HandlerFactory {
+	if name == "NAMESPACE" {
+		return func() server.Handler { return &namespaceCommand{} }
+	}
+	return nil
+}
+
+type namespaceCommand struct{}
+
+func (cmd *namespaceCommand) Parse(fields []interface{}) error {
+	return nil
+}
+
+func (cmd *namespaceCommand) Handle(conn server.Conn) error {
+	if conn.Context().User == nil {
+		return server.ErrNotAuthenticated
+	}
+
+	personal := []interface{}{[]interface{}{"", core.FolderDelimiter}}
+	shared := []interface{}{[]interface{}{projectsNamespace + core.FolderDelimiter, core.FolderDelimiter}}
+	return conn.WriteResp(imap.NewUntaggedResp([]interface{}{imap.RawString("NAMESPACE"), personal, nil, shared}))
+}
+
+// hierarchyMailbox is a level of the projects namespace that only exists to
+// group inboxes, listed with \Noselect
+type hierarchyMailbox struct {
+	name string
+}
+
+func (m *hierarchyMailbox) Name() string {
+	return m.name
+}
+
+func (m *hierarchyMailbox) Info() (*imap.MailboxInfo, error) {
+	return &imap.MailboxInfo{
+		Attributes: []string{imap.NoSelectAttr, imap.HasChildrenAttr},
+		Delimiter:  core.FolderDelimiter,
+		Name:       m.name,
+	}, nil
+}
+
+func (m *hierarchyMailbox) Status(items []imap.StatusItem) (*imap.MailboxStatus, error) {
+	return nil, errNoSelect
+}
+
+func (m *hierarchyMailbox) SetSubscribed(subscribed bool) error {
+	return errNoSelect
+}
+
+func (m *hierarchyMailbox) Check() error {
+	return errNoSelect
+}
+
+func (m *hierarchyMailbox) ListMessages(uid bool, seqSet *imap.SeqSet, items []imap.FetchItem, ch chan<- *imap.Message) error {
+	close(ch)
+	return errNoSelect
+}
+
+func (m *hierarchyMailbox) SearchMessages(uid bool, criteria *imap.SearchCriteria) ([]uint32, error) {
+	return nil, errNoSelect
+}
+
+func (m *hierarchyMailbox) CreateMessage(flags []string, date time.Time, body imap.Literal) error {
+	return errNoSelect
+}
+
+func (m *hierarchyMailbox) UpdateMessagesFlags(uid bool, seqSet *imap.SeqSet, operation imap.FlagsOp, flags []string) error {
+	return errNoSelect
+}
+
+func (m *hierarchyMailbox) CopyMessages(uid bool, seqSet *imap.SeqSet, dest string) error {
+	return errNoSelect
+}
+
+func (m *hierarchyMailbox) Expunge() error {
+	return errNoSelect
+}
@@ -0,0 +1,58 @@
+package imap
+
+import (
+	"context"
+	"testing"
+
+	"inbox451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsInboxName(t *testing.T) {
+	assert.True(t, isInboxName("INBOX"))
+	assert.True(t, isInboxName("inbox"))
+	assert.False(t, isInboxName("INBOX/Sent"))
+	assert.False(t, isInboxName("inbox@example.com"))
+}
+
+func TestCutInboxPath(t *testing.T) {
+	inbox := &models.Inbox{Email: "alerts@example.com", ProjectName: "Ops/EU"}
+
+	tests := []struct {
+		name   string
+		folder string
+		ok     bool
+	}{
+		{name: "Projects/Ops/EU/alerts@example.com", folder: "", ok: true},
+		{name: "Projects/Ops/EU/alerts@example.com/Archive/2024", folder: "Archive/2024", ok: true},
+		{name: "Projects/Ops/EU/alerts@example.com.au", ok: false},
+		{name: "Projects/Ops/alerts@example.com", ok: false},
+		{name: "alerts@example.com", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folder, ok := cutInboxPath(tt.name, inbox)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.folder, folder)
+		})
+	}
+}
+
+func TestParentNames(t *testing.T) {
+	assert.Equal(t, []string{"Projects", "Projects/Ops"}, parentNames(&models.Inbox{ProjectName: "Ops"}))
+	assert.Equal(t, []string{"Projects", "Projects/Ops", "Projects/Ops/EU"}, parentNames(&models.Inbox{ProjectName: "Ops/EU"}))
+}
+
+func TestInboxMailboxName(t *testing.T) {
+	inbox := &models.Inbox{Base: models.Base{ID: "inbox-1"}, Email: "alerts@example.com", ProjectName: "Ops"}
+
+	aliased := newInboxMailbox(context.Background(), inbox, nil)
+	canonical := NewImapMailbox(context.Background(), inbox, nil, nil)
+
+	assert.Equal(t, "INBOX", aliased.Name())
+	assert.Equal(t, "Projects/Ops/alerts@example.com", canonical.Name())
+	assert.True(t, sameMailbox(aliased, canonical))
+	assert.False(t, sameMailbox(aliased, NewImapMailbox(context.Background(), inbox, &models.Folder{Base: models.Base{ID: "folder-1"}}, nil)))
+}
@@ -0,0 +1,274 @@
+package imap
+
+import (
+	"errors"
+	"strconv"
+
+	"inbox451/internal/core"
+	"inbox451/internal/models"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/server"
+	"github.com/emersion/go-imap/utf7"
+)
+
+// errOverQuota refuses an APPEND, COPY or MOVE that doesn't fit in the quota
+// of the destination (RFC 9208 section 4.3)
+var errOverQuota = &imap.ErrStatusResp{Resp: &imap.StatusResp{
+	Type: imap.StatusRespNo,
+	Code: "OVERQUOTA",
+	Info: "Quota exceeded",
+}}
+
+var errNoSuchQuotaRoot = &imap.ErrStatusResp{Resp: &imap.StatusResp{
+	Type: imap.StatusRespNo,
+	Code: "NONEXISTENT",
+	Info: "No such quota root",
+}}
+
+// quotaError gives quota errors of the core their IMAP response code
+func quotaError(err error) error {
+	if errors.Is(err, core.ErrQuotaExceeded) {
+		return errOverQuota
+	}
+	return err
+}
+
+// quotaRoot is a resource limit of a mailbox. Every inbox is one, named like
+// its mailbox, and so is every project, named like the level of the projects
+// namespace holding its inboxes.
+type quotaRoot struct {
+	name  string
+	usage models.Usage
+}
+
+// projectRootName returns the name of the quota root of the project of an inbox
+func projectRootName(inbox *models.Inbox) string {
+	return projectsNamespace + core.FolderDelimiter + inbox.ProjectName
+}
+
+// newQuotaRoots returns the quota roots of an inbox that have a limit, the
+// inbox's first. Roots without any are left out, as RFC 9208 has no way to
+// tell a resource is unlimited.
+func newQuotaRoots(inbox *models.Inbox, quotas []*models.Quota) []*quotaRoot {
+	var roots []*quotaRoot
+	for _, quota := range quotas {
+		if quota.MaxMessages == 0 && quota.MaxBytes == 0 {
+			continue
+		}
+		name := inboxPath(inbox)
+		if quota.Kind == "project" {
+			name = projectRootName(inbox)
+		}
+		roots = append(roots, &quotaRoot{name: name, usage: quota.Usage})
+	}
+	return roots
+}
+
+// resources lists the limited resources of the root with their usage and
+// limit. STORAGE is in units of 1024 octets, usage rounded up.
+func (r *quotaRoot) resources() []interface{} {
+	var resources []interface{}
+	if r.usage.MaxBytes > 0 {
+		resources = append(resources, imap.RawString("STORAGE"),
+			number64((r.usage.StorageBytes+1023)/1024), number64(r.usage.MaxBytes/1024))
+	}
+	if r.usage.MaxMessages > 0 {
+		resources = append(resources, imap.RawString("MESSAGE"),
+			number64(r.usage.MessageCount), number64(r.usage.MaxMessages))
+	}
+	return resources
+}
+
+// number64 writes a number that may not fit in the 32 bits go-imap writes
+func number64(n int64) imap.RawString {
+	return imap.RawString(strconv.FormatInt(n, 10))
+}
+
+func (r *quotaRoot) response() *imap.DataResp {
+	return imap.NewUntaggedResp([]interface{}{imap.RawString("QUOTA"), r.name, r.resources()})
+}
+
+// quotaUser is a user whose mailboxes have quota roots
+type quotaUser interface {
+	// MailboxQuotaRoots returns the quota roots of a mailbox
+	MailboxQuotaRoots(mailbox string) ([]*quotaRoot, error)
+	// QuotaRoot returns a quota root by name
+	QuotaRoot(name string) (*quotaRoot, error)
+}
+
+// MailboxQuotaRoots returns the quota roots of the inbox of a mailbox, which
+// its folders share
+func (u *ImapUser) MailboxQuotaRoots(mailbox string) ([]*quotaRoot, error) {
+	inbox, _, err := u.resolveName(u.ctx, mailbox)
+	if err != nil {
+		return nil, err
+	}
+
+	quotas, err := u.core.QuotaService.Get(u.ctx, inbox.ID)
+	if err != nil {
+		return nil, err
+	}
+	return newQuotaRoots(inbox, quotas), nil
+}
+
+// QuotaRoot looks a quota root up among the inboxes of the user and their
+// projects
+func (u *ImapUser) QuotaRoot(name string) (*quotaRoot, error) {
+	inboxes, err := u.core.InboxService.ListByUser(u.ctx, u.userModel.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inbox := range inboxes {
+		if name != inboxPath(inbox) && name != projectRootName(inbox) {
+			continue
+		}
+		quotas, err := u.core.QuotaService.Get(u.ctx, inbox.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, root := range newQuotaRoots(inbox, quotas) {
+			if root.name == name {
+				return root, nil
+			}
+		}
+		break
+	}
+	return nil, errNoSuchQuotaRoot
+}
+
+// quotaExtension implements RFC 9208 QUOTA with the STORAGE and MESSAGE
+// resources. Quotas are managed through the REST API, so SETQUOTA is refused.
+type quotaExtension struct{}
+
+func (quotaExtension) Capabilities(c server.Conn) []string {
+	return []string{"QUOTA", "QUOTA=RES-STORAGE", "QUOTA=RES-MESSAGE"}
+}
+
+func (quotaExtension) Command(name string) server.HandlerFactory {
+	switch name {
+	case "GETQUOTA":
+		return func() server.Handler { return &getQuotaCommand{} }
+	case "GETQUOTAROOT":
+		return func() server.Handler { return &getQuotaRootCommand{} }
+	case "SETQUOTA":
+		return func() server.Handler { return &setQuotaCommand{} }
+	}
+	return nil
+}
+
+// authenticatedQuotaUser returns the user of the connection, when logged in
+func authenticatedQuotaUser(conn server.Conn) (quotaUser, error) {
+	ctx := conn.Context()
+	if ctx.User == nil {
+		return nil, server.ErrNotAuthenticated
+	}
+	user, ok := ctx.User.(quotaUser)
+	if !ok {
+		return nil, errors.New("QUOTA is not supported by this user")
+	}
+	return user, nil
+}
+
+type getQuotaCommand struct {
+	root string
+}
+
+func (cmd *getQuotaCommand) Parse(fields []interface{}) error {
+	if len(fields) != 1 {
+		return errors.New("GETQUOTA takes a quota root")
+	}
+	root, err := imap.ParseString(fields[0])
+	if err != nil {
+		return err
+	}
+	cmd.root = root
+	return nil
+}
+
+func (cmd *getQuotaCommand) Handle(conn server.Conn) error {
+	user, err := authenticatedQuotaUser(conn)
+	if err != nil {
+		return err
+	}
+
+	root, err := user.QuotaRoot(cmd.root)
+	if err != nil {
+		return err
+	}
+	return conn.WriteResp(root.response())
+}
+
+type getQuotaRootCommand struct {
+	mailbox string
+}
+
+func (cmd *getQuotaRootCommand) Parse(fields []interface{}) error {
+	if len(fields) != 1 {
+		return errors.New("GETQUOTAROOT takes a mailbox name")
+	}
+	mailbox, err := imap.ParseString(fields[0])
+	if err != nil {
+		return err
+	}
+	if mailbox, err = utf7.Encoding.NewDecoder().String(mailbox); err != nil {
+		return err
+	}
+	cmd.mailbox = imap.CanonicalMailboxName(mailbox)
+	return nil
+}
+
+func (cmd *getQuotaRootCommand) Handle(conn server.Conn) error {
+	user, err := authenticatedQuotaUser(conn)
+	if err != nil {
+		return err
+	}
+
+	roots, err := user.MailboxQuotaRoots(cmd.mailbox)
+	if err == backend.ErrNoSuchMailbox {
+		return server.ErrStatusResp(&imap.StatusResp{
+			Type: imap.StatusRespNo,
+			Code: "NONEXISTENT",
+			Info: err.Error(),
+		})
+	} else if err != nil {
+		return err
+	}
+
+	mailbox, err := utf7.Encoding.NewEncoder().String(cmd.mailbox)
+	if err != nil {
+		return err
+	}
+	fields := []interface{}{imap.RawString("QUOTAROOT"), imap.FormatMailboxName(mailbox)}
+	for _, root := range roots {
+		fields = append(fields, root.name)
+	}
+	if err := conn.WriteResp(imap.NewUntaggedResp(fields)); err != nil {
+		return err
+	}
+	for _, root := range roots {
+		if err := conn.WriteResp(root.response()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type setQuotaCommand struct{}
+
+func (cmd *setQuotaCommand) Parse(fields []interface{}) error {
+	return nil
+}
+
+func (cmd *setQuotaCommand) Handle(conn server.Conn) error {
+	if conn.Context().User == nil {
+		return server.ErrNotAuthenticated
+	}
+	return server.ErrStatusResp(&imap.StatusResp{
+		Type: imap.StatusRespNo,
+		Code: "NOPERM",
+		Info: "Quotas are managed through the REST API",
+	})
+}
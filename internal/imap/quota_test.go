@@ -0,0 +1,59 @@
+package imap
+
+import (
+	"testing"
+
+	"inbox451/internal/core"
+	"inbox451/internal/models"
+
+	"github.com/emersion/go-imap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewQuotaRoots(t *testing.T) {
+	inbox := &models.Inbox{Email: "alerts@example.com", ProjectName: "Ops"}
+
+	roots := newQuotaRoots(inbox, []*models.Quota{
+		{Kind: "inbox", Usage: models.Usage{MaxMessages: 100, MessageCount: 42, StorageBytes: 5000}},
+		{Kind: "project", Usage: models.Usage{MaxBytes: 10 << 20, MessageCount: 420, StorageBytes: 1025}},
+	})
+	require.Len(t, roots, 2)
+	assert.Equal(t, "Projects/Ops/alerts@example.com", roots[0].name)
+	assert.Equal(t, []interface{}{imap.RawString("MESSAGE"), imap.RawString("42"), imap.RawString("100")}, roots[0].resources())
+	assert.Equal(t, "Projects/Ops", roots[1].name)
+	assert.Equal(t, []interface{}{imap.RawString("STORAGE"), imap.RawString("2"), imap.RawString("10240")}, roots[1].resources())
+
+	// Unlimited quotas aren't roots
+	roots = newQuotaRoots(inbox, []*models.Quota{
+		{Kind: "inbox", Usage: models.Usage{MessageCount: 42}},
+		{Kind: "project", Usage: models.Usage{MaxMessages: 1000, MessageCount: 420}},
+	})
+	require.Len(t, roots, 1)
+	assert.Equal(t, "Projects/Ops", roots[0].name)
+}
+
+func TestQuotaError(t *testing.T) {
+	assert.Equal(t, errOverQuota, quotaError(core.ErrQuotaExceeded))
+	assert.Equal(t, core.ErrNotFound, quotaError(core.ErrNotFound))
+	assert.NoError(t, quotaError(nil))
+}
+
+func TestGetQuotaRootCommand_Parse(t *testing.T) {
+	cmd := &getQuotaRootCommand{}
+	require.NoError(t, cmd.Parse([]interface{}{"inbox"}))
+	assert.Equal(t, "INBOX", cmd.mailbox)
+
+	require.NoError(t, cmd.Parse([]interface{}{"Projects/Caf&AOk-/alerts@example.com"}))
+	assert.Equal(t, "Projects/Café/alerts@example.com", cmd.mailbox)
+
+	assert.Error(t, cmd.Parse(nil))
+}
+
+func TestGetQuotaCommand_Parse(t *testing.T) {
+	cmd := &getQuotaCommand{}
+	require.NoError(t, cmd.Parse([]interface{}{"Projects/Ops"}))
+	assert.Equal(t, "Projects/Ops", cmd.root)
+
+	assert.Error(t, cmd.Parse([]interface{}{"Projects/Ops", "extra"}))
+}
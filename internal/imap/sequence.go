@@ -0,0 +1,144 @@
+package imap
+
+import (
+	"sort"
+
+	"github.com/emersion/go-imap"
+)
+
+// sequenceMap holds the message sequence numbers of a session (RFC 3501 section
+// 2.3.1.2). uids lists the UIDs of the messages the client knows about in
+// ascending order, message sequence number n being uids[n-1]. The numbers only
+// change when the client is told so, through EXISTS and EXPUNGE responses.
+type sequenceMap struct {
+	uids []uint32
+}
+
+// sequenceUpdate describes how the messages of the selected mailbox changed
+// since the client last heard about it
+type sequenceUpdate struct {
+	// Expunged holds the sequence numbers of the removed messages in descending order
+	Expunged []uint32
+	// Vanished holds the UIDs of the removed messages in ascending order
+	Vanished []uint32
+	// Exists is the new number of messages when messages were added, otherwise 0
+	Exists uint32
+}
+
+// seqNum returns the sequence number of the message with the given UID, or 0
+// when the client does not know about it
+func (s *sequenceMap) seqNum(uid uint32) uint32 {
+	i := sort.Search(len(s.uids), func(i int) bool { return s.uids[i] >= uid })
+	if i < len(s.uids) && s.uids[i] == uid {
+		return uint32(i + 1)
+	}
+	return 0
+}
+
+// resolve returns the UIDs of the messages addressed by seqSet, in ascending
+// order. seqSet holds UIDs in UID mode and sequence numbers otherwise; "*"
+// stands for the highest of either, as required for ranges like "559:*" where
+// 559 is above every UID in the mailbox.
+func (s *sequenceMap) resolve(seqSet *imap.SeqSet, uid bool) []uint32 {
+	if seqSet == nil || len(s.uids) == 0 {
+		return nil
+	}
+
+	highest := uint32(len(s.uids))
+	if uid {
+		highest = s.uids[len(s.uids)-1]
+	}
+
+	set := make([]imap.Seq, 0, len(seqSet.Set))
+	for _, seq := range seqSet.Set {
+		start, stop := seq.Start, seq.Stop
+		if start == 0 {
+			start = highest
+		}
+		if stop == 0 {
+			stop = highest
+		}
+		if start > stop {
+			start, stop = stop, start
+		}
+		set = append(set, imap.Seq{Start: start, Stop: stop})
+	}
+
+	var uids []uint32
+	for i, messageUID := range s.uids {
+		id := uint32(i + 1)
+		if uid {
+			id = messageUID
+		}
+		for _, seq := range set {
+			if seq.Contains(id) {
+				uids = append(uids, messageUID)
+				break
+			}
+		}
+	}
+	return uids
+}
+
+// remove drops the messages with the given UIDs from the map and returns their
+// sequence numbers in descending order, the order in which EXPUNGE responses
+// have to be sent so that each number is still valid when it is announced
+func (s *sequenceMap) remove(uids []uint32) []uint32 {
+	removed := make(map[uint32]bool, len(uids))
+	for _, uid := range uids {
+		removed[uid] = true
+	}
+
+	var seqNums []uint32
+	kept := s.uids[:0]
+	for i, uid := range s.uids {
+		if removed[uid] {
+			seqNums = append(seqNums, uint32(i+1))
+			continue
+		}
+		kept = append(kept, uid)
+	}
+	s.uids = kept
+
+	sort.Slice(seqNums, func(i, j int) bool { return seqNums[i] > seqNums[j] })
+	return seqNums
+}
+
+// sync brings the map in line with the UIDs currently in the mailbox, in
+// ascending order. It returns the sequence numbers of the messages that are
+// gone, in descending order, their UIDs, and whether new messages were added.
+func (s *sequenceMap) sync(current []uint32) (expunged []uint32, vanished []uint32, grew bool) {
+	present := make(map[uint32]bool, len(current))
+	for _, uid := range current {
+		present[uid] = true
+	}
+
+	for _, uid := range s.uids {
+		if !present[uid] {
+			vanished = append(vanished, uid)
+		}
+	}
+	expunged = s.remove(vanished)
+
+	// UIDs only ever grow, so new messages are always added at the end
+	var last uint32
+	if len(s.uids) > 0 {
+		last = s.uids[len(s.uids)-1]
+	}
+	for _, uid := range current {
+		if uid > last {
+			s.uids = append(s.uids, uid)
+			grew = true
+		}
+	}
+	return expunged, vanished, grew
+}
+
+// uidSet returns the given UIDs as a set
+func uidSet(uids []uint32) map[uint32]bool {
+	set := make(map[uint32]bool, len(uids))
+	for _, uid := range uids {
+		set[uid] = true
+	}
+	return set
+}
@@ -0,0 +1,79 @@
+package imap
+
+import (
+	"testing"
+
+	"github.com/emersion/go-imap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceMap_SeqNum(t *testing.T) {
+	seq := &sequenceMap{uids: []uint32{3, 7, 12}}
+
+	assert.Equal(t, uint32(1), seq.seqNum(3))
+	assert.Equal(t, uint32(3), seq.seqNum(12))
+	assert.Equal(t, uint32(0), seq.seqNum(5))
+	assert.Equal(t, uint32(0), seq.seqNum(13))
+}
+
+func TestSequenceMap_Resolve(t *testing.T) {
+	seq := &sequenceMap{uids: []uint32{3, 7, 12, 20}}
+
+	tests := []struct {
+		name   string
+		seqSet string
+		uid    bool
+		want   []uint32
+	}{
+		{name: "single sequence number", seqSet: "2", want: []uint32{7}},
+		{name: "sequence range", seqSet: "2:3", want: []uint32{7, 12}},
+		{name: "reversed range", seqSet: "3:2", want: []uint32{7, 12}},
+		{name: "star is the last message", seqSet: "*", want: []uint32{20}},
+		{name: "range to star", seqSet: "3:*", want: []uint32{12, 20}},
+		{name: "out of range", seqSet: "5:9", want: nil},
+		{name: "set", seqSet: "1,4", want: []uint32{3, 20}},
+		{name: "uid", seqSet: "7", uid: true, want: []uint32{7}},
+		{name: "unknown uid", seqSet: "8", uid: true, want: nil},
+		{name: "uid range", seqSet: "5:15", uid: true, want: []uint32{7, 12}},
+		{name: "uid star is the highest uid", seqSet: "*", uid: true, want: []uint32{20}},
+		{name: "uid range above highest uid", seqSet: "559:*", uid: true, want: []uint32{20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seqSet, err := imap.ParseSeqSet(tt.seqSet)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, seq.resolve(seqSet, tt.uid))
+		})
+	}
+
+	empty := &sequenceMap{}
+	seqSet, _ := imap.ParseSeqSet("1:*")
+	assert.Empty(t, empty.resolve(seqSet, false))
+}
+
+func TestSequenceMap_Remove(t *testing.T) {
+	seq := &sequenceMap{uids: []uint32{3, 7, 12, 20}}
+
+	seqNums := seq.remove([]uint32{3, 12, 99})
+
+	assert.Equal(t, []uint32{3, 1}, seqNums)
+	assert.Equal(t, []uint32{7, 20}, seq.uids)
+}
+
+func TestSequenceMap_Sync(t *testing.T) {
+	seq := &sequenceMap{uids: []uint32{3, 7, 12}}
+
+	expunged, vanished, grew := seq.sync([]uint32{3, 12, 15, 16})
+
+	assert.Equal(t, []uint32{2}, expunged)
+	assert.Equal(t, []uint32{7}, vanished)
+	assert.True(t, grew)
+	assert.Equal(t, []uint32{3, 12, 15, 16}, seq.uids)
+
+	expunged, vanished, grew = seq.sync([]uint32{3, 12, 15, 16})
+	assert.Empty(t, expunged)
+	assert.Empty(t, vanished)
+	assert.False(t, grew)
+}
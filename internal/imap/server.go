@@ -2,23 +2,42 @@ package imap
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 
+	"inbox451/internal/config"
 	"inbox451/internal/core"
-	"inbox451/internal/util"
+	"inbox451/internal/mailauth"
 
+	"github.com/emersion/go-imap"
+	imapbackend "github.com/emersion/go-imap/backend"
 	"github.com/emersion/go-imap/server"
+	"github.com/emersion/go-sasl"
 )
 
 // Server represents the IMAP server
 type ImapServer struct {
 	core *core.Core
 	imap *server.Server
+	// tlsAddr is the implicit TLS (IMAPS) address, empty when disabled
+	tlsAddr string
 }
 
-// ListenAndServe starts the IMAP server
+// ListenAndServe starts the IMAP server, and the IMAPS listener when configured
 func (s *ImapServer) ListenAndServe() error {
-	return s.imap.ListenAndServe()
+	errs := make(chan error, 2)
+
+	if s.tlsAddr != "" {
+		listener, err := tls.Listen("tcp", s.tlsAddr, s.imap.TLSConfig)
+		if err != nil {
+			return fmt.Errorf("IMAP: Failed to listen for implicit TLS: %w", err)
+		}
+		s.core.Logger.Info("IMAP Server listening for implicit TLS on: %s", s.tlsAddr)
+		go func() { errs <- s.imap.Serve(listener) }()
+	}
+
+	go func() { errs <- s.imap.ListenAndServe() }()
+	return <-errs
 }
 
 // Shutdown gracefully shuts down the IMAP server
@@ -53,20 +72,69 @@ func NewServer(core *core.Core) (*ImapServer, error) {
 	// Enable debug logging
 	s.Debug = core.Logger.Writer()
 
-	// Configure TLS if enabled
-	if core.Config.Server.IMAP.EnableTLS {
-		config, err := util.GetTLSConfig(core, core.Config.Server.TLS.Cert, core.Config.Server.TLS.Key)
+	// Configure TLS if enabled, the certificate serves both STARTTLS and IMAPS
+	if core.Config.Server.IMAP.EnableTLS || core.Config.Server.IMAP.TLSPort != "" {
+		config, err := core.TLSConfig()
 		if err != nil {
 			return nil, fmt.Errorf("IMAP: Failed to load TLS configuration: %w", err)
 		}
 		s.TLSConfig = config
 	}
 
-	// Allow unencrypted plain text authentication based on config
-	s.AllowInsecureAuth = core.Config.Server.IMAP.AllowInsecureAuth
+	// Allow unencrypted plain text authentication based on config, unless TLS
+	// is required before authentication
+	s.AllowInsecureAuth = core.Config.Server.IMAP.AllowInsecureAuth && !core.Config.Server.IMAP.RequireTLSAuth
 
-	return &ImapServer{
+	// PLAIN is built in; add LOGIN for Outlook and OIDC access tokens for SSO users
+	s.EnableAuth(sasl.Login, func(conn server.Conn) sasl.Server {
+		return mailauth.NewLoginServer(func(username, password string) error {
+			user, err := backend.Login(conn.Info(), username, password)
+			if err != nil {
+				return err
+			}
+			authenticated(conn, user)
+			return nil
+		})
+	})
+	if core.BearerVerifier != nil {
+		s.EnableAuth(sasl.OAuthBearer, func(conn server.Conn) sasl.Server {
+			return sasl.NewOAuthBearerServer(func(opts sasl.OAuthBearerOptions) *sasl.OAuthBearerError {
+				user, err := backend.LoginWithBearer(conn.Info(), opts.Username, opts.Token)
+				if err != nil {
+					return &sasl.OAuthBearerError{Status: "invalid_token", Schemes: "bearer"}
+				}
+				authenticated(conn, user)
+				return nil
+			})
+		})
+		s.EnableAuth(mailauth.XOAuth2, func(conn server.Conn) sasl.Server {
+			return mailauth.NewXOAuth2Server(func(username, token string) error {
+				user, err := backend.LoginWithBearer(conn.Info(), username, token)
+				if err != nil {
+					return &mailauth.XOAuth2Error{Status: "401", Schemes: "bearer"}
+				}
+				authenticated(conn, user)
+				return nil
+			})
+		})
+	}
+
+	session := &sessionExtension{}
+	s.Enable(namespaceExtension{}, specialUseExtension{}, uidplusExtension{}, condstoreExtension{}, sortThreadExtension{}, quotaExtension{}, session)
+	session.handleMove = true
+
+	imapServer := &ImapServer{
 		core: core,
 		imap: s,
-	}, nil
+	}
+	imapServer.tlsAddr = config.TLSListenAddr("", core.Config.Server.IMAP.TLSPort)
+	return imapServer, nil
+}
+
+// authenticated moves a connection to the authenticated state, as go-imap does
+// itself after a successful LOGIN or AUTHENTICATE PLAIN
+func authenticated(conn server.Conn, user imapbackend.User) {
+	ctx := conn.Context()
+	ctx.State = imap.AuthenticatedState
+	ctx.User = user
 }
@@ -0,0 +1,194 @@
+package imap
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"inbox451/internal/certs"
+	"inbox451/internal/config"
+	"inbox451/internal/core"
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+	"inbox451/internal/test"
+
+	"github.com/emersion/go-imap/client"
+	"github.com/emersion/go-sasl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type stubBearerVerifier map[string]string
+
+func (v stubBearerVerifier) VerifyBearer(ctx context.Context, token string) (string, error) {
+	email, ok := v[token]
+	if !ok {
+		return "", errors.New("token is not valid")
+	}
+	return email, nil
+}
+
+func newTestCore(t *testing.T, verifier core.BearerVerifier) (*core.Core, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	c := &core.Core{
+		Config:         &config.Config{},
+		Logger:         logger.New(io.Discard, logger.DEBUG),
+		Repository:     mockRepo,
+		BearerVerifier: verifier,
+	}
+	c.Config.Server.IMAP.AllowInsecureAuth = true
+	c.UserService = core.NewUserService(c)
+	c.TokenService = core.NewTokensService(c)
+	return c, mockRepo
+}
+
+func startTestServer(t *testing.T, verifier core.BearerVerifier) (string, *mocks.Repository) {
+	c, mockRepo := newTestCore(t, verifier)
+
+	s, err := NewServer(c)
+	require.NoError(t, err)
+	s.imap.ErrorLog = log.New(io.Discard, "", 0)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = s.imap.Serve(listener) }()
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+
+	return listener.Addr().String(), mockRepo
+}
+
+func dialTestServer(t *testing.T, addr string) *client.Client {
+	cl, err := client.Dial(addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cl.Logout() })
+	return cl
+}
+
+func TestServer_Authenticate(t *testing.T) {
+	user := &models.User{
+		Base:     models.Base{ID: "test-user-id-1"},
+		Username: "testuser",
+		Email:    "test@example.com",
+		Status:   "active",
+	}
+
+	t.Run("LOGIN", func(t *testing.T) {
+		addr, mockRepo := startTestServer(t, nil)
+		cl := dialTestServer(t, addr)
+		mockRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(user, nil)
+		mockRepo.On("GetTokenByValue", mock.Anything, "api-token").
+			Return(&models.Token{UserID: user.ID, Token: "api-token"}, nil)
+
+		ok, err := cl.SupportAuth(sasl.OAuthBearer)
+		require.NoError(t, err)
+		assert.False(t, ok, "bearer tokens need OIDC")
+
+		require.NoError(t, cl.Authenticate(sasl.NewLoginClient("testuser", "api-token")))
+	})
+
+	t.Run("OAUTHBEARER", func(t *testing.T) {
+		addr, mockRepo := startTestServer(t, stubBearerVerifier{"access-token": "test@example.com"})
+		cl := dialTestServer(t, addr)
+		mockRepo.On("GetUserByEmail", mock.Anything, "test@example.com").Return(user, nil)
+
+		ok, err := cl.SupportAuth(sasl.OAuthBearer)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		require.NoError(t, cl.Authenticate(sasl.NewOAuthBearerClient(&sasl.OAuthBearerOptions{
+			Username: "testuser",
+			Token:    "access-token",
+		})))
+	})
+
+	t.Run("OAUTHBEARER with an invalid token", func(t *testing.T) {
+		addr, _ := startTestServer(t, stubBearerVerifier{})
+
+		// The go-imap client doesn't finish the exchange after an error
+		// challenge, so speak the protocol directly
+		conn, err := textproto.Dial("tcp", addr)
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = conn.ReadLine()
+		require.NoError(t, err)
+
+		_, ir, err := sasl.NewOAuthBearerClient(&sasl.OAuthBearerOptions{
+			Username: "testuser",
+			Token:    "expired-token",
+		}).Start()
+		require.NoError(t, err)
+		require.NoError(t, conn.PrintfLine("a1 AUTHENTICATE OAUTHBEARER %s", base64.StdEncoding.EncodeToString(ir)))
+
+		line, err := conn.ReadLine()
+		require.NoError(t, err)
+		challenge, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "+ "))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"status":"invalid_token","schemes":"bearer","scope":""}`, string(challenge))
+
+		require.NoError(t, conn.PrintfLine("%s", base64.StdEncoding.EncodeToString([]byte{0x01})))
+		line, err = conn.ReadLine()
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(line, "a1 NO"), line)
+	})
+}
+
+// freePort returns a local port that was free a moment ago
+func freePort(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return port
+}
+
+func TestServer_ImplicitTLS(t *testing.T) {
+	c, _ := newTestCore(t, nil)
+	certFile, keyFile := test.WriteCertificate(t, t.TempDir(), time.Now().Add(time.Hour), "127.0.0.1")
+	certificates, err := certs.NewManager(c.Logger, []config.TLSCertificateConfig{{Cert: certFile, Key: keyFile}}, nil)
+	require.NoError(t, err)
+	c.Certificates = certificates
+	c.Config.Server.IMAP.Port = freePort(t)
+	c.Config.Server.IMAP.TLSPort = freePort(t)
+	c.Config.Server.IMAP.RequireTLSAuth = true
+
+	s, err := NewServer(c)
+	require.NoError(t, err)
+	s.imap.ErrorLog = log.New(io.Discard, "", 0)
+	go func() { _ = s.ListenAndServe() }()
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+
+	var cl *client.Client
+	require.Eventually(t, func() bool {
+		cl, err = client.DialTLS("127.0.0.1:"+c.Config.Server.IMAP.TLSPort, &tls.Config{InsecureSkipVerify: true})
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	defer cl.Logout()
+	ok, err := cl.SupportAuth(sasl.Plain)
+	require.NoError(t, err)
+	assert.True(t, ok, "authentication is allowed over implicit TLS")
+	ok, err = cl.SupportStartTLS()
+	require.NoError(t, err)
+	assert.False(t, ok, "the connection is already encrypted")
+
+	// Without TLS, authentication is refused despite allow_insecure_auth
+	plain, err := client.Dial("127.0.0.1:" + c.Config.Server.IMAP.Port)
+	require.NoError(t, err)
+	defer plain.Logout()
+	ok, err = plain.Support("LOGINDISABLED")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = plain.SupportStartTLS()
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,569 @@
+package imap
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"inbox451/internal/core"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/commands"
+	"github.com/emersion/go-imap/server"
+	gomessage "github.com/emersion/go-message"
+	"github.com/emersion/go-message/mail"
+)
+
+const (
+	threadReferences     = "REFERENCES"
+	threadOrderedSubject = "ORDEREDSUBJECT"
+)
+
+// sortCriterion is an RFC 5256 sort key with its REVERSE modifier
+type sortCriterion struct {
+	key     string
+	reverse bool
+}
+
+var sortKeys = []string{"ARRIVAL", "CC", "DATE", "FROM", "SIZE", "SUBJECT", "TO"}
+
+// sortMessage holds the values of a message that SORT and THREAD compare
+type sortMessage struct {
+	// id is the sequence number or the UID sent to the client
+	id      uint32
+	seqNum  uint32
+	arrival time.Time
+	// date is the sent date, the arrival date when the message has none
+	date    time.Time
+	from    string
+	to      string
+	cc      string
+	subject string
+	isReply bool
+	size    int
+
+	messageID  string
+	references []string
+}
+
+// newSortMessage reads the values SORT and THREAD need from a matching message.
+// Its raw form must have been loaded.
+func newSortMessage(match searchMatch, uid bool) *sortMessage {
+	content := core.RawMessage(match.message)
+	sm := &sortMessage{
+		id:         match.id(uid),
+		seqNum:     match.seqNum,
+		arrival:    match.message.CreatedAt.Time,
+		date:       match.message.CreatedAt.Time,
+		size:       len(content),
+		messageID:  match.message.HeaderMessageID,
+		references: match.message.References,
+	}
+
+	subject := match.message.Subject
+	if fields, _, err := readHeader(content); err == nil {
+		header := mail.Header{Header: gomessage.Header{Header: fields}}
+		if date, err := header.Date(); err == nil && !date.IsZero() {
+			sm.date = date
+		}
+		if decoded, err := header.Subject(); err == nil {
+			subject = decoded
+		}
+		sm.from = firstMailbox(header, "From")
+		sm.to = firstMailbox(header, "To")
+		sm.cc = firstMailbox(header, "Cc")
+	}
+	sm.subject, sm.isReply = core.BaseSubject(subject)
+	return sm
+}
+
+// firstMailbox returns the lower case address of the first mailbox of an
+// address header, the value SORT compares
+func firstMailbox(header mail.Header, field string) string {
+	addresses, err := header.AddressList(field)
+	if err != nil || len(addresses) == 0 {
+		return ""
+	}
+	return strings.ToLower(addresses[0].Address)
+}
+
+// compareSortKey compares two messages on a single sort key
+func compareSortKey(key string, a, b *sortMessage) int {
+	switch key {
+	case "ARRIVAL":
+		return a.arrival.Compare(b.arrival)
+	case "CC":
+		return strings.Compare(a.cc, b.cc)
+	case "DATE":
+		return a.date.Compare(b.date)
+	case "FROM":
+		return strings.Compare(a.from, b.from)
+	case "SIZE":
+		return a.size - b.size
+	case "SUBJECT":
+		return strings.Compare(a.subject, b.subject)
+	case "TO":
+		return strings.Compare(a.to, b.to)
+	}
+	return 0
+}
+
+// sortMessages sorts messages by the given criteria. Messages that compare equal
+// keep their sequence number order (RFC 5256 section 3).
+func sortMessages(messages []*sortMessage, criteria []sortCriterion) []uint32 {
+	sorted := append([]*sortMessage(nil), messages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, c := range criteria {
+			cmp := compareSortKey(c.key, sorted[i], sorted[j])
+			if c.reverse {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return sorted[i].seqNum < sorted[j].seqNum
+	})
+
+	ids := make([]uint32, 0, len(sorted))
+	for _, sm := range sorted {
+		ids = append(ids, sm.id)
+	}
+	return ids
+}
+
+// threadNode is a message of a thread. A node without a message groups the
+// replies to a message that is not part of the results.
+type threadNode struct {
+	message  *sortMessage
+	parent   *threadNode
+	children []*threadNode
+}
+
+// date is the date a node is sorted by: that of its message, or that of its
+// first child for a node without a message
+func (n *threadNode) date() (time.Time, uint32) {
+	if n.message != nil {
+		return n.message.date, n.message.seqNum
+	}
+	if len(n.children) > 0 {
+		return n.children[0].date()
+	}
+	return time.Time{}, 0
+}
+
+// subject is the base subject of a node, taken from its first child for a node
+// without a message
+func (n *threadNode) subject() (string, bool) {
+	if n.message != nil {
+		return n.message.subject, n.message.isReply
+	}
+	if len(n.children) > 0 {
+		return n.children[0].subject()
+	}
+	return "", false
+}
+
+func (n *threadNode) addChild(child *threadNode) {
+	child.parent = n
+	n.children = append(n.children, child)
+}
+
+func (n *threadNode) removeChild(child *threadNode) {
+	for i, c := range n.children {
+		if c == child {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			break
+		}
+	}
+	child.parent = nil
+}
+
+// isAncestorOf reports whether n is other or one of its ancestors
+func (n *threadNode) isAncestorOf(other *threadNode) bool {
+	for ; other != nil; other = other.parent {
+		if other == n {
+			return true
+		}
+	}
+	return false
+}
+
+// sortByDate sorts nodes by date, then by sequence number
+func sortByDate(nodes []*threadNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		di, si := nodes[i].date()
+		dj, sj := nodes[j].date()
+		if !di.Equal(dj) {
+			return di.Before(dj)
+		}
+		return si < sj
+	})
+}
+
+// sortTree sorts the children of all nodes by date, deepest level first
+func sortTree(nodes []*threadNode) {
+	for _, n := range nodes {
+		sortTree(n.children)
+	}
+	sortByDate(nodes)
+}
+
+// threadByReferences implements the RFC 5256 REFERENCES threading algorithm and
+// returns the threads sorted by date
+func threadByReferences(messages []*sortMessage) []*threadNode {
+	// Link the messages through their references
+	byID := make(map[string]*threadNode)
+	container := func(id string) *threadNode {
+		n, ok := byID[id]
+		if !ok {
+			n = &threadNode{}
+			byID[id] = n
+		}
+		return n
+	}
+	for i, sm := range messages {
+		id := sm.messageID
+		if id == "" || (byID[id] != nil && byID[id].message != nil) {
+			// Messages without a unique Message-ID are threaded on their own
+			id = "\x00" + strconv.Itoa(i)
+		}
+		node := container(id)
+		node.message = sm
+
+		var parent *threadNode
+		for _, ref := range sm.references {
+			ref := container(ref)
+			if parent != nil && ref.parent == nil && !ref.isAncestorOf(parent) {
+				parent.addChild(ref)
+			}
+			parent = ref
+		}
+		if node.parent != nil && node.parent != parent {
+			node.parent.removeChild(node)
+		}
+		if parent != nil && node.parent == nil && !node.isAncestorOf(parent) {
+			parent.addChild(node)
+		}
+	}
+
+	var roots []*threadNode
+	seen := make(map[*threadNode]bool)
+	for _, n := range byID {
+		for n.parent != nil {
+			n = n.parent
+		}
+		if !seen[n] {
+			seen[n] = true
+			roots = append(roots, n)
+		}
+	}
+	roots = pruneEmpty(roots, true)
+	sortTree(roots)
+
+	// Gather the threads with the same base subject
+	bySubject := make(map[string]*threadNode)
+	for _, root := range roots {
+		subject, _ := root.subject()
+		if subject == "" {
+			continue
+		}
+		existing, ok := bySubject[subject]
+		if !ok ||
+			(existing.message != nil && root.message == nil) ||
+			(existing.message != nil && root.message != nil && existing.message.isReply && !root.message.isReply) {
+			bySubject[subject] = root
+		}
+	}
+	merged := make([]*threadNode, 0, len(roots))
+	for _, root := range roots {
+		if root.parent != nil {
+			// Already gathered under a thread with the same subject
+			continue
+		}
+		subject, isReply := root.subject()
+		target := bySubject[subject]
+		if subject == "" || target == nil || target == root {
+			merged = append(merged, root)
+			continue
+		}
+		_, targetIsReply := target.subject()
+		switch {
+		case target.message == nil && root.message == nil:
+			for _, child := range append([]*threadNode(nil), root.children...) {
+				root.removeChild(child)
+				target.addChild(child)
+			}
+		case target.message == nil:
+			target.addChild(root)
+		case root.message != nil && isReply && !targetIsReply:
+			target.addChild(root)
+		default:
+			// Neither is the original, both become replies to a common subject
+			dummy := &threadNode{}
+			if i := slices.Index(merged, target); i >= 0 {
+				merged[i] = dummy
+			} else {
+				merged = append(merged, dummy)
+			}
+			bySubject[subject] = dummy
+			dummy.addChild(target)
+			dummy.addChild(root)
+		}
+	}
+	sortTree(merged)
+	return merged
+}
+
+// pruneEmpty removes the nodes without a message. Their children take their
+// place, except at the top level where a node without a message remains to
+// group several children.
+func pruneEmpty(nodes []*threadNode, top bool) []*threadNode {
+	var result []*threadNode
+	for _, n := range nodes {
+		n.children = pruneEmpty(n.children, false)
+		for _, c := range n.children {
+			c.parent = n
+		}
+		if n.message != nil || (top && len(n.children) > 1) {
+			result = append(result, n)
+			continue
+		}
+		for _, c := range n.children {
+			c.parent = n.parent
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// threadByOrderedSubject implements the RFC 5256 ORDEREDSUBJECT threading
+// algorithm: messages with the same base subject are replies to the first of them
+func threadByOrderedSubject(messages []*sortMessage) []*threadNode {
+	sorted := append([]*sortMessage(nil), messages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].subject != sorted[j].subject {
+			return sorted[i].subject < sorted[j].subject
+		}
+		if !sorted[i].date.Equal(sorted[j].date) {
+			return sorted[i].date.Before(sorted[j].date)
+		}
+		return sorted[i].seqNum < sorted[j].seqNum
+	})
+
+	var roots []*threadNode
+	var current *threadNode
+	for _, sm := range sorted {
+		node := &threadNode{message: sm}
+		if current != nil && current.message.subject == sm.subject {
+			current.addChild(node)
+			continue
+		}
+		current = node
+		roots = append(roots, node)
+	}
+	sortByDate(roots)
+	return roots
+}
+
+// formatThreads formats threads as the data of a THREAD response, e.g.
+// "(2)(3 6 (4 23)(44 7 96))". Thread lists are not separated by spaces, so the
+// response cannot be written as regular lists.
+func formatThreads(threads []*threadNode) string {
+	var sb strings.Builder
+	for _, t := range threads {
+		sb.WriteByte('(')
+		formatThread(&sb, t)
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}
+
+func formatThread(sb *strings.Builder, n *threadNode) {
+	for {
+		if n.message != nil {
+			if sb.Len() > 0 && sb.String()[sb.Len()-1] != '(' {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(strconv.FormatUint(uint64(n.message.id), 10))
+		}
+		if len(n.children) == 1 && n.message != nil {
+			n = n.children[0]
+			continue
+		}
+		if len(n.children) > 0 && n.message != nil {
+			sb.WriteByte(' ')
+		}
+		for _, c := range n.children {
+			sb.WriteByte('(')
+			formatThread(sb, c)
+			sb.WriteByte(')')
+		}
+		return
+	}
+}
+
+// sortThreadMailbox is implemented by mailboxes that can sort and thread the
+// messages matching search criteria
+type sortThreadMailbox interface {
+	SortMessages(uid bool, criteria []sortCriterion, search *imap.SearchCriteria) ([]uint32, error)
+	ThreadMessages(uid bool, algorithm string, search *imap.SearchCriteria) ([]*threadNode, error)
+}
+
+// sortThreadExtension implements the RFC 5256 SORT and THREAD commands
+type sortThreadExtension struct{}
+
+func (sortThreadExtension) Capabilities(c server.Conn) []string {
+	return []string{"SORT", "THREAD=" + threadOrderedSubject, "THREAD=" + threadReferences}
+}
+
+func (sortThreadExtension) Command(name string) server.HandlerFactory {
+	switch name {
+	case "SORT":
+		return func() server.Handler { return &sortCommand{} }
+	case "THREAD":
+		return func() server.Handler { return &threadCommand{} }
+	}
+	return nil
+}
+
+// parseSearchWithCharset parses the mandatory charset and the search criteria
+// that end the SORT and THREAD commands
+func parseSearchWithCharset(fields []interface{}) (*imap.SearchCriteria, error) {
+	if len(fields) < 2 {
+		return nil, errors.New("Missing charset or search criteria")
+	}
+	charset, ok := fields[0].(string)
+	if !ok {
+		return nil, errors.New("Charset must be a string")
+	}
+	search := &commands.Search{}
+	if err := search.Parse(append([]interface{}{"CHARSET", charset}, fields[1:]...)); err != nil {
+		return nil, err
+	}
+	return search.Criteria, nil
+}
+
+type sortCommand struct {
+	criteria []sortCriterion
+	search   *imap.SearchCriteria
+}
+
+func (cmd *sortCommand) Parse(fields []interface{}) error {
+	if len(fields) == 0 {
+		return errors.New("Missing sort criteria")
+	}
+	keys, ok := fields[0].([]interface{})
+	if !ok || len(keys) == 0 {
+		return errors.New("Sort criteria must be a non-empty list")
+	}
+	reverse := false
+	for _, f := range keys {
+		key := strings.ToUpper(stringField(f))
+		if key == "REVERSE" {
+			reverse = true
+			continue
+		}
+		if !slices.Contains(sortKeys, key) {
+			return fmt.Errorf("Unknown sort key %v", f)
+		}
+		cmd.criteria = append(cmd.criteria, sortCriterion{key: key, reverse: reverse})
+		reverse = false
+	}
+	if reverse {
+		return errors.New("REVERSE must be followed by a sort key")
+	}
+
+	search, err := parseSearchWithCharset(fields[1:])
+	if err != nil {
+		return err
+	}
+	cmd.search = search
+	return nil
+}
+
+func (cmd *sortCommand) handle(uid bool, conn server.Conn) error {
+	ctx := conn.Context()
+	if ctx.Mailbox == nil {
+		return server.ErrNoMailboxSelected
+	}
+	mbox, ok := ctx.Mailbox.(sortThreadMailbox)
+	if !ok {
+		return errors.New("SORT is not supported by this mailbox")
+	}
+
+	ids, err := mbox.SortMessages(uid, cmd.criteria, cmd.search)
+	if err != nil {
+		return err
+	}
+
+	fields := []interface{}{imap.RawString("SORT")}
+	for _, id := range ids {
+		fields = append(fields, id)
+	}
+	return conn.WriteResp(imap.NewUntaggedResp(fields))
+}
+
+func (cmd *sortCommand) Handle(conn server.Conn) error {
+	return cmd.handle(false, conn)
+}
+
+func (cmd *sortCommand) UidHandle(conn server.Conn) error {
+	return cmd.handle(true, conn)
+}
+
+type threadCommand struct {
+	algorithm string
+	search    *imap.SearchCriteria
+}
+
+func (cmd *threadCommand) Parse(fields []interface{}) error {
+	if len(fields) == 0 {
+		return errors.New("Missing threading algorithm")
+	}
+	cmd.algorithm = strings.ToUpper(stringField(fields[0]))
+	if cmd.algorithm != threadReferences && cmd.algorithm != threadOrderedSubject {
+		return fmt.Errorf("Unsupported threading algorithm %v", fields[0])
+	}
+
+	search, err := parseSearchWithCharset(fields[1:])
+	if err != nil {
+		return err
+	}
+	cmd.search = search
+	return nil
+}
+
+func (cmd *threadCommand) handle(uid bool, conn server.Conn) error {
+	ctx := conn.Context()
+	if ctx.Mailbox == nil {
+		return server.ErrNoMailboxSelected
+	}
+	mbox, ok := ctx.Mailbox.(sortThreadMailbox)
+	if !ok {
+		return errors.New("THREAD is not supported by this mailbox")
+	}
+
+	threads, err := mbox.ThreadMessages(uid, cmd.algorithm, cmd.search)
+	if err != nil {
+		return err
+	}
+
+	fields := []interface{}{imap.RawString("THREAD")}
+	if len(threads) > 0 {
+		fields = append(fields, imap.RawString(formatThreads(threads)))
+	}
+	return conn.WriteResp(imap.NewUntaggedResp(fields))
+}
+
+func (cmd *threadCommand) Handle(conn server.Conn) error {
+	return cmd.handle(false, conn)
+}
+
+func (cmd *threadCommand) UidHandle(conn server.Conn) error {
+	return cmd.handle(true, conn)
+}
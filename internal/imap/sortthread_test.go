@@ -0,0 +1,151 @@
+package imap
+
+import (
+	"testing"
+	"time"
+
+	"inbox451/internal/core"
+	"inbox451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	null "github.com/volatiletech/null/v9"
+)
+
+func testSortMessage(seqNum uint32, day int, subject, messageID string, references ...string) *sortMessage {
+	date := time.Date(2024, 3, day, 12, 0, 0, 0, time.UTC)
+	base, isReply := core.BaseSubject(subject)
+	return &sortMessage{
+		id:         seqNum,
+		seqNum:     seqNum,
+		arrival:    date,
+		date:       date,
+		subject:    base,
+		isReply:    isReply,
+		messageID:  messageID,
+		references: references,
+	}
+}
+
+func TestNewSortMessage(t *testing.T) {
+	raw := "From: Alice <Alice@Example.com>\r\n" +
+		"To: bob@example.com\r\n" +
+		"Cc: Carol <carol@example.com>, dave@example.com\r\n" +
+		"Date: Fri, 01 Mar 2024 10:00:00 +0000\r\n" +
+		"Subject: Re: Order confirmation\r\n" +
+		"\r\n" +
+		"Thanks\r\n"
+	received := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	message := &models.Message{
+		Base:            models.Base{CreatedAt: null.TimeFrom(received)},
+		UID:             7,
+		Raw:             []byte(raw),
+		HeaderMessageID: "reply@example.com",
+		References:      []string{"original@example.com"},
+	}
+
+	sm := newSortMessage(searchMatch{message: message, seqNum: 3}, true)
+	assert.Equal(t, uint32(7), sm.id)
+	assert.Equal(t, uint32(3), sm.seqNum)
+	assert.Equal(t, received, sm.arrival)
+	assert.True(t, sm.date.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "alice@example.com", sm.from)
+	assert.Equal(t, "bob@example.com", sm.to)
+	assert.Equal(t, "carol@example.com", sm.cc)
+	assert.Equal(t, "order confirmation", sm.subject)
+	assert.True(t, sm.isReply)
+	assert.Equal(t, len(raw), sm.size)
+	assert.Equal(t, "reply@example.com", sm.messageID)
+}
+
+func TestSortMessages(t *testing.T) {
+	messages := []*sortMessage{
+		{id: 1, seqNum: 1, from: "carol@example.com", subject: "b", size: 300},
+		{id: 2, seqNum: 2, from: "alice@example.com", subject: "a", size: 100},
+		{id: 3, seqNum: 3, from: "bob@example.com", subject: "b", size: 200},
+		{id: 4, seqNum: 4, from: "alice@example.com", subject: "c", size: 100},
+	}
+
+	tests := []struct {
+		name     string
+		criteria []sortCriterion
+		want     []uint32
+	}{
+		{"from", []sortCriterion{{key: "FROM"}}, []uint32{2, 4, 3, 1}},
+		{"reverse size", []sortCriterion{{key: "SIZE", reverse: true}}, []uint32{1, 3, 2, 4}},
+		{"subject then reverse from", []sortCriterion{{key: "SUBJECT"}, {key: "FROM", reverse: true}}, []uint32{2, 1, 3, 4}},
+		{"equal keys keep sequence order", []sortCriterion{{key: "ARRIVAL"}}, []uint32{1, 2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sortMessages(messages, tt.criteria))
+		})
+	}
+}
+
+func TestThreadByReferences(t *testing.T) {
+	messages := []*sortMessage{
+		testSortMessage(1, 1, "Order confirmation", "a@x"),
+		testSortMessage(2, 2, "Re: Order confirmation", "b@x", "a@x"),
+		testSortMessage(3, 3, "Shipping", "c@x"),
+		testSortMessage(4, 4, "Re: Order confirmation", "d@x", "a@x", "b@x"),
+		testSortMessage(5, 5, "Re: Order confirmation", "e@x", "a@x"),
+		// Replies to a message that is not in the mailbox
+		testSortMessage(6, 6, "Re: Invoice", "f@x", "missing@x"),
+		testSortMessage(7, 7, "Re: Invoice", "g@x", "missing@x"),
+		// A reply without references is gathered by subject
+		testSortMessage(8, 8, "Re: Shipping", "h@x"),
+	}
+
+	threads := threadByReferences(messages)
+	assert.Equal(t, "(1 (2 4)(5))(3 8)((6)(7))", formatThreads(threads))
+}
+
+func TestThreadByReferences_Loops(t *testing.T) {
+	messages := []*sortMessage{
+		testSortMessage(1, 1, "One", "a@x", "b@x"),
+		testSortMessage(2, 2, "Two", "b@x", "a@x"),
+		testSortMessage(3, 3, "Three", "a@x"),
+	}
+
+	threads := threadByReferences(messages)
+	require.NotEmpty(t, threads)
+	// The second message would close a loop, it stays the parent of the first
+	assert.Equal(t, "(2 1)(3)", formatThreads(threads))
+}
+
+func TestThreadByOrderedSubject(t *testing.T) {
+	messages := []*sortMessage{
+		testSortMessage(1, 3, "Shipping", ""),
+		testSortMessage(2, 1, "Order confirmation", ""),
+		testSortMessage(3, 2, "Re: Order confirmation", ""),
+		testSortMessage(4, 4, "Re: Shipping", ""),
+		testSortMessage(5, 5, "Fwd: Order confirmation", ""),
+	}
+
+	threads := threadByOrderedSubject(messages)
+	assert.Equal(t, "(2 (3)(5))(1 4)", formatThreads(threads))
+}
+
+func TestSortCommand_Parse(t *testing.T) {
+	cmd := &sortCommand{}
+	err := cmd.Parse([]interface{}{[]interface{}{"REVERSE", "date", "SUBJECT"}, "UTF-8", "UNSEEN"})
+	require.NoError(t, err)
+	assert.Equal(t, []sortCriterion{{key: "DATE", reverse: true}, {key: "SUBJECT"}}, cmd.criteria)
+	require.NotNil(t, cmd.search)
+	assert.NotEmpty(t, cmd.search.WithoutFlags)
+
+	assert.Error(t, (&sortCommand{}).Parse([]interface{}{[]interface{}{"SPAM"}, "UTF-8", "ALL"}))
+	assert.Error(t, (&sortCommand{}).Parse([]interface{}{[]interface{}{"REVERSE"}, "UTF-8", "ALL"}))
+	assert.Error(t, (&sortCommand{}).Parse([]interface{}{[]interface{}{"DATE"}}))
+}
+
+func TestThreadCommand_Parse(t *testing.T) {
+	cmd := &threadCommand{}
+	require.NoError(t, cmd.Parse([]interface{}{"references", "UTF-8", "ALL"}))
+	assert.Equal(t, threadReferences, cmd.algorithm)
+
+	assert.Error(t, (&threadCommand{}).Parse([]interface{}{"REFS", "UTF-8", "ALL"}))
+	assert.Error(t, (&threadCommand{}).Parse([]interface{}{"ORDEREDSUBJECT"}))
+}
@@ -3,6 +3,8 @@ package imap
 import (
 	"context"
 	"errors"
+	"sort"
+	"strings"
 
 	"inbox451/internal/core"
 	"inbox451/internal/models"
@@ -15,6 +17,8 @@ type ImapUser struct {
 	userModel *models.User
 	core      *core.Core
 	ctx       context.Context
+	// enabled holds the extensions the client turned on with ENABLE (RFC 5161)
+	enabled map[string]bool
 }
 
 // NewImapUser creates a new IMAP user
@@ -34,7 +38,21 @@ func (u *ImapUser) Username() string {
 	return u.userModel.Email
 }
 
-// ListMailboxes returns a list of mailboxes for the user
+// Enable turns on an extension for the rest of the session
+func (u *ImapUser) Enable(capability string) {
+	if u.enabled == nil {
+		u.enabled = make(map[string]bool)
+	}
+	u.enabled[capability] = true
+}
+
+// Enabled reports whether the client turned on an extension
+func (u *ImapUser) Enabled(capability string) bool {
+	return u.enabled[capability]
+}
+
+// ListMailboxes returns INBOX, followed by the inboxes of the user grouped by
+// project together with their folders
 func (u *ImapUser) ListMailboxes(subscribed bool) ([]backend.Mailbox, error) {
 	ctx := u.ctx
 	inboxes, err := u.core.InboxService.ListByUser(ctx, u.userModel.ID)
@@ -42,10 +60,42 @@ func (u *ImapUser) ListMailboxes(subscribed bool) ([]backend.Mailbox, error) {
 		u.core.Logger.Error("Failed to list inboxes for user %s: %v", u.userModel.ID, err)
 		return nil, err
 	}
+	if len(inboxes) == 0 {
+		return []backend.Mailbox{}, nil
+	}
 
-	mailboxes := make([]backend.Mailbox, len(inboxes))
-	for i, inbox := range inboxes {
-		mailboxes[i] = NewImapMailbox(ctx, inbox, u)
+	defaultInbox, err := u.core.InboxService.DefaultForUser(ctx, u.userModel)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(inboxes, func(i, j int) bool {
+		return inboxes[i].ProjectName < inboxes[j].ProjectName
+	})
+
+	mailboxes := []backend.Mailbox{newInboxMailbox(ctx, defaultInbox, u)}
+	listed := make(map[string]bool)
+	for _, inbox := range inboxes {
+		for _, name := range parentNames(inbox) {
+			if !listed[name] {
+				listed[name] = true
+				mailboxes = append(mailboxes, &hierarchyMailbox{name: name})
+			}
+		}
+
+		mailboxes = append(mailboxes, NewImapMailbox(ctx, inbox, nil, u))
+
+		folders, err := u.core.FolderService.ListAllByInbox(ctx, inbox.ID)
+		if err != nil {
+			u.core.Logger.Error("Failed to list folders for inbox %s: %v", inbox.ID, err)
+			return nil, err
+		}
+		for _, folder := range folders {
+			if subscribed && !folder.Subscribed {
+				continue
+			}
+			mailboxes = append(mailboxes, NewImapMailbox(ctx, inbox, folder, u))
+		}
 	}
 
 	u.core.Logger.Info("Listed %d mailboxes for user %s", len(mailboxes), u.userModel.ID)
@@ -56,44 +106,133 @@ func (u *ImapUser) ListMailboxes(subscribed bool) ([]backend.Mailbox, error) {
 func (u *ImapUser) GetMailbox(name string) (backend.Mailbox, error) {
 	ctx := u.ctx
 
-	// Handle special case for "INBOX" - map to user's first inbox
-	if name == "INBOX" {
+	inbox, folder, err := u.resolveMailbox(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if isInboxName(name) {
+		return newInboxMailbox(ctx, inbox, u), nil
+	}
+	return NewImapMailbox(ctx, inbox, folder, u), nil
+}
+
+// resolveName maps a mailbox name to an inbox of the user and the path of the
+// folder inside it, empty for the inbox itself. Besides INBOX and names in the
+// projects namespace, the former <inbox email>[/<folder path>] names are still
+// accepted so that existing client setups keep working.
+func (u *ImapUser) resolveName(ctx context.Context, name string) (*models.Inbox, string, error) {
+	if isInboxName(name) {
+		inbox, err := u.core.InboxService.DefaultForUser(ctx, u.userModel)
+		if err != nil {
+			u.core.Logger.Warn("IMAP: No inbox available as INBOX for user %s: %v", u.userModel.ID, err)
+			return nil, "", backend.ErrNoSuchMailbox
+		}
+		return inbox, "", nil
+	}
+
+	if strings.HasPrefix(name, projectsNamespace+core.FolderDelimiter) {
 		inboxes, err := u.core.InboxService.ListByUser(ctx, u.userModel.ID)
 		if err != nil {
-			u.core.Logger.Error("IMAP GetMailbox: Error fetching inboxes for INBOX special name for user %s: %v", u.userModel.ID, err)
-			return nil, err
+			return nil, "", err
 		}
-		if len(inboxes) == 0 {
-			u.core.Logger.Warn("IMAP GetMailbox: No inboxes available for user %s, cannot select INBOX.", u.userModel.ID)
-			return nil, backend.ErrNoSuchMailbox
+		for _, inbox := range inboxes {
+			if path, ok := cutInboxPath(name, inbox); ok {
+				return inbox, path, nil
+			}
 		}
-		u.core.Logger.Debug("IMAP GetMailbox: Mapping 'INBOX' to user %s's first inbox: %s", u.userModel.ID, inboxes[0].Email)
-		return NewImapMailbox(ctx, inboxes[0], u), nil
+		return nil, "", backend.ErrNoSuchMailbox
+	}
+
+	email, path := splitMailboxName(name)
+	inbox, err := u.core.InboxService.GetByEmailAndUser(ctx, email, u.userModel.ID)
+	if err != nil {
+		u.core.Logger.Debug("Failed to get mailbox %s for user %s: %v", name, u.userModel.ID, err)
+		return nil, "", backend.ErrNoSuchMailbox
+	}
+	return inbox, path, nil
+}
+
+// resolveMailbox maps a mailbox name to an inbox of the user and, for folders, the folder
+func (u *ImapUser) resolveMailbox(ctx context.Context, name string) (*models.Inbox, *models.Folder, error) {
+	inbox, path, err := u.resolveName(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if path == "" {
+		return inbox, nil, nil
 	}
 
-	// Try to get inbox by email address
-	inbox, err := u.core.InboxService.GetByEmailAndUser(ctx, name, u.userModel.ID)
+	folder, err := u.core.FolderService.GetByName(ctx, inbox.ID, path)
 	if err != nil {
-		u.core.Logger.Error("Failed to get mailbox %s for user %s: %v", name, u.userModel.ID, err)
-		return nil, backend.ErrNoSuchMailbox
+		u.core.Logger.Debug("Folder %q not found in inbox %s: %v", path, inbox.ID, err)
+		return nil, nil, backend.ErrNoSuchMailbox
 	}
 
-	return NewImapMailbox(ctx, inbox, u), nil
+	return inbox, folder, nil
 }
 
-// CreateMailbox is not supported
+// CreateMailbox creates a folder, and any missing parents, inside an inbox
 func (u *ImapUser) CreateMailbox(name string) error {
-	return errors.New("mailbox creation not supported")
+	ctx := u.ctx
+
+	inbox, path, err := u.resolveName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return errors.New("inboxes cannot be created over IMAP")
+	}
+
+	if _, err := u.core.FolderService.GetByName(ctx, inbox.ID, path); err == nil {
+		return errors.New("mailbox already exists")
+	}
+
+	_, err = u.core.FolderService.CreateWithParents(ctx, inbox.ID, path)
+	return err
 }
 
-// DeleteMailbox is not supported
+// DeleteMailbox deletes a folder and its messages
 func (u *ImapUser) DeleteMailbox(name string) error {
-	return errors.New("mailbox deletion not supported")
+	_, folder, err := u.resolveMailbox(u.ctx, name)
+	if err != nil {
+		return err
+	}
+	if folder == nil {
+		return errors.New("inboxes cannot be deleted over IMAP")
+	}
+
+	return u.core.FolderService.Delete(u.ctx, folder.ID)
 }
 
-// RenameMailbox is not supported
+// RenameMailbox renames a folder, together with its subfolders, within the same inbox
 func (u *ImapUser) RenameMailbox(existingName, newName string) error {
-	return errors.New("mailbox renaming not supported")
+	ctx := u.ctx
+
+	inbox, folder, err := u.resolveMailbox(ctx, existingName)
+	if err != nil {
+		return err
+	}
+	if folder == nil {
+		return errors.New("inboxes cannot be renamed over IMAP")
+	}
+
+	newInbox, path, err := u.resolveName(ctx, newName)
+	if err != nil || newInbox.ID != inbox.ID || path == "" {
+		return errors.New("folders can only be renamed within their inbox")
+	}
+
+	if _, err := u.core.FolderService.GetByName(ctx, inbox.ID, path); err == nil {
+		return errors.New("mailbox already exists")
+	}
+
+	if err := u.core.FolderService.CreateParents(ctx, inbox.ID, path); err != nil {
+		return err
+	}
+
+	folder.Name = path
+	return u.core.FolderService.Update(ctx, folder)
 }
 
 // Logout handles user logout
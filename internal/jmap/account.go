@@ -0,0 +1,132 @@
+package jmap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"inbox451/internal/core"
+	"inbox451/internal/models"
+)
+
+// account is the JMAP view of a user. The inboxes of all projects of the user
+// are top-level mailboxes with their folders below them. Only the folders of
+// the default inbox get roles, as roles are unique within an account.
+type account struct {
+	user      *models.User
+	inboxes   []*models.Inbox
+	mailboxes []*mailbox
+	byID      map[string]*mailbox
+}
+
+func loadAccount(ctx context.Context, c *core.Core, user *models.User) (*account, error) {
+	inboxes, err := c.InboxService.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &account{user: user, inboxes: inboxes, byID: make(map[string]*mailbox)}
+
+	counts, err := c.MessageService.CountByMailbox(ctx, a.inboxIDs())
+	if err != nil {
+		return nil, err
+	}
+	byMailbox := make(map[string]*models.MailboxCount, len(counts))
+	for _, count := range counts {
+		id := count.InboxID
+		if count.FolderID.Valid {
+			id = count.FolderID.String
+		}
+		byMailbox[id] = count
+	}
+
+	defaultID := ""
+	if len(inboxes) > 0 {
+		defaultID = inboxes[0].ID
+		for _, inbox := range inboxes {
+			if inbox.ID == user.DefaultInboxID.String {
+				defaultID = inbox.ID
+			}
+		}
+	}
+
+	for _, inbox := range inboxes {
+		folders, err := c.FolderService.ListAllByInbox(ctx, inbox.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		a.add(newInboxMailbox(inbox, inbox.ID == defaultID))
+		byName := make(map[string]*models.Folder, len(folders))
+		for _, folder := range folders {
+			byName[folder.Name] = folder
+		}
+		for _, folder := range folders {
+			parentID := inbox.ID
+			if i := strings.LastIndex(folder.Name, core.FolderDelimiter); i >= 0 {
+				if parent, ok := byName[folder.Name[:i]]; ok {
+					parentID = parent.ID
+				}
+			}
+			a.add(newFolderMailbox(inbox, folder, parentID, inbox.ID == defaultID))
+		}
+	}
+
+	for _, m := range a.mailboxes {
+		if count, ok := byMailbox[m.ID]; ok {
+			m.TotalEmails, m.UnreadEmails = count.Total, count.Unread
+			m.TotalThreads, m.UnreadThreads = count.Threads, count.UnreadThreads
+		}
+	}
+	return a, nil
+}
+
+func (a *account) add(m *mailbox) {
+	a.mailboxes = append(a.mailboxes, m)
+	a.byID[m.ID] = m
+}
+
+func (a *account) inboxIDs() []string {
+	ids := make([]string, 0, len(a.inboxes))
+	for _, inbox := range a.inboxes {
+		ids = append(ids, inbox.ID)
+	}
+	return ids
+}
+
+// owns reports whether a message belongs to one of the inboxes of the account
+func (a *account) owns(message *models.Message) bool {
+	return slices.ContainsFunc(a.inboxes, func(inbox *models.Inbox) bool {
+		return inbox.ID == message.InboxID
+	})
+}
+
+// mailboxOf returns the mailbox holding a message
+func (a *account) mailboxOf(message *models.Message) *mailbox {
+	if message.FolderID.Valid {
+		return a.byID[message.FolderID.String]
+	}
+	return a.byID[message.InboxID]
+}
+
+// mailboxState changes whenever a mailbox or its counts change. Mailboxes are
+// few, so the state is derived from all of them rather than tracked.
+func (a *account) mailboxState() string {
+	data, _ := json.Marshal(a.mailboxes)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// emailState returns the state of the messages of an account, the highest
+// mod-sequence of its inboxes
+func emailState(ctx context.Context, c *core.Core, a *account) (string, error) {
+	modSeq, err := c.MessageService.HighestModSeq(ctx, a.inboxIDs())
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(modSeq, 10), nil
+}
@@ -0,0 +1,74 @@
+package jmap
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"inbox451/internal/core"
+	"inbox451/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Download serves a blob (RFC 8620 section 6.2). The blob of an email is its raw
+// message; body parts have blobs of their own, made of the message ID and the
+// part ID, that hold their decoded content.
+func (h *Handler) Download(c echo.Context) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	if c.Param("accountId") != user.ID {
+		return echo.NewHTTPError(http.StatusNotFound, "Account not found")
+	}
+
+	blobID := c.Param("blobId")
+	messageID, partID, isPart := strings.Cut(blobID, ".")
+	if uuid.Validate(messageID) != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Blob not found")
+	}
+
+	ctx := c.Request().Context()
+	message, err := h.core.MessageService.Get(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Blob not found")
+		}
+		return err
+	}
+	acct, err := loadAccount(ctx, h.core, user)
+	if err != nil {
+		return err
+	}
+	if !acct.owns(message) {
+		return echo.NewHTTPError(http.StatusNotFound, "Blob not found")
+	}
+	if err := h.core.MessageService.AttachRaw(ctx, []*models.Message{message}); err != nil {
+		return err
+	}
+
+	content := core.RawMessage(message)
+	contentType := "message/rfc822"
+	if isPart {
+		parsed, err := parseEmail(message.ID, content)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusNotFound, "Blob not found")
+		}
+		part := parsed.part(partID)
+		if part == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "Blob not found")
+		}
+		content, contentType = part.content, part.Type
+	}
+	if accept := c.QueryParam("accept"); accept != "" {
+		contentType = accept
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		mime.FormatMediaType("attachment", map[string]string{"filename": c.Param("name")}))
+	c.Response().Header().Set("Cache-Control", "private, immutable, max-age=31536000")
+	return c.Blob(http.StatusOK, contentType, content)
+}
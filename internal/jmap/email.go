@@ -0,0 +1,1062 @@
+package jmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/mail"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"inbox451/internal/core"
+	"inbox451/internal/models"
+
+	gomail "github.com/emersion/go-message/mail"
+	"github.com/google/uuid"
+	null "github.com/volatiletech/null/v9"
+)
+
+// System keywords (RFC 8621 section 4.1.1) and the message flags they stand for.
+// Other keywords are project labels.
+const (
+	keywordSeen     = "$seen"
+	keywordFlagged  = "$flagged"
+	keywordAnswered = "$answered"
+	keywordDraft    = "$draft"
+)
+
+// emailSortOptions are the properties Email/query can sort by
+var emailSortOptions = []string{"receivedAt", "from", "to", "subject"}
+
+// defaultEmailProperties are returned by Email/get when no properties are given
+var defaultEmailProperties = []string{
+	"id", "blobId", "threadId", "mailboxIds", "keywords", "size",
+	"receivedAt", "messageId", "inReplyTo", "references", "sender", "from",
+	"to", "cc", "bcc", "replyTo", "subject", "sentAt", "hasAttachment",
+	"preview", "bodyValues", "textBody", "htmlBody", "attachments",
+}
+
+// defaultBodyProperties are returned for body parts when no body properties are given
+var defaultBodyProperties = []string{"partId", "blobId", "size", "name", "type", "charset", "disposition", "cid"}
+
+// metadataProperties can be returned without loading the raw message
+var metadataProperties = []string{"id", "blobId", "threadId", "mailboxIds", "keywords", "receivedAt"}
+
+// keywords returns the keywords of a message: its flags and the keywords of its labels
+func keywords(message *models.Message) map[string]bool {
+	result := make(map[string]bool)
+	if message.IsRead {
+		result[keywordSeen] = true
+	}
+	if message.IsFlagged {
+		result[keywordFlagged] = true
+	}
+	if message.IsAnswered {
+		result[keywordAnswered] = true
+	}
+	if message.IsDraft {
+		result[keywordDraft] = true
+	}
+	for _, label := range message.Labels {
+		result[strings.ToLower(core.LabelKeyword(label.Name))] = true
+	}
+	return result
+}
+
+// messageFlags converts keywords to message flags. The keywords that are not
+// system keywords are returned as label keywords.
+func messageFlags(keywords map[string]bool) (models.MessageFlags, []string) {
+	var flags models.MessageFlags
+	var labels []string
+	for keyword, set := range keywords {
+		if !set {
+			continue
+		}
+		switch strings.ToLower(keyword) {
+		case keywordSeen:
+			flags.Seen = true
+		case keywordFlagged:
+			flags.Flagged = true
+		case keywordAnswered:
+			flags.Answered = true
+		case keywordDraft:
+			flags.Draft = true
+		default:
+			labels = append(labels, keyword)
+		}
+	}
+	sort.Strings(labels)
+	return flags, labels
+}
+
+// validKeyword reports whether a keyword can be stored (RFC 8621 section 4.1.1)
+func validKeyword(keyword string) bool {
+	if keyword == "" || len(keyword) > 255 {
+		return false
+	}
+	return !strings.ContainsFunc(keyword, func(r rune) bool {
+		return r <= ' ' || r >= 0x7f || strings.ContainsRune(`(){]%*"\`, r)
+	})
+}
+
+// getMessages returns the messages of the account with the given IDs in their
+// order, and the IDs that were not found
+func (r *call) getMessages(acct *account, ids []string) ([]*models.Message, []string, error) {
+	resolved := make([]string, 0, len(ids))
+	for _, id := range ids {
+		// Message IDs are UUIDs, anything else cannot be found
+		if id, ok := r.resolveID(id); ok && uuid.Validate(id) == nil {
+			resolved = append(resolved, id)
+		}
+	}
+	found, err := r.h.core.MessageService.GetMany(r.ctx, resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[string]*models.Message, len(found))
+	for _, message := range found {
+		if acct.owns(message) {
+			byID[message.ID] = message
+		}
+	}
+
+	messages := make([]*models.Message, 0, len(ids))
+	notFound := []string{}
+	for _, id := range ids {
+		resolved, _ := r.resolveID(id)
+		if message, ok := byID[resolved]; ok {
+			messages = append(messages, message)
+		} else {
+			notFound = append(notFound, id)
+		}
+	}
+	return messages, notFound, nil
+}
+
+type emailGetArgs struct {
+	AccountID           string    `json:"accountId"`
+	IDs                 *[]string `json:"ids"`
+	Properties          []string  `json:"properties"`
+	BodyProperties      []string  `json:"bodyProperties"`
+	FetchTextBodyValues bool      `json:"fetchTextBodyValues"`
+	FetchHTMLBodyValues bool      `json:"fetchHTMLBodyValues"`
+	FetchAllBodyValues  bool      `json:"fetchAllBodyValues"`
+	MaxBodyValueBytes   int       `json:"maxBodyValueBytes"`
+}
+
+func (r *call) emailGet(args json.RawMessage) (interface{}, error) {
+	var a emailGetArgs
+	if err := unmarshalArgs(args, &a); err != nil {
+		return nil, err
+	}
+	acct, err := r.account(a.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if a.IDs == nil {
+		return nil, invalidArguments("ids is required, use Email/query to find emails")
+	}
+	if len(*a.IDs) > maxObjectsInGet {
+		return nil, errRequestTooLarge
+	}
+	if a.Properties == nil {
+		a.Properties = defaultEmailProperties
+	}
+	if a.BodyProperties == nil {
+		a.BodyProperties = defaultBodyProperties
+	}
+	for _, property := range a.Properties {
+		if !slices.Contains(defaultEmailProperties, property) {
+			return nil, invalidArguments("unknown property %s", property)
+		}
+	}
+
+	messages, notFound, err := r.getMessages(acct, *a.IDs)
+	if err != nil {
+		return nil, err
+	}
+	needsRaw := slices.ContainsFunc(a.Properties, func(property string) bool {
+		return !slices.Contains(metadataProperties, property)
+	})
+	if needsRaw {
+		if err := r.h.core.MessageService.AttachRaw(r.ctx, messages); err != nil {
+			return nil, err
+		}
+	}
+
+	state, err := emailState(r.ctx, r.h.core, acct)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []interface{}{}
+	for _, message := range messages {
+		var parsed *parsedEmail
+		raw := core.RawMessage(message)
+		if needsRaw {
+			if parsed, err = parseEmail(message.ID, raw); err != nil {
+				r.h.core.Logger.Warn("JMAP: cannot parse message %s: %v", message.ID, err)
+				notFound = append(notFound, message.ID)
+				continue
+			}
+		}
+		list = append(list, emailObject(acct, message, raw, parsed, &a))
+	}
+
+	return map[string]interface{}{
+		"accountId": a.AccountID,
+		"state":     state,
+		"list":      list,
+		"notFound":  notFound,
+	}, nil
+}
+
+// emailObject returns the requested properties of an Email (RFC 8621 section 4.1).
+// parsed is nil when only metadata is requested.
+func emailObject(acct *account, message *models.Message, raw []byte, parsed *parsedEmail, a *emailGetArgs) map[string]interface{} {
+	object := map[string]interface{}{"id": message.ID}
+	for _, property := range a.Properties {
+		switch property {
+		case "blobId":
+			object[property] = message.ID
+		case "threadId":
+			object[property] = message.ThreadID
+		case "mailboxIds":
+			mailboxIDs := map[string]bool{}
+			if m := acct.mailboxOf(message); m != nil {
+				mailboxIDs[m.ID] = true
+			}
+			object[property] = mailboxIDs
+		case "keywords":
+			object[property] = keywords(message)
+		case "receivedAt":
+			object[property] = message.CreatedAt.Time.UTC().Format(time.RFC3339)
+		case "size":
+			object[property] = len(raw)
+		case "messageId":
+			object[property] = parsed.messageIDs("Message-Id")
+		case "inReplyTo":
+			object[property] = parsed.messageIDs("In-Reply-To")
+		case "references":
+			object[property] = parsed.messageIDs("References")
+		case "sender", "from", "to", "cc", "bcc", "replyTo":
+			field := property
+			if property == "replyTo" {
+				field = "Reply-To"
+			}
+			object[property] = parsed.addresses(field)
+		case "subject":
+			if parsed.header.Has("Subject") {
+				subject, _ := parsed.header.Subject()
+				object[property] = subject
+			} else {
+				object[property] = nil
+			}
+		case "sentAt":
+			if date, err := parsed.header.Date(); err == nil && parsed.header.Has("Date") {
+				object[property] = date.Format(time.RFC3339)
+			} else {
+				object[property] = nil
+			}
+		case "hasAttachment":
+			object[property] = len(parsed.attachments) > 0
+		case "preview":
+			object[property] = parsed.preview()
+		case "textBody":
+			object[property] = bodyPartObjects(parsed.textBody, a.BodyProperties)
+		case "htmlBody":
+			object[property] = bodyPartObjects(parsed.htmlBody, a.BodyProperties)
+		case "attachments":
+			object[property] = bodyPartObjects(parsed.attachments, a.BodyProperties)
+		case "bodyValues":
+			values := map[string]bodyValue{}
+			add := func(parts []*bodyPart) {
+				for _, bp := range parts {
+					if strings.HasPrefix(bp.Type, "text/") {
+						values[bp.PartID] = bp.value(a.MaxBodyValueBytes)
+					}
+				}
+			}
+			if a.FetchAllBodyValues {
+				add(parsed.parts)
+			}
+			if a.FetchTextBodyValues {
+				add(parsed.textBody)
+			}
+			if a.FetchHTMLBodyValues {
+				add(parsed.htmlBody)
+			}
+			object[property] = values
+		}
+	}
+	return object
+}
+
+// bodyPartObjects returns the requested properties of body parts
+func bodyPartObjects(parts []*bodyPart, properties []string) []map[string]interface{} {
+	objects := make([]map[string]interface{}, 0, len(parts))
+	for _, bp := range parts {
+		all, err := filterProperties(bp, nil)
+		if err != nil {
+			continue
+		}
+		object := make(map[string]interface{}, len(properties))
+		for _, property := range properties {
+			if value, ok := all[property]; ok {
+				object[property] = value
+			}
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// changesResponse is the response of the /changes methods (RFC 8620 section 5.2)
+type changesResponse struct {
+	AccountID      string   `json:"accountId"`
+	OldState       string   `json:"oldState"`
+	NewState       string   `json:"newState"`
+	HasMoreChanges bool     `json:"hasMoreChanges"`
+	Created        []string `json:"created"`
+	Updated        []string `json:"updated"`
+	Destroyed      []string `json:"destroyed"`
+}
+
+// messageChanges lists the message changes for Email/changes and Thread/changes
+// and returns a response without created, updated and destroyed IDs. The state is
+// the highest mod-sequence of the inboxes of the account.
+func (r *call) messageChanges(args json.RawMessage) (*changesResponse, *account, []*models.MessageChange, error) {
+	var a struct {
+		AccountID  string `json:"accountId"`
+		SinceState string `json:"sinceState"`
+		MaxChanges *int   `json:"maxChanges"`
+	}
+	if err := unmarshalArgs(args, &a); err != nil {
+		return nil, nil, nil, err
+	}
+	acct, err := r.account(a.AccountID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	limit := maxObjectsInGet
+	if a.MaxChanges != nil {
+		if *a.MaxChanges <= 0 {
+			return nil, nil, nil, invalidArguments("maxChanges must be positive")
+		}
+		limit = min(*a.MaxChanges, limit)
+	}
+
+	current, err := r.h.core.MessageService.HighestModSeq(r.ctx, acct.inboxIDs())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	since, err := strconv.ParseUint(a.SinceState, 10, 64)
+	if err != nil || since > current {
+		return nil, nil, nil, errCannotCalculateChanges
+	}
+
+	changes, err := r.h.core.MessageService.ListChanges(r.ctx, acct.inboxIDs(), since, limit)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	resp := &changesResponse{
+		AccountID: a.AccountID,
+		OldState:  a.SinceState,
+		NewState:  strconv.FormatUint(current, 10),
+		Created:   []string{},
+		Updated:   []string{},
+		Destroyed: []string{},
+	}
+	if len(changes) == limit {
+		resp.HasMoreChanges = true
+		resp.NewState = strconv.FormatUint(changes[len(changes)-1].ModSeq, 10)
+	}
+	return resp, acct, changes, nil
+}
+
+func (r *call) emailChanges(args json.RawMessage) (interface{}, error) {
+	resp, _, changes, err := r.messageChanges(args)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		switch {
+		case change.Destroyed:
+			resp.Destroyed = append(resp.Destroyed, change.MessageID)
+		case change.Created:
+			resp.Created = append(resp.Created, change.MessageID)
+		default:
+			resp.Updated = append(resp.Updated, change.MessageID)
+		}
+	}
+	return resp, nil
+}
+
+// emailFilter is an Email FilterCondition (RFC 8621 section 4.4.1)
+type emailFilter struct {
+	InMailbox          *string  `json:"inMailbox"`
+	InMailboxOtherThan []string `json:"inMailboxOtherThan"`
+	Before             *string  `json:"before"`
+	After              *string  `json:"after"`
+	HasKeyword         *string  `json:"hasKeyword"`
+	NotKeyword         *string  `json:"notKeyword"`
+	Text               *string  `json:"text"`
+	From               *string  `json:"from"`
+	To                 *string  `json:"to"`
+	Subject            *string  `json:"subject"`
+	Body               *string  `json:"body"`
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// matches evaluates an Email FilterCondition against a message
+func (r *call) emailMatches(acct *account, message *models.Message) func(json.RawMessage) (bool, error) {
+	return func(condition json.RawMessage) (bool, error) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(condition, &fields); err != nil {
+			return false, &MethodError{Type: "unsupportedFilter", Description: err.Error()}
+		}
+		var c emailFilter
+		if err := json.Unmarshal(condition, &c); err != nil {
+			return false, &MethodError{Type: "unsupportedFilter", Description: err.Error()}
+		}
+
+		mailboxID := ""
+		if m := acct.mailboxOf(message); m != nil {
+			mailboxID = m.ID
+		}
+		received := message.CreatedAt.Time
+		for key := range fields {
+			ok := true
+			switch key {
+			case "inMailbox":
+				id, _ := r.resolveID(stringValue(c.InMailbox))
+				ok = mailboxID == id
+			case "inMailboxOtherThan":
+				ok = !slices.ContainsFunc(c.InMailboxOtherThan, func(id string) bool {
+					id, _ = r.resolveID(id)
+					return id == mailboxID
+				})
+			case "before", "after":
+				value := c.Before
+				if key == "after" {
+					value = c.After
+				}
+				date, err := time.Parse(time.RFC3339, stringValue(value))
+				if err != nil {
+					return false, &MethodError{Type: "unsupportedFilter", Description: key + " must be a UTCDate"}
+				}
+				if key == "before" {
+					ok = received.Before(date)
+				} else {
+					ok = !received.Before(date)
+				}
+			case "hasKeyword":
+				ok = keywords(message)[strings.ToLower(stringValue(c.HasKeyword))]
+			case "notKeyword":
+				ok = !keywords(message)[strings.ToLower(stringValue(c.NotKeyword))]
+			case "text":
+				text := stringValue(c.Text)
+				ok = containsFold(message.Sender, text) || containsFold(message.Receiver, text) ||
+					containsFold(message.Subject, text) || containsFold(message.Body, text)
+			case "from":
+				ok = containsFold(message.Sender, stringValue(c.From))
+			case "to":
+				ok = containsFold(message.Receiver, stringValue(c.To))
+			case "subject":
+				ok = containsFold(message.Subject, stringValue(c.Subject))
+			case "body":
+				ok = containsFold(message.Body, stringValue(c.Body))
+			default:
+				return false, &MethodError{Type: "unsupportedFilter", Description: "unknown condition " + key}
+			}
+			if !ok {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// compareEmails compares two messages by a sort property
+func compareEmails(property string, a, b *models.Message) int {
+	switch property {
+	case "receivedAt":
+		return a.CreatedAt.Time.Compare(b.CreatedAt.Time)
+	case "from":
+		return strings.Compare(strings.ToLower(a.Sender), strings.ToLower(b.Sender))
+	case "to":
+		return strings.Compare(strings.ToLower(a.Receiver), strings.ToLower(b.Receiver))
+	default:
+		return strings.Compare(strings.ToLower(a.Subject), strings.ToLower(b.Subject))
+	}
+}
+
+// emailQuery implements Email/query. Messages are filtered and sorted in memory,
+// only the mailbox of an inMailbox condition at the top of the filter is used to
+// narrow them down. Without a sort, the newest messages come first.
+func (r *call) emailQuery(args json.RawMessage) (interface{}, error) {
+	var a struct {
+		AccountID       string          `json:"accountId"`
+		Filter          json.RawMessage `json:"filter"`
+		Sort            []comparator    `json:"sort"`
+		Position        int             `json:"position"`
+		Anchor          *string         `json:"anchor"`
+		AnchorOffset    int             `json:"anchorOffset"`
+		Limit           *int            `json:"limit"`
+		CalculateTotal  bool            `json:"calculateTotal"`
+		CollapseThreads bool            `json:"collapseThreads"`
+	}
+	if err := unmarshalArgs(args, &a); err != nil {
+		return nil, err
+	}
+	acct, err := r.account(a.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range a.Sort {
+		if !slices.Contains(emailSortOptions, c.Property) {
+			return nil, &MethodError{Type: "unsupportedSort", Description: "cannot sort by " + c.Property}
+		}
+	}
+	if len(a.Sort) == 0 {
+		descending := false
+		a.Sort = []comparator{{Property: "receivedAt", IsAscending: &descending}}
+	}
+	hasFilter := len(a.Filter) > 0 && string(a.Filter) != "null"
+
+	mailboxes := acct.mailboxes
+	if hasFilter {
+		var top emailFilter
+		if err := json.Unmarshal(a.Filter, &top); err == nil && top.InMailbox != nil {
+			id, _ := r.resolveID(*top.InMailbox)
+			m, ok := acct.byID[id]
+			if !ok {
+				return nil, &MethodError{Type: "unsupportedFilter", Description: "unknown mailbox " + *top.InMailbox}
+			}
+			mailboxes = []*mailbox{m}
+		}
+	}
+
+	var candidates []*models.Message
+	for _, m := range mailboxes {
+		messages, err := r.h.core.MessageService.ListAll(r.ctx, m.inbox.ID, models.MessageFilters{FolderID: m.folderID()})
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, messages...)
+	}
+	if err := r.h.core.LabelService.AttachToMessages(r.ctx, candidates); err != nil {
+		return nil, err
+	}
+
+	var matches []*models.Message
+	for _, message := range candidates {
+		if hasFilter {
+			ok, err := matchFilter(a.Filter, r.emailMatches(acct, message))
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		matches = append(matches, message)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		for _, c := range a.Sort {
+			if cmp := compareEmails(c.Property, matches[i], matches[j]); cmp != 0 {
+				return cmp < 0 == c.ascending()
+			}
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	ids := make([]string, 0, len(matches))
+	seenThreads := make(map[string]bool)
+	for _, message := range matches {
+		// A collapsed thread is represented by its first message in the sort order
+		if a.CollapseThreads {
+			if seenThreads[message.ThreadID] {
+				continue
+			}
+			seenThreads[message.ThreadID] = true
+		}
+		ids = append(ids, message.ID)
+	}
+	window, position, err := queryWindow(ids, a.Position, a.Anchor, a.AnchorOffset, a.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := emailState(r.ctx, r.h.core, acct)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{
+		"accountId":           a.AccountID,
+		"queryState":          state,
+		"canCalculateChanges": false,
+		"position":            position,
+		"ids":                 window,
+		"collapseThreads":     a.CollapseThreads,
+	}
+	if a.CalculateTotal {
+		result["total"] = len(ids)
+	}
+	return result, nil
+}
+
+func (r *call) emailSet(args json.RawMessage) (interface{}, error) {
+	var a setArgs
+	if err := unmarshalArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if a.size() > maxObjectsInSet {
+		return nil, errRequestTooLarge
+	}
+	acct, err := r.account(a.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	state, err := emailState(r.ctx, r.h.core, acct)
+	if err != nil {
+		return nil, err
+	}
+	if a.IfInState != nil && *a.IfInState != state {
+		return nil, errStateMismatch
+	}
+
+	resp := newSetResponse(a.AccountID, state)
+
+	for creationID, object := range a.Create {
+		message, setErr, err := r.createEmail(acct, object)
+		if err != nil {
+			return nil, err
+		}
+		if setErr != nil {
+			resp.NotCreated[creationID] = setErr
+			continue
+		}
+		r.created[creationID] = message.ID
+		resp.Created[creationID] = map[string]interface{}{
+			"id":       message.ID,
+			"blobId":   message.ID,
+			"threadId": message.ThreadID,
+			"size":     len(message.Raw),
+		}
+	}
+
+	if len(a.Update) > 0 {
+		ids := make([]string, 0, len(a.Update))
+		for id := range a.Update {
+			ids = append(ids, id)
+		}
+		messages, notFound, err := r.getMessages(acct, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range notFound {
+			resp.NotUpdated[id] = errSetNotFound
+		}
+		byID := make(map[string]*models.Message, len(messages))
+		for _, message := range messages {
+			byID[message.ID] = message
+		}
+		for _, id := range ids {
+			resolved, _ := r.resolveID(id)
+			message, ok := byID[resolved]
+			if !ok {
+				continue
+			}
+			setErr, err := r.updateEmail(acct, message, a.Update[id])
+			if err != nil {
+				return nil, err
+			}
+			if setErr != nil {
+				resp.NotUpdated[id] = setErr
+				continue
+			}
+			resp.Updated[id] = nil
+		}
+	}
+
+	if len(a.Destroy) > 0 {
+		messages, notFound, err := r.getMessages(acct, a.Destroy)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range notFound {
+			resp.NotDestroyed[id] = errSetNotFound
+		}
+		for _, message := range messages {
+			if err := r.h.core.MessageService.Delete(r.ctx, message.ID); err != nil {
+				setErr, err := setError(err)
+				if err != nil {
+					return nil, err
+				}
+				resp.NotDestroyed[message.ID] = setErr
+				continue
+			}
+			resp.Destroyed = append(resp.Destroyed, message.ID)
+		}
+	}
+
+	r.reloadAccount()
+	if acct, err = r.account(a.AccountID); err != nil {
+		return nil, err
+	}
+	if resp.NewState, err = emailState(r.ctx, r.h.core, acct); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// emailCreate holds the properties of an Email/set create. The body is given
+// as textBody and htmlBody parts referencing bodyValues, blob references are
+// not supported as there are no uploads.
+type emailCreate struct {
+	MailboxIDs map[string]bool      `json:"mailboxIds"`
+	Keywords   map[string]bool      `json:"keywords"`
+	ReceivedAt *time.Time           `json:"receivedAt"`
+	MessageID  []string             `json:"messageId"`
+	InReplyTo  []string             `json:"inReplyTo"`
+	References []string             `json:"references"`
+	Sender     []emailAddress       `json:"sender"`
+	From       []emailAddress       `json:"from"`
+	To         []emailAddress       `json:"to"`
+	Cc         []emailAddress       `json:"cc"`
+	Bcc        []emailAddress       `json:"bcc"`
+	ReplyTo    []emailAddress       `json:"replyTo"`
+	Subject    *string              `json:"subject"`
+	SentAt     *time.Time           `json:"sentAt"`
+	BodyValues map[string]bodyValue `json:"bodyValues"`
+	TextBody   []bodyPart           `json:"textBody"`
+	HTMLBody   []bodyPart           `json:"htmlBody"`
+}
+
+// createEmail composes a message from the properties of an Email/set create and
+// stores it in its mailbox
+func (r *call) createEmail(acct *account, object json.RawMessage) (*models.Message, *SetError, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(object, &fields); err != nil {
+		return nil, invalidProperties(err.Error()), nil
+	}
+	for key := range fields {
+		switch key {
+		case "mailboxIds", "keywords", "receivedAt", "messageId", "inReplyTo", "references",
+			"sender", "from", "to", "cc", "bcc", "replyTo", "subject", "sentAt",
+			"bodyValues", "textBody", "htmlBody":
+		default:
+			return nil, invalidProperties("property cannot be set", key), nil
+		}
+	}
+	var e emailCreate
+	if err := json.Unmarshal(object, &e); err != nil {
+		return nil, invalidProperties(err.Error()), nil
+	}
+
+	m, setErr := r.singleMailbox(acct, e.MailboxIDs)
+	if setErr != nil {
+		return nil, setErr, nil
+	}
+	for keyword := range e.Keywords {
+		if !validKeyword(keyword) {
+			return nil, invalidProperties("invalid keyword "+keyword, "keywords"), nil
+		}
+	}
+	if len(e.TextBody) > 1 || len(e.HTMLBody) > 1 {
+		return nil, invalidProperties("only a single text and HTML body are supported", "textBody", "htmlBody"), nil
+	}
+
+	raw, setErr, err := composeEmail(&e)
+	if err != nil || setErr != nil {
+		return nil, setErr, err
+	}
+	message, err := core.ParseRawMessage(raw)
+	if err != nil {
+		return nil, invalidProperties(err.Error()), nil
+	}
+	// Drafts may lack addresses, the inbox stands in for them
+	if message.Sender == "" {
+		message.Sender = m.inbox.Email
+	}
+	if message.Receiver == "" {
+		message.Receiver = m.inbox.Email
+	}
+	message.InboxID = m.inbox.ID
+	if m.folder != nil {
+		message.FolderID = null.StringFrom(m.folder.ID)
+	}
+	if e.ReceivedAt != nil {
+		message.CreatedAt = null.TimeFrom(*e.ReceivedAt)
+	}
+	flags, labelKeywords := messageFlags(e.Keywords)
+	message.IsRead, message.IsFlagged = flags.Seen, flags.Flagged
+	message.IsAnswered, message.IsDraft = flags.Answered, flags.Draft
+
+	if err := r.h.core.MessageService.Store(r.ctx, message); err != nil {
+		if errors.Is(err, core.ErrQuotaExceeded) {
+			return nil, errSetOverQuota, nil
+		}
+		return nil, nil, err
+	}
+
+	labels, err := r.h.core.LabelService.ResolveKeywords(r.ctx, m.inbox.ProjectID, labelKeywords, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, label := range labels {
+		if err := r.h.core.LabelService.AddToMessage(r.ctx, m.inbox.ProjectID, message.ID, label.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return message, nil, nil
+}
+
+// singleMailbox returns the mailbox of a mailboxIds value. Messages are in
+// exactly one mailbox.
+func (r *call) singleMailbox(acct *account, mailboxIDs map[string]bool) (*mailbox, *SetError) {
+	var ids []string
+	for id, set := range mailboxIDs {
+		if set {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) != 1 {
+		return nil, invalidProperties("an email must be in exactly one mailbox", "mailboxIds")
+	}
+	id, _ := r.resolveID(ids[0])
+	m, ok := acct.byID[id]
+	if !ok {
+		return nil, invalidProperties("unknown mailbox", "mailboxIds")
+	}
+	return m, nil
+}
+
+func addressList(addresses []emailAddress) []*gomail.Address {
+	list := make([]*gomail.Address, 0, len(addresses))
+	for _, address := range addresses {
+		list = append(list, &gomail.Address{Name: stringValue(address.Name), Address: address.Email})
+	}
+	return list
+}
+
+// composeEmail writes the RFC 5322 message of an Email/set create
+func composeEmail(e *emailCreate) ([]byte, *SetError, error) {
+	var h gomail.Header
+	for field, addresses := range map[string][]emailAddress{
+		"Sender": e.Sender, "From": e.From, "To": e.To, "Cc": e.Cc, "Bcc": e.Bcc, "Reply-To": e.ReplyTo,
+	} {
+		if len(addresses) > 0 {
+			for _, address := range addresses {
+				if _, err := mail.ParseAddress(address.Email); err != nil {
+					return nil, invalidProperties("invalid address "+address.Email, strings.ToLower(field[:1])+field[1:]), nil
+				}
+			}
+			h.SetAddressList(field, addressList(addresses))
+		}
+	}
+	if e.Subject != nil {
+		h.SetSubject(*e.Subject)
+	}
+	sentAt := time.Now()
+	if e.SentAt != nil {
+		sentAt = *e.SentAt
+	}
+	h.SetDate(sentAt)
+	if len(e.MessageID) > 0 {
+		h.SetMsgIDList("Message-Id", e.MessageID)
+	} else if err := h.GenerateMessageID(); err != nil {
+		return nil, nil, err
+	}
+	if len(e.InReplyTo) > 0 {
+		h.SetMsgIDList("In-Reply-To", e.InReplyTo)
+	}
+	if len(e.References) > 0 {
+		h.SetMsgIDList("References", e.References)
+	}
+
+	type inline struct {
+		mediaType string
+		content   string
+	}
+	var parts []inline
+	for _, body := range []struct {
+		parts     []bodyPart
+		mediaType string
+		property  string
+	}{{e.TextBody, "text/plain", "textBody"}, {e.HTMLBody, "text/html", "htmlBody"}} {
+		for _, bp := range body.parts {
+			value, ok := e.BodyValues[bp.PartID]
+			if !ok {
+				return nil, invalidProperties("body parts must reference bodyValues", body.property), nil
+			}
+			if bp.Type != "" && bp.Type != body.mediaType {
+				return nil, invalidProperties("type must be "+body.mediaType, body.property), nil
+			}
+			parts = append(parts, inline{mediaType: body.mediaType, content: value.Value})
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, inline{mediaType: "text/plain"})
+	}
+
+	var buf bytes.Buffer
+	writePart := func(w io.WriteCloser, content string) error {
+		if _, err := io.WriteString(w, content); err != nil {
+			return err
+		}
+		return w.Close()
+	}
+	if len(parts) == 1 {
+		h.SetContentType(parts[0].mediaType, map[string]string{"charset": "utf-8"})
+		w, err := gomail.CreateSingleInlineWriter(&buf, h)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writePart(w, parts[0].content); err != nil {
+			return nil, nil, err
+		}
+		return buf.Bytes(), nil, nil
+	}
+
+	w, err := gomail.CreateInlineWriter(&buf, h)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, part := range parts {
+		var ph gomail.InlineHeader
+		ph.SetContentType(part.mediaType, map[string]string{"charset": "utf-8"})
+		pw, err := w.CreatePart(ph)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writePart(pw, part.content); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), nil, nil
+}
+
+// applyPatch applies the patches of a set-valued property (keywords or
+// mailboxIds) to its current value
+func applyPatch(property string, current map[string]bool, patch map[string]json.RawMessage) (map[string]bool, bool, *SetError) {
+	result := current
+	changed := false
+	if value, ok := patch[property]; ok {
+		result = map[string]bool{}
+		if err := json.Unmarshal(value, &result); err != nil {
+			return nil, false, invalidProperties(err.Error(), property)
+		}
+		changed = true
+	}
+	for key, value := range patch {
+		name, ok := strings.CutPrefix(key, property+"/")
+		if !ok {
+			continue
+		}
+		if _, full := patch[property]; full {
+			return nil, false, invalidProperties("both "+property+" and a patch of it are set", key)
+		}
+		if !changed {
+			result = make(map[string]bool, len(current))
+			for k, v := range current {
+				result[k] = v
+			}
+			changed = true
+		}
+		switch string(value) {
+		case "true":
+			result[name] = true
+		case "null", "false":
+			delete(result, name)
+		default:
+			return nil, false, invalidProperties("value must be true or null", key)
+		}
+	}
+	return result, changed, nil
+}
+
+// updateEmail applies an Email/set patch. Only keywords and mailboxIds can be changed.
+func (r *call) updateEmail(acct *account, message *models.Message, patch map[string]json.RawMessage) (*SetError, error) {
+	for key := range patch {
+		if key != "keywords" && key != "mailboxIds" &&
+			!strings.HasPrefix(key, "keywords/") && !strings.HasPrefix(key, "mailboxIds/") {
+			return invalidProperties("property cannot be changed", key), nil
+		}
+	}
+
+	current := acct.mailboxOf(message)
+	if current == nil {
+		return errSetNotFound, nil
+	}
+	mailboxIDs, moved, setErr := applyPatch("mailboxIds", map[string]bool{current.ID: true}, patch)
+	if setErr != nil {
+		return setErr, nil
+	}
+	newKeywords, keywordsChanged, setErr := applyPatch("keywords", keywords(message), patch)
+	if setErr != nil {
+		return setErr, nil
+	}
+	for keyword := range newKeywords {
+		if !validKeyword(keyword) {
+			return invalidProperties("invalid keyword "+keyword, "keywords"), nil
+		}
+	}
+
+	dest := current
+	if moved {
+		if dest, setErr = r.singleMailbox(acct, mailboxIDs); setErr != nil {
+			return setErr, nil
+		}
+	}
+
+	uid := message.UID
+	if dest != current {
+		uids, err := r.h.core.MessageService.Move(r.ctx, current.inbox.ID, current.folderID(), []uint32{message.UID}, dest.inbox.ID, dest.folderID())
+		if err != nil {
+			return setError(err, "mailboxIds")
+		}
+		if len(uids) == 0 {
+			return errSetNotFound, nil
+		}
+		uid = uids[0]
+	}
+
+	if keywordsChanged {
+		flags, labelKeywords := messageFlags(newKeywords)
+		labels, err := r.h.core.LabelService.ResolveKeywords(r.ctx, dest.inbox.ProjectID, labelKeywords, true)
+		if err != nil {
+			return nil, err
+		}
+		flags.LabelIDs = []string{}
+		for _, label := range labels {
+			flags.LabelIDs = append(flags.LabelIDs, label.ID)
+		}
+		// JMAP has no deleted keyword, the IMAP flag is kept as it is
+		flags.Deleted = message.IsDeleted
+		if err := r.h.core.MessageService.UpdateFlags(r.ctx, dest.inbox.ID, dest.folderID(), []uint32{uid}, models.FlagsSet, flags); err != nil {
+			return setError(err, "keywords")
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,83 @@
+package jmap
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposeEmail(t *testing.T) {
+	subject := "Report"
+	name := "Alice"
+	sentAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	e := &emailCreate{
+		From:      []emailAddress{{Name: &name, Email: "alice@example.com"}},
+		To:        []emailAddress{{Email: "bob@example.com"}},
+		Subject:   &subject,
+		SentAt:    &sentAt,
+		InReplyTo: []string{"0@example.com"},
+		BodyValues: map[string]bodyValue{
+			"text": {Value: "Hi Bob"},
+			"html": {Value: "<p>Hi Bob</p>"},
+		},
+		TextBody: []bodyPart{{PartID: "text", Type: "text/plain"}},
+		HTMLBody: []bodyPart{{PartID: "html"}},
+	}
+
+	raw, setErr, err := composeEmail(e)
+	require.NoError(t, err)
+	require.Nil(t, setErr)
+
+	parsed, err := parseEmail("msg-1", raw)
+	require.NoError(t, err)
+	assert.Equal(t, []emailAddress{{Name: &name, Email: "alice@example.com"}}, parsed.addresses("From"))
+	assert.Equal(t, []string{"0@example.com"}, parsed.messageIDs("In-Reply-To"))
+	assert.Len(t, parsed.messageIDs("Message-Id"), 1)
+	require.Len(t, parsed.textBody, 1)
+	require.Len(t, parsed.htmlBody, 1)
+	assert.Equal(t, "Hi Bob", string(parsed.textBody[0].content))
+	assert.Equal(t, "<p>Hi Bob</p>", string(parsed.htmlBody[0].content))
+
+	e.TextBody = []bodyPart{{PartID: "missing"}}
+	_, setErr, err = composeEmail(e)
+	require.NoError(t, err)
+	require.NotNil(t, setErr)
+	assert.Equal(t, []string{"textBody"}, setErr.Properties)
+}
+
+func TestApplyPatch(t *testing.T) {
+	current := map[string]bool{"$seen": true, "work": true}
+
+	tests := []struct {
+		name        string
+		patch       string
+		want        map[string]bool
+		wantChanged bool
+		wantErr     bool
+	}{
+		{"untouched", `{}`, current, false, false},
+		{"replace", `{"keywords": {"$flagged": true}}`, map[string]bool{"$flagged": true}, true, false},
+		{"add and remove", `{"keywords/$flagged": true, "keywords/work": null}`, map[string]bool{"$seen": true, "$flagged": true}, true, false},
+		{"both forms", `{"keywords": {}, "keywords/$seen": true}`, nil, false, true},
+		{"invalid value", `{"keywords/$seen": 1}`, nil, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal([]byte(tt.patch), &patch))
+
+			got, changed, setErr := applyPatch("keywords", current, patch)
+			if tt.wantErr {
+				assert.NotNil(t, setErr)
+				return
+			}
+			require.Nil(t, setErr)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantChanged, changed)
+		})
+	}
+	assert.Equal(t, map[string]bool{"$seen": true, "work": true}, current)
+}
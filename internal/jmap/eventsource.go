@@ -0,0 +1,159 @@
+package jmap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"inbox451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// pushInterval is how often the states of an account are checked for changes
+// while a client listens for them
+const pushInterval = 5 * time.Second
+
+// pushTypes are the data types whose state changes are pushed
+var pushTypes = []string{"Mailbox", "Email", "Thread"}
+
+// stateChange is a StateChange push object (RFC 8620 section 7.1)
+type stateChange struct {
+	Type    string                       `json:"@type"`
+	Changed map[string]map[string]string `json:"changed"`
+}
+
+// EventSource pushes state changes as server-sent events (RFC 8620 section 7.3).
+// The states are polled, so changes arrive with a delay of up to pushInterval.
+func (h *Handler) EventSource(c echo.Context) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	types := pushTypes
+	if param := c.QueryParam("types"); param != "" && param != "*" {
+		types = strings.Split(param, ",")
+		for _, t := range types {
+			if !slices.Contains(pushTypes, t) {
+				return echo.NewHTTPError(http.StatusBadRequest, "Unknown type "+t)
+			}
+		}
+	}
+	closeAfter := c.QueryParam("closeafter")
+	if closeAfter != "" && closeAfter != "state" && closeAfter != "no" {
+		return echo.NewHTTPError(http.StatusBadRequest, "closeafter must be state or no")
+	}
+	ping := 0
+	if param := c.QueryParam("ping"); param != "" {
+		if ping, err = strconv.Atoi(param); err != nil || ping < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "ping must be a non-negative number")
+		}
+	}
+
+	ctx := c.Request().Context()
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.WriteHeader(http.StatusOK)
+
+	var pings <-chan time.Time
+	if ping > 0 {
+		ticker := time.NewTicker(time.Duration(ping) * time.Second)
+		defer ticker.Stop()
+		pings = ticker.C
+	}
+	poll := time.NewTicker(pushInterval)
+	defer poll.Stop()
+
+	// The first event holds the current states
+	var last map[string]string
+	for {
+		states, err := h.states(ctx, user, types)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		changed := make(map[string]string)
+		for t, state := range states {
+			if last == nil || last[t] != state {
+				changed[t] = state
+			}
+		}
+		if len(changed) > 0 {
+			if err := writeEvent(res, "state", stateChange{
+				Type:    "StateChange",
+				Changed: map[string]map[string]string{user.ID: changed},
+			}); err != nil {
+				return nil
+			}
+			if last != nil && closeAfter == "state" {
+				return nil
+			}
+		}
+		last = states
+
+		if !waitForPoll(ctx, res, poll.C, pings, ping) {
+			return nil
+		}
+	}
+}
+
+// waitForPoll sends pings until the next poll is due. It returns false when the
+// client went away.
+func waitForPoll(ctx context.Context, res *echo.Response, poll, pings <-chan time.Time, interval int) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-pings:
+			if err := writeEvent(res, "ping", map[string]int{"interval": interval}); err != nil {
+				return false
+			}
+		case <-poll:
+			return true
+		}
+	}
+}
+
+// states returns the current states of the given types for a user
+func (h *Handler) states(ctx context.Context, user *models.User, types []string) (map[string]string, error) {
+	acct, err := loadAccount(ctx, h.core, user)
+	if err != nil {
+		return nil, err
+	}
+	emails, err := emailState(ctx, h.core, acct)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]string, len(types))
+	for _, t := range types {
+		if t == "Mailbox" {
+			states[t] = acct.mailboxState()
+		} else {
+			states[t] = emails
+		}
+	}
+	return states, nil
+}
+
+func writeEvent(res *echo.Response, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
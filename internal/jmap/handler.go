@@ -0,0 +1,343 @@
+package jmap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+
+	"inbox451/internal/auth"
+	"inbox451/internal/core"
+	"inbox451/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler serves JMAP (RFC 8620 and RFC 8621) next to the REST API: the session
+// resource, the API endpoint, blob downloads and push over EventSource. It
+// relies on the API authentication middleware for the current user.
+type Handler struct {
+	core *core.Core
+}
+
+// NewHandler creates a JMAP handler
+func NewHandler(core *core.Core) *Handler {
+	return &Handler{core: core}
+}
+
+// currentUser returns the user set by the authentication middleware
+func currentUser(c echo.Context) (*models.User, error) {
+	user, ok := c.Get(auth.UserKey).(models.User)
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+	}
+	return &user, nil
+}
+
+// sessionState changes whenever the session resource of a user changes, which
+// only happens when the user itself changes
+func sessionState(user *models.User) string {
+	sum := sha256.Sum256([]byte(user.ID + "\x00" + user.Username))
+	return hex.EncodeToString(sum[:8])
+}
+
+type session struct {
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	Accounts        map[string]accountInfo `json:"accounts"`
+	PrimaryAccounts map[string]string      `json:"primaryAccounts"`
+	Username        string                 `json:"username"`
+	APIURL          string                 `json:"apiUrl"`
+	DownloadURL     string                 `json:"downloadUrl"`
+	UploadURL       string                 `json:"uploadUrl"`
+	EventSourceURL  string                 `json:"eventSourceUrl"`
+	State           string                 `json:"state"`
+}
+
+type coreCapability struct {
+	MaxSizeUpload         int      `json:"maxSizeUpload"`
+	MaxConcurrentUpload   int      `json:"maxConcurrentUpload"`
+	MaxSizeRequest        int      `json:"maxSizeRequest"`
+	MaxConcurrentRequests int      `json:"maxConcurrentRequests"`
+	MaxCallsInRequest     int      `json:"maxCallsInRequest"`
+	MaxObjectsInGet       int      `json:"maxObjectsInGet"`
+	MaxObjectsInSet       int      `json:"maxObjectsInSet"`
+	CollationAlgorithms   []string `json:"collationAlgorithms"`
+}
+
+type mailCapability struct {
+	MaxMailboxesPerEmail       int      `json:"maxMailboxesPerEmail"`
+	MaxMailboxDepth            *int     `json:"maxMailboxDepth"`
+	MaxSizeMailboxName         int      `json:"maxSizeMailboxName"`
+	MaxSizeAttachmentsPerEmail int      `json:"maxSizeAttachmentsPerEmail"`
+	EmailQuerySortOptions      []string `json:"emailQuerySortOptions"`
+	MayCreateTopLevelMailbox   bool     `json:"mayCreateTopLevelMailbox"`
+}
+
+type accountInfo struct {
+	Name                string                 `json:"name"`
+	IsPersonal          bool                   `json:"isPersonal"`
+	IsReadOnly          bool                   `json:"isReadOnly"`
+	AccountCapabilities map[string]interface{} `json:"accountCapabilities"`
+}
+
+// WellKnown redirects to the session resource (RFC 8620 section 2.2)
+func (h *Handler) WellKnown(c echo.Context) error {
+	return c.Redirect(http.StatusTemporaryRedirect, "/jmap/session")
+}
+
+// Session returns the session resource of the current user. Every user has a
+// single account holding the inboxes of all projects of the user.
+func (h *Handler) Session(c echo.Context) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	base := c.Scheme() + "://" + c.Request().Host
+	return c.JSON(http.StatusOK, session{
+		Capabilities: map[string]interface{}{
+			CapabilityCore: coreCapability{
+				// Uploads are not supported, messages are created with Email/set
+				MaxSizeUpload:         0,
+				MaxConcurrentUpload:   1,
+				MaxSizeRequest:        maxSizeRequest,
+				MaxConcurrentRequests: maxConcurrentRequests,
+				MaxCallsInRequest:     maxCallsInRequest,
+				MaxObjectsInGet:       maxObjectsInGet,
+				MaxObjectsInSet:       maxObjectsInSet,
+				CollationAlgorithms:   []string{"i;ascii-casemap"},
+			},
+			CapabilityMail: struct{}{},
+		},
+		Accounts: map[string]accountInfo{
+			user.ID: {
+				Name:       user.Username,
+				IsPersonal: true,
+				AccountCapabilities: map[string]interface{}{
+					CapabilityCore: struct{}{},
+					CapabilityMail: mailCapability{
+						MaxMailboxesPerEmail:       1,
+						MaxSizeMailboxName:         255,
+						MaxSizeAttachmentsPerEmail: maxSizeRequest,
+						EmailQuerySortOptions:      emailSortOptions,
+						MayCreateTopLevelMailbox:   false,
+					},
+				},
+			},
+		},
+		PrimaryAccounts: map[string]string{
+			CapabilityCore: user.ID,
+			CapabilityMail: user.ID,
+		},
+		Username:       user.Username,
+		APIURL:         base + "/jmap/api",
+		DownloadURL:    base + "/jmap/download/{accountId}/{blobId}/{name}?accept={type}",
+		UploadURL:      base + "/jmap/upload/{accountId}/",
+		EventSourceURL: base + "/jmap/eventsource?types={types}&closeafter={closeafter}&ping={ping}",
+		State:          sessionState(user),
+	})
+}
+
+// Upload rejects blob uploads, which are not supported
+func (h *Handler) Upload(c echo.Context) error {
+	return c.JSON(http.StatusForbidden, problem{
+		Type:   "about:blank",
+		Status: http.StatusForbidden,
+		Detail: "Uploads are not supported",
+	})
+}
+
+// call is the state of a single API request shared by its method calls
+type call struct {
+	h       *Handler
+	ctx     context.Context
+	user    *models.User
+	using   []string
+	acct    *account
+	created map[string]string
+}
+
+// method implements a JMAP method, returning its response arguments
+type method func(r *call, args json.RawMessage) (interface{}, error)
+
+var methods = map[string]method{
+	"Core/echo": func(r *call, args json.RawMessage) (interface{}, error) {
+		return args, nil
+	},
+	"Mailbox/get":          (*call).mailboxGet,
+	"Mailbox/changes":      (*call).mailboxChanges,
+	"Mailbox/query":        (*call).mailboxQuery,
+	"Mailbox/queryChanges": (*call).queryChanges,
+	"Mailbox/set":          (*call).mailboxSet,
+	"Email/get":            (*call).emailGet,
+	"Email/changes":        (*call).emailChanges,
+	"Email/query":          (*call).emailQuery,
+	"Email/queryChanges":   (*call).queryChanges,
+	"Email/set":            (*call).emailSet,
+	"Thread/get":           (*call).threadGet,
+	"Thread/changes":       (*call).threadChanges,
+}
+
+// API processes a JMAP request (RFC 8620 section 3)
+func (h *Handler) API(c echo.Context) error {
+	user, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req Request
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, maxSizeRequest)
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		var syntaxErr *json.SyntaxError
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			return c.JSON(http.StatusBadRequest, problem{
+				Type:   "urn:ietf:params:jmap:error:limit",
+				Status: http.StatusBadRequest,
+				Limit:  "maxSizeRequest",
+			})
+		case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			return requestError(c, "urn:ietf:params:jmap:error:notJSON", err.Error())
+		}
+		return requestError(c, "urn:ietf:params:jmap:error:notRequest", err.Error())
+	}
+	for _, capability := range req.Using {
+		if capability != CapabilityCore && capability != CapabilityMail {
+			return requestError(c, "urn:ietf:params:jmap:error:unknownCapability", "Unknown capability "+capability)
+		}
+	}
+	if len(req.MethodCalls) > maxCallsInRequest {
+		return c.JSON(http.StatusBadRequest, problem{
+			Type:   "urn:ietf:params:jmap:error:limit",
+			Status: http.StatusBadRequest,
+			Limit:  "maxCallsInRequest",
+		})
+	}
+
+	r := &call{
+		h:       h,
+		ctx:     c.Request().Context(),
+		user:    user,
+		using:   req.Using,
+		created: req.CreatedIDs,
+	}
+	if r.created == nil {
+		r.created = make(map[string]string)
+	}
+
+	responses := make([]Invocation, 0, len(req.MethodCalls))
+	for _, inv := range req.MethodCalls {
+		responses = append(responses, r.invoke(inv, responses))
+	}
+
+	resp := Response{MethodResponses: responses, SessionState: sessionState(user)}
+	if req.CreatedIDs != nil {
+		resp.CreatedIDs = r.created
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func requestError(c echo.Context, errorType, detail string) error {
+	return c.JSON(http.StatusBadRequest, problem{Type: errorType, Status: http.StatusBadRequest, Detail: detail})
+}
+
+// invoke runs a single method call and returns its response
+func (r *call) invoke(inv Invocation, responses []Invocation) Invocation {
+	result, err := r.run(inv, responses)
+	if err == nil {
+		var data []byte
+		if data, err = json.Marshal(result); err == nil {
+			return Invocation{Name: inv.Name, Args: data, CallID: inv.CallID}
+		}
+	}
+
+	var methodErr *MethodError
+	if !errors.As(err, &methodErr) {
+		r.h.core.Logger.Error("JMAP: %s failed: %v", inv.Name, err)
+		methodErr = errServerFail
+	}
+	data, _ := json.Marshal(methodErr)
+	return Invocation{Name: "error", Args: data, CallID: inv.CallID}
+}
+
+func (r *call) run(inv Invocation, responses []Invocation) (interface{}, error) {
+	m, ok := methods[inv.Name]
+	if !ok {
+		return nil, errUnknownMethod
+	}
+	if inv.Name != "Core/echo" && !slices.Contains(r.using, CapabilityMail) {
+		return nil, errUnknownMethod
+	}
+
+	args, err := resolveReferences(inv.Args, responses)
+	if err != nil {
+		return nil, err
+	}
+	return m(r, args)
+}
+
+// account returns the account with the given ID, loading it on first use
+func (r *call) account(accountID string) (*account, error) {
+	if accountID != r.user.ID {
+		return nil, errAccountNotFound
+	}
+	if r.acct == nil {
+		acct, err := loadAccount(r.ctx, r.h.core, r.user)
+		if err != nil {
+			return nil, err
+		}
+		r.acct = acct
+	}
+	return r.acct, nil
+}
+
+// reloadAccount drops the loaded account after changes to mailboxes or messages
+func (r *call) reloadAccount() {
+	r.acct = nil
+}
+
+// resolveID replaces a creation ID reference (#creationId) by the ID of the
+// object created under it
+func (r *call) resolveID(id string) (string, bool) {
+	creationID, ok := cutCreationID(id)
+	if !ok {
+		return id, true
+	}
+	created, ok := r.created[creationID]
+	return created, ok
+}
+
+func cutCreationID(id string) (string, bool) {
+	if len(id) > 1 && id[0] == '#' {
+		return id[1:], true
+	}
+	return "", false
+}
+
+// unmarshalArgs decodes method arguments, reporting errors as invalidArguments
+func unmarshalArgs(args json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(args, v); err != nil {
+		return invalidArguments("%v", err)
+	}
+	return nil
+}
+
+// queryChanges implements /queryChanges for all types. Query results are not
+// tracked, so clients have to run the query again.
+func (r *call) queryChanges(args json.RawMessage) (interface{}, error) {
+	var a struct {
+		AccountID string `json:"accountId"`
+	}
+	if err := unmarshalArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if _, err := r.account(a.AccountID); err != nil {
+		return nil, err
+	}
+	return nil, errCannotCalculateChanges
+}
@@ -0,0 +1,321 @@
+package jmap
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"inbox451/internal/auth"
+	"inbox451/internal/core"
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	null "github.com/volatiletech/null/v9"
+)
+
+const testRaw = "From: Alice <alice@example.com>\r\n" +
+	"To: bob@example.com\r\n" +
+	"Subject: Hello\r\n" +
+	"Date: Mon, 02 Jan 2006 15:04:05 +0000\r\n" +
+	"Message-Id: <1@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"Hello Bob\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Hello <b>Bob</b></p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf\r\n" +
+	"Content-Disposition: attachment; filename=report.pdf\r\n" +
+	"\r\n" +
+	"%PDF\r\n" +
+	"--outer--\r\n"
+
+const (
+	msgID1 = "7b0a1c2e-0000-4000-8000-000000000001"
+	msgID2 = "7b0a1c2e-0000-4000-8000-000000000002"
+)
+
+var testUser = models.User{Base: models.Base{ID: "user-1"}, Username: "alice", Status: "active"}
+
+func setupJMAPTestHandler(t *testing.T) (*Handler, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	c := &core.Core{
+		Logger:     logger.New(io.Discard, logger.DEBUG),
+		Repository: mockRepo,
+	}
+	c.InboxService = core.NewInboxService(c)
+	c.FolderService = core.NewFolderService(c)
+	c.LabelService = core.NewLabelService(c)
+	c.MessageService = core.NewMessageService(c)
+	c.ThreadService = core.NewThreadService(c)
+	return NewHandler(c), mockRepo
+}
+
+func newContext(method, target, body string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.Set(auth.UserKey, testUser)
+	return c, rec
+}
+
+func expectAccount(m *mocks.Repository) {
+	inbox := &models.Inbox{Base: models.Base{ID: "inbox-1"}, ProjectID: "project-1", Email: "alice@example.com"}
+	folders := []*models.Folder{
+		{Base: models.Base{ID: "folder-sent"}, InboxID: "inbox-1", Name: "Sent", SpecialUse: null.StringFrom(`\Sent`), Subscribed: true},
+		{Base: models.Base{ID: "folder-work"}, InboxID: "inbox-1", Name: "Work", Subscribed: true},
+		{Base: models.Base{ID: "folder-2024"}, InboxID: "inbox-1", Name: "Work/2024", Subscribed: true},
+	}
+	m.On("ListInboxesByUser", mock.Anything, "user-1").Return([]*models.Inbox{inbox}, nil)
+	m.On("ListFoldersByInbox", mock.Anything, "inbox-1", mock.Anything, 0).Return(folders, len(folders), nil)
+	m.On("CountMessagesByMailbox", mock.Anything, []string{"inbox-1"}).Return([]*models.MailboxCount{
+		{InboxID: "inbox-1", Total: 2, Unread: 1, Threads: 1, UnreadThreads: 1},
+		{InboxID: "inbox-1", FolderID: null.StringFrom("folder-work"), Total: 1},
+	}, nil)
+}
+
+// call posts a JMAP request and returns its method responses
+func callAPI(t *testing.T, h *Handler, methodCalls string) []Invocation {
+	body := `{"using":["urn:ietf:params:jmap:core","urn:ietf:params:jmap:mail"],"methodCalls":` + methodCalls + `}`
+	c, rec := newContext(http.MethodPost, "/jmap/api", body)
+	require.NoError(t, h.API(c))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.MethodResponses
+}
+
+func decode(t *testing.T, inv Invocation) map[string]interface{} {
+	var args map[string]interface{}
+	require.NoError(t, json.Unmarshal(inv.Args, &args))
+	return args
+}
+
+func TestSession(t *testing.T) {
+	h, _ := setupJMAPTestHandler(t)
+
+	c, rec := newContext(http.MethodGet, "/jmap/session", "")
+	require.NoError(t, h.Session(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var s map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &s))
+	assert.Equal(t, "alice", s["username"])
+	assert.Equal(t, "http://example.com/jmap/api", s["apiUrl"])
+	assert.Contains(t, s["capabilities"], CapabilityCore)
+	assert.Contains(t, s["capabilities"], CapabilityMail)
+	assert.Equal(t, map[string]interface{}{CapabilityCore: "user-1", CapabilityMail: "user-1"}, s["primaryAccounts"])
+	assert.Contains(t, s["accounts"], "user-1")
+}
+
+func TestAPIRequestErrors(t *testing.T) {
+	h, _ := setupJMAPTestHandler(t)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantType string
+	}{
+		{"not JSON", `{"using":`, "urn:ietf:params:jmap:error:notJSON"},
+		{"not a request", `{"using":"core"}`, "urn:ietf:params:jmap:error:notRequest"},
+		{"unknown capability", `{"using":["urn:example"],"methodCalls":[]}`, "urn:ietf:params:jmap:error:unknownCapability"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := newContext(http.MethodPost, "/jmap/api", tt.body)
+			require.NoError(t, h.API(c))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+			var p problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+			assert.Equal(t, tt.wantType, p.Type)
+		})
+	}
+}
+
+func TestAPIMethodErrors(t *testing.T) {
+	h, _ := setupJMAPTestHandler(t)
+
+	responses := callAPI(t, h, `[
+		["Core/echo", {"hello": true}, "c0"],
+		["Foo/get", {}, "c1"],
+		["Mailbox/get", {"accountId": "other"}, "c2"]
+	]`)
+	require.Len(t, responses, 3)
+
+	assert.Equal(t, "Core/echo", responses[0].Name)
+	assert.JSONEq(t, `{"hello": true}`, string(responses[0].Args))
+	assert.Equal(t, "error", responses[1].Name)
+	assert.Equal(t, "unknownMethod", decode(t, responses[1])["type"])
+	assert.Equal(t, "error", responses[2].Name)
+	assert.Equal(t, "accountNotFound", decode(t, responses[2])["type"])
+}
+
+func TestMailboxGet(t *testing.T) {
+	h, mockRepo := setupJMAPTestHandler(t)
+	expectAccount(mockRepo)
+
+	responses := callAPI(t, h, `[
+		["Mailbox/query", {"accountId": "user-1", "filter": {"parentId": "folder-work"}}, "q"],
+		["Mailbox/get", {"accountId": "user-1", "#ids": {"resultOf": "q", "name": "Mailbox/query", "path": "/ids"}}, "g"],
+		["Mailbox/get", {"accountId": "user-1", "ids": ["inbox-1", "folder-sent", "unknown"], "properties": ["name", "role", "totalEmails", "totalThreads"]}, "g2"]
+	]`)
+	require.Len(t, responses, 3)
+
+	query := decode(t, responses[0])
+	assert.Equal(t, []interface{}{"folder-2024"}, query["ids"])
+
+	get := decode(t, responses[1])
+	list := get["list"].([]interface{})
+	require.Len(t, list, 1)
+	assert.Equal(t, "2024", list[0].(map[string]interface{})["name"])
+	assert.Equal(t, "folder-work", list[0].(map[string]interface{})["parentId"])
+
+	get = decode(t, responses[2])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "inbox-1", "name": "alice@example.com", "role": "inbox", "totalEmails": float64(2), "totalThreads": float64(1)},
+		map[string]interface{}{"id": "folder-sent", "name": "Sent", "role": "sent", "totalEmails": float64(0), "totalThreads": float64(0)},
+	}, get["list"])
+	assert.Equal(t, []interface{}{"unknown"}, get["notFound"])
+}
+
+func TestEmailGet(t *testing.T) {
+	h, mockRepo := setupJMAPTestHandler(t)
+	expectAccount(mockRepo)
+
+	received := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	message := &models.Message{
+		Base:      models.Base{ID: msgID1, CreatedAt: null.TimeFrom(received)},
+		InboxID:   "inbox-1",
+		FolderID:  null.StringFrom("folder-work"),
+		UID:       1,
+		IsFlagged: true,
+	}
+	foreign := &models.Message{Base: models.Base{ID: msgID2}, InboxID: "inbox-other"}
+	mockRepo.On("ListMessagesByIDs", mock.Anything, []string{msgID1, msgID2}).
+		Return([]*models.Message{message, foreign}, nil)
+	mockRepo.On("ListLabelsByMessages", mock.Anything, []string{msgID1, msgID2}).
+		Return([]*models.MessageLabel{{MessageID: msgID1, Label: models.Label{Name: "Important"}}}, nil)
+	mockRepo.On("ListRawMessages", mock.Anything, []string{msgID1}).
+		Return([]*models.Message{{Base: models.Base{ID: msgID1}, Raw: []byte(testRaw)}}, nil)
+	mockRepo.On("GetHighestModSeqForInboxes", mock.Anything, []string{"inbox-1"}).Return(uint64(42), nil)
+
+	responses := callAPI(t, h, `[
+		["Email/get", {
+			"accountId": "user-1",
+			"ids": ["7b0a1c2e-0000-4000-8000-000000000001", "7b0a1c2e-0000-4000-8000-000000000002"],
+			"properties": ["mailboxIds", "keywords", "receivedAt", "from", "subject", "sentAt", "preview", "hasAttachment", "textBody", "bodyValues", "attachments"],
+			"bodyProperties": ["partId", "type", "name"],
+			"fetchTextBodyValues": true
+		}, "g"]
+	]`)
+	require.Len(t, responses, 1)
+	require.Equal(t, "Email/get", responses[0].Name, string(responses[0].Args))
+
+	get := decode(t, responses[0])
+	assert.Equal(t, "42", get["state"])
+	assert.Equal(t, []interface{}{msgID2}, get["notFound"])
+	list := get["list"].([]interface{})
+	require.Len(t, list, 1)
+
+	email := list[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"folder-work": true}, email["mailboxIds"])
+	assert.Equal(t, map[string]interface{}{"$flagged": true, "important": true}, email["keywords"])
+	assert.Equal(t, "2024-05-01T12:00:00Z", email["receivedAt"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "Alice", "email": "alice@example.com"}}, email["from"])
+	assert.Equal(t, "Hello", email["subject"])
+	assert.Equal(t, "2006-01-02T15:04:05Z", email["sentAt"])
+	assert.Equal(t, "Hello Bob", email["preview"])
+	assert.Equal(t, true, email["hasAttachment"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"partId": "1.1", "type": "text/plain", "name": nil}}, email["textBody"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"partId": "2", "type": "application/pdf", "name": "report.pdf"}}, email["attachments"])
+	assert.Equal(t, map[string]interface{}{
+		"1.1": map[string]interface{}{"value": "Hello Bob", "isEncodingProblem": false, "isTruncated": false},
+	}, email["bodyValues"])
+}
+
+func TestEmailChanges(t *testing.T) {
+	h, mockRepo := setupJMAPTestHandler(t)
+	expectAccount(mockRepo)
+
+	mockRepo.On("GetHighestModSeqForInboxes", mock.Anything, []string{"inbox-1"}).Return(uint64(20), nil)
+	mockRepo.On("ListMessageChanges", mock.Anything, []string{"inbox-1"}, uint64(10), 2).
+		Return([]*models.MessageChange{
+			{MessageID: msgID1, ModSeq: 11, Created: true},
+			{MessageID: msgID2, ModSeq: 12, Destroyed: true},
+		}, nil)
+
+	responses := callAPI(t, h, `[
+		["Email/changes", {"accountId": "user-1", "sinceState": "10", "maxChanges": 2}, "c"],
+		["Email/changes", {"accountId": "user-1", "sinceState": "30"}, "c2"]
+	]`)
+	require.Len(t, responses, 2)
+
+	changes := decode(t, responses[0])
+	assert.Equal(t, "12", changes["newState"])
+	assert.Equal(t, true, changes["hasMoreChanges"])
+	assert.Equal(t, []interface{}{msgID1}, changes["created"])
+	assert.Equal(t, []interface{}{}, changes["updated"])
+	assert.Equal(t, []interface{}{msgID2}, changes["destroyed"])
+
+	assert.Equal(t, "error", responses[1].Name)
+	assert.Equal(t, "cannotCalculateChanges", decode(t, responses[1])["type"])
+}
+
+func TestThreadGetAndChanges(t *testing.T) {
+	h, mockRepo := setupJMAPTestHandler(t)
+	expectAccount(mockRepo)
+
+	const msgID3 = "7b0a1c2e-0000-4000-8000-000000000003"
+	thread := []*models.Message{
+		{Base: models.Base{ID: msgID1}, InboxID: "inbox-1", ThreadID: msgID1},
+		{Base: models.Base{ID: msgID2}, InboxID: "inbox-1", ThreadID: msgID1},
+	}
+	mockRepo.On("ListMessagesByThreads", mock.Anything, []string{msgID1, msgID3}).Return(thread, nil).Twice()
+	mockRepo.On("GetHighestModSeqForInboxes", mock.Anything, []string{"inbox-1"}).Return(uint64(20), nil)
+	mockRepo.On("ListMessageChanges", mock.Anything, []string{"inbox-1"}, uint64(10), maxObjectsInGet).
+		Return([]*models.MessageChange{
+			{MessageID: msgID1, ThreadID: msgID1, ModSeq: 11, Created: true},
+			{MessageID: msgID2, ThreadID: msgID1, ModSeq: 12, Created: true},
+			{MessageID: msgID3, ThreadID: msgID3, ModSeq: 13, Destroyed: true},
+		}, nil)
+
+	responses := callAPI(t, h, `[
+		["Thread/get", {"accountId": "user-1", "ids": ["7b0a1c2e-0000-4000-8000-000000000001", "7b0a1c2e-0000-4000-8000-000000000003", "nope"]}, "g"],
+		["Thread/changes", {"accountId": "user-1", "sinceState": "10"}, "c"]
+	]`)
+	require.Len(t, responses, 2)
+	require.Equal(t, "Thread/get", responses[0].Name, string(responses[0].Args))
+
+	get := decode(t, responses[0])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": msgID1, "emailIds": []interface{}{msgID1, msgID2}},
+	}, get["list"])
+	assert.Equal(t, []interface{}{msgID3, "nope"}, get["notFound"])
+
+	changes := decode(t, responses[1])
+	assert.Equal(t, []interface{}{msgID1}, changes["created"])
+	assert.Equal(t, []interface{}{}, changes["updated"])
+	assert.Equal(t, []interface{}{msgID3}, changes["destroyed"])
+}
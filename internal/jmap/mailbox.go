@@ -0,0 +1,618 @@
+package jmap
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"inbox451/internal/core"
+	"inbox451/internal/models"
+)
+
+// mailbox is a JMAP Mailbox (RFC 8621 section 2): an inbox or one of its folders
+type mailbox struct {
+	ID            string        `json:"id"`
+	Name          string        `json:"name"`
+	ParentID      *string       `json:"parentId"`
+	Role          *string       `json:"role"`
+	SortOrder     int           `json:"sortOrder"`
+	TotalEmails   int           `json:"totalEmails"`
+	UnreadEmails  int           `json:"unreadEmails"`
+	TotalThreads  int           `json:"totalThreads"`
+	UnreadThreads int           `json:"unreadThreads"`
+	MyRights      mailboxRights `json:"myRights"`
+	IsSubscribed  bool          `json:"isSubscribed"`
+
+	inbox *models.Inbox
+	// folder is nil for the inbox itself
+	folder *models.Folder
+}
+
+type mailboxRights struct {
+	MayReadItems   bool `json:"mayReadItems"`
+	MayAddItems    bool `json:"mayAddItems"`
+	MayRemoveItems bool `json:"mayRemoveItems"`
+	MaySetSeen     bool `json:"maySetSeen"`
+	MaySetKeywords bool `json:"maySetKeywords"`
+	MayCreateChild bool `json:"mayCreateChild"`
+	MayRename      bool `json:"mayRename"`
+	MayDelete      bool `json:"mayDelete"`
+	MaySubmit      bool `json:"maySubmit"`
+}
+
+func fullRights() mailboxRights {
+	return mailboxRights{
+		MayReadItems:   true,
+		MayAddItems:    true,
+		MayRemoveItems: true,
+		MaySetSeen:     true,
+		MaySetKeywords: true,
+		MayCreateChild: true,
+		MayRename:      true,
+		MayDelete:      true,
+	}
+}
+
+func newInboxMailbox(inbox *models.Inbox, isDefault bool) *mailbox {
+	m := &mailbox{
+		ID:           inbox.ID,
+		Name:         inbox.Email,
+		SortOrder:    10,
+		MyRights:     fullRights(),
+		IsSubscribed: true,
+		inbox:        inbox,
+	}
+	// Inboxes are managed through the REST API
+	m.MyRights.MayRename = false
+	m.MyRights.MayDelete = false
+	if isDefault {
+		role := "inbox"
+		m.Role = &role
+		m.SortOrder = 0
+	}
+	return m
+}
+
+func newFolderMailbox(inbox *models.Inbox, folder *models.Folder, parentID string, isDefault bool) *mailbox {
+	m := &mailbox{
+		ID:           folder.ID,
+		Name:         folder.Name[strings.LastIndex(folder.Name, core.FolderDelimiter)+1:],
+		ParentID:     &parentID,
+		SortOrder:    10,
+		MyRights:     fullRights(),
+		IsSubscribed: folder.Subscribed,
+		inbox:        inbox,
+		folder:       folder,
+	}
+	if folder.SpecialUse.Valid {
+		m.MyRights.MayDelete = false
+		for i, f := range core.SpecialUseFolders {
+			if f.SpecialUse == folder.SpecialUse.String {
+				m.SortOrder = i + 1
+			}
+		}
+		if isDefault {
+			// RFC 6154 attributes and JMAP roles share their names
+			role := strings.ToLower(strings.TrimPrefix(folder.SpecialUse.String, `\`))
+			m.Role = &role
+		}
+	}
+	return m
+}
+
+// folderID returns the folder ID of the mailbox, empty for an inbox
+func (m *mailbox) folderID() string {
+	if m.folder == nil {
+		return ""
+	}
+	return m.folder.ID
+}
+
+func (r *call) mailboxGet(args json.RawMessage) (interface{}, error) {
+	var a struct {
+		AccountID  string    `json:"accountId"`
+		IDs        *[]string `json:"ids"`
+		Properties []string  `json:"properties"`
+	}
+	if err := unmarshalArgs(args, &a); err != nil {
+		return nil, err
+	}
+	acct, err := r.account(a.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []interface{}{}
+	notFound := []string{}
+	var mailboxes []*mailbox
+	if a.IDs == nil {
+		mailboxes = acct.mailboxes
+	} else {
+		if len(*a.IDs) > maxObjectsInGet {
+			return nil, errRequestTooLarge
+		}
+		for _, id := range *a.IDs {
+			resolved, _ := r.resolveID(id)
+			if m, ok := acct.byID[resolved]; ok {
+				mailboxes = append(mailboxes, m)
+			} else {
+				notFound = append(notFound, id)
+			}
+		}
+	}
+
+	for _, m := range mailboxes {
+		object, err := filterProperties(m, a.Properties)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, object)
+	}
+
+	return map[string]interface{}{
+		"accountId": a.AccountID,
+		"state":     acct.mailboxState(),
+		"list":      list,
+		"notFound":  notFound,
+	}, nil
+}
+
+// mailboxChanges implements Mailbox/changes. Mailbox changes are not tracked,
+// so clients that are not up to date have to fetch all mailboxes again.
+func (r *call) mailboxChanges(args json.RawMessage) (interface{}, error) {
+	var a struct {
+		AccountID  string `json:"accountId"`
+		SinceState string `json:"sinceState"`
+	}
+	if err := unmarshalArgs(args, &a); err != nil {
+		return nil, err
+	}
+	acct, err := r.account(a.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := acct.mailboxState()
+	if a.SinceState != state {
+		return nil, errCannotCalculateChanges
+	}
+	return map[string]interface{}{
+		"accountId":         a.AccountID,
+		"oldState":          state,
+		"newState":          state,
+		"hasMoreChanges":    false,
+		"created":           []string{},
+		"updated":           []string{},
+		"destroyed":         []string{},
+		"updatedProperties": nil,
+	}, nil
+}
+
+func (r *call) mailboxQuery(args json.RawMessage) (interface{}, error) {
+	var a struct {
+		AccountID      string          `json:"accountId"`
+		Filter         json.RawMessage `json:"filter"`
+		Sort           []comparator    `json:"sort"`
+		Position       int             `json:"position"`
+		Anchor         *string         `json:"anchor"`
+		AnchorOffset   int             `json:"anchorOffset"`
+		Limit          *int            `json:"limit"`
+		CalculateTotal bool            `json:"calculateTotal"`
+	}
+	if err := unmarshalArgs(args, &a); err != nil {
+		return nil, err
+	}
+	acct, err := r.account(a.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*mailbox
+	for _, m := range acct.mailboxes {
+		if len(a.Filter) > 0 && string(a.Filter) != "null" {
+			ok, err := matchFilter(a.Filter, m.matches)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		matches = append(matches, m)
+	}
+
+	for _, c := range a.Sort {
+		if c.Property != "name" && c.Property != "sortOrder" {
+			return nil, &MethodError{Type: "unsupportedSort", Description: "cannot sort by " + c.Property}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		for _, c := range a.Sort {
+			var cmp int
+			if c.Property == "name" {
+				cmp = strings.Compare(strings.ToLower(matches[i].Name), strings.ToLower(matches[j].Name))
+			} else {
+				cmp = matches[i].SortOrder - matches[j].SortOrder
+			}
+			if cmp != 0 {
+				return cmp < 0 == c.ascending()
+			}
+		}
+		return false
+	})
+
+	ids := make([]string, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.ID)
+	}
+	window, position, err := queryWindow(ids, a.Position, a.Anchor, a.AnchorOffset, a.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"accountId":           a.AccountID,
+		"queryState":          acct.mailboxState(),
+		"canCalculateChanges": false,
+		"position":            position,
+		"ids":                 window,
+	}
+	if a.CalculateTotal {
+		result["total"] = len(ids)
+	}
+	return result, nil
+}
+
+// matches evaluates a Mailbox FilterCondition (RFC 8621 section 2.3)
+func (m *mailbox) matches(condition json.RawMessage) (bool, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(condition, &fields); err != nil {
+		return false, &MethodError{Type: "unsupportedFilter", Description: err.Error()}
+	}
+	var c struct {
+		ParentID     *string `json:"parentId"`
+		Name         *string `json:"name"`
+		Role         *string `json:"role"`
+		HasAnyRole   *bool   `json:"hasAnyRole"`
+		IsSubscribed *bool   `json:"isSubscribed"`
+	}
+	if err := json.Unmarshal(condition, &c); err != nil {
+		return false, &MethodError{Type: "unsupportedFilter", Description: err.Error()}
+	}
+
+	for key := range fields {
+		switch key {
+		case "parentId":
+			parent := ""
+			if m.ParentID != nil {
+				parent = *m.ParentID
+			}
+			if c.ParentID == nil && parent != "" || c.ParentID != nil && *c.ParentID != parent {
+				return false, nil
+			}
+		case "name":
+			if c.Name == nil || !strings.Contains(strings.ToLower(m.Name), strings.ToLower(*c.Name)) {
+				return false, nil
+			}
+		case "role":
+			if (c.Role == nil) != (m.Role == nil) || c.Role != nil && *c.Role != *m.Role {
+				return false, nil
+			}
+		case "hasAnyRole":
+			if c.HasAnyRole != nil && *c.HasAnyRole != (m.Role != nil) {
+				return false, nil
+			}
+		case "isSubscribed":
+			if c.IsSubscribed != nil && *c.IsSubscribed != m.IsSubscribed {
+				return false, nil
+			}
+		default:
+			return false, &MethodError{Type: "unsupportedFilter", Description: "unknown condition " + key}
+		}
+	}
+	return true, nil
+}
+
+// setResponse is the response of the /set methods (RFC 8620 section 5.3)
+type setResponse struct {
+	AccountID    string                 `json:"accountId"`
+	OldState     string                 `json:"oldState"`
+	NewState     string                 `json:"newState"`
+	Created      map[string]interface{} `json:"created"`
+	Updated      map[string]interface{} `json:"updated"`
+	Destroyed    []string               `json:"destroyed"`
+	NotCreated   map[string]*SetError   `json:"notCreated"`
+	NotUpdated   map[string]*SetError   `json:"notUpdated"`
+	NotDestroyed map[string]*SetError   `json:"notDestroyed"`
+}
+
+func newSetResponse(accountID, oldState string) *setResponse {
+	return &setResponse{
+		AccountID:    accountID,
+		OldState:     oldState,
+		Created:      map[string]interface{}{},
+		Updated:      map[string]interface{}{},
+		Destroyed:    []string{},
+		NotCreated:   map[string]*SetError{},
+		NotUpdated:   map[string]*SetError{},
+		NotDestroyed: map[string]*SetError{},
+	}
+}
+
+// setArgs are the arguments of the /set methods
+type setArgs struct {
+	AccountID string                                `json:"accountId"`
+	IfInState *string                               `json:"ifInState"`
+	Create    map[string]json.RawMessage            `json:"create"`
+	Update    map[string]map[string]json.RawMessage `json:"update"`
+	Destroy   []string                              `json:"destroy"`
+}
+
+func (a *setArgs) size() int {
+	return len(a.Create) + len(a.Update) + len(a.Destroy)
+}
+
+// setError converts an error of the services to a SetError. Errors that are not
+// about the object itself are returned as they are.
+func setError(err error, properties ...string) (*SetError, error) {
+	var apiErr *core.APIError
+	switch {
+	case errors.Is(err, core.ErrNotFound):
+		return errSetNotFound, nil
+	case errors.Is(err, core.ErrQuotaExceeded):
+		return errSetOverQuota, nil
+	case errors.As(err, &apiErr) && apiErr.Code == http.StatusBadRequest:
+		return invalidProperties(apiErr.Message, properties...), nil
+	}
+	return nil, err
+}
+
+func (r *call) mailboxSet(args json.RawMessage) (interface{}, error) {
+	var a struct {
+		setArgs
+		OnDestroyRemoveEmails bool `json:"onDestroyRemoveEmails"`
+	}
+	if err := unmarshalArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if a.size() > maxObjectsInSet {
+		return nil, errRequestTooLarge
+	}
+	acct, err := r.account(a.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	state := acct.mailboxState()
+	if a.IfInState != nil && *a.IfInState != state {
+		return nil, errStateMismatch
+	}
+
+	resp := newSetResponse(a.AccountID, state)
+
+	for creationID, object := range a.Create {
+		m, setErr, err := r.createMailbox(acct, object)
+		if err != nil {
+			return nil, err
+		}
+		if setErr != nil {
+			resp.NotCreated[creationID] = setErr
+			continue
+		}
+		r.created[creationID] = m.ID
+		// The new mailbox must be found by later creations below it
+		acct.add(m)
+		resp.Created[creationID] = map[string]interface{}{
+			"id":            m.ID,
+			"role":          nil,
+			"sortOrder":     m.SortOrder,
+			"totalEmails":   0,
+			"unreadEmails":  0,
+			"totalThreads":  0,
+			"unreadThreads": 0,
+			"myRights":      m.MyRights,
+		}
+	}
+
+	for id, patch := range a.Update {
+		resolved, _ := r.resolveID(id)
+		m, ok := acct.byID[resolved]
+		if !ok {
+			resp.NotUpdated[id] = errSetNotFound
+			continue
+		}
+		setErr, err := r.updateMailbox(acct, m, patch)
+		if err != nil {
+			return nil, err
+		}
+		if setErr != nil {
+			resp.NotUpdated[id] = setErr
+			continue
+		}
+		resp.Updated[id] = nil
+	}
+
+	for _, id := range a.Destroy {
+		resolved, _ := r.resolveID(id)
+		m, ok := acct.byID[resolved]
+		if !ok {
+			resp.NotDestroyed[id] = errSetNotFound
+			continue
+		}
+		setErr, err := r.destroyMailbox(acct, m, a.OnDestroyRemoveEmails)
+		if err != nil {
+			return nil, err
+		}
+		if setErr != nil {
+			resp.NotDestroyed[id] = setErr
+			continue
+		}
+		resp.Destroyed = append(resp.Destroyed, id)
+	}
+
+	r.reloadAccount()
+	if acct, err = r.account(a.AccountID); err != nil {
+		return nil, err
+	}
+	resp.NewState = acct.mailboxState()
+	return resp, nil
+}
+
+// mailboxPatch holds the settable properties of a mailbox
+type mailboxPatch struct {
+	Name         *string `json:"name"`
+	ParentID     *string `json:"parentId"`
+	IsSubscribed *bool   `json:"isSubscribed"`
+}
+
+// decodeMailboxPatch reads the settable properties of a mailbox from a create
+// object or a patch. Other properties are server-set or derived.
+func decodeMailboxPatch(fields map[string]json.RawMessage) (*mailboxPatch, *SetError) {
+	for key, value := range fields {
+		switch key {
+		case "name", "isSubscribed":
+		case "parentId":
+			if string(value) == "null" {
+				return nil, invalidProperties("mailboxes can only be created below an inbox", key)
+			}
+		case "role":
+			if string(value) != "null" {
+				return nil, invalidProperties("roles are derived from special-use folders", key)
+			}
+		case "sortOrder":
+		default:
+			return nil, invalidProperties("property cannot be set", key)
+		}
+	}
+
+	data, _ := json.Marshal(fields)
+	var p mailboxPatch
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, invalidProperties(err.Error())
+	}
+	if p.Name != nil && (*p.Name == "" || strings.Contains(*p.Name, core.FolderDelimiter)) {
+		return nil, invalidProperties("name must not be empty or contain "+core.FolderDelimiter, "name")
+	}
+	return &p, nil
+}
+
+// folderPath returns the path of a folder with the given name below a mailbox
+func folderPath(parent *mailbox, name string) string {
+	if parent.folder == nil {
+		return name
+	}
+	return parent.folder.Name + core.FolderDelimiter + name
+}
+
+func (r *call) createMailbox(acct *account, object json.RawMessage) (*mailbox, *SetError, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(object, &fields); err != nil {
+		return nil, invalidProperties(err.Error()), nil
+	}
+	p, setErr := decodeMailboxPatch(fields)
+	if setErr != nil {
+		return nil, setErr, nil
+	}
+	if p.Name == nil {
+		return nil, invalidProperties("name is required", "name"), nil
+	}
+	if p.ParentID == nil {
+		return nil, invalidProperties("mailboxes can only be created below an inbox", "parentId"), nil
+	}
+	parentID, _ := r.resolveID(*p.ParentID)
+	parent, ok := acct.byID[parentID]
+	if !ok {
+		return nil, invalidProperties("unknown parent mailbox", "parentId"), nil
+	}
+
+	path := folderPath(parent, *p.Name)
+	if _, err := r.h.core.FolderService.GetByName(r.ctx, parent.inbox.ID, path); err == nil {
+		return nil, invalidProperties("a mailbox with this name already exists", "name"), nil
+	}
+
+	folder := &models.Folder{InboxID: parent.inbox.ID, Name: path, Subscribed: true}
+	if p.IsSubscribed != nil {
+		folder.Subscribed = *p.IsSubscribed
+	}
+	if err := r.h.core.FolderService.Create(r.ctx, folder); err != nil {
+		setErr, err := setError(err, "name")
+		return nil, setErr, err
+	}
+	return newFolderMailbox(parent.inbox, folder, parent.ID, false), nil, nil
+}
+
+func (r *call) updateMailbox(acct *account, m *mailbox, patch map[string]json.RawMessage) (*SetError, error) {
+	p, setErr := decodeMailboxPatch(patch)
+	if setErr != nil {
+		return setErr, nil
+	}
+
+	if m.folder == nil {
+		if p.Name != nil && *p.Name != m.Name || p.ParentID != nil || p.IsSubscribed != nil && !*p.IsSubscribed {
+			return &SetError{Type: "forbidden", Description: "inboxes are managed through the REST API"}, nil
+		}
+		return nil, nil
+	}
+
+	folder := *m.folder
+	if p.IsSubscribed != nil {
+		folder.Subscribed = *p.IsSubscribed
+	}
+	if p.Name != nil || p.ParentID != nil {
+		name := m.Name
+		if p.Name != nil {
+			name = *p.Name
+		}
+		parent := acct.byID[*m.ParentID]
+		if p.ParentID != nil {
+			parentID, _ := r.resolveID(*p.ParentID)
+			if parent = acct.byID[parentID]; parent == nil {
+				return invalidProperties("unknown parent mailbox", "parentId"), nil
+			}
+		}
+		if parent.inbox.ID != m.inbox.ID {
+			return invalidProperties("mailboxes cannot move between inboxes", "parentId"), nil
+		}
+		if parent.folder != nil && (parent.folder.ID == folder.ID ||
+			strings.HasPrefix(parent.folder.Name, folder.Name+core.FolderDelimiter)) {
+			return invalidProperties("a mailbox cannot be moved below itself", "parentId"), nil
+		}
+
+		path := folderPath(parent, name)
+		if path != folder.Name {
+			if _, err := r.h.core.FolderService.GetByName(r.ctx, m.inbox.ID, path); err == nil {
+				return invalidProperties("a mailbox with this name already exists", "name"), nil
+			}
+			folder.Name = path
+		}
+	}
+
+	if err := r.h.core.FolderService.Update(r.ctx, &folder); err != nil {
+		return setError(err, "name")
+	}
+	return nil, nil
+}
+
+func (r *call) destroyMailbox(acct *account, m *mailbox, removeEmails bool) (*SetError, error) {
+	if m.folder == nil {
+		return &SetError{Type: "forbidden", Description: "inboxes are managed through the REST API"}, nil
+	}
+	for _, child := range acct.mailboxes {
+		if child.ParentID != nil && *child.ParentID == m.ID {
+			return &SetError{Type: "mailboxHasChild"}, nil
+		}
+	}
+	if m.TotalEmails > 0 && !removeEmails {
+		return &SetError{Type: "mailboxHasEmail"}, nil
+	}
+
+	if err := r.h.core.FolderService.Delete(r.ctx, m.ID); err != nil {
+		var apiErr *core.APIError
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusBadRequest {
+			return &SetError{Type: "forbidden", Description: apiErr.Message}, nil
+		}
+		return setError(err)
+	}
+	return nil, nil
+}
@@ -0,0 +1,262 @@
+package jmap
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/emersion/go-message"
+	_ "github.com/emersion/go-message/charset"
+	"github.com/emersion/go-message/mail"
+)
+
+// previewLength is the maximum length of the preview of an email, in characters
+const previewLength = 256
+
+// bodyPart is an EmailBodyPart (RFC 8621 section 4.1.4) for a leaf part of a message
+type bodyPart struct {
+	PartID      string  `json:"partId"`
+	BlobID      string  `json:"blobId"`
+	Size        int     `json:"size"`
+	Name        *string `json:"name"`
+	Type        string  `json:"type"`
+	Charset     *string `json:"charset"`
+	Disposition *string `json:"disposition"`
+	CID         *string `json:"cid"`
+
+	// content is the decoded content of the part
+	content []byte
+	// encodingProblem is set when the content could not be decoded
+	encodingProblem bool
+}
+
+// parsedEmail is the structure of a raw message as needed for Email/get
+type parsedEmail struct {
+	header      mail.Header
+	parts       []*bodyPart
+	textBody    []*bodyPart
+	htmlBody    []*bodyPart
+	attachments []*bodyPart
+}
+
+// partBlobID returns the blob ID of a body part. Message IDs are UUIDs, so the
+// dot cannot be part of them.
+func partBlobID(messageID, partID string) string {
+	return messageID + "." + partID
+}
+
+// parseEmail parses a raw message. Leaf parts are numbered like IMAP body
+// sections. A simplified RFC 8621 parseStructure sorts them into text and HTML
+// bodies and attachments.
+func parseEmail(messageID string, raw []byte) (*parsedEmail, error) {
+	entity, err := message.Read(bytes.NewReader(raw))
+	if err != nil && !message.IsUnknownCharset(err) {
+		return nil, err
+	}
+
+	p := &parsedEmail{header: mail.Header{Header: entity.Header}}
+	multipartTypes := make(map[string]string)
+	var plain, html []*bodyPart
+
+	err = entity.Walk(func(path []int, part *message.Entity, err error) error {
+		encodingProblem := false
+		if err != nil {
+			if !message.IsUnknownCharset(err) && !message.IsUnknownEncoding(err) {
+				return err
+			}
+			encodingProblem = true
+		}
+
+		mediaType, params, _ := part.Header.ContentType()
+		if mediaType == "" {
+			mediaType = "text/plain"
+		}
+		partID := sectionPath(path)
+		if strings.HasPrefix(mediaType, "multipart/") {
+			multipartTypes[pathKey(path)] = mediaType
+			return nil
+		}
+
+		content, err := io.ReadAll(part.Body)
+		if err != nil {
+			return err
+		}
+
+		bp := &bodyPart{
+			PartID:          partID,
+			BlobID:          partBlobID(messageID, partID),
+			Size:            len(content),
+			Type:            mediaType,
+			content:         content,
+			encodingProblem: encodingProblem,
+		}
+		if charset, ok := params["charset"]; ok {
+			bp.Charset = &charset
+		} else if strings.HasPrefix(mediaType, "text/") {
+			charset := "us-ascii"
+			bp.Charset = &charset
+		}
+		disposition, dispositionParams, _ := part.Header.ContentDisposition()
+		if disposition != "" {
+			bp.Disposition = &disposition
+		}
+		if name := dispositionParams["filename"]; name != "" {
+			bp.Name = &name
+		} else if name := params["name"]; name != "" {
+			bp.Name = &name
+		}
+		if cid := strings.Trim(part.Header.Get("Content-Id"), "<> "); cid != "" {
+			bp.CID = &cid
+		}
+		p.parts = append(p.parts, bp)
+
+		isInline := disposition != "attachment" && (mediaType == "text/plain" || mediaType == "text/html")
+		switch {
+		case !isInline:
+			p.attachments = append(p.attachments, bp)
+		case len(path) > 0 && multipartTypes[pathKey(path[:len(path)-1])] == "multipart/alternative":
+			if mediaType == "text/plain" {
+				plain = append(plain, bp)
+			} else {
+				html = append(html, bp)
+			}
+		default:
+			// Outside of alternatives, a text part is shown in either view
+			plain = append(plain, bp)
+			html = append(html, bp)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.textBody, p.htmlBody = plain, html
+	if len(p.textBody) == 0 {
+		p.textBody = html
+	}
+	if len(p.htmlBody) == 0 {
+		p.htmlBody = plain
+	}
+	return p, nil
+}
+
+// sectionPath returns the IMAP section number of a part from its go-message path.
+// A message that is not multipart has a single part 1.
+func sectionPath(path []int) string {
+	if len(path) == 0 {
+		return "1"
+	}
+	levels := make([]string, len(path))
+	for i, index := range path {
+		levels[i] = strconv.Itoa(index + 1)
+	}
+	return strings.Join(levels, ".")
+}
+
+// pathKey identifies a part by its go-message path, the root being empty
+func pathKey(path []int) string {
+	if len(path) == 0 {
+		return ""
+	}
+	return sectionPath(path)
+}
+
+// part returns the leaf part with the given ID, or nil
+func (p *parsedEmail) part(partID string) *bodyPart {
+	for _, bp := range p.parts {
+		if bp.PartID == partID {
+			return bp
+		}
+	}
+	return nil
+}
+
+var (
+	htmlTags   = regexp.MustCompile(`(?s)<(script|style)[^>]*>.*?</(script|style)>|<[^>]*>`)
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+// preview returns the start of the text of the email (RFC 8621 section 4.1.4)
+func (p *parsedEmail) preview() string {
+	for _, bp := range p.textBody {
+		text := string(bp.content)
+		if bp.Type == "text/html" {
+			text = htmlTags.ReplaceAllString(text, " ")
+		}
+		text = strings.TrimSpace(whitespace.ReplaceAllString(text, " "))
+		if text == "" {
+			continue
+		}
+		if utf8.RuneCountInString(text) > previewLength {
+			text = string([]rune(text)[:previewLength])
+		}
+		return text
+	}
+	return ""
+}
+
+// bodyValue is an EmailBodyValue (RFC 8621 section 4.1.4)
+type bodyValue struct {
+	Value             string `json:"value"`
+	IsEncodingProblem bool   `json:"isEncodingProblem"`
+	IsTruncated       bool   `json:"isTruncated"`
+}
+
+func (bp *bodyPart) value(maxBytes int) bodyValue {
+	content := bp.content
+	truncated := false
+	if maxBytes > 0 && len(content) > maxBytes {
+		// Do not cut a character in half
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		content = content[:cut]
+		truncated = true
+	}
+	return bodyValue{
+		Value:             strings.ToValidUTF8(string(content), "�"),
+		IsEncodingProblem: bp.encodingProblem || !utf8.Valid(bp.content),
+		IsTruncated:       truncated,
+	}
+}
+
+// emailAddress is an EmailAddress (RFC 8621 section 4.1.2.3)
+type emailAddress struct {
+	Name  *string `json:"name"`
+	Email string  `json:"email"`
+}
+
+// addresses returns the addresses of a header field, or nil when it is missing
+func (p *parsedEmail) addresses(field string) []emailAddress {
+	if !p.header.Has(field) {
+		return nil
+	}
+	list, _ := p.header.AddressList(field)
+	result := make([]emailAddress, 0, len(list))
+	for _, address := range list {
+		ea := emailAddress{Email: address.Address}
+		if address.Name != "" {
+			name := address.Name
+			ea.Name = &name
+		}
+		result = append(result, ea)
+	}
+	return result
+}
+
+// messageIDs returns the message IDs of a header field, or nil when it is missing
+func (p *parsedEmail) messageIDs(field string) []string {
+	if !p.header.Has(field) {
+		return nil
+	}
+	ids, err := p.header.MsgIDList(field)
+	if err != nil || len(ids) == 0 {
+		return nil
+	}
+	return ids
+}
@@ -0,0 +1,349 @@
+package jmap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Capabilities supported by the server
+const (
+	CapabilityCore = "urn:ietf:params:jmap:core"
+	CapabilityMail = "urn:ietf:params:jmap:mail"
+)
+
+// Limits advertised in the core capability (RFC 8620 section 2)
+const (
+	maxSizeRequest        = 10 << 20
+	maxConcurrentRequests = 4
+	maxCallsInRequest     = 32
+	maxObjectsInGet       = 500
+	maxObjectsInSet       = 500
+)
+
+// Request is a JMAP API request (RFC 8620 section 3.3)
+type Request struct {
+	Using       []string          `json:"using"`
+	MethodCalls []Invocation      `json:"methodCalls"`
+	CreatedIDs  map[string]string `json:"createdIds,omitempty"`
+}
+
+// Response is a JMAP API response (RFC 8620 section 3.4)
+type Response struct {
+	MethodResponses []Invocation      `json:"methodResponses"`
+	CreatedIDs      map[string]string `json:"createdIds,omitempty"`
+	SessionState    string            `json:"sessionState"`
+}
+
+// Invocation is a method call or response, sent as a [name, arguments, call ID] array
+type Invocation struct {
+	Name   string
+	Args   json.RawMessage
+	CallID string
+}
+
+func (inv *Invocation) UnmarshalJSON(data []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) != 3 {
+		return errors.New("invocation must have 3 elements")
+	}
+	if err := json.Unmarshal(fields[0], &inv.Name); err != nil {
+		return err
+	}
+	if len(fields[1]) == 0 || fields[1][0] != '{' {
+		return errors.New("invocation arguments must be an object")
+	}
+	inv.Args = fields[1]
+	return json.Unmarshal(fields[2], &inv.CallID)
+}
+
+func (inv Invocation) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{inv.Name, inv.Args, inv.CallID})
+}
+
+// MethodError is returned in place of a method response (RFC 8620 section 3.6.2)
+type MethodError struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+func (e *MethodError) Error() string {
+	if e.Description == "" {
+		return e.Type
+	}
+	return e.Type + ": " + e.Description
+}
+
+func invalidArguments(format string, args ...interface{}) *MethodError {
+	return &MethodError{Type: "invalidArguments", Description: fmt.Sprintf(format, args...)}
+}
+
+var (
+	errAccountNotFound        = &MethodError{Type: "accountNotFound"}
+	errUnknownMethod          = &MethodError{Type: "unknownMethod"}
+	errCannotCalculateChanges = &MethodError{Type: "cannotCalculateChanges"}
+	errStateMismatch          = &MethodError{Type: "stateMismatch"}
+	errRequestTooLarge        = &MethodError{Type: "requestTooLarge"}
+	errServerFail             = &MethodError{Type: "serverFail"}
+)
+
+// SetError describes why a single object could not be created, updated or
+// destroyed (RFC 8620 section 5.3)
+type SetError struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Properties  []string `json:"properties,omitempty"`
+}
+
+func (e *SetError) Error() string {
+	return e.Type + ": " + e.Description
+}
+
+func invalidProperties(description string, properties ...string) *SetError {
+	return &SetError{Type: "invalidProperties", Description: description, Properties: properties}
+}
+
+var (
+	errSetNotFound  = &SetError{Type: "notFound"}
+	errSetForbidden = &SetError{Type: "forbidden"}
+	errSetOverQuota = &SetError{Type: "overQuota"}
+)
+
+// problem is a request-level error as RFC 7807 problem details
+type problem struct {
+	Type   string `json:"type"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Limit  string `json:"limit,omitempty"`
+}
+
+// resultReference points to a value in the response of an earlier call
+// (RFC 8620 section 3.7)
+type resultReference struct {
+	ResultOf string `json:"resultOf"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+}
+
+// resolveReferences replaces the arguments whose name starts with # by the values
+// they reference in the responses so far
+func resolveReferences(args json.RawMessage, responses []Invocation) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(args, &fields); err != nil {
+		return nil, err
+	}
+
+	resolved := false
+	for key, value := range fields {
+		name, ok := strings.CutPrefix(key, "#")
+		if !ok {
+			continue
+		}
+		if _, clash := fields[name]; clash {
+			return nil, invalidArguments("both %s and #%s are set", name, name)
+		}
+
+		var ref resultReference
+		if err := json.Unmarshal(value, &ref); err != nil {
+			return nil, &MethodError{Type: "invalidResultReference", Description: err.Error()}
+		}
+		result, err := ref.evaluate(responses)
+		if err != nil {
+			return nil, &MethodError{Type: "invalidResultReference", Description: err.Error()}
+		}
+
+		delete(fields, key)
+		fields[name] = result
+		resolved = true
+	}
+
+	if !resolved {
+		return args, nil
+	}
+	return json.Marshal(fields)
+}
+
+func (ref *resultReference) evaluate(responses []Invocation) (json.RawMessage, error) {
+	for _, response := range responses {
+		if response.CallID != ref.ResultOf {
+			continue
+		}
+		if response.Name != ref.Name {
+			return nil, fmt.Errorf("call %s is a %s response", ref.ResultOf, response.Name)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(response.Args, &value); err != nil {
+			return nil, err
+		}
+		result, err := evaluatePointer(value, ref.Path)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(result)
+	}
+	return nil, fmt.Errorf("no response for call %s", ref.ResultOf)
+}
+
+// evaluatePointer evaluates a JSON pointer with the JMAP * extension, which maps
+// the rest of the pointer over an array and flattens the results
+func evaluatePointer(value interface{}, path string) (interface{}, error) {
+	if path == "" {
+		return value, nil
+	}
+	rest, ok := strings.CutPrefix(path, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid path %q", path)
+	}
+
+	token, next, hasNext := strings.Cut(rest, "/")
+	if hasNext {
+		next = "/" + next
+	}
+	token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		child, ok := v[token]
+		if !ok {
+			return nil, fmt.Errorf("no %q in result", token)
+		}
+		return evaluatePointer(child, next)
+	case []interface{}:
+		if token == "*" {
+			results := []interface{}{}
+			for _, item := range v {
+				result, err := evaluatePointer(item, next)
+				if err != nil {
+					return nil, err
+				}
+				if list, ok := result.([]interface{}); ok {
+					results = append(results, list...)
+				} else {
+					results = append(results, result)
+				}
+			}
+			return results, nil
+		}
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i >= len(v) {
+			return nil, fmt.Errorf("invalid index %q", token)
+		}
+		return evaluatePointer(v[i], next)
+	default:
+		return nil, fmt.Errorf("cannot evaluate %q on a scalar", token)
+	}
+}
+
+// filterProperties returns the JSON object of v restricted to the given
+// properties, or all of them when properties is nil. The id is always included.
+func filterProperties(v interface{}, properties []string) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	if properties == nil {
+		return object, nil
+	}
+
+	filtered := map[string]interface{}{"id": object["id"]}
+	for _, property := range properties {
+		if value, ok := object[property]; ok {
+			filtered[property] = value
+		}
+	}
+	return filtered, nil
+}
+
+// matchFilter evaluates a FilterOperator or FilterCondition (RFC 8620 section 5.5),
+// using condition for the latter
+func matchFilter(filter json.RawMessage, condition func(json.RawMessage) (bool, error)) (bool, error) {
+	var operator struct {
+		Operator   *string           `json:"operator"`
+		Conditions []json.RawMessage `json:"conditions"`
+	}
+	if err := json.Unmarshal(filter, &operator); err != nil {
+		return false, &MethodError{Type: "unsupportedFilter", Description: err.Error()}
+	}
+	if operator.Operator == nil {
+		return condition(filter)
+	}
+
+	switch *operator.Operator {
+	case "AND", "OR", "NOT":
+	default:
+		return false, &MethodError{Type: "unsupportedFilter", Description: "unknown operator " + *operator.Operator}
+	}
+
+	matched := 0
+	for _, c := range operator.Conditions {
+		ok, err := matchFilter(c, condition)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			matched++
+		}
+	}
+
+	switch *operator.Operator {
+	case "AND":
+		return matched == len(operator.Conditions), nil
+	case "OR":
+		return matched > 0, nil
+	default:
+		return matched == 0, nil
+	}
+}
+
+// comparator is an element of the sort argument of /query methods
+type comparator struct {
+	Property    string `json:"property"`
+	IsAscending *bool  `json:"isAscending"`
+	Collation   string `json:"collation"`
+}
+
+func (c comparator) ascending() bool {
+	return c.IsAscending == nil || *c.IsAscending
+}
+
+// queryWindow applies position, anchor and limit to the full query result
+// (RFC 8620 section 5.5) and returns the window and its position
+func queryWindow(ids []string, position int, anchor *string, anchorOffset int, limit *int) ([]string, int, error) {
+	if anchor != nil {
+		index := -1
+		for i, id := range ids {
+			if id == *anchor {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, 0, &MethodError{Type: "anchorNotFound"}
+		}
+		position = max(index+anchorOffset, 0)
+	} else if position < 0 {
+		position = max(len(ids)+position, 0)
+	}
+
+	if position > len(ids) {
+		position = len(ids)
+	}
+	end := len(ids)
+	if limit != nil {
+		if *limit < 0 {
+			return nil, 0, invalidArguments("limit must not be negative")
+		}
+		end = min(position+*limit, len(ids))
+	}
+	return ids[position:end], position, nil
+}
@@ -0,0 +1,137 @@
+package jmap
+
+import (
+	"encoding/json"
+
+	"inbox451/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// thread is a JMAP Thread (RFC 8621 section 3). Its ID is the ID of the first
+// message of the thread, its emails are sorted by the date they were received.
+type thread struct {
+	ID       string   `json:"id"`
+	EmailIDs []string `json:"emailIds"`
+}
+
+// threadMessages returns the messages of the account in each of the given threads
+func (r *call) threadMessages(acct *account, threadIDs []string) (map[string][]*models.Message, error) {
+	valid := make([]string, 0, len(threadIDs))
+	for _, id := range threadIDs {
+		// Thread IDs are UUIDs, anything else cannot be found
+		if uuid.Validate(id) == nil {
+			valid = append(valid, id)
+		}
+	}
+	messages, err := r.h.core.ThreadService.ListMessages(r.ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+
+	byThread := make(map[string][]*models.Message)
+	for _, message := range messages {
+		if acct.owns(message) {
+			byThread[message.ThreadID] = append(byThread[message.ThreadID], message)
+		}
+	}
+	return byThread, nil
+}
+
+func (r *call) threadGet(args json.RawMessage) (interface{}, error) {
+	var a struct {
+		AccountID  string    `json:"accountId"`
+		IDs        *[]string `json:"ids"`
+		Properties []string  `json:"properties"`
+	}
+	if err := unmarshalArgs(args, &a); err != nil {
+		return nil, err
+	}
+	acct, err := r.account(a.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if a.IDs == nil {
+		return nil, invalidArguments("ids is required")
+	}
+	if len(*a.IDs) > maxObjectsInGet {
+		return nil, errRequestTooLarge
+	}
+
+	byThread, err := r.threadMessages(acct, *a.IDs)
+	if err != nil {
+		return nil, err
+	}
+	state, err := emailState(r.ctx, r.h.core, acct)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []interface{}{}
+	notFound := []string{}
+	for _, id := range *a.IDs {
+		messages, ok := byThread[id]
+		if !ok {
+			notFound = append(notFound, id)
+			continue
+		}
+		t := thread{ID: id, EmailIDs: make([]string, 0, len(messages))}
+		for _, message := range messages {
+			t.EmailIDs = append(t.EmailIDs, message.ID)
+		}
+		object, err := filterProperties(t, a.Properties)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, object)
+	}
+	return map[string]interface{}{
+		"accountId": a.AccountID,
+		"state":     state,
+		"list":      list,
+		"notFound":  notFound,
+	}, nil
+}
+
+// threadChanges implements Thread/changes from the changes of the messages. A
+// thread is created with its first message and destroyed with its last one, any
+// other message change updates it.
+func (r *call) threadChanges(args json.RawMessage) (interface{}, error) {
+	resp, acct, changes, err := r.messageChanges(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var threadIDs []string
+	created := make(map[string]bool)
+	changed := make(map[string]bool)
+	for _, change := range changes {
+		if change.ThreadID == "" || changed[change.ThreadID] {
+			continue
+		}
+		changed[change.ThreadID] = true
+		threadIDs = append(threadIDs, change.ThreadID)
+		if change.Created && change.MessageID == change.ThreadID {
+			created[change.ThreadID] = true
+		}
+	}
+
+	remaining, err := r.threadMessages(acct, threadIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range threadIDs {
+		_, exists := remaining[id]
+		switch {
+		case created[id] && !exists:
+			// Created and destroyed since the old state
+		case created[id]:
+			resp.Created = append(resp.Created, id)
+		case !exists:
+			resp.Destroyed = append(resp.Destroyed, id)
+		default:
+			resp.Updated = append(resp.Updated, id)
+		}
+	}
+	return resp, nil
+}
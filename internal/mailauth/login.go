@@ -0,0 +1,55 @@
+// Package mailauth implements the SASL mechanisms mail clients expect that
+// go-sasl only provides a client for. They are shared by the MSA and IMAP.
+package mailauth
+
+import (
+	"github.com/emersion/go-sasl"
+)
+
+// LoginAuthenticator checks the credentials sent with the LOGIN mechanism
+type LoginAuthenticator func(username, password string) error
+
+const (
+	loginStart = iota
+	loginUsername
+	loginPassword
+	loginDone
+)
+
+var (
+	usernameChallenge = []byte("Username:")
+	passwordChallenge = []byte("Password:")
+)
+
+type loginServer struct {
+	state        int
+	username     string
+	authenticate LoginAuthenticator
+}
+
+// NewLoginServer returns a server for the obsolete LOGIN mechanism
+// (draft-murchison-sasl-login), which Outlook still insists on. The username
+// may come as the initial response.
+func NewLoginServer(authenticator LoginAuthenticator) sasl.Server {
+	return &loginServer{authenticate: authenticator}
+}
+
+func (a *loginServer) Next(response []byte) ([]byte, bool, error) {
+	switch a.state {
+	case loginStart:
+		if response == nil {
+			a.state = loginUsername
+			return usernameChallenge, false, nil
+		}
+		fallthrough
+	case loginUsername:
+		a.username = string(response)
+		a.state = loginPassword
+		return passwordChallenge, false, nil
+	case loginPassword:
+		a.state = loginDone
+		return nil, true, a.authenticate(a.username, string(response))
+	default:
+		return nil, true, sasl.ErrUnexpectedClientResponse
+	}
+}
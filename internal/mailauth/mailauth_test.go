@@ -0,0 +1,125 @@
+package mailauth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/emersion/go-sasl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginServer(t *testing.T) {
+	var gotUsername, gotPassword string
+	authenticate := func(username, password string) error {
+		gotUsername, gotPassword = username, password
+		return nil
+	}
+
+	t.Run("challenges for the username", func(t *testing.T) {
+		s := NewLoginServer(authenticate)
+
+		challenge, done, err := s.Next(nil)
+		require.NoError(t, err)
+		assert.False(t, done)
+		assert.Equal(t, "Username:", string(challenge))
+
+		challenge, done, err = s.Next([]byte("alice"))
+		require.NoError(t, err)
+		assert.False(t, done)
+		assert.Equal(t, "Password:", string(challenge))
+
+		_, done, err = s.Next([]byte("secret"))
+		require.NoError(t, err)
+		assert.True(t, done)
+		assert.Equal(t, "alice", gotUsername)
+		assert.Equal(t, "secret", gotPassword)
+	})
+
+	t.Run("username as initial response", func(t *testing.T) {
+		client := sasl.NewLoginClient("bob", "hunter2")
+		_, ir, err := client.Start()
+		require.NoError(t, err)
+
+		s := NewLoginServer(authenticate)
+		challenge, done, err := s.Next(ir)
+		require.NoError(t, err)
+		assert.False(t, done)
+
+		response, err := client.Next(challenge)
+		require.NoError(t, err)
+		_, done, err = s.Next(response)
+		require.NoError(t, err)
+		assert.True(t, done)
+		assert.Equal(t, "bob", gotUsername)
+		assert.Equal(t, "hunter2", gotPassword)
+	})
+
+	t.Run("authentication error", func(t *testing.T) {
+		s := NewLoginServer(func(username, password string) error { return errors.New("invalid credentials") })
+		_, _, err := s.Next([]byte("alice"))
+		require.NoError(t, err)
+		_, done, err := s.Next([]byte("wrong"))
+		assert.True(t, done)
+		assert.EqualError(t, err, "invalid credentials")
+	})
+}
+
+func TestXOAuth2Server(t *testing.T) {
+	response := []byte("user=alice@example.com\x01auth=Bearer token123\x01\x01")
+
+	t.Run("valid token", func(t *testing.T) {
+		var gotUsername, gotToken string
+		s := NewXOAuth2Server(func(username, token string) error {
+			gotUsername, gotToken = username, token
+			return nil
+		})
+
+		challenge, done, err := s.Next(nil)
+		require.NoError(t, err)
+		assert.False(t, done)
+		assert.Empty(t, challenge)
+
+		_, done, err = s.Next(response)
+		require.NoError(t, err)
+		assert.True(t, done)
+		assert.Equal(t, "alice@example.com", gotUsername)
+		assert.Equal(t, "token123", gotToken)
+	})
+
+	t.Run("rejected token", func(t *testing.T) {
+		s := NewXOAuth2Server(func(username, token string) error {
+			return &XOAuth2Error{Status: "401", Schemes: "bearer"}
+		})
+
+		challenge, done, err := s.Next(response)
+		require.NoError(t, err)
+		assert.False(t, done)
+		assert.JSONEq(t, `{"status":"401","schemes":"bearer"}`, string(challenge))
+
+		_, done, err = s.Next([]byte{})
+		assert.True(t, done)
+		var authErr *XOAuth2Error
+		assert.ErrorAs(t, err, &authErr)
+	})
+
+	t.Run("other errors end the exchange", func(t *testing.T) {
+		s := NewXOAuth2Server(func(username, token string) error { return errors.New("database down") })
+		_, done, err := s.Next(response)
+		assert.True(t, done)
+		assert.EqualError(t, err, "database down")
+	})
+
+	t.Run("malformed response", func(t *testing.T) {
+		for _, response := range []string{
+			"user=alice@example.com\x01\x01",
+			"user=alice@example.com\x01auth=Basic abc\x01\x01",
+			"garbage",
+		} {
+			s := NewXOAuth2Server(func(username, token string) error { return nil })
+			_, done, err := s.Next([]byte(response))
+			assert.True(t, done, response)
+			assert.Error(t, err, response)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package mailauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/emersion/go-sasl"
+)
+
+// XOAuth2 is the name of Google's XOAUTH2 mechanism, which predates
+// OAUTHBEARER and is still what most clients send
+const XOAuth2 = "XOAUTH2"
+
+// XOAuth2Authenticator checks a bearer token sent with XOAUTH2
+type XOAuth2Authenticator func(username, token string) error
+
+// XOAuth2Error is sent to the client as a JSON challenge when authentication
+// fails, as described by Google
+type XOAuth2Error struct {
+	Status  string `json:"status"`
+	Schemes string `json:"schemes"`
+	Scope   string `json:"scope,omitempty"`
+}
+
+func (e *XOAuth2Error) Error() string {
+	return "XOAUTH2 authentication error (" + e.Status + ")"
+}
+
+var errInvalidXOAuth2 = errors.New("sasl: invalid XOAUTH2 response")
+
+type xoauth2Server struct {
+	done         bool
+	failErr      error
+	authenticate XOAuth2Authenticator
+}
+
+// NewXOAuth2Server returns a server for the XOAUTH2 mechanism. The client
+// response has the form "user=<user>^Aauth=Bearer <token>^A^A".
+func NewXOAuth2Server(authenticator XOAuth2Authenticator) sasl.Server {
+	return &xoauth2Server{authenticate: authenticator}
+}
+
+func (a *xoauth2Server) Next(response []byte) ([]byte, bool, error) {
+	// The client acknowledges the error challenge with an empty response
+	if a.failErr != nil {
+		return nil, true, a.failErr
+	}
+	if a.done {
+		return nil, true, sasl.ErrUnexpectedClientResponse
+	}
+
+	// Ask for the credentials when there was no initial response
+	if response == nil {
+		return []byte{}, false, nil
+	}
+	a.done = true
+
+	var username, token string
+	for _, field := range bytes.Split(response, []byte{0x01}) {
+		if len(field) == 0 {
+			continue
+		}
+		key, value, ok := strings.Cut(string(field), "=")
+		if !ok {
+			return nil, true, errInvalidXOAuth2
+		}
+		switch key {
+		case "user":
+			username = value
+		case "auth":
+			scheme, credentials, ok := strings.Cut(value, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") {
+				return nil, true, errInvalidXOAuth2
+			}
+			token = credentials
+		}
+	}
+	if token == "" {
+		return nil, true, errInvalidXOAuth2
+	}
+
+	if err := a.authenticate(username, token); err != nil {
+		var authErr *XOAuth2Error
+		if !errors.As(err, &authErr) {
+			return nil, true, err
+		}
+		challenge, err := json.Marshal(authErr)
+		if err != nil {
+			return nil, true, err
+		}
+		a.failErr = authErr
+		return challenge, false, nil
+	}
+	return nil, true, nil
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -10,6 +11,11 @@ import (
 func TimeoutMiddleware(timeout time.Duration) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			// Event streams stay open for as long as the client listens
+			if strings.Contains(c.Request().Header.Get(echo.HeaderAccept), "text/event-stream") {
+				return next(c)
+			}
+
 			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
 			defer cancel()
 
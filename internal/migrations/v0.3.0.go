@@ -0,0 +1,309 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"inbox451/internal/config"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func V0_3_0(db *sqlx.DB, config *config.Config, log *log.Logger) error {
+	log.Print("Running migration v0.3.0")
+
+	schema := []string{
+		// Project-level labels that can be assigned to messages
+		`CREATE TABLE IF NOT EXISTS labels (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			name VARCHAR(100) NOT NULL CHECK (LENGTH(name) >= 1),
+			color VARCHAR(7) NOT NULL DEFAULT '#808080',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(project_id, name)
+		)`,
+
+		// Many-to-many relationship between messages and labels
+		`CREATE TABLE IF NOT EXISTS message_labels (
+			message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+			label_id UUID NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (message_id, label_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_labels_label_id ON message_labels (label_id)`,
+
+		// Remaining IMAP system flags
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS is_flagged BOOLEAN NOT NULL DEFAULT false`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS is_answered BOOLEAN NOT NULL DEFAULT false`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS is_draft BOOLEAN NOT NULL DEFAULT false`,
+
+		// Folders inside an inbox, named by their full path using "/" as delimiter.
+		// Each folder has its own UID sequence and UIDVALIDITY.
+		`CREATE TABLE IF NOT EXISTS folders (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			inbox_id UUID NOT NULL REFERENCES inboxes(id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL CHECK (LENGTH(name) >= 1),
+			uid_validity BIGINT NOT NULL DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP)::BIGINT,
+			uid_next BIGINT NOT NULL DEFAULT 1,
+			subscribed BOOLEAN NOT NULL DEFAULT true,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(inbox_id, name)
+		)`,
+
+		// Messages without a folder are in the inbox itself
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS folder_id UUID REFERENCES folders(id) ON DELETE CASCADE`,
+
+		// UIDs are unique per folder rather than per inbox
+		`DROP INDEX IF EXISTS idx_messages_inbox_uid`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_inbox_folder_uid ON messages (inbox_id, COALESCE(folder_id, '00000000-0000-0000-0000-000000000000'::UUID), uid)`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_folder_id ON messages (folder_id)`,
+
+		// RFC 6154 special-use folders, at most one of each kind per inbox
+		`ALTER TABLE folders ADD COLUMN IF NOT EXISTS special_use VARCHAR(16)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_folders_inbox_special_use ON folders (inbox_id, special_use) WHERE special_use IS NOT NULL`,
+		`UPDATE folders f
+		SET special_use = su.special_use
+		FROM (VALUES ('Sent', '\Sent'), ('Trash', '\Trash'), ('Junk', '\Junk'), ('Archive', '\Archive')) AS su(name, special_use)
+		WHERE f.name = su.name AND f.special_use IS NULL
+		AND NOT EXISTS (SELECT 1 FROM folders o WHERE o.inbox_id = f.inbox_id AND o.special_use = su.special_use)`,
+		`INSERT INTO folders (inbox_id, name, special_use)
+		SELECT i.id, su.name, su.special_use
+		FROM inboxes i
+		CROSS JOIN (VALUES ('Sent', '\Sent'), ('Trash', '\Trash'), ('Junk', '\Junk'), ('Archive', '\Archive')) AS su(name, special_use)
+		ON CONFLICT DO NOTHING`,
+
+		// Messages as received over SMTP or IMAP APPEND
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS raw BYTEA`,
+
+		// Per-inbox UIDVALIDITY and UID sequence. Existing inboxes keep the UIDVALIDITY
+		// clients already saw, which was derived from the inbox creation time.
+		`ALTER TABLE inboxes ADD COLUMN IF NOT EXISTS uid_validity BIGINT`,
+		`UPDATE inboxes SET uid_validity = EXTRACT(EPOCH FROM COALESCE(created_at, CURRENT_TIMESTAMP))::BIGINT WHERE uid_validity IS NULL`,
+		`ALTER TABLE inboxes ALTER COLUMN uid_validity SET DEFAULT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP)::BIGINT`,
+		`ALTER TABLE inboxes ALTER COLUMN uid_validity SET NOT NULL`,
+		`ALTER TABLE inboxes ADD COLUMN IF NOT EXISTS uid_next BIGINT NOT NULL DEFAULT 1`,
+		`UPDATE inboxes i SET uid_next = m.max_uid + 1
+		FROM (SELECT inbox_id, MAX(uid) AS max_uid FROM messages WHERE folder_id IS NULL GROUP BY inbox_id) m
+		WHERE m.inbox_id = i.id AND i.uid_next <= m.max_uid`,
+		`ALTER TABLE messages ALTER COLUMN uid DROP DEFAULT`,
+
+		// CONDSTORE mod-sequences, and the UIDs of removed messages for QRESYNC
+		`CREATE SEQUENCE IF NOT EXISTS messages_modseq_seq`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS modseq BIGINT NOT NULL DEFAULT nextval('messages_modseq_seq')`,
+		`CREATE TABLE IF NOT EXISTS expunged_messages (
+			inbox_id UUID NOT NULL REFERENCES inboxes(id) ON DELETE CASCADE,
+			folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+			uid INTEGER NOT NULL,
+			modseq BIGINT NOT NULL DEFAULT nextval('messages_modseq_seq'),
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_expunged_messages_mailbox ON expunged_messages (inbox_id, folder_id, modseq)`,
+
+		// The inbox IMAP clients see as INBOX
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS default_inbox_id UUID REFERENCES inboxes(id) ON DELETE SET NULL`,
+
+		// JMAP Email/changes tells created from updated messages, and needs the
+		// IDs of removed messages
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS created_modseq BIGINT`,
+		`UPDATE messages SET created_modseq = modseq WHERE created_modseq IS NULL`,
+		`CREATE OR REPLACE FUNCTION messages_set_created_modseq() RETURNS trigger AS $$
+		BEGIN
+			NEW.created_modseq := NEW.modseq;
+			RETURN NEW;
+		END
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS messages_created_modseq ON messages`,
+		`CREATE TRIGGER messages_created_modseq BEFORE INSERT ON messages
+		FOR EACH ROW EXECUTE FUNCTION messages_set_created_modseq()`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_inbox_modseq ON messages (inbox_id, modseq)`,
+		`ALTER TABLE expunged_messages ADD COLUMN IF NOT EXISTS message_id UUID`,
+
+		// Conversation threading. The thread ID is the ID of the first message of
+		// the thread; the headers and the base subject link replies to it.
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS thread_id UUID`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS header_message_id TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS header_references TEXT[] NOT NULL DEFAULT '{}'`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS base_subject TEXT NOT NULL DEFAULT ''`,
+		`UPDATE messages SET thread_id = id WHERE thread_id IS NULL`,
+		`UPDATE messages SET base_subject = lower(regexp_replace(subject, '^\s*((re|fwd?)\s*(\[\d+\])?:\s*)+', '', 'i'))
+		WHERE base_subject = ''`,
+		`ALTER TABLE messages ALTER COLUMN thread_id SET NOT NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_thread ON messages (thread_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_header_message_id ON messages (inbox_id, header_message_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_header_references ON messages USING GIN (header_references)`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_base_subject ON messages (inbox_id, base_subject)`,
+		`ALTER TABLE expunged_messages ADD COLUMN IF NOT EXISTS thread_id UUID`,
+
+		// Addresses the members of a project may submit mail from, besides the
+		// addresses of its inboxes. An address starting with "@" allows a whole
+		// domain; a row without user_id applies to every member of the project.
+		`CREATE TABLE IF NOT EXISTS sender_addresses (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+			address VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_sender_addresses_unique
+		ON sender_addresses (project_id, COALESCE(user_id, '00000000-0000-0000-0000-000000000000'), lower(address))`,
+
+		// Queue of submitted messages waiting to be relayed to the smarthost
+		`CREATE TABLE IF NOT EXISTS outbound_messages (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+			mail_from VARCHAR(255) NOT NULL,
+			recipients TEXT[] NOT NULL,
+			raw BYTEA NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'sent', 'failed')),
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_outbound_messages_due
+		ON outbound_messages (next_attempt_at) WHERE status = 'queued'`,
+
+		// DKIM signing keys. The private key is stored encrypted with the
+		// configured encryption key; the newest key of a domain signs its mail.
+		`CREATE TABLE IF NOT EXISTS dkim_keys (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			domain VARCHAR(253) NOT NULL,
+			selector VARCHAR(63) NOT NULL,
+			algorithm VARCHAR(10) NOT NULL CHECK (algorithm IN ('rsa', 'ed25519')),
+			public_key TEXT NOT NULL,
+			private_key BYTEA NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(domain, selector)
+		)`,
+
+		// Address a forward rule sends matching messages to
+		`ALTER TABLE forward_rules ADD COLUMN IF NOT EXISTS forward_to VARCHAR(255) NOT NULL DEFAULT ''`,
+
+		// ACME account keys, certificates and challenge tokens, shared by all
+		// replicas. The data is encrypted.
+		`CREATE TABLE IF NOT EXISTS acme_cache (
+			key VARCHAR(255) PRIMARY KEY,
+			data BYTEA NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// SMTP TLS reports (RFC 8460) received at the report address. The
+		// policies are kept as sent; domains and session counts are taken
+		// from them for filtering.
+		`CREATE TABLE IF NOT EXISTS tls_reports (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			organization_name VARCHAR(255) NOT NULL,
+			report_id VARCHAR(255) NOT NULL,
+			contact_info VARCHAR(255) NOT NULL DEFAULT '',
+			start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+			end_date TIMESTAMP WITH TIME ZONE NOT NULL,
+			domains TEXT[] NOT NULL DEFAULT '{}',
+			successful_sessions BIGINT NOT NULL DEFAULT 0,
+			failed_sessions BIGINT NOT NULL DEFAULT 0,
+			policies JSONB NOT NULL DEFAULT '[]'::jsonb,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(organization_name, report_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_tls_reports_start_date ON tls_reports (start_date DESC)`,
+
+		// DMARC aggregate reports (RFC 7489) mailed to inboxes flagged as
+		// report sinks, with one record per source IP and result
+		`ALTER TABLE inboxes ADD COLUMN IF NOT EXISTS dmarc_reports BOOLEAN NOT NULL DEFAULT FALSE`,
+		`CREATE TABLE IF NOT EXISTS dmarc_reports (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			inbox_id UUID NOT NULL REFERENCES inboxes(id) ON DELETE CASCADE,
+			org_name VARCHAR(255) NOT NULL,
+			org_email VARCHAR(255) NOT NULL DEFAULT '',
+			report_id VARCHAR(255) NOT NULL,
+			domain VARCHAR(253) NOT NULL,
+			date_begin TIMESTAMP WITH TIME ZONE NOT NULL,
+			date_end TIMESTAMP WITH TIME ZONE NOT NULL,
+			policy_adkim VARCHAR(1) NOT NULL DEFAULT '',
+			policy_aspf VARCHAR(1) NOT NULL DEFAULT '',
+			policy_p VARCHAR(20) NOT NULL DEFAULT '',
+			policy_sp VARCHAR(20) NOT NULL DEFAULT '',
+			policy_pct INTEGER NOT NULL DEFAULT 100,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(inbox_id, org_name, report_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_dmarc_reports_domain_date ON dmarc_reports (domain, date_begin DESC)`,
+		`CREATE TABLE IF NOT EXISTS dmarc_records (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			report_id UUID NOT NULL REFERENCES dmarc_reports(id) ON DELETE CASCADE,
+			source_ip VARCHAR(45) NOT NULL,
+			count BIGINT NOT NULL,
+			disposition VARCHAR(20) NOT NULL DEFAULT '',
+			dkim VARCHAR(10) NOT NULL DEFAULT '',
+			spf VARCHAR(10) NOT NULL DEFAULT '',
+			header_from VARCHAR(253) NOT NULL DEFAULT '',
+			envelope_from VARCHAR(253) NOT NULL DEFAULT '',
+			auth_results JSONB NOT NULL DEFAULT '{}'::jsonb
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_dmarc_records_report ON dmarc_records (report_id)`,
+		`CREATE TABLE IF NOT EXISTS greylist (
+			network VARCHAR(64) NOT NULL,
+			sender VARCHAR(320) NOT NULL,
+			recipient VARCHAR(320) NOT NULL,
+			first_seen TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_seen TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (network, sender, recipient)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_greylist_last_seen ON greylist (last_seen)`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS dnsbl_hits TEXT[] NOT NULL DEFAULT '{}'`,
+		// Storage quotas. A limit of 0 is no limit; the usage columns are kept
+		// up to date by the queries storing, copying, moving and deleting messages.
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS size BIGINT NOT NULL DEFAULT 0`,
+		`UPDATE messages SET size = COALESCE(NULLIF(octet_length(raw), 0), octet_length(body)) WHERE size = 0`,
+		`ALTER TABLE inboxes ADD COLUMN IF NOT EXISTS max_messages BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE inboxes ADD COLUMN IF NOT EXISTS max_bytes BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE inboxes ADD COLUMN IF NOT EXISTS message_count BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE inboxes ADD COLUMN IF NOT EXISTS storage_bytes BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS max_messages BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS max_bytes BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS message_count BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS storage_bytes BIGINT NOT NULL DEFAULT 0`,
+		`UPDATE inboxes i SET
+			message_count = (SELECT COUNT(*) FROM messages m WHERE m.inbox_id = i.id),
+			storage_bytes = (SELECT COALESCE(SUM(m.size), 0) FROM messages m WHERE m.inbox_id = i.id)`,
+		`UPDATE projects p SET
+			message_count = (SELECT COALESCE(SUM(i.message_count), 0) FROM inboxes i WHERE i.project_id = p.id),
+			storage_bytes = (SELECT COALESCE(SUM(i.storage_bytes), 0) FROM inboxes i WHERE i.project_id = p.id)`,
+	}
+
+	// Start a transaction
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Ensure proper rollback handling
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("failed to rollback transaction: %v\n", err)
+		}
+	}()
+
+	// Execute the schema changes
+	for _, query := range schema {
+		if _, err := tx.Exec(query); err != nil {
+			return fmt.Errorf("failed to execute schema update '%s': %w", query, err)
+		}
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Print("Finished migration v0.3.0")
+	return nil
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	"context"
 	"inbox451/internal/models"
+	"time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -38,215 +39,284 @@ func (_m *Repository) EXPECT() *Repository_Expecter {
 	return &Repository_Expecter{mock: &_m.Mock}
 }
 
-// CreateInbox provides a mock function for the type Repository
-func (_mock *Repository) CreateInbox(ctx context.Context, inbox *models.Inbox) error {
-	ret := _mock.Called(ctx, inbox)
+// AddMessageLabel provides a mock function for the type Repository
+func (_mock *Repository) AddMessageLabel(ctx context.Context, messageID string, labelID string) error {
+	ret := _mock.Called(ctx, messageID, labelID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateInbox")
+		panic("no return value specified for AddMessageLabel")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Inbox) error); ok {
-		r0 = returnFunc(ctx, inbox)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, messageID, labelID)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Repository_CreateInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateInbox'
-type Repository_CreateInbox_Call struct {
+// Repository_AddMessageLabel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddMessageLabel'
+type Repository_AddMessageLabel_Call struct {
 	*mock.Call
 }
 
-// CreateInbox is a helper method to define mock.On call
+// AddMessageLabel is a helper method to define mock.On call
 //   - ctx context.Context
-//   - inbox *models.Inbox
-func (_e *Repository_Expecter) CreateInbox(ctx interface{}, inbox interface{}) *Repository_CreateInbox_Call {
-	return &Repository_CreateInbox_Call{Call: _e.mock.On("CreateInbox", ctx, inbox)}
+//   - messageID string
+//   - labelID string
+func (_e *Repository_Expecter) AddMessageLabel(ctx interface{}, messageID interface{}, labelID interface{}) *Repository_AddMessageLabel_Call {
+	return &Repository_AddMessageLabel_Call{Call: _e.mock.On("AddMessageLabel", ctx, messageID, labelID)}
 }
 
-func (_c *Repository_CreateInbox_Call) Run(run func(ctx context.Context, inbox *models.Inbox)) *Repository_CreateInbox_Call {
+func (_c *Repository_AddMessageLabel_Call) Run(run func(ctx context.Context, messageID string, labelID string)) *Repository_AddMessageLabel_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 *models.Inbox
+		var arg1 string
 		if args[1] != nil {
-			arg1 = args[1].(*models.Inbox)
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
 		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_CreateInbox_Call) Return(err error) *Repository_CreateInbox_Call {
+func (_c *Repository_AddMessageLabel_Call) Return(err error) *Repository_AddMessageLabel_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_CreateInbox_Call) RunAndReturn(run func(ctx context.Context, inbox *models.Inbox) error) *Repository_CreateInbox_Call {
+func (_c *Repository_AddMessageLabel_Call) RunAndReturn(run func(ctx context.Context, messageID string, labelID string) error) *Repository_AddMessageLabel_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// CreateMessage provides a mock function for the type Repository
-func (_mock *Repository) CreateMessage(ctx context.Context, message *models.Message) error {
-	ret := _mock.Called(ctx, message)
+// ClaimOutboundMessages provides a mock function for the type Repository
+func (_mock *Repository) ClaimOutboundMessages(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboundMessage, error) {
+	ret := _mock.Called(ctx, limit, lease)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateMessage")
+		panic("no return value specified for ClaimOutboundMessages")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Message) error); ok {
-		r0 = returnFunc(ctx, message)
+	var r0 []*models.OutboundMessage
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, time.Duration) ([]*models.OutboundMessage, error)); ok {
+		return returnFunc(ctx, limit, lease)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, time.Duration) []*models.OutboundMessage); ok {
+		r0 = returnFunc(ctx, limit, lease)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.OutboundMessage)
+		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, time.Duration) error); ok {
+		r1 = returnFunc(ctx, limit, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// Repository_CreateMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateMessage'
-type Repository_CreateMessage_Call struct {
+// Repository_ClaimOutboundMessages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimOutboundMessages'
+type Repository_ClaimOutboundMessages_Call struct {
 	*mock.Call
 }
 
-// CreateMessage is a helper method to define mock.On call
+// ClaimOutboundMessages is a helper method to define mock.On call
 //   - ctx context.Context
-//   - message *models.Message
-func (_e *Repository_Expecter) CreateMessage(ctx interface{}, message interface{}) *Repository_CreateMessage_Call {
-	return &Repository_CreateMessage_Call{Call: _e.mock.On("CreateMessage", ctx, message)}
+//   - limit int
+//   - lease time.Duration
+func (_e *Repository_Expecter) ClaimOutboundMessages(ctx interface{}, limit interface{}, lease interface{}) *Repository_ClaimOutboundMessages_Call {
+	return &Repository_ClaimOutboundMessages_Call{Call: _e.mock.On("ClaimOutboundMessages", ctx, limit, lease)}
 }
 
-func (_c *Repository_CreateMessage_Call) Run(run func(ctx context.Context, message *models.Message)) *Repository_CreateMessage_Call {
+func (_c *Repository_ClaimOutboundMessages_Call) Run(run func(ctx context.Context, limit int, lease time.Duration)) *Repository_ClaimOutboundMessages_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 *models.Message
+		var arg1 int
 		if args[1] != nil {
-			arg1 = args[1].(*models.Message)
+			arg1 = args[1].(int)
+		}
+		var arg2 time.Duration
+		if args[2] != nil {
+			arg2 = args[2].(time.Duration)
 		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_CreateMessage_Call) Return(err error) *Repository_CreateMessage_Call {
-	_c.Call.Return(err)
+func (_c *Repository_ClaimOutboundMessages_Call) Return(outboundMessages []*models.OutboundMessage, err error) *Repository_ClaimOutboundMessages_Call {
+	_c.Call.Return(outboundMessages, err)
 	return _c
 }
 
-func (_c *Repository_CreateMessage_Call) RunAndReturn(run func(ctx context.Context, message *models.Message) error) *Repository_CreateMessage_Call {
+func (_c *Repository_ClaimOutboundMessages_Call) RunAndReturn(run func(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboundMessage, error)) *Repository_ClaimOutboundMessages_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// CreateProject provides a mock function for the type Repository
-func (_mock *Repository) CreateProject(ctx context.Context, project *models.Project) error {
-	ret := _mock.Called(ctx, project)
+// CopyMessages provides a mock function for the type Repository
+func (_mock *Repository) CopyMessages(ctx context.Context, inboxID string, folderID string, uids []uint32, destInboxID string, destFolderID string) ([]uint32, error) {
+	ret := _mock.Called(ctx, inboxID, folderID, uids, destInboxID, destFolderID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateProject")
+		panic("no return value specified for CopyMessages")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Project) error); ok {
-		r0 = returnFunc(ctx, project)
+	var r0 []uint32
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []uint32, string, string) ([]uint32, error)); ok {
+		return returnFunc(ctx, inboxID, folderID, uids, destInboxID, destFolderID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []uint32, string, string) []uint32); ok {
+		r0 = returnFunc(ctx, inboxID, folderID, uids, destInboxID, destFolderID)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint32)
+		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, []uint32, string, string) error); ok {
+		r1 = returnFunc(ctx, inboxID, folderID, uids, destInboxID, destFolderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// Repository_CreateProject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateProject'
-type Repository_CreateProject_Call struct {
+// Repository_CopyMessages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CopyMessages'
+type Repository_CopyMessages_Call struct {
 	*mock.Call
 }
 
-// CreateProject is a helper method to define mock.On call
+// CopyMessages is a helper method to define mock.On call
 //   - ctx context.Context
-//   - project *models.Project
-func (_e *Repository_Expecter) CreateProject(ctx interface{}, project interface{}) *Repository_CreateProject_Call {
-	return &Repository_CreateProject_Call{Call: _e.mock.On("CreateProject", ctx, project)}
+//   - inboxID string
+//   - folderID string
+//   - uids []uint32
+//   - destInboxID string
+//   - destFolderID string
+func (_e *Repository_Expecter) CopyMessages(ctx interface{}, inboxID interface{}, folderID interface{}, uids interface{}, destInboxID interface{}, destFolderID interface{}) *Repository_CopyMessages_Call {
+	return &Repository_CopyMessages_Call{Call: _e.mock.On("CopyMessages", ctx, inboxID, folderID, uids, destInboxID, destFolderID)}
 }
 
-func (_c *Repository_CreateProject_Call) Run(run func(ctx context.Context, project *models.Project)) *Repository_CreateProject_Call {
+func (_c *Repository_CopyMessages_Call) Run(run func(ctx context.Context, inboxID string, folderID string, uids []uint32, destInboxID string, destFolderID string)) *Repository_CopyMessages_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 *models.Project
+		var arg1 string
 		if args[1] != nil {
-			arg1 = args[1].(*models.Project)
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []uint32
+		if args[3] != nil {
+			arg3 = args[3].([]uint32)
+		}
+		var arg4 string
+		if args[4] != nil {
+			arg4 = args[4].(string)
+		}
+		var arg5 string
+		if args[5] != nil {
+			arg5 = args[5].(string)
 		}
 		run(
 			arg0,
 			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_CreateProject_Call) Return(err error) *Repository_CreateProject_Call {
-	_c.Call.Return(err)
+func (_c *Repository_CopyMessages_Call) Return(uint32s []uint32, err error) *Repository_CopyMessages_Call {
+	_c.Call.Return(uint32s, err)
 	return _c
 }
 
-func (_c *Repository_CreateProject_Call) RunAndReturn(run func(ctx context.Context, project *models.Project) error) *Repository_CreateProject_Call {
+func (_c *Repository_CopyMessages_Call) RunAndReturn(run func(ctx context.Context, inboxID string, folderID string, uids []uint32, destInboxID string, destFolderID string) ([]uint32, error)) *Repository_CopyMessages_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// CreateRule provides a mock function for the type Repository
-func (_mock *Repository) CreateRule(ctx context.Context, rule *models.ForwardRule) error {
-	ret := _mock.Called(ctx, rule)
+// CountMessagesByMailbox provides a mock function for the type Repository
+func (_mock *Repository) CountMessagesByMailbox(ctx context.Context, inboxIDs []string) ([]*models.MailboxCount, error) {
+	ret := _mock.Called(ctx, inboxIDs)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateRule")
+		panic("no return value specified for CountMessagesByMailbox")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.ForwardRule) error); ok {
-		r0 = returnFunc(ctx, rule)
+	var r0 []*models.MailboxCount
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]*models.MailboxCount, error)); ok {
+		return returnFunc(ctx, inboxIDs)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []*models.MailboxCount); ok {
+		r0 = returnFunc(ctx, inboxIDs)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.MailboxCount)
+		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, inboxIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// Repository_CreateRule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateRule'
-type Repository_CreateRule_Call struct {
+// Repository_CountMessagesByMailbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountMessagesByMailbox'
+type Repository_CountMessagesByMailbox_Call struct {
 	*mock.Call
 }
 
-// CreateRule is a helper method to define mock.On call
+// CountMessagesByMailbox is a helper method to define mock.On call
 //   - ctx context.Context
-//   - rule *models.ForwardRule
-func (_e *Repository_Expecter) CreateRule(ctx interface{}, rule interface{}) *Repository_CreateRule_Call {
-	return &Repository_CreateRule_Call{Call: _e.mock.On("CreateRule", ctx, rule)}
+//   - inboxIDs []string
+func (_e *Repository_Expecter) CountMessagesByMailbox(ctx interface{}, inboxIDs interface{}) *Repository_CountMessagesByMailbox_Call {
+	return &Repository_CountMessagesByMailbox_Call{Call: _e.mock.On("CountMessagesByMailbox", ctx, inboxIDs)}
 }
 
-func (_c *Repository_CreateRule_Call) Run(run func(ctx context.Context, rule *models.ForwardRule)) *Repository_CreateRule_Call {
+func (_c *Repository_CountMessagesByMailbox_Call) Run(run func(ctx context.Context, inboxIDs []string)) *Repository_CountMessagesByMailbox_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 *models.ForwardRule
+		var arg1 []string
 		if args[1] != nil {
-			arg1 = args[1].(*models.ForwardRule)
+			arg1 = args[1].([]string)
 		}
 		run(
 			arg0,
@@ -256,54 +326,54 @@ func (_c *Repository_CreateRule_Call) Run(run func(ctx context.Context, rule *mo
 	return _c
 }
 
-func (_c *Repository_CreateRule_Call) Return(err error) *Repository_CreateRule_Call {
-	_c.Call.Return(err)
+func (_c *Repository_CountMessagesByMailbox_Call) Return(mailboxCounts []*models.MailboxCount, err error) *Repository_CountMessagesByMailbox_Call {
+	_c.Call.Return(mailboxCounts, err)
 	return _c
 }
 
-func (_c *Repository_CreateRule_Call) RunAndReturn(run func(ctx context.Context, rule *models.ForwardRule) error) *Repository_CreateRule_Call {
+func (_c *Repository_CountMessagesByMailbox_Call) RunAndReturn(run func(ctx context.Context, inboxIDs []string) ([]*models.MailboxCount, error)) *Repository_CountMessagesByMailbox_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// CreateToken provides a mock function for the type Repository
-func (_mock *Repository) CreateToken(ctx context.Context, token *models.Token) error {
-	ret := _mock.Called(ctx, token)
+// CreateDKIMKey provides a mock function for the type Repository
+func (_mock *Repository) CreateDKIMKey(ctx context.Context, key *models.DKIMKey) error {
+	ret := _mock.Called(ctx, key)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateToken")
+		panic("no return value specified for CreateDKIMKey")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Token) error); ok {
-		r0 = returnFunc(ctx, token)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.DKIMKey) error); ok {
+		r0 = returnFunc(ctx, key)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Repository_CreateToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateToken'
-type Repository_CreateToken_Call struct {
+// Repository_CreateDKIMKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateDKIMKey'
+type Repository_CreateDKIMKey_Call struct {
 	*mock.Call
 }
 
-// CreateToken is a helper method to define mock.On call
+// CreateDKIMKey is a helper method to define mock.On call
 //   - ctx context.Context
-//   - token *models.Token
-func (_e *Repository_Expecter) CreateToken(ctx interface{}, token interface{}) *Repository_CreateToken_Call {
-	return &Repository_CreateToken_Call{Call: _e.mock.On("CreateToken", ctx, token)}
+//   - key *models.DKIMKey
+func (_e *Repository_Expecter) CreateDKIMKey(ctx interface{}, key interface{}) *Repository_CreateDKIMKey_Call {
+	return &Repository_CreateDKIMKey_Call{Call: _e.mock.On("CreateDKIMKey", ctx, key)}
 }
 
-func (_c *Repository_CreateToken_Call) Run(run func(ctx context.Context, token *models.Token)) *Repository_CreateToken_Call {
+func (_c *Repository_CreateDKIMKey_Call) Run(run func(ctx context.Context, key *models.DKIMKey)) *Repository_CreateDKIMKey_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 *models.Token
+		var arg1 *models.DKIMKey
 		if args[1] != nil {
-			arg1 = args[1].(*models.Token)
+			arg1 = args[1].(*models.DKIMKey)
 		}
 		run(
 			arg0,
@@ -313,54 +383,54 @@ func (_c *Repository_CreateToken_Call) Run(run func(ctx context.Context, token *
 	return _c
 }
 
-func (_c *Repository_CreateToken_Call) Return(err error) *Repository_CreateToken_Call {
+func (_c *Repository_CreateDKIMKey_Call) Return(err error) *Repository_CreateDKIMKey_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_CreateToken_Call) RunAndReturn(run func(ctx context.Context, token *models.Token) error) *Repository_CreateToken_Call {
+func (_c *Repository_CreateDKIMKey_Call) RunAndReturn(run func(ctx context.Context, key *models.DKIMKey) error) *Repository_CreateDKIMKey_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// CreateUser provides a mock function for the type Repository
-func (_mock *Repository) CreateUser(ctx context.Context, user *models.User) error {
-	ret := _mock.Called(ctx, user)
+// CreateDMARCReport provides a mock function for the type Repository
+func (_mock *Repository) CreateDMARCReport(ctx context.Context, report *models.DMARCReport) error {
+	ret := _mock.Called(ctx, report)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateUser")
+		panic("no return value specified for CreateDMARCReport")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.User) error); ok {
-		r0 = returnFunc(ctx, user)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.DMARCReport) error); ok {
+		r0 = returnFunc(ctx, report)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Repository_CreateUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateUser'
-type Repository_CreateUser_Call struct {
+// Repository_CreateDMARCReport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateDMARCReport'
+type Repository_CreateDMARCReport_Call struct {
 	*mock.Call
 }
 
-// CreateUser is a helper method to define mock.On call
+// CreateDMARCReport is a helper method to define mock.On call
 //   - ctx context.Context
-//   - user *models.User
-func (_e *Repository_Expecter) CreateUser(ctx interface{}, user interface{}) *Repository_CreateUser_Call {
-	return &Repository_CreateUser_Call{Call: _e.mock.On("CreateUser", ctx, user)}
+//   - report *models.DMARCReport
+func (_e *Repository_Expecter) CreateDMARCReport(ctx interface{}, report interface{}) *Repository_CreateDMARCReport_Call {
+	return &Repository_CreateDMARCReport_Call{Call: _e.mock.On("CreateDMARCReport", ctx, report)}
 }
 
-func (_c *Repository_CreateUser_Call) Run(run func(ctx context.Context, user *models.User)) *Repository_CreateUser_Call {
+func (_c *Repository_CreateDMARCReport_Call) Run(run func(ctx context.Context, report *models.DMARCReport)) *Repository_CreateDMARCReport_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 *models.User
+		var arg1 *models.DMARCReport
 		if args[1] != nil {
-			arg1 = args[1].(*models.User)
+			arg1 = args[1].(*models.DMARCReport)
 		}
 		run(
 			arg0,
@@ -370,54 +440,54 @@ func (_c *Repository_CreateUser_Call) Run(run func(ctx context.Context, user *mo
 	return _c
 }
 
-func (_c *Repository_CreateUser_Call) Return(err error) *Repository_CreateUser_Call {
+func (_c *Repository_CreateDMARCReport_Call) Return(err error) *Repository_CreateDMARCReport_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_CreateUser_Call) RunAndReturn(run func(ctx context.Context, user *models.User) error) *Repository_CreateUser_Call {
+func (_c *Repository_CreateDMARCReport_Call) RunAndReturn(run func(ctx context.Context, report *models.DMARCReport) error) *Repository_CreateDMARCReport_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// DeleteInbox provides a mock function for the type Repository
-func (_mock *Repository) DeleteInbox(ctx context.Context, id string) error {
-	ret := _mock.Called(ctx, id)
+// CreateFolder provides a mock function for the type Repository
+func (_mock *Repository) CreateFolder(ctx context.Context, folder *models.Folder) error {
+	ret := _mock.Called(ctx, folder)
 
 	if len(ret) == 0 {
-		panic("no return value specified for DeleteInbox")
+		panic("no return value specified for CreateFolder")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = returnFunc(ctx, id)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Folder) error); ok {
+		r0 = returnFunc(ctx, folder)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Repository_DeleteInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteInbox'
-type Repository_DeleteInbox_Call struct {
+// Repository_CreateFolder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateFolder'
+type Repository_CreateFolder_Call struct {
 	*mock.Call
 }
 
-// DeleteInbox is a helper method to define mock.On call
+// CreateFolder is a helper method to define mock.On call
 //   - ctx context.Context
-//   - id string
-func (_e *Repository_Expecter) DeleteInbox(ctx interface{}, id interface{}) *Repository_DeleteInbox_Call {
-	return &Repository_DeleteInbox_Call{Call: _e.mock.On("DeleteInbox", ctx, id)}
+//   - folder *models.Folder
+func (_e *Repository_Expecter) CreateFolder(ctx interface{}, folder interface{}) *Repository_CreateFolder_Call {
+	return &Repository_CreateFolder_Call{Call: _e.mock.On("CreateFolder", ctx, folder)}
 }
 
-func (_c *Repository_DeleteInbox_Call) Run(run func(ctx context.Context, id string)) *Repository_DeleteInbox_Call {
+func (_c *Repository_CreateFolder_Call) Run(run func(ctx context.Context, folder *models.Folder)) *Repository_CreateFolder_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *models.Folder
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*models.Folder)
 		}
 		run(
 			arg0,
@@ -427,54 +497,54 @@ func (_c *Repository_DeleteInbox_Call) Run(run func(ctx context.Context, id stri
 	return _c
 }
 
-func (_c *Repository_DeleteInbox_Call) Return(err error) *Repository_DeleteInbox_Call {
+func (_c *Repository_CreateFolder_Call) Return(err error) *Repository_CreateFolder_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_DeleteInbox_Call) RunAndReturn(run func(ctx context.Context, id string) error) *Repository_DeleteInbox_Call {
+func (_c *Repository_CreateFolder_Call) RunAndReturn(run func(ctx context.Context, folder *models.Folder) error) *Repository_CreateFolder_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// DeleteMessage provides a mock function for the type Repository
-func (_mock *Repository) DeleteMessage(ctx context.Context, messageID string) error {
-	ret := _mock.Called(ctx, messageID)
+// CreateInbox provides a mock function for the type Repository
+func (_mock *Repository) CreateInbox(ctx context.Context, inbox *models.Inbox) error {
+	ret := _mock.Called(ctx, inbox)
 
 	if len(ret) == 0 {
-		panic("no return value specified for DeleteMessage")
+		panic("no return value specified for CreateInbox")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = returnFunc(ctx, messageID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Inbox) error); ok {
+		r0 = returnFunc(ctx, inbox)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Repository_DeleteMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteMessage'
-type Repository_DeleteMessage_Call struct {
+// Repository_CreateInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateInbox'
+type Repository_CreateInbox_Call struct {
 	*mock.Call
 }
 
-// DeleteMessage is a helper method to define mock.On call
+// CreateInbox is a helper method to define mock.On call
 //   - ctx context.Context
-//   - messageID string
-func (_e *Repository_Expecter) DeleteMessage(ctx interface{}, messageID interface{}) *Repository_DeleteMessage_Call {
-	return &Repository_DeleteMessage_Call{Call: _e.mock.On("DeleteMessage", ctx, messageID)}
+//   - inbox *models.Inbox
+func (_e *Repository_Expecter) CreateInbox(ctx interface{}, inbox interface{}) *Repository_CreateInbox_Call {
+	return &Repository_CreateInbox_Call{Call: _e.mock.On("CreateInbox", ctx, inbox)}
 }
 
-func (_c *Repository_DeleteMessage_Call) Run(run func(ctx context.Context, messageID string)) *Repository_DeleteMessage_Call {
+func (_c *Repository_CreateInbox_Call) Run(run func(ctx context.Context, inbox *models.Inbox)) *Repository_CreateInbox_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *models.Inbox
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*models.Inbox)
 		}
 		run(
 			arg0,
@@ -484,54 +554,54 @@ func (_c *Repository_DeleteMessage_Call) Run(run func(ctx context.Context, messa
 	return _c
 }
 
-func (_c *Repository_DeleteMessage_Call) Return(err error) *Repository_DeleteMessage_Call {
+func (_c *Repository_CreateInbox_Call) Return(err error) *Repository_CreateInbox_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_DeleteMessage_Call) RunAndReturn(run func(ctx context.Context, messageID string) error) *Repository_DeleteMessage_Call {
+func (_c *Repository_CreateInbox_Call) RunAndReturn(run func(ctx context.Context, inbox *models.Inbox) error) *Repository_CreateInbox_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// DeleteProject provides a mock function for the type Repository
-func (_mock *Repository) DeleteProject(ctx context.Context, id string) error {
-	ret := _mock.Called(ctx, id)
+// CreateLabel provides a mock function for the type Repository
+func (_mock *Repository) CreateLabel(ctx context.Context, label *models.Label) error {
+	ret := _mock.Called(ctx, label)
 
 	if len(ret) == 0 {
-		panic("no return value specified for DeleteProject")
+		panic("no return value specified for CreateLabel")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = returnFunc(ctx, id)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Label) error); ok {
+		r0 = returnFunc(ctx, label)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Repository_DeleteProject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteProject'
-type Repository_DeleteProject_Call struct {
+// Repository_CreateLabel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateLabel'
+type Repository_CreateLabel_Call struct {
 	*mock.Call
 }
 
-// DeleteProject is a helper method to define mock.On call
+// CreateLabel is a helper method to define mock.On call
 //   - ctx context.Context
-//   - id string
-func (_e *Repository_Expecter) DeleteProject(ctx interface{}, id interface{}) *Repository_DeleteProject_Call {
-	return &Repository_DeleteProject_Call{Call: _e.mock.On("DeleteProject", ctx, id)}
+//   - label *models.Label
+func (_e *Repository_Expecter) CreateLabel(ctx interface{}, label interface{}) *Repository_CreateLabel_Call {
+	return &Repository_CreateLabel_Call{Call: _e.mock.On("CreateLabel", ctx, label)}
 }
 
-func (_c *Repository_DeleteProject_Call) Run(run func(ctx context.Context, id string)) *Repository_DeleteProject_Call {
+func (_c *Repository_CreateLabel_Call) Run(run func(ctx context.Context, label *models.Label)) *Repository_CreateLabel_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *models.Label
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*models.Label)
 		}
 		run(
 			arg0,
@@ -541,54 +611,54 @@ func (_c *Repository_DeleteProject_Call) Run(run func(ctx context.Context, id st
 	return _c
 }
 
-func (_c *Repository_DeleteProject_Call) Return(err error) *Repository_DeleteProject_Call {
+func (_c *Repository_CreateLabel_Call) Return(err error) *Repository_CreateLabel_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_DeleteProject_Call) RunAndReturn(run func(ctx context.Context, id string) error) *Repository_DeleteProject_Call {
+func (_c *Repository_CreateLabel_Call) RunAndReturn(run func(ctx context.Context, label *models.Label) error) *Repository_CreateLabel_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// DeleteRule provides a mock function for the type Repository
-func (_mock *Repository) DeleteRule(ctx context.Context, id string) error {
-	ret := _mock.Called(ctx, id)
+// CreateMessage provides a mock function for the type Repository
+func (_mock *Repository) CreateMessage(ctx context.Context, message *models.Message) error {
+	ret := _mock.Called(ctx, message)
 
 	if len(ret) == 0 {
-		panic("no return value specified for DeleteRule")
+		panic("no return value specified for CreateMessage")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = returnFunc(ctx, id)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Message) error); ok {
+		r0 = returnFunc(ctx, message)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Repository_DeleteRule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteRule'
-type Repository_DeleteRule_Call struct {
+// Repository_CreateMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateMessage'
+type Repository_CreateMessage_Call struct {
 	*mock.Call
 }
 
-// DeleteRule is a helper method to define mock.On call
+// CreateMessage is a helper method to define mock.On call
 //   - ctx context.Context
-//   - id string
-func (_e *Repository_Expecter) DeleteRule(ctx interface{}, id interface{}) *Repository_DeleteRule_Call {
-	return &Repository_DeleteRule_Call{Call: _e.mock.On("DeleteRule", ctx, id)}
+//   - message *models.Message
+func (_e *Repository_Expecter) CreateMessage(ctx interface{}, message interface{}) *Repository_CreateMessage_Call {
+	return &Repository_CreateMessage_Call{Call: _e.mock.On("CreateMessage", ctx, message)}
 }
 
-func (_c *Repository_DeleteRule_Call) Run(run func(ctx context.Context, id string)) *Repository_DeleteRule_Call {
+func (_c *Repository_CreateMessage_Call) Run(run func(ctx context.Context, message *models.Message)) *Repository_CreateMessage_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *models.Message
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*models.Message)
 		}
 		run(
 			arg0,
@@ -598,54 +668,54 @@ func (_c *Repository_DeleteRule_Call) Run(run func(ctx context.Context, id strin
 	return _c
 }
 
-func (_c *Repository_DeleteRule_Call) Return(err error) *Repository_DeleteRule_Call {
+func (_c *Repository_CreateMessage_Call) Return(err error) *Repository_CreateMessage_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_DeleteRule_Call) RunAndReturn(run func(ctx context.Context, id string) error) *Repository_DeleteRule_Call {
+func (_c *Repository_CreateMessage_Call) RunAndReturn(run func(ctx context.Context, message *models.Message) error) *Repository_CreateMessage_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// DeleteToken provides a mock function for the type Repository
-func (_mock *Repository) DeleteToken(ctx context.Context, tokenID string) error {
-	ret := _mock.Called(ctx, tokenID)
+// CreateProject provides a mock function for the type Repository
+func (_mock *Repository) CreateProject(ctx context.Context, project *models.Project) error {
+	ret := _mock.Called(ctx, project)
 
 	if len(ret) == 0 {
-		panic("no return value specified for DeleteToken")
+		panic("no return value specified for CreateProject")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = returnFunc(ctx, tokenID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Project) error); ok {
+		r0 = returnFunc(ctx, project)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Repository_DeleteToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteToken'
-type Repository_DeleteToken_Call struct {
+// Repository_CreateProject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateProject'
+type Repository_CreateProject_Call struct {
 	*mock.Call
 }
 
-// DeleteToken is a helper method to define mock.On call
+// CreateProject is a helper method to define mock.On call
 //   - ctx context.Context
-//   - tokenID string
-func (_e *Repository_Expecter) DeleteToken(ctx interface{}, tokenID interface{}) *Repository_DeleteToken_Call {
-	return &Repository_DeleteToken_Call{Call: _e.mock.On("DeleteToken", ctx, tokenID)}
+//   - project *models.Project
+func (_e *Repository_Expecter) CreateProject(ctx interface{}, project interface{}) *Repository_CreateProject_Call {
+	return &Repository_CreateProject_Call{Call: _e.mock.On("CreateProject", ctx, project)}
 }
 
-func (_c *Repository_DeleteToken_Call) Run(run func(ctx context.Context, tokenID string)) *Repository_DeleteToken_Call {
+func (_c *Repository_CreateProject_Call) Run(run func(ctx context.Context, project *models.Project)) *Repository_CreateProject_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *models.Project
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*models.Project)
 		}
 		run(
 			arg0,
@@ -655,54 +725,54 @@ func (_c *Repository_DeleteToken_Call) Run(run func(ctx context.Context, tokenID
 	return _c
 }
 
-func (_c *Repository_DeleteToken_Call) Return(err error) *Repository_DeleteToken_Call {
+func (_c *Repository_CreateProject_Call) Return(err error) *Repository_CreateProject_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_DeleteToken_Call) RunAndReturn(run func(ctx context.Context, tokenID string) error) *Repository_DeleteToken_Call {
+func (_c *Repository_CreateProject_Call) RunAndReturn(run func(ctx context.Context, project *models.Project) error) *Repository_CreateProject_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// DeleteUser provides a mock function for the type Repository
-func (_mock *Repository) DeleteUser(ctx context.Context, userId string) error {
-	ret := _mock.Called(ctx, userId)
+// CreateRule provides a mock function for the type Repository
+func (_mock *Repository) CreateRule(ctx context.Context, rule *models.ForwardRule) error {
+	ret := _mock.Called(ctx, rule)
 
 	if len(ret) == 0 {
-		panic("no return value specified for DeleteUser")
+		panic("no return value specified for CreateRule")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = returnFunc(ctx, userId)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.ForwardRule) error); ok {
+		r0 = returnFunc(ctx, rule)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Repository_DeleteUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteUser'
-type Repository_DeleteUser_Call struct {
+// Repository_CreateRule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateRule'
+type Repository_CreateRule_Call struct {
 	*mock.Call
 }
 
-// DeleteUser is a helper method to define mock.On call
+// CreateRule is a helper method to define mock.On call
 //   - ctx context.Context
-//   - userId string
-func (_e *Repository_Expecter) DeleteUser(ctx interface{}, userId interface{}) *Repository_DeleteUser_Call {
-	return &Repository_DeleteUser_Call{Call: _e.mock.On("DeleteUser", ctx, userId)}
+//   - rule *models.ForwardRule
+func (_e *Repository_Expecter) CreateRule(ctx interface{}, rule interface{}) *Repository_CreateRule_Call {
+	return &Repository_CreateRule_Call{Call: _e.mock.On("CreateRule", ctx, rule)}
 }
 
-func (_c *Repository_DeleteUser_Call) Run(run func(ctx context.Context, userId string)) *Repository_DeleteUser_Call {
+func (_c *Repository_CreateRule_Call) Run(run func(ctx context.Context, rule *models.ForwardRule)) *Repository_CreateRule_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *models.ForwardRule
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*models.ForwardRule)
 		}
 		run(
 			arg0,
@@ -712,65 +782,54 @@ func (_c *Repository_DeleteUser_Call) Run(run func(ctx context.Context, userId s
 	return _c
 }
 
-func (_c *Repository_DeleteUser_Call) Return(err error) *Repository_DeleteUser_Call {
+func (_c *Repository_CreateRule_Call) Return(err error) *Repository_CreateRule_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_DeleteUser_Call) RunAndReturn(run func(ctx context.Context, userId string) error) *Repository_DeleteUser_Call {
+func (_c *Repository_CreateRule_Call) RunAndReturn(run func(ctx context.Context, rule *models.ForwardRule) error) *Repository_CreateRule_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetAllMessageUIDsForInbox provides a mock function for the type Repository
-func (_mock *Repository) GetAllMessageUIDsForInbox(ctx context.Context, inboxID string) ([]uint32, error) {
-	ret := _mock.Called(ctx, inboxID)
+// CreateSenderAddress provides a mock function for the type Repository
+func (_mock *Repository) CreateSenderAddress(ctx context.Context, sender *models.SenderAddress) error {
+	ret := _mock.Called(ctx, sender)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAllMessageUIDsForInbox")
+		panic("no return value specified for CreateSenderAddress")
 	}
 
-	var r0 []uint32
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]uint32, error)); ok {
-		return returnFunc(ctx, inboxID)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []uint32); ok {
-		r0 = returnFunc(ctx, inboxID)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]uint32)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, inboxID)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.SenderAddress) error); ok {
+		r0 = returnFunc(ctx, sender)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetAllMessageUIDsForInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllMessageUIDsForInbox'
-type Repository_GetAllMessageUIDsForInbox_Call struct {
+// Repository_CreateSenderAddress_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateSenderAddress'
+type Repository_CreateSenderAddress_Call struct {
 	*mock.Call
 }
 
-// GetAllMessageUIDsForInbox is a helper method to define mock.On call
+// CreateSenderAddress is a helper method to define mock.On call
 //   - ctx context.Context
-//   - inboxID string
-func (_e *Repository_Expecter) GetAllMessageUIDsForInbox(ctx interface{}, inboxID interface{}) *Repository_GetAllMessageUIDsForInbox_Call {
-	return &Repository_GetAllMessageUIDsForInbox_Call{Call: _e.mock.On("GetAllMessageUIDsForInbox", ctx, inboxID)}
+//   - sender *models.SenderAddress
+func (_e *Repository_Expecter) CreateSenderAddress(ctx interface{}, sender interface{}) *Repository_CreateSenderAddress_Call {
+	return &Repository_CreateSenderAddress_Call{Call: _e.mock.On("CreateSenderAddress", ctx, sender)}
 }
 
-func (_c *Repository_GetAllMessageUIDsForInbox_Call) Run(run func(ctx context.Context, inboxID string)) *Repository_GetAllMessageUIDsForInbox_Call {
+func (_c *Repository_CreateSenderAddress_Call) Run(run func(ctx context.Context, sender *models.SenderAddress)) *Repository_CreateSenderAddress_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *models.SenderAddress
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*models.SenderAddress)
 		}
 		run(
 			arg0,
@@ -780,65 +839,54 @@ func (_c *Repository_GetAllMessageUIDsForInbox_Call) Run(run func(ctx context.Co
 	return _c
 }
 
-func (_c *Repository_GetAllMessageUIDsForInbox_Call) Return(uint32s []uint32, err error) *Repository_GetAllMessageUIDsForInbox_Call {
-	_c.Call.Return(uint32s, err)
+func (_c *Repository_CreateSenderAddress_Call) Return(err error) *Repository_CreateSenderAddress_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetAllMessageUIDsForInbox_Call) RunAndReturn(run func(ctx context.Context, inboxID string) ([]uint32, error)) *Repository_GetAllMessageUIDsForInbox_Call {
+func (_c *Repository_CreateSenderAddress_Call) RunAndReturn(run func(ctx context.Context, sender *models.SenderAddress) error) *Repository_CreateSenderAddress_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetAllMessageUIDsForInboxIncludingDeleted provides a mock function for the type Repository
-func (_mock *Repository) GetAllMessageUIDsForInboxIncludingDeleted(ctx context.Context, inboxID string) ([]uint32, error) {
-	ret := _mock.Called(ctx, inboxID)
+// CreateTLSReport provides a mock function for the type Repository
+func (_mock *Repository) CreateTLSReport(ctx context.Context, report *models.TLSReport) error {
+	ret := _mock.Called(ctx, report)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAllMessageUIDsForInboxIncludingDeleted")
+		panic("no return value specified for CreateTLSReport")
 	}
 
-	var r0 []uint32
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]uint32, error)); ok {
-		return returnFunc(ctx, inboxID)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []uint32); ok {
-		r0 = returnFunc(ctx, inboxID)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]uint32)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, inboxID)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.TLSReport) error); ok {
+		r0 = returnFunc(ctx, report)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllMessageUIDsForInboxIncludingDeleted'
-type Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call struct {
+// Repository_CreateTLSReport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateTLSReport'
+type Repository_CreateTLSReport_Call struct {
 	*mock.Call
 }
 
-// GetAllMessageUIDsForInboxIncludingDeleted is a helper method to define mock.On call
+// CreateTLSReport is a helper method to define mock.On call
 //   - ctx context.Context
-//   - inboxID string
-func (_e *Repository_Expecter) GetAllMessageUIDsForInboxIncludingDeleted(ctx interface{}, inboxID interface{}) *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call {
-	return &Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call{Call: _e.mock.On("GetAllMessageUIDsForInboxIncludingDeleted", ctx, inboxID)}
+//   - report *models.TLSReport
+func (_e *Repository_Expecter) CreateTLSReport(ctx interface{}, report interface{}) *Repository_CreateTLSReport_Call {
+	return &Repository_CreateTLSReport_Call{Call: _e.mock.On("CreateTLSReport", ctx, report)}
 }
 
-func (_c *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call) Run(run func(ctx context.Context, inboxID string)) *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call {
+func (_c *Repository_CreateTLSReport_Call) Run(run func(ctx context.Context, report *models.TLSReport)) *Repository_CreateTLSReport_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *models.TLSReport
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*models.TLSReport)
 		}
 		run(
 			arg0,
@@ -848,65 +896,54 @@ func (_c *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call) Run(run fun
 	return _c
 }
 
-func (_c *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call) Return(uint32s []uint32, err error) *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call {
-	_c.Call.Return(uint32s, err)
+func (_c *Repository_CreateTLSReport_Call) Return(err error) *Repository_CreateTLSReport_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call) RunAndReturn(run func(ctx context.Context, inboxID string) ([]uint32, error)) *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call {
+func (_c *Repository_CreateTLSReport_Call) RunAndReturn(run func(ctx context.Context, report *models.TLSReport) error) *Repository_CreateTLSReport_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetInbox provides a mock function for the type Repository
-func (_mock *Repository) GetInbox(ctx context.Context, id string) (*models.Inbox, error) {
-	ret := _mock.Called(ctx, id)
+// CreateToken provides a mock function for the type Repository
+func (_mock *Repository) CreateToken(ctx context.Context, token *models.Token) error {
+	ret := _mock.Called(ctx, token)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetInbox")
+		panic("no return value specified for CreateToken")
 	}
 
-	var r0 *models.Inbox
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Inbox, error)); ok {
-		return returnFunc(ctx, id)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Inbox); ok {
-		r0 = returnFunc(ctx, id)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Inbox)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, id)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Token) error); ok {
+		r0 = returnFunc(ctx, token)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInbox'
-type Repository_GetInbox_Call struct {
+// Repository_CreateToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateToken'
+type Repository_CreateToken_Call struct {
 	*mock.Call
 }
 
-// GetInbox is a helper method to define mock.On call
+// CreateToken is a helper method to define mock.On call
 //   - ctx context.Context
-//   - id string
-func (_e *Repository_Expecter) GetInbox(ctx interface{}, id interface{}) *Repository_GetInbox_Call {
-	return &Repository_GetInbox_Call{Call: _e.mock.On("GetInbox", ctx, id)}
+//   - token *models.Token
+func (_e *Repository_Expecter) CreateToken(ctx interface{}, token interface{}) *Repository_CreateToken_Call {
+	return &Repository_CreateToken_Call{Call: _e.mock.On("CreateToken", ctx, token)}
 }
 
-func (_c *Repository_GetInbox_Call) Run(run func(ctx context.Context, id string)) *Repository_GetInbox_Call {
+func (_c *Repository_CreateToken_Call) Run(run func(ctx context.Context, token *models.Token)) *Repository_CreateToken_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *models.Token
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*models.Token)
 		}
 		run(
 			arg0,
@@ -916,65 +953,54 @@ func (_c *Repository_GetInbox_Call) Run(run func(ctx context.Context, id string)
 	return _c
 }
 
-func (_c *Repository_GetInbox_Call) Return(inbox *models.Inbox, err error) *Repository_GetInbox_Call {
-	_c.Call.Return(inbox, err)
+func (_c *Repository_CreateToken_Call) Return(err error) *Repository_CreateToken_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetInbox_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.Inbox, error)) *Repository_GetInbox_Call {
+func (_c *Repository_CreateToken_Call) RunAndReturn(run func(ctx context.Context, token *models.Token) error) *Repository_CreateToken_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetInboxByEmail provides a mock function for the type Repository
-func (_mock *Repository) GetInboxByEmail(ctx context.Context, email string) (*models.Inbox, error) {
-	ret := _mock.Called(ctx, email)
+// CreateUser provides a mock function for the type Repository
+func (_mock *Repository) CreateUser(ctx context.Context, user *models.User) error {
+	ret := _mock.Called(ctx, user)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetInboxByEmail")
+		panic("no return value specified for CreateUser")
 	}
 
-	var r0 *models.Inbox
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Inbox, error)); ok {
-		return returnFunc(ctx, email)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Inbox); ok {
-		r0 = returnFunc(ctx, email)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Inbox)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, email)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.User) error); ok {
+		r0 = returnFunc(ctx, user)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetInboxByEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInboxByEmail'
-type Repository_GetInboxByEmail_Call struct {
+// Repository_CreateUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateUser'
+type Repository_CreateUser_Call struct {
 	*mock.Call
 }
 
-// GetInboxByEmail is a helper method to define mock.On call
+// CreateUser is a helper method to define mock.On call
 //   - ctx context.Context
-//   - email string
-func (_e *Repository_Expecter) GetInboxByEmail(ctx interface{}, email interface{}) *Repository_GetInboxByEmail_Call {
-	return &Repository_GetInboxByEmail_Call{Call: _e.mock.On("GetInboxByEmail", ctx, email)}
+//   - user *models.User
+func (_e *Repository_Expecter) CreateUser(ctx interface{}, user interface{}) *Repository_CreateUser_Call {
+	return &Repository_CreateUser_Call{Call: _e.mock.On("CreateUser", ctx, user)}
 }
 
-func (_c *Repository_GetInboxByEmail_Call) Run(run func(ctx context.Context, email string)) *Repository_GetInboxByEmail_Call {
+func (_c *Repository_CreateUser_Call) Run(run func(ctx context.Context, user *models.User)) *Repository_CreateUser_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *models.User
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*models.User)
 		}
 		run(
 			arg0,
@@ -984,58 +1010,46 @@ func (_c *Repository_GetInboxByEmail_Call) Run(run func(ctx context.Context, ema
 	return _c
 }
 
-func (_c *Repository_GetInboxByEmail_Call) Return(inbox *models.Inbox, err error) *Repository_GetInboxByEmail_Call {
-	_c.Call.Return(inbox, err)
+func (_c *Repository_CreateUser_Call) Return(err error) *Repository_CreateUser_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetInboxByEmail_Call) RunAndReturn(run func(ctx context.Context, email string) (*models.Inbox, error)) *Repository_GetInboxByEmail_Call {
+func (_c *Repository_CreateUser_Call) RunAndReturn(run func(ctx context.Context, user *models.User) error) *Repository_CreateUser_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetInboxByEmailAndUser provides a mock function for the type Repository
-func (_mock *Repository) GetInboxByEmailAndUser(ctx context.Context, email string, userID string) (*models.Inbox, error) {
-	ret := _mock.Called(ctx, email, userID)
+// DeleteACMECacheEntry provides a mock function for the type Repository
+func (_mock *Repository) DeleteACMECacheEntry(ctx context.Context, key string) error {
+	ret := _mock.Called(ctx, key)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetInboxByEmailAndUser")
+		panic("no return value specified for DeleteACMECacheEntry")
 	}
 
-	var r0 *models.Inbox
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.Inbox, error)); ok {
-		return returnFunc(ctx, email, userID)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.Inbox); ok {
-		r0 = returnFunc(ctx, email, userID)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Inbox)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = returnFunc(ctx, email, userID)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, key)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetInboxByEmailAndUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInboxByEmailAndUser'
-type Repository_GetInboxByEmailAndUser_Call struct {
+// Repository_DeleteACMECacheEntry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteACMECacheEntry'
+type Repository_DeleteACMECacheEntry_Call struct {
 	*mock.Call
 }
 
-// GetInboxByEmailAndUser is a helper method to define mock.On call
+// DeleteACMECacheEntry is a helper method to define mock.On call
 //   - ctx context.Context
-//   - email string
-//   - userID string
-func (_e *Repository_Expecter) GetInboxByEmailAndUser(ctx interface{}, email interface{}, userID interface{}) *Repository_GetInboxByEmailAndUser_Call {
-	return &Repository_GetInboxByEmailAndUser_Call{Call: _e.mock.On("GetInboxByEmailAndUser", ctx, email, userID)}
+//   - key string
+func (_e *Repository_Expecter) DeleteACMECacheEntry(ctx interface{}, key interface{}) *Repository_DeleteACMECacheEntry_Call {
+	return &Repository_DeleteACMECacheEntry_Call{Call: _e.mock.On("DeleteACMECacheEntry", ctx, key)}
 }
 
-func (_c *Repository_GetInboxByEmailAndUser_Call) Run(run func(ctx context.Context, email string, userID string)) *Repository_GetInboxByEmailAndUser_Call {
+func (_c *Repository_DeleteACMECacheEntry_Call) Run(run func(ctx context.Context, key string)) *Repository_DeleteACMECacheEntry_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1045,68 +1059,54 @@ func (_c *Repository_GetInboxByEmailAndUser_Call) Run(run func(ctx context.Conte
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 string
-		if args[2] != nil {
-			arg2 = args[2].(string)
-		}
 		run(
 			arg0,
 			arg1,
-			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_GetInboxByEmailAndUser_Call) Return(inbox *models.Inbox, err error) *Repository_GetInboxByEmailAndUser_Call {
-	_c.Call.Return(inbox, err)
+func (_c *Repository_DeleteACMECacheEntry_Call) Return(err error) *Repository_DeleteACMECacheEntry_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetInboxByEmailAndUser_Call) RunAndReturn(run func(ctx context.Context, email string, userID string) (*models.Inbox, error)) *Repository_GetInboxByEmailAndUser_Call {
+func (_c *Repository_DeleteACMECacheEntry_Call) RunAndReturn(run func(ctx context.Context, key string) error) *Repository_DeleteACMECacheEntry_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetMaxMessageUID provides a mock function for the type Repository
-func (_mock *Repository) GetMaxMessageUID(ctx context.Context, inboxID string) (uint32, error) {
-	ret := _mock.Called(ctx, inboxID)
+// DeleteDKIMKey provides a mock function for the type Repository
+func (_mock *Repository) DeleteDKIMKey(ctx context.Context, id string) error {
+	ret := _mock.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetMaxMessageUID")
+		panic("no return value specified for DeleteDKIMKey")
 	}
 
-	var r0 uint32
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (uint32, error)); ok {
-		return returnFunc(ctx, inboxID)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) uint32); ok {
-		r0 = returnFunc(ctx, inboxID)
-	} else {
-		r0 = ret.Get(0).(uint32)
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, inboxID)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, id)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetMaxMessageUID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetMaxMessageUID'
-type Repository_GetMaxMessageUID_Call struct {
+// Repository_DeleteDKIMKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteDKIMKey'
+type Repository_DeleteDKIMKey_Call struct {
 	*mock.Call
 }
 
-// GetMaxMessageUID is a helper method to define mock.On call
+// DeleteDKIMKey is a helper method to define mock.On call
 //   - ctx context.Context
-//   - inboxID string
-func (_e *Repository_Expecter) GetMaxMessageUID(ctx interface{}, inboxID interface{}) *Repository_GetMaxMessageUID_Call {
-	return &Repository_GetMaxMessageUID_Call{Call: _e.mock.On("GetMaxMessageUID", ctx, inboxID)}
+//   - id string
+func (_e *Repository_Expecter) DeleteDKIMKey(ctx interface{}, id interface{}) *Repository_DeleteDKIMKey_Call {
+	return &Repository_DeleteDKIMKey_Call{Call: _e.mock.On("DeleteDKIMKey", ctx, id)}
 }
 
-func (_c *Repository_GetMaxMessageUID_Call) Run(run func(ctx context.Context, inboxID string)) *Repository_GetMaxMessageUID_Call {
+func (_c *Repository_DeleteDKIMKey_Call) Run(run func(ctx context.Context, id string)) *Repository_DeleteDKIMKey_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1124,57 +1124,47 @@ func (_c *Repository_GetMaxMessageUID_Call) Run(run func(ctx context.Context, in
 	return _c
 }
 
-func (_c *Repository_GetMaxMessageUID_Call) Return(v uint32, err error) *Repository_GetMaxMessageUID_Call {
-	_c.Call.Return(v, err)
+func (_c *Repository_DeleteDKIMKey_Call) Return(err error) *Repository_DeleteDKIMKey_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetMaxMessageUID_Call) RunAndReturn(run func(ctx context.Context, inboxID string) (uint32, error)) *Repository_GetMaxMessageUID_Call {
+func (_c *Repository_DeleteDKIMKey_Call) RunAndReturn(run func(ctx context.Context, id string) error) *Repository_DeleteDKIMKey_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetMessage provides a mock function for the type Repository
-func (_mock *Repository) GetMessage(ctx context.Context, id string) (*models.Message, error) {
-	ret := _mock.Called(ctx, id)
+// DeleteDMARCReport provides a mock function for the type Repository
+func (_mock *Repository) DeleteDMARCReport(ctx context.Context, projectID string, id string) error {
+	ret := _mock.Called(ctx, projectID, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetMessage")
+		panic("no return value specified for DeleteDMARCReport")
 	}
 
-	var r0 *models.Message
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Message, error)); ok {
-		return returnFunc(ctx, id)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Message); ok {
-		r0 = returnFunc(ctx, id)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Message)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, id)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, projectID, id)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetMessage'
-type Repository_GetMessage_Call struct {
+// Repository_DeleteDMARCReport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteDMARCReport'
+type Repository_DeleteDMARCReport_Call struct {
 	*mock.Call
 }
 
-// GetMessage is a helper method to define mock.On call
+// DeleteDMARCReport is a helper method to define mock.On call
 //   - ctx context.Context
+//   - projectID string
 //   - id string
-func (_e *Repository_Expecter) GetMessage(ctx interface{}, id interface{}) *Repository_GetMessage_Call {
-	return &Repository_GetMessage_Call{Call: _e.mock.On("GetMessage", ctx, id)}
+func (_e *Repository_Expecter) DeleteDMARCReport(ctx interface{}, projectID interface{}, id interface{}) *Repository_DeleteDMARCReport_Call {
+	return &Repository_DeleteDMARCReport_Call{Call: _e.mock.On("DeleteDMARCReport", ctx, projectID, id)}
 }
 
-func (_c *Repository_GetMessage_Call) Run(run func(ctx context.Context, id string)) *Repository_GetMessage_Call {
+func (_c *Repository_DeleteDMARCReport_Call) Run(run func(ctx context.Context, projectID string, id string)) *Repository_DeleteDMARCReport_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1184,138 +1174,125 @@ func (_c *Repository_GetMessage_Call) Run(run func(ctx context.Context, id strin
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_GetMessage_Call) Return(message *models.Message, err error) *Repository_GetMessage_Call {
-	_c.Call.Return(message, err)
+func (_c *Repository_DeleteDMARCReport_Call) Return(err error) *Repository_DeleteDMARCReport_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetMessage_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.Message, error)) *Repository_GetMessage_Call {
+func (_c *Repository_DeleteDMARCReport_Call) RunAndReturn(run func(ctx context.Context, projectID string, id string) error) *Repository_DeleteDMARCReport_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetMessageIDFromUID provides a mock function for the type Repository
-func (_mock *Repository) GetMessageIDFromUID(ctx context.Context, inboxID string, uid uint32) (string, error) {
-	ret := _mock.Called(ctx, inboxID, uid)
+// DeleteExpiredGreylistTriplets provides a mock function for the type Repository
+func (_mock *Repository) DeleteExpiredGreylistTriplets(ctx context.Context, maxAge time.Duration) (int64, error) {
+	ret := _mock.Called(ctx, maxAge)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetMessageIDFromUID")
+		panic("no return value specified for DeleteExpiredGreylistTriplets")
 	}
 
-	var r0 string
+	var r0 int64
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, uint32) (string, error)); ok {
-		return returnFunc(ctx, inboxID, uid)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Duration) (int64, error)); ok {
+		return returnFunc(ctx, maxAge)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, uint32) string); ok {
-		r0 = returnFunc(ctx, inboxID, uid)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Duration) int64); ok {
+		r0 = returnFunc(ctx, maxAge)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(int64)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, uint32) error); ok {
-		r1 = returnFunc(ctx, inboxID, uid)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, time.Duration) error); ok {
+		r1 = returnFunc(ctx, maxAge)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// Repository_GetMessageIDFromUID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetMessageIDFromUID'
-type Repository_GetMessageIDFromUID_Call struct {
+// Repository_DeleteExpiredGreylistTriplets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteExpiredGreylistTriplets'
+type Repository_DeleteExpiredGreylistTriplets_Call struct {
 	*mock.Call
 }
 
-// GetMessageIDFromUID is a helper method to define mock.On call
+// DeleteExpiredGreylistTriplets is a helper method to define mock.On call
 //   - ctx context.Context
-//   - inboxID string
-//   - uid uint32
-func (_e *Repository_Expecter) GetMessageIDFromUID(ctx interface{}, inboxID interface{}, uid interface{}) *Repository_GetMessageIDFromUID_Call {
-	return &Repository_GetMessageIDFromUID_Call{Call: _e.mock.On("GetMessageIDFromUID", ctx, inboxID, uid)}
+//   - maxAge time.Duration
+func (_e *Repository_Expecter) DeleteExpiredGreylistTriplets(ctx interface{}, maxAge interface{}) *Repository_DeleteExpiredGreylistTriplets_Call {
+	return &Repository_DeleteExpiredGreylistTriplets_Call{Call: _e.mock.On("DeleteExpiredGreylistTriplets", ctx, maxAge)}
 }
 
-func (_c *Repository_GetMessageIDFromUID_Call) Run(run func(ctx context.Context, inboxID string, uid uint32)) *Repository_GetMessageIDFromUID_Call {
+func (_c *Repository_DeleteExpiredGreylistTriplets_Call) Run(run func(ctx context.Context, maxAge time.Duration)) *Repository_DeleteExpiredGreylistTriplets_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 time.Duration
 		if args[1] != nil {
-			arg1 = args[1].(string)
-		}
-		var arg2 uint32
-		if args[2] != nil {
-			arg2 = args[2].(uint32)
+			arg1 = args[1].(time.Duration)
 		}
 		run(
 			arg0,
 			arg1,
-			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_GetMessageIDFromUID_Call) Return(s string, err error) *Repository_GetMessageIDFromUID_Call {
-	_c.Call.Return(s, err)
+func (_c *Repository_DeleteExpiredGreylistTriplets_Call) Return(n int64, err error) *Repository_DeleteExpiredGreylistTriplets_Call {
+	_c.Call.Return(n, err)
 	return _c
 }
 
-func (_c *Repository_GetMessageIDFromUID_Call) RunAndReturn(run func(ctx context.Context, inboxID string, uid uint32) (string, error)) *Repository_GetMessageIDFromUID_Call {
+func (_c *Repository_DeleteExpiredGreylistTriplets_Call) RunAndReturn(run func(ctx context.Context, maxAge time.Duration) (int64, error)) *Repository_DeleteExpiredGreylistTriplets_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetMessagesByUIDs provides a mock function for the type Repository
-func (_mock *Repository) GetMessagesByUIDs(ctx context.Context, inboxID string, uids []uint32) ([]*models.Message, error) {
-	ret := _mock.Called(ctx, inboxID, uids)
+// DeleteFolder provides a mock function for the type Repository
+func (_mock *Repository) DeleteFolder(ctx context.Context, id string) error {
+	ret := _mock.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetMessagesByUIDs")
+		panic("no return value specified for DeleteFolder")
 	}
 
-	var r0 []*models.Message
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []uint32) ([]*models.Message, error)); ok {
-		return returnFunc(ctx, inboxID, uids)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []uint32) []*models.Message); ok {
-		r0 = returnFunc(ctx, inboxID, uids)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Message)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []uint32) error); ok {
-		r1 = returnFunc(ctx, inboxID, uids)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, id)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetMessagesByUIDs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetMessagesByUIDs'
-type Repository_GetMessagesByUIDs_Call struct {
+// Repository_DeleteFolder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteFolder'
+type Repository_DeleteFolder_Call struct {
 	*mock.Call
 }
 
-// GetMessagesByUIDs is a helper method to define mock.On call
+// DeleteFolder is a helper method to define mock.On call
 //   - ctx context.Context
-//   - inboxID string
-//   - uids []uint32
-func (_e *Repository_Expecter) GetMessagesByUIDs(ctx interface{}, inboxID interface{}, uids interface{}) *Repository_GetMessagesByUIDs_Call {
-	return &Repository_GetMessagesByUIDs_Call{Call: _e.mock.On("GetMessagesByUIDs", ctx, inboxID, uids)}
+//   - id string
+func (_e *Repository_Expecter) DeleteFolder(ctx interface{}, id interface{}) *Repository_DeleteFolder_Call {
+	return &Repository_DeleteFolder_Call{Call: _e.mock.On("DeleteFolder", ctx, id)}
 }
 
-func (_c *Repository_GetMessagesByUIDs_Call) Run(run func(ctx context.Context, inboxID string, uids []uint32)) *Repository_GetMessagesByUIDs_Call {
+func (_c *Repository_DeleteFolder_Call) Run(run func(ctx context.Context, id string)) *Repository_DeleteFolder_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1325,70 +1302,54 @@ func (_c *Repository_GetMessagesByUIDs_Call) Run(run func(ctx context.Context, i
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 []uint32
-		if args[2] != nil {
-			arg2 = args[2].([]uint32)
-		}
 		run(
 			arg0,
 			arg1,
-			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_GetMessagesByUIDs_Call) Return(messages []*models.Message, err error) *Repository_GetMessagesByUIDs_Call {
-	_c.Call.Return(messages, err)
+func (_c *Repository_DeleteFolder_Call) Return(err error) *Repository_DeleteFolder_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetMessagesByUIDs_Call) RunAndReturn(run func(ctx context.Context, inboxID string, uids []uint32) ([]*models.Message, error)) *Repository_GetMessagesByUIDs_Call {
+func (_c *Repository_DeleteFolder_Call) RunAndReturn(run func(ctx context.Context, id string) error) *Repository_DeleteFolder_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetProject provides a mock function for the type Repository
-func (_mock *Repository) GetProject(ctx context.Context, id string) (*models.Project, error) {
+// DeleteInbox provides a mock function for the type Repository
+func (_mock *Repository) DeleteInbox(ctx context.Context, id string) error {
 	ret := _mock.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetProject")
+		panic("no return value specified for DeleteInbox")
 	}
 
-	var r0 *models.Project
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Project, error)); ok {
-		return returnFunc(ctx, id)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Project); ok {
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
 		r0 = returnFunc(ctx, id)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Project)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, id)
-	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetProject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetProject'
-type Repository_GetProject_Call struct {
+// Repository_DeleteInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteInbox'
+type Repository_DeleteInbox_Call struct {
 	*mock.Call
 }
 
-// GetProject is a helper method to define mock.On call
+// DeleteInbox is a helper method to define mock.On call
 //   - ctx context.Context
 //   - id string
-func (_e *Repository_Expecter) GetProject(ctx interface{}, id interface{}) *Repository_GetProject_Call {
-	return &Repository_GetProject_Call{Call: _e.mock.On("GetProject", ctx, id)}
+func (_e *Repository_Expecter) DeleteInbox(ctx interface{}, id interface{}) *Repository_DeleteInbox_Call {
+	return &Repository_DeleteInbox_Call{Call: _e.mock.On("DeleteInbox", ctx, id)}
 }
 
-func (_c *Repository_GetProject_Call) Run(run func(ctx context.Context, id string)) *Repository_GetProject_Call {
+func (_c *Repository_DeleteInbox_Call) Run(run func(ctx context.Context, id string)) *Repository_DeleteInbox_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1406,57 +1367,46 @@ func (_c *Repository_GetProject_Call) Run(run func(ctx context.Context, id strin
 	return _c
 }
 
-func (_c *Repository_GetProject_Call) Return(project *models.Project, err error) *Repository_GetProject_Call {
-	_c.Call.Return(project, err)
+func (_c *Repository_DeleteInbox_Call) Return(err error) *Repository_DeleteInbox_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetProject_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.Project, error)) *Repository_GetProject_Call {
+func (_c *Repository_DeleteInbox_Call) RunAndReturn(run func(ctx context.Context, id string) error) *Repository_DeleteInbox_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetRule provides a mock function for the type Repository
-func (_mock *Repository) GetRule(ctx context.Context, id string) (*models.ForwardRule, error) {
+// DeleteLabel provides a mock function for the type Repository
+func (_mock *Repository) DeleteLabel(ctx context.Context, id string) error {
 	ret := _mock.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetRule")
+		panic("no return value specified for DeleteLabel")
 	}
 
-	var r0 *models.ForwardRule
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.ForwardRule, error)); ok {
-		return returnFunc(ctx, id)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.ForwardRule); ok {
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
 		r0 = returnFunc(ctx, id)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.ForwardRule)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, id)
-	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetRule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRule'
-type Repository_GetRule_Call struct {
+// Repository_DeleteLabel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteLabel'
+type Repository_DeleteLabel_Call struct {
 	*mock.Call
 }
 
-// GetRule is a helper method to define mock.On call
+// DeleteLabel is a helper method to define mock.On call
 //   - ctx context.Context
 //   - id string
-func (_e *Repository_Expecter) GetRule(ctx interface{}, id interface{}) *Repository_GetRule_Call {
-	return &Repository_GetRule_Call{Call: _e.mock.On("GetRule", ctx, id)}
+func (_e *Repository_Expecter) DeleteLabel(ctx interface{}, id interface{}) *Repository_DeleteLabel_Call {
+	return &Repository_DeleteLabel_Call{Call: _e.mock.On("DeleteLabel", ctx, id)}
 }
 
-func (_c *Repository_GetRule_Call) Run(run func(ctx context.Context, id string)) *Repository_GetRule_Call {
+func (_c *Repository_DeleteLabel_Call) Run(run func(ctx context.Context, id string)) *Repository_DeleteLabel_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1474,58 +1424,46 @@ func (_c *Repository_GetRule_Call) Run(run func(ctx context.Context, id string))
 	return _c
 }
 
-func (_c *Repository_GetRule_Call) Return(forwardRule *models.ForwardRule, err error) *Repository_GetRule_Call {
-	_c.Call.Return(forwardRule, err)
+func (_c *Repository_DeleteLabel_Call) Return(err error) *Repository_DeleteLabel_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetRule_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.ForwardRule, error)) *Repository_GetRule_Call {
+func (_c *Repository_DeleteLabel_Call) RunAndReturn(run func(ctx context.Context, id string) error) *Repository_DeleteLabel_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetTokenByUser provides a mock function for the type Repository
-func (_mock *Repository) GetTokenByUser(ctx context.Context, userID string, tokenID string) (*models.Token, error) {
-	ret := _mock.Called(ctx, userID, tokenID)
+// DeleteMessage provides a mock function for the type Repository
+func (_mock *Repository) DeleteMessage(ctx context.Context, messageID string) error {
+	ret := _mock.Called(ctx, messageID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetTokenByUser")
+		panic("no return value specified for DeleteMessage")
 	}
 
-	var r0 *models.Token
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.Token, error)); ok {
-		return returnFunc(ctx, userID, tokenID)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.Token); ok {
-		r0 = returnFunc(ctx, userID, tokenID)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Token)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = returnFunc(ctx, userID, tokenID)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, messageID)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetTokenByUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTokenByUser'
-type Repository_GetTokenByUser_Call struct {
+// Repository_DeleteMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteMessage'
+type Repository_DeleteMessage_Call struct {
 	*mock.Call
 }
 
-// GetTokenByUser is a helper method to define mock.On call
+// DeleteMessage is a helper method to define mock.On call
 //   - ctx context.Context
-//   - userID string
-//   - tokenID string
-func (_e *Repository_Expecter) GetTokenByUser(ctx interface{}, userID interface{}, tokenID interface{}) *Repository_GetTokenByUser_Call {
-	return &Repository_GetTokenByUser_Call{Call: _e.mock.On("GetTokenByUser", ctx, userID, tokenID)}
+//   - messageID string
+func (_e *Repository_Expecter) DeleteMessage(ctx interface{}, messageID interface{}) *Repository_DeleteMessage_Call {
+	return &Repository_DeleteMessage_Call{Call: _e.mock.On("DeleteMessage", ctx, messageID)}
 }
 
-func (_c *Repository_GetTokenByUser_Call) Run(run func(ctx context.Context, userID string, tokenID string)) *Repository_GetTokenByUser_Call {
+func (_c *Repository_DeleteMessage_Call) Run(run func(ctx context.Context, messageID string)) *Repository_DeleteMessage_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1535,70 +1473,54 @@ func (_c *Repository_GetTokenByUser_Call) Run(run func(ctx context.Context, user
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 string
-		if args[2] != nil {
-			arg2 = args[2].(string)
-		}
 		run(
 			arg0,
 			arg1,
-			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_GetTokenByUser_Call) Return(token *models.Token, err error) *Repository_GetTokenByUser_Call {
-	_c.Call.Return(token, err)
+func (_c *Repository_DeleteMessage_Call) Return(err error) *Repository_DeleteMessage_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetTokenByUser_Call) RunAndReturn(run func(ctx context.Context, userID string, tokenID string) (*models.Token, error)) *Repository_GetTokenByUser_Call {
+func (_c *Repository_DeleteMessage_Call) RunAndReturn(run func(ctx context.Context, messageID string) error) *Repository_DeleteMessage_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetTokenByValue provides a mock function for the type Repository
-func (_mock *Repository) GetTokenByValue(ctx context.Context, tokenValue string) (*models.Token, error) {
-	ret := _mock.Called(ctx, tokenValue)
+// DeleteProject provides a mock function for the type Repository
+func (_mock *Repository) DeleteProject(ctx context.Context, id string) error {
+	ret := _mock.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetTokenByValue")
+		panic("no return value specified for DeleteProject")
 	}
 
-	var r0 *models.Token
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Token, error)); ok {
-		return returnFunc(ctx, tokenValue)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Token); ok {
-		r0 = returnFunc(ctx, tokenValue)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Token)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, tokenValue)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, id)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetTokenByValue_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTokenByValue'
-type Repository_GetTokenByValue_Call struct {
+// Repository_DeleteProject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteProject'
+type Repository_DeleteProject_Call struct {
 	*mock.Call
 }
 
-// GetTokenByValue is a helper method to define mock.On call
+// DeleteProject is a helper method to define mock.On call
 //   - ctx context.Context
-//   - tokenValue string
-func (_e *Repository_Expecter) GetTokenByValue(ctx interface{}, tokenValue interface{}) *Repository_GetTokenByValue_Call {
-	return &Repository_GetTokenByValue_Call{Call: _e.mock.On("GetTokenByValue", ctx, tokenValue)}
+//   - id string
+func (_e *Repository_Expecter) DeleteProject(ctx interface{}, id interface{}) *Repository_DeleteProject_Call {
+	return &Repository_DeleteProject_Call{Call: _e.mock.On("DeleteProject", ctx, id)}
 }
 
-func (_c *Repository_GetTokenByValue_Call) Run(run func(ctx context.Context, tokenValue string)) *Repository_GetTokenByValue_Call {
+func (_c *Repository_DeleteProject_Call) Run(run func(ctx context.Context, id string)) *Repository_DeleteProject_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1616,57 +1538,46 @@ func (_c *Repository_GetTokenByValue_Call) Run(run func(ctx context.Context, tok
 	return _c
 }
 
-func (_c *Repository_GetTokenByValue_Call) Return(token *models.Token, err error) *Repository_GetTokenByValue_Call {
-	_c.Call.Return(token, err)
+func (_c *Repository_DeleteProject_Call) Return(err error) *Repository_DeleteProject_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetTokenByValue_Call) RunAndReturn(run func(ctx context.Context, tokenValue string) (*models.Token, error)) *Repository_GetTokenByValue_Call {
+func (_c *Repository_DeleteProject_Call) RunAndReturn(run func(ctx context.Context, id string) error) *Repository_DeleteProject_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetUser provides a mock function for the type Repository
-func (_mock *Repository) GetUser(ctx context.Context, id string) (*models.User, error) {
+// DeleteRule provides a mock function for the type Repository
+func (_mock *Repository) DeleteRule(ctx context.Context, id string) error {
 	ret := _mock.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUser")
+		panic("no return value specified for DeleteRule")
 	}
 
-	var r0 *models.User
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
-		return returnFunc(ctx, id)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
 		r0 = returnFunc(ctx, id)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.User)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, id)
-	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUser'
-type Repository_GetUser_Call struct {
+// Repository_DeleteRule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteRule'
+type Repository_DeleteRule_Call struct {
 	*mock.Call
 }
 
-// GetUser is a helper method to define mock.On call
+// DeleteRule is a helper method to define mock.On call
 //   - ctx context.Context
 //   - id string
-func (_e *Repository_Expecter) GetUser(ctx interface{}, id interface{}) *Repository_GetUser_Call {
-	return &Repository_GetUser_Call{Call: _e.mock.On("GetUser", ctx, id)}
+func (_e *Repository_Expecter) DeleteRule(ctx interface{}, id interface{}) *Repository_DeleteRule_Call {
+	return &Repository_DeleteRule_Call{Call: _e.mock.On("DeleteRule", ctx, id)}
 }
 
-func (_c *Repository_GetUser_Call) Run(run func(ctx context.Context, id string)) *Repository_GetUser_Call {
+func (_c *Repository_DeleteRule_Call) Run(run func(ctx context.Context, id string)) *Repository_DeleteRule_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1684,57 +1595,46 @@ func (_c *Repository_GetUser_Call) Run(run func(ctx context.Context, id string))
 	return _c
 }
 
-func (_c *Repository_GetUser_Call) Return(user *models.User, err error) *Repository_GetUser_Call {
-	_c.Call.Return(user, err)
+func (_c *Repository_DeleteRule_Call) Return(err error) *Repository_DeleteRule_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetUser_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.User, error)) *Repository_GetUser_Call {
+func (_c *Repository_DeleteRule_Call) RunAndReturn(run func(ctx context.Context, id string) error) *Repository_DeleteRule_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetUserByEmail provides a mock function for the type Repository
-func (_mock *Repository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	ret := _mock.Called(ctx, email)
+// DeleteSenderAddress provides a mock function for the type Repository
+func (_mock *Repository) DeleteSenderAddress(ctx context.Context, id string) error {
+	ret := _mock.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserByEmail")
+		panic("no return value specified for DeleteSenderAddress")
 	}
 
-	var r0 *models.User
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
-		return returnFunc(ctx, email)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
-		r0 = returnFunc(ctx, email)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.User)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, email)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, id)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetUserByEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByEmail'
-type Repository_GetUserByEmail_Call struct {
+// Repository_DeleteSenderAddress_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteSenderAddress'
+type Repository_DeleteSenderAddress_Call struct {
 	*mock.Call
 }
 
-// GetUserByEmail is a helper method to define mock.On call
+// DeleteSenderAddress is a helper method to define mock.On call
 //   - ctx context.Context
-//   - email string
-func (_e *Repository_Expecter) GetUserByEmail(ctx interface{}, email interface{}) *Repository_GetUserByEmail_Call {
-	return &Repository_GetUserByEmail_Call{Call: _e.mock.On("GetUserByEmail", ctx, email)}
+//   - id string
+func (_e *Repository_Expecter) DeleteSenderAddress(ctx interface{}, id interface{}) *Repository_DeleteSenderAddress_Call {
+	return &Repository_DeleteSenderAddress_Call{Call: _e.mock.On("DeleteSenderAddress", ctx, id)}
 }
 
-func (_c *Repository_GetUserByEmail_Call) Run(run func(ctx context.Context, email string)) *Repository_GetUserByEmail_Call {
+func (_c *Repository_DeleteSenderAddress_Call) Run(run func(ctx context.Context, id string)) *Repository_DeleteSenderAddress_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1752,57 +1652,46 @@ func (_c *Repository_GetUserByEmail_Call) Run(run func(ctx context.Context, emai
 	return _c
 }
 
-func (_c *Repository_GetUserByEmail_Call) Return(user *models.User, err error) *Repository_GetUserByEmail_Call {
-	_c.Call.Return(user, err)
+func (_c *Repository_DeleteSenderAddress_Call) Return(err error) *Repository_DeleteSenderAddress_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetUserByEmail_Call) RunAndReturn(run func(ctx context.Context, email string) (*models.User, error)) *Repository_GetUserByEmail_Call {
+func (_c *Repository_DeleteSenderAddress_Call) RunAndReturn(run func(ctx context.Context, id string) error) *Repository_DeleteSenderAddress_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetUserByUsername provides a mock function for the type Repository
-func (_mock *Repository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
-	ret := _mock.Called(ctx, username)
+// DeleteTLSReport provides a mock function for the type Repository
+func (_mock *Repository) DeleteTLSReport(ctx context.Context, id string) error {
+	ret := _mock.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserByUsername")
+		panic("no return value specified for DeleteTLSReport")
 	}
 
-	var r0 *models.User
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
-		return returnFunc(ctx, username)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
-		r0 = returnFunc(ctx, username)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.User)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, username)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, id)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_GetUserByUsername_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByUsername'
-type Repository_GetUserByUsername_Call struct {
+// Repository_DeleteTLSReport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteTLSReport'
+type Repository_DeleteTLSReport_Call struct {
 	*mock.Call
 }
 
-// GetUserByUsername is a helper method to define mock.On call
+// DeleteTLSReport is a helper method to define mock.On call
 //   - ctx context.Context
-//   - username string
-func (_e *Repository_Expecter) GetUserByUsername(ctx interface{}, username interface{}) *Repository_GetUserByUsername_Call {
-	return &Repository_GetUserByUsername_Call{Call: _e.mock.On("GetUserByUsername", ctx, username)}
+//   - id string
+func (_e *Repository_Expecter) DeleteTLSReport(ctx interface{}, id interface{}) *Repository_DeleteTLSReport_Call {
+	return &Repository_DeleteTLSReport_Call{Call: _e.mock.On("DeleteTLSReport", ctx, id)}
 }
 
-func (_c *Repository_GetUserByUsername_Call) Run(run func(ctx context.Context, username string)) *Repository_GetUserByUsername_Call {
+func (_c *Repository_DeleteTLSReport_Call) Run(run func(ctx context.Context, id string)) *Repository_DeleteTLSReport_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1820,65 +1709,46 @@ func (_c *Repository_GetUserByUsername_Call) Run(run func(ctx context.Context, u
 	return _c
 }
 
-func (_c *Repository_GetUserByUsername_Call) Return(user *models.User, err error) *Repository_GetUserByUsername_Call {
-	_c.Call.Return(user, err)
+func (_c *Repository_DeleteTLSReport_Call) Return(err error) *Repository_DeleteTLSReport_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_GetUserByUsername_Call) RunAndReturn(run func(ctx context.Context, username string) (*models.User, error)) *Repository_GetUserByUsername_Call {
+func (_c *Repository_DeleteTLSReport_Call) RunAndReturn(run func(ctx context.Context, id string) error) *Repository_DeleteTLSReport_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListInboxesByProject provides a mock function for the type Repository
-func (_mock *Repository) ListInboxesByProject(ctx context.Context, projectID string, limit int, offset int) ([]*models.Inbox, int, error) {
-	ret := _mock.Called(ctx, projectID, limit, offset)
+// DeleteToken provides a mock function for the type Repository
+func (_mock *Repository) DeleteToken(ctx context.Context, tokenID string) error {
+	ret := _mock.Called(ctx, tokenID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListInboxesByProject")
+		panic("no return value specified for DeleteToken")
 	}
 
-	var r0 []*models.Inbox
-	var r1 int
-	var r2 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.Inbox, int, error)); ok {
-		return returnFunc(ctx, projectID, limit, offset)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Inbox); ok {
-		r0 = returnFunc(ctx, projectID, limit, offset)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Inbox)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
-		r1 = returnFunc(ctx, projectID, limit, offset)
-	} else {
-		r1 = ret.Get(1).(int)
-	}
-	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
-		r2 = returnFunc(ctx, projectID, limit, offset)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, tokenID)
 	} else {
-		r2 = ret.Error(2)
+		r0 = ret.Error(0)
 	}
-	return r0, r1, r2
+	return r0
 }
 
-// Repository_ListInboxesByProject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListInboxesByProject'
-type Repository_ListInboxesByProject_Call struct {
+// Repository_DeleteToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteToken'
+type Repository_DeleteToken_Call struct {
 	*mock.Call
 }
 
-// ListInboxesByProject is a helper method to define mock.On call
+// DeleteToken is a helper method to define mock.On call
 //   - ctx context.Context
-//   - projectID string
-//   - limit int
-//   - offset int
-func (_e *Repository_Expecter) ListInboxesByProject(ctx interface{}, projectID interface{}, limit interface{}, offset interface{}) *Repository_ListInboxesByProject_Call {
-	return &Repository_ListInboxesByProject_Call{Call: _e.mock.On("ListInboxesByProject", ctx, projectID, limit, offset)}
+//   - tokenID string
+func (_e *Repository_Expecter) DeleteToken(ctx interface{}, tokenID interface{}) *Repository_DeleteToken_Call {
+	return &Repository_DeleteToken_Call{Call: _e.mock.On("DeleteToken", ctx, tokenID)}
 }
 
-func (_c *Repository_ListInboxesByProject_Call) Run(run func(ctx context.Context, projectID string, limit int, offset int)) *Repository_ListInboxesByProject_Call {
+func (_c *Repository_DeleteToken_Call) Run(run func(ctx context.Context, tokenID string)) *Repository_DeleteToken_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -1888,19 +1758,2885 @@ func (_c *Repository_ListInboxesByProject_Call) Run(run func(ctx context.Context
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 int
-		if args[2] != nil {
-			arg2 = args[2].(int)
-		}
-		var arg3 int
-		if args[3] != nil {
-			arg3 = args[3].(int)
-		}
 		run(
 			arg0,
 			arg1,
-			arg2,
-			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_DeleteToken_Call) Return(err error) *Repository_DeleteToken_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Repository_DeleteToken_Call) RunAndReturn(run func(ctx context.Context, tokenID string) error) *Repository_DeleteToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteUser provides a mock function for the type Repository
+func (_mock *Repository) DeleteUser(ctx context.Context, userId string) error {
+	ret := _mock.Called(ctx, userId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUser")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = returnFunc(ctx, userId)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Repository_DeleteUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteUser'
+type Repository_DeleteUser_Call struct {
+	*mock.Call
+}
+
+// DeleteUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userId string
+func (_e *Repository_Expecter) DeleteUser(ctx interface{}, userId interface{}) *Repository_DeleteUser_Call {
+	return &Repository_DeleteUser_Call{Call: _e.mock.On("DeleteUser", ctx, userId)}
+}
+
+func (_c *Repository_DeleteUser_Call) Run(run func(ctx context.Context, userId string)) *Repository_DeleteUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_DeleteUser_Call) Return(err error) *Repository_DeleteUser_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Repository_DeleteUser_Call) RunAndReturn(run func(ctx context.Context, userId string) error) *Repository_DeleteUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// EnqueueOutboundMessage provides a mock function for the type Repository
+func (_mock *Repository) EnqueueOutboundMessage(ctx context.Context, message *models.OutboundMessage) error {
+	ret := _mock.Called(ctx, message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnqueueOutboundMessage")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.OutboundMessage) error); ok {
+		r0 = returnFunc(ctx, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Repository_EnqueueOutboundMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnqueueOutboundMessage'
+type Repository_EnqueueOutboundMessage_Call struct {
+	*mock.Call
+}
+
+// EnqueueOutboundMessage is a helper method to define mock.On call
+//   - ctx context.Context
+//   - message *models.OutboundMessage
+func (_e *Repository_Expecter) EnqueueOutboundMessage(ctx interface{}, message interface{}) *Repository_EnqueueOutboundMessage_Call {
+	return &Repository_EnqueueOutboundMessage_Call{Call: _e.mock.On("EnqueueOutboundMessage", ctx, message)}
+}
+
+func (_c *Repository_EnqueueOutboundMessage_Call) Run(run func(ctx context.Context, message *models.OutboundMessage)) *Repository_EnqueueOutboundMessage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *models.OutboundMessage
+		if args[1] != nil {
+			arg1 = args[1].(*models.OutboundMessage)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_EnqueueOutboundMessage_Call) Return(err error) *Repository_EnqueueOutboundMessage_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Repository_EnqueueOutboundMessage_Call) RunAndReturn(run func(ctx context.Context, message *models.OutboundMessage) error) *Repository_EnqueueOutboundMessage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindThreadByMessageIDs provides a mock function for the type Repository
+func (_mock *Repository) FindThreadByMessageIDs(ctx context.Context, inboxID string, referencedIDs []string, messageID string) (string, error) {
+	ret := _mock.Called(ctx, inboxID, referencedIDs, messageID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindThreadByMessageIDs")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string, string) (string, error)); ok {
+		return returnFunc(ctx, inboxID, referencedIDs, messageID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string, string) string); ok {
+		r0 = returnFunc(ctx, inboxID, referencedIDs, messageID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string, string) error); ok {
+		r1 = returnFunc(ctx, inboxID, referencedIDs, messageID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_FindThreadByMessageIDs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindThreadByMessageIDs'
+type Repository_FindThreadByMessageIDs_Call struct {
+	*mock.Call
+}
+
+// FindThreadByMessageIDs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - referencedIDs []string
+//   - messageID string
+func (_e *Repository_Expecter) FindThreadByMessageIDs(ctx interface{}, inboxID interface{}, referencedIDs interface{}, messageID interface{}) *Repository_FindThreadByMessageIDs_Call {
+	return &Repository_FindThreadByMessageIDs_Call{Call: _e.mock.On("FindThreadByMessageIDs", ctx, inboxID, referencedIDs, messageID)}
+}
+
+func (_c *Repository_FindThreadByMessageIDs_Call) Run(run func(ctx context.Context, inboxID string, referencedIDs []string, messageID string)) *Repository_FindThreadByMessageIDs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_FindThreadByMessageIDs_Call) Return(s string, err error) *Repository_FindThreadByMessageIDs_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *Repository_FindThreadByMessageIDs_Call) RunAndReturn(run func(ctx context.Context, inboxID string, referencedIDs []string, messageID string) (string, error)) *Repository_FindThreadByMessageIDs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindThreadBySubject provides a mock function for the type Repository
+func (_mock *Repository) FindThreadBySubject(ctx context.Context, inboxID string, baseSubject string, since time.Time) (string, error) {
+	ret := _mock.Called(ctx, inboxID, baseSubject, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindThreadBySubject")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, time.Time) (string, error)); ok {
+		return returnFunc(ctx, inboxID, baseSubject, since)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, time.Time) string); ok {
+		r0 = returnFunc(ctx, inboxID, baseSubject, since)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, time.Time) error); ok {
+		r1 = returnFunc(ctx, inboxID, baseSubject, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_FindThreadBySubject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindThreadBySubject'
+type Repository_FindThreadBySubject_Call struct {
+	*mock.Call
+}
+
+// FindThreadBySubject is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - baseSubject string
+//   - since time.Time
+func (_e *Repository_Expecter) FindThreadBySubject(ctx interface{}, inboxID interface{}, baseSubject interface{}, since interface{}) *Repository_FindThreadBySubject_Call {
+	return &Repository_FindThreadBySubject_Call{Call: _e.mock.On("FindThreadBySubject", ctx, inboxID, baseSubject, since)}
+}
+
+func (_c *Repository_FindThreadBySubject_Call) Run(run func(ctx context.Context, inboxID string, baseSubject string, since time.Time)) *Repository_FindThreadBySubject_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 time.Time
+		if args[3] != nil {
+			arg3 = args[3].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_FindThreadBySubject_Call) Return(s string, err error) *Repository_FindThreadBySubject_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *Repository_FindThreadBySubject_Call) RunAndReturn(run func(ctx context.Context, inboxID string, baseSubject string, since time.Time) (string, error)) *Repository_FindThreadBySubject_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetACMECacheEntry provides a mock function for the type Repository
+func (_mock *Repository) GetACMECacheEntry(ctx context.Context, key string) ([]byte, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetACMECacheEntry")
+	}
+
+	var r0 []byte
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]byte, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []byte); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetACMECacheEntry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetACMECacheEntry'
+type Repository_GetACMECacheEntry_Call struct {
+	*mock.Call
+}
+
+// GetACMECacheEntry is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *Repository_Expecter) GetACMECacheEntry(ctx interface{}, key interface{}) *Repository_GetACMECacheEntry_Call {
+	return &Repository_GetACMECacheEntry_Call{Call: _e.mock.On("GetACMECacheEntry", ctx, key)}
+}
+
+func (_c *Repository_GetACMECacheEntry_Call) Run(run func(ctx context.Context, key string)) *Repository_GetACMECacheEntry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetACMECacheEntry_Call) Return(bytes []byte, err error) *Repository_GetACMECacheEntry_Call {
+	_c.Call.Return(bytes, err)
+	return _c
+}
+
+func (_c *Repository_GetACMECacheEntry_Call) RunAndReturn(run func(ctx context.Context, key string) ([]byte, error)) *Repository_GetACMECacheEntry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAllMessageUIDsForInbox provides a mock function for the type Repository
+func (_mock *Repository) GetAllMessageUIDsForInbox(ctx context.Context, inboxID string, folderID string) ([]uint32, error) {
+	ret := _mock.Called(ctx, inboxID, folderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllMessageUIDsForInbox")
+	}
+
+	var r0 []uint32
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) ([]uint32, error)); ok {
+		return returnFunc(ctx, inboxID, folderID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) []uint32); ok {
+		r0 = returnFunc(ctx, inboxID, folderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint32)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, inboxID, folderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetAllMessageUIDsForInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllMessageUIDsForInbox'
+type Repository_GetAllMessageUIDsForInbox_Call struct {
+	*mock.Call
+}
+
+// GetAllMessageUIDsForInbox is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - folderID string
+func (_e *Repository_Expecter) GetAllMessageUIDsForInbox(ctx interface{}, inboxID interface{}, folderID interface{}) *Repository_GetAllMessageUIDsForInbox_Call {
+	return &Repository_GetAllMessageUIDsForInbox_Call{Call: _e.mock.On("GetAllMessageUIDsForInbox", ctx, inboxID, folderID)}
+}
+
+func (_c *Repository_GetAllMessageUIDsForInbox_Call) Run(run func(ctx context.Context, inboxID string, folderID string)) *Repository_GetAllMessageUIDsForInbox_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetAllMessageUIDsForInbox_Call) Return(uint32s []uint32, err error) *Repository_GetAllMessageUIDsForInbox_Call {
+	_c.Call.Return(uint32s, err)
+	return _c
+}
+
+func (_c *Repository_GetAllMessageUIDsForInbox_Call) RunAndReturn(run func(ctx context.Context, inboxID string, folderID string) ([]uint32, error)) *Repository_GetAllMessageUIDsForInbox_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAllMessageUIDsForInboxIncludingDeleted provides a mock function for the type Repository
+func (_mock *Repository) GetAllMessageUIDsForInboxIncludingDeleted(ctx context.Context, inboxID string, folderID string) ([]uint32, error) {
+	ret := _mock.Called(ctx, inboxID, folderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllMessageUIDsForInboxIncludingDeleted")
+	}
+
+	var r0 []uint32
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) ([]uint32, error)); ok {
+		return returnFunc(ctx, inboxID, folderID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) []uint32); ok {
+		r0 = returnFunc(ctx, inboxID, folderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint32)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, inboxID, folderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllMessageUIDsForInboxIncludingDeleted'
+type Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call struct {
+	*mock.Call
+}
+
+// GetAllMessageUIDsForInboxIncludingDeleted is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - folderID string
+func (_e *Repository_Expecter) GetAllMessageUIDsForInboxIncludingDeleted(ctx interface{}, inboxID interface{}, folderID interface{}) *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call {
+	return &Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call{Call: _e.mock.On("GetAllMessageUIDsForInboxIncludingDeleted", ctx, inboxID, folderID)}
+}
+
+func (_c *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call) Run(run func(ctx context.Context, inboxID string, folderID string)) *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call) Return(uint32s []uint32, err error) *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call {
+	_c.Call.Return(uint32s, err)
+	return _c
+}
+
+func (_c *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call) RunAndReturn(run func(ctx context.Context, inboxID string, folderID string) ([]uint32, error)) *Repository_GetAllMessageUIDsForInboxIncludingDeleted_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetDKIMKey provides a mock function for the type Repository
+func (_mock *Repository) GetDKIMKey(ctx context.Context, id string) (*models.DKIMKey, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDKIMKey")
+	}
+
+	var r0 *models.DKIMKey
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.DKIMKey, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.DKIMKey); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.DKIMKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetDKIMKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDKIMKey'
+type Repository_GetDKIMKey_Call struct {
+	*mock.Call
+}
+
+// GetDKIMKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Repository_Expecter) GetDKIMKey(ctx interface{}, id interface{}) *Repository_GetDKIMKey_Call {
+	return &Repository_GetDKIMKey_Call{Call: _e.mock.On("GetDKIMKey", ctx, id)}
+}
+
+func (_c *Repository_GetDKIMKey_Call) Run(run func(ctx context.Context, id string)) *Repository_GetDKIMKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetDKIMKey_Call) Return(dKIMKey *models.DKIMKey, err error) *Repository_GetDKIMKey_Call {
+	_c.Call.Return(dKIMKey, err)
+	return _c
+}
+
+func (_c *Repository_GetDKIMKey_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.DKIMKey, error)) *Repository_GetDKIMKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetDKIMKeyByDomain provides a mock function for the type Repository
+func (_mock *Repository) GetDKIMKeyByDomain(ctx context.Context, domain string) (*models.DKIMKey, error) {
+	ret := _mock.Called(ctx, domain)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDKIMKeyByDomain")
+	}
+
+	var r0 *models.DKIMKey
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.DKIMKey, error)); ok {
+		return returnFunc(ctx, domain)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.DKIMKey); ok {
+		r0 = returnFunc(ctx, domain)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.DKIMKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, domain)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetDKIMKeyByDomain_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDKIMKeyByDomain'
+type Repository_GetDKIMKeyByDomain_Call struct {
+	*mock.Call
+}
+
+// GetDKIMKeyByDomain is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+func (_e *Repository_Expecter) GetDKIMKeyByDomain(ctx interface{}, domain interface{}) *Repository_GetDKIMKeyByDomain_Call {
+	return &Repository_GetDKIMKeyByDomain_Call{Call: _e.mock.On("GetDKIMKeyByDomain", ctx, domain)}
+}
+
+func (_c *Repository_GetDKIMKeyByDomain_Call) Run(run func(ctx context.Context, domain string)) *Repository_GetDKIMKeyByDomain_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetDKIMKeyByDomain_Call) Return(dKIMKey *models.DKIMKey, err error) *Repository_GetDKIMKeyByDomain_Call {
+	_c.Call.Return(dKIMKey, err)
+	return _c
+}
+
+func (_c *Repository_GetDKIMKeyByDomain_Call) RunAndReturn(run func(ctx context.Context, domain string) (*models.DKIMKey, error)) *Repository_GetDKIMKeyByDomain_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetDMARCReport provides a mock function for the type Repository
+func (_mock *Repository) GetDMARCReport(ctx context.Context, projectID string, id string) (*models.DMARCReport, error) {
+	ret := _mock.Called(ctx, projectID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDMARCReport")
+	}
+
+	var r0 *models.DMARCReport
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.DMARCReport, error)); ok {
+		return returnFunc(ctx, projectID, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.DMARCReport); ok {
+		r0 = returnFunc(ctx, projectID, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.DMARCReport)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, projectID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetDMARCReport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDMARCReport'
+type Repository_GetDMARCReport_Call struct {
+	*mock.Call
+}
+
+// GetDMARCReport is a helper method to define mock.On call
+//   - ctx context.Context
+//   - projectID string
+//   - id string
+func (_e *Repository_Expecter) GetDMARCReport(ctx interface{}, projectID interface{}, id interface{}) *Repository_GetDMARCReport_Call {
+	return &Repository_GetDMARCReport_Call{Call: _e.mock.On("GetDMARCReport", ctx, projectID, id)}
+}
+
+func (_c *Repository_GetDMARCReport_Call) Run(run func(ctx context.Context, projectID string, id string)) *Repository_GetDMARCReport_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetDMARCReport_Call) Return(dMARCReport *models.DMARCReport, err error) *Repository_GetDMARCReport_Call {
+	_c.Call.Return(dMARCReport, err)
+	return _c
+}
+
+func (_c *Repository_GetDMARCReport_Call) RunAndReturn(run func(ctx context.Context, projectID string, id string) (*models.DMARCReport, error)) *Repository_GetDMARCReport_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetFolder provides a mock function for the type Repository
+func (_mock *Repository) GetFolder(ctx context.Context, id string) (*models.Folder, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFolder")
+	}
+
+	var r0 *models.Folder
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Folder, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Folder); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Folder)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetFolder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetFolder'
+type Repository_GetFolder_Call struct {
+	*mock.Call
+}
+
+// GetFolder is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Repository_Expecter) GetFolder(ctx interface{}, id interface{}) *Repository_GetFolder_Call {
+	return &Repository_GetFolder_Call{Call: _e.mock.On("GetFolder", ctx, id)}
+}
+
+func (_c *Repository_GetFolder_Call) Run(run func(ctx context.Context, id string)) *Repository_GetFolder_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetFolder_Call) Return(folder *models.Folder, err error) *Repository_GetFolder_Call {
+	_c.Call.Return(folder, err)
+	return _c
+}
+
+func (_c *Repository_GetFolder_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.Folder, error)) *Repository_GetFolder_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetFolderByName provides a mock function for the type Repository
+func (_mock *Repository) GetFolderByName(ctx context.Context, inboxID string, name string) (*models.Folder, error) {
+	ret := _mock.Called(ctx, inboxID, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFolderByName")
+	}
+
+	var r0 *models.Folder
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.Folder, error)); ok {
+		return returnFunc(ctx, inboxID, name)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.Folder); ok {
+		r0 = returnFunc(ctx, inboxID, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Folder)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, inboxID, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetFolderByName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetFolderByName'
+type Repository_GetFolderByName_Call struct {
+	*mock.Call
+}
+
+// GetFolderByName is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - name string
+func (_e *Repository_Expecter) GetFolderByName(ctx interface{}, inboxID interface{}, name interface{}) *Repository_GetFolderByName_Call {
+	return &Repository_GetFolderByName_Call{Call: _e.mock.On("GetFolderByName", ctx, inboxID, name)}
+}
+
+func (_c *Repository_GetFolderByName_Call) Run(run func(ctx context.Context, inboxID string, name string)) *Repository_GetFolderByName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetFolderByName_Call) Return(folder *models.Folder, err error) *Repository_GetFolderByName_Call {
+	_c.Call.Return(folder, err)
+	return _c
+}
+
+func (_c *Repository_GetFolderByName_Call) RunAndReturn(run func(ctx context.Context, inboxID string, name string) (*models.Folder, error)) *Repository_GetFolderByName_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetFolderBySpecialUse provides a mock function for the type Repository
+func (_mock *Repository) GetFolderBySpecialUse(ctx context.Context, inboxID string, specialUse string) (*models.Folder, error) {
+	ret := _mock.Called(ctx, inboxID, specialUse)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFolderBySpecialUse")
+	}
+
+	var r0 *models.Folder
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.Folder, error)); ok {
+		return returnFunc(ctx, inboxID, specialUse)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.Folder); ok {
+		r0 = returnFunc(ctx, inboxID, specialUse)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Folder)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, inboxID, specialUse)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetFolderBySpecialUse_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetFolderBySpecialUse'
+type Repository_GetFolderBySpecialUse_Call struct {
+	*mock.Call
+}
+
+// GetFolderBySpecialUse is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - specialUse string
+func (_e *Repository_Expecter) GetFolderBySpecialUse(ctx interface{}, inboxID interface{}, specialUse interface{}) *Repository_GetFolderBySpecialUse_Call {
+	return &Repository_GetFolderBySpecialUse_Call{Call: _e.mock.On("GetFolderBySpecialUse", ctx, inboxID, specialUse)}
+}
+
+func (_c *Repository_GetFolderBySpecialUse_Call) Run(run func(ctx context.Context, inboxID string, specialUse string)) *Repository_GetFolderBySpecialUse_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetFolderBySpecialUse_Call) Return(folder *models.Folder, err error) *Repository_GetFolderBySpecialUse_Call {
+	_c.Call.Return(folder, err)
+	return _c
+}
+
+func (_c *Repository_GetFolderBySpecialUse_Call) RunAndReturn(run func(ctx context.Context, inboxID string, specialUse string) (*models.Folder, error)) *Repository_GetFolderBySpecialUse_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetHighestModSeq provides a mock function for the type Repository
+func (_mock *Repository) GetHighestModSeq(ctx context.Context, inboxID string, folderID string) (uint64, error) {
+	ret := _mock.Called(ctx, inboxID, folderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetHighestModSeq")
+	}
+
+	var r0 uint64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (uint64, error)); ok {
+		return returnFunc(ctx, inboxID, folderID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) uint64); ok {
+		r0 = returnFunc(ctx, inboxID, folderID)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, inboxID, folderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetHighestModSeq_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetHighestModSeq'
+type Repository_GetHighestModSeq_Call struct {
+	*mock.Call
+}
+
+// GetHighestModSeq is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - folderID string
+func (_e *Repository_Expecter) GetHighestModSeq(ctx interface{}, inboxID interface{}, folderID interface{}) *Repository_GetHighestModSeq_Call {
+	return &Repository_GetHighestModSeq_Call{Call: _e.mock.On("GetHighestModSeq", ctx, inboxID, folderID)}
+}
+
+func (_c *Repository_GetHighestModSeq_Call) Run(run func(ctx context.Context, inboxID string, folderID string)) *Repository_GetHighestModSeq_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetHighestModSeq_Call) Return(v uint64, err error) *Repository_GetHighestModSeq_Call {
+	_c.Call.Return(v, err)
+	return _c
+}
+
+func (_c *Repository_GetHighestModSeq_Call) RunAndReturn(run func(ctx context.Context, inboxID string, folderID string) (uint64, error)) *Repository_GetHighestModSeq_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetHighestModSeqForInboxes provides a mock function for the type Repository
+func (_mock *Repository) GetHighestModSeqForInboxes(ctx context.Context, inboxIDs []string) (uint64, error) {
+	ret := _mock.Called(ctx, inboxIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetHighestModSeqForInboxes")
+	}
+
+	var r0 uint64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) (uint64, error)); ok {
+		return returnFunc(ctx, inboxIDs)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) uint64); ok {
+		r0 = returnFunc(ctx, inboxIDs)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, inboxIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetHighestModSeqForInboxes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetHighestModSeqForInboxes'
+type Repository_GetHighestModSeqForInboxes_Call struct {
+	*mock.Call
+}
+
+// GetHighestModSeqForInboxes is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxIDs []string
+func (_e *Repository_Expecter) GetHighestModSeqForInboxes(ctx interface{}, inboxIDs interface{}) *Repository_GetHighestModSeqForInboxes_Call {
+	return &Repository_GetHighestModSeqForInboxes_Call{Call: _e.mock.On("GetHighestModSeqForInboxes", ctx, inboxIDs)}
+}
+
+func (_c *Repository_GetHighestModSeqForInboxes_Call) Run(run func(ctx context.Context, inboxIDs []string)) *Repository_GetHighestModSeqForInboxes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetHighestModSeqForInboxes_Call) Return(v uint64, err error) *Repository_GetHighestModSeqForInboxes_Call {
+	_c.Call.Return(v, err)
+	return _c
+}
+
+func (_c *Repository_GetHighestModSeqForInboxes_Call) RunAndReturn(run func(ctx context.Context, inboxIDs []string) (uint64, error)) *Repository_GetHighestModSeqForInboxes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetInbox provides a mock function for the type Repository
+func (_mock *Repository) GetInbox(ctx context.Context, id string) (*models.Inbox, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInbox")
+	}
+
+	var r0 *models.Inbox
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Inbox, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Inbox); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Inbox)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInbox'
+type Repository_GetInbox_Call struct {
+	*mock.Call
+}
+
+// GetInbox is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Repository_Expecter) GetInbox(ctx interface{}, id interface{}) *Repository_GetInbox_Call {
+	return &Repository_GetInbox_Call{Call: _e.mock.On("GetInbox", ctx, id)}
+}
+
+func (_c *Repository_GetInbox_Call) Run(run func(ctx context.Context, id string)) *Repository_GetInbox_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetInbox_Call) Return(inbox *models.Inbox, err error) *Repository_GetInbox_Call {
+	_c.Call.Return(inbox, err)
+	return _c
+}
+
+func (_c *Repository_GetInbox_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.Inbox, error)) *Repository_GetInbox_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetInboxByEmail provides a mock function for the type Repository
+func (_mock *Repository) GetInboxByEmail(ctx context.Context, email string) (*models.Inbox, error) {
+	ret := _mock.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInboxByEmail")
+	}
+
+	var r0 *models.Inbox
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Inbox, error)); ok {
+		return returnFunc(ctx, email)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Inbox); ok {
+		r0 = returnFunc(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Inbox)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetInboxByEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInboxByEmail'
+type Repository_GetInboxByEmail_Call struct {
+	*mock.Call
+}
+
+// GetInboxByEmail is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+func (_e *Repository_Expecter) GetInboxByEmail(ctx interface{}, email interface{}) *Repository_GetInboxByEmail_Call {
+	return &Repository_GetInboxByEmail_Call{Call: _e.mock.On("GetInboxByEmail", ctx, email)}
+}
+
+func (_c *Repository_GetInboxByEmail_Call) Run(run func(ctx context.Context, email string)) *Repository_GetInboxByEmail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetInboxByEmail_Call) Return(inbox *models.Inbox, err error) *Repository_GetInboxByEmail_Call {
+	_c.Call.Return(inbox, err)
+	return _c
+}
+
+func (_c *Repository_GetInboxByEmail_Call) RunAndReturn(run func(ctx context.Context, email string) (*models.Inbox, error)) *Repository_GetInboxByEmail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetInboxByEmailAndUser provides a mock function for the type Repository
+func (_mock *Repository) GetInboxByEmailAndUser(ctx context.Context, email string, userID string) (*models.Inbox, error) {
+	ret := _mock.Called(ctx, email, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInboxByEmailAndUser")
+	}
+
+	var r0 *models.Inbox
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.Inbox, error)); ok {
+		return returnFunc(ctx, email, userID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.Inbox); ok {
+		r0 = returnFunc(ctx, email, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Inbox)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, email, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetInboxByEmailAndUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInboxByEmailAndUser'
+type Repository_GetInboxByEmailAndUser_Call struct {
+	*mock.Call
+}
+
+// GetInboxByEmailAndUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+//   - userID string
+func (_e *Repository_Expecter) GetInboxByEmailAndUser(ctx interface{}, email interface{}, userID interface{}) *Repository_GetInboxByEmailAndUser_Call {
+	return &Repository_GetInboxByEmailAndUser_Call{Call: _e.mock.On("GetInboxByEmailAndUser", ctx, email, userID)}
+}
+
+func (_c *Repository_GetInboxByEmailAndUser_Call) Run(run func(ctx context.Context, email string, userID string)) *Repository_GetInboxByEmailAndUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetInboxByEmailAndUser_Call) Return(inbox *models.Inbox, err error) *Repository_GetInboxByEmailAndUser_Call {
+	_c.Call.Return(inbox, err)
+	return _c
+}
+
+func (_c *Repository_GetInboxByEmailAndUser_Call) RunAndReturn(run func(ctx context.Context, email string, userID string) (*models.Inbox, error)) *Repository_GetInboxByEmailAndUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetInboxQuotas provides a mock function for the type Repository
+func (_mock *Repository) GetInboxQuotas(ctx context.Context, inboxID string) ([]*models.Quota, error) {
+	ret := _mock.Called(ctx, inboxID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInboxQuotas")
+	}
+
+	var r0 []*models.Quota
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]*models.Quota, error)); ok {
+		return returnFunc(ctx, inboxID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []*models.Quota); ok {
+		r0 = returnFunc(ctx, inboxID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Quota)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, inboxID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetInboxQuotas_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInboxQuotas'
+type Repository_GetInboxQuotas_Call struct {
+	*mock.Call
+}
+
+// GetInboxQuotas is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+func (_e *Repository_Expecter) GetInboxQuotas(ctx interface{}, inboxID interface{}) *Repository_GetInboxQuotas_Call {
+	return &Repository_GetInboxQuotas_Call{Call: _e.mock.On("GetInboxQuotas", ctx, inboxID)}
+}
+
+func (_c *Repository_GetInboxQuotas_Call) Run(run func(ctx context.Context, inboxID string)) *Repository_GetInboxQuotas_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetInboxQuotas_Call) Return(quotas []*models.Quota, err error) *Repository_GetInboxQuotas_Call {
+	_c.Call.Return(quotas, err)
+	return _c
+}
+
+func (_c *Repository_GetInboxQuotas_Call) RunAndReturn(run func(ctx context.Context, inboxID string) ([]*models.Quota, error)) *Repository_GetInboxQuotas_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetLabel provides a mock function for the type Repository
+func (_mock *Repository) GetLabel(ctx context.Context, id string) (*models.Label, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLabel")
+	}
+
+	var r0 *models.Label
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Label, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Label); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Label)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetLabel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLabel'
+type Repository_GetLabel_Call struct {
+	*mock.Call
+}
+
+// GetLabel is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Repository_Expecter) GetLabel(ctx interface{}, id interface{}) *Repository_GetLabel_Call {
+	return &Repository_GetLabel_Call{Call: _e.mock.On("GetLabel", ctx, id)}
+}
+
+func (_c *Repository_GetLabel_Call) Run(run func(ctx context.Context, id string)) *Repository_GetLabel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetLabel_Call) Return(label *models.Label, err error) *Repository_GetLabel_Call {
+	_c.Call.Return(label, err)
+	return _c
+}
+
+func (_c *Repository_GetLabel_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.Label, error)) *Repository_GetLabel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetLabelByName provides a mock function for the type Repository
+func (_mock *Repository) GetLabelByName(ctx context.Context, projectID string, name string) (*models.Label, error) {
+	ret := _mock.Called(ctx, projectID, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLabelByName")
+	}
+
+	var r0 *models.Label
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.Label, error)); ok {
+		return returnFunc(ctx, projectID, name)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.Label); ok {
+		r0 = returnFunc(ctx, projectID, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Label)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, projectID, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetLabelByName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLabelByName'
+type Repository_GetLabelByName_Call struct {
+	*mock.Call
+}
+
+// GetLabelByName is a helper method to define mock.On call
+//   - ctx context.Context
+//   - projectID string
+//   - name string
+func (_e *Repository_Expecter) GetLabelByName(ctx interface{}, projectID interface{}, name interface{}) *Repository_GetLabelByName_Call {
+	return &Repository_GetLabelByName_Call{Call: _e.mock.On("GetLabelByName", ctx, projectID, name)}
+}
+
+func (_c *Repository_GetLabelByName_Call) Run(run func(ctx context.Context, projectID string, name string)) *Repository_GetLabelByName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetLabelByName_Call) Return(label *models.Label, err error) *Repository_GetLabelByName_Call {
+	_c.Call.Return(label, err)
+	return _c
+}
+
+func (_c *Repository_GetLabelByName_Call) RunAndReturn(run func(ctx context.Context, projectID string, name string) (*models.Label, error)) *Repository_GetLabelByName_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetMaxMessageUID provides a mock function for the type Repository
+func (_mock *Repository) GetMaxMessageUID(ctx context.Context, inboxID string, folderID string) (uint32, error) {
+	ret := _mock.Called(ctx, inboxID, folderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMaxMessageUID")
+	}
+
+	var r0 uint32
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (uint32, error)); ok {
+		return returnFunc(ctx, inboxID, folderID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) uint32); ok {
+		r0 = returnFunc(ctx, inboxID, folderID)
+	} else {
+		r0 = ret.Get(0).(uint32)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, inboxID, folderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetMaxMessageUID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetMaxMessageUID'
+type Repository_GetMaxMessageUID_Call struct {
+	*mock.Call
+}
+
+// GetMaxMessageUID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - folderID string
+func (_e *Repository_Expecter) GetMaxMessageUID(ctx interface{}, inboxID interface{}, folderID interface{}) *Repository_GetMaxMessageUID_Call {
+	return &Repository_GetMaxMessageUID_Call{Call: _e.mock.On("GetMaxMessageUID", ctx, inboxID, folderID)}
+}
+
+func (_c *Repository_GetMaxMessageUID_Call) Run(run func(ctx context.Context, inboxID string, folderID string)) *Repository_GetMaxMessageUID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetMaxMessageUID_Call) Return(v uint32, err error) *Repository_GetMaxMessageUID_Call {
+	_c.Call.Return(v, err)
+	return _c
+}
+
+func (_c *Repository_GetMaxMessageUID_Call) RunAndReturn(run func(ctx context.Context, inboxID string, folderID string) (uint32, error)) *Repository_GetMaxMessageUID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetMessage provides a mock function for the type Repository
+func (_mock *Repository) GetMessage(ctx context.Context, id string) (*models.Message, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMessage")
+	}
+
+	var r0 *models.Message
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Message, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Message); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Message)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetMessage'
+type Repository_GetMessage_Call struct {
+	*mock.Call
+}
+
+// GetMessage is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Repository_Expecter) GetMessage(ctx interface{}, id interface{}) *Repository_GetMessage_Call {
+	return &Repository_GetMessage_Call{Call: _e.mock.On("GetMessage", ctx, id)}
+}
+
+func (_c *Repository_GetMessage_Call) Run(run func(ctx context.Context, id string)) *Repository_GetMessage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetMessage_Call) Return(message *models.Message, err error) *Repository_GetMessage_Call {
+	_c.Call.Return(message, err)
+	return _c
+}
+
+func (_c *Repository_GetMessage_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.Message, error)) *Repository_GetMessage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetMessageIDFromUID provides a mock function for the type Repository
+func (_mock *Repository) GetMessageIDFromUID(ctx context.Context, inboxID string, folderID string, uid uint32) (string, error) {
+	ret := _mock.Called(ctx, inboxID, folderID, uid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMessageIDFromUID")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, uint32) (string, error)); ok {
+		return returnFunc(ctx, inboxID, folderID, uid)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, uint32) string); ok {
+		r0 = returnFunc(ctx, inboxID, folderID, uid)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, uint32) error); ok {
+		r1 = returnFunc(ctx, inboxID, folderID, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetMessageIDFromUID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetMessageIDFromUID'
+type Repository_GetMessageIDFromUID_Call struct {
+	*mock.Call
+}
+
+// GetMessageIDFromUID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - folderID string
+//   - uid uint32
+func (_e *Repository_Expecter) GetMessageIDFromUID(ctx interface{}, inboxID interface{}, folderID interface{}, uid interface{}) *Repository_GetMessageIDFromUID_Call {
+	return &Repository_GetMessageIDFromUID_Call{Call: _e.mock.On("GetMessageIDFromUID", ctx, inboxID, folderID, uid)}
+}
+
+func (_c *Repository_GetMessageIDFromUID_Call) Run(run func(ctx context.Context, inboxID string, folderID string, uid uint32)) *Repository_GetMessageIDFromUID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 uint32
+		if args[3] != nil {
+			arg3 = args[3].(uint32)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetMessageIDFromUID_Call) Return(s string, err error) *Repository_GetMessageIDFromUID_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *Repository_GetMessageIDFromUID_Call) RunAndReturn(run func(ctx context.Context, inboxID string, folderID string, uid uint32) (string, error)) *Repository_GetMessageIDFromUID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetMessagesByUIDs provides a mock function for the type Repository
+func (_mock *Repository) GetMessagesByUIDs(ctx context.Context, inboxID string, folderID string, uids []uint32) ([]*models.Message, error) {
+	ret := _mock.Called(ctx, inboxID, folderID, uids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMessagesByUIDs")
+	}
+
+	var r0 []*models.Message
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []uint32) ([]*models.Message, error)); ok {
+		return returnFunc(ctx, inboxID, folderID, uids)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []uint32) []*models.Message); ok {
+		r0 = returnFunc(ctx, inboxID, folderID, uids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Message)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, []uint32) error); ok {
+		r1 = returnFunc(ctx, inboxID, folderID, uids)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetMessagesByUIDs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetMessagesByUIDs'
+type Repository_GetMessagesByUIDs_Call struct {
+	*mock.Call
+}
+
+// GetMessagesByUIDs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - folderID string
+//   - uids []uint32
+func (_e *Repository_Expecter) GetMessagesByUIDs(ctx interface{}, inboxID interface{}, folderID interface{}, uids interface{}) *Repository_GetMessagesByUIDs_Call {
+	return &Repository_GetMessagesByUIDs_Call{Call: _e.mock.On("GetMessagesByUIDs", ctx, inboxID, folderID, uids)}
+}
+
+func (_c *Repository_GetMessagesByUIDs_Call) Run(run func(ctx context.Context, inboxID string, folderID string, uids []uint32)) *Repository_GetMessagesByUIDs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []uint32
+		if args[3] != nil {
+			arg3 = args[3].([]uint32)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetMessagesByUIDs_Call) Return(messages []*models.Message, err error) *Repository_GetMessagesByUIDs_Call {
+	_c.Call.Return(messages, err)
+	return _c
+}
+
+func (_c *Repository_GetMessagesByUIDs_Call) RunAndReturn(run func(ctx context.Context, inboxID string, folderID string, uids []uint32) ([]*models.Message, error)) *Repository_GetMessagesByUIDs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetProject provides a mock function for the type Repository
+func (_mock *Repository) GetProject(ctx context.Context, id string) (*models.Project, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetProject")
+	}
+
+	var r0 *models.Project
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Project, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Project); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Project)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetProject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetProject'
+type Repository_GetProject_Call struct {
+	*mock.Call
+}
+
+// GetProject is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Repository_Expecter) GetProject(ctx interface{}, id interface{}) *Repository_GetProject_Call {
+	return &Repository_GetProject_Call{Call: _e.mock.On("GetProject", ctx, id)}
+}
+
+func (_c *Repository_GetProject_Call) Run(run func(ctx context.Context, id string)) *Repository_GetProject_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetProject_Call) Return(project *models.Project, err error) *Repository_GetProject_Call {
+	_c.Call.Return(project, err)
+	return _c
+}
+
+func (_c *Repository_GetProject_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.Project, error)) *Repository_GetProject_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRule provides a mock function for the type Repository
+func (_mock *Repository) GetRule(ctx context.Context, id string) (*models.ForwardRule, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRule")
+	}
+
+	var r0 *models.ForwardRule
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.ForwardRule, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.ForwardRule); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ForwardRule)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetRule_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRule'
+type Repository_GetRule_Call struct {
+	*mock.Call
+}
+
+// GetRule is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Repository_Expecter) GetRule(ctx interface{}, id interface{}) *Repository_GetRule_Call {
+	return &Repository_GetRule_Call{Call: _e.mock.On("GetRule", ctx, id)}
+}
+
+func (_c *Repository_GetRule_Call) Run(run func(ctx context.Context, id string)) *Repository_GetRule_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetRule_Call) Return(forwardRule *models.ForwardRule, err error) *Repository_GetRule_Call {
+	_c.Call.Return(forwardRule, err)
+	return _c
+}
+
+func (_c *Repository_GetRule_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.ForwardRule, error)) *Repository_GetRule_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSenderAddress provides a mock function for the type Repository
+func (_mock *Repository) GetSenderAddress(ctx context.Context, id string) (*models.SenderAddress, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSenderAddress")
+	}
+
+	var r0 *models.SenderAddress
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.SenderAddress, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.SenderAddress); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.SenderAddress)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetSenderAddress_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSenderAddress'
+type Repository_GetSenderAddress_Call struct {
+	*mock.Call
+}
+
+// GetSenderAddress is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Repository_Expecter) GetSenderAddress(ctx interface{}, id interface{}) *Repository_GetSenderAddress_Call {
+	return &Repository_GetSenderAddress_Call{Call: _e.mock.On("GetSenderAddress", ctx, id)}
+}
+
+func (_c *Repository_GetSenderAddress_Call) Run(run func(ctx context.Context, id string)) *Repository_GetSenderAddress_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetSenderAddress_Call) Return(senderAddress *models.SenderAddress, err error) *Repository_GetSenderAddress_Call {
+	_c.Call.Return(senderAddress, err)
+	return _c
+}
+
+func (_c *Repository_GetSenderAddress_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.SenderAddress, error)) *Repository_GetSenderAddress_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTLSReport provides a mock function for the type Repository
+func (_mock *Repository) GetTLSReport(ctx context.Context, id string) (*models.TLSReport, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTLSReport")
+	}
+
+	var r0 *models.TLSReport
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.TLSReport, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.TLSReport); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TLSReport)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetTLSReport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTLSReport'
+type Repository_GetTLSReport_Call struct {
+	*mock.Call
+}
+
+// GetTLSReport is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Repository_Expecter) GetTLSReport(ctx interface{}, id interface{}) *Repository_GetTLSReport_Call {
+	return &Repository_GetTLSReport_Call{Call: _e.mock.On("GetTLSReport", ctx, id)}
+}
+
+func (_c *Repository_GetTLSReport_Call) Run(run func(ctx context.Context, id string)) *Repository_GetTLSReport_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetTLSReport_Call) Return(tLSReport *models.TLSReport, err error) *Repository_GetTLSReport_Call {
+	_c.Call.Return(tLSReport, err)
+	return _c
+}
+
+func (_c *Repository_GetTLSReport_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.TLSReport, error)) *Repository_GetTLSReport_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTokenByUser provides a mock function for the type Repository
+func (_mock *Repository) GetTokenByUser(ctx context.Context, userID string, tokenID string) (*models.Token, error) {
+	ret := _mock.Called(ctx, userID, tokenID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTokenByUser")
+	}
+
+	var r0 *models.Token
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.Token, error)); ok {
+		return returnFunc(ctx, userID, tokenID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.Token); ok {
+		r0 = returnFunc(ctx, userID, tokenID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Token)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, userID, tokenID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetTokenByUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTokenByUser'
+type Repository_GetTokenByUser_Call struct {
+	*mock.Call
+}
+
+// GetTokenByUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - tokenID string
+func (_e *Repository_Expecter) GetTokenByUser(ctx interface{}, userID interface{}, tokenID interface{}) *Repository_GetTokenByUser_Call {
+	return &Repository_GetTokenByUser_Call{Call: _e.mock.On("GetTokenByUser", ctx, userID, tokenID)}
+}
+
+func (_c *Repository_GetTokenByUser_Call) Run(run func(ctx context.Context, userID string, tokenID string)) *Repository_GetTokenByUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetTokenByUser_Call) Return(token *models.Token, err error) *Repository_GetTokenByUser_Call {
+	_c.Call.Return(token, err)
+	return _c
+}
+
+func (_c *Repository_GetTokenByUser_Call) RunAndReturn(run func(ctx context.Context, userID string, tokenID string) (*models.Token, error)) *Repository_GetTokenByUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTokenByValue provides a mock function for the type Repository
+func (_mock *Repository) GetTokenByValue(ctx context.Context, tokenValue string) (*models.Token, error) {
+	ret := _mock.Called(ctx, tokenValue)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTokenByValue")
+	}
+
+	var r0 *models.Token
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Token, error)); ok {
+		return returnFunc(ctx, tokenValue)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Token); ok {
+		r0 = returnFunc(ctx, tokenValue)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Token)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, tokenValue)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetTokenByValue_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTokenByValue'
+type Repository_GetTokenByValue_Call struct {
+	*mock.Call
+}
+
+// GetTokenByValue is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tokenValue string
+func (_e *Repository_Expecter) GetTokenByValue(ctx interface{}, tokenValue interface{}) *Repository_GetTokenByValue_Call {
+	return &Repository_GetTokenByValue_Call{Call: _e.mock.On("GetTokenByValue", ctx, tokenValue)}
+}
+
+func (_c *Repository_GetTokenByValue_Call) Run(run func(ctx context.Context, tokenValue string)) *Repository_GetTokenByValue_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetTokenByValue_Call) Return(token *models.Token, err error) *Repository_GetTokenByValue_Call {
+	_c.Call.Return(token, err)
+	return _c
+}
+
+func (_c *Repository_GetTokenByValue_Call) RunAndReturn(run func(ctx context.Context, tokenValue string) (*models.Token, error)) *Repository_GetTokenByValue_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUser provides a mock function for the type Repository
+func (_mock *Repository) GetUser(ctx context.Context, id string) (*models.User, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUser")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUser'
+type Repository_GetUser_Call struct {
+	*mock.Call
+}
+
+// GetUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Repository_Expecter) GetUser(ctx interface{}, id interface{}) *Repository_GetUser_Call {
+	return &Repository_GetUser_Call{Call: _e.mock.On("GetUser", ctx, id)}
+}
+
+func (_c *Repository_GetUser_Call) Run(run func(ctx context.Context, id string)) *Repository_GetUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetUser_Call) Return(user *models.User, err error) *Repository_GetUser_Call {
+	_c.Call.Return(user, err)
+	return _c
+}
+
+func (_c *Repository_GetUser_Call) RunAndReturn(run func(ctx context.Context, id string) (*models.User, error)) *Repository_GetUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserByEmail provides a mock function for the type Repository
+func (_mock *Repository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ret := _mock.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByEmail")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
+		return returnFunc(ctx, email)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = returnFunc(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetUserByEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByEmail'
+type Repository_GetUserByEmail_Call struct {
+	*mock.Call
+}
+
+// GetUserByEmail is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+func (_e *Repository_Expecter) GetUserByEmail(ctx interface{}, email interface{}) *Repository_GetUserByEmail_Call {
+	return &Repository_GetUserByEmail_Call{Call: _e.mock.On("GetUserByEmail", ctx, email)}
+}
+
+func (_c *Repository_GetUserByEmail_Call) Run(run func(ctx context.Context, email string)) *Repository_GetUserByEmail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetUserByEmail_Call) Return(user *models.User, err error) *Repository_GetUserByEmail_Call {
+	_c.Call.Return(user, err)
+	return _c
+}
+
+func (_c *Repository_GetUserByEmail_Call) RunAndReturn(run func(ctx context.Context, email string) (*models.User, error)) *Repository_GetUserByEmail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserByUsername provides a mock function for the type Repository
+func (_mock *Repository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ret := _mock.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByUsername")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.User, error)); ok {
+		return returnFunc(ctx, username)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = returnFunc(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_GetUserByUsername_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByUsername'
+type Repository_GetUserByUsername_Call struct {
+	*mock.Call
+}
+
+// GetUserByUsername is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+func (_e *Repository_Expecter) GetUserByUsername(ctx interface{}, username interface{}) *Repository_GetUserByUsername_Call {
+	return &Repository_GetUserByUsername_Call{Call: _e.mock.On("GetUserByUsername", ctx, username)}
+}
+
+func (_c *Repository_GetUserByUsername_Call) Run(run func(ctx context.Context, username string)) *Repository_GetUserByUsername_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_GetUserByUsername_Call) Return(user *models.User, err error) *Repository_GetUserByUsername_Call {
+	_c.Call.Return(user, err)
+	return _c
+}
+
+func (_c *Repository_GetUserByUsername_Call) RunAndReturn(run func(ctx context.Context, username string) (*models.User, error)) *Repository_GetUserByUsername_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsSenderAllowed provides a mock function for the type Repository
+func (_mock *Repository) IsSenderAllowed(ctx context.Context, userID string, addresses []string) (bool, error) {
+	ret := _mock.Called(ctx, userID, addresses)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsSenderAllowed")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) (bool, error)); ok {
+		return returnFunc(ctx, userID, addresses)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string) bool); ok {
+		r0 = returnFunc(ctx, userID, addresses)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = returnFunc(ctx, userID, addresses)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_IsSenderAllowed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsSenderAllowed'
+type Repository_IsSenderAllowed_Call struct {
+	*mock.Call
+}
+
+// IsSenderAllowed is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - addresses []string
+func (_e *Repository_Expecter) IsSenderAllowed(ctx interface{}, userID interface{}, addresses interface{}) *Repository_IsSenderAllowed_Call {
+	return &Repository_IsSenderAllowed_Call{Call: _e.mock.On("IsSenderAllowed", ctx, userID, addresses)}
+}
+
+func (_c *Repository_IsSenderAllowed_Call) Run(run func(ctx context.Context, userID string, addresses []string)) *Repository_IsSenderAllowed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		if args[2] != nil {
+			arg2 = args[2].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_IsSenderAllowed_Call) Return(b bool, err error) *Repository_IsSenderAllowed_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *Repository_IsSenderAllowed_Call) RunAndReturn(run func(ctx context.Context, userID string, addresses []string) (bool, error)) *Repository_IsSenderAllowed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListDKIMKeys provides a mock function for the type Repository
+func (_mock *Repository) ListDKIMKeys(ctx context.Context, limit int, offset int) ([]*models.DKIMKey, int, error) {
+	ret := _mock.Called(ctx, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDKIMKeys")
+	}
+
+	var r0 []*models.DKIMKey
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) ([]*models.DKIMKey, int, error)); ok {
+		return returnFunc(ctx, limit, offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) []*models.DKIMKey); ok {
+		r0 = returnFunc(ctx, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.DKIMKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int) int); ok {
+		r1 = returnFunc(ctx, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, int, int) error); ok {
+		r2 = returnFunc(ctx, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Repository_ListDKIMKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListDKIMKeys'
+type Repository_ListDKIMKeys_Call struct {
+	*mock.Call
+}
+
+// ListDKIMKeys is a helper method to define mock.On call
+//   - ctx context.Context
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListDKIMKeys(ctx interface{}, limit interface{}, offset interface{}) *Repository_ListDKIMKeys_Call {
+	return &Repository_ListDKIMKeys_Call{Call: _e.mock.On("ListDKIMKeys", ctx, limit, offset)}
+}
+
+func (_c *Repository_ListDKIMKeys_Call) Run(run func(ctx context.Context, limit int, offset int)) *Repository_ListDKIMKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListDKIMKeys_Call) Return(dKIMKeys []*models.DKIMKey, n int, err error) *Repository_ListDKIMKeys_Call {
+	_c.Call.Return(dKIMKeys, n, err)
+	return _c
+}
+
+func (_c *Repository_ListDKIMKeys_Call) RunAndReturn(run func(ctx context.Context, limit int, offset int) ([]*models.DKIMKey, int, error)) *Repository_ListDKIMKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListDMARCReports provides a mock function for the type Repository
+func (_mock *Repository) ListDMARCReports(ctx context.Context, filter models.DMARCFilter, limit int, offset int) ([]*models.DMARCReport, int, error) {
+	ret := _mock.Called(ctx, filter, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDMARCReports")
+	}
+
+	var r0 []*models.DMARCReport
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.DMARCFilter, int, int) ([]*models.DMARCReport, int, error)); ok {
+		return returnFunc(ctx, filter, limit, offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.DMARCFilter, int, int) []*models.DMARCReport); ok {
+		r0 = returnFunc(ctx, filter, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.DMARCReport)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, models.DMARCFilter, int, int) int); ok {
+		r1 = returnFunc(ctx, filter, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, models.DMARCFilter, int, int) error); ok {
+		r2 = returnFunc(ctx, filter, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Repository_ListDMARCReports_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListDMARCReports'
+type Repository_ListDMARCReports_Call struct {
+	*mock.Call
+}
+
+// ListDMARCReports is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter models.DMARCFilter
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListDMARCReports(ctx interface{}, filter interface{}, limit interface{}, offset interface{}) *Repository_ListDMARCReports_Call {
+	return &Repository_ListDMARCReports_Call{Call: _e.mock.On("ListDMARCReports", ctx, filter, limit, offset)}
+}
+
+func (_c *Repository_ListDMARCReports_Call) Run(run func(ctx context.Context, filter models.DMARCFilter, limit int, offset int)) *Repository_ListDMARCReports_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 models.DMARCFilter
+		if args[1] != nil {
+			arg1 = args[1].(models.DMARCFilter)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListDMARCReports_Call) Return(dMARCReports []*models.DMARCReport, n int, err error) *Repository_ListDMARCReports_Call {
+	_c.Call.Return(dMARCReports, n, err)
+	return _c
+}
+
+func (_c *Repository_ListDMARCReports_Call) RunAndReturn(run func(ctx context.Context, filter models.DMARCFilter, limit int, offset int) ([]*models.DMARCReport, int, error)) *Repository_ListDMARCReports_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListExpungedMessageUIDs provides a mock function for the type Repository
+func (_mock *Repository) ListExpungedMessageUIDs(ctx context.Context, inboxID string, folderID string, sinceModSeq uint64) ([]uint32, error) {
+	ret := _mock.Called(ctx, inboxID, folderID, sinceModSeq)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListExpungedMessageUIDs")
+	}
+
+	var r0 []uint32
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, uint64) ([]uint32, error)); ok {
+		return returnFunc(ctx, inboxID, folderID, sinceModSeq)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, uint64) []uint32); ok {
+		r0 = returnFunc(ctx, inboxID, folderID, sinceModSeq)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint32)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, uint64) error); ok {
+		r1 = returnFunc(ctx, inboxID, folderID, sinceModSeq)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_ListExpungedMessageUIDs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListExpungedMessageUIDs'
+type Repository_ListExpungedMessageUIDs_Call struct {
+	*mock.Call
+}
+
+// ListExpungedMessageUIDs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - folderID string
+//   - sinceModSeq uint64
+func (_e *Repository_Expecter) ListExpungedMessageUIDs(ctx interface{}, inboxID interface{}, folderID interface{}, sinceModSeq interface{}) *Repository_ListExpungedMessageUIDs_Call {
+	return &Repository_ListExpungedMessageUIDs_Call{Call: _e.mock.On("ListExpungedMessageUIDs", ctx, inboxID, folderID, sinceModSeq)}
+}
+
+func (_c *Repository_ListExpungedMessageUIDs_Call) Run(run func(ctx context.Context, inboxID string, folderID string, sinceModSeq uint64)) *Repository_ListExpungedMessageUIDs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 uint64
+		if args[3] != nil {
+			arg3 = args[3].(uint64)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListExpungedMessageUIDs_Call) Return(uint32s []uint32, err error) *Repository_ListExpungedMessageUIDs_Call {
+	_c.Call.Return(uint32s, err)
+	return _c
+}
+
+func (_c *Repository_ListExpungedMessageUIDs_Call) RunAndReturn(run func(ctx context.Context, inboxID string, folderID string, sinceModSeq uint64) ([]uint32, error)) *Repository_ListExpungedMessageUIDs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListFoldersByInbox provides a mock function for the type Repository
+func (_mock *Repository) ListFoldersByInbox(ctx context.Context, inboxID string, limit int, offset int) ([]*models.Folder, int, error) {
+	ret := _mock.Called(ctx, inboxID, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListFoldersByInbox")
+	}
+
+	var r0 []*models.Folder
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.Folder, int, error)); ok {
+		return returnFunc(ctx, inboxID, limit, offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Folder); ok {
+		r0 = returnFunc(ctx, inboxID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Folder)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = returnFunc(ctx, inboxID, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = returnFunc(ctx, inboxID, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Repository_ListFoldersByInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListFoldersByInbox'
+type Repository_ListFoldersByInbox_Call struct {
+	*mock.Call
+}
+
+// ListFoldersByInbox is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListFoldersByInbox(ctx interface{}, inboxID interface{}, limit interface{}, offset interface{}) *Repository_ListFoldersByInbox_Call {
+	return &Repository_ListFoldersByInbox_Call{Call: _e.mock.On("ListFoldersByInbox", ctx, inboxID, limit, offset)}
+}
+
+func (_c *Repository_ListFoldersByInbox_Call) Run(run func(ctx context.Context, inboxID string, limit int, offset int)) *Repository_ListFoldersByInbox_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListFoldersByInbox_Call) Return(folders []*models.Folder, n int, err error) *Repository_ListFoldersByInbox_Call {
+	_c.Call.Return(folders, n, err)
+	return _c
+}
+
+func (_c *Repository_ListFoldersByInbox_Call) RunAndReturn(run func(ctx context.Context, inboxID string, limit int, offset int) ([]*models.Folder, int, error)) *Repository_ListFoldersByInbox_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListInboxesByProject provides a mock function for the type Repository
+func (_mock *Repository) ListInboxesByProject(ctx context.Context, projectID string, limit int, offset int) ([]*models.Inbox, int, error) {
+	ret := _mock.Called(ctx, projectID, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListInboxesByProject")
+	}
+
+	var r0 []*models.Inbox
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.Inbox, int, error)); ok {
+		return returnFunc(ctx, projectID, limit, offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Inbox); ok {
+		r0 = returnFunc(ctx, projectID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Inbox)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = returnFunc(ctx, projectID, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = returnFunc(ctx, projectID, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Repository_ListInboxesByProject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListInboxesByProject'
+type Repository_ListInboxesByProject_Call struct {
+	*mock.Call
+}
+
+// ListInboxesByProject is a helper method to define mock.On call
+//   - ctx context.Context
+//   - projectID string
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListInboxesByProject(ctx interface{}, projectID interface{}, limit interface{}, offset interface{}) *Repository_ListInboxesByProject_Call {
+	return &Repository_ListInboxesByProject_Call{Call: _e.mock.On("ListInboxesByProject", ctx, projectID, limit, offset)}
+}
+
+func (_c *Repository_ListInboxesByProject_Call) Run(run func(ctx context.Context, projectID string, limit int, offset int)) *Repository_ListInboxesByProject_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
 		)
 	})
 	return _c
@@ -1911,98 +4647,1126 @@ func (_c *Repository_ListInboxesByProject_Call) Return(inboxs []*models.Inbox, n
 	return _c
 }
 
-func (_c *Repository_ListInboxesByProject_Call) RunAndReturn(run func(ctx context.Context, projectID string, limit int, offset int) ([]*models.Inbox, int, error)) *Repository_ListInboxesByProject_Call {
+func (_c *Repository_ListInboxesByProject_Call) RunAndReturn(run func(ctx context.Context, projectID string, limit int, offset int) ([]*models.Inbox, int, error)) *Repository_ListInboxesByProject_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListInboxesByUser provides a mock function for the type Repository
+func (_mock *Repository) ListInboxesByUser(ctx context.Context, userID string) ([]*models.Inbox, error) {
+	ret := _mock.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListInboxesByUser")
+	}
+
+	var r0 []*models.Inbox
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]*models.Inbox, error)); ok {
+		return returnFunc(ctx, userID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []*models.Inbox); ok {
+		r0 = returnFunc(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Inbox)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_ListInboxesByUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListInboxesByUser'
+type Repository_ListInboxesByUser_Call struct {
+	*mock.Call
+}
+
+// ListInboxesByUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+func (_e *Repository_Expecter) ListInboxesByUser(ctx interface{}, userID interface{}) *Repository_ListInboxesByUser_Call {
+	return &Repository_ListInboxesByUser_Call{Call: _e.mock.On("ListInboxesByUser", ctx, userID)}
+}
+
+func (_c *Repository_ListInboxesByUser_Call) Run(run func(ctx context.Context, userID string)) *Repository_ListInboxesByUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListInboxesByUser_Call) Return(inboxs []*models.Inbox, err error) *Repository_ListInboxesByUser_Call {
+	_c.Call.Return(inboxs, err)
+	return _c
+}
+
+func (_c *Repository_ListInboxesByUser_Call) RunAndReturn(run func(ctx context.Context, userID string) ([]*models.Inbox, error)) *Repository_ListInboxesByUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListLabelsByMessages provides a mock function for the type Repository
+func (_mock *Repository) ListLabelsByMessages(ctx context.Context, messageIDs []string) ([]*models.MessageLabel, error) {
+	ret := _mock.Called(ctx, messageIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListLabelsByMessages")
+	}
+
+	var r0 []*models.MessageLabel
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]*models.MessageLabel, error)); ok {
+		return returnFunc(ctx, messageIDs)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []*models.MessageLabel); ok {
+		r0 = returnFunc(ctx, messageIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.MessageLabel)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, messageIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_ListLabelsByMessages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListLabelsByMessages'
+type Repository_ListLabelsByMessages_Call struct {
+	*mock.Call
+}
+
+// ListLabelsByMessages is a helper method to define mock.On call
+//   - ctx context.Context
+//   - messageIDs []string
+func (_e *Repository_Expecter) ListLabelsByMessages(ctx interface{}, messageIDs interface{}) *Repository_ListLabelsByMessages_Call {
+	return &Repository_ListLabelsByMessages_Call{Call: _e.mock.On("ListLabelsByMessages", ctx, messageIDs)}
+}
+
+func (_c *Repository_ListLabelsByMessages_Call) Run(run func(ctx context.Context, messageIDs []string)) *Repository_ListLabelsByMessages_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListLabelsByMessages_Call) Return(messageLabels []*models.MessageLabel, err error) *Repository_ListLabelsByMessages_Call {
+	_c.Call.Return(messageLabels, err)
+	return _c
+}
+
+func (_c *Repository_ListLabelsByMessages_Call) RunAndReturn(run func(ctx context.Context, messageIDs []string) ([]*models.MessageLabel, error)) *Repository_ListLabelsByMessages_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListLabelsByProject provides a mock function for the type Repository
+func (_mock *Repository) ListLabelsByProject(ctx context.Context, projectID string, limit int, offset int) ([]*models.Label, int, error) {
+	ret := _mock.Called(ctx, projectID, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListLabelsByProject")
+	}
+
+	var r0 []*models.Label
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.Label, int, error)); ok {
+		return returnFunc(ctx, projectID, limit, offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Label); ok {
+		r0 = returnFunc(ctx, projectID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Label)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = returnFunc(ctx, projectID, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = returnFunc(ctx, projectID, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Repository_ListLabelsByProject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListLabelsByProject'
+type Repository_ListLabelsByProject_Call struct {
+	*mock.Call
+}
+
+// ListLabelsByProject is a helper method to define mock.On call
+//   - ctx context.Context
+//   - projectID string
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListLabelsByProject(ctx interface{}, projectID interface{}, limit interface{}, offset interface{}) *Repository_ListLabelsByProject_Call {
+	return &Repository_ListLabelsByProject_Call{Call: _e.mock.On("ListLabelsByProject", ctx, projectID, limit, offset)}
+}
+
+func (_c *Repository_ListLabelsByProject_Call) Run(run func(ctx context.Context, projectID string, limit int, offset int)) *Repository_ListLabelsByProject_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListLabelsByProject_Call) Return(labels []*models.Label, n int, err error) *Repository_ListLabelsByProject_Call {
+	_c.Call.Return(labels, n, err)
+	return _c
+}
+
+func (_c *Repository_ListLabelsByProject_Call) RunAndReturn(run func(ctx context.Context, projectID string, limit int, offset int) ([]*models.Label, int, error)) *Repository_ListLabelsByProject_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListMessageChanges provides a mock function for the type Repository
+func (_mock *Repository) ListMessageChanges(ctx context.Context, inboxIDs []string, sinceModSeq uint64, limit int) ([]*models.MessageChange, error) {
+	ret := _mock.Called(ctx, inboxIDs, sinceModSeq, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMessageChanges")
+	}
+
+	var r0 []*models.MessageChange
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string, uint64, int) ([]*models.MessageChange, error)); ok {
+		return returnFunc(ctx, inboxIDs, sinceModSeq, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string, uint64, int) []*models.MessageChange); ok {
+		r0 = returnFunc(ctx, inboxIDs, sinceModSeq, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.MessageChange)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string, uint64, int) error); ok {
+		r1 = returnFunc(ctx, inboxIDs, sinceModSeq, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_ListMessageChanges_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMessageChanges'
+type Repository_ListMessageChanges_Call struct {
+	*mock.Call
+}
+
+// ListMessageChanges is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxIDs []string
+//   - sinceModSeq uint64
+//   - limit int
+func (_e *Repository_Expecter) ListMessageChanges(ctx interface{}, inboxIDs interface{}, sinceModSeq interface{}, limit interface{}) *Repository_ListMessageChanges_Call {
+	return &Repository_ListMessageChanges_Call{Call: _e.mock.On("ListMessageChanges", ctx, inboxIDs, sinceModSeq, limit)}
+}
+
+func (_c *Repository_ListMessageChanges_Call) Run(run func(ctx context.Context, inboxIDs []string, sinceModSeq uint64, limit int)) *Repository_ListMessageChanges_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		var arg2 uint64
+		if args[2] != nil {
+			arg2 = args[2].(uint64)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListMessageChanges_Call) Return(messageChanges []*models.MessageChange, err error) *Repository_ListMessageChanges_Call {
+	_c.Call.Return(messageChanges, err)
+	return _c
+}
+
+func (_c *Repository_ListMessageChanges_Call) RunAndReturn(run func(ctx context.Context, inboxIDs []string, sinceModSeq uint64, limit int) ([]*models.MessageChange, error)) *Repository_ListMessageChanges_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListMessagesByIDs provides a mock function for the type Repository
+func (_mock *Repository) ListMessagesByIDs(ctx context.Context, messageIDs []string) ([]*models.Message, error) {
+	ret := _mock.Called(ctx, messageIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMessagesByIDs")
+	}
+
+	var r0 []*models.Message
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]*models.Message, error)); ok {
+		return returnFunc(ctx, messageIDs)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []*models.Message); ok {
+		r0 = returnFunc(ctx, messageIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Message)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, messageIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_ListMessagesByIDs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMessagesByIDs'
+type Repository_ListMessagesByIDs_Call struct {
+	*mock.Call
+}
+
+// ListMessagesByIDs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - messageIDs []string
+func (_e *Repository_Expecter) ListMessagesByIDs(ctx interface{}, messageIDs interface{}) *Repository_ListMessagesByIDs_Call {
+	return &Repository_ListMessagesByIDs_Call{Call: _e.mock.On("ListMessagesByIDs", ctx, messageIDs)}
+}
+
+func (_c *Repository_ListMessagesByIDs_Call) Run(run func(ctx context.Context, messageIDs []string)) *Repository_ListMessagesByIDs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListMessagesByIDs_Call) Return(messages []*models.Message, err error) *Repository_ListMessagesByIDs_Call {
+	_c.Call.Return(messages, err)
+	return _c
+}
+
+func (_c *Repository_ListMessagesByIDs_Call) RunAndReturn(run func(ctx context.Context, messageIDs []string) ([]*models.Message, error)) *Repository_ListMessagesByIDs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListMessagesByInbox provides a mock function for the type Repository
+func (_mock *Repository) ListMessagesByInbox(ctx context.Context, inboxID string, limit int, offset int) ([]*models.Message, int, error) {
+	ret := _mock.Called(ctx, inboxID, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMessagesByInbox")
+	}
+
+	var r0 []*models.Message
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.Message, int, error)); ok {
+		return returnFunc(ctx, inboxID, limit, offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Message); ok {
+		r0 = returnFunc(ctx, inboxID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Message)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = returnFunc(ctx, inboxID, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = returnFunc(ctx, inboxID, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Repository_ListMessagesByInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMessagesByInbox'
+type Repository_ListMessagesByInbox_Call struct {
+	*mock.Call
+}
+
+// ListMessagesByInbox is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListMessagesByInbox(ctx interface{}, inboxID interface{}, limit interface{}, offset interface{}) *Repository_ListMessagesByInbox_Call {
+	return &Repository_ListMessagesByInbox_Call{Call: _e.mock.On("ListMessagesByInbox", ctx, inboxID, limit, offset)}
+}
+
+func (_c *Repository_ListMessagesByInbox_Call) Run(run func(ctx context.Context, inboxID string, limit int, offset int)) *Repository_ListMessagesByInbox_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListMessagesByInbox_Call) Return(messages []*models.Message, n int, err error) *Repository_ListMessagesByInbox_Call {
+	_c.Call.Return(messages, n, err)
+	return _c
+}
+
+func (_c *Repository_ListMessagesByInbox_Call) RunAndReturn(run func(ctx context.Context, inboxID string, limit int, offset int) ([]*models.Message, int, error)) *Repository_ListMessagesByInbox_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListMessagesByInboxWithFilter provides a mock function for the type Repository
+func (_mock *Repository) ListMessagesByInboxWithFilter(ctx context.Context, inboxID string, isRead *bool, limit int, offset int) ([]*models.Message, int, error) {
+	ret := _mock.Called(ctx, inboxID, isRead, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMessagesByInboxWithFilter")
+	}
+
+	var r0 []*models.Message
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, *bool, int, int) ([]*models.Message, int, error)); ok {
+		return returnFunc(ctx, inboxID, isRead, limit, offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, *bool, int, int) []*models.Message); ok {
+		r0 = returnFunc(ctx, inboxID, isRead, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Message)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, *bool, int, int) int); ok {
+		r1 = returnFunc(ctx, inboxID, isRead, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, *bool, int, int) error); ok {
+		r2 = returnFunc(ctx, inboxID, isRead, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Repository_ListMessagesByInboxWithFilter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMessagesByInboxWithFilter'
+type Repository_ListMessagesByInboxWithFilter_Call struct {
+	*mock.Call
+}
+
+// ListMessagesByInboxWithFilter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - isRead *bool
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListMessagesByInboxWithFilter(ctx interface{}, inboxID interface{}, isRead interface{}, limit interface{}, offset interface{}) *Repository_ListMessagesByInboxWithFilter_Call {
+	return &Repository_ListMessagesByInboxWithFilter_Call{Call: _e.mock.On("ListMessagesByInboxWithFilter", ctx, inboxID, isRead, limit, offset)}
+}
+
+func (_c *Repository_ListMessagesByInboxWithFilter_Call) Run(run func(ctx context.Context, inboxID string, isRead *bool, limit int, offset int)) *Repository_ListMessagesByInboxWithFilter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 *bool
+		if args[2] != nil {
+			arg2 = args[2].(*bool)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		var arg4 int
+		if args[4] != nil {
+			arg4 = args[4].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListMessagesByInboxWithFilter_Call) Return(messages []*models.Message, n int, err error) *Repository_ListMessagesByInboxWithFilter_Call {
+	_c.Call.Return(messages, n, err)
+	return _c
+}
+
+func (_c *Repository_ListMessagesByInboxWithFilter_Call) RunAndReturn(run func(ctx context.Context, inboxID string, isRead *bool, limit int, offset int) ([]*models.Message, int, error)) *Repository_ListMessagesByInboxWithFilter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListMessagesByInboxWithFilters provides a mock function for the type Repository
+func (_mock *Repository) ListMessagesByInboxWithFilters(ctx context.Context, inboxID string, filters models.MessageFilters, limit int, offset int) ([]*models.Message, int, error) {
+	ret := _mock.Called(ctx, inboxID, filters, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMessagesByInboxWithFilters")
+	}
+
+	var r0 []*models.Message
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, models.MessageFilters, int, int) ([]*models.Message, int, error)); ok {
+		return returnFunc(ctx, inboxID, filters, limit, offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, models.MessageFilters, int, int) []*models.Message); ok {
+		r0 = returnFunc(ctx, inboxID, filters, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Message)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, models.MessageFilters, int, int) int); ok {
+		r1 = returnFunc(ctx, inboxID, filters, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, models.MessageFilters, int, int) error); ok {
+		r2 = returnFunc(ctx, inboxID, filters, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Repository_ListMessagesByInboxWithFilters_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMessagesByInboxWithFilters'
+type Repository_ListMessagesByInboxWithFilters_Call struct {
+	*mock.Call
+}
+
+// ListMessagesByInboxWithFilters is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - filters models.MessageFilters
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListMessagesByInboxWithFilters(ctx interface{}, inboxID interface{}, filters interface{}, limit interface{}, offset interface{}) *Repository_ListMessagesByInboxWithFilters_Call {
+	return &Repository_ListMessagesByInboxWithFilters_Call{Call: _e.mock.On("ListMessagesByInboxWithFilters", ctx, inboxID, filters, limit, offset)}
+}
+
+func (_c *Repository_ListMessagesByInboxWithFilters_Call) Run(run func(ctx context.Context, inboxID string, filters models.MessageFilters, limit int, offset int)) *Repository_ListMessagesByInboxWithFilters_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 models.MessageFilters
+		if args[2] != nil {
+			arg2 = args[2].(models.MessageFilters)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		var arg4 int
+		if args[4] != nil {
+			arg4 = args[4].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListMessagesByInboxWithFilters_Call) Return(messages []*models.Message, n int, err error) *Repository_ListMessagesByInboxWithFilters_Call {
+	_c.Call.Return(messages, n, err)
+	return _c
+}
+
+func (_c *Repository_ListMessagesByInboxWithFilters_Call) RunAndReturn(run func(ctx context.Context, inboxID string, filters models.MessageFilters, limit int, offset int) ([]*models.Message, int, error)) *Repository_ListMessagesByInboxWithFilters_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListMessagesByThreads provides a mock function for the type Repository
+func (_mock *Repository) ListMessagesByThreads(ctx context.Context, threadIDs []string) ([]*models.Message, error) {
+	ret := _mock.Called(ctx, threadIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMessagesByThreads")
+	}
+
+	var r0 []*models.Message
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]*models.Message, error)); ok {
+		return returnFunc(ctx, threadIDs)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []*models.Message); ok {
+		r0 = returnFunc(ctx, threadIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Message)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, threadIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_ListMessagesByThreads_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMessagesByThreads'
+type Repository_ListMessagesByThreads_Call struct {
+	*mock.Call
+}
+
+// ListMessagesByThreads is a helper method to define mock.On call
+//   - ctx context.Context
+//   - threadIDs []string
+func (_e *Repository_Expecter) ListMessagesByThreads(ctx interface{}, threadIDs interface{}) *Repository_ListMessagesByThreads_Call {
+	return &Repository_ListMessagesByThreads_Call{Call: _e.mock.On("ListMessagesByThreads", ctx, threadIDs)}
+}
+
+func (_c *Repository_ListMessagesByThreads_Call) Run(run func(ctx context.Context, threadIDs []string)) *Repository_ListMessagesByThreads_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListMessagesByThreads_Call) Return(messages []*models.Message, err error) *Repository_ListMessagesByThreads_Call {
+	_c.Call.Return(messages, err)
+	return _c
+}
+
+func (_c *Repository_ListMessagesByThreads_Call) RunAndReturn(run func(ctx context.Context, threadIDs []string) ([]*models.Message, error)) *Repository_ListMessagesByThreads_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListProjects provides a mock function for the type Repository
+func (_mock *Repository) ListProjects(ctx context.Context, limit int, offset int) ([]*models.Project, int, error) {
+	ret := _mock.Called(ctx, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListProjects")
+	}
+
+	var r0 []*models.Project
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) ([]*models.Project, int, error)); ok {
+		return returnFunc(ctx, limit, offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) []*models.Project); ok {
+		r0 = returnFunc(ctx, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Project)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int) int); ok {
+		r1 = returnFunc(ctx, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, int, int) error); ok {
+		r2 = returnFunc(ctx, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Repository_ListProjects_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListProjects'
+type Repository_ListProjects_Call struct {
+	*mock.Call
+}
+
+// ListProjects is a helper method to define mock.On call
+//   - ctx context.Context
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListProjects(ctx interface{}, limit interface{}, offset interface{}) *Repository_ListProjects_Call {
+	return &Repository_ListProjects_Call{Call: _e.mock.On("ListProjects", ctx, limit, offset)}
+}
+
+func (_c *Repository_ListProjects_Call) Run(run func(ctx context.Context, limit int, offset int)) *Repository_ListProjects_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListProjects_Call) Return(projects []*models.Project, n int, err error) *Repository_ListProjects_Call {
+	_c.Call.Return(projects, n, err)
+	return _c
+}
+
+func (_c *Repository_ListProjects_Call) RunAndReturn(run func(ctx context.Context, limit int, offset int) ([]*models.Project, int, error)) *Repository_ListProjects_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListProjectsByUser provides a mock function for the type Repository
+func (_mock *Repository) ListProjectsByUser(ctx context.Context, userID string, limit int, offset int) ([]*models.Project, int, error) {
+	ret := _mock.Called(ctx, userID, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListProjectsByUser")
+	}
+
+	var r0 []*models.Project
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.Project, int, error)); ok {
+		return returnFunc(ctx, userID, limit, offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Project); ok {
+		r0 = returnFunc(ctx, userID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Project)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = returnFunc(ctx, userID, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = returnFunc(ctx, userID, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Repository_ListProjectsByUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListProjectsByUser'
+type Repository_ListProjectsByUser_Call struct {
+	*mock.Call
+}
+
+// ListProjectsByUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListProjectsByUser(ctx interface{}, userID interface{}, limit interface{}, offset interface{}) *Repository_ListProjectsByUser_Call {
+	return &Repository_ListProjectsByUser_Call{Call: _e.mock.On("ListProjectsByUser", ctx, userID, limit, offset)}
+}
+
+func (_c *Repository_ListProjectsByUser_Call) Run(run func(ctx context.Context, userID string, limit int, offset int)) *Repository_ListProjectsByUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListProjectsByUser_Call) Return(projects []*models.Project, n int, err error) *Repository_ListProjectsByUser_Call {
+	_c.Call.Return(projects, n, err)
+	return _c
+}
+
+func (_c *Repository_ListProjectsByUser_Call) RunAndReturn(run func(ctx context.Context, userID string, limit int, offset int) ([]*models.Project, int, error)) *Repository_ListProjectsByUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListRawMessages provides a mock function for the type Repository
+func (_mock *Repository) ListRawMessages(ctx context.Context, messageIDs []string) ([]*models.Message, error) {
+	ret := _mock.Called(ctx, messageIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRawMessages")
+	}
+
+	var r0 []*models.Message
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]*models.Message, error)); ok {
+		return returnFunc(ctx, messageIDs)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []*models.Message); ok {
+		r0 = returnFunc(ctx, messageIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Message)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, messageIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_ListRawMessages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRawMessages'
+type Repository_ListRawMessages_Call struct {
+	*mock.Call
+}
+
+// ListRawMessages is a helper method to define mock.On call
+//   - ctx context.Context
+//   - messageIDs []string
+func (_e *Repository_Expecter) ListRawMessages(ctx interface{}, messageIDs interface{}) *Repository_ListRawMessages_Call {
+	return &Repository_ListRawMessages_Call{Call: _e.mock.On("ListRawMessages", ctx, messageIDs)}
+}
+
+func (_c *Repository_ListRawMessages_Call) Run(run func(ctx context.Context, messageIDs []string)) *Repository_ListRawMessages_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListRawMessages_Call) Return(messages []*models.Message, err error) *Repository_ListRawMessages_Call {
+	_c.Call.Return(messages, err)
+	return _c
+}
+
+func (_c *Repository_ListRawMessages_Call) RunAndReturn(run func(ctx context.Context, messageIDs []string) ([]*models.Message, error)) *Repository_ListRawMessages_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListRecentMessagesByInbox provides a mock function for the type Repository
+func (_mock *Repository) ListRecentMessagesByInbox(ctx context.Context, inboxID string, limit int) ([]*models.Message, error) {
+	ret := _mock.Called(ctx, inboxID, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRecentMessagesByInbox")
+	}
+
+	var r0 []*models.Message
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int) ([]*models.Message, error)); ok {
+		return returnFunc(ctx, inboxID, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int) []*models.Message); ok {
+		r0 = returnFunc(ctx, inboxID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Message)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = returnFunc(ctx, inboxID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_ListRecentMessagesByInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRecentMessagesByInbox'
+type Repository_ListRecentMessagesByInbox_Call struct {
+	*mock.Call
+}
+
+// ListRecentMessagesByInbox is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - limit int
+func (_e *Repository_Expecter) ListRecentMessagesByInbox(ctx interface{}, inboxID interface{}, limit interface{}) *Repository_ListRecentMessagesByInbox_Call {
+	return &Repository_ListRecentMessagesByInbox_Call{Call: _e.mock.On("ListRecentMessagesByInbox", ctx, inboxID, limit)}
+}
+
+func (_c *Repository_ListRecentMessagesByInbox_Call) Run(run func(ctx context.Context, inboxID string, limit int)) *Repository_ListRecentMessagesByInbox_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListRecentMessagesByInbox_Call) Return(messages []*models.Message, err error) *Repository_ListRecentMessagesByInbox_Call {
+	_c.Call.Return(messages, err)
+	return _c
+}
+
+func (_c *Repository_ListRecentMessagesByInbox_Call) RunAndReturn(run func(ctx context.Context, inboxID string, limit int) ([]*models.Message, error)) *Repository_ListRecentMessagesByInbox_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListInboxesByUser provides a mock function for the type Repository
-func (_mock *Repository) ListInboxesByUser(ctx context.Context, userID string) ([]*models.Inbox, error) {
-	ret := _mock.Called(ctx, userID)
+// ListRules provides a mock function for the type Repository
+func (_mock *Repository) ListRules(ctx context.Context, limit int, offset int) ([]*models.ForwardRule, int, error) {
+	ret := _mock.Called(ctx, limit, offset)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListInboxesByUser")
+		panic("no return value specified for ListRules")
 	}
 
-	var r0 []*models.Inbox
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]*models.Inbox, error)); ok {
-		return returnFunc(ctx, userID)
+	var r0 []*models.ForwardRule
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) ([]*models.ForwardRule, int, error)); ok {
+		return returnFunc(ctx, limit, offset)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []*models.Inbox); ok {
-		r0 = returnFunc(ctx, userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) []*models.ForwardRule); ok {
+		r0 = returnFunc(ctx, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Inbox)
+			r0 = ret.Get(0).([]*models.ForwardRule)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, userID)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int) int); ok {
+		r1 = returnFunc(ctx, limit, offset)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(int)
 	}
-	return r0, r1
+	if returnFunc, ok := ret.Get(2).(func(context.Context, int, int) error); ok {
+		r2 = returnFunc(ctx, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
 }
 
-// Repository_ListInboxesByUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListInboxesByUser'
-type Repository_ListInboxesByUser_Call struct {
+// Repository_ListRules_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRules'
+type Repository_ListRules_Call struct {
 	*mock.Call
 }
 
-// ListInboxesByUser is a helper method to define mock.On call
+// ListRules is a helper method to define mock.On call
 //   - ctx context.Context
-//   - userID string
-func (_e *Repository_Expecter) ListInboxesByUser(ctx interface{}, userID interface{}) *Repository_ListInboxesByUser_Call {
-	return &Repository_ListInboxesByUser_Call{Call: _e.mock.On("ListInboxesByUser", ctx, userID)}
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListRules(ctx interface{}, limit interface{}, offset interface{}) *Repository_ListRules_Call {
+	return &Repository_ListRules_Call{Call: _e.mock.On("ListRules", ctx, limit, offset)}
 }
 
-func (_c *Repository_ListInboxesByUser_Call) Run(run func(ctx context.Context, userID string)) *Repository_ListInboxesByUser_Call {
+func (_c *Repository_ListRules_Call) Run(run func(ctx context.Context, limit int, offset int)) *Repository_ListRules_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 int
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(int)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
 		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_ListInboxesByUser_Call) Return(inboxs []*models.Inbox, err error) *Repository_ListInboxesByUser_Call {
-	_c.Call.Return(inboxs, err)
+func (_c *Repository_ListRules_Call) Return(forwardRules []*models.ForwardRule, n int, err error) *Repository_ListRules_Call {
+	_c.Call.Return(forwardRules, n, err)
 	return _c
 }
 
-func (_c *Repository_ListInboxesByUser_Call) RunAndReturn(run func(ctx context.Context, userID string) ([]*models.Inbox, error)) *Repository_ListInboxesByUser_Call {
+func (_c *Repository_ListRules_Call) RunAndReturn(run func(ctx context.Context, limit int, offset int) ([]*models.ForwardRule, int, error)) *Repository_ListRules_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListMessagesByInbox provides a mock function for the type Repository
-func (_mock *Repository) ListMessagesByInbox(ctx context.Context, inboxID string, limit int, offset int) ([]*models.Message, int, error) {
+// ListRulesByInbox provides a mock function for the type Repository
+func (_mock *Repository) ListRulesByInbox(ctx context.Context, inboxID string, limit int, offset int) ([]*models.ForwardRule, int, error) {
 	ret := _mock.Called(ctx, inboxID, limit, offset)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListMessagesByInbox")
+		panic("no return value specified for ListRulesByInbox")
 	}
 
-	var r0 []*models.Message
+	var r0 []*models.ForwardRule
 	var r1 int
 	var r2 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.Message, int, error)); ok {
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.ForwardRule, int, error)); ok {
 		return returnFunc(ctx, inboxID, limit, offset)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Message); ok {
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.ForwardRule); ok {
 		r0 = returnFunc(ctx, inboxID, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Message)
+			r0 = ret.Get(0).([]*models.ForwardRule)
 		}
 	}
 	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
@@ -2018,21 +5782,193 @@ func (_mock *Repository) ListMessagesByInbox(ctx context.Context, inboxID string
 	return r0, r1, r2
 }
 
-// Repository_ListMessagesByInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMessagesByInbox'
-type Repository_ListMessagesByInbox_Call struct {
+// Repository_ListRulesByInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRulesByInbox'
+type Repository_ListRulesByInbox_Call struct {
+	*mock.Call
+}
+
+// ListRulesByInbox is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListRulesByInbox(ctx interface{}, inboxID interface{}, limit interface{}, offset interface{}) *Repository_ListRulesByInbox_Call {
+	return &Repository_ListRulesByInbox_Call{Call: _e.mock.On("ListRulesByInbox", ctx, inboxID, limit, offset)}
+}
+
+func (_c *Repository_ListRulesByInbox_Call) Run(run func(ctx context.Context, inboxID string, limit int, offset int)) *Repository_ListRulesByInbox_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListRulesByInbox_Call) Return(forwardRules []*models.ForwardRule, n int, err error) *Repository_ListRulesByInbox_Call {
+	_c.Call.Return(forwardRules, n, err)
+	return _c
+}
+
+func (_c *Repository_ListRulesByInbox_Call) RunAndReturn(run func(ctx context.Context, inboxID string, limit int, offset int) ([]*models.ForwardRule, int, error)) *Repository_ListRulesByInbox_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListSenderAddressesByProject provides a mock function for the type Repository
+func (_mock *Repository) ListSenderAddressesByProject(ctx context.Context, projectID string, limit int, offset int) ([]*models.SenderAddress, int, error) {
+	ret := _mock.Called(ctx, projectID, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSenderAddressesByProject")
+	}
+
+	var r0 []*models.SenderAddress
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.SenderAddress, int, error)); ok {
+		return returnFunc(ctx, projectID, limit, offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.SenderAddress); ok {
+		r0 = returnFunc(ctx, projectID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.SenderAddress)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = returnFunc(ctx, projectID, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = returnFunc(ctx, projectID, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Repository_ListSenderAddressesByProject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListSenderAddressesByProject'
+type Repository_ListSenderAddressesByProject_Call struct {
+	*mock.Call
+}
+
+// ListSenderAddressesByProject is a helper method to define mock.On call
+//   - ctx context.Context
+//   - projectID string
+//   - limit int
+//   - offset int
+func (_e *Repository_Expecter) ListSenderAddressesByProject(ctx interface{}, projectID interface{}, limit interface{}, offset interface{}) *Repository_ListSenderAddressesByProject_Call {
+	return &Repository_ListSenderAddressesByProject_Call{Call: _e.mock.On("ListSenderAddressesByProject", ctx, projectID, limit, offset)}
+}
+
+func (_c *Repository_ListSenderAddressesByProject_Call) Run(run func(ctx context.Context, projectID string, limit int, offset int)) *Repository_ListSenderAddressesByProject_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ListSenderAddressesByProject_Call) Return(senderAddresss []*models.SenderAddress, n int, err error) *Repository_ListSenderAddressesByProject_Call {
+	_c.Call.Return(senderAddresss, n, err)
+	return _c
+}
+
+func (_c *Repository_ListSenderAddressesByProject_Call) RunAndReturn(run func(ctx context.Context, projectID string, limit int, offset int) ([]*models.SenderAddress, int, error)) *Repository_ListSenderAddressesByProject_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListTLSReports provides a mock function for the type Repository
+func (_mock *Repository) ListTLSReports(ctx context.Context, domain string, limit int, offset int) ([]*models.TLSReport, int, error) {
+	ret := _mock.Called(ctx, domain, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTLSReports")
+	}
+
+	var r0 []*models.TLSReport
+	var r1 int
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.TLSReport, int, error)); ok {
+		return returnFunc(ctx, domain, limit, offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.TLSReport); ok {
+		r0 = returnFunc(ctx, domain, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.TLSReport)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = returnFunc(ctx, domain, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = returnFunc(ctx, domain, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Repository_ListTLSReports_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListTLSReports'
+type Repository_ListTLSReports_Call struct {
 	*mock.Call
 }
 
-// ListMessagesByInbox is a helper method to define mock.On call
+// ListTLSReports is a helper method to define mock.On call
 //   - ctx context.Context
-//   - inboxID string
+//   - domain string
 //   - limit int
 //   - offset int
-func (_e *Repository_Expecter) ListMessagesByInbox(ctx interface{}, inboxID interface{}, limit interface{}, offset interface{}) *Repository_ListMessagesByInbox_Call {
-	return &Repository_ListMessagesByInbox_Call{Call: _e.mock.On("ListMessagesByInbox", ctx, inboxID, limit, offset)}
+func (_e *Repository_Expecter) ListTLSReports(ctx interface{}, domain interface{}, limit interface{}, offset interface{}) *Repository_ListTLSReports_Call {
+	return &Repository_ListTLSReports_Call{Call: _e.mock.On("ListTLSReports", ctx, domain, limit, offset)}
 }
 
-func (_c *Repository_ListMessagesByInbox_Call) Run(run func(ctx context.Context, inboxID string, limit int, offset int)) *Repository_ListMessagesByInbox_Call {
+func (_c *Repository_ListTLSReports_Call) Run(run func(ctx context.Context, domain string, limit int, offset int)) *Repository_ListTLSReports_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -2060,66 +5996,65 @@ func (_c *Repository_ListMessagesByInbox_Call) Run(run func(ctx context.Context,
 	return _c
 }
 
-func (_c *Repository_ListMessagesByInbox_Call) Return(messages []*models.Message, n int, err error) *Repository_ListMessagesByInbox_Call {
-	_c.Call.Return(messages, n, err)
+func (_c *Repository_ListTLSReports_Call) Return(tLSReports []*models.TLSReport, n int, err error) *Repository_ListTLSReports_Call {
+	_c.Call.Return(tLSReports, n, err)
 	return _c
 }
 
-func (_c *Repository_ListMessagesByInbox_Call) RunAndReturn(run func(ctx context.Context, inboxID string, limit int, offset int) ([]*models.Message, int, error)) *Repository_ListMessagesByInbox_Call {
+func (_c *Repository_ListTLSReports_Call) RunAndReturn(run func(ctx context.Context, domain string, limit int, offset int) ([]*models.TLSReport, int, error)) *Repository_ListTLSReports_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListMessagesByInboxWithFilter provides a mock function for the type Repository
-func (_mock *Repository) ListMessagesByInboxWithFilter(ctx context.Context, inboxID string, isRead *bool, limit int, offset int) ([]*models.Message, int, error) {
-	ret := _mock.Called(ctx, inboxID, isRead, limit, offset)
+// ListThreadsByInbox provides a mock function for the type Repository
+func (_mock *Repository) ListThreadsByInbox(ctx context.Context, inboxID string, limit int, offset int) ([]*models.Thread, int, error) {
+	ret := _mock.Called(ctx, inboxID, limit, offset)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListMessagesByInboxWithFilter")
+		panic("no return value specified for ListThreadsByInbox")
 	}
 
-	var r0 []*models.Message
+	var r0 []*models.Thread
 	var r1 int
 	var r2 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, *bool, int, int) ([]*models.Message, int, error)); ok {
-		return returnFunc(ctx, inboxID, isRead, limit, offset)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.Thread, int, error)); ok {
+		return returnFunc(ctx, inboxID, limit, offset)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, *bool, int, int) []*models.Message); ok {
-		r0 = returnFunc(ctx, inboxID, isRead, limit, offset)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Thread); ok {
+		r0 = returnFunc(ctx, inboxID, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Message)
+			r0 = ret.Get(0).([]*models.Thread)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, *bool, int, int) int); ok {
-		r1 = returnFunc(ctx, inboxID, isRead, limit, offset)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = returnFunc(ctx, inboxID, limit, offset)
 	} else {
 		r1 = ret.Get(1).(int)
 	}
-	if returnFunc, ok := ret.Get(2).(func(context.Context, string, *bool, int, int) error); ok {
-		r2 = returnFunc(ctx, inboxID, isRead, limit, offset)
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = returnFunc(ctx, inboxID, limit, offset)
 	} else {
 		r2 = ret.Error(2)
 	}
 	return r0, r1, r2
 }
 
-// Repository_ListMessagesByInboxWithFilter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMessagesByInboxWithFilter'
-type Repository_ListMessagesByInboxWithFilter_Call struct {
+// Repository_ListThreadsByInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListThreadsByInbox'
+type Repository_ListThreadsByInbox_Call struct {
 	*mock.Call
 }
 
-// ListMessagesByInboxWithFilter is a helper method to define mock.On call
+// ListThreadsByInbox is a helper method to define mock.On call
 //   - ctx context.Context
 //   - inboxID string
-//   - isRead *bool
 //   - limit int
 //   - offset int
-func (_e *Repository_Expecter) ListMessagesByInboxWithFilter(ctx interface{}, inboxID interface{}, isRead interface{}, limit interface{}, offset interface{}) *Repository_ListMessagesByInboxWithFilter_Call {
-	return &Repository_ListMessagesByInboxWithFilter_Call{Call: _e.mock.On("ListMessagesByInboxWithFilter", ctx, inboxID, isRead, limit, offset)}
+func (_e *Repository_Expecter) ListThreadsByInbox(ctx interface{}, inboxID interface{}, limit interface{}, offset interface{}) *Repository_ListThreadsByInbox_Call {
+	return &Repository_ListThreadsByInbox_Call{Call: _e.mock.On("ListThreadsByInbox", ctx, inboxID, limit, offset)}
 }
 
-func (_c *Repository_ListMessagesByInboxWithFilter_Call) Run(run func(ctx context.Context, inboxID string, isRead *bool, limit int, offset int)) *Repository_ListMessagesByInboxWithFilter_Call {
+func (_c *Repository_ListThreadsByInbox_Call) Run(run func(ctx context.Context, inboxID string, limit int, offset int)) *Repository_ListThreadsByInbox_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -2129,89 +6064,83 @@ func (_c *Repository_ListMessagesByInboxWithFilter_Call) Run(run func(ctx contex
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 *bool
+		var arg2 int
 		if args[2] != nil {
-			arg2 = args[2].(*bool)
+			arg2 = args[2].(int)
 		}
 		var arg3 int
 		if args[3] != nil {
 			arg3 = args[3].(int)
 		}
-		var arg4 int
-		if args[4] != nil {
-			arg4 = args[4].(int)
-		}
 		run(
 			arg0,
 			arg1,
 			arg2,
 			arg3,
-			arg4,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_ListMessagesByInboxWithFilter_Call) Return(messages []*models.Message, n int, err error) *Repository_ListMessagesByInboxWithFilter_Call {
-	_c.Call.Return(messages, n, err)
+func (_c *Repository_ListThreadsByInbox_Call) Return(threads []*models.Thread, n int, err error) *Repository_ListThreadsByInbox_Call {
+	_c.Call.Return(threads, n, err)
 	return _c
 }
 
-func (_c *Repository_ListMessagesByInboxWithFilter_Call) RunAndReturn(run func(ctx context.Context, inboxID string, isRead *bool, limit int, offset int) ([]*models.Message, int, error)) *Repository_ListMessagesByInboxWithFilter_Call {
+func (_c *Repository_ListThreadsByInbox_Call) RunAndReturn(run func(ctx context.Context, inboxID string, limit int, offset int) ([]*models.Thread, int, error)) *Repository_ListThreadsByInbox_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListMessagesByInboxWithFilters provides a mock function for the type Repository
-func (_mock *Repository) ListMessagesByInboxWithFilters(ctx context.Context, inboxID string, filters models.MessageFilters, limit int, offset int) ([]*models.Message, int, error) {
-	ret := _mock.Called(ctx, inboxID, filters, limit, offset)
+// ListTokensByUser provides a mock function for the type Repository
+func (_mock *Repository) ListTokensByUser(ctx context.Context, userID string, limit int, offset int) ([]*models.Token, int, error) {
+	ret := _mock.Called(ctx, userID, limit, offset)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListMessagesByInboxWithFilters")
+		panic("no return value specified for ListTokensByUser")
 	}
 
-	var r0 []*models.Message
+	var r0 []*models.Token
 	var r1 int
 	var r2 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, models.MessageFilters, int, int) ([]*models.Message, int, error)); ok {
-		return returnFunc(ctx, inboxID, filters, limit, offset)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.Token, int, error)); ok {
+		return returnFunc(ctx, userID, limit, offset)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, models.MessageFilters, int, int) []*models.Message); ok {
-		r0 = returnFunc(ctx, inboxID, filters, limit, offset)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Token); ok {
+		r0 = returnFunc(ctx, userID, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Message)
+			r0 = ret.Get(0).([]*models.Token)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, models.MessageFilters, int, int) int); ok {
-		r1 = returnFunc(ctx, inboxID, filters, limit, offset)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = returnFunc(ctx, userID, limit, offset)
 	} else {
 		r1 = ret.Get(1).(int)
 	}
-	if returnFunc, ok := ret.Get(2).(func(context.Context, string, models.MessageFilters, int, int) error); ok {
-		r2 = returnFunc(ctx, inboxID, filters, limit, offset)
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = returnFunc(ctx, userID, limit, offset)
 	} else {
 		r2 = ret.Error(2)
 	}
 	return r0, r1, r2
 }
 
-// Repository_ListMessagesByInboxWithFilters_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListMessagesByInboxWithFilters'
-type Repository_ListMessagesByInboxWithFilters_Call struct {
+// Repository_ListTokensByUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListTokensByUser'
+type Repository_ListTokensByUser_Call struct {
 	*mock.Call
 }
 
-// ListMessagesByInboxWithFilters is a helper method to define mock.On call
+// ListTokensByUser is a helper method to define mock.On call
 //   - ctx context.Context
-//   - inboxID string
-//   - filters models.MessageFilters
+//   - userID string
 //   - limit int
 //   - offset int
-func (_e *Repository_Expecter) ListMessagesByInboxWithFilters(ctx interface{}, inboxID interface{}, filters interface{}, limit interface{}, offset interface{}) *Repository_ListMessagesByInboxWithFilters_Call {
-	return &Repository_ListMessagesByInboxWithFilters_Call{Call: _e.mock.On("ListMessagesByInboxWithFilters", ctx, inboxID, filters, limit, offset)}
+func (_e *Repository_Expecter) ListTokensByUser(ctx interface{}, userID interface{}, limit interface{}, offset interface{}) *Repository_ListTokensByUser_Call {
+	return &Repository_ListTokensByUser_Call{Call: _e.mock.On("ListTokensByUser", ctx, userID, limit, offset)}
 }
 
-func (_c *Repository_ListMessagesByInboxWithFilters_Call) Run(run func(ctx context.Context, inboxID string, filters models.MessageFilters, limit int, offset int)) *Repository_ListMessagesByInboxWithFilters_Call {
+func (_c *Repository_ListTokensByUser_Call) Run(run func(ctx context.Context, userID string, limit int, offset int)) *Repository_ListTokensByUser_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -2221,58 +6150,53 @@ func (_c *Repository_ListMessagesByInboxWithFilters_Call) Run(run func(ctx conte
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 models.MessageFilters
+		var arg2 int
 		if args[2] != nil {
-			arg2 = args[2].(models.MessageFilters)
+			arg2 = args[2].(int)
 		}
 		var arg3 int
 		if args[3] != nil {
 			arg3 = args[3].(int)
 		}
-		var arg4 int
-		if args[4] != nil {
-			arg4 = args[4].(int)
-		}
 		run(
 			arg0,
 			arg1,
 			arg2,
 			arg3,
-			arg4,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_ListMessagesByInboxWithFilters_Call) Return(messages []*models.Message, n int, err error) *Repository_ListMessagesByInboxWithFilters_Call {
-	_c.Call.Return(messages, n, err)
+func (_c *Repository_ListTokensByUser_Call) Return(tokens []*models.Token, n int, err error) *Repository_ListTokensByUser_Call {
+	_c.Call.Return(tokens, n, err)
 	return _c
 }
 
-func (_c *Repository_ListMessagesByInboxWithFilters_Call) RunAndReturn(run func(ctx context.Context, inboxID string, filters models.MessageFilters, limit int, offset int) ([]*models.Message, int, error)) *Repository_ListMessagesByInboxWithFilters_Call {
+func (_c *Repository_ListTokensByUser_Call) RunAndReturn(run func(ctx context.Context, userID string, limit int, offset int) ([]*models.Token, int, error)) *Repository_ListTokensByUser_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListProjects provides a mock function for the type Repository
-func (_mock *Repository) ListProjects(ctx context.Context, limit int, offset int) ([]*models.Project, int, error) {
+// ListUsers provides a mock function for the type Repository
+func (_mock *Repository) ListUsers(ctx context.Context, limit int, offset int) ([]*models.User, int, error) {
 	ret := _mock.Called(ctx, limit, offset)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListProjects")
+		panic("no return value specified for ListUsers")
 	}
 
-	var r0 []*models.Project
+	var r0 []*models.User
 	var r1 int
 	var r2 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) ([]*models.Project, int, error)); ok {
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) ([]*models.User, int, error)); ok {
 		return returnFunc(ctx, limit, offset)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) []*models.Project); ok {
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) []*models.User); ok {
 		r0 = returnFunc(ctx, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Project)
+			r0 = ret.Get(0).([]*models.User)
 		}
 	}
 	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int) int); ok {
@@ -2288,20 +6212,20 @@ func (_mock *Repository) ListProjects(ctx context.Context, limit int, offset int
 	return r0, r1, r2
 }
 
-// Repository_ListProjects_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListProjects'
-type Repository_ListProjects_Call struct {
+// Repository_ListUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUsers'
+type Repository_ListUsers_Call struct {
 	*mock.Call
 }
 
-// ListProjects is a helper method to define mock.On call
+// ListUsers is a helper method to define mock.On call
 //   - ctx context.Context
 //   - limit int
 //   - offset int
-func (_e *Repository_Expecter) ListProjects(ctx interface{}, limit interface{}, offset interface{}) *Repository_ListProjects_Call {
-	return &Repository_ListProjects_Call{Call: _e.mock.On("ListProjects", ctx, limit, offset)}
+func (_e *Repository_Expecter) ListUsers(ctx interface{}, limit interface{}, offset interface{}) *Repository_ListUsers_Call {
+	return &Repository_ListUsers_Call{Call: _e.mock.On("ListUsers", ctx, limit, offset)}
 }
 
-func (_c *Repository_ListProjects_Call) Run(run func(ctx context.Context, limit int, offset int)) *Repository_ListProjects_Call {
+func (_c *Repository_ListUsers_Call) Run(run func(ctx context.Context, limit int, offset int)) *Repository_ListUsers_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -2324,65 +6248,196 @@ func (_c *Repository_ListProjects_Call) Run(run func(ctx context.Context, limit
 	return _c
 }
 
-func (_c *Repository_ListProjects_Call) Return(projects []*models.Project, n int, err error) *Repository_ListProjects_Call {
-	_c.Call.Return(projects, n, err)
+func (_c *Repository_ListUsers_Call) Return(users []*models.User, n int, err error) *Repository_ListUsers_Call {
+	_c.Call.Return(users, n, err)
 	return _c
 }
 
-func (_c *Repository_ListProjects_Call) RunAndReturn(run func(ctx context.Context, limit int, offset int) ([]*models.Project, int, error)) *Repository_ListProjects_Call {
+func (_c *Repository_ListUsers_Call) RunAndReturn(run func(ctx context.Context, limit int, offset int) ([]*models.User, int, error)) *Repository_ListUsers_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListProjectsByUser provides a mock function for the type Repository
-func (_mock *Repository) ListProjectsByUser(ctx context.Context, userID string, limit int, offset int) ([]*models.Project, int, error) {
-	ret := _mock.Called(ctx, userID, limit, offset)
+// MoveMessages provides a mock function for the type Repository
+func (_mock *Repository) MoveMessages(ctx context.Context, inboxID string, folderID string, uids []uint32, destInboxID string, destFolderID string) ([]uint32, error) {
+	ret := _mock.Called(ctx, inboxID, folderID, uids, destInboxID, destFolderID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListProjectsByUser")
+		panic("no return value specified for MoveMessages")
 	}
 
-	var r0 []*models.Project
-	var r1 int
-	var r2 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.Project, int, error)); ok {
-		return returnFunc(ctx, userID, limit, offset)
+	var r0 []uint32
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []uint32, string, string) ([]uint32, error)); ok {
+		return returnFunc(ctx, inboxID, folderID, uids, destInboxID, destFolderID)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Project); ok {
-		r0 = returnFunc(ctx, userID, limit, offset)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []uint32, string, string) []uint32); ok {
+		r0 = returnFunc(ctx, inboxID, folderID, uids, destInboxID, destFolderID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Project)
+			r0 = ret.Get(0).([]uint32)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
-		r1 = returnFunc(ctx, userID, limit, offset)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, []uint32, string, string) error); ok {
+		r1 = returnFunc(ctx, inboxID, folderID, uids, destInboxID, destFolderID)
 	} else {
-		r1 = ret.Get(1).(int)
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Repository_MoveMessages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MoveMessages'
+type Repository_MoveMessages_Call struct {
+	*mock.Call
+}
+
+// MoveMessages is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - folderID string
+//   - uids []uint32
+//   - destInboxID string
+//   - destFolderID string
+func (_e *Repository_Expecter) MoveMessages(ctx interface{}, inboxID interface{}, folderID interface{}, uids interface{}, destInboxID interface{}, destFolderID interface{}) *Repository_MoveMessages_Call {
+	return &Repository_MoveMessages_Call{Call: _e.mock.On("MoveMessages", ctx, inboxID, folderID, uids, destInboxID, destFolderID)}
+}
+
+func (_c *Repository_MoveMessages_Call) Run(run func(ctx context.Context, inboxID string, folderID string, uids []uint32, destInboxID string, destFolderID string)) *Repository_MoveMessages_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []uint32
+		if args[3] != nil {
+			arg3 = args[3].([]uint32)
+		}
+		var arg4 string
+		if args[4] != nil {
+			arg4 = args[4].(string)
+		}
+		var arg5 string
+		if args[5] != nil {
+			arg5 = args[5].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_MoveMessages_Call) Return(uint32s []uint32, err error) *Repository_MoveMessages_Call {
+	_c.Call.Return(uint32s, err)
+	return _c
+}
+
+func (_c *Repository_MoveMessages_Call) RunAndReturn(run func(ctx context.Context, inboxID string, folderID string, uids []uint32, destInboxID string, destFolderID string) ([]uint32, error)) *Repository_MoveMessages_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ProjectAddUser provides a mock function for the type Repository
+func (_mock *Repository) ProjectAddUser(ctx context.Context, projectUser *models.ProjectUser) error {
+	ret := _mock.Called(ctx, projectUser)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ProjectAddUser")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.ProjectUser) error); ok {
+		r0 = returnFunc(ctx, projectUser)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Repository_ProjectAddUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ProjectAddUser'
+type Repository_ProjectAddUser_Call struct {
+	*mock.Call
+}
+
+// ProjectAddUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - projectUser *models.ProjectUser
+func (_e *Repository_Expecter) ProjectAddUser(ctx interface{}, projectUser interface{}) *Repository_ProjectAddUser_Call {
+	return &Repository_ProjectAddUser_Call{Call: _e.mock.On("ProjectAddUser", ctx, projectUser)}
+}
+
+func (_c *Repository_ProjectAddUser_Call) Run(run func(ctx context.Context, projectUser *models.ProjectUser)) *Repository_ProjectAddUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *models.ProjectUser
+		if args[1] != nil {
+			arg1 = args[1].(*models.ProjectUser)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_ProjectAddUser_Call) Return(err error) *Repository_ProjectAddUser_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Repository_ProjectAddUser_Call) RunAndReturn(run func(ctx context.Context, projectUser *models.ProjectUser) error) *Repository_ProjectAddUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ProjectRemoveUser provides a mock function for the type Repository
+func (_mock *Repository) ProjectRemoveUser(ctx context.Context, projectID string, userID string) error {
+	ret := _mock.Called(ctx, projectID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ProjectRemoveUser")
 	}
-	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
-		r2 = returnFunc(ctx, userID, limit, offset)
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, projectID, userID)
 	} else {
-		r2 = ret.Error(2)
+		r0 = ret.Error(0)
 	}
-	return r0, r1, r2
+	return r0
 }
 
-// Repository_ListProjectsByUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListProjectsByUser'
-type Repository_ListProjectsByUser_Call struct {
+// Repository_ProjectRemoveUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ProjectRemoveUser'
+type Repository_ProjectRemoveUser_Call struct {
 	*mock.Call
 }
 
-// ListProjectsByUser is a helper method to define mock.On call
+// ProjectRemoveUser is a helper method to define mock.On call
 //   - ctx context.Context
+//   - projectID string
 //   - userID string
-//   - limit int
-//   - offset int
-func (_e *Repository_Expecter) ListProjectsByUser(ctx interface{}, userID interface{}, limit interface{}, offset interface{}) *Repository_ListProjectsByUser_Call {
-	return &Repository_ListProjectsByUser_Call{Call: _e.mock.On("ListProjectsByUser", ctx, userID, limit, offset)}
+func (_e *Repository_Expecter) ProjectRemoveUser(ctx interface{}, projectID interface{}, userID interface{}) *Repository_ProjectRemoveUser_Call {
+	return &Repository_ProjectRemoveUser_Call{Call: _e.mock.On("ProjectRemoveUser", ctx, projectID, userID)}
 }
 
-func (_c *Repository_ListProjectsByUser_Call) Run(run func(ctx context.Context, userID string, limit int, offset int)) *Repository_ListProjectsByUser_Call {
+func (_c *Repository_ProjectRemoveUser_Call) Run(run func(ctx context.Context, projectID string, userID string)) *Repository_ProjectRemoveUser_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -2392,163 +6447,120 @@ func (_c *Repository_ListProjectsByUser_Call) Run(run func(ctx context.Context,
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 int
+		var arg2 string
 		if args[2] != nil {
-			arg2 = args[2].(int)
-		}
-		var arg3 int
-		if args[3] != nil {
-			arg3 = args[3].(int)
+			arg2 = args[2].(string)
 		}
 		run(
 			arg0,
 			arg1,
 			arg2,
-			arg3,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_ListProjectsByUser_Call) Return(projects []*models.Project, n int, err error) *Repository_ListProjectsByUser_Call {
-	_c.Call.Return(projects, n, err)
+func (_c *Repository_ProjectRemoveUser_Call) Return(err error) *Repository_ProjectRemoveUser_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_ListProjectsByUser_Call) RunAndReturn(run func(ctx context.Context, userID string, limit int, offset int) ([]*models.Project, int, error)) *Repository_ListProjectsByUser_Call {
+func (_c *Repository_ProjectRemoveUser_Call) RunAndReturn(run func(ctx context.Context, projectID string, userID string) error) *Repository_ProjectRemoveUser_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListRules provides a mock function for the type Repository
-func (_mock *Repository) ListRules(ctx context.Context, limit int, offset int) ([]*models.ForwardRule, int, error) {
-	ret := _mock.Called(ctx, limit, offset)
+// PruneExpiredTokens provides a mock function for the type Repository
+func (_mock *Repository) PruneExpiredTokens(ctx context.Context) (int64, error) {
+	ret := _mock.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListRules")
+		panic("no return value specified for PruneExpiredTokens")
 	}
 
-	var r0 []*models.ForwardRule
-	var r1 int
-	var r2 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) ([]*models.ForwardRule, int, error)); ok {
-		return returnFunc(ctx, limit, offset)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) []*models.ForwardRule); ok {
-		r0 = returnFunc(ctx, limit, offset)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.ForwardRule)
-		}
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return returnFunc(ctx)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int) int); ok {
-		r1 = returnFunc(ctx, limit, offset)
+	if returnFunc, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = returnFunc(ctx)
 	} else {
-		r1 = ret.Get(1).(int)
+		r0 = ret.Get(0).(int64)
 	}
-	if returnFunc, ok := ret.Get(2).(func(context.Context, int, int) error); ok {
-		r2 = returnFunc(ctx, limit, offset)
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
 	} else {
-		r2 = ret.Error(2)
+		r1 = ret.Error(1)
 	}
-	return r0, r1, r2
+	return r0, r1
 }
 
-// Repository_ListRules_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRules'
-type Repository_ListRules_Call struct {
+// Repository_PruneExpiredTokens_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PruneExpiredTokens'
+type Repository_PruneExpiredTokens_Call struct {
 	*mock.Call
 }
 
-// ListRules is a helper method to define mock.On call
+// PruneExpiredTokens is a helper method to define mock.On call
 //   - ctx context.Context
-//   - limit int
-//   - offset int
-func (_e *Repository_Expecter) ListRules(ctx interface{}, limit interface{}, offset interface{}) *Repository_ListRules_Call {
-	return &Repository_ListRules_Call{Call: _e.mock.On("ListRules", ctx, limit, offset)}
+func (_e *Repository_Expecter) PruneExpiredTokens(ctx interface{}) *Repository_PruneExpiredTokens_Call {
+	return &Repository_PruneExpiredTokens_Call{Call: _e.mock.On("PruneExpiredTokens", ctx)}
 }
 
-func (_c *Repository_ListRules_Call) Run(run func(ctx context.Context, limit int, offset int)) *Repository_ListRules_Call {
+func (_c *Repository_PruneExpiredTokens_Call) Run(run func(ctx context.Context)) *Repository_PruneExpiredTokens_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 int
-		if args[1] != nil {
-			arg1 = args[1].(int)
-		}
-		var arg2 int
-		if args[2] != nil {
-			arg2 = args[2].(int)
-		}
 		run(
 			arg0,
-			arg1,
-			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_ListRules_Call) Return(forwardRules []*models.ForwardRule, n int, err error) *Repository_ListRules_Call {
-	_c.Call.Return(forwardRules, n, err)
+func (_c *Repository_PruneExpiredTokens_Call) Return(n int64, err error) *Repository_PruneExpiredTokens_Call {
+	_c.Call.Return(n, err)
 	return _c
 }
 
-func (_c *Repository_ListRules_Call) RunAndReturn(run func(ctx context.Context, limit int, offset int) ([]*models.ForwardRule, int, error)) *Repository_ListRules_Call {
+func (_c *Repository_PruneExpiredTokens_Call) RunAndReturn(run func(ctx context.Context) (int64, error)) *Repository_PruneExpiredTokens_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListRulesByInbox provides a mock function for the type Repository
-func (_mock *Repository) ListRulesByInbox(ctx context.Context, inboxID string, limit int, offset int) ([]*models.ForwardRule, int, error) {
-	ret := _mock.Called(ctx, inboxID, limit, offset)
+// PutACMECacheEntry provides a mock function for the type Repository
+func (_mock *Repository) PutACMECacheEntry(ctx context.Context, key string, data []byte) error {
+	ret := _mock.Called(ctx, key, data)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListRulesByInbox")
+		panic("no return value specified for PutACMECacheEntry")
 	}
 
-	var r0 []*models.ForwardRule
-	var r1 int
-	var r2 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.ForwardRule, int, error)); ok {
-		return returnFunc(ctx, inboxID, limit, offset)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.ForwardRule); ok {
-		r0 = returnFunc(ctx, inboxID, limit, offset)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.ForwardRule)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
-		r1 = returnFunc(ctx, inboxID, limit, offset)
-	} else {
-		r1 = ret.Get(1).(int)
-	}
-	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
-		r2 = returnFunc(ctx, inboxID, limit, offset)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []byte) error); ok {
+		r0 = returnFunc(ctx, key, data)
 	} else {
-		r2 = ret.Error(2)
+		r0 = ret.Error(0)
 	}
-	return r0, r1, r2
+	return r0
 }
 
-// Repository_ListRulesByInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRulesByInbox'
-type Repository_ListRulesByInbox_Call struct {
+// Repository_PutACMECacheEntry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutACMECacheEntry'
+type Repository_PutACMECacheEntry_Call struct {
 	*mock.Call
 }
 
-// ListRulesByInbox is a helper method to define mock.On call
+// PutACMECacheEntry is a helper method to define mock.On call
 //   - ctx context.Context
-//   - inboxID string
-//   - limit int
-//   - offset int
-func (_e *Repository_Expecter) ListRulesByInbox(ctx interface{}, inboxID interface{}, limit interface{}, offset interface{}) *Repository_ListRulesByInbox_Call {
-	return &Repository_ListRulesByInbox_Call{Call: _e.mock.On("ListRulesByInbox", ctx, inboxID, limit, offset)}
+//   - key string
+//   - data []byte
+func (_e *Repository_Expecter) PutACMECacheEntry(ctx interface{}, key interface{}, data interface{}) *Repository_PutACMECacheEntry_Call {
+	return &Repository_PutACMECacheEntry_Call{Call: _e.mock.On("PutACMECacheEntry", ctx, key, data)}
 }
 
-func (_c *Repository_ListRulesByInbox_Call) Run(run func(ctx context.Context, inboxID string, limit int, offset int)) *Repository_ListRulesByInbox_Call {
+func (_c *Repository_PutACMECacheEntry_Call) Run(run func(ctx context.Context, key string, data []byte)) *Repository_PutACMECacheEntry_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -2558,83 +6570,60 @@ func (_c *Repository_ListRulesByInbox_Call) Run(run func(ctx context.Context, in
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 int
+		var arg2 []byte
 		if args[2] != nil {
-			arg2 = args[2].(int)
-		}
-		var arg3 int
-		if args[3] != nil {
-			arg3 = args[3].(int)
+			arg2 = args[2].([]byte)
 		}
 		run(
 			arg0,
 			arg1,
 			arg2,
-			arg3,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_ListRulesByInbox_Call) Return(forwardRules []*models.ForwardRule, n int, err error) *Repository_ListRulesByInbox_Call {
-	_c.Call.Return(forwardRules, n, err)
+func (_c *Repository_PutACMECacheEntry_Call) Return(err error) *Repository_PutACMECacheEntry_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_ListRulesByInbox_Call) RunAndReturn(run func(ctx context.Context, inboxID string, limit int, offset int) ([]*models.ForwardRule, int, error)) *Repository_ListRulesByInbox_Call {
+func (_c *Repository_PutACMECacheEntry_Call) RunAndReturn(run func(ctx context.Context, key string, data []byte) error) *Repository_PutACMECacheEntry_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListTokensByUser provides a mock function for the type Repository
-func (_mock *Repository) ListTokensByUser(ctx context.Context, userID string, limit int, offset int) ([]*models.Token, int, error) {
-	ret := _mock.Called(ctx, userID, limit, offset)
+// RemoveMessageLabel provides a mock function for the type Repository
+func (_mock *Repository) RemoveMessageLabel(ctx context.Context, messageID string, labelID string) error {
+	ret := _mock.Called(ctx, messageID, labelID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListTokensByUser")
+		panic("no return value specified for RemoveMessageLabel")
 	}
 
-	var r0 []*models.Token
-	var r1 int
-	var r2 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) ([]*models.Token, int, error)); ok {
-		return returnFunc(ctx, userID, limit, offset)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int, int) []*models.Token); ok {
-		r0 = returnFunc(ctx, userID, limit, offset)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Token)
-		}
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
-		r1 = returnFunc(ctx, userID, limit, offset)
-	} else {
-		r1 = ret.Get(1).(int)
-	}
-	if returnFunc, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
-		r2 = returnFunc(ctx, userID, limit, offset)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, messageID, labelID)
 	} else {
-		r2 = ret.Error(2)
+		r0 = ret.Error(0)
 	}
-	return r0, r1, r2
+	return r0
 }
 
-// Repository_ListTokensByUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListTokensByUser'
-type Repository_ListTokensByUser_Call struct {
+// Repository_RemoveMessageLabel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveMessageLabel'
+type Repository_RemoveMessageLabel_Call struct {
 	*mock.Call
 }
 
-// ListTokensByUser is a helper method to define mock.On call
+// RemoveMessageLabel is a helper method to define mock.On call
 //   - ctx context.Context
-//   - userID string
-//   - limit int
-//   - offset int
-func (_e *Repository_Expecter) ListTokensByUser(ctx interface{}, userID interface{}, limit interface{}, offset interface{}) *Repository_ListTokensByUser_Call {
-	return &Repository_ListTokensByUser_Call{Call: _e.mock.On("ListTokensByUser", ctx, userID, limit, offset)}
+//   - messageID string
+//   - labelID string
+func (_e *Repository_Expecter) RemoveMessageLabel(ctx interface{}, messageID interface{}, labelID interface{}) *Repository_RemoveMessageLabel_Call {
+	return &Repository_RemoveMessageLabel_Call{Call: _e.mock.On("RemoveMessageLabel", ctx, messageID, labelID)}
 }
 
-func (_c *Repository_ListTokensByUser_Call) Run(run func(ctx context.Context, userID string, limit int, offset int)) *Repository_ListTokensByUser_Call {
+func (_c *Repository_RemoveMessageLabel_Call) Run(run func(ctx context.Context, messageID string, labelID string)) *Repository_RemoveMessageLabel_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -2644,332 +6633,330 @@ func (_c *Repository_ListTokensByUser_Call) Run(run func(ctx context.Context, us
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 int
+		var arg2 string
 		if args[2] != nil {
-			arg2 = args[2].(int)
-		}
-		var arg3 int
-		if args[3] != nil {
-			arg3 = args[3].(int)
+			arg2 = args[2].(string)
 		}
 		run(
 			arg0,
 			arg1,
 			arg2,
-			arg3,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_ListTokensByUser_Call) Return(tokens []*models.Token, n int, err error) *Repository_ListTokensByUser_Call {
-	_c.Call.Return(tokens, n, err)
+func (_c *Repository_RemoveMessageLabel_Call) Return(err error) *Repository_RemoveMessageLabel_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_ListTokensByUser_Call) RunAndReturn(run func(ctx context.Context, userID string, limit int, offset int) ([]*models.Token, int, error)) *Repository_ListTokensByUser_Call {
+func (_c *Repository_RemoveMessageLabel_Call) RunAndReturn(run func(ctx context.Context, messageID string, labelID string) error) *Repository_RemoveMessageLabel_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListUsers provides a mock function for the type Repository
-func (_mock *Repository) ListUsers(ctx context.Context, limit int, offset int) ([]*models.User, int, error) {
-	ret := _mock.Called(ctx, limit, offset)
+// SummarizeDMARCRecords provides a mock function for the type Repository
+func (_mock *Repository) SummarizeDMARCRecords(ctx context.Context, filter models.DMARCFilter, limit int, offset int) ([]*models.DMARCSummary, int, error) {
+	ret := _mock.Called(ctx, filter, limit, offset)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListUsers")
+		panic("no return value specified for SummarizeDMARCRecords")
 	}
 
-	var r0 []*models.User
+	var r0 []*models.DMARCSummary
 	var r1 int
 	var r2 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) ([]*models.User, int, error)); ok {
-		return returnFunc(ctx, limit, offset)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.DMARCFilter, int, int) ([]*models.DMARCSummary, int, error)); ok {
+		return returnFunc(ctx, filter, limit, offset)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) []*models.User); ok {
-		r0 = returnFunc(ctx, limit, offset)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.DMARCFilter, int, int) []*models.DMARCSummary); ok {
+		r0 = returnFunc(ctx, filter, limit, offset)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.User)
+			r0 = ret.Get(0).([]*models.DMARCSummary)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int) int); ok {
-		r1 = returnFunc(ctx, limit, offset)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, models.DMARCFilter, int, int) int); ok {
+		r1 = returnFunc(ctx, filter, limit, offset)
 	} else {
 		r1 = ret.Get(1).(int)
 	}
-	if returnFunc, ok := ret.Get(2).(func(context.Context, int, int) error); ok {
-		r2 = returnFunc(ctx, limit, offset)
+	if returnFunc, ok := ret.Get(2).(func(context.Context, models.DMARCFilter, int, int) error); ok {
+		r2 = returnFunc(ctx, filter, limit, offset)
 	} else {
 		r2 = ret.Error(2)
 	}
 	return r0, r1, r2
 }
 
-// Repository_ListUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUsers'
-type Repository_ListUsers_Call struct {
+// Repository_SummarizeDMARCRecords_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SummarizeDMARCRecords'
+type Repository_SummarizeDMARCRecords_Call struct {
 	*mock.Call
 }
 
-// ListUsers is a helper method to define mock.On call
+// SummarizeDMARCRecords is a helper method to define mock.On call
 //   - ctx context.Context
+//   - filter models.DMARCFilter
 //   - limit int
 //   - offset int
-func (_e *Repository_Expecter) ListUsers(ctx interface{}, limit interface{}, offset interface{}) *Repository_ListUsers_Call {
-	return &Repository_ListUsers_Call{Call: _e.mock.On("ListUsers", ctx, limit, offset)}
+func (_e *Repository_Expecter) SummarizeDMARCRecords(ctx interface{}, filter interface{}, limit interface{}, offset interface{}) *Repository_SummarizeDMARCRecords_Call {
+	return &Repository_SummarizeDMARCRecords_Call{Call: _e.mock.On("SummarizeDMARCRecords", ctx, filter, limit, offset)}
 }
 
-func (_c *Repository_ListUsers_Call) Run(run func(ctx context.Context, limit int, offset int)) *Repository_ListUsers_Call {
+func (_c *Repository_SummarizeDMARCRecords_Call) Run(run func(ctx context.Context, filter models.DMARCFilter, limit int, offset int)) *Repository_SummarizeDMARCRecords_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 int
+		var arg1 models.DMARCFilter
 		if args[1] != nil {
-			arg1 = args[1].(int)
+			arg1 = args[1].(models.DMARCFilter)
 		}
 		var arg2 int
 		if args[2] != nil {
 			arg2 = args[2].(int)
 		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
 		run(
 			arg0,
 			arg1,
 			arg2,
+			arg3,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_ListUsers_Call) Return(users []*models.User, n int, err error) *Repository_ListUsers_Call {
-	_c.Call.Return(users, n, err)
+func (_c *Repository_SummarizeDMARCRecords_Call) Return(dMARCSummarys []*models.DMARCSummary, n int, err error) *Repository_SummarizeDMARCRecords_Call {
+	_c.Call.Return(dMARCSummarys, n, err)
 	return _c
 }
 
-func (_c *Repository_ListUsers_Call) RunAndReturn(run func(ctx context.Context, limit int, offset int) ([]*models.User, int, error)) *Repository_ListUsers_Call {
+func (_c *Repository_SummarizeDMARCRecords_Call) RunAndReturn(run func(ctx context.Context, filter models.DMARCFilter, limit int, offset int) ([]*models.DMARCSummary, int, error)) *Repository_SummarizeDMARCRecords_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ProjectAddUser provides a mock function for the type Repository
-func (_mock *Repository) ProjectAddUser(ctx context.Context, projectUser *models.ProjectUser) error {
-	ret := _mock.Called(ctx, projectUser)
+// TouchGreylistTriplet provides a mock function for the type Repository
+func (_mock *Repository) TouchGreylistTriplet(ctx context.Context, triplet *models.GreylistTriplet, maxAge time.Duration) error {
+	ret := _mock.Called(ctx, triplet, maxAge)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ProjectAddUser")
+		panic("no return value specified for TouchGreylistTriplet")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.ProjectUser) error); ok {
-		r0 = returnFunc(ctx, projectUser)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.GreylistTriplet, time.Duration) error); ok {
+		r0 = returnFunc(ctx, triplet, maxAge)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Repository_ProjectAddUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ProjectAddUser'
-type Repository_ProjectAddUser_Call struct {
+// Repository_TouchGreylistTriplet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TouchGreylistTriplet'
+type Repository_TouchGreylistTriplet_Call struct {
 	*mock.Call
 }
 
-// ProjectAddUser is a helper method to define mock.On call
+// TouchGreylistTriplet is a helper method to define mock.On call
 //   - ctx context.Context
-//   - projectUser *models.ProjectUser
-func (_e *Repository_Expecter) ProjectAddUser(ctx interface{}, projectUser interface{}) *Repository_ProjectAddUser_Call {
-	return &Repository_ProjectAddUser_Call{Call: _e.mock.On("ProjectAddUser", ctx, projectUser)}
+//   - triplet *models.GreylistTriplet
+//   - maxAge time.Duration
+func (_e *Repository_Expecter) TouchGreylistTriplet(ctx interface{}, triplet interface{}, maxAge interface{}) *Repository_TouchGreylistTriplet_Call {
+	return &Repository_TouchGreylistTriplet_Call{Call: _e.mock.On("TouchGreylistTriplet", ctx, triplet, maxAge)}
 }
 
-func (_c *Repository_ProjectAddUser_Call) Run(run func(ctx context.Context, projectUser *models.ProjectUser)) *Repository_ProjectAddUser_Call {
+func (_c *Repository_TouchGreylistTriplet_Call) Run(run func(ctx context.Context, triplet *models.GreylistTriplet, maxAge time.Duration)) *Repository_TouchGreylistTriplet_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 *models.ProjectUser
+		var arg1 *models.GreylistTriplet
 		if args[1] != nil {
-			arg1 = args[1].(*models.ProjectUser)
+			arg1 = args[1].(*models.GreylistTriplet)
+		}
+		var arg2 time.Duration
+		if args[2] != nil {
+			arg2 = args[2].(time.Duration)
 		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_ProjectAddUser_Call) Return(err error) *Repository_ProjectAddUser_Call {
+func (_c *Repository_TouchGreylistTriplet_Call) Return(err error) *Repository_TouchGreylistTriplet_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_ProjectAddUser_Call) RunAndReturn(run func(ctx context.Context, projectUser *models.ProjectUser) error) *Repository_ProjectAddUser_Call {
+func (_c *Repository_TouchGreylistTriplet_Call) RunAndReturn(run func(ctx context.Context, triplet *models.GreylistTriplet, maxAge time.Duration) error) *Repository_TouchGreylistTriplet_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ProjectRemoveUser provides a mock function for the type Repository
-func (_mock *Repository) ProjectRemoveUser(ctx context.Context, projectID string, userID string) error {
-	ret := _mock.Called(ctx, projectID, userID)
+// UpdateFolder provides a mock function for the type Repository
+func (_mock *Repository) UpdateFolder(ctx context.Context, folder *models.Folder) error {
+	ret := _mock.Called(ctx, folder)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ProjectRemoveUser")
+		panic("no return value specified for UpdateFolder")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = returnFunc(ctx, projectID, userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Folder) error); ok {
+		r0 = returnFunc(ctx, folder)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Repository_ProjectRemoveUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ProjectRemoveUser'
-type Repository_ProjectRemoveUser_Call struct {
+// Repository_UpdateFolder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateFolder'
+type Repository_UpdateFolder_Call struct {
 	*mock.Call
 }
 
-// ProjectRemoveUser is a helper method to define mock.On call
+// UpdateFolder is a helper method to define mock.On call
 //   - ctx context.Context
-//   - projectID string
-//   - userID string
-func (_e *Repository_Expecter) ProjectRemoveUser(ctx interface{}, projectID interface{}, userID interface{}) *Repository_ProjectRemoveUser_Call {
-	return &Repository_ProjectRemoveUser_Call{Call: _e.mock.On("ProjectRemoveUser", ctx, projectID, userID)}
+//   - folder *models.Folder
+func (_e *Repository_Expecter) UpdateFolder(ctx interface{}, folder interface{}) *Repository_UpdateFolder_Call {
+	return &Repository_UpdateFolder_Call{Call: _e.mock.On("UpdateFolder", ctx, folder)}
 }
 
-func (_c *Repository_ProjectRemoveUser_Call) Run(run func(ctx context.Context, projectID string, userID string)) *Repository_ProjectRemoveUser_Call {
+func (_c *Repository_UpdateFolder_Call) Run(run func(ctx context.Context, folder *models.Folder)) *Repository_UpdateFolder_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *models.Folder
 		if args[1] != nil {
-			arg1 = args[1].(string)
-		}
-		var arg2 string
-		if args[2] != nil {
-			arg2 = args[2].(string)
+			arg1 = args[1].(*models.Folder)
 		}
 		run(
 			arg0,
 			arg1,
-			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_ProjectRemoveUser_Call) Return(err error) *Repository_ProjectRemoveUser_Call {
+func (_c *Repository_UpdateFolder_Call) Return(err error) *Repository_UpdateFolder_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_ProjectRemoveUser_Call) RunAndReturn(run func(ctx context.Context, projectID string, userID string) error) *Repository_ProjectRemoveUser_Call {
+func (_c *Repository_UpdateFolder_Call) RunAndReturn(run func(ctx context.Context, folder *models.Folder) error) *Repository_UpdateFolder_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// PruneExpiredTokens provides a mock function for the type Repository
-func (_mock *Repository) PruneExpiredTokens(ctx context.Context) (int64, error) {
-	ret := _mock.Called(ctx)
+// UpdateInbox provides a mock function for the type Repository
+func (_mock *Repository) UpdateInbox(ctx context.Context, inbox *models.Inbox) error {
+	ret := _mock.Called(ctx, inbox)
 
 	if len(ret) == 0 {
-		panic("no return value specified for PruneExpiredTokens")
+		panic("no return value specified for UpdateInbox")
 	}
 
-	var r0 int64
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
-		return returnFunc(ctx)
-	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context) int64); ok {
-		r0 = returnFunc(ctx)
-	} else {
-		r0 = ret.Get(0).(int64)
-	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = returnFunc(ctx)
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Inbox) error); ok {
+		r0 = returnFunc(ctx, inbox)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
-	return r0, r1
+	return r0
 }
 
-// Repository_PruneExpiredTokens_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PruneExpiredTokens'
-type Repository_PruneExpiredTokens_Call struct {
+// Repository_UpdateInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateInbox'
+type Repository_UpdateInbox_Call struct {
 	*mock.Call
 }
 
-// PruneExpiredTokens is a helper method to define mock.On call
+// UpdateInbox is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *Repository_Expecter) PruneExpiredTokens(ctx interface{}) *Repository_PruneExpiredTokens_Call {
-	return &Repository_PruneExpiredTokens_Call{Call: _e.mock.On("PruneExpiredTokens", ctx)}
+//   - inbox *models.Inbox
+func (_e *Repository_Expecter) UpdateInbox(ctx interface{}, inbox interface{}) *Repository_UpdateInbox_Call {
+	return &Repository_UpdateInbox_Call{Call: _e.mock.On("UpdateInbox", ctx, inbox)}
 }
 
-func (_c *Repository_PruneExpiredTokens_Call) Run(run func(ctx context.Context)) *Repository_PruneExpiredTokens_Call {
+func (_c *Repository_UpdateInbox_Call) Run(run func(ctx context.Context, inbox *models.Inbox)) *Repository_UpdateInbox_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
+		var arg1 *models.Inbox
+		if args[1] != nil {
+			arg1 = args[1].(*models.Inbox)
+		}
 		run(
 			arg0,
+			arg1,
 		)
 	})
 	return _c
 }
 
-func (_c *Repository_PruneExpiredTokens_Call) Return(n int64, err error) *Repository_PruneExpiredTokens_Call {
-	_c.Call.Return(n, err)
+func (_c *Repository_UpdateInbox_Call) Return(err error) *Repository_UpdateInbox_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_PruneExpiredTokens_Call) RunAndReturn(run func(ctx context.Context) (int64, error)) *Repository_PruneExpiredTokens_Call {
+func (_c *Repository_UpdateInbox_Call) RunAndReturn(run func(ctx context.Context, inbox *models.Inbox) error) *Repository_UpdateInbox_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// UpdateInbox provides a mock function for the type Repository
-func (_mock *Repository) UpdateInbox(ctx context.Context, inbox *models.Inbox) error {
-	ret := _mock.Called(ctx, inbox)
+// UpdateLabel provides a mock function for the type Repository
+func (_mock *Repository) UpdateLabel(ctx context.Context, label *models.Label) error {
+	ret := _mock.Called(ctx, label)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateInbox")
+		panic("no return value specified for UpdateLabel")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Inbox) error); ok {
-		r0 = returnFunc(ctx, inbox)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Label) error); ok {
+		r0 = returnFunc(ctx, label)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Repository_UpdateInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateInbox'
-type Repository_UpdateInbox_Call struct {
+// Repository_UpdateLabel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateLabel'
+type Repository_UpdateLabel_Call struct {
 	*mock.Call
 }
 
-// UpdateInbox is a helper method to define mock.On call
+// UpdateLabel is a helper method to define mock.On call
 //   - ctx context.Context
-//   - inbox *models.Inbox
-func (_e *Repository_Expecter) UpdateInbox(ctx interface{}, inbox interface{}) *Repository_UpdateInbox_Call {
-	return &Repository_UpdateInbox_Call{Call: _e.mock.On("UpdateInbox", ctx, inbox)}
+//   - label *models.Label
+func (_e *Repository_Expecter) UpdateLabel(ctx interface{}, label interface{}) *Repository_UpdateLabel_Call {
+	return &Repository_UpdateLabel_Call{Call: _e.mock.On("UpdateLabel", ctx, label)}
 }
 
-func (_c *Repository_UpdateInbox_Call) Run(run func(ctx context.Context, inbox *models.Inbox)) *Repository_UpdateInbox_Call {
+func (_c *Repository_UpdateLabel_Call) Run(run func(ctx context.Context, label *models.Label)) *Repository_UpdateLabel_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 *models.Inbox
+		var arg1 *models.Label
 		if args[1] != nil {
-			arg1 = args[1].(*models.Inbox)
+			arg1 = args[1].(*models.Label)
 		}
 		run(
 			arg0,
@@ -2979,12 +6966,12 @@ func (_c *Repository_UpdateInbox_Call) Run(run func(ctx context.Context, inbox *
 	return _c
 }
 
-func (_c *Repository_UpdateInbox_Call) Return(err error) *Repository_UpdateInbox_Call {
+func (_c *Repository_UpdateLabel_Call) Return(err error) *Repository_UpdateLabel_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Repository_UpdateInbox_Call) RunAndReturn(run func(ctx context.Context, inbox *models.Inbox) error) *Repository_UpdateInbox_Call {
+func (_c *Repository_UpdateLabel_Call) RunAndReturn(run func(ctx context.Context, label *models.Label) error) *Repository_UpdateLabel_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -3052,6 +7039,87 @@ func (_c *Repository_UpdateMessageDeletedStatus_Call) RunAndReturn(run func(ctx
 	return _c
 }
 
+// UpdateMessageFlags provides a mock function for the type Repository
+func (_mock *Repository) UpdateMessageFlags(ctx context.Context, inboxID string, folderID string, uids []uint32, op models.FlagOperation, flags models.MessageFlags) error {
+	ret := _mock.Called(ctx, inboxID, folderID, uids, op, flags)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMessageFlags")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []uint32, models.FlagOperation, models.MessageFlags) error); ok {
+		r0 = returnFunc(ctx, inboxID, folderID, uids, op, flags)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Repository_UpdateMessageFlags_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateMessageFlags'
+type Repository_UpdateMessageFlags_Call struct {
+	*mock.Call
+}
+
+// UpdateMessageFlags is a helper method to define mock.On call
+//   - ctx context.Context
+//   - inboxID string
+//   - folderID string
+//   - uids []uint32
+//   - op models.FlagOperation
+//   - flags models.MessageFlags
+func (_e *Repository_Expecter) UpdateMessageFlags(ctx interface{}, inboxID interface{}, folderID interface{}, uids interface{}, op interface{}, flags interface{}) *Repository_UpdateMessageFlags_Call {
+	return &Repository_UpdateMessageFlags_Call{Call: _e.mock.On("UpdateMessageFlags", ctx, inboxID, folderID, uids, op, flags)}
+}
+
+func (_c *Repository_UpdateMessageFlags_Call) Run(run func(ctx context.Context, inboxID string, folderID string, uids []uint32, op models.FlagOperation, flags models.MessageFlags)) *Repository_UpdateMessageFlags_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []uint32
+		if args[3] != nil {
+			arg3 = args[3].([]uint32)
+		}
+		var arg4 models.FlagOperation
+		if args[4] != nil {
+			arg4 = args[4].(models.FlagOperation)
+		}
+		var arg5 models.MessageFlags
+		if args[5] != nil {
+			arg5 = args[5].(models.MessageFlags)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_UpdateMessageFlags_Call) Return(err error) *Repository_UpdateMessageFlags_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Repository_UpdateMessageFlags_Call) RunAndReturn(run func(ctx context.Context, inboxID string, folderID string, uids []uint32, op models.FlagOperation, flags models.MessageFlags) error) *Repository_UpdateMessageFlags_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateMessageReadStatus provides a mock function for the type Repository
 func (_mock *Repository) UpdateMessageReadStatus(ctx context.Context, messageID string, isRead bool) error {
 	ret := _mock.Called(ctx, messageID, isRead)
@@ -3115,6 +7183,63 @@ func (_c *Repository_UpdateMessageReadStatus_Call) RunAndReturn(run func(ctx con
 	return _c
 }
 
+// UpdateOutboundMessage provides a mock function for the type Repository
+func (_mock *Repository) UpdateOutboundMessage(ctx context.Context, message *models.OutboundMessage) error {
+	ret := _mock.Called(ctx, message)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateOutboundMessage")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.OutboundMessage) error); ok {
+		r0 = returnFunc(ctx, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Repository_UpdateOutboundMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateOutboundMessage'
+type Repository_UpdateOutboundMessage_Call struct {
+	*mock.Call
+}
+
+// UpdateOutboundMessage is a helper method to define mock.On call
+//   - ctx context.Context
+//   - message *models.OutboundMessage
+func (_e *Repository_Expecter) UpdateOutboundMessage(ctx interface{}, message interface{}) *Repository_UpdateOutboundMessage_Call {
+	return &Repository_UpdateOutboundMessage_Call{Call: _e.mock.On("UpdateOutboundMessage", ctx, message)}
+}
+
+func (_c *Repository_UpdateOutboundMessage_Call) Run(run func(ctx context.Context, message *models.OutboundMessage)) *Repository_UpdateOutboundMessage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *models.OutboundMessage
+		if args[1] != nil {
+			arg1 = args[1].(*models.OutboundMessage)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_UpdateOutboundMessage_Call) Return(err error) *Repository_UpdateOutboundMessage_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Repository_UpdateOutboundMessage_Call) RunAndReturn(run func(ctx context.Context, message *models.OutboundMessage) error) *Repository_UpdateOutboundMessage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateProject provides a mock function for the type Repository
 func (_mock *Repository) UpdateProject(ctx context.Context, project *models.Project) error {
 	ret := _mock.Called(ctx, project)
@@ -3342,3 +7467,66 @@ func (_c *Repository_UpdateUser_Call) RunAndReturn(run func(ctx context.Context,
 	_c.Call.Return(run)
 	return _c
 }
+
+// UpdateUserDefaultInbox provides a mock function for the type Repository
+func (_mock *Repository) UpdateUserDefaultInbox(ctx context.Context, userID string, inboxID string) error {
+	ret := _mock.Called(ctx, userID, inboxID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUserDefaultInbox")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, userID, inboxID)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Repository_UpdateUserDefaultInbox_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateUserDefaultInbox'
+type Repository_UpdateUserDefaultInbox_Call struct {
+	*mock.Call
+}
+
+// UpdateUserDefaultInbox is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - inboxID string
+func (_e *Repository_Expecter) UpdateUserDefaultInbox(ctx interface{}, userID interface{}, inboxID interface{}) *Repository_UpdateUserDefaultInbox_Call {
+	return &Repository_UpdateUserDefaultInbox_Call{Call: _e.mock.On("UpdateUserDefaultInbox", ctx, userID, inboxID)}
+}
+
+func (_c *Repository_UpdateUserDefaultInbox_Call) Run(run func(ctx context.Context, userID string, inboxID string)) *Repository_UpdateUserDefaultInbox_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *Repository_UpdateUserDefaultInbox_Call) Return(err error) *Repository_UpdateUserDefaultInbox_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Repository_UpdateUserDefaultInbox_Call) RunAndReturn(run func(ctx context.Context, userID string, inboxID string) error) *Repository_UpdateUserDefaultInbox_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 
 	null "github.com/volatiletech/null/v9"
@@ -18,12 +19,59 @@ type Base struct {
 type Project struct {
 	Base
 	Name string `json:"name" db:"name" validate:"required,min=2,max=100"`
+	Usage
 }
 
 type Inbox struct {
 	Base
 	ProjectID string `json:"project_id" db:"project_id" validate:"required"`
 	Email     string `json:"email" db:"email" validate:"required,email"`
+	// DMARCReports marks the inbox as a DMARC report sink, whose aggregate
+	// reports are parsed on delivery
+	DMARCReports bool   `json:"dmarc_reports" db:"dmarc_reports"`
+	UIDValidity  uint32 `json:"uid_validity" db:"uid_validity"`
+	UIDNext      uint32 `json:"uid_next" db:"uid_next"`
+	Usage
+	// ProjectName is only loaded when listing the inboxes of a user
+	ProjectName string `json:"project_name,omitempty" db:"project_name"`
+}
+
+// Usage is the storage quota of an inbox or a project with what it holds. A
+// limit of 0 is no limit; the usage is maintained as messages come and go and
+// can't be set.
+type Usage struct {
+	MaxMessages  int64 `json:"max_messages" db:"max_messages" validate:"min=0"`
+	MaxBytes     int64 `json:"max_bytes" db:"max_bytes" validate:"min=0"`
+	MessageCount int64 `json:"message_count" db:"message_count"`
+	StorageBytes int64 `json:"storage_bytes" db:"storage_bytes"`
+}
+
+// Allows reports whether a message of size bytes fits in the quota
+func (u Usage) Allows(size int64) bool {
+	return u.AllowsMany(1, size)
+}
+
+// AllowsMany reports whether count messages of size bytes in all fit in the
+// quota
+func (u Usage) AllowsMany(count, size int64) bool {
+	return (u.MaxMessages == 0 || u.MessageCount+count <= u.MaxMessages) &&
+		(u.MaxBytes == 0 || u.StorageBytes+size <= u.MaxBytes)
+}
+
+// Exceeded reports whether the quota is used up, so that not even an empty
+// message fits
+func (u Usage) Exceeded() bool {
+	return !u.Allows(0)
+}
+
+// Quota is the usage of the inbox or the project a message is stored against
+type Quota struct {
+	// Kind is "inbox" or "project"
+	Kind string `json:"kind" db:"kind"`
+	ID   string `json:"id" db:"id"`
+	// Name is the email of an inbox, the name of a project
+	Name string `json:"name" db:"name"`
+	Usage
 }
 
 type User struct {
@@ -38,6 +86,9 @@ type User struct {
 	Role          string      `json:"role" db:"role"`
 	PasswordLogin bool        `json:"password_login" db:"password_login"`
 	LoggedinAt    null.Time   `json:"loggedin_at" db:"loggedin_at"`
+	// DefaultInboxID is the inbox IMAP clients see as INBOX, the first inbox of
+	// the user when not set
+	DefaultInboxID null.String `json:"default_inbox_id" db:"default_inbox_id"`
 }
 
 func (u *User) HashPassword(password string) error {
@@ -93,23 +144,284 @@ type ForwardRule struct {
 	Sender   string `json:"sender" db:"sender" validate:"omitempty,email"`
 	Receiver string `json:"receiver" db:"receiver" validate:"omitempty,email"`
 	Subject  string `json:"subject" db:"subject" validate:"omitempty,max=200"`
+	// ForwardTo is where matching messages are forwarded, if anywhere
+	ForwardTo string `json:"forward_to" db:"forward_to" validate:"omitempty,email"`
 }
 
 type Message struct {
 	Base
-	InboxID   string `json:"inbox_id" db:"inbox_id" validate:"required"`
-	UID       uint32 `json:"uid" db:"uid"`
-	Sender    string `json:"sender" db:"sender" validate:"required,email"`
-	Receiver  string `json:"receiver" db:"receiver" validate:"required,email"`
-	Subject   string `json:"subject" db:"subject" validate:"required,max=200"`
-	Body      string `json:"body" db:"body" validate:"required"`
-	IsRead    bool   `json:"is_read" db:"is_read"`
-	IsDeleted bool   `json:"is_deleted" db:"is_deleted"`
+	InboxID    string      `json:"inbox_id" db:"inbox_id" validate:"required"`
+	FolderID   null.String `json:"folder_id" db:"folder_id"`
+	UID        uint32      `json:"uid" db:"uid"`
+	Sender     string      `json:"sender" db:"sender" validate:"required,email"`
+	Receiver   string      `json:"receiver" db:"receiver" validate:"required,email"`
+	Subject    string      `json:"subject" db:"subject" validate:"required,max=200"`
+	Body       string      `json:"body" db:"body" validate:"required"`
+	IsRead     bool        `json:"is_read" db:"is_read"`
+	IsDeleted  bool        `json:"is_deleted" db:"is_deleted"`
+	IsFlagged  bool        `json:"is_flagged" db:"is_flagged"`
+	IsAnswered bool        `json:"is_answered" db:"is_answered"`
+	IsDraft    bool        `json:"is_draft" db:"is_draft"`
+	// ModSeq is the mod-sequence of the last change to the message (RFC 7162)
+	ModSeq uint64 `json:"modseq" db:"modseq"`
+	// Raw is the RFC 822 message as received. It is only loaded where needed.
+	Raw []byte `json:"-" db:"raw"`
+	// ThreadID groups the messages of a conversation. It is the ID of the first
+	// message of the thread.
+	ThreadID string `json:"thread_id" db:"thread_id"`
+	// HeaderMessageID, References and BaseSubject link the message to its thread.
+	// References holds the References and In-Reply-To message IDs, the parent last.
+	HeaderMessageID string         `json:"-" db:"header_message_id"`
+	References      pq.StringArray `json:"-" db:"header_references"`
+	BaseSubject     string         `json:"-" db:"base_subject"`
+	// DNSBLHits lists the DNS blocklists the sending MTA was listed on
+	DNSBLHits pq.StringArray `json:"dnsbl_hits" db:"dnsbl_hits"`
+	// Size is what the message counts against the storage quotas, the length
+	// of Raw, or of Body when there's no raw message
+	Size int64 `json:"size" db:"size"`
+	// Labels is populated by the services, it is not a column of messages.
+	Labels []*Label `json:"labels" db:"-"`
 }
 
 type MessageFilters struct {
+	// FolderID selects the folder to list, the inbox itself when empty
+	FolderID  string
 	IsRead    *bool
 	IsDeleted *bool
+	LabelID   *string
+}
+
+// FlagOperation selects how a flag update is applied to the current flags of a message
+type FlagOperation int
+
+const (
+	FlagsAdd FlagOperation = iota
+	FlagsRemove
+	FlagsSet
+)
+
+// MessageFlags is the set of flags a flag update refers to. Keywords are
+// stored as labels and referenced by their IDs.
+type MessageFlags struct {
+	Seen     bool
+	Answered bool
+	Flagged  bool
+	Deleted  bool
+	Draft    bool
+	LabelIDs []string
+	// UnchangedSince, when set, limits the update to messages whose mod-sequence
+	// is not above it (RFC 7162 UNCHANGEDSINCE)
+	UnchangedSince uint64
+}
+
+// Folder is a mailbox inside an inbox. Name is the full path, using "/" as delimiter.
+type Folder struct {
+	Base
+	InboxID     string      `json:"inbox_id" db:"inbox_id" validate:"required"`
+	Name        string      `json:"name" db:"name" validate:"required,min=1,max=255"`
+	UIDValidity uint32      `json:"uid_validity" db:"uid_validity"`
+	UIDNext     uint32      `json:"uid_next" db:"uid_next"`
+	Subscribed  bool        `json:"subscribed" db:"subscribed"`
+	SpecialUse  null.String `json:"special_use" db:"special_use"`
+}
+
+type Label struct {
+	Base
+	ProjectID string `json:"project_id" db:"project_id" validate:"required"`
+	Name      string `json:"name" db:"name" validate:"required,min=1,max=100"`
+	Color     string `json:"color" db:"color" validate:"omitempty,hexcolor"`
+}
+
+// MessageChange is a message created, updated or removed after a given mod-sequence
+type MessageChange struct {
+	MessageID string `db:"id"`
+	ThreadID  string `db:"thread_id"`
+	ModSeq    uint64 `db:"modseq"`
+	Created   bool   `db:"created"`
+	Destroyed bool   `db:"destroyed"`
+}
+
+// MailboxCount is the number of messages in an inbox or one of its folders
+type MailboxCount struct {
+	InboxID  string      `db:"inbox_id"`
+	FolderID null.String `db:"folder_id"`
+	Total    int         `db:"total"`
+	Unread   int         `db:"unread"`
+	// Threads and UnreadThreads count the threads with messages in the mailbox
+	Threads       int `db:"threads"`
+	UnreadThreads int `db:"unread_threads"`
+}
+
+// Thread is a conversation: the messages of an inbox linked by their Message-ID,
+// In-Reply-To and References headers or, failing those, by their subject
+type Thread struct {
+	ID           string    `json:"id" db:"id"`
+	InboxID      string    `json:"inbox_id" db:"inbox_id"`
+	Subject      string    `json:"subject" db:"subject"`
+	MessageCount int       `json:"message_count" db:"message_count"`
+	UnreadCount  int       `json:"unread_count" db:"unread_count"`
+	LastActivity null.Time `json:"last_activity" db:"last_activity"`
+}
+
+// SenderAddress allows the members of a project, or a single one of them, to
+// submit mail from an address that is not one of the project's inboxes. An
+// Address starting with "@" allows every address of that domain.
+type SenderAddress struct {
+	Base
+	ProjectID string      `json:"project_id" db:"project_id"`
+	UserID    null.String `json:"user_id" db:"user_id" validate:"omitempty,uuid"`
+	Address   string      `json:"address" db:"address" validate:"required,max=255"`
+}
+
+// OutboundMessage is a submitted message queued for delivery to external
+// recipients through the smarthost
+type OutboundMessage struct {
+	Base
+	UserID        null.String    `json:"user_id" db:"user_id"`
+	MailFrom      string         `json:"mail_from" db:"mail_from"`
+	Recipients    pq.StringArray `json:"recipients" db:"recipients"`
+	Raw           []byte         `json:"-" db:"raw"`
+	Status        string         `json:"status" db:"status"`
+	Attempts      int            `json:"attempts" db:"attempts"`
+	NextAttemptAt null.Time      `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError     string         `json:"last_error" db:"last_error"`
+}
+
+// DKIMKey is a key outgoing mail from a domain is signed with. DNSName and
+// DNSRecord are the TXT record that publishes its public key.
+type DKIMKey struct {
+	Base
+	Domain     string `json:"domain" db:"domain"`
+	Selector   string `json:"selector" db:"selector"`
+	Algorithm  string `json:"algorithm" db:"algorithm"`
+	PublicKey  string `json:"public_key" db:"public_key"`
+	PrivateKey []byte `json:"-" db:"private_key"`
+	DNSName    string `json:"dns_name" db:"-"`
+	DNSRecord  string `json:"dns_record" db:"-"`
+}
+
+// DKIMKeyRequest creates a DKIM key. A new key is generated unless PrivateKey
+// holds a PEM encoded key to import.
+type DKIMKeyRequest struct {
+	Domain     string `json:"domain" validate:"required,fqdn"`
+	Selector   string `json:"selector" validate:"required,max=63"`
+	Algorithm  string `json:"algorithm" validate:"omitempty,oneof=rsa ed25519"`
+	Bits       int    `json:"bits" validate:"omitempty,oneof=1024 2048 3072 4096"`
+	PrivateKey string `json:"private_key"`
+}
+
+// TLSReport is an SMTP TLS aggregate report (RFC 8460) a sending MTA mailed to
+// the report address. Policies holds the report's policies as sent; Domains
+// and the session counts are summed up from them.
+type TLSReport struct {
+	Base
+	OrganizationName   string          `json:"organization_name" db:"organization_name"`
+	ReportID           string          `json:"report_id" db:"report_id"`
+	ContactInfo        string          `json:"contact_info" db:"contact_info"`
+	StartDate          null.Time       `json:"start_date" db:"start_date"`
+	EndDate            null.Time       `json:"end_date" db:"end_date"`
+	Domains            pq.StringArray  `json:"domains" db:"domains"`
+	SuccessfulSessions int64           `json:"successful_sessions" db:"successful_sessions"`
+	FailedSessions     int64           `json:"failed_sessions" db:"failed_sessions"`
+	Policies           json.RawMessage `json:"policies" db:"policies"`
+}
+
+// TLSReportQuery filters the TLS reports listed
+type TLSReportQuery struct {
+	PaginationQuery
+	Domain string `query:"domain" validate:"omitempty,fqdn"`
+}
+
+// DMARCReport is a DMARC aggregate report (RFC 7489) received by an inbox
+// flagged as a report sink, with the policy published for the domain.
+// MessageCount sums its records, which are only loaded for a single report.
+type DMARCReport struct {
+	Base
+	InboxID      string         `json:"inbox_id" db:"inbox_id"`
+	OrgName      string         `json:"org_name" db:"org_name"`
+	OrgEmail     string         `json:"org_email" db:"org_email"`
+	ReportID     string         `json:"report_id" db:"report_id"`
+	Domain       string         `json:"domain" db:"domain"`
+	DateBegin    null.Time      `json:"date_begin" db:"date_begin"`
+	DateEnd      null.Time      `json:"date_end" db:"date_end"`
+	PolicyADKIM  string         `json:"policy_adkim" db:"policy_adkim"`
+	PolicyASPF   string         `json:"policy_aspf" db:"policy_aspf"`
+	PolicyP      string         `json:"policy_p" db:"policy_p"`
+	PolicySP     string         `json:"policy_sp" db:"policy_sp"`
+	PolicyPct    int            `json:"policy_pct" db:"policy_pct"`
+	MessageCount int64          `json:"message_count" db:"message_count"`
+	Records      []*DMARCRecord `json:"records,omitempty" db:"-"`
+}
+
+// DMARCRecord counts the messages from a source IP that got the same
+// results. DKIM and SPF are the aligned results DMARC was evaluated on,
+// AuthResults the raw ones.
+type DMARCRecord struct {
+	ID           string          `json:"id" db:"id"`
+	ReportID     string          `json:"-" db:"report_id"`
+	SourceIP     string          `json:"source_ip" db:"source_ip"`
+	Count        int64           `json:"count" db:"count"`
+	Disposition  string          `json:"disposition" db:"disposition"`
+	DKIM         string          `json:"dkim" db:"dkim"`
+	SPF          string          `json:"spf" db:"spf"`
+	HeaderFrom   string          `json:"header_from" db:"header_from"`
+	EnvelopeFrom string          `json:"envelope_from" db:"envelope_from"`
+	AuthResults  json.RawMessage `json:"auth_results" db:"auth_results"`
+}
+
+// DMARCQuery filters the DMARC reports of a project, or groups their records
+// by "domain", "source" or "date". Start and End are dates (2006-01-02) or
+// RFC 3339 times.
+type DMARCQuery struct {
+	PaginationQuery
+	Domain  string `query:"domain" validate:"omitempty,fqdn"`
+	Source  string `query:"source" validate:"omitempty,ip"`
+	Start   string `query:"start"`
+	End     string `query:"end"`
+	GroupBy string `query:"group_by" validate:"omitempty,oneof=domain source date"`
+}
+
+// DMARCFilter selects DMARC reports and records in storage
+type DMARCFilter struct {
+	ProjectID string
+	Domain    string
+	Source    string
+	Start     null.Time
+	End       null.Time
+	GroupBy   string
+}
+
+// DMARCSummary sums the DMARC records sharing a domain, source IP or day
+type DMARCSummary struct {
+	Key         string `json:"key" db:"key"`
+	Messages    int64  `json:"messages" db:"messages"`
+	DKIMPass    int64  `json:"dkim_pass" db:"dkim_pass"`
+	SPFPass     int64  `json:"spf_pass" db:"spf_pass"`
+	DMARCPass   int64  `json:"dmarc_pass" db:"dmarc_pass"`
+	Quarantined int64  `json:"quarantined" db:"quarantined"`
+	Rejected    int64  `json:"rejected" db:"rejected"`
+}
+
+// MTASTSPolicy is the MTA-STS policy (RFC 8461) served for a domain, with the
+// TXT record that announces it and, when reports are accepted, the TLS-RPT
+// record that asks for them
+type MTASTSPolicy struct {
+	Domain          string   `json:"domain"`
+	Mode            string   `json:"mode"`
+	MX              []string `json:"mx"`
+	MaxAge          int64    `json:"max_age"`
+	Policy          string   `json:"policy"`
+	PolicyURL       string   `json:"policy_url"`
+	DNSName         string   `json:"dns_name"`
+	DNSRecord       string   `json:"dns_record"`
+	TLSRPTDNSName   string   `json:"tlsrpt_dns_name,omitempty"`
+	TLSRPTDNSRecord string   `json:"tlsrpt_dns_record,omitempty"`
+}
+
+// MessageLabel is a label together with the message it is assigned to.
+type MessageLabel struct {
+	Label
+	MessageID string `json:"message_id" db:"message_id"`
 }
 
 type Session struct {
@@ -122,3 +434,50 @@ type Session struct {
 	UserAgent      string          `db:"user_agent" json:"user_agent"`
 	IsActive       bool            `db:"is_active" json:"is_active"`
 }
+
+// DefaultInboxRequest sets the inbox IMAP clients of a user see as INBOX. An
+// empty InboxID goes back to the first inbox of the user.
+type DefaultInboxRequest struct {
+	InboxID string `json:"inbox_id" validate:"omitempty,uuid"`
+}
+
+// RuleTestRequest carries the message a single rule is dry-run against.
+// Either Raw (a full RFC 822 message) or MessageID must be set.
+type RuleTestRequest struct {
+	Raw       string `json:"raw"`
+	MessageID string `json:"message_id"`
+}
+
+// RuleMatch describes the outcome of evaluating one rule against one message.
+type RuleMatch struct {
+	RuleID  string   `json:"rule_id"`
+	Matched bool     `json:"matched"`
+	Reasons []string `json:"reasons"`
+}
+
+// RuleSimulateRequest selects how many of the most recent messages are
+// evaluated when simulating an inbox's rules.
+type RuleSimulateRequest struct {
+	Limit int `json:"limit" validate:"min=0,max=100"`
+}
+
+// RuleSimulation reports which rule, if any, would have matched a stored message.
+type RuleSimulation struct {
+	MessageID     string      `json:"message_id"`
+	Sender        string      `json:"sender"`
+	Receiver      string      `json:"receiver"`
+	Subject       string      `json:"subject"`
+	MatchedRuleID null.String `json:"matched_rule_id"`
+	Reasons       []string    `json:"reasons"`
+}
+
+// GreylistTriplet is a client network, envelope sender and recipient that
+// tried to deliver. FirstSeen is when the current greylisting started, LastSeen
+// the latest attempt.
+type GreylistTriplet struct {
+	Network   string    `json:"network" db:"network"`
+	Sender    string    `json:"sender" db:"sender"`
+	Recipient string    `json:"recipient" db:"recipient"`
+	FirstSeen null.Time `json:"first_seen" db:"first_seen"`
+	LastSeen  null.Time `json:"last_seen" db:"last_seen"`
+}
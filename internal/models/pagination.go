@@ -18,5 +18,7 @@ type PaginatedResponse struct {
 
 type MessageQuery struct {
 	PaginationQuery
-	IsRead *bool `query:"is_read"`
+	IsRead   *bool   `query:"is_read"`
+	LabelID  *string `query:"label_id" validate:"omitempty,uuid"`
+	FolderID string  `query:"folder_id" validate:"omitempty,uuid"`
 }
@@ -0,0 +1,128 @@
+package pop3
+
+import (
+	"bytes"
+	"context"
+
+	"inbox451/internal/core"
+	"inbox451/internal/models"
+)
+
+// maildrop is the snapshot of an inbox a POP3 session works on. Messages are
+// numbered from 1 in UID order and keep their number for the whole session,
+// deleted or not.
+type maildrop struct {
+	inbox    *models.Inbox
+	messages []*models.Message
+	// contents holds each message in RFC 822 form with CRLF line endings,
+	// whose length is the size reported to the client
+	contents [][]byte
+	deleted  []bool
+}
+
+// openMaildrop loads the messages of the inbox itself, without its folders and
+// without messages marked as deleted over IMAP. POP3 clients need the size of
+// every message upfront, so the messages are loaded in full.
+func openMaildrop(ctx context.Context, c *core.Core, inbox *models.Inbox) (*maildrop, error) {
+	notDeleted := false
+	messages, err := c.MessageService.ListAll(ctx, inbox.ID, models.MessageFilters{IsDeleted: &notDeleted})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.MessageService.AttachRaw(ctx, messages); err != nil {
+		return nil, err
+	}
+
+	m := &maildrop{
+		inbox:    inbox,
+		messages: messages,
+		contents: make([][]byte, len(messages)),
+		deleted:  make([]bool, len(messages)),
+	}
+	for i, message := range messages {
+		m.contents[i] = toCRLF(core.RawMessage(message))
+		// The raw messages are only needed in their CRLF form
+		message.Raw = nil
+	}
+	return m, nil
+}
+
+// message returns the index of the message with the given number, or -1 when
+// there is no such message or it was deleted
+func (m *maildrop) message(num int) int {
+	i := num - 1
+	if i < 0 || i >= len(m.messages) || m.deleted[i] {
+		return -1
+	}
+	return i
+}
+
+// stat returns the number and total size of the messages not marked as deleted
+func (m *maildrop) stat() (count int, size int) {
+	for i, content := range m.contents {
+		if !m.deleted[i] {
+			count++
+			size += len(content)
+		}
+	}
+	return count, size
+}
+
+// reset unmarks all messages marked as deleted
+func (m *maildrop) reset() {
+	for i := range m.deleted {
+		m.deleted[i] = false
+	}
+}
+
+// commit removes the messages marked as deleted. It returns the number of
+// messages that could not be removed.
+func (m *maildrop) commit(ctx context.Context, c *core.Core) int {
+	failed := 0
+	for i, message := range m.messages {
+		if !m.deleted[i] {
+			continue
+		}
+		if err := c.MessageService.Delete(ctx, message.ID); err != nil {
+			c.Logger.Error("POP3: Failed to delete message %s: %v", message.ID, err)
+			failed++
+		}
+	}
+	return failed
+}
+
+// toCRLF converts bare LF line endings to CRLF
+func toCRLF(content []byte) []byte {
+	if bytes.Count(content, []byte("\n")) == bytes.Count(content, []byte("\r\n")) {
+		return content
+	}
+
+	out := make([]byte, 0, len(content)+len(content)/32)
+	for i, b := range content {
+		if b == '\n' && (i == 0 || content[i-1] != '\r') {
+			out = append(out, '\r')
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// top returns the header of a message, the blank line after it and the first
+// lines of its body (RFC 1939 TOP)
+func top(content []byte, lines int) []byte {
+	end := bytes.Index(content, []byte("\r\n\r\n"))
+	if end < 0 {
+		return content
+	}
+	end += len("\r\n\r\n")
+
+	for n := 0; n < lines && end < len(content); n++ {
+		next := bytes.Index(content[end:], []byte("\r\n"))
+		if next < 0 {
+			return content
+		}
+		end += next + len("\r\n")
+	}
+	return content[:end]
+}
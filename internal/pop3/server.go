@@ -0,0 +1,128 @@
+package pop3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"inbox451/internal/core"
+)
+
+// Pop3Server serves the inboxes of users over POP3 (RFC 1939)
+type Pop3Server struct {
+	core *core.Core
+	addr string
+	// tlsConfig enables STLS when set
+	tlsConfig         *tls.Config
+	allowInsecureAuth bool
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// NewServer creates and configures a new POP3 server
+func NewServer(core *core.Core) (*Pop3Server, error) {
+	core.Logger.Info("POP3 Server initializing")
+
+	cfg := core.Config.Server.POP3
+	s := &Pop3Server{
+		core:              core,
+		allowInsecureAuth: cfg.AllowInsecureAuth,
+		conns:             make(map[net.Conn]struct{}),
+	}
+
+	// Configure server address
+	if cfg.Address != "" {
+		s.addr = cfg.Address
+	} else if cfg.Port != "" {
+		if cfg.Port[0] == ':' {
+			s.addr = cfg.Port
+		} else {
+			s.addr = ":" + cfg.Port
+		}
+	} else {
+		s.addr = ":1110"
+	}
+
+	core.Logger.Info("POP3 Server will listen on: %s", s.addr)
+
+	// Configure TLS if enabled
+	if cfg.EnableTLS {
+		config, err := core.TLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("POP3: Failed to load TLS configuration: %w", err)
+		}
+		s.tlsConfig = config
+	}
+
+	return s, nil
+}
+
+// ListenAndServe starts the POP3 server
+func (s *Pop3Server) ListenAndServe() error {
+	l, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on the listener until the server is shut down
+func (s *Pop3Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return errors.New("POP3 server is closed")
+	}
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		go func() {
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+			}()
+			newSession(s, conn).serve()
+		}()
+	}
+}
+
+// Shutdown stops accepting connections and closes the open ones. Messages
+// marked for deletion by sessions that did not QUIT are kept.
+func (s *Pop3Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return err
+}
@@ -0,0 +1,393 @@
+package pop3
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"inbox451/internal/models"
+
+	"github.com/emersion/go-sasl"
+)
+
+// idleTimeout is the RFC 1939 autologout timer
+const idleTimeout = 10 * time.Minute
+
+var (
+	errAuthFailed  = errors.New("[AUTH] Invalid credentials")
+	errAuthAborted = errors.New("authentication cancelled")
+)
+
+// session is a single POP3 connection. It starts in the AUTHORIZATION state
+// and enters the TRANSACTION state once the maildrop is open.
+type session struct {
+	server *Pop3Server
+	conn   net.Conn
+	text   *textproto.Conn
+	tls    bool
+
+	// username is the argument of the last USER command
+	username string
+	user     *models.User
+	drop     *maildrop
+}
+
+func newSession(s *Pop3Server, conn net.Conn) *session {
+	_, isTLS := conn.(*tls.Conn)
+	return &session{
+		server: s,
+		conn:   conn,
+		text:   textproto.NewConn(conn),
+		tls:    isTLS,
+	}
+}
+
+func (s *session) serve() {
+	defer s.text.Close()
+
+	logger := s.server.core.Logger
+	logger.Debug("POP3: New connection from %s", s.conn.RemoteAddr())
+
+	if err := s.ok("inbox451 POP3 server ready"); err != nil {
+		return
+	}
+
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		line, err := s.text.ReadLine()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				logger.Debug("POP3: Connection from %s closed: %v", s.conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		cmd, args, _ := strings.Cut(line, " ")
+		cmd = strings.ToUpper(cmd)
+
+		var quit bool
+		if s.drop == nil {
+			quit, err = s.handleAuthorization(cmd, args)
+		} else {
+			quit, err = s.handleTransaction(cmd, args)
+		}
+		if err != nil || quit {
+			return
+		}
+	}
+}
+
+func (s *session) ok(format string, args ...interface{}) error {
+	return s.text.PrintfLine("+OK "+format, args...)
+}
+
+func (s *session) fail(format string, args ...interface{}) error {
+	return s.text.PrintfLine("-ERR "+format, args...)
+}
+
+// multiline writes a positive response followed by a dot-stuffed body
+func (s *session) multiline(status string, body []byte) error {
+	if err := s.ok("%s", status); err != nil {
+		return err
+	}
+	w := s.text.DotWriter()
+	if _, err := w.Write(body); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (s *session) capabilities() []string {
+	caps := []string{"TOP", "UIDL", "USER", "RESP-CODES", "AUTH-RESP-CODE", "PIPELINING"}
+	if s.drop == nil {
+		if s.server.tlsConfig != nil && !s.tls {
+			caps = append(caps, "STLS")
+		}
+		if s.authAllowed() {
+			caps = append(caps, "SASL "+sasl.Plain)
+		}
+	}
+	return append(caps, "IMPLEMENTATION inbox451")
+}
+
+// authAllowed reports whether credentials may be sent over the connection
+func (s *session) authAllowed() bool {
+	return s.tls || s.server.allowInsecureAuth
+}
+
+func (s *session) handleAuthorization(cmd, args string) (bool, error) {
+	switch cmd {
+	case "CAPA":
+		return false, s.multiline("Capability list follows", []byte(strings.Join(s.capabilities(), "\r\n")+"\r\n"))
+	case "STLS":
+		return false, s.startTLS()
+	case "USER":
+		if !s.authAllowed() {
+			return false, s.fail("[AUTH] TLS is required to log in")
+		}
+		if args == "" {
+			return false, s.fail("Missing username")
+		}
+		s.username = args
+		return false, s.ok("Send your token as password")
+	case "PASS":
+		if s.username == "" {
+			return false, s.fail("Send USER first")
+		}
+		username := s.username
+		s.username = ""
+		return false, s.login(username, args)
+	case "AUTH":
+		return false, s.authenticate(args)
+	case "QUIT":
+		return true, s.ok("Bye")
+	default:
+		return false, s.fail("Command not valid in this state")
+	}
+}
+
+func (s *session) startTLS() error {
+	if s.tls {
+		return s.fail("TLS is already active")
+	}
+	if s.server.tlsConfig == nil {
+		return s.fail("TLS is not available")
+	}
+	if err := s.ok("Begin TLS negotiation"); err != nil {
+		return err
+	}
+
+	conn := tls.Server(s.conn, s.server.tlsConfig)
+	if err := conn.Handshake(); err != nil {
+		s.server.core.Logger.Warn("POP3: TLS handshake with %s failed: %v", s.conn.RemoteAddr(), err)
+		return err
+	}
+
+	s.conn = conn
+	s.text = textproto.NewConn(conn)
+	s.tls = true
+	s.username = ""
+	return nil
+}
+
+// authenticate runs RFC 5034 AUTH. Only the PLAIN mechanism is offered.
+func (s *session) authenticate(args string) error {
+	mech, initial, hasInitial := strings.Cut(args, " ")
+	if mech == "" {
+		return s.multiline("Supported mechanisms follow", []byte(sasl.Plain+"\r\n"))
+	}
+	if !s.authAllowed() {
+		return s.fail("[AUTH] TLS is required to log in")
+	}
+	if !strings.EqualFold(mech, sasl.Plain) {
+		return s.fail("Unsupported authentication mechanism")
+	}
+
+	var username, password string
+	server := sasl.NewPlainServer(func(identity, user, pass string) error {
+		if identity != "" && identity != user {
+			return errAuthFailed
+		}
+		username, password = user, pass
+		return nil
+	})
+
+	if !hasInitial {
+		if err := s.text.PrintfLine("+ "); err != nil {
+			return err
+		}
+		line, err := s.text.ReadLine()
+		if err != nil {
+			return err
+		}
+		initial = line
+	}
+	if initial == "*" {
+		return s.fail("%v", errAuthAborted)
+	}
+
+	var response []byte
+	if initial != "=" {
+		decoded, err := base64.StdEncoding.DecodeString(initial)
+		if err != nil {
+			return s.fail("Invalid base64 response")
+		}
+		response = decoded
+	}
+
+	if _, _, err := server.Next(response); err != nil {
+		return s.fail("%v", errAuthFailed)
+	}
+	return s.login(username, password)
+}
+
+// login authenticates with a token the same way IMAP does and opens the
+// maildrop of the inbox named in the login, or the user's default inbox
+func (s *session) login(login, password string) error {
+	logger := s.server.core.Logger
+	username, email := parseLogin(login)
+	logger.Info("POP3 Token Login attempt for username: %s", username)
+
+	ctx := context.Background()
+	user, err := s.server.core.UserService.LoginWithToken(ctx, username, password)
+	if err != nil {
+		logger.Warn("POP3 Token Login failed for username: %s, error: %v", username, err)
+		return s.fail("%v", errAuthFailed)
+	}
+
+	var inbox *models.Inbox
+	if email != "" {
+		inbox, err = s.server.core.InboxService.GetByEmailAndUser(ctx, email, user.ID)
+	} else {
+		inbox, err = s.server.core.InboxService.DefaultForUser(ctx, user)
+	}
+	if err != nil {
+		logger.Warn("POP3: No inbox for username: %s, error: %v", username, err)
+		return s.fail("[AUTH] No such inbox")
+	}
+
+	drop, err := openMaildrop(ctx, s.server.core, inbox)
+	if err != nil {
+		logger.Error("POP3: Failed to open maildrop %s: %v", inbox.Email, err)
+		return s.fail("[SYS/TEMP] Unable to open maildrop")
+	}
+
+	logger.Info("POP3 Token Login successful for username: %s, inbox: %s", username, inbox.Email)
+	s.user = user
+	s.drop = drop
+	count, size := drop.stat()
+	return s.ok("%s has %d messages (%d octets)", inbox.Email, count, size)
+}
+
+// parseLogin splits a login of the form user@inbox into the username and the
+// inbox email. Usernames may be email addresses themselves, so the inbox email
+// only starts after the second to last @.
+func parseLogin(login string) (username, email string) {
+	if strings.Count(login, "@") < 2 {
+		return login, ""
+	}
+	at := strings.LastIndex(login, "@")
+	at = strings.LastIndex(login[:at], "@")
+	return login[:at], login[at+1:]
+}
+
+func (s *session) handleTransaction(cmd, args string) (bool, error) {
+	switch cmd {
+	case "CAPA":
+		return false, s.multiline("Capability list follows", []byte(strings.Join(s.capabilities(), "\r\n")+"\r\n"))
+	case "STAT":
+		count, size := s.drop.stat()
+		return false, s.ok("%d %d", count, size)
+	case "LIST":
+		return false, s.list(args, func(i int) string { return strconv.Itoa(len(s.drop.contents[i])) })
+	case "UIDL":
+		return false, s.list(args, func(i int) string { return s.drop.messages[i].ID })
+	case "RETR":
+		return false, s.retrieve(args)
+	case "TOP":
+		return false, s.top(args)
+	case "DELE":
+		i, err := s.messageArg(args)
+		if err != nil {
+			return false, s.fail("%v", err)
+		}
+		s.drop.deleted[i] = true
+		return false, s.ok("Message %d deleted", i+1)
+	case "NOOP":
+		return false, s.ok("")
+	case "RSET":
+		s.drop.reset()
+		count, size := s.drop.stat()
+		return false, s.ok("Maildrop has %d messages (%d octets)", count, size)
+	case "QUIT":
+		return true, s.update()
+	default:
+		return false, s.fail("Command not valid in this state")
+	}
+}
+
+// messageArg parses a message number and returns the index of the message
+func (s *session) messageArg(arg string) (int, error) {
+	num, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, errors.New("invalid message number")
+	}
+	i := s.drop.message(num)
+	if i < 0 {
+		return 0, errors.New("no such message")
+	}
+	return i, nil
+}
+
+// list answers LIST and UIDL, for a single message or all of them
+func (s *session) list(args string, value func(i int) string) error {
+	if args != "" {
+		i, err := s.messageArg(args)
+		if err != nil {
+			return s.fail("%v", err)
+		}
+		return s.ok("%d %s", i+1, value(i))
+	}
+
+	var b strings.Builder
+	for i := range s.drop.messages {
+		if !s.drop.deleted[i] {
+			fmt.Fprintf(&b, "%d %s\r\n", i+1, value(i))
+		}
+	}
+	count, _ := s.drop.stat()
+	return s.multiline(fmt.Sprintf("%d messages", count), []byte(b.String()))
+}
+
+func (s *session) retrieve(args string) error {
+	i, err := s.messageArg(args)
+	if err != nil {
+		return s.fail("%v", err)
+	}
+
+	content := s.drop.contents[i]
+	if err := s.multiline(fmt.Sprintf("%d octets", len(content)), content); err != nil {
+		return err
+	}
+
+	message := s.drop.messages[i]
+	if !message.IsRead {
+		if err := s.server.core.MessageService.MarkAsRead(context.Background(), message.ID); err != nil {
+			s.server.core.Logger.Warn("POP3: Failed to mark message %s as read: %v", message.ID, err)
+		} else {
+			message.IsRead = true
+		}
+	}
+	return nil
+}
+
+func (s *session) top(args string) error {
+	msg, lines, _ := strings.Cut(args, " ")
+	i, err := s.messageArg(msg)
+	if err != nil {
+		return s.fail("%v", err)
+	}
+	n, err := strconv.Atoi(lines)
+	if err != nil || n < 0 {
+		return s.fail("Invalid number of lines")
+	}
+	return s.multiline("Top of message follows", top(s.drop.contents[i], n))
+}
+
+// update enters the UPDATE state and removes the messages marked as deleted
+func (s *session) update() error {
+	if failed := s.drop.commit(context.Background(), s.server.core); failed > 0 {
+		return s.fail("[SYS/TEMP] %d messages could not be deleted", failed)
+	}
+	count, _ := s.drop.stat()
+	return s.ok("Bye (%d messages left)", count)
+}
@@ -0,0 +1,213 @@
+package pop3
+
+import (
+	"encoding/base64"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"inbox451/internal/core"
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testRaw = "From: sender@example.com\r\nTo: a@example.com\r\nSubject: Raw\r\n\r\nline 1\r\n.line 2\r\nline 3\r\n"
+
+func setupPop3TestServer(t *testing.T, allowInsecureAuth bool) (*Pop3Server, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+	c := &core.Core{
+		Logger:     logger.New(io.Discard, logger.DEBUG),
+		Repository: mockRepo,
+	}
+	c.UserService = core.NewUserService(c)
+	c.TokenService = core.NewTokensService(c)
+	c.InboxService = core.NewInboxService(c)
+	c.MessageService = core.NewMessageService(c)
+
+	return &Pop3Server{core: c, allowInsecureAuth: allowInsecureAuth}, mockRepo
+}
+
+// dial runs a session over an in-memory connection and returns the client end
+// after reading the greeting
+func dial(t *testing.T, s *Pop3Server) *textproto.Conn {
+	client, server := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		newSession(s, server).serve()
+		close(done)
+	}()
+
+	conn := textproto.NewConn(client)
+	t.Cleanup(func() {
+		conn.Close()
+		<-done
+	})
+
+	line, err := conn.ReadLine()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(line, "+OK"), line)
+	return conn
+}
+
+// cmd sends a command and returns the status line
+func cmd(t *testing.T, conn *textproto.Conn, line string) string {
+	require.NoError(t, conn.PrintfLine("%s", line))
+	resp, err := conn.ReadLine()
+	require.NoError(t, err)
+	return resp
+}
+
+func readLines(t *testing.T, conn *textproto.Conn) []string {
+	lines, err := conn.ReadDotLines()
+	require.NoError(t, err)
+	return lines
+}
+
+func expectLogin(m *mocks.Repository) {
+	user := &models.User{Base: models.Base{ID: "user-1"}, Username: "alice", Status: "active"}
+	m.On("GetUserByUsername", mock.Anything, "alice").Return(user, nil)
+	m.On("GetTokenByValue", mock.Anything, "secret").Return(&models.Token{UserID: "user-1"}, nil)
+}
+
+func expectMaildrop(m *mocks.Repository) {
+	messages := []*models.Message{
+		{Base: models.Base{ID: "msg-1"}, InboxID: "inbox-1", UID: 1},
+		{Base: models.Base{ID: "msg-2"}, InboxID: "inbox-1", UID: 2, IsRead: true},
+	}
+	m.On("ListMessagesByInboxWithFilters", mock.Anything, "inbox-1", mock.AnythingOfType("models.MessageFilters"), 100, 0).
+		Return(messages, 2, nil)
+	m.On("ListRawMessages", mock.Anything, []string{"msg-1", "msg-2"}).
+		Return([]*models.Message{
+			{Base: models.Base{ID: "msg-1"}, Raw: []byte(testRaw)},
+			{Base: models.Base{ID: "msg-2"}, Raw: []byte("Subject: Second\n\nbody\n")},
+		}, nil)
+}
+
+func TestSession(t *testing.T) {
+	s, m := setupPop3TestServer(t, true)
+	expectLogin(m)
+	m.On("ListInboxesByUser", mock.Anything, "user-1").
+		Return([]*models.Inbox{{Base: models.Base{ID: "inbox-1"}, Email: "a@example.com"}}, nil)
+	expectMaildrop(m)
+	m.On("UpdateMessageReadStatus", mock.Anything, "msg-1", true).Return(nil)
+	m.On("DeleteMessage", mock.Anything, "msg-2").Return(nil)
+
+	conn := dial(t, s)
+
+	assert.Equal(t, "-ERR Command not valid in this state", cmd(t, conn, "STAT"))
+	assert.Equal(t, "-ERR Send USER first", cmd(t, conn, "PASS secret"))
+	assert.Equal(t, "+OK Send your token as password", cmd(t, conn, "USER alice"))
+	assert.Equal(t, "+OK a@example.com has 2 messages (111 octets)", cmd(t, conn, "PASS secret"))
+
+	assert.Equal(t, "+OK 2 111", cmd(t, conn, "STAT"))
+
+	assert.Equal(t, "+OK 2 messages", cmd(t, conn, "LIST"))
+	assert.Equal(t, []string{"1 86", "2 25"}, readLines(t, conn))
+	assert.Equal(t, "+OK 2 25", cmd(t, conn, "LIST 2"))
+	assert.Equal(t, "-ERR no such message", cmd(t, conn, "LIST 3"))
+
+	assert.Equal(t, "+OK 2 messages", cmd(t, conn, "UIDL"))
+	assert.Equal(t, []string{"1 msg-1", "2 msg-2"}, readLines(t, conn))
+
+	assert.Equal(t, "+OK 86 octets", cmd(t, conn, "RETR 1"))
+	assert.Equal(t, strings.Split(strings.TrimSuffix(testRaw, "\r\n"), "\r\n"), readLines(t, conn))
+
+	assert.Equal(t, "+OK Top of message follows", cmd(t, conn, "TOP 1 1"))
+	assert.Equal(t, []string{"From: sender@example.com", "To: a@example.com", "Subject: Raw", "", "line 1"}, readLines(t, conn))
+	assert.Equal(t, "-ERR Invalid number of lines", cmd(t, conn, "TOP 1"))
+
+	assert.Equal(t, "+OK Message 2 deleted", cmd(t, conn, "DELE 2"))
+	assert.Equal(t, "-ERR no such message", cmd(t, conn, "RETR 2"))
+	assert.Equal(t, "+OK 1 86", cmd(t, conn, "STAT"))
+
+	assert.Equal(t, "+OK Maildrop has 2 messages (111 octets)", cmd(t, conn, "RSET"))
+	assert.Equal(t, "+OK Message 2 deleted", cmd(t, conn, "DELE 2"))
+
+	assert.Equal(t, "+OK Bye (1 messages left)", cmd(t, conn, "QUIT"))
+}
+
+func TestSession_AuthPlain(t *testing.T) {
+	s, m := setupPop3TestServer(t, true)
+	expectLogin(m)
+	m.On("GetInboxByEmailAndUser", mock.Anything, "a@example.com", "user-1").
+		Return(&models.Inbox{Base: models.Base{ID: "inbox-1"}, Email: "a@example.com"}, nil)
+	expectMaildrop(m)
+
+	conn := dial(t, s)
+
+	assert.Equal(t, "+OK Capability list follows", cmd(t, conn, "CAPA"))
+	assert.Contains(t, readLines(t, conn), "SASL PLAIN")
+
+	assert.Equal(t, "-ERR Unsupported authentication mechanism", cmd(t, conn, "AUTH CRAM-MD5"))
+	assert.Equal(t, "+ ", cmd(t, conn, "AUTH PLAIN"))
+	assert.Equal(t, "-ERR authentication cancelled", cmd(t, conn, "*"))
+
+	response := base64.StdEncoding.EncodeToString([]byte("\x00alice@a@example.com\x00secret"))
+	assert.Equal(t, "+ ", cmd(t, conn, "AUTH PLAIN"))
+	assert.Equal(t, "+OK a@example.com has 2 messages (111 octets)", cmd(t, conn, response))
+
+	assert.Equal(t, "+OK Bye (2 messages left)", cmd(t, conn, "QUIT"))
+}
+
+func TestSession_InsecureAuth(t *testing.T) {
+	s, _ := setupPop3TestServer(t, false)
+	conn := dial(t, s)
+
+	assert.Equal(t, "+OK Capability list follows", cmd(t, conn, "CAPA"))
+	assert.NotContains(t, readLines(t, conn), "SASL PLAIN")
+
+	assert.Equal(t, "-ERR [AUTH] TLS is required to log in", cmd(t, conn, "USER alice"))
+	assert.Equal(t, "-ERR [AUTH] TLS is required to log in", cmd(t, conn, "AUTH PLAIN AGFsaWNlAHNlY3JldA=="))
+	assert.Equal(t, "-ERR TLS is not available", cmd(t, conn, "STLS"))
+	assert.Equal(t, "+OK Bye", cmd(t, conn, "QUIT"))
+}
+
+func TestSession_AuthFailed(t *testing.T) {
+	s, m := setupPop3TestServer(t, true)
+	user := &models.User{Base: models.Base{ID: "user-1"}, Username: "alice", Status: "active"}
+	m.On("GetUserByUsername", mock.Anything, "alice").Return(user, nil)
+	m.On("GetTokenByValue", mock.Anything, "wrong").Return((*models.Token)(nil), nil)
+
+	conn := dial(t, s)
+
+	assert.Equal(t, "+OK Send your token as password", cmd(t, conn, "USER alice"))
+	assert.Equal(t, "-ERR [AUTH] Invalid credentials", cmd(t, conn, "PASS wrong"))
+	assert.Equal(t, "-ERR Command not valid in this state", cmd(t, conn, "STAT"))
+}
+
+func TestParseLogin(t *testing.T) {
+	tests := []struct {
+		login    string
+		username string
+		email    string
+	}{
+		{login: "alice", username: "alice"},
+		{login: "alice@example.com", username: "alice@example.com"},
+		{login: "alice@inbox@example.com", username: "alice", email: "inbox@example.com"},
+		{login: "alice@example.com@inbox@example.com", username: "alice@example.com", email: "inbox@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.login, func(t *testing.T) {
+			username, email := parseLogin(tt.login)
+			assert.Equal(t, tt.username, username)
+			assert.Equal(t, tt.email, email)
+		})
+	}
+}
+
+func TestTop(t *testing.T) {
+	content := []byte("Subject: a\r\n\r\none\r\ntwo\r\n")
+
+	assert.Equal(t, "Subject: a\r\n\r\n", string(top(content, 0)))
+	assert.Equal(t, "Subject: a\r\n\r\none\r\n", string(top(content, 1)))
+	assert.Equal(t, string(content), string(top(content, 5)))
+	assert.Equal(t, "a\r\nb\r\n", string(toCRLF([]byte("a\nb\r\n"))))
+}
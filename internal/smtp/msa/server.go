@@ -3,17 +3,23 @@ package msa
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
+	"inbox451/internal/config"
 	"inbox451/internal/core"
+	"inbox451/internal/mailauth"
 	"inbox451/internal/models"
-	"inbox451/internal/util"
+	"inbox451/internal/storage"
 
 	"github.com/emersion/go-message"
+	"github.com/emersion/go-message/mail"
 	"github.com/emersion/go-sasl"
 	"github.com/emersion/go-smtp"
 )
@@ -21,13 +27,19 @@ import (
 type MSAServer struct {
 	core *core.Core
 	smtp *smtp.Server
+	// tlsAddr is the implicit TLS (SMTPS) address, empty when disabled
+	tlsAddr string
 }
 
 type MSASession struct {
-	core         *core.Core
-	to           string
+	core *core.Core
+	// to are the recipients with an inbox on this server, relay the external
+	// recipients the message is relayed to through the smarthost
+	to           []string
+	relay        []string
 	from         string
 	authUsername string
+	authUserID   string
 }
 
 type MSABackend struct {
@@ -35,7 +47,11 @@ type MSABackend struct {
 }
 
 func (s *MSASession) AuthMechanisms() []string {
-	return []string{sasl.Plain}
+	mechanisms := []string{sasl.Plain, sasl.Login}
+	if s.core.BearerVerifier != nil {
+		mechanisms = append(mechanisms, sasl.OAuthBearer, mailauth.XOAuth2)
+	}
+	return mechanisms
 }
 
 func (backend MSABackend) NewSession(c *smtp.Conn) (smtp.Session, error) {
@@ -50,38 +66,110 @@ func (backend MSABackend) NewSession(c *smtp.Conn) (smtp.Session, error) {
 
 func (s *MSASession) Auth(mech string) (sasl.Server, error) {
 	s.Reset()
-	return sasl.NewPlainServer(func(identity, username, password string) error {
-		return s.AuthPlain(identity, username, password)
-	}), nil
+	switch {
+	case mech == sasl.Plain:
+		return sasl.NewPlainServer(func(identity, username, password string) error {
+			return s.AuthPlain(identity, username, password)
+		}), nil
+	case mech == sasl.Login:
+		return mailauth.NewLoginServer(func(username, password string) error {
+			return s.AuthPlain("", username, password)
+		}), nil
+	case mech == sasl.OAuthBearer && s.core.BearerVerifier != nil:
+		return bearerServer{sasl.NewOAuthBearerServer(func(opts sasl.OAuthBearerOptions) *sasl.OAuthBearerError {
+			if err := s.AuthBearer(opts.Username, opts.Token); err != nil {
+				return &sasl.OAuthBearerError{Status: "invalid_token", Schemes: "bearer"}
+			}
+			return nil
+		})}, nil
+	case mech == mailauth.XOAuth2 && s.core.BearerVerifier != nil:
+		return bearerServer{mailauth.NewXOAuth2Server(func(username, token string) error {
+			if err := s.AuthBearer(username, token); err != nil {
+				return &mailauth.XOAuth2Error{Status: "401", Schemes: "bearer"}
+			}
+			return nil
+		})}, nil
+	default:
+		return nil, smtp.ErrAuthUnknownMechanism
+	}
+}
+
+// bearerServer replies 535 once the client acknowledges the error challenge
+// of a rejected bearer token, rather than the 454 go-smtp uses by default
+type bearerServer struct {
+	sasl.Server
 }
 
-func NewServer(core *core.Core) *MSAServer {
+func (b bearerServer) Next(response []byte) ([]byte, bool, error) {
+	challenge, done, err := b.Server.Next(response)
+	var oauthErr *sasl.OAuthBearerError
+	var xoauthErr *mailauth.XOAuth2Error
+	if errors.As(err, &oauthErr) || errors.As(err, &xoauthErr) {
+		err = errAuthInvalid
+	}
+	return challenge, done, err
+}
+
+var errAuthInvalid = &smtp.SMTPError{
+	Code:         535,
+	EnhancedCode: smtp.EnhancedCode{5, 7, 8},
+	Message:      "Authentication credentials invalid",
+}
+
+// errMailboxFull refuses a message that doesn't fit in the quotas of a local
+// recipient
+var errMailboxFull = &smtp.SMTPError{
+	Code:         552,
+	EnhancedCode: smtp.EnhancedCode{5, 2, 2},
+	Message:      "Mailbox full",
+}
+
+func NewServer(core *core.Core) (*MSAServer, error) {
 	backend := &MSABackend{core: core}
 	s := smtp.NewServer(backend)
 	s.Addr = core.Config.Server.SMTP.Hostname + ":" + core.Config.Server.SMTP.MSA.Port
 	s.Domain = core.Config.Server.SMTP.Domain
 	s.Debug = os.Stdout
 
-	s.AllowInsecureAuth = core.Config.Server.SMTP.AllowInsecureAuth
+	msa := core.Config.Server.SMTP.MSA
+	s.AllowInsecureAuth = core.Config.Server.SMTP.AllowInsecureAuth && !msa.RequireTLSAuth
 
-	if core.Config.Server.SMTP.MSA.EnableTLS {
-		config, err := util.GetTLSConfig(core, core.Config.Server.TLS.Cert, core.Config.Server.TLS.Key)
+	// The certificate serves both STARTTLS and the implicit TLS port
+	if msa.EnableTLS || msa.TLSPort != "" {
+		config, err := core.TLSConfig()
 		if err != nil {
-			core.Logger.Error("MSA: Failed to load TLS configuration: %v . Aborting!", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("MSA: Failed to load TLS configuration: %w", err)
 		}
 		s.TLSConfig = config
 	}
 
-	return &MSAServer{
+	server := &MSAServer{
 		core: core,
 		smtp: s,
 	}
+	server.tlsAddr = config.TLSListenAddr(core.Config.Server.SMTP.Hostname, msa.TLSPort)
+	return server, nil
 }
 
+// ListenAndServe serves the submission port and, when configured, the
+// implicit TLS port until the server is shut down or one of them fails
 func (s *MSAServer) ListenAndServe() error {
+	errs := make(chan error, 2)
+
+	if s.tlsAddr != "" {
+		listener, err := tls.Listen("tcp", s.tlsAddr, s.smtp.TLSConfig)
+		if err != nil {
+			s.core.Logger.Error("MSA: Error starting SMTPS server: %v", err)
+			return err
+		}
+		s.core.Logger.Info("MSA: Starting SMTPS server on %s", s.tlsAddr)
+		go func() { errs <- s.smtp.Serve(listener) }()
+	}
+
 	s.core.Logger.Info("MSA: Starting SMTP server on %s", s.smtp.Addr)
-	if err := s.smtp.ListenAndServe(); err != nil {
+	go func() { errs <- s.smtp.ListenAndServe() }()
+
+	if err := <-errs; err != nil {
 		s.core.Logger.Error("MSA: Error starting SMTP server: %v", err)
 		return err
 	}
@@ -99,8 +187,10 @@ func (s *MSAServer) Shutdown(ctx context.Context) error {
 
 func (s *MSASession) Reset() {
 	s.from = ""
-	s.to = ""
+	s.to = nil
+	s.relay = nil
 	s.authUsername = ""
+	s.authUserID = ""
 }
 
 func (s *MSASession) Logout() error {
@@ -110,17 +200,25 @@ func (s *MSASession) Logout() error {
 
 func (s *MSASession) AuthPlain(identity, username, password string) error {
 	s.core.Logger.Info("MSA: Authentication attempt for username '%s'", username)
+	return s.authenticate(s.core.UserService.LoginWithToken, username, password)
+}
+
+// AuthBearer authenticates with an OIDC access token, the username is optional
+func (s *MSASession) AuthBearer(username, token string) error {
+	s.core.Logger.Info("MSA: Bearer token authentication attempt for username '%s'", username)
+	return s.authenticate(s.core.UserService.LoginWithBearer, username, token)
+}
+
+// authenticate logs in with one of the UserService login methods and maps its
+// errors to SMTP replies
+func (s *MSASession) authenticate(login func(ctx context.Context, username, secret string) (*models.User, error), username, secret string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	user, err := s.core.UserService.LoginWithToken(ctx, username, password)
+	user, err := login(ctx, username, secret)
 	defer cancel()
 	if err != nil {
 		if errors.Is(err, core.ErrAuthFailed) {
 			s.core.Logger.Info("MSA: Authentication failed for username '%s': %v", username, err)
-			return &smtp.SMTPError{
-				Code:         535,
-				EnhancedCode: smtp.EnhancedCode{5, 7, 8},
-				Message:      "Authentication credentials invalid",
-			}
+			return errAuthInvalid
 		}
 		if errors.Is(err, core.ErrAccountInactive) {
 			s.core.Logger.Info("MSA: Authentication failed because of user account disabled '%s': %v", username, err)
@@ -137,8 +235,9 @@ func (s *MSASession) AuthPlain(identity, username, password string) error {
 			Message:      "Temporary authentication failure",
 		}
 	}
-	s.core.Logger.Info("MSA: Authentication successful for username '%s'", username)
+	s.core.Logger.Info("MSA: Authentication successful for username '%s'", user.Username)
 	s.authUsername = user.Username
+	s.authUserID = user.ID
 	return nil
 }
 
@@ -169,20 +268,29 @@ func (s *MSASession) Rcpt(to string, opts *smtp.RcptOptions) error {
 		return err
 	}
 
-	// Validate the domain of the recipient email address
-	expectedDomain := "@" + s.core.Config.Server.EmailDomain
-	if !strings.HasSuffix(to, expectedDomain) {
-		return &smtp.SMTPError{
-			Code:         550,
-			EnhancedCode: smtp.EnhancedCode{5, 7, 1},
-			Message:      "Relay not permitted for domain, message refused",
-		}
-	}
-
 	// TODO: Understand how much time we can wait here and make it configurable
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// Recipients outside of our domain are relayed through the smarthost
+	expectedDomain := "@" + strings.ToLower(s.core.Config.Server.EmailDomain)
+	if !strings.HasSuffix(strings.ToLower(to), expectedDomain) {
+		if !s.core.Config.Server.SMTP.Outbound.Enabled {
+			return &smtp.SMTPError{
+				Code:         550,
+				EnhancedCode: smtp.EnhancedCode{5, 7, 1},
+				Message:      "Relay not permitted for domain, message refused",
+			}
+		}
+		if err := s.checkSender(ctx, s.from); err != nil {
+			return err
+		}
+
+		s.core.Logger.Info("MSA: Recipient %s accepted for relay from user %s", to, s.authUsername)
+		s.relay = append(s.relay, to)
+		return nil
+	}
+
 	inbox, err := s.core.InboxService.GetByEmailWithWildcard(ctx, to)
 	if err != nil {
 		if errors.Is(err, core.ErrNotFound) {
@@ -203,12 +311,12 @@ func (s *MSASession) Rcpt(to string, opts *smtp.RcptOptions) error {
 	}
 
 	s.core.Logger.Info("MSA: Recipient %s accepted for user %s (inbox ID: %s)", to, s.authUsername, inbox.ID)
-	s.to = to
+	s.to = append(s.to, to)
 	return nil
 }
 
 func (s *MSASession) Data(r io.Reader) error {
-	s.core.Logger.Info("MSA: Processing email data from %s to %s", s.from, s.to)
+	s.core.Logger.Info("MSA: Processing email data from %s to %v", s.from, append(slices.Clone(s.to), s.relay...))
 	if err := s.RequireAuthentication(); err != nil {
 		return err
 	}
@@ -249,35 +357,110 @@ func (s *MSASession) Data(r io.Reader) error {
 		}
 	}
 
-	inbox, err := s.core.InboxService.GetByEmailWithWildcard(ctx, s.to)
+	// The headers are checked before anything is stored, so that a rejected
+	// message is not delivered to local recipients either
+	if len(s.relay) > 0 {
+		if err := s.checkHeaderSenders(ctx, header); err != nil {
+			return err
+		}
+	}
+
+	// Every local recipient is looked up and its quota checked before anything
+	// is stored, so that a failure the client retries doesn't store copies twice
+	inboxes := make([]*models.Inbox, 0, len(s.to))
+	for _, to := range s.to {
+		inbox, err := s.localInbox(ctx, to, int64(buffer.Len()))
+		if err != nil {
+			return err
+		}
+		inboxes = append(inboxes, inbox)
+	}
+
+	// Once a copy is stored, failing the transaction would have the client
+	// store it again, so later failures are only logged
+	stored := 0
+	for i, to := range s.to {
+		if err := s.deliverLocal(ctx, inboxes[i], to, header, body.String(), buffer.Bytes()); err != nil {
+			if stored == 0 {
+				return err
+			}
+			s.core.Logger.Error("MSA: Message for %s lost after it was stored for other recipients: %v", to, err)
+			continue
+		}
+		stored++
+	}
+
+	if len(s.relay) > 0 {
+		if _, err := s.core.OutboundService.Enqueue(ctx, s.authUserID, s.from, s.relay, buffer.Bytes()); err != nil {
+			if stored == 0 {
+				return &smtp.SMTPError{
+					Code:         451,
+					EnhancedCode: smtp.EnhancedCode{4, 3, 0},
+					Message:      "Message could not be queued for delivery",
+				}
+			}
+			s.core.Logger.Error("MSA: Message from %s not queued after it was stored for local recipients: %v", s.from, err)
+		} else {
+			s.core.Logger.Info("MSA: Message from %s queued for %d external recipients", s.from, len(s.relay))
+		}
+	}
+
+	s.saveSent(ctx, header, body.String(), buffer.Bytes())
+	return nil
+}
+
+// localInbox returns the inbox of a local recipient, provided a message of size
+// bytes fits in its quotas
+func (s *MSASession) localInbox(ctx context.Context, to string, size int64) (*models.Inbox, error) {
+	inbox, err := s.core.InboxService.GetByEmailWithWildcard(ctx, to)
 	if err != nil {
 		if errors.Is(err, core.ErrNotFound) {
-			s.core.Logger.Info("MTA: Recipient %s not found in inboxes", s.to)
-			return &smtp.SMTPError{
+			s.core.Logger.Info("MTA: Recipient %s not found in inboxes", to)
+			return nil, &smtp.SMTPError{
 				Code:         550,
 				EnhancedCode: smtp.EnhancedCode{5, 1, 1},
 				Message:      "Recipient address rejected: User unknown",
 			}
 		}
 
-		s.core.Logger.Error("MTA: Error fetching inbox for %s: %v", s.to, err)
-		return &smtp.SMTPError{
+		s.core.Logger.Error("MTA: Error fetching inbox for %s: %v", to, err)
+		return nil, &smtp.SMTPError{
+			Code:         451,
+			EnhancedCode: smtp.EnhancedCode{4, 3, 0},
+			Message:      "Temporary error while processing recipient",
+		}
+	}
+
+	if err := s.core.QuotaService.Check(ctx, inbox.ID, size); err != nil {
+		if errors.Is(err, core.ErrQuotaExceeded) {
+			return nil, errMailboxFull
+		}
+		s.core.Logger.Error("MSA: Error checking quota of %s: %v", to, err)
+		return nil, &smtp.SMTPError{
 			Code:         451,
 			EnhancedCode: smtp.EnhancedCode{4, 3, 0},
 			Message:      "Temporary error while processing recipient",
 		}
 	}
+	return inbox, nil
+}
 
+// deliverLocal stores a submitted message in the inbox of a local recipient
+func (s *MSASession) deliverLocal(ctx context.Context, inbox *models.Inbox, to string, header message.Header, body string, raw []byte) error {
 	m := &models.Message{
 		InboxID:  inbox.ID,
 		Sender:   s.from,
-		Receiver: s.to,
+		Receiver: to,
 		Subject:  header.Get("Subject"),
-		Body:     body.String(),
+		Body:     body,
+		Raw:      raw,
 		IsRead:   false,
 	}
 
 	if err := s.core.MessageService.Store(ctx, m); err != nil {
+		if errors.Is(err, core.ErrQuotaExceeded) {
+			return errMailboxFull
+		}
 		s.core.Logger.Error("MTA: Error storing message: %v", err)
 		return &smtp.SMTPError{
 			Code:         554,
@@ -286,6 +469,83 @@ func (s *MSASession) Data(r io.Reader) error {
 		}
 	}
 
-	s.core.Logger.Info("MTA: Message stored successfully for %s", s.to)
+	s.core.Logger.Info("MTA: Message stored successfully for %s", to)
+	return nil
+}
+
+// checkSender rejects a sender address the authenticated user may not send
+// from, so that tokens cannot relay mail on behalf of arbitrary addresses
+func (s *MSASession) checkSender(ctx context.Context, address string) error {
+	allowed, err := s.core.SenderService.IsAllowed(ctx, s.authUserID, address)
+	if err != nil {
+		return &smtp.SMTPError{
+			Code:         451,
+			EnhancedCode: smtp.EnhancedCode{4, 3, 0},
+			Message:      "Temporary error while checking sender",
+		}
+	}
+	if !allowed {
+		s.core.Logger.Info("MSA: User %s may not send from %q", s.authUsername, address)
+		return &smtp.SMTPError{
+			Code:         553,
+			EnhancedCode: smtp.EnhancedCode{5, 7, 1},
+			Message:      "Sender address not allowed for this user",
+		}
+	}
+	return nil
+}
+
+// checkHeaderSenders checks the From and Sender header addresses of a message
+// that is relayed, as those are what the recipients see
+func (s *MSASession) checkHeaderSenders(ctx context.Context, header message.Header) error {
+	h := mail.Header{Header: header}
+	from, err := h.AddressList("From")
+	if err != nil || len(from) == 0 {
+		return &smtp.SMTPError{
+			Code:         550,
+			EnhancedCode: smtp.EnhancedCode{5, 6, 0},
+			Message:      "Message must have a valid From header",
+		}
+	}
+	sender, err := h.AddressList("Sender")
+	if err != nil {
+		return &smtp.SMTPError{
+			Code:         550,
+			EnhancedCode: smtp.EnhancedCode{5, 6, 0},
+			Message:      "Message has an invalid Sender header",
+		}
+	}
+
+	for _, address := range append(from, sender...) {
+		if err := s.checkSender(ctx, address.Address); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// saveSent stores a copy of a submitted message in the Sent folder of the sender's
+// inbox, when the sender address belongs to an inbox of the authenticated user.
+// The submission has already been accepted, so failures are only logged.
+func (s *MSASession) saveSent(ctx context.Context, header message.Header, body string, raw []byte) {
+	inbox, err := s.core.InboxService.GetByEmailAndUser(ctx, s.from, s.authUserID)
+	if err != nil {
+		if !errors.Is(err, core.ErrNotFound) && !errors.Is(err, storage.ErrNotFound) {
+			s.core.Logger.Error("MSA: Error fetching sender inbox for %s: %v", s.from, err)
+		}
+		return
+	}
+
+	m := &models.Message{
+		InboxID:  inbox.ID,
+		Sender:   s.from,
+		Receiver: strings.Join(append(slices.Clone(s.to), s.relay...), ", "),
+		Subject:  header.Get("Subject"),
+		Body:     body,
+		Raw:      raw,
+		IsRead:   true,
+	}
+	if err := s.core.MessageService.StoreInSpecialUse(ctx, m, core.SpecialUseSent); err != nil {
+		s.core.Logger.Error("MSA: Error saving sent copy for %s: %v", s.from, err)
+	}
+}
@@ -0,0 +1,227 @@
+package mta
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"inbox451/internal/config"
+
+	"golang.org/x/time/rate"
+)
+
+// ipList matches client addresses against IPs and CIDRs
+type ipList []*net.IPNet
+
+func parseIPList(entries []string) (ipList, error) {
+	list := make(ipList, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q", entry)
+			}
+			list = append(list, network)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP %q", entry)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		list = append(list, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return list, nil
+}
+
+func (l ipList) contains(ip net.IP) bool {
+	for _, network := range l {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientKey is what a client is rate limited by: its address, or the /64 of
+// an IPv6 address, as a client usually gets a whole one
+func clientKey(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.String()
+	}
+	return ip.Mask(net.CIDRMask(64, 128)).String()
+}
+
+// ipLimiter allows each client a number of events per interval, in bursts of
+// up to that number. A nil ipLimiter allows everything.
+type ipLimiter struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	interval  time.Duration
+	limiters  map[string]*rate.Limiter
+	lastPrune time.Time
+}
+
+func newIPLimiter(events int, interval time.Duration) *ipLimiter {
+	if events <= 0 || interval <= 0 {
+		return nil
+	}
+	return &ipLimiter{
+		limit:     rate.Every(interval / time.Duration(events)),
+		burst:     events,
+		interval:  interval,
+		limiters:  make(map[string]*rate.Limiter),
+		lastPrune: time.Now(),
+	}
+}
+
+// allow records an event of the client and reports whether it is within the
+// limit
+func (l *ipLimiter) allow(ip net.IP) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	// A client idle for a whole interval has its full burst back, so it is
+	// as good as new
+	if now.Sub(l.lastPrune) > l.interval {
+		for key, limiter := range l.limiters {
+			if limiter.TokensAt(now) >= float64(l.burst) {
+				delete(l.limiters, key)
+			}
+		}
+		l.lastPrune = now
+	}
+
+	key := clientKey(ip)
+	limiter, ok := l.limiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[key] = limiter
+	}
+	return limiter.AllowN(now, 1)
+}
+
+// guard decides which clients may connect and send, before the SMTP
+// conversation gets to their mail
+type guard struct {
+	allow       ipList
+	deny        ipList
+	connections *ipLimiter
+	messages    *ipLimiter
+	maxSessions int64
+	sessions    atomic.Int64
+}
+
+func newGuard(cfg config.MTAConfig) (*guard, error) {
+	allow, err := parseIPList(cfg.Allow)
+	if err != nil {
+		return nil, fmt.Errorf("mta.allow: %w", err)
+	}
+	deny, err := parseIPList(cfg.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("mta.deny: %w", err)
+	}
+	return &guard{
+		allow:       allow,
+		deny:        deny,
+		connections: newIPLimiter(cfg.RateLimit.Connections, cfg.RateLimit.Interval),
+		messages:    newIPLimiter(cfg.RateLimit.Messages, cfg.RateLimit.Interval),
+		maxSessions: int64(cfg.MaxSessions),
+	}, nil
+}
+
+// allowed reports whether the client is exempt from rate limits and
+// greylisting
+func (g *guard) allowed(ip net.IP) bool {
+	return ip != nil && g.allow.contains(ip)
+}
+
+// admit decides on a new connection and takes a session slot for it. It
+// returns the reply refusing the connection, or an empty string when the
+// client may go on.
+func (g *guard) admit(ip net.IP) string {
+	if ip != nil && g.deny.contains(ip) && !g.allowed(ip) {
+		return "554 5.7.1 Access denied"
+	}
+	// A client turned away by the session cap keeps its connection budget
+	if sessions := g.sessions.Add(1); g.maxSessions > 0 && sessions > g.maxSessions {
+		g.release()
+		return "421 4.7.0 Too many connections, try again later"
+	}
+	if ip != nil && !g.allowed(ip) && !g.connections.allow(ip) {
+		g.release()
+		return "421 4.7.0 Too many connections from your address, try again later"
+	}
+	return ""
+}
+
+// release frees the session slot of a connection
+func (g *guard) release() {
+	g.sessions.Add(-1)
+}
+
+// remoteIP is the IP of the client at the other end of conn, or nil
+func remoteIP(conn net.Conn) net.IP {
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP
+	}
+	return nil
+}
+
+// guardedListener refuses the connections the guard doesn't admit before the
+// SMTP server sees them
+type guardedListener struct {
+	net.Listener
+	guard  *guard
+	domain string
+	logf   func(format string, args ...interface{})
+}
+
+func (l *guardedListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		ip := remoteIP(conn)
+		if reply := l.guard.admit(ip); reply != "" {
+			l.logf("MTA: Refusing connection from %s: %s", conn.RemoteAddr(), reply)
+			// The greeting is written aside, so a slow client can't hold up
+			// the connections behind it
+			go func() {
+				_ = conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+				code, text, _ := strings.Cut(reply, " ")
+				fmt.Fprintf(conn, "%s %s %s\r\n", code, l.domain, text)
+				conn.Close()
+			}()
+			continue
+		}
+		return &guardedConn{Conn: conn, guard: l.guard}, nil
+	}
+}
+
+// guardedConn gives its session slot back when closed
+type guardedConn struct {
+	net.Conn
+	guard *guard
+	once  sync.Once
+}
+
+func (c *guardedConn) Close() error {
+	c.once.Do(c.guard.release)
+	return c.Conn.Close()
+}
@@ -0,0 +1,105 @@
+package mta
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"inbox451/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIPList(t *testing.T) {
+	list, err := parseIPList([]string{"192.0.2.0/24", "198.51.100.7", "2001:db8::/32", " 2001:db8:ffff::1 "})
+	require.NoError(t, err)
+
+	assert.True(t, list.contains(net.ParseIP("192.0.2.200")))
+	assert.True(t, list.contains(net.ParseIP("198.51.100.7")))
+	assert.False(t, list.contains(net.ParseIP("198.51.100.8")))
+	assert.True(t, list.contains(net.ParseIP("2001:db8:1234::1")))
+	assert.False(t, list.contains(net.ParseIP("2001:db9::1")))
+
+	_, err = parseIPList([]string{"192.0.2.0/33"})
+	assert.Error(t, err)
+	_, err = parseIPList([]string{"mail.example.com"})
+	assert.Error(t, err)
+}
+
+func TestIPLimiter(t *testing.T) {
+	assert.Nil(t, newIPLimiter(0, time.Minute))
+	var unlimited *ipLimiter
+	assert.True(t, unlimited.allow(net.ParseIP("192.0.2.1")))
+
+	limiter := newIPLimiter(2, time.Hour)
+	client := net.ParseIP("192.0.2.1")
+	assert.True(t, limiter.allow(client))
+	assert.True(t, limiter.allow(client))
+	assert.False(t, limiter.allow(client))
+
+	// Other clients have their own budget, but an IPv6 /64 is one client
+	assert.True(t, limiter.allow(net.ParseIP("192.0.2.2")))
+	assert.True(t, limiter.allow(net.ParseIP("2001:db8::1")))
+	assert.True(t, limiter.allow(net.ParseIP("2001:db8::2")))
+	assert.False(t, limiter.allow(net.ParseIP("2001:db8::3")))
+}
+
+func TestGuard_Admit(t *testing.T) {
+	g, err := newGuard(config.MTAConfig{
+		MaxSessions: 2,
+		RateLimit:   config.MTARateLimit{Connections: 1, Interval: time.Hour},
+		Allow:       []string{"192.0.2.10"},
+		Deny:        []string{"192.0.2.0/24"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "554 5.7.1 Access denied", g.admit(net.ParseIP("192.0.2.1")))
+
+	// Allowed clients aren't denied nor rate limited
+	assert.Empty(t, g.admit(net.ParseIP("192.0.2.10")))
+	g.release()
+	assert.Empty(t, g.admit(net.ParseIP("192.0.2.10")))
+	g.release()
+
+	assert.Empty(t, g.admit(net.ParseIP("198.51.100.1")))
+	assert.Contains(t, g.admit(net.ParseIP("198.51.100.1")), "421 4.7.0")
+
+	// The session cap applies to everyone
+	assert.Empty(t, g.admit(net.ParseIP("192.0.2.10")))
+	assert.Equal(t, "421 4.7.0 Too many connections, try again later", g.admit(net.ParseIP("198.51.100.2")))
+	g.release()
+	assert.Empty(t, g.admit(net.ParseIP("198.51.100.2")))
+
+	_, err = newGuard(config.MTAConfig{Deny: []string{"not an address"}})
+	assert.Error(t, err)
+}
+
+func TestGuardedListener(t *testing.T) {
+	g, err := newGuard(config.MTAConfig{Deny: []string{"127.0.0.1"}})
+	require.NoError(t, err)
+
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	listener := &guardedListener{
+		Listener: inner,
+		guard:    g,
+		domain:   "mx.example.com",
+		logf:     func(string, ...interface{}) {},
+	}
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+
+	conn, err := net.Dial("tcp", inner.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	buf := make([]byte, 128)
+	n, _ := conn.Read(buf)
+	assert.Equal(t, "554 mx.example.com 5.7.1 Access denied\r\n", string(buf[:n]))
+}
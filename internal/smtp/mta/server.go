@@ -12,62 +12,152 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"strings"
 	"time"
 
 	"inbox451/internal/core"
+	"inbox451/internal/dnsbl"
 	"inbox451/internal/models"
+	"inbox451/internal/srs"
 
 	"github.com/emersion/go-message"
 	"github.com/emersion/go-smtp"
 )
 
+// Defaults of the limits left unset in the configuration, where go-smtp would
+// take 0 as no limit at all
+const (
+	defaultReadTimeout     = 5 * time.Second
+	defaultWriteTimeout    = 10 * time.Second
+	defaultMaxMessageBytes = 10 * 1024 * 1024
+	defaultMaxRecipients   = 100
+)
+
 type MTAServer struct {
-	core *core.Core
-	smtp *smtp.Server
+	core  *core.Core
+	smtp  *smtp.Server
+	guard *guard
+	done  chan struct{}
 }
 
 type MTABackend struct {
-	core *core.Core
+	core  *core.Core
+	guard *guard
 }
 
 type MTASession struct {
-	core *core.Core
-	from string
-	to   string
+	core  *core.Core
+	guard *guard
+	// ip is the address of the client, allowed whether it is on the allow list
+	ip      net.IP
+	allowed bool
+	// listings are the DNS blocklists the client is on, in tag mode
+	listings []dnsbl.Listing
+	from     string
+	to       string
+	// bounces holds the original senders of SRS-rewritten recipients
+	bounces []string
+	// tlsReport is set when the TLS report address is a recipient
+	tlsReport bool
 }
 
-func NewServer(core *core.Core) *MTAServer {
-	backend := &MTABackend{core: core}
+func NewServer(core *core.Core) (*MTAServer, error) {
+	mta := core.Config.Server.SMTP.MTA
+	guard, err := newGuard(mta)
+	if err != nil {
+		return nil, fmt.Errorf("MTA: Invalid configuration: %w", err)
+	}
+
+	backend := &MTABackend{core: core, guard: guard}
 	smtpServer := smtp.NewServer(backend)
 
-	// TODO: Review configuration options and place them in core.Config
-	smtpServer.Addr = core.Config.Server.SMTP.Hostname + ":" + core.Config.Server.SMTP.MTA.Port
+	smtpServer.Addr = core.Config.Server.SMTP.Hostname + ":" + mta.Port
 	smtpServer.Domain = core.Config.Server.SMTP.Domain
-	smtpServer.ReadTimeout = 5 * time.Second
-	smtpServer.WriteTimeout = 10 * time.Second
-	smtpServer.MaxMessageBytes = 10 * 1024 * 1024
-	smtpServer.MaxRecipients = 100
+	smtpServer.ReadTimeout = mta.ReadTimeout
+	if smtpServer.ReadTimeout <= 0 {
+		smtpServer.ReadTimeout = defaultReadTimeout
+	}
+	smtpServer.WriteTimeout = mta.WriteTimeout
+	if smtpServer.WriteTimeout <= 0 {
+		smtpServer.WriteTimeout = defaultWriteTimeout
+	}
+	smtpServer.MaxMessageBytes = mta.MaxMessageBytes
+	if smtpServer.MaxMessageBytes <= 0 {
+		smtpServer.MaxMessageBytes = defaultMaxMessageBytes
+	}
+	smtpServer.MaxRecipients = mta.MaxRecipients
+	if smtpServer.MaxRecipients <= 0 {
+		smtpServer.MaxRecipients = defaultMaxRecipients
+	}
 	smtpServer.AllowInsecureAuth = core.Config.Server.SMTP.AllowInsecureAuth
 
-	return &MTAServer{
-		core: core,
-		smtp: smtpServer,
+	// Offer STARTTLS to sending MTAs
+	if mta.EnableTLS {
+		config, err := core.TLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("MTA: Failed to load TLS configuration: %w", err)
+		}
+		smtpServer.TLSConfig = config
 	}
+
+	return &MTAServer{
+		core:  core,
+		smtp:  smtpServer,
+		guard: guard,
+		done:  make(chan struct{}),
+	}, nil
 }
 
+// ListenAndServe serves the MTA port. Connections the allow and deny lists,
+// the rate limits or the session cap refuse are turned away before the SMTP
+// server sees them.
 func (s *MTAServer) ListenAndServe() error {
 	s.core.Logger.Info("MTA: Starting SMTP server on %s", s.smtp.Addr)
-	if err := s.smtp.ListenAndServe(); err != nil {
+	listener, err := net.Listen("tcp", s.smtp.Addr)
+	if err != nil {
+		s.core.Logger.Error("MTA: Error starting SMTP server: %v", err)
+		return err
+	}
+
+	if s.core.GreylistService.Enabled() {
+		go s.pruneGreylist()
+	}
+
+	if err := s.smtp.Serve(&guardedListener{
+		Listener: listener,
+		guard:    s.guard,
+		domain:   s.smtp.Domain,
+		logf:     s.core.Logger.Info,
+	}); err != nil {
 		s.core.Logger.Error("MTA: Error starting SMTP server: %v", err)
 		return err
 	}
 	return nil
 }
 
+// pruneGreylist forgets expired greylist triplets every hour until the server
+// is shut down
+func (s *MTAServer) pruneGreylist() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			_ = s.core.GreylistService.Prune(ctx)
+			cancel()
+		}
+	}
+}
+
 func (s *MTAServer) Shutdown(ctx context.Context) error {
 	s.core.Logger.Info("MTA: Shutting down SMTP server")
+	close(s.done)
 	if err := s.smtp.Shutdown(ctx); err != nil {
 		s.core.Logger.Error("MTA: Error shutting down SMTP server: %v", err)
 		return err
@@ -78,20 +168,128 @@ func (s *MTAServer) Shutdown(ctx context.Context) error {
 
 func (backend *MTABackend) NewSession(c *smtp.Conn) (smtp.Session, error) {
 	backend.core.Logger.Info("MTA: New connection from %s", c.Conn().RemoteAddr())
-	session := &MTASession{core: backend.core}
+	ip := remoteIP(c.Conn())
+	session := &MTASession{
+		core:    backend.core,
+		guard:   backend.guard,
+		ip:      ip,
+		allowed: backend.guard.allowed(ip),
+	}
+
+	if backend.core.DNSBL != nil && !session.allowed {
+		listings := backend.checkDNSBL(ip)
+		if len(listings) > 0 && backend.core.Config.Server.SMTP.MTA.DNSBL.Mode == dnsbl.ModeReject {
+			message := fmt.Sprintf("Client host [%s] blocked using %s", ip, listings[0].Zone)
+			if listings[0].Reason != "" {
+				message += "; " + listings[0].Reason
+			}
+			return nil, &smtp.SMTPError{
+				Code:         554,
+				EnhancedCode: smtp.EnhancedCode{5, 7, 1},
+				Message:      message,
+			}
+		}
+		session.listings = listings
+	}
+
 	session.Reset()
 	return session, nil
 }
 
+// checkDNSBL returns the blocklists ip is on. Clients are let in when the
+// lookups fail or time out.
+func (backend *MTABackend) checkDNSBL(ip net.IP) []dnsbl.Listing {
+	listings, err := backend.core.DNSBL.Check(context.Background(), ip)
+	if err != nil {
+		backend.core.Logger.Warn("MTA: DNSBL lookup of %s failed: %v", ip, err)
+	}
+	for _, listing := range listings {
+		backend.core.Logger.Info("MTA: Client %s is listed on %s", ip, listing)
+	}
+	return listings
+}
+
 func (s *MTASession) Mail(from string, opts *smtp.MailOptions) error {
 	s.core.Logger.Info("MTA: Mail from %s", from)
+
+	if !s.allowed && s.ip != nil && !s.guard.messages.allow(s.ip) {
+		s.core.Logger.Info("MTA: Rate limiting messages from %s", s.ip)
+		return &smtp.SMTPError{
+			Code:         451,
+			EnhancedCode: smtp.EnhancedCode{4, 7, 1},
+			Message:      "Too many messages from your address, try again later",
+		}
+	}
+
 	s.from = from
 	return nil
 }
 
+// greylist defers the first attempts of a client network to deliver mail from
+// the sender to a recipient. The delivery goes through when the greylist can't
+// be checked.
+func (s *MTASession) greylist(to string) error {
+	if s.allowed || s.ip == nil || !s.core.GreylistService.Enabled() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	passed, err := s.core.GreylistService.Check(ctx, s.ip, s.from, to)
+	if err != nil || passed {
+		return nil
+	}
+	s.core.Logger.Info("MTA: Greylisting mail from %s at %s to %s", s.from, s.ip, to)
+	return &smtp.SMTPError{
+		Code:         451,
+		EnhancedCode: smtp.EnhancedCode{4, 7, 1},
+		Message:      "Greylisted, try again later",
+	}
+}
+
 func (s *MTASession) Rcpt(to string, opts *smtp.RcptOptions) error {
 	s.core.Logger.Info("MTA: Recipient to %s", to)
 
+	// Bounces of forwarded mail come back to SRS addresses and are relayed to
+	// the original sender
+	if s.core.SRS != nil && srs.IsSRS(to) {
+		original, err := s.core.SRS.Reverse(to)
+		switch {
+		case errors.Is(err, srs.ErrNotSRS):
+		case err != nil:
+			s.core.Logger.Info("MTA: Rejecting SRS recipient %s: %v", to, err)
+			return &smtp.SMTPError{
+				Code:         550,
+				EnhancedCode: smtp.EnhancedCode{5, 1, 1},
+				Message:      "Invalid SRS address",
+			}
+		case !s.core.Config.Server.SMTP.Outbound.Enabled:
+			return &smtp.SMTPError{
+				Code:         550,
+				EnhancedCode: smtp.EnhancedCode{5, 7, 1},
+				Message:      "Relay not permitted for domain, message refused",
+			}
+		default:
+			if err := s.greylist(to); err != nil {
+				return err
+			}
+			s.core.Logger.Info("MTA: SRS recipient %s accepted for %s", to, original)
+			s.bounces = append(s.bounces, original)
+			return nil
+		}
+	}
+
+	// TLS reports are parsed rather than delivered to an inbox
+	if s.core.TLSReportService.IsReportAddress(to) {
+		if err := s.greylist(to); err != nil {
+			return err
+		}
+		s.core.Logger.Info("MTA: TLS report recipient %s accepted", to)
+		s.tlsReport = true
+		return nil
+	}
+
 	// Validate the domain of the recipient email address
 	expectedDomain := "@" + s.core.Config.Server.EmailDomain
 	if !strings.HasSuffix(to, expectedDomain) {
@@ -125,6 +323,25 @@ func (s *MTASession) Rcpt(to string, opts *smtp.RcptOptions) error {
 		}
 	}
 
+	// A full inbox is turned away before the message is sent; the sender may
+	// retry once room is made. Failing to look the quota up doesn't refuse the
+	// recipient, as storing the message checks it again.
+	switch err := s.core.QuotaService.Check(ctx, inbox.ID, 0); {
+	case errors.Is(err, core.ErrQuotaExceeded):
+		s.core.Logger.Info("MTA: Recipient %s is over quota", to)
+		return &smtp.SMTPError{
+			Code:         452,
+			EnhancedCode: smtp.EnhancedCode{4, 2, 2},
+			Message:      "Mailbox full, try again later",
+		}
+	case err != nil:
+		s.core.Logger.Error("MTA: Error checking quota of %s: %v", to, err)
+	}
+
+	if err := s.greylist(to); err != nil {
+		return err
+	}
+
 	s.core.Logger.Info("MSA: Recipient %s accepted for (inbox ID: %s)", to, inbox.ID)
 
 	s.to = to
@@ -134,6 +351,8 @@ func (s *MTASession) Rcpt(to string, opts *smtp.RcptOptions) error {
 func (s *MTASession) Reset() {
 	s.from = ""
 	s.to = ""
+	s.bounces = nil
+	s.tlsReport = false
 }
 
 func (s *MTASession) Data(r io.Reader) error {
@@ -153,8 +372,50 @@ func (s *MTASession) Data(r io.Reader) error {
 		}
 	}
 
+	if s.to != "" {
+		if err := s.deliver(ctx, buffer.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	// Bounces and TLS reports are handled once the message is stored, so that
+	// a delivery the sender retries doesn't record them twice. Once anything
+	// is recorded a failure is only logged, as failing the transaction would
+	// record it again.
+	recorded := s.to != ""
+	if len(s.bounces) > 0 {
+		if _, err := s.core.OutboundService.Enqueue(ctx, "", s.from, s.bounces, buffer.Bytes()); err != nil {
+			s.core.Logger.Error("MTA: Error relaying bounce from %s: %v", s.from, err)
+			if !recorded {
+				return &smtp.SMTPError{
+					Code:         451,
+					EnhancedCode: smtp.EnhancedCode{4, 3, 0},
+					Message:      "Temporary error while relaying message",
+				}
+			}
+		}
+		recorded = true
+	}
+
+	if s.tlsReport {
+		if _, err := s.core.TLSReportService.Ingest(ctx, buffer.Bytes()); err != nil {
+			s.core.Logger.Error("MTA: Error storing TLS report from %s: %v", s.from, err)
+			if !recorded {
+				return &smtp.SMTPError{
+					Code:         451,
+					EnhancedCode: smtp.EnhancedCode{4, 3, 0},
+					Message:      "Temporary error while storing report",
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// deliver stores a message received for the local recipient of the session
+func (s *MTASession) deliver(ctx context.Context, raw []byte) error {
 	// parse the message content, body, headers, etc.
-	msg, err := message.Read(bytes.NewReader(buffer.Bytes()))
+	msg, err := message.Read(bytes.NewReader(raw))
 	if err != nil {
 		s.core.Logger.Error("MTA: Error parsing message: %v", err)
 		return &smtp.SMTPError{
@@ -199,10 +460,29 @@ func (s *MTASession) Data(r io.Reader) error {
 		Receiver: s.to,
 		Subject:  header.Get("Subject"),
 		Body:     body.String(),
+		Raw:      raw,
 		IsRead:   false,
 	}
+	for _, listing := range s.listings {
+		m.DNSBLHits = append(m.DNSBLHits, listing.String())
+	}
 
-	if err := s.core.MessageService.Store(ctx, m); err != nil {
+	spam := core.IsSpam(header, s.core.Config.Server.SMTP.SpamThreshold)
+	if spam {
+		s.core.Logger.Info("MTA: Message from %s to %s marked as spam, storing in Junk", s.from, s.to)
+		err = s.core.MessageService.StoreInSpecialUse(ctx, m, core.SpecialUseJunk)
+	} else {
+		err = s.core.MessageService.Store(ctx, m)
+	}
+	if errors.Is(err, core.ErrQuotaExceeded) {
+		s.core.Logger.Info("MTA: Message from %s to %s exceeds the quota", s.from, s.to)
+		return &smtp.SMTPError{
+			Code:         552,
+			EnhancedCode: smtp.EnhancedCode{5, 2, 2},
+			Message:      "Mailbox full",
+		}
+	}
+	if err != nil {
 		s.core.Logger.Error("MTA: Error storing message: %v", err)
 		return &smtp.SMTPError{
 			Code:         554,
@@ -212,6 +492,19 @@ func (s *MTASession) Data(r io.Reader) error {
 	}
 
 	s.core.Logger.Info("MTA: Message stored successfully for %s", s.to)
+
+	// The message is kept either way, so a report that fails to be stored
+	// doesn't fail the delivery
+	if _, err := s.core.DMARCService.Ingest(ctx, inbox, raw); err != nil {
+		s.core.Logger.Error("MTA: Error ingesting DMARC reports of message %s: %v", m.ID, err)
+	}
+
+	// Junk is never forwarded
+	if !spam {
+		if err := s.core.RuleService.Forward(ctx, m); err != nil {
+			s.core.Logger.Error("MTA: Error forwarding message %s: %v", m.ID, err)
+		}
+	}
 	return nil
 }
 
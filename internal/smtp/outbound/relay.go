@@ -0,0 +1,194 @@
+package outbound
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"inbox451/internal/core"
+	"inbox451/internal/models"
+
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+)
+
+const (
+	defaultPollInterval = 30 * time.Second
+	// batchSize is how many due messages are claimed at once
+	batchSize = 10
+	// deliveryTimeout bounds a single delivery to the smarthost
+	deliveryTimeout = 5 * time.Minute
+	// leaseDuration is how long a claimed message is hidden from other relays. It
+	// outlasts the delivery of a whole batch.
+	leaseDuration = batchSize * deliveryTimeout
+)
+
+// Relay delivers queued outbound messages to the configured smarthost
+type Relay struct {
+	core *core.Core
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+
+	// deliver sends a message to the smarthost; replaced in tests
+	deliver func(ctx context.Context, message *models.OutboundMessage) error
+}
+
+func NewRelay(core *core.Core) *Relay {
+	r := &Relay{
+		core: core,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	r.deliver = r.send
+	return r
+}
+
+// ListenAndServe polls the queue and delivers due messages until Shutdown is called
+func (r *Relay) ListenAndServe() error {
+	defer close(r.done)
+
+	cfg := r.core.Config.Server.SMTP.Outbound
+	interval := cfg.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	r.core.Logger.Info("Outbound: Relaying through %s every %s", net.JoinHostPort(cfg.Host, cfg.Port), interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-r.stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		r.processQueue(ctx)
+		cancel()
+
+		select {
+		case <-r.stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown stops polling and waits for the current batch to finish
+func (r *Relay) Shutdown(ctx context.Context) error {
+	r.core.Logger.Info("Outbound: Shutting down relay")
+	r.stopOnce.Do(func() { close(r.stop) })
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// processQueue delivers due messages batch by batch until none is left
+func (r *Relay) processQueue(ctx context.Context) {
+	for ctx.Err() == nil {
+		messages, err := r.core.OutboundService.Claim(ctx, batchSize, leaseDuration)
+		if err != nil || len(messages) == 0 {
+			return
+		}
+
+		for _, message := range messages {
+			r.process(ctx, message)
+		}
+		if len(messages) < batchSize {
+			return
+		}
+	}
+}
+
+func (r *Relay) process(ctx context.Context, message *models.OutboundMessage) {
+	deliveryCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	err := r.deliver(deliveryCtx, message)
+	cancel()
+
+	// The outcome is recorded even when shutting down, so that the message is
+	// not sent twice
+	updateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err == nil {
+		r.core.Logger.Info("Outbound: Message %s from %s relayed to %d recipients", message.ID, message.MailFrom, len(message.Recipients))
+		_ = r.core.OutboundService.MarkSent(updateCtx, message)
+		return
+	}
+	_ = r.core.OutboundService.MarkFailed(updateCtx, message, err, isPermanent(err))
+}
+
+// isPermanent reports whether a delivery error is a permanent rejection by the
+// smarthost. Connection problems and 4xx replies are retried.
+func isPermanent(err error) bool {
+	var smtpErr *smtp.SMTPError
+	return errors.As(err, &smtpErr) && smtpErr.Code >= 500
+}
+
+// send signs a message and delivers it to the smarthost
+func (r *Relay) send(ctx context.Context, message *models.OutboundMessage) error {
+	raw, err := r.core.DKIMService.Sign(ctx, message.Raw)
+	if err != nil {
+		return fmt.Errorf("failed to DKIM sign message: %v", err)
+	}
+
+	cfg := r.core.Config.Server.SMTP.Outbound
+	addr := net.JoinHostPort(cfg.Host, cfg.Port)
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smarthost %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var client *smtp.Client
+	switch cfg.TLS {
+	case "tls":
+		client = smtp.NewClient(tls.Client(conn, tlsConfig))
+	case "none":
+		client = smtp.NewClient(conn)
+	default:
+		client, err = smtp.NewClientStartTLS(conn, tlsConfig)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to start TLS with smarthost %s: %w", addr, err)
+		}
+	}
+	defer client.Close()
+
+	if domain := r.core.Config.Server.SMTP.Domain; domain != "" {
+		if err := client.Hello(domain); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Username != "" {
+		// A rejected login is a problem of the configuration rather than of the
+		// message, so it is not wrapped and the message is retried
+		if err := client.Auth(sasl.NewPlainClient("", cfg.Username, cfg.Password)); err != nil {
+			return fmt.Errorf("failed to authenticate with smarthost %s: %v", addr, err)
+		}
+	}
+
+	if err := client.SendMail(message.MailFrom, message.Recipients, bytes.NewReader(raw)); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,176 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"inbox451/internal/config"
+	"inbox451/internal/core"
+	"inbox451/internal/logger"
+	"inbox451/internal/mocks"
+	"inbox451/internal/models"
+	"inbox451/internal/storage"
+	"inbox451/internal/test"
+
+	"github.com/emersion/go-smtp"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type received struct {
+	from string
+	to   []string
+	data string
+}
+
+// smarthost is an SMTP server standing in for the smarthost
+type smarthost struct {
+	rejectRcpt *smtp.SMTPError
+	messages   chan received
+}
+
+func (b *smarthost) NewSession(c *smtp.Conn) (smtp.Session, error) {
+	return &smarthostSession{backend: b}, nil
+}
+
+type smarthostSession struct {
+	backend *smarthost
+	msg     received
+}
+
+func (s *smarthostSession) Mail(from string, opts *smtp.MailOptions) error {
+	s.msg.from = from
+	return nil
+}
+
+func (s *smarthostSession) Rcpt(to string, opts *smtp.RcptOptions) error {
+	if s.backend.rejectRcpt != nil {
+		return s.backend.rejectRcpt
+	}
+	s.msg.to = append(s.msg.to, to)
+	return nil
+}
+
+func (s *smarthostSession) Data(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.msg.data = string(data)
+	s.backend.messages <- s.msg
+	return nil
+}
+
+func (s *smarthostSession) Reset()        { s.msg = received{} }
+func (s *smarthostSession) Logout() error { return nil }
+
+func startSmarthost(t *testing.T, backend *smarthost) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := smtp.NewServer(backend)
+	server.Domain = "smarthost.test"
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	return listener.Addr().String()
+}
+
+func setupRelay(t *testing.T, addr string) (*Relay, *mocks.Repository) {
+	mockRepo := mocks.NewRepository(t)
+
+	c := &core.Core{
+		Config:     &config.Config{},
+		Logger:     logger.New(io.Discard, logger.DEBUG),
+		Repository: mockRepo,
+	}
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	c.Config.Server.SMTP.Domain = "mail.example.com"
+	c.Config.Server.SMTP.Outbound = config.OutboundConfig{Enabled: true, Host: host, Port: port, TLS: "none", MaxAttempts: 3}
+	c.OutboundService = core.NewOutboundService(c)
+	c.DKIMService = core.NewDKIMService(c)
+
+	// No DKIM key, so messages leave unsigned
+	mockRepo.On("GetDKIMKeyByDomain", mock.Anything, "example.com").Return(nil, storage.ErrNotFound).Maybe()
+
+	return NewRelay(c), mockRepo
+}
+
+func queuedMessage() *models.OutboundMessage {
+	return &models.OutboundMessage{
+		Base:       models.Base{ID: test.RandomTestUUID()},
+		MailFrom:   "support@example.com",
+		Recipients: pq.StringArray{"alice@remote.test", "bob@remote.test"},
+		Raw:        []byte("From: support@example.com\r\nSubject: Hello\r\n\r\nHi there\r\n"),
+		Status:     core.OutboundQueued,
+		Attempts:   1,
+	}
+}
+
+func TestRelay_Delivers(t *testing.T) {
+	backend := &smarthost{messages: make(chan received, 1)}
+	relay, mockRepo := setupRelay(t, startSmarthost(t, backend))
+
+	message := queuedMessage()
+	mockRepo.On("ClaimOutboundMessages", mock.Anything, batchSize, leaseDuration).
+		Return([]*models.OutboundMessage{message}, nil).Once()
+	mockRepo.On("UpdateOutboundMessage", mock.Anything, message).Return(nil).Once()
+
+	relay.processQueue(context.Background())
+
+	got := <-backend.messages
+	assert.Equal(t, "support@example.com", got.from)
+	assert.Equal(t, []string{"alice@remote.test", "bob@remote.test"}, got.to)
+	assert.Contains(t, got.data, "Subject: Hello")
+	assert.Equal(t, core.OutboundSent, message.Status)
+}
+
+func TestRelay_Rejections(t *testing.T) {
+	tests := []struct {
+		name       string
+		reject     *smtp.SMTPError
+		wantStatus string
+	}{
+		{
+			name:       "temporary",
+			reject:     &smtp.SMTPError{Code: 451, EnhancedCode: smtp.EnhancedCode{4, 3, 0}, Message: "Try again later"},
+			wantStatus: core.OutboundQueued,
+		},
+		{
+			name:       "permanent",
+			reject:     &smtp.SMTPError{Code: 550, EnhancedCode: smtp.EnhancedCode{5, 1, 1}, Message: "No such user"},
+			wantStatus: core.OutboundFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &smarthost{rejectRcpt: tt.reject, messages: make(chan received, 1)}
+			relay, mockRepo := setupRelay(t, startSmarthost(t, backend))
+
+			message := queuedMessage()
+			mockRepo.On("ClaimOutboundMessages", mock.Anything, batchSize, leaseDuration).
+				Return([]*models.OutboundMessage{message}, nil).Once()
+			mockRepo.On("UpdateOutboundMessage", mock.Anything, message).Return(nil).Once()
+
+			relay.processQueue(context.Background())
+
+			assert.Equal(t, tt.wantStatus, message.Status)
+			assert.Contains(t, message.LastError, tt.reject.Message)
+		})
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	assert.True(t, isPermanent(&smtp.SMTPError{Code: 554}))
+	assert.True(t, isPermanent(fmt.Errorf("data: %w", &smtp.SMTPError{Code: 552})))
+	assert.False(t, isPermanent(&smtp.SMTPError{Code: 421}))
+	assert.False(t, isPermanent(errors.New("connection refused")))
+}
@@ -0,0 +1,228 @@
+// Package srs implements the Sender Rewriting Scheme, so that forwarded mail
+// passes SPF at its destination and bounces find their way back.
+//
+// A forwarded sender user@example.org becomes
+//
+//	SRS0=HHHH=TT=example.org=user@forwarder.example
+//
+// where TT is the day it was rewritten and HHHH a truncated HMAC of the rest.
+// Forwarding an SRS0 address again keeps the first forwarder in an SRS1 address,
+// so that a bounce only travels back one hop at a time.
+package srs
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	hashLength = 4
+	// timestampAlphabet encodes days in base 32, two characters wrapping
+	// around every 1024 days
+	timestampAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+	timestampPeriod   = 1024
+
+	defaultMaxAge = 21 * 24 * time.Hour
+)
+
+var (
+	ErrNotSRS    = errors.New("not an SRS address")
+	ErrInvalid   = errors.New("malformed SRS address")
+	ErrBadHash   = errors.New("SRS address hash does not match")
+	ErrExpired   = errors.New("SRS address has expired")
+	ErrNoSecrets = errors.New("SRS needs at least one secret")
+)
+
+// Rewriter rewrites sender addresses into SRS addresses of its domain and back
+type Rewriter struct {
+	domain  string
+	secrets [][]byte
+	maxAge  time.Duration
+	now     func() time.Time
+}
+
+// New returns a rewriter for addresses of domain. The first secret signs new
+// addresses; the others are still accepted, so that secrets can be rotated.
+func New(domain string, secrets []string, maxAge time.Duration) (*Rewriter, error) {
+	r := &Rewriter{domain: strings.ToLower(domain), maxAge: maxAge, now: time.Now}
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, []byte(secret))
+		}
+	}
+	if len(r.secrets) == 0 {
+		return nil, ErrNoSecrets
+	}
+	if r.maxAge <= 0 {
+		r.maxAge = defaultMaxAge
+	}
+	return r, nil
+}
+
+// Domain returns the domain of the addresses the rewriter produces
+func (r *Rewriter) Domain() string {
+	return r.domain
+}
+
+// Forward rewrites the envelope sender of a message forwarded by this host. The
+// null sender and addresses of our own domain are left unchanged.
+func (r *Rewriter) Forward(address string) (string, error) {
+	if address == "" {
+		return "", nil
+	}
+	local, host, ok := split(address)
+	if !ok {
+		return "", ErrInvalid
+	}
+	if strings.EqualFold(host, r.domain) {
+		return address, nil
+	}
+
+	switch prefix(local) {
+	case "SRS0":
+		// SRS0=HHHH=TT=domain=user@host becomes SRS1=HHHH=host==HHHH=TT=domain=user
+		rest := local[len("SRS0"):]
+		data := host + rest
+		return fmt.Sprintf("SRS1=%s=%s=%s@%s", r.hash(r.secrets[0], data), host, rest, r.domain), nil
+	case "SRS1":
+		// Keep the first forwarder: SRS1=HHHH=first==rest@host becomes
+		// SRS1=NNNN=first==rest
+		first, rest, err := splitSRS1(local)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("SRS1=%s=%s=%s@%s", r.hash(r.secrets[0], first+rest), first, rest, r.domain), nil
+	}
+
+	timestamp := encodeTimestamp(r.now())
+	hash := r.hash(r.secrets[0], timestamp+host+local)
+	return fmt.Sprintf("SRS0=%s=%s=%s=%s@%s", hash, timestamp, host, local, r.domain), nil
+}
+
+// Reverse returns the address an SRS address of our domain was rewritten from:
+// the original sender for SRS0, the SRS0 address of the first forwarder for SRS1
+func (r *Rewriter) Reverse(address string) (string, error) {
+	local, host, ok := split(address)
+	if !ok || !strings.EqualFold(host, r.domain) {
+		return "", ErrNotSRS
+	}
+
+	switch prefix(local) {
+	case "SRS0":
+		parts := strings.SplitN(local[len("SRS0")+1:], "=", 4)
+		if len(parts) != 4 || parts[2] == "" || parts[3] == "" {
+			return "", ErrInvalid
+		}
+		hash, timestamp, domain, user := parts[0], parts[1], parts[2], parts[3]
+		if !r.verify(hash, timestamp+domain+user) {
+			return "", ErrBadHash
+		}
+		if err := r.checkTimestamp(timestamp); err != nil {
+			return "", err
+		}
+		return user + "@" + domain, nil
+	case "SRS1":
+		hash, _, _ := strings.Cut(local[len("SRS1")+1:], "=")
+		first, rest, err := splitSRS1(local)
+		if err != nil {
+			return "", err
+		}
+		if !r.verify(hash, first+rest) {
+			return "", ErrBadHash
+		}
+		return "SRS0" + rest + "@" + first, nil
+	default:
+		return "", ErrNotSRS
+	}
+}
+
+// IsSRS reports whether an address looks like an SRS address
+func IsSRS(address string) bool {
+	local, _, ok := split(address)
+	return ok && prefix(local) != ""
+}
+
+func (r *Rewriter) hash(secret []byte, data string) string {
+	mac := hmac.New(sha1.New, secret)
+	mac.Write([]byte(strings.ToLower(data)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))[:hashLength]
+}
+
+// verify checks a hash against every secret. Hashes are compared without regard
+// to case, as some MTAs fold the case of local parts.
+func (r *Rewriter) verify(hash, data string) bool {
+	for _, secret := range r.secrets {
+		expected := r.hash(secret, data)
+		if hmac.Equal([]byte(strings.ToLower(hash)), []byte(strings.ToLower(expected))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rewriter) checkTimestamp(timestamp string) error {
+	if len(timestamp) != 2 {
+		return ErrInvalid
+	}
+	var then int
+	for _, c := range strings.ToUpper(timestamp) {
+		i := strings.IndexRune(timestampAlphabet, c)
+		if i < 0 {
+			return ErrInvalid
+		}
+		then = then*len(timestampAlphabet) + i
+	}
+
+	today := int(r.now().Unix()/86400) % timestampPeriod
+	age := (today - then + timestampPeriod) % timestampPeriod
+	if time.Duration(age)*24*time.Hour > r.maxAge {
+		return ErrExpired
+	}
+	return nil
+}
+
+func encodeTimestamp(t time.Time) string {
+	days := int(t.Unix()/86400) % timestampPeriod
+	n := len(timestampAlphabet)
+	return string([]byte{timestampAlphabet[days/n], timestampAlphabet[days%n]})
+}
+
+// prefix returns "SRS0" or "SRS1" when the local part starts with one of them
+// followed by a separator, or an empty string
+func prefix(local string) string {
+	if len(local) < 5 {
+		return ""
+	}
+	switch sep := local[4]; {
+	case sep != '=' && sep != '+' && sep != '-':
+		return ""
+	case strings.EqualFold(local[:4], "SRS0"):
+		return "SRS0"
+	case strings.EqualFold(local[:4], "SRS1"):
+		return "SRS1"
+	}
+	return ""
+}
+
+// splitSRS1 returns the first forwarder of an SRS1 local part and the rest of
+// its SRS0 address, starting with the separator that followed SRS0
+func splitSRS1(local string) (string, string, error) {
+	parts := strings.SplitN(local[len("SRS1")+1:], "=", 3)
+	if len(parts) != 3 || parts[1] == "" || len(parts[2]) < 2 || !strings.ContainsRune("=+-", rune(parts[2][0])) {
+		return "", "", ErrInvalid
+	}
+	return parts[1], parts[2], nil
+}
+
+func split(address string) (string, string, bool) {
+	at := strings.LastIndex(address, "@")
+	if at <= 0 || at == len(address)-1 {
+		return "", "", false
+	}
+	return address[:at], address[at+1:], true
+}
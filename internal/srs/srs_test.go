@@ -0,0 +1,140 @@
+package srs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRewriter(t *testing.T, domain string, secrets ...string) *Rewriter {
+	r, err := New(domain, secrets, 0)
+	require.NoError(t, err)
+	r.now = func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }
+	return r
+}
+
+func TestForwardAndReverse(t *testing.T) {
+	r := newTestRewriter(t, "forwarder.example", "secret")
+
+	rewritten, err := r.Forward("alice@example.org")
+	require.NoError(t, err)
+	assert.Regexp(t, `^SRS0=[A-Za-z0-9+/]{4}=[A-Z2-7]{2}=example\.org=alice@forwarder\.example$`, rewritten)
+
+	original, err := r.Reverse(rewritten)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.org", original)
+
+	// Some MTAs fold the case of the local part
+	original, err = r.Reverse(strings.ToLower(rewritten))
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.org", original)
+}
+
+func TestForwardUnchanged(t *testing.T) {
+	r := newTestRewriter(t, "forwarder.example", "secret")
+
+	rewritten, err := r.Forward("")
+	require.NoError(t, err)
+	assert.Equal(t, "", rewritten)
+
+	rewritten, err = r.Forward("bob@Forwarder.example")
+	require.NoError(t, err)
+	assert.Equal(t, "bob@Forwarder.example", rewritten)
+}
+
+func TestSRS1(t *testing.T) {
+	first := newTestRewriter(t, "first.example", "first secret")
+	second := newTestRewriter(t, "second.example", "second secret")
+	third := newTestRewriter(t, "third.example", "third secret")
+
+	srs0, err := first.Forward("alice@example.org")
+	require.NoError(t, err)
+
+	srs1, err := second.Forward(srs0)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(srs1, "SRS1="))
+	assert.Contains(t, srs1, "=first.example==")
+	assert.True(t, strings.HasSuffix(srs1, "@second.example"))
+
+	// A third hop still points back at the first forwarder
+	again, err := third.Forward(srs1)
+	require.NoError(t, err)
+	assert.Contains(t, again, "=first.example==")
+	assert.True(t, strings.HasSuffix(again, "@third.example"))
+
+	back, err := third.Reverse(again)
+	require.NoError(t, err)
+	assert.Equal(t, srs0, back)
+
+	back, err = second.Reverse(srs1)
+	require.NoError(t, err)
+	assert.Equal(t, srs0, back)
+
+	original, err := first.Reverse(back)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.org", original)
+}
+
+func TestReverseErrors(t *testing.T) {
+	r := newTestRewriter(t, "forwarder.example", "secret")
+	valid, err := r.Forward("alice@example.org")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		address string
+		wantErr error
+	}{
+		{name: "not SRS", address: "alice@forwarder.example", wantErr: ErrNotSRS},
+		{name: "other domain", address: strings.Replace(valid, "@forwarder.example", "@elsewhere.example", 1), wantErr: ErrNotSRS},
+		{name: "forged hash", address: "SRS0=AAAA" + valid[9:], wantErr: ErrBadHash},
+		{name: "tampered sender", address: strings.Replace(valid, "=alice@", "=mallory@", 1), wantErr: ErrBadHash},
+		{name: "malformed", address: "SRS0=abcd@forwarder.example", wantErr: ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.Reverse(tt.address)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestReverseExpired(t *testing.T) {
+	r := newTestRewriter(t, "forwarder.example", "secret")
+	rewritten, err := r.Forward("alice@example.org")
+	require.NoError(t, err)
+
+	r.now = func() time.Time { return time.Date(2026, 11, 30, 12, 0, 0, 0, time.UTC) }
+	_, err = r.Reverse(rewritten)
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestSecretRotation(t *testing.T) {
+	old := newTestRewriter(t, "forwarder.example", "old secret")
+	rewritten, err := old.Forward("alice@example.org")
+	require.NoError(t, err)
+
+	rotated := newTestRewriter(t, "forwarder.example", "new secret", "old secret")
+	original, err := rotated.Reverse(rewritten)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.org", original)
+
+	retired := newTestRewriter(t, "forwarder.example", "new secret")
+	_, err = retired.Reverse(rewritten)
+	assert.ErrorIs(t, err, ErrBadHash)
+}
+
+func TestNew_NoSecrets(t *testing.T) {
+	_, err := New("forwarder.example", []string{""}, 0)
+	assert.ErrorIs(t, err, ErrNoSecrets)
+}
+
+func TestIsSRS(t *testing.T) {
+	assert.True(t, IsSRS("SRS0=abcd=AB=example.org=alice@forwarder.example"))
+	assert.True(t, IsSRS("srs1+abcd=first.example==x@forwarder.example"))
+	assert.False(t, IsSRS("srs@forwarder.example"))
+	assert.False(t, IsSRS("alice@example.org"))
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+)
+
+func (r *repository) GetACMECacheEntry(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := r.queries.GetACMECacheEntry.GetContext(ctx, &data, key)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return data, nil
+}
+
+// PutACMECacheEntry creates or replaces an entry
+func (r *repository) PutACMECacheEntry(ctx context.Context, key string, data []byte) error {
+	_, err := r.queries.PutACMECacheEntry.ExecContext(ctx, key, data)
+	return handleDBError(err)
+}
+
+// DeleteACMECacheEntry removes an entry, deleting a missing entry is not an error
+func (r *repository) DeleteACMECacheEntry(ctx context.Context, key string) error {
+	_, err := r.queries.DeleteACMECacheEntry.ExecContext(ctx, key)
+	return handleDBError(err)
+}
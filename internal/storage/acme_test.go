@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupACMETestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectPrepare("SELECT data FROM acme_cache") // GetACMECacheEntry
+	mock.ExpectPrepare("INSERT INTO acme_cache")      // PutACMECacheEntry
+	mock.ExpectPrepare("DELETE FROM acme_cache")      // DeleteACMECacheEntry
+
+	get, err := sqlxDB.Preparex("SELECT data FROM acme_cache WHERE key = ?")
+	require.NoError(t, err)
+
+	put, err := sqlxDB.Preparex("INSERT INTO acme_cache (key, data) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET data = EXCLUDED.data")
+	require.NoError(t, err)
+
+	del, err := sqlxDB.Preparex("DELETE FROM acme_cache WHERE key = ?")
+	require.NoError(t, err)
+
+	queries := &Queries{
+		GetACMECacheEntry:    get,
+		PutACMECacheEntry:    put,
+		DeleteACMECacheEntry: del,
+	}
+
+	repo := &repository{
+		db:      sqlxDB,
+		queries: queries,
+	}
+
+	return repo, mock
+}
+
+func TestRepository_GetACMECacheEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		mockFn  func(sqlmock.Sqlmock)
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "existing entry",
+			key:  "mail.example.com",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT data FROM acme_cache").
+					WithArgs("mail.example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow([]byte("encrypted")))
+			},
+			want: []byte("encrypted"),
+		},
+		{
+			name: "missing entry",
+			key:  "acme_account+key",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT data FROM acme_cache").
+					WithArgs("acme_account+key").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupACMETestDB(t)
+			tt.mockFn(mock)
+
+			got, err := repo.GetACMECacheEntry(context.Background(), tt.key)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_PutACMECacheEntry(t *testing.T) {
+	repo, mock := setupACMETestDB(t)
+	mock.ExpectExec("INSERT INTO acme_cache").
+		WithArgs("mail.example.com", []byte("encrypted")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.PutACMECacheEntry(context.Background(), "mail.example.com", []byte("encrypted"))
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_DeleteACMECacheEntry(t *testing.T) {
+	repo, mock := setupACMETestDB(t)
+	mock.ExpectExec("DELETE FROM acme_cache").
+		WithArgs("mail.example.com").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.DeleteACMECacheEntry(context.Background(), "mail.example.com")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
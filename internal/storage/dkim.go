@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+
+	"inbox451/internal/models"
+)
+
+func (r *repository) ListDKIMKeys(ctx context.Context, limit, offset int) ([]*models.DKIMKey, int, error) {
+	var total int
+	err := r.queries.CountDKIMKeys.GetContext(ctx, &total)
+	if err != nil {
+		return nil, 0, handleDBError(err)
+	}
+
+	keys := []*models.DKIMKey{}
+	if total > 0 {
+		err = r.queries.ListDKIMKeys.SelectContext(ctx, &keys, limit, offset)
+		if err != nil {
+			return nil, 0, handleDBError(err)
+		}
+	}
+
+	return keys, total, nil
+}
+
+func (r *repository) GetDKIMKey(ctx context.Context, id string) (*models.DKIMKey, error) {
+	var key models.DKIMKey
+	err := r.queries.GetDKIMKey.GetContext(ctx, &key, id)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return &key, nil
+}
+
+// GetDKIMKeyByDomain returns the newest key of a domain
+func (r *repository) GetDKIMKeyByDomain(ctx context.Context, domain string) (*models.DKIMKey, error) {
+	var key models.DKIMKey
+	err := r.queries.GetDKIMKeyByDomain.GetContext(ctx, &key, domain)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return &key, nil
+}
+
+func (r *repository) CreateDKIMKey(ctx context.Context, key *models.DKIMKey) error {
+	err := r.queries.CreateDKIMKey.QueryRowContext(ctx, key.Domain, key.Selector, key.Algorithm, key.PublicKey, key.PrivateKey).
+		Scan(&key.ID, &key.CreatedAt, &key.UpdatedAt)
+	return handleDBError(err)
+}
+
+func (r *repository) DeleteDKIMKey(ctx context.Context, id string) error {
+	result, err := r.queries.DeleteDKIMKey.ExecContext(ctx, id)
+	if err != nil {
+		return handleDBError(err)
+	}
+	return handleRowsAffected(result)
+}
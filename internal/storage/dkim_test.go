@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"inbox451/internal/models"
+	"inbox451/internal/test"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupDKIMTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectPrepare("SELECT (.+) FROM dkim_keys WHERE domain") // GetDKIMKeyByDomain
+	mock.ExpectPrepare("INSERT INTO dkim_keys")                   // CreateDKIMKey
+
+	getByDomain, err := sqlxDB.Preparex("SELECT id, domain, selector, algorithm, public_key, private_key, created_at, updated_at FROM dkim_keys WHERE domain = lower(?) LIMIT 1")
+	require.NoError(t, err)
+
+	create, err := sqlxDB.Preparex("INSERT INTO dkim_keys (domain, selector, algorithm, public_key, private_key) VALUES (?, ?, ?, ?, ?) RETURNING id, created_at, updated_at")
+	require.NoError(t, err)
+
+	queries := &Queries{
+		GetDKIMKeyByDomain: getByDomain,
+		CreateDKIMKey:      create,
+	}
+
+	repo := &repository{
+		db:      sqlxDB,
+		queries: queries,
+	}
+
+	return repo, mock
+}
+
+func TestRepository_GetDKIMKeyByDomain(t *testing.T) {
+	testKeyID := test.RandomTestUUID()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "found",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM dkim_keys").
+					WithArgs("example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "domain", "selector", "algorithm", "public_key", "private_key", "created_at", "updated_at"}).
+						AddRow(testKeyID, "example.com", "mail", "ed25519", "cHVibGlj", []byte("sealed"), nil, nil))
+			},
+		},
+		{
+			name: "no key",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM dkim_keys").
+					WithArgs("example.com").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupDKIMTestDB(t)
+			defer repo.db.Close()
+
+			tt.mockFn(mock)
+
+			key, err := repo.GetDKIMKeyByDomain(context.Background(), "example.com")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, testKeyID, key.ID)
+				assert.Equal(t, "mail", key.Selector)
+				assert.Equal(t, []byte("sealed"), key.PrivateKey)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_CreateDKIMKey(t *testing.T) {
+	testKeyID := test.RandomTestUUID()
+
+	repo, mock := setupDKIMTestDB(t)
+	defer repo.db.Close()
+
+	mock.ExpectQuery("INSERT INTO dkim_keys").
+		WithArgs("example.com", "mail", "rsa", "cHVibGlj", []byte("sealed")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(testKeyID, nil, nil))
+
+	key := &models.DKIMKey{
+		Domain:     "example.com",
+		Selector:   "mail",
+		Algorithm:  "rsa",
+		PublicKey:  "cHVibGlj",
+		PrivateKey: []byte("sealed"),
+	}
+	require.NoError(t, repo.CreateDKIMKey(context.Background(), key))
+	assert.Equal(t, testKeyID, key.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
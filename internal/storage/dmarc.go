@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+
+	"inbox451/internal/models"
+)
+
+// ListDMARCReports lists the reports of a project, newest first
+func (r *repository) ListDMARCReports(ctx context.Context, filter models.DMARCFilter, limit, offset int) ([]*models.DMARCReport, int, error) {
+	var total int
+	err := r.queries.CountDMARCReports.GetContext(ctx, &total, filter.ProjectID, filter.Domain, filter.Start, filter.End)
+	if err != nil {
+		return nil, 0, handleDBError(err)
+	}
+
+	reports := []*models.DMARCReport{}
+	if total > 0 {
+		err = r.queries.ListDMARCReports.SelectContext(ctx, &reports, filter.ProjectID, filter.Domain, filter.Start, filter.End, limit, offset)
+		if err != nil {
+			return nil, 0, handleDBError(err)
+		}
+	}
+
+	return reports, total, nil
+}
+
+// GetDMARCReport returns a report of a project with its records
+func (r *repository) GetDMARCReport(ctx context.Context, projectID, id string) (*models.DMARCReport, error) {
+	var report models.DMARCReport
+	if err := r.queries.GetDMARCReport.GetContext(ctx, &report, id, projectID); err != nil {
+		return nil, handleDBError(err)
+	}
+
+	report.Records = []*models.DMARCRecord{}
+	if err := r.queries.ListDMARCRecords.SelectContext(ctx, &report.Records, report.ID); err != nil {
+		return nil, handleDBError(err)
+	}
+	return &report, nil
+}
+
+// CreateDMARCReport stores a report and its records, replacing an earlier
+// copy of the report in the same inbox
+func (r *repository) CreateDMARCReport(ctx context.Context, report *models.DMARCReport) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return handleDBError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = tx.StmtxContext(ctx, r.queries.CreateDMARCReport).QueryRowContext(ctx,
+		report.InboxID, report.OrgName, report.OrgEmail, report.ReportID, report.Domain, report.DateBegin, report.DateEnd,
+		report.PolicyADKIM, report.PolicyASPF, report.PolicyP, report.PolicySP, report.PolicyPct,
+	).Scan(&report.ID, &report.CreatedAt, &report.UpdatedAt)
+	if err != nil {
+		return handleDBError(err)
+	}
+
+	if _, err := tx.StmtxContext(ctx, r.queries.DeleteDMARCRecords).ExecContext(ctx, report.ID); err != nil {
+		return handleDBError(err)
+	}
+
+	create := tx.StmtxContext(ctx, r.queries.CreateDMARCRecord)
+	for _, record := range report.Records {
+		record.ReportID = report.ID
+		err := create.QueryRowContext(ctx, record.ReportID, record.SourceIP, record.Count, record.Disposition,
+			record.DKIM, record.SPF, record.HeaderFrom, record.EnvelopeFrom, string(record.AuthResults),
+		).Scan(&record.ID)
+		if err != nil {
+			return handleDBError(err)
+		}
+	}
+
+	return handleDBError(tx.Commit())
+}
+
+func (r *repository) DeleteDMARCReport(ctx context.Context, projectID, id string) error {
+	result, err := r.queries.DeleteDMARCReport.ExecContext(ctx, id, projectID)
+	if err != nil {
+		return handleDBError(err)
+	}
+	return handleRowsAffected(result)
+}
+
+// SummarizeDMARCRecords sums the records of a project grouped by
+// filter.GroupBy, the largest groups first
+func (r *repository) SummarizeDMARCRecords(ctx context.Context, filter models.DMARCFilter, limit, offset int) ([]*models.DMARCSummary, int, error) {
+	var total int
+	err := r.queries.CountDMARCSummary.GetContext(ctx, &total, filter.ProjectID, filter.GroupBy, filter.Domain, filter.Source, filter.Start, filter.End)
+	if err != nil {
+		return nil, 0, handleDBError(err)
+	}
+
+	summaries := []*models.DMARCSummary{}
+	if total > 0 {
+		err = r.queries.SummarizeDMARCRecords.SelectContext(ctx, &summaries, filter.ProjectID, filter.GroupBy, filter.Domain, filter.Source, filter.Start, filter.End, limit, offset)
+		if err != nil {
+			return nil, 0, handleDBError(err)
+		}
+	}
+
+	return summaries, total, nil
+}
@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"inbox451/internal/models"
+	"inbox451/internal/test"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	null "github.com/volatiletech/null/v9"
+)
+
+func setupDMARCTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectPrepare("SELECT (.+) FROM dmarc_reports")  // GetDMARCReport
+	mock.ExpectPrepare("SELECT (.+) FROM dmarc_records")  // ListDMARCRecords
+	mock.ExpectPrepare("INSERT INTO dmarc_reports")       // CreateDMARCReport
+	mock.ExpectPrepare("DELETE FROM dmarc_records")       // DeleteDMARCRecords
+	mock.ExpectPrepare("INSERT INTO dmarc_records")       // CreateDMARCRecord
+	mock.ExpectPrepare("SELECT COUNT(.+) FROM dmarc_rec") // CountDMARCSummary
+	mock.ExpectPrepare("SELECT (.+) FROM dmarc_records")  // SummarizeDMARCRecords
+
+	getReport, err := sqlxDB.Preparex("SELECT id, inbox_id, org_name FROM dmarc_reports WHERE id = ? AND project_id = ?")
+	require.NoError(t, err)
+
+	listRecords, err := sqlxDB.Preparex("SELECT id, report_id, source_ip, count FROM dmarc_records WHERE report_id = ?")
+	require.NoError(t, err)
+
+	createReport, err := sqlxDB.Preparex("INSERT INTO dmarc_reports (inbox_id, org_name) VALUES (?, ?) RETURNING id, created_at, updated_at")
+	require.NoError(t, err)
+
+	deleteRecords, err := sqlxDB.Preparex("DELETE FROM dmarc_records WHERE report_id = ?")
+	require.NoError(t, err)
+
+	createRecord, err := sqlxDB.Preparex("INSERT INTO dmarc_records (report_id, source_ip) VALUES (?, ?) RETURNING id")
+	require.NoError(t, err)
+
+	countSummary, err := sqlxDB.Preparex("SELECT COUNT(DISTINCT key) FROM dmarc_records")
+	require.NoError(t, err)
+
+	summarize, err := sqlxDB.Preparex("SELECT key, SUM(count) AS messages FROM dmarc_records GROUP BY 1 LIMIT ? OFFSET ?")
+	require.NoError(t, err)
+
+	queries := &Queries{
+		GetDMARCReport:        getReport,
+		ListDMARCRecords:      listRecords,
+		CreateDMARCReport:     createReport,
+		DeleteDMARCRecords:    deleteRecords,
+		CreateDMARCRecord:     createRecord,
+		CountDMARCSummary:     countSummary,
+		SummarizeDMARCRecords: summarize,
+	}
+
+	repo := &repository{
+		db:      sqlxDB,
+		queries: queries,
+	}
+
+	return repo, mock
+}
+
+func testDMARCReport(inboxID string) *models.DMARCReport {
+	begin := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	return &models.DMARCReport{
+		InboxID:   inboxID,
+		OrgName:   "google.com",
+		OrgEmail:  "noreply-dmarc-support@google.com",
+		ReportID:  "1234567890",
+		Domain:    "example.com",
+		DateBegin: null.TimeFrom(begin),
+		DateEnd:   null.TimeFrom(begin.Add(24 * time.Hour)),
+		PolicyP:   "none",
+		PolicyPct: 100,
+		Records: []*models.DMARCRecord{
+			{SourceIP: "192.0.2.1", Count: 3, Disposition: "none", DKIM: "pass", SPF: "pass", HeaderFrom: "example.com", AuthResults: json.RawMessage(`{}`)},
+			{SourceIP: "198.51.100.7", Count: 1, Disposition: "none", DKIM: "fail", SPF: "fail", HeaderFrom: "example.com", AuthResults: json.RawMessage(`{}`)},
+		},
+	}
+}
+
+func TestRepository_CreateDMARCReport(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	testReportID := test.RandomTestUUID()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantErr bool
+	}{
+		{
+			name: "report and records",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO dmarc_reports").
+					WithArgs(testInboxID, "google.com", "noreply-dmarc-support@google.com", "1234567890", "example.com",
+						sqlmock.AnyArg(), sqlmock.AnyArg(), "", "", "none", "", 100).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(testReportID, nil, nil))
+				mock.ExpectExec("DELETE FROM dmarc_records").
+					WithArgs(testReportID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("INSERT INTO dmarc_records").
+					WithArgs(testReportID, "192.0.2.1", int64(3), "none", "pass", "pass", "example.com", "", "{}").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(test.RandomTestUUID()))
+				mock.ExpectQuery("INSERT INTO dmarc_records").
+					WithArgs(testReportID, "198.51.100.7", int64(1), "none", "fail", "fail", "example.com", "", "{}").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(test.RandomTestUUID()))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "record error rolls back",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO dmarc_reports").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(testReportID, nil, nil))
+				mock.ExpectExec("DELETE FROM dmarc_records").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("INSERT INTO dmarc_records").
+					WillReturnError(errors.New("connection lost"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupDMARCTestDB(t)
+			defer repo.db.Close()
+
+			tt.mockFn(mock)
+
+			report := testDMARCReport(testInboxID)
+			err := repo.CreateDMARCReport(context.Background(), report)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, testReportID, report.ID)
+				assert.Equal(t, testReportID, report.Records[1].ReportID)
+				assert.NotEmpty(t, report.Records[1].ID)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_GetDMARCReport(t *testing.T) {
+	testProjectID := test.RandomTestUUID()
+	testReportID := test.RandomTestUUID()
+
+	repo, mock := setupDMARCTestDB(t)
+	defer repo.db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM dmarc_reports").
+		WithArgs(testReportID, testProjectID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "inbox_id", "org_name", "message_count"}).
+			AddRow(testReportID, test.RandomTestUUID(), "google.com", 4))
+	mock.ExpectQuery("SELECT (.+) FROM dmarc_records").
+		WithArgs(testReportID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "report_id", "source_ip", "count", "auth_results"}).
+			AddRow(test.RandomTestUUID(), testReportID, "192.0.2.1", 4, []byte(`{}`)))
+
+	report, err := repo.GetDMARCReport(context.Background(), testProjectID, testReportID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), report.MessageCount)
+	require.Len(t, report.Records, 1)
+	assert.Equal(t, "192.0.2.1", report.Records[0].SourceIP)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Reports of other projects are not found
+	mock.ExpectQuery("SELECT (.+) FROM dmarc_reports").
+		WithArgs(testReportID, testProjectID).
+		WillReturnError(sql.ErrNoRows)
+	_, err = repo.GetDMARCReport(context.Background(), testProjectID, testReportID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRepository_SummarizeDMARCRecords(t *testing.T) {
+	testProjectID := test.RandomTestUUID()
+
+	repo, mock := setupDMARCTestDB(t)
+	defer repo.db.Close()
+
+	filter := models.DMARCFilter{ProjectID: testProjectID, GroupBy: "source", Domain: "example.com"}
+	mock.ExpectQuery("SELECT COUNT(.+) FROM dmarc_records").
+		WithArgs(testProjectID, "source", "example.com", "", filter.Start, filter.End).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT (.+) FROM dmarc_records").
+		WithArgs(testProjectID, "source", "example.com", "", filter.Start, filter.End, 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "messages", "dkim_pass", "spf_pass", "dmarc_pass", "quarantined", "rejected"}).
+			AddRow("192.0.2.1", 4, 3, 3, 3, 0, 1))
+
+	summaries, total, err := repo.SummarizeDMARCRecords(context.Background(), filter, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, &models.DMARCSummary{Key: "192.0.2.1", Messages: 4, DKIMPass: 3, SPFPass: 3, DMARCPass: 3, Rejected: 1}, summaries[0])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+
+	"inbox451/internal/models"
+)
+
+func (r *repository) ListFoldersByInbox(ctx context.Context, inboxID string, limit, offset int) ([]*models.Folder, int, error) {
+	var total int
+	err := r.queries.CountFoldersByInbox.GetContext(ctx, &total, inboxID)
+	if err != nil {
+		return nil, 0, handleDBError(err)
+	}
+
+	folders := []*models.Folder{}
+	if total > 0 {
+		err = r.queries.ListFoldersByInbox.SelectContext(ctx, &folders, inboxID, limit, offset)
+		if err != nil {
+			return nil, 0, handleDBError(err)
+		}
+	}
+
+	return folders, total, nil
+}
+
+func (r *repository) GetFolder(ctx context.Context, id string) (*models.Folder, error) {
+	var folder models.Folder
+	err := r.queries.GetFolder.GetContext(ctx, &folder, id)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return &folder, nil
+}
+
+func (r *repository) GetFolderByName(ctx context.Context, inboxID string, name string) (*models.Folder, error) {
+	var folder models.Folder
+	err := r.queries.GetFolderByName.GetContext(ctx, &folder, inboxID, name)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return &folder, nil
+}
+
+// GetFolderBySpecialUse returns the folder of an inbox with the given RFC 6154 attribute
+func (r *repository) GetFolderBySpecialUse(ctx context.Context, inboxID string, specialUse string) (*models.Folder, error) {
+	var folder models.Folder
+	err := r.queries.GetFolderBySpecialUse.GetContext(ctx, &folder, inboxID, specialUse)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return &folder, nil
+}
+
+func (r *repository) CreateFolder(ctx context.Context, folder *models.Folder) error {
+	err := r.queries.CreateFolder.QueryRowContext(ctx, folder.InboxID, folder.Name, folder.Subscribed, folder.SpecialUse).
+		Scan(&folder.ID, &folder.UIDValidity, &folder.UIDNext, &folder.CreatedAt, &folder.UpdatedAt)
+	return handleDBError(err)
+}
+
+// UpdateFolder renames a folder, together with its subfolders, and updates its subscription
+func (r *repository) UpdateFolder(ctx context.Context, folder *models.Folder) error {
+	result, err := r.queries.UpdateFolder.ExecContext(ctx, folder.ID, folder.Name, folder.Subscribed)
+	if err != nil {
+		return handleDBError(err)
+	}
+	return handleRowsAffected(result)
+}
+
+func (r *repository) DeleteFolder(ctx context.Context, id string) error {
+	result, err := r.queries.DeleteFolder.ExecContext(ctx, id)
+	if err != nil {
+		return handleDBError(err)
+	}
+	return handleRowsAffected(result)
+}
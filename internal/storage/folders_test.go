@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"inbox451/internal/test"
+
+	"inbox451/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	null "github.com/volatiletech/null/v9"
+)
+
+func setupFolderTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectPrepare("SELECT (.+) FROM folders WHERE inbox_id = (.+) AND name")        // GetFolderByName
+	mock.ExpectPrepare("SELECT (.+) FROM folders WHERE inbox_id = (.+) AND special_use") // GetFolderBySpecialUse
+	mock.ExpectPrepare("INSERT INTO folders")                                            // CreateFolder
+	mock.ExpectPrepare("UPDATE folders")                                                 // UpdateFolder
+	mock.ExpectPrepare("DELETE FROM folders")                                            // DeleteFolder
+
+	getFolderByName, err := sqlxDB.Preparex("SELECT id, inbox_id, name, uid_validity, uid_next, subscribed, created_at, updated_at FROM folders WHERE inbox_id = ? AND name = ?")
+	require.NoError(t, err)
+
+	getFolderBySpecialUse, err := sqlxDB.Preparex("SELECT id, inbox_id, name, uid_validity, uid_next, subscribed, special_use, created_at, updated_at FROM folders WHERE inbox_id = ? AND special_use = ?")
+	require.NoError(t, err)
+
+	createFolder, err := sqlxDB.Preparex("INSERT INTO folders (inbox_id, name, subscribed, special_use) VALUES (?, ?, ?, ?)")
+	require.NoError(t, err)
+
+	updateFolder, err := sqlxDB.Preparex("UPDATE folders SET name = ?, subscribed = ? WHERE id = ?")
+	require.NoError(t, err)
+
+	deleteFolder, err := sqlxDB.Preparex("DELETE FROM folders WHERE id = ?")
+	require.NoError(t, err)
+
+	queries := &Queries{
+		GetFolderByName:       getFolderByName,
+		GetFolderBySpecialUse: getFolderBySpecialUse,
+		CreateFolder:          createFolder,
+		UpdateFolder:          updateFolder,
+		DeleteFolder:          deleteFolder,
+	}
+
+	repo := &repository{
+		db:      sqlxDB,
+		queries: queries,
+	}
+
+	return repo, mock
+}
+
+func TestRepository_CreateFolder(t *testing.T) {
+	now := time.Now()
+	testInboxID := test.RandomTestUUID()
+	testFolderID := test.RandomTestUUID()
+
+	repo, mock := setupFolderTestDB(t)
+	mock.ExpectQuery("INSERT INTO folders").
+		WithArgs(testInboxID, "Archive", true, null.String{}).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "uid_validity", "uid_next", "created_at", "updated_at"}).
+				AddRow(testFolderID, 1700000000, 1, now, now),
+		)
+
+	folder := &models.Folder{InboxID: testInboxID, Name: "Archive", Subscribed: true}
+	err := repo.CreateFolder(context.Background(), folder)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testFolderID, folder.ID)
+	assert.Equal(t, uint32(1700000000), folder.UIDValidity)
+	assert.Equal(t, uint32(1), folder.UIDNext)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetFolderBySpecialUse(t *testing.T) {
+	now := time.Now()
+	testInboxID := test.RandomTestUUID()
+	testFolderID := test.RandomTestUUID()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "existing folder",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM folders").
+					WithArgs(testInboxID, `\Trash`).
+					WillReturnRows(
+						sqlmock.NewRows([]string{"id", "inbox_id", "name", "uid_validity", "uid_next", "subscribed", "special_use", "created_at", "updated_at"}).
+							AddRow(testFolderID, testInboxID, "Trash", 1700000000, 3, true, `\Trash`, now, now),
+					)
+			},
+		},
+		{
+			name: "folder not found",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM folders").
+					WithArgs(testInboxID, `\Trash`).
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupFolderTestDB(t)
+			tt.mockFn(mock)
+
+			folder, err := repo.GetFolderBySpecialUse(context.Background(), testInboxID, `\Trash`)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, folder)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testFolderID, folder.ID)
+				assert.Equal(t, null.StringFrom(`\Trash`), folder.SpecialUse)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_GetFolderByName(t *testing.T) {
+	now := time.Now()
+	testInboxID := test.RandomTestUUID()
+	testFolderID := test.RandomTestUUID()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "existing folder",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM folders").
+					WithArgs(testInboxID, "Archive").
+					WillReturnRows(
+						sqlmock.NewRows([]string{"id", "inbox_id", "name", "uid_validity", "uid_next", "subscribed", "created_at", "updated_at"}).
+							AddRow(testFolderID, testInboxID, "Archive", 1700000000, 5, true, now, now),
+					)
+			},
+		},
+		{
+			name: "folder not found",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM folders").
+					WithArgs(testInboxID, "Archive").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupFolderTestDB(t)
+			tt.mockFn(mock)
+
+			folder, err := repo.GetFolderByName(context.Background(), testInboxID, "Archive")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, folder)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testFolderID, folder.ID)
+				assert.Equal(t, uint32(5), folder.UIDNext)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_UpdateFolder(t *testing.T) {
+	testFolderID := test.RandomTestUUID()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "rename with subfolders",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE folders").
+					WithArgs(testFolderID, "Old", false).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+		},
+		{
+			name: "non-existent folder",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE folders").
+					WithArgs(testFolderID, "Old", false).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: ErrNoRowsAffected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupFolderTestDB(t)
+			tt.mockFn(mock)
+
+			err := repo.UpdateFolder(context.Background(), &models.Folder{Base: models.Base{ID: testFolderID}, Name: "Old"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"inbox451/internal/models"
+)
+
+// TouchGreylistTriplet records a delivery attempt and loads when the triplet
+// was first and last seen. A triplet unseen for longer than maxAge is
+// greylisted anew.
+func (r *repository) TouchGreylistTriplet(ctx context.Context, triplet *models.GreylistTriplet, maxAge time.Duration) error {
+	err := r.queries.TouchGreylistTriplet.QueryRowxContext(ctx,
+		triplet.Network,
+		triplet.Sender,
+		triplet.Recipient,
+		maxAge.Seconds(),
+	).Scan(&triplet.FirstSeen, &triplet.LastSeen)
+	return handleDBError(err)
+}
+
+// DeleteExpiredGreylistTriplets removes the triplets unseen for longer than
+// maxAge and returns how many there were
+func (r *repository) DeleteExpiredGreylistTriplets(ctx context.Context, maxAge time.Duration) (int64, error) {
+	result, err := r.queries.DeleteExpiredGreylistTriplets.ExecContext(ctx, maxAge.Seconds())
+	if err != nil {
+		return 0, handleDBError(err)
+	}
+	return result.RowsAffected()
+}
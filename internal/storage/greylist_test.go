@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"inbox451/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupGreylistTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectPrepare("INSERT INTO greylist") // TouchGreylistTriplet
+	mock.ExpectPrepare("DELETE FROM greylist") // DeleteExpiredGreylistTriplets
+
+	touch, err := sqlxDB.Preparex("INSERT INTO greylist (network, sender, recipient) VALUES (?, ?, ?) RETURNING first_seen, last_seen")
+	require.NoError(t, err)
+
+	deleteExpired, err := sqlxDB.Preparex("DELETE FROM greylist WHERE last_seen < ?")
+	require.NoError(t, err)
+
+	queries := &Queries{
+		TouchGreylistTriplet:          touch,
+		DeleteExpiredGreylistTriplets: deleteExpired,
+	}
+
+	repo := &repository{
+		db:      sqlxDB,
+		queries: queries,
+	}
+
+	return repo, mock
+}
+
+func TestRepository_TouchGreylistTriplet(t *testing.T) {
+	firstSeen := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lastSeen := firstSeen.Add(6 * time.Minute)
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantErr bool
+	}{
+		{
+			name: "success",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO greylist").
+					WithArgs("192.0.2.0/24", "sender@example.org", "user@example.com", float64(3600)).
+					WillReturnRows(sqlmock.NewRows([]string{"first_seen", "last_seen"}).AddRow(firstSeen, lastSeen))
+			},
+		},
+		{
+			name: "database error",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO greylist").
+					WillReturnError(errors.New("connection refused"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupGreylistTestDB(t)
+			tt.mockFn(mock)
+
+			triplet := &models.GreylistTriplet{
+				Network:   "192.0.2.0/24",
+				Sender:    "sender@example.org",
+				Recipient: "user@example.com",
+			}
+			err := repo.TouchGreylistTriplet(context.Background(), triplet, time.Hour)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.True(t, triplet.FirstSeen.Time.Equal(firstSeen))
+				assert.True(t, triplet.LastSeen.Time.Equal(lastSeen))
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_DeleteExpiredGreylistTriplets(t *testing.T) {
+	repo, mock := setupGreylistTestDB(t)
+
+	mock.ExpectExec("DELETE FROM greylist").
+		WithArgs(float64(86400)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	deleted, err := repo.DeleteExpiredGreylistTriplets(context.Background(), 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -9,8 +9,8 @@ import (
 )
 
 func (r *repository) CreateInbox(ctx context.Context, inbox *models.Inbox) error {
-	return r.queries.CreateInbox.QueryRowContext(ctx, inbox.ProjectID, inbox.Email).
-		Scan(&inbox.ID, &inbox.CreatedAt, &inbox.UpdatedAt)
+	return r.queries.CreateInbox.QueryRowContext(ctx, inbox.ProjectID, inbox.Email, inbox.DMARCReports, inbox.MaxMessages, inbox.MaxBytes).
+		Scan(&inbox.ID, &inbox.UIDValidity, &inbox.UIDNext, &inbox.CreatedAt, &inbox.UpdatedAt)
 }
 
 func (r *repository) GetInbox(ctx context.Context, id string) (*models.Inbox, error) {
@@ -32,7 +32,7 @@ func (r *repository) GetInboxByEmail(ctx context.Context, email string) (*models
 }
 
 func (r *repository) UpdateInbox(ctx context.Context, inbox *models.Inbox) error {
-	result, err := r.queries.UpdateInbox.ExecContext(ctx, inbox.Email, inbox.ID)
+	result, err := r.queries.UpdateInbox.ExecContext(ctx, inbox.Email, inbox.DMARCReports, inbox.MaxMessages, inbox.MaxBytes, inbox.ID)
 	if err != nil {
 		return handleDBError(err)
 	}
@@ -47,6 +47,17 @@ func (r *repository) DeleteInbox(ctx context.Context, id string) error {
 	return handleRowsAffected(result)
 }
 
+// GetInboxQuotas returns the quotas a message stored in the inbox counts
+// against, the inbox's first and then its project's
+func (r *repository) GetInboxQuotas(ctx context.Context, inboxID string) ([]*models.Quota, error) {
+	quotas := []*models.Quota{}
+	err := r.queries.GetInboxQuotas.SelectContext(ctx, &quotas, inboxID)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return quotas, nil
+}
+
 func (r *repository) ListInboxesByProject(ctx context.Context, projectID string, limit, offset int) ([]*models.Inbox, int, error) {
 	var total int
 	err := r.queries.CountInboxesByProject.GetContext(ctx, &total, projectID)
@@ -30,6 +30,7 @@ func setupInboxTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
 	mock.ExpectPrepare("UPDATE inboxes")                       // UpdateInbox
 	mock.ExpectPrepare("DELETE FROM inboxes")                  // DeleteInbox
 	mock.ExpectPrepare("SELECT (.+) FROM inboxes WHERE email") // GetInboxByEmail
+	mock.ExpectPrepare("SELECT (.+) UNION ALL")                // GetInboxQuotas
 
 	listInboxes, err := sqlxDB.Preparex("SELECT id, project_id, email, created_at, updated_at FROM inboxes WHERE project_id = ? LIMIT ? OFFSET ?")
 	require.NoError(t, err)
@@ -40,10 +41,10 @@ func setupInboxTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
 	getInbox, err := sqlxDB.Preparex("SELECT id, project_id, email, created_at, updated_at FROM inboxes WHERE id = ?")
 	require.NoError(t, err)
 
-	createInbox, err := sqlxDB.Preparex("INSERT INTO inboxes (project_id, email) VALUES (?, ?)")
+	createInbox, err := sqlxDB.Preparex("INSERT INTO inboxes (project_id, email, dmarc_reports) VALUES (?, ?, ?)")
 	require.NoError(t, err)
 
-	updateInbox, err := sqlxDB.Preparex("UPDATE inboxes SET email = ? WHERE id = ?")
+	updateInbox, err := sqlxDB.Preparex("UPDATE inboxes SET email = ?, dmarc_reports = ? WHERE id = ?")
 	require.NoError(t, err)
 
 	deleteInbox, err := sqlxDB.Preparex("DELETE FROM inboxes WHERE id = ?")
@@ -52,6 +53,9 @@ func setupInboxTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
 	getInboxByEmail, err := sqlxDB.Preparex("SELECT id, project_id, email, created_at, updated_at FROM inboxes WHERE email = ?")
 	require.NoError(t, err)
 
+	getInboxQuotas, err := sqlxDB.Preparex("SELECT 'inbox' AS kind, id, email AS name FROM inboxes WHERE id = ? UNION ALL SELECT 'project' AS kind, p.id, p.name FROM projects p")
+	require.NoError(t, err)
+
 	queries := &Queries{
 		ListInboxesByProject:  listInboxes,
 		CountInboxesByProject: countInboxes,
@@ -60,6 +64,7 @@ func setupInboxTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
 		UpdateInbox:           updateInbox,
 		DeleteInbox:           deleteInbox,
 		GetInboxByEmail:       getInboxByEmail,
+		GetInboxQuotas:        getInboxQuotas,
 	}
 
 	repo := &repository{
@@ -88,10 +93,10 @@ func TestRepository_CreateInbox(t *testing.T) {
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("INSERT INTO inboxes").
-					WithArgs(testProjectID1, "test@example.com").
+					WithArgs(testProjectID1, "test@example.com", false, int64(0), int64(0)).
 					WillReturnRows(
-						sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
-							AddRow(testProjectID1, now, now),
+						sqlmock.NewRows([]string{"id", "uid_validity", "uid_next", "created_at", "updated_at"}).
+							AddRow(testProjectID1, 1700000000, 1, now, now),
 					)
 			},
 			wantErr: false,
@@ -104,7 +109,7 @@ func TestRepository_CreateInbox(t *testing.T) {
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("INSERT INTO inboxes").
-					WithArgs(testProjectID1, "existing@example.com").
+					WithArgs(testProjectID1, "existing@example.com", false, int64(0), int64(0)).
 					WillReturnError(sql.ErrConnDone)
 			},
 			wantErr: true,
@@ -126,6 +131,8 @@ func TestRepository_CreateInbox(t *testing.T) {
 
 			assert.NoError(t, err)
 			assert.NotZero(t, tt.inbox.ID)
+			assert.NotZero(t, tt.inbox.UIDValidity)
+			assert.NotZero(t, tt.inbox.UIDNext)
 			assert.NotZero(t, tt.inbox.CreatedAt)
 			assert.NotZero(t, tt.inbox.UpdatedAt)
 
@@ -298,10 +305,11 @@ func TestRepository_UpdateInbox(t *testing.T) {
 				Base:      models.Base{ID: testInboxID1},
 				ProjectID: testProjectID1,
 				Email:     "updated@example.com",
+				Usage:     models.Usage{MaxMessages: 1000, MaxBytes: 10485760},
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectExec("UPDATE inboxes").
-					WithArgs("updated@example.com", testInboxID1).
+					WithArgs("updated@example.com", false, int64(1000), int64(10485760), testInboxID1).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 			wantErr: false,
@@ -315,7 +323,7 @@ func TestRepository_UpdateInbox(t *testing.T) {
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectExec("UPDATE inboxes").
-					WithArgs("updated@example.com", testNonExistingInboxID).
+					WithArgs("updated@example.com", false, int64(0), int64(0), testNonExistingInboxID).
 					WillReturnResult(sqlmock.NewResult(0, 0))
 			},
 			wantErr: true,
@@ -494,3 +502,27 @@ func TestRepository_ListInboxesByProject(t *testing.T) {
 		})
 	}
 }
+
+func TestRepository_GetInboxQuotas(t *testing.T) {
+	testInboxID1 := test.RandomTestUUID()
+	testProjectID1 := test.RandomTestUUID()
+
+	repo, mock := setupInboxTestDB(t)
+	defer repo.db.Close()
+
+	rows := sqlmock.NewRows([]string{"kind", "id", "name", "max_messages", "max_bytes", "message_count", "storage_bytes"}).
+		AddRow("inbox", testInboxID1, "test@example.com", 100, 0, 42, 4096).
+		AddRow("project", testProjectID1, "Test Project", 0, 1048576, 420, 40960)
+	mock.ExpectQuery("SELECT (.+) UNION ALL").
+		WithArgs(testInboxID1).
+		WillReturnRows(rows)
+
+	got, err := repo.GetInboxQuotas(context.Background(), testInboxID1)
+	require.NoError(t, err)
+	assert.Equal(t, []*models.Quota{
+		{Kind: "inbox", ID: testInboxID1, Name: "test@example.com", Usage: models.Usage{MaxMessages: 100, MessageCount: 42, StorageBytes: 4096}},
+		{Kind: "project", ID: testProjectID1, Name: "Test Project", Usage: models.Usage{MaxBytes: 1048576, MessageCount: 420, StorageBytes: 40960}},
+	}, got)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
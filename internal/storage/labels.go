@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+
+	"inbox451/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (r *repository) ListLabelsByProject(ctx context.Context, projectID string, limit, offset int) ([]*models.Label, int, error) {
+	var total int
+	err := r.queries.CountLabelsByProject.GetContext(ctx, &total, projectID)
+	if err != nil {
+		return nil, 0, handleDBError(err)
+	}
+
+	labels := []*models.Label{}
+	if total > 0 {
+		err = r.queries.ListLabelsByProject.SelectContext(ctx, &labels, projectID, limit, offset)
+		if err != nil {
+			return nil, 0, handleDBError(err)
+		}
+	}
+
+	return labels, total, nil
+}
+
+func (r *repository) GetLabel(ctx context.Context, id string) (*models.Label, error) {
+	var label models.Label
+	err := r.queries.GetLabel.GetContext(ctx, &label, id)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return &label, nil
+}
+
+func (r *repository) GetLabelByName(ctx context.Context, projectID string, name string) (*models.Label, error) {
+	var label models.Label
+	err := r.queries.GetLabelByName.GetContext(ctx, &label, projectID, name)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return &label, nil
+}
+
+func (r *repository) CreateLabel(ctx context.Context, label *models.Label) error {
+	err := r.queries.CreateLabel.QueryRowContext(ctx, label.ProjectID, label.Name, label.Color).
+		Scan(&label.ID, &label.CreatedAt, &label.UpdatedAt)
+	return handleDBError(err)
+}
+
+func (r *repository) UpdateLabel(ctx context.Context, label *models.Label) error {
+	result, err := r.queries.UpdateLabel.ExecContext(ctx, label.Name, label.Color, label.ID)
+	if err != nil {
+		return handleDBError(err)
+	}
+	return handleRowsAffected(result)
+}
+
+func (r *repository) DeleteLabel(ctx context.Context, id string) error {
+	result, err := r.queries.DeleteLabel.ExecContext(ctx, id)
+	if err != nil {
+		return handleDBError(err)
+	}
+	return handleRowsAffected(result)
+}
+
+// AddMessageLabel assigns a label to a message. Assigning it twice is a no-op.
+func (r *repository) AddMessageLabel(ctx context.Context, messageID string, labelID string) error {
+	_, err := r.queries.AddMessageLabel.ExecContext(ctx, messageID, labelID)
+	return handleDBError(err)
+}
+
+func (r *repository) RemoveMessageLabel(ctx context.Context, messageID string, labelID string) error {
+	result, err := r.queries.RemoveMessageLabel.ExecContext(ctx, messageID, labelID)
+	if err != nil {
+		return handleDBError(err)
+	}
+	return handleRowsAffected(result)
+}
+
+// ListLabelsByMessages returns the labels of all given messages in one query
+func (r *repository) ListLabelsByMessages(ctx context.Context, messageIDs []string) ([]*models.MessageLabel, error) {
+	labels := []*models.MessageLabel{}
+	if len(messageIDs) == 0 {
+		return labels, nil
+	}
+
+	err := r.queries.ListLabelsByMessages.SelectContext(ctx, &labels, pq.Array(messageIDs))
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return labels, nil
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"inbox451/internal/test"
+
+	"inbox451/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLabelTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectPrepare("SELECT (.+) FROM labels WHERE project_id = (.+) AND name") // GetLabelByName
+	mock.ExpectPrepare("INSERT INTO labels")                                       // CreateLabel
+	mock.ExpectPrepare("INSERT INTO message_labels")                               // AddMessageLabel
+	mock.ExpectPrepare("DELETE FROM message_labels")                               // RemoveMessageLabel
+	mock.ExpectPrepare("SELECT (.+) FROM message_labels")                          // ListLabelsByMessages
+
+	getLabelByName, err := sqlxDB.Preparex("SELECT id, project_id, name, color, created_at, updated_at FROM labels WHERE project_id = ? AND name = ?")
+	require.NoError(t, err)
+
+	createLabel, err := sqlxDB.Preparex("INSERT INTO labels (project_id, name, color) VALUES (?, ?, ?)")
+	require.NoError(t, err)
+
+	addMessageLabel, err := sqlxDB.Preparex("INSERT INTO message_labels (message_id, label_id) VALUES (?, ?)")
+	require.NoError(t, err)
+
+	removeMessageLabel, err := sqlxDB.Preparex("DELETE FROM message_labels WHERE message_id = ? AND label_id = ?")
+	require.NoError(t, err)
+
+	listLabelsByMessages, err := sqlxDB.Preparex("SELECT ml.message_id, l.id, l.project_id, l.name, l.color, l.created_at, l.updated_at FROM message_labels ml WHERE ml.message_id = ANY(?)")
+	require.NoError(t, err)
+
+	queries := &Queries{
+		GetLabelByName:       getLabelByName,
+		CreateLabel:          createLabel,
+		AddMessageLabel:      addMessageLabel,
+		RemoveMessageLabel:   removeMessageLabel,
+		ListLabelsByMessages: listLabelsByMessages,
+	}
+
+	repo := &repository{
+		db:      sqlxDB,
+		queries: queries,
+	}
+
+	return repo, mock
+}
+
+func TestRepository_CreateLabel(t *testing.T) {
+	now := time.Now()
+	testProjectID := test.RandomTestUUID()
+	testLabelID := test.RandomTestUUID()
+
+	repo, mock := setupLabelTestDB(t)
+	mock.ExpectQuery("INSERT INTO labels").
+		WithArgs(testProjectID, "Important", "#ff0000").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+				AddRow(testLabelID, now, now),
+		)
+
+	label := &models.Label{ProjectID: testProjectID, Name: "Important", Color: "#ff0000"}
+	err := repo.CreateLabel(context.Background(), label)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testLabelID, label.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetLabelByName(t *testing.T) {
+	now := time.Now()
+	testProjectID := test.RandomTestUUID()
+	testLabelID := test.RandomTestUUID()
+
+	tests := []struct {
+		name    string
+		mockFn  func(sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "existing label",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM labels").
+					WithArgs(testProjectID, "Important").
+					WillReturnRows(
+						sqlmock.NewRows([]string{"id", "project_id", "name", "color", "created_at", "updated_at"}).
+							AddRow(testLabelID, testProjectID, "Important", "#ff0000", now, now),
+					)
+			},
+		},
+		{
+			name: "label not found",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM labels").
+					WithArgs(testProjectID, "Important").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupLabelTestDB(t)
+			tt.mockFn(mock)
+
+			label, err := repo.GetLabelByName(context.Background(), testProjectID, "Important")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, label)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testLabelID, label.ID)
+				assert.Equal(t, "#ff0000", label.Color)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_MessageLabels(t *testing.T) {
+	now := time.Now()
+	testMessageID := test.RandomTestUUID()
+	testLabelID := test.RandomTestUUID()
+	testProjectID := test.RandomTestUUID()
+
+	repo, mock := setupLabelTestDB(t)
+	mock.ExpectExec("INSERT INTO message_labels").
+		WithArgs(testMessageID, testLabelID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT (.+) FROM message_labels").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"message_id", "id", "project_id", "name", "color", "created_at", "updated_at"}).
+				AddRow(testMessageID, testLabelID, testProjectID, "Important", "#ff0000", now, now),
+		)
+	mock.ExpectExec("DELETE FROM message_labels").
+		WithArgs(testMessageID, testLabelID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.AddMessageLabel(context.Background(), testMessageID, testLabelID)
+	assert.NoError(t, err)
+
+	labels, err := repo.ListLabelsByMessages(context.Background(), []string{testMessageID})
+	assert.NoError(t, err)
+	if assert.Len(t, labels, 1) {
+		assert.Equal(t, testMessageID, labels[0].MessageID)
+		assert.Equal(t, "Important", labels[0].Name)
+	}
+
+	err = repo.RemoveMessageLabel(context.Background(), testMessageID, testLabelID)
+	assert.ErrorIs(t, err, ErrNoRowsAffected)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
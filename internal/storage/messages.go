@@ -9,9 +9,20 @@ import (
 )
 
 func (r *repository) CreateMessage(ctx context.Context, message *models.Message) error {
+	references := message.References
+	if references == nil {
+		references = pq.StringArray{}
+	}
+	dnsblHits := message.DNSBLHits
+	if dnsblHits == nil {
+		dnsblHits = pq.StringArray{}
+	}
 	err := r.queries.CreateMessage.QueryRowContext(ctx,
-		message.InboxID, message.Sender, message.Receiver, message.Subject, message.Body).
-		Scan(&message.ID, &message.CreatedAt, &message.UpdatedAt, &message.UID)
+		message.InboxID, message.Sender, message.Receiver, message.Subject, message.Body,
+		message.FolderID.String, message.IsRead, message.Raw,
+		message.IsDeleted, message.IsFlagged, message.IsAnswered, message.IsDraft, message.CreatedAt,
+		message.ThreadID, message.HeaderMessageID, references, message.BaseSubject, dnsblHits, message.Size).
+		Scan(&message.ID, &message.CreatedAt, &message.UpdatedAt, &message.UID, &message.ThreadID)
 	return handleDBError(err)
 }
 
@@ -97,10 +108,73 @@ func (r *repository) UpdateMessageDeletedStatus(ctx context.Context, messageID s
 	return handleRowsAffected(result)
 }
 
-// ListMessagesByInboxWithFilters returns messages with both read and deleted filters
+// UpdateMessageFlags applies a flag update to the messages with the given UIDs
+// in a single transaction. Keywords are stored as message labels.
+func (r *repository) UpdateMessageFlags(ctx context.Context, inboxID string, folderID string, uids []uint32, op models.FlagOperation, flags models.MessageFlags) error {
+	if len(uids) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return handleDBError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	args := []any{inboxID, pq.Array(uids)}
+	for _, present := range []bool{flags.Seen, flags.Answered, flags.Flagged, flags.Deleted, flags.Draft} {
+		update, value := flagUpdate(op, present)
+		args = append(args, update, value)
+	}
+	args = append(args, folderID, flags.UnchangedSince)
+
+	var messageIDs []string
+	if err := tx.StmtxContext(ctx, r.queries.UpdateMessageFlags).SelectContext(ctx, &messageIDs, args...); err != nil {
+		return handleDBError(err)
+	}
+
+	// An empty array rather than NULL, so that setting no keywords clears all labels
+	labels := flags.LabelIDs
+	if labels == nil {
+		labels = []string{}
+	}
+	ids, labelIDs := pq.Array(messageIDs), pq.Array(labels)
+	switch op {
+	case models.FlagsAdd:
+		_, err = tx.StmtxContext(ctx, r.queries.AddMessagesLabels).ExecContext(ctx, ids, labelIDs)
+	case models.FlagsRemove:
+		_, err = tx.StmtxContext(ctx, r.queries.RemoveMessagesLabels).ExecContext(ctx, ids, labelIDs)
+	case models.FlagsSet:
+		_, err = tx.StmtxContext(ctx, r.queries.RemoveMessagesLabelsExcept).ExecContext(ctx, ids, labelIDs)
+		if err == nil {
+			_, err = tx.StmtxContext(ctx, r.queries.AddMessagesLabels).ExecContext(ctx, ids, labelIDs)
+		}
+	}
+	if err != nil {
+		return handleDBError(err)
+	}
+
+	return handleDBError(tx.Commit())
+}
+
+// flagUpdate returns whether a flag column is changed by the operation and its new value
+func flagUpdate(op models.FlagOperation, present bool) (update bool, value bool) {
+	switch op {
+	case models.FlagsSet:
+		return true, present
+	case models.FlagsRemove:
+		return present, false
+	default:
+		return present, true
+	}
+}
+
+// ListMessagesByInboxWithFilters returns messages with read, deleted and label filters
 func (r *repository) ListMessagesByInboxWithFilters(ctx context.Context, inboxID string, filters models.MessageFilters, limit, offset int) ([]*models.Message, int, error) {
 	var total int
-	err := r.queries.CountMessagesByInboxWithFilters.GetContext(ctx, &total, inboxID, filters.IsRead, filters.IsDeleted)
+	err := r.queries.CountMessagesByInboxWithFilters.GetContext(ctx, &total, inboxID, filters.IsRead, filters.IsDeleted, filters.LabelID, filters.FolderID)
 	if err != nil {
 		return nil, 0, handleDBError(err)
 	}
@@ -108,7 +182,7 @@ func (r *repository) ListMessagesByInboxWithFilters(ctx context.Context, inboxID
 	messages := []*models.Message{}
 
 	if total > 0 {
-		err = r.queries.ListMessagesByInboxWithFilters.SelectContext(ctx, &messages, inboxID, filters.IsRead, filters.IsDeleted, limit, offset)
+		err = r.queries.ListMessagesByInboxWithFilters.SelectContext(ctx, &messages, inboxID, filters.IsRead, filters.IsDeleted, filters.LabelID, filters.FolderID, limit, offset)
 		if err != nil {
 			return nil, 0, handleDBError(err)
 		}
@@ -118,13 +192,13 @@ func (r *repository) ListMessagesByInboxWithFilters(ctx context.Context, inboxID
 }
 
 // GetMessagesByUIDs returns messages by their IDs (UIDs in IMAP context)
-func (r *repository) GetMessagesByUIDs(ctx context.Context, inboxID string, uids []uint32) ([]*models.Message, error) {
+func (r *repository) GetMessagesByUIDs(ctx context.Context, inboxID string, folderID string, uids []uint32) ([]*models.Message, error) {
 	if len(uids) == 0 {
 		return []*models.Message{}, nil
 	}
 
 	var messages []*models.Message
-	err := r.queries.GetMessagesByUIDs.SelectContext(ctx, &messages, inboxID, pq.Array(uids))
+	err := r.queries.GetMessagesByUIDs.SelectContext(ctx, &messages, inboxID, folderID, pq.Array(uids))
 	if err != nil {
 		return nil, handleDBError(err)
 	}
@@ -132,10 +206,11 @@ func (r *repository) GetMessagesByUIDs(ctx context.Context, inboxID string, uids
 	return messages, nil
 }
 
-// GetAllMessageUIDsForInbox returns all message IDs for an inbox (excluding deleted)
-func (r *repository) GetAllMessageUIDsForInbox(ctx context.Context, inboxID string) ([]uint32, error) {
+// GetAllMessageUIDsForInbox returns all message IDs for an inbox (excluding deleted).
+// Messages are taken from the given folder, or from the inbox itself when folderID is empty.
+func (r *repository) GetAllMessageUIDsForInbox(ctx context.Context, inboxID string, folderID string) ([]uint32, error) {
 	var uids []uint32
-	err := r.queries.GetAllMessageUIDsForInbox.SelectContext(ctx, &uids, inboxID)
+	err := r.queries.GetAllMessageUIDsForInbox.SelectContext(ctx, &uids, inboxID, folderID)
 	if err != nil {
 		return nil, handleDBError(err)
 	}
@@ -145,9 +220,9 @@ func (r *repository) GetAllMessageUIDsForInbox(ctx context.Context, inboxID stri
 
 // GetAllMessageUIDsForInboxIncludingDeleted returns all message IDs for an inbox (including deleted)
 // This is used for IMAP sequence number mapping where deleted messages are still addressable until expunged
-func (r *repository) GetAllMessageUIDsForInboxIncludingDeleted(ctx context.Context, inboxID string) ([]uint32, error) {
+func (r *repository) GetAllMessageUIDsForInboxIncludingDeleted(ctx context.Context, inboxID string, folderID string) ([]uint32, error) {
 	var uids []uint32
-	err := r.queries.GetAllMessageUIDsForInboxIncludingDeleted.SelectContext(ctx, &uids, inboxID)
+	err := r.queries.GetAllMessageUIDsForInboxIncludingDeleted.SelectContext(ctx, &uids, inboxID, folderID)
 	if err != nil {
 		return nil, handleDBError(err)
 	}
@@ -155,15 +230,174 @@ func (r *repository) GetAllMessageUIDsForInboxIncludingDeleted(ctx context.Conte
 	return uids, nil
 }
 
-// GetMaxMessageUID returns the highest message UID in an inbox
-func (r *repository) GetMaxMessageUID(ctx context.Context, inboxID string) (uint32, error) {
+// GetMaxMessageUID returns the highest message UID in an inbox or one of its folders
+func (r *repository) GetMaxMessageUID(ctx context.Context, inboxID string, folderID string) (uint32, error) {
 	var maxUID uint32
-	err := r.queries.GetMaxMessageUID.GetContext(ctx, &maxUID, inboxID)
+	err := r.queries.GetMaxMessageUID.GetContext(ctx, &maxUID, inboxID, folderID)
 	return maxUID, handleDBError(err)
 }
 
-func (r *repository) GetMessageIDFromUID(ctx context.Context, inboxID string, uid uint32) (string, error) {
+// GetHighestModSeq returns the highest mod-sequence of an inbox or one of its folders,
+// including the removal of messages (RFC 7162 HIGHESTMODSEQ)
+func (r *repository) GetHighestModSeq(ctx context.Context, inboxID string, folderID string) (uint64, error) {
+	var modSeq uint64
+	err := r.queries.GetHighestModSeq.GetContext(ctx, &modSeq, inboxID, folderID)
+	return modSeq, handleDBError(err)
+}
+
+// ListExpungedMessageUIDs returns the UIDs of the messages removed from an inbox or
+// one of its folders after the given mod-sequence, in UID order
+func (r *repository) ListExpungedMessageUIDs(ctx context.Context, inboxID string, folderID string, sinceModSeq uint64) ([]uint32, error) {
+	uids := []uint32{}
+	err := r.queries.ListExpungedMessageUIDs.SelectContext(ctx, &uids, inboxID, folderID, sinceModSeq)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return uids, nil
+}
+
+// GetHighestModSeqForInboxes returns the mod-sequence of the last change to any
+// message of the given inboxes and their folders, removals included
+func (r *repository) GetHighestModSeqForInboxes(ctx context.Context, inboxIDs []string) (uint64, error) {
+	var modSeq uint64
+	err := r.queries.GetHighestModSeqForInboxes.GetContext(ctx, &modSeq, pq.Array(inboxIDs))
+	return modSeq, handleDBError(err)
+}
+
+// ListMessageChanges returns up to limit messages of the given inboxes that were
+// created, updated or removed after the given mod-sequence, oldest change first
+func (r *repository) ListMessageChanges(ctx context.Context, inboxIDs []string, sinceModSeq uint64, limit int) ([]*models.MessageChange, error) {
+	changes := []*models.MessageChange{}
+	err := r.queries.ListMessageChanges.SelectContext(ctx, &changes, pq.Array(inboxIDs), sinceModSeq, limit)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return changes, nil
+}
+
+// CountMessagesByMailbox returns the number of messages in each of the given
+// inboxes and their folders. Empty mailboxes are left out.
+func (r *repository) CountMessagesByMailbox(ctx context.Context, inboxIDs []string) ([]*models.MailboxCount, error) {
+	counts := []*models.MailboxCount{}
+	err := r.queries.CountMessagesByMailbox.SelectContext(ctx, &counts, pq.Array(inboxIDs))
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return counts, nil
+}
+
+// ListMessagesByIDs returns the messages with the given IDs. Unknown IDs are left out.
+func (r *repository) ListMessagesByIDs(ctx context.Context, messageIDs []string) ([]*models.Message, error) {
+	messages := []*models.Message{}
+	if len(messageIDs) == 0 {
+		return messages, nil
+	}
+
+	err := r.queries.ListMessagesByIDs.SelectContext(ctx, &messages, pq.Array(messageIDs))
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return messages, nil
+}
+
+// ListRawMessages returns the raw RFC 822 form of the given messages. Only the
+// ID and Raw fields are set, and messages stored without it are left out.
+func (r *repository) ListRawMessages(ctx context.Context, messageIDs []string) ([]*models.Message, error) {
+	messages := []*models.Message{}
+	if len(messageIDs) == 0 {
+		return messages, nil
+	}
+
+	err := r.queries.ListRawMessages.SelectContext(ctx, &messages, pq.Array(messageIDs))
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return messages, nil
+}
+
+func (r *repository) GetMessageIDFromUID(ctx context.Context, inboxID string, folderID string, uid uint32) (string, error) {
 	var messageID string
-	err := r.queries.GetMessageIDFromUID.GetContext(ctx, &messageID, inboxID, uid)
+	err := r.queries.GetMessageIDFromUID.GetContext(ctx, &messageID, inboxID, folderID, uid)
 	return messageID, handleDBError(err)
 }
+
+// CopyMessages copies the messages with the given UIDs into another inbox or folder
+// in a single transaction and returns the UIDs of the copies, in UID order.
+func (r *repository) CopyMessages(ctx context.Context, inboxID string, folderID string, uids []uint32, destInboxID string, destFolderID string) ([]uint32, error) {
+	return r.transferMessages(ctx, inboxID, folderID, uids, destInboxID, destFolderID, false)
+}
+
+// MoveMessages moves the messages with the given UIDs into another inbox or folder
+// in a single transaction and returns their new UIDs, in UID order.
+func (r *repository) MoveMessages(ctx context.Context, inboxID string, folderID string, uids []uint32, destInboxID string, destFolderID string) ([]uint32, error) {
+	return r.transferMessages(ctx, inboxID, folderID, uids, destInboxID, destFolderID, true)
+}
+
+func (r *repository) transferMessages(ctx context.Context, inboxID string, folderID string, uids []uint32, destInboxID string, destFolderID string, move bool) ([]uint32, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var messages []*models.Message
+	if err := tx.StmtxContext(ctx, r.queries.GetMessagesByUIDs).SelectContext(ctx, &messages, inboxID, folderID, pq.Array(uids)); err != nil {
+		return nil, handleDBError(err)
+	}
+	if len(messages) == 0 {
+		return []uint32{}, nil
+	}
+
+	// Folders hand out UIDs from their own sequence, messages in the inbox itself from the inbox's
+	var first uint32
+	reserve := tx.StmtxContext(ctx, r.queries.ReserveInboxUIDs)
+	reserveID := destInboxID
+	if destFolderID != "" {
+		reserve = tx.StmtxContext(ctx, r.queries.ReserveFolderUIDs)
+		reserveID = destFolderID
+	}
+	if err := reserve.GetContext(ctx, &first, reserveID, len(messages)); err != nil {
+		return nil, handleDBError(err)
+	}
+
+	destUIDs := make([]uint32, 0, len(messages))
+	for _, message := range messages {
+		uid := first + uint32(len(destUIDs))
+
+		var destUID uint32
+		if move {
+			if err := tx.StmtxContext(ctx, r.queries.MoveMessage).GetContext(ctx, &destUID, message.ID, destInboxID, destFolderID, uid); err != nil {
+				return nil, handleDBError(err)
+			}
+			if _, err := tx.StmtxContext(ctx, r.queries.RemoveForeignMessageLabels).ExecContext(ctx, message.ID, destInboxID); err != nil {
+				return nil, handleDBError(err)
+			}
+		} else {
+			var copyID string
+			if err := tx.StmtxContext(ctx, r.queries.CopyMessage).QueryRowxContext(ctx, message.ID, destInboxID, destFolderID, uid).Scan(&copyID, &destUID); err != nil {
+				return nil, handleDBError(err)
+			}
+			if _, err := tx.StmtxContext(ctx, r.queries.CopyMessageLabels).ExecContext(ctx, copyID, message.ID, destInboxID); err != nil {
+				return nil, handleDBError(err)
+			}
+		}
+		destUIDs = append(destUIDs, destUID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, handleDBError(err)
+	}
+	return destUIDs, nil
+}
+
+// ListRecentMessagesByInbox returns the newest non-deleted messages of an inbox, newest first
+func (r *repository) ListRecentMessagesByInbox(ctx context.Context, inboxID string, limit int) ([]*models.Message, error) {
+	messages := []*models.Message{}
+	err := r.queries.ListRecentMessagesByInbox.SelectContext(ctx, &messages, inboxID, limit)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return messages, nil
+}
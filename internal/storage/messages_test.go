@@ -78,6 +78,10 @@ func setupMessageTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
 func TestRepository_CreateMessage(t *testing.T) {
 	now := time.Now()
 	testInboxID1 := test.RandomTestUUID()
+	testFolderID := test.RandomTestUUID()
+	testThreadID := test.RandomTestUUID()
+	internalDate := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	raw := "Subject: Test Subject\r\n\r\nTest Body"
 
 	tests := []struct {
 		name    string
@@ -93,6 +97,7 @@ func TestRepository_CreateMessage(t *testing.T) {
 				Receiver: "receiver@example.com",
 				Subject:  "Test Subject",
 				Body:     "Test Body",
+				Size:     9,
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("INSERT INTO messages").
@@ -102,10 +107,59 @@ func TestRepository_CreateMessage(t *testing.T) {
 						"receiver@example.com",
 						"Test Subject",
 						"Test Body",
+						"",
+						false,
+						[]byte(nil),
+						false, false, false, false,
+						null.Time{},
+						"", "", "{}", "", "{}", int64(9),
 					).
 					WillReturnRows(
-						sqlmock.NewRows([]string{"id", "created_at", "updated_at", "uid"}).
-							AddRow(testInboxID1, now, now, 1),
+						sqlmock.NewRows([]string{"id", "created_at", "updated_at", "uid", "thread_id"}).
+							AddRow(testInboxID1, now, now, 1, testInboxID1),
+					)
+			},
+			wantErr: false,
+		},
+		{
+			name: "stored in folder",
+			message: &models.Message{
+				InboxID:         testInboxID1,
+				Base:            models.Base{CreatedAt: null.TimeFrom(internalDate)},
+				FolderID:        null.StringFrom(testFolderID),
+				Sender:          "sender@example.com",
+				Receiver:        "receiver@example.com",
+				Subject:         "Test Subject",
+				Body:            "Test Body",
+				Raw:             []byte(raw),
+				IsRead:          true,
+				IsFlagged:       true,
+				ThreadID:        testThreadID,
+				HeaderMessageID: "reply@example.com",
+				References:      []string{"original@example.com"},
+				BaseSubject:     "test subject",
+				DNSBLHits:       []string{"zen.spamhaus.org"},
+				Size:            int64(len(raw)),
+			},
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO messages").
+					WithArgs(
+						testInboxID1,
+						"sender@example.com",
+						"receiver@example.com",
+						"Test Subject",
+						"Test Body",
+						testFolderID,
+						true,
+						[]byte(raw),
+						false, true, false, false,
+						null.TimeFrom(internalDate),
+						testThreadID, "reply@example.com", "{\"original@example.com\"}", "test subject",
+						"{\"zen.spamhaus.org\"}", int64(len(raw)),
+					).
+					WillReturnRows(
+						sqlmock.NewRows([]string{"id", "created_at", "updated_at", "uid", "thread_id"}).
+							AddRow(testInboxID1, now, now, 1, testThreadID),
 					)
 			},
 			wantErr: false,
@@ -118,6 +172,7 @@ func TestRepository_CreateMessage(t *testing.T) {
 				Receiver: "receiver@example.com",
 				Subject:  "Test Subject",
 				Body:     "Test Body",
+				Size:     9,
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("INSERT INTO messages").
@@ -127,6 +182,12 @@ func TestRepository_CreateMessage(t *testing.T) {
 						"receiver@example.com",
 						"Test Subject",
 						"Test Body",
+						"",
+						false,
+						[]byte(nil),
+						false, false, false, false,
+						null.Time{},
+						"", "", "{}", "", "{}", int64(9),
 					).
 					WillReturnError(sql.ErrConnDone)
 			},
@@ -152,6 +213,7 @@ func TestRepository_CreateMessage(t *testing.T) {
 			assert.NotZero(t, tt.message.CreatedAt)
 			assert.NotZero(t, tt.message.UpdatedAt)
 			assert.NotZero(t, tt.message.UID)
+			assert.NotZero(t, tt.message.ThreadID)
 
 			err = mock.ExpectationsWereMet()
 			assert.NoError(t, err)
@@ -729,3 +791,128 @@ func TestRepository_ListMessagesByInbox(t *testing.T) {
 		})
 	}
 }
+
+func setupMessageFlagsTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectPrepare("UPDATE messages SET")                                    // UpdateMessageFlags
+	mock.ExpectPrepare("INSERT INTO message_labels")                             // AddMessagesLabels
+	mock.ExpectPrepare("DELETE FROM message_labels WHERE (.+) AND label_id")     // RemoveMessagesLabels
+	mock.ExpectPrepare("DELETE FROM message_labels WHERE (.+) AND NOT label_id") // RemoveMessagesLabelsExcept
+
+	updateMessageFlags, err := sqlxDB.Preparex("UPDATE messages SET is_read = ?, is_answered = ?, is_flagged = ?, is_deleted = ?, is_draft = ? WHERE inbox_id = ? AND folder_id = ? AND uid = ANY(?) RETURNING id")
+	require.NoError(t, err)
+
+	addMessagesLabels, err := sqlxDB.Preparex("INSERT INTO message_labels (message_id, label_id) VALUES (?, ?)")
+	require.NoError(t, err)
+
+	removeMessagesLabels, err := sqlxDB.Preparex("DELETE FROM message_labels WHERE message_id = ANY(?) AND label_id = ANY(?)")
+	require.NoError(t, err)
+
+	removeMessagesLabelsExcept, err := sqlxDB.Preparex("DELETE FROM message_labels WHERE message_id = ANY(?) AND NOT label_id = ANY(?)")
+	require.NoError(t, err)
+
+	queries := &Queries{
+		UpdateMessageFlags:         updateMessageFlags,
+		AddMessagesLabels:          addMessagesLabels,
+		RemoveMessagesLabels:       removeMessagesLabels,
+		RemoveMessagesLabelsExcept: removeMessagesLabelsExcept,
+	}
+
+	repo := &repository{
+		db:      sqlxDB,
+		queries: queries,
+	}
+
+	return repo, mock
+}
+
+func TestRepository_UpdateMessageFlags(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	testMessageID := test.RandomTestUUID()
+	testLabelID := test.RandomTestUUID()
+
+	tests := []struct {
+		name    string
+		op      models.FlagOperation
+		flags   models.MessageFlags
+		mockFn  func(sqlmock.Sqlmock)
+		wantErr bool
+	}{
+		{
+			name:  "add flags and keywords",
+			op:    models.FlagsAdd,
+			flags: models.MessageFlags{Seen: true, Flagged: true, LabelIDs: []string{testLabelID}},
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE messages SET").
+					WithArgs(testInboxID, sqlmock.AnyArg(), true, true, false, true, true, true, false, true, false, true, "", uint64(0)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testMessageID))
+				mock.ExpectExec("INSERT INTO message_labels").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:  "set flags replaces keywords",
+			op:    models.FlagsSet,
+			flags: models.MessageFlags{Answered: true},
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE messages SET").
+					WithArgs(testInboxID, sqlmock.AnyArg(), true, false, true, true, true, false, true, false, true, false, "", uint64(0)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testMessageID))
+				mock.ExpectExec("DELETE FROM message_labels WHERE (.+) AND NOT label_id").
+					WithArgs(sqlmock.AnyArg(), "{}").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("INSERT INTO message_labels").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:  "unchanged since limits the update",
+			op:    models.FlagsAdd,
+			flags: models.MessageFlags{Seen: true, UnchangedSince: 42},
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE messages SET").
+					WithArgs(testInboxID, sqlmock.AnyArg(), true, true, false, true, false, true, false, true, false, true, "", uint64(42)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec("INSERT INTO message_labels").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:  "database error rolls back",
+			op:    models.FlagsRemove,
+			flags: models.MessageFlags{Deleted: true},
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE messages SET").
+					WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupMessageFlagsTestDB(t)
+			tt.mockFn(mock)
+
+			err := repo.UpdateMessageFlags(context.Background(), testInboxID, "", []uint32{1, 2}, tt.op, tt.flags)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
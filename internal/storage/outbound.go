@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"inbox451/internal/models"
+)
+
+func (r *repository) EnqueueOutboundMessage(ctx context.Context, message *models.OutboundMessage) error {
+	err := r.queries.EnqueueOutboundMessage.QueryRowContext(ctx, message.UserID, message.MailFrom, message.Recipients, message.Raw).
+		Scan(&message.ID, &message.Status, &message.NextAttemptAt, &message.CreatedAt, &message.UpdatedAt)
+	return handleDBError(err)
+}
+
+// ClaimOutboundMessages returns up to limit messages that are due for delivery and
+// leases them for the given duration. Each claim counts as a delivery attempt.
+func (r *repository) ClaimOutboundMessages(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboundMessage, error) {
+	messages := []*models.OutboundMessage{}
+	err := r.queries.ClaimOutboundMessages.SelectContext(ctx, &messages, limit, lease.Seconds())
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return messages, nil
+}
+
+// UpdateOutboundMessage records the outcome of a delivery attempt
+func (r *repository) UpdateOutboundMessage(ctx context.Context, message *models.OutboundMessage) error {
+	result, err := r.queries.UpdateOutboundMessage.ExecContext(ctx, message.ID, message.Status, message.NextAttemptAt, message.LastError)
+	if err != nil {
+		return handleDBError(err)
+	}
+	return handleRowsAffected(result)
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"inbox451/internal/models"
+	"inbox451/internal/test"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	null "github.com/volatiletech/null/v9"
+)
+
+func setupOutboundTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectPrepare("INSERT INTO outbound_messages") // EnqueueOutboundMessage
+	mock.ExpectPrepare("WITH due AS")                   // ClaimOutboundMessages
+	mock.ExpectPrepare("UPDATE outbound_messages SET")  // UpdateOutboundMessage
+
+	enqueue, err := sqlxDB.Preparex("INSERT INTO outbound_messages (user_id, mail_from, recipients, raw) VALUES (?, ?, ?, ?) RETURNING id, status, next_attempt_at, created_at, updated_at")
+	require.NoError(t, err)
+
+	claim, err := sqlxDB.Preparex("WITH due AS (SELECT id FROM outbound_messages LIMIT ?) UPDATE outbound_messages SET next_attempt_at = NOW() + ? RETURNING *")
+	require.NoError(t, err)
+
+	update, err := sqlxDB.Preparex("UPDATE outbound_messages SET status = ?, next_attempt_at = ?, last_error = ? WHERE id = ?")
+	require.NoError(t, err)
+
+	queries := &Queries{
+		EnqueueOutboundMessage: enqueue,
+		ClaimOutboundMessages:  claim,
+		UpdateOutboundMessage:  update,
+	}
+
+	repo := &repository{
+		db:      sqlxDB,
+		queries: queries,
+	}
+
+	return repo, mock
+}
+
+func TestRepository_EnqueueOutboundMessage(t *testing.T) {
+	testUserID := test.RandomTestUUID()
+	testMessageID := test.RandomTestUUID()
+	now := time.Now()
+
+	repo, mock := setupOutboundTestDB(t)
+	defer repo.db.Close()
+
+	raw := []byte("Subject: hi\r\n\r\nhello\r\n")
+	mock.ExpectQuery("INSERT INTO outbound_messages").
+		WithArgs(testUserID, "support@example.com", "{\"alice@remote.test\"}", raw).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "next_attempt_at", "created_at", "updated_at"}).
+			AddRow(testMessageID, "queued", now, now, now))
+
+	message := &models.OutboundMessage{
+		UserID:     null.StringFrom(testUserID),
+		MailFrom:   "support@example.com",
+		Recipients: pq.StringArray{"alice@remote.test"},
+		Raw:        raw,
+	}
+	require.NoError(t, repo.EnqueueOutboundMessage(context.Background(), message))
+	assert.Equal(t, testMessageID, message.ID)
+	assert.Equal(t, "queued", message.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ClaimOutboundMessages(t *testing.T) {
+	testMessageID := test.RandomTestUUID()
+
+	repo, mock := setupOutboundTestDB(t)
+	defer repo.db.Close()
+
+	mock.ExpectQuery("WITH due AS").
+		WithArgs(10, float64(300)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "mail_from", "recipients", "raw", "status", "attempts", "next_attempt_at", "last_error", "created_at", "updated_at"}).
+			AddRow(testMessageID, nil, "support@example.com", "{alice@remote.test,bob@remote.test}", []byte("raw"), "queued", 2, nil, "", nil, nil))
+
+	messages, err := repo.ClaimOutboundMessages(context.Background(), 10, 5*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, []string{"alice@remote.test", "bob@remote.test"}, []string(messages[0].Recipients))
+	assert.Equal(t, 2, messages[0].Attempts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_UpdateOutboundMessage(t *testing.T) {
+	testMessageID := test.RandomTestUUID()
+	next := time.Now().Add(time.Minute)
+
+	repo, mock := setupOutboundTestDB(t)
+	defer repo.db.Close()
+
+	mock.ExpectExec("UPDATE outbound_messages").
+		WithArgs(testMessageID, "queued", next, "421 try again later").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.UpdateOutboundMessage(context.Background(), &models.OutboundMessage{
+		Base:          models.Base{ID: testMessageID},
+		Status:        "queued",
+		NextAttemptAt: null.TimeFrom(next),
+		LastError:     "421 try again later",
+	})
+	assert.ErrorIs(t, err, ErrNoRowsAffected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
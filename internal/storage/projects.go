@@ -49,13 +49,13 @@ func (r *repository) GetProject(ctx context.Context, id string) (*models.Project
 }
 
 func (r *repository) CreateProject(ctx context.Context, project *models.Project) error {
-	err := r.queries.CreateProject.QueryRowContext(ctx, project.Name).
+	err := r.queries.CreateProject.QueryRowContext(ctx, project.Name, project.MaxMessages, project.MaxBytes).
 		Scan(&project.ID, &project.CreatedAt, &project.UpdatedAt)
 	return handleDBError(err)
 }
 
 func (r *repository) UpdateProject(ctx context.Context, project *models.Project) error {
-	err := r.queries.UpdateProject.QueryRowContext(ctx, project.Name, project.ID).
+	err := r.queries.UpdateProject.QueryRowContext(ctx, project.Name, project.MaxMessages, project.MaxBytes, project.ID).
 		Scan(&project.UpdatedAt)
 	return handleDBError(err)
 }
@@ -102,7 +102,7 @@ func TestRepository_CreateProject(t *testing.T) {
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("INSERT INTO projects").
-					WithArgs("Test Project").
+					WithArgs("Test Project", int64(0), int64(0)).
 					WillReturnRows(
 						sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
 							AddRow(testProjectID1, now, now),
@@ -117,7 +117,7 @@ func TestRepository_CreateProject(t *testing.T) {
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("INSERT INTO projects").
-					WithArgs("Test Project").
+					WithArgs("Test Project", int64(0), int64(0)).
 					WillReturnError(sql.ErrConnDone)
 			},
 			wantErr: true,
@@ -236,12 +236,13 @@ func TestRepository_UpdateProject(t *testing.T) {
 		{
 			name: "successful update",
 			project: &models.Project{
-				Base: models.Base{ID: testProjectID1},
-				Name: "Updated Project",
+				Base:  models.Base{ID: testProjectID1},
+				Name:  "Updated Project",
+				Usage: models.Usage{MaxMessages: 5000},
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("UPDATE projects").
-					WithArgs("Updated Project", testProjectID1).
+					WithArgs("Updated Project", int64(5000), int64(0), testProjectID1).
 					WillReturnRows(
 						sqlmock.NewRows([]string{"updated_at"}).
 							AddRow(now),
@@ -257,7 +258,7 @@ func TestRepository_UpdateProject(t *testing.T) {
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("UPDATE projects").
-					WithArgs("Updated Project", nonExistingProjectID).
+					WithArgs("Updated Project", int64(0), int64(0), nonExistingProjectID).
 					WillReturnError(sql.ErrNoRows)
 			},
 			wantErr: true,
@@ -35,6 +35,7 @@ type Queries struct {
 	ListInboxesByProject  *sqlx.Stmt `query:"list-inboxes-by-project"`
 	CountInboxesByProject *sqlx.Stmt `query:"count-inboxes-by-project"`
 	GetInboxByEmail       *sqlx.Stmt `query:"get-inbox-by-email"`
+	GetInboxQuotas        *sqlx.Stmt `query:"get-inbox-quotas"`
 
 	// Rule queries
 	CreateRule        *sqlx.Stmt `query:"create-rule"`
@@ -46,6 +47,79 @@ type Queries struct {
 	ListRules         *sqlx.Stmt `query:"list-rules"`
 	CountRules        *sqlx.Stmt `query:"count-rules"`
 
+	// Folder queries
+	ListFoldersByInbox    *sqlx.Stmt `query:"list-folders-by-inbox"`
+	CountFoldersByInbox   *sqlx.Stmt `query:"count-folders-by-inbox"`
+	GetFolder             *sqlx.Stmt `query:"get-folder"`
+	GetFolderByName       *sqlx.Stmt `query:"get-folder-by-name"`
+	GetFolderBySpecialUse *sqlx.Stmt `query:"get-folder-by-special-use"`
+	CreateFolder          *sqlx.Stmt `query:"create-folder"`
+	UpdateFolder          *sqlx.Stmt `query:"update-folder"`
+	DeleteFolder          *sqlx.Stmt `query:"delete-folder"`
+	ReserveFolderUIDs     *sqlx.Stmt `query:"reserve-folder-uids"`
+	ReserveInboxUIDs      *sqlx.Stmt `query:"reserve-inbox-uids"`
+
+	// Label queries
+	ListLabelsByProject  *sqlx.Stmt `query:"list-labels-by-project"`
+	CountLabelsByProject *sqlx.Stmt `query:"count-labels-by-project"`
+	GetLabel             *sqlx.Stmt `query:"get-label"`
+	GetLabelByName       *sqlx.Stmt `query:"get-label-by-name"`
+	CreateLabel          *sqlx.Stmt `query:"create-label"`
+	UpdateLabel          *sqlx.Stmt `query:"update-label"`
+	DeleteLabel          *sqlx.Stmt `query:"delete-label"`
+	AddMessageLabel      *sqlx.Stmt `query:"add-message-label"`
+	RemoveMessageLabel   *sqlx.Stmt `query:"remove-message-label"`
+	ListLabelsByMessages *sqlx.Stmt `query:"list-labels-by-messages"`
+
+	// Sender address queries
+	ListSenderAddressesByProject  *sqlx.Stmt `query:"list-sender-addresses-by-project"`
+	CountSenderAddressesByProject *sqlx.Stmt `query:"count-sender-addresses-by-project"`
+	GetSenderAddress              *sqlx.Stmt `query:"get-sender-address"`
+	CreateSenderAddress           *sqlx.Stmt `query:"create-sender-address"`
+	DeleteSenderAddress           *sqlx.Stmt `query:"delete-sender-address"`
+	IsSenderAllowed               *sqlx.Stmt `query:"is-sender-allowed"`
+
+	// Outbound queue queries
+	EnqueueOutboundMessage *sqlx.Stmt `query:"enqueue-outbound-message"`
+	ClaimOutboundMessages  *sqlx.Stmt `query:"claim-outbound-messages"`
+	UpdateOutboundMessage  *sqlx.Stmt `query:"update-outbound-message"`
+
+	// ACME cache queries
+	GetACMECacheEntry    *sqlx.Stmt `query:"get-acme-cache-entry"`
+	PutACMECacheEntry    *sqlx.Stmt `query:"put-acme-cache-entry"`
+	DeleteACMECacheEntry *sqlx.Stmt `query:"delete-acme-cache-entry"`
+
+	// TLS report queries
+	ListTLSReports  *sqlx.Stmt `query:"list-tls-reports"`
+	CountTLSReports *sqlx.Stmt `query:"count-tls-reports"`
+	GetTLSReport    *sqlx.Stmt `query:"get-tls-report"`
+	CreateTLSReport *sqlx.Stmt `query:"create-tls-report"`
+	DeleteTLSReport *sqlx.Stmt `query:"delete-tls-report"`
+
+	// DMARC report queries
+	ListDMARCReports      *sqlx.Stmt `query:"list-dmarc-reports"`
+	CountDMARCReports     *sqlx.Stmt `query:"count-dmarc-reports"`
+	GetDMARCReport        *sqlx.Stmt `query:"get-dmarc-report"`
+	ListDMARCRecords      *sqlx.Stmt `query:"list-dmarc-records"`
+	CreateDMARCReport     *sqlx.Stmt `query:"create-dmarc-report"`
+	DeleteDMARCRecords    *sqlx.Stmt `query:"delete-dmarc-records"`
+	CreateDMARCRecord     *sqlx.Stmt `query:"create-dmarc-record"`
+	DeleteDMARCReport     *sqlx.Stmt `query:"delete-dmarc-report"`
+	SummarizeDMARCRecords *sqlx.Stmt `query:"summarize-dmarc-records"`
+	CountDMARCSummary     *sqlx.Stmt `query:"count-dmarc-summary"`
+
+	// Greylist queries
+	TouchGreylistTriplet          *sqlx.Stmt `query:"touch-greylist-triplet"`
+	DeleteExpiredGreylistTriplets *sqlx.Stmt `query:"delete-expired-greylist-triplets"`
+
+	// DKIM key queries
+	ListDKIMKeys       *sqlx.Stmt `query:"list-dkim-keys"`
+	CountDKIMKeys      *sqlx.Stmt `query:"count-dkim-keys"`
+	GetDKIMKey         *sqlx.Stmt `query:"get-dkim-key"`
+	GetDKIMKeyByDomain *sqlx.Stmt `query:"get-dkim-key-by-domain"`
+	CreateDKIMKey      *sqlx.Stmt `query:"create-dkim-key"`
+	DeleteDKIMKey      *sqlx.Stmt `query:"delete-dkim-key"`
+
 	// Message queries
 	CreateMessage                      *sqlx.Stmt `query:"create-message"`
 	GetMessage                         *sqlx.Stmt `query:"get-message"`
@@ -55,16 +129,18 @@ type Queries struct {
 	DeleteMessage                      *sqlx.Stmt `query:"delete-message"`
 	ListMessagesByInboxWithReadFilter  *sqlx.Stmt `query:"list-messages-by-inbox-with-read-filter"`
 	CountMessagesByInboxWithReadFilter *sqlx.Stmt `query:"count-messages-by-inbox-with-read-filter"`
+	ListRecentMessagesByInbox          *sqlx.Stmt `query:"list-recent-messages-by-inbox"`
 
 	// User queries
-	ListUsers         *sqlx.Stmt `query:"list-users"`
-	CountUsers        *sqlx.Stmt `query:"count-users"`
-	GetUser           *sqlx.Stmt `query:"get-user"`
-	CreateUser        *sqlx.Stmt `query:"create-user"`
-	UpdateUser        *sqlx.Stmt `query:"update-user"`
-	DeleteUser        *sqlx.Stmt `query:"delete-user"`
-	GetUserByUsername *sqlx.Stmt `query:"get-user-by-username"`
-	GetUserByEmail    *sqlx.Stmt `query:"get-user-by-email"`
+	ListUsers              *sqlx.Stmt `query:"list-users"`
+	CountUsers             *sqlx.Stmt `query:"count-users"`
+	GetUser                *sqlx.Stmt `query:"get-user"`
+	CreateUser             *sqlx.Stmt `query:"create-user"`
+	UpdateUser             *sqlx.Stmt `query:"update-user"`
+	UpdateUserDefaultInbox *sqlx.Stmt `query:"update-user-default-inbox"`
+	DeleteUser             *sqlx.Stmt `query:"delete-user"`
+	GetUserByUsername      *sqlx.Stmt `query:"get-user-by-username"`
+	GetUserByEmail         *sqlx.Stmt `query:"get-user-by-email"`
 
 	// Tokens
 	ListTokensByUser    *sqlx.Stmt `query:"list-tokens-by-user"`
@@ -85,6 +161,14 @@ type Queries struct {
 
 	// IMAP-related queries
 	UpdateMessageDeletedStatus                *sqlx.Stmt `query:"update-message-deleted-status"`
+	CopyMessage                               *sqlx.Stmt `query:"copy-message"`
+	MoveMessage                               *sqlx.Stmt `query:"move-message"`
+	CopyMessageLabels                         *sqlx.Stmt `query:"copy-message-labels"`
+	RemoveForeignMessageLabels                *sqlx.Stmt `query:"remove-foreign-message-labels"`
+	UpdateMessageFlags                        *sqlx.Stmt `query:"update-message-flags"`
+	AddMessagesLabels                         *sqlx.Stmt `query:"add-messages-labels"`
+	RemoveMessagesLabels                      *sqlx.Stmt `query:"remove-messages-labels"`
+	RemoveMessagesLabelsExcept                *sqlx.Stmt `query:"remove-messages-labels-except"`
 	ListMessagesByInboxWithFilters            *sqlx.Stmt `query:"list-messages-by-inbox-with-filters"`
 	CountMessagesByInboxWithFilters           *sqlx.Stmt `query:"count-messages-by-inbox-with-filters"`
 	ListInboxesByUser                         *sqlx.Stmt `query:"list-inboxes-by-user"`
@@ -93,6 +177,18 @@ type Queries struct {
 	GetAllMessageUIDsForInbox                 *sqlx.Stmt `query:"get-all-message-uids-for-inbox"`
 	GetAllMessageUIDsForInboxIncludingDeleted *sqlx.Stmt `query:"get-all-message-uids-for-inbox-including-deleted"`
 	GetMaxMessageUID                          *sqlx.Stmt `query:"get-max-message-uid"`
+	GetHighestModSeq                          *sqlx.Stmt `query:"get-highest-modseq"`
+	ListExpungedMessageUIDs                   *sqlx.Stmt `query:"list-expunged-message-uids"`
+	GetHighestModSeqForInboxes                *sqlx.Stmt `query:"get-highest-modseq-for-inboxes"`
+	ListMessageChanges                        *sqlx.Stmt `query:"list-message-changes"`
+	CountMessagesByMailbox                    *sqlx.Stmt `query:"count-messages-by-mailbox"`
+	ListMessagesByIDs                         *sqlx.Stmt `query:"list-messages-by-ids"`
+	ListRawMessages                           *sqlx.Stmt `query:"list-raw-messages"`
+	FindThreadByMessageIDs                    *sqlx.Stmt `query:"find-thread-by-message-ids"`
+	FindThreadBySubject                       *sqlx.Stmt `query:"find-thread-by-subject"`
+	CountThreadsByInbox                       *sqlx.Stmt `query:"count-threads-by-inbox"`
+	ListThreadsByInbox                        *sqlx.Stmt `query:"list-threads-by-inbox"`
+	ListMessagesByThreads                     *sqlx.Stmt `query:"list-messages-by-threads"`
 	GetMessageIDFromUID                       *sqlx.Stmt `query:"get-message-id-from-uid"`
 }
 
@@ -3,13 +3,14 @@
 -- -------------------------------------------
 
 -- name: list-projects
-SELECT id, name, created_at, updated_at
+SELECT id, name, max_messages, max_bytes, message_count, storage_bytes, created_at, updated_at
 FROM projects
 ORDER BY id
 LIMIT $1 OFFSET $2;
 
 -- name: list-projects-by-user
-SELECT projects.id, projects.name, projects.created_at, projects.updated_at
+SELECT projects.id, projects.name, projects.max_messages, projects.max_bytes, projects.message_count, projects.storage_bytes,
+       projects.created_at, projects.updated_at
 FROM projects
 INNER JOIN project_users ON projects.id = project_users.project_id
 WHERE project_users.user_id = $1
@@ -23,19 +24,19 @@ INNER JOIN project_users ON projects.id = project_users.project_id
 WHERE project_users.user_id = $1;
 
 -- name: get-project
-SELECT id, name, created_at, updated_at
+SELECT id, name, max_messages, max_bytes, message_count, storage_bytes, created_at, updated_at
 FROM projects
 WHERE id = $1;
 
 -- name: create-project
-INSERT INTO projects (name, created_at, updated_at)
-VALUES ($1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+INSERT INTO projects (name, max_messages, max_bytes, created_at, updated_at)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 RETURNING id, created_at, updated_at;
 
 -- name: update-project
 UPDATE projects
-SET name = $1, updated_at = CURRENT_TIMESTAMP
-WHERE id = $2
+SET name = $1, max_messages = $2, max_bytes = $3, updated_at = CURRENT_TIMESTAMP
+WHERE id = $4
 RETURNING updated_at;
 
 -- name: delete-project
@@ -62,25 +63,35 @@ WHERE user_id = $1 AND project_id = $2;
 -- -------------------------------------------
 
 -- name: create-inbox
-INSERT INTO inboxes (project_id, email, created_at, updated_at)
-VALUES ($1, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-RETURNING id, created_at, updated_at;
+INSERT INTO inboxes (project_id, email, dmarc_reports, max_messages, max_bytes, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING id, uid_validity, uid_next, created_at, updated_at;
 
 -- name: get-inbox
-SELECT id, project_id, email, created_at, updated_at
+SELECT id, project_id, email, dmarc_reports, uid_validity, uid_next,
+       max_messages, max_bytes, message_count, storage_bytes, created_at, updated_at
 FROM inboxes
 WHERE id = $1;
 
 -- name: update-inbox
 UPDATE inboxes
-SET email = $1
-WHERE id = $2;
+SET email = $1, dmarc_reports = $2, max_messages = $3, max_bytes = $4
+WHERE id = $5;
 
 -- name: delete-inbox
+-- The messages of the inbox no longer count against its project.
+WITH usage AS (
+    UPDATE projects p
+    SET message_count = GREATEST(0, p.message_count - i.message_count),
+        storage_bytes = GREATEST(0, p.storage_bytes - i.storage_bytes)
+    FROM inboxes i
+    WHERE i.id = $1 AND p.id = i.project_id
+)
 DELETE FROM inboxes WHERE id = $1;
 
 -- name: list-inboxes-by-project
-SELECT id, project_id, email, created_at, updated_at
+SELECT id, project_id, email, dmarc_reports, uid_validity, uid_next,
+       max_messages, max_bytes, message_count, storage_bytes, created_at, updated_at
 FROM inboxes
 WHERE project_id = $1
 ORDER BY id
@@ -91,8 +102,20 @@ SELECT COUNT(*)
 FROM inboxes
 WHERE project_id = $1;
 
+-- name: get-inbox-quotas
+-- The quotas a message stored in inbox $1 counts against: the inbox's, then its project's.
+SELECT 'inbox' AS kind, id, email AS name, max_messages, max_bytes, message_count, storage_bytes
+FROM inboxes
+WHERE id = $1
+UNION ALL
+SELECT 'project' AS kind, p.id, p.name, p.max_messages, p.max_bytes, p.message_count, p.storage_bytes
+FROM projects p
+INNER JOIN inboxes i ON i.project_id = p.id
+WHERE i.id = $1;
+
 -- name: get-inbox-by-email
-SELECT id, project_id, email, created_at, updated_at
+SELECT id, project_id, email, dmarc_reports, uid_validity, uid_next,
+       max_messages, max_bytes, message_count, storage_bytes, created_at, updated_at
 FROM inboxes
 WHERE email = $1;
 
@@ -101,25 +124,25 @@ WHERE email = $1;
 -- -------------------------------------------
 
 -- name: create-rule
-INSERT INTO forward_rules (inbox_id, sender, receiver, subject, created_at, updated_at)
-VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+INSERT INTO forward_rules (inbox_id, sender, receiver, subject, forward_to, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 RETURNING id, created_at, updated_at;
 
 -- name: get-rule
-SELECT id, inbox_id, sender, receiver, subject, created_at, updated_at
+SELECT id, inbox_id, sender, receiver, subject, forward_to, created_at, updated_at
 FROM forward_rules
 WHERE id = $1;
 
 -- name: update-rule
 UPDATE forward_rules
-SET sender = $1, receiver = $2, subject = $3
-WHERE id = $4;
+SET sender = $1, receiver = $2, subject = $3, forward_to = $4
+WHERE id = $5;
 
 -- name: delete-rule
 DELETE FROM forward_rules WHERE id = $1;
 
 -- name: list-rules-by-inbox
-SELECT id, inbox_id, sender, receiver, subject, created_at, updated_at
+SELECT id, inbox_id, sender, receiver, subject, forward_to, created_at, updated_at
 FROM forward_rules
 WHERE inbox_id = $1
 ORDER BY id
@@ -131,7 +154,7 @@ FROM forward_rules
 WHERE inbox_id = $1;
 
 -- name: list-rules
-SELECT id, inbox_id, sender, receiver, subject, created_at, updated_at
+SELECT id, inbox_id, sender, receiver, subject, forward_to, created_at, updated_at
 FROM forward_rules
 ORDER BY id
 LIMIT $1 OFFSET $2;
@@ -139,22 +162,512 @@ LIMIT $1 OFFSET $2;
 -- name: count-rules
 SELECT COUNT(*) FROM forward_rules;
 
+--- ------------------------------------------
+-- Labels
+-- -------------------------------------------
+
+-- name: list-labels-by-project
+SELECT id, project_id, name, color, created_at, updated_at
+FROM labels
+WHERE project_id = $1
+ORDER BY name
+LIMIT $2 OFFSET $3;
+
+-- name: count-labels-by-project
+SELECT COUNT(*)
+FROM labels
+WHERE project_id = $1;
+
+-- name: get-label
+SELECT id, project_id, name, color, created_at, updated_at
+FROM labels
+WHERE id = $1;
+
+-- name: get-label-by-name
+SELECT id, project_id, name, color, created_at, updated_at
+FROM labels
+WHERE project_id = $1 AND name = $2;
+
+-- name: create-label
+INSERT INTO labels (project_id, name, color, created_at, updated_at)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING id, created_at, updated_at;
+
+-- name: update-label
+UPDATE labels
+SET name = $1, color = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $3;
+
+-- name: delete-label
+DELETE FROM labels WHERE id = $1;
+
+-- name: add-message-label
+-- Labels are IMAP keywords, so a change gives the message a new mod-sequence.
+WITH added AS (
+    INSERT INTO message_labels (message_id, label_id)
+    VALUES ($1, $2)
+    ON CONFLICT DO NOTHING
+    RETURNING message_id
+)
+UPDATE messages SET modseq = nextval('messages_modseq_seq')
+WHERE id IN (SELECT message_id FROM added);
+
+-- name: remove-message-label
+WITH removed AS (
+    DELETE FROM message_labels
+    WHERE message_id = $1 AND label_id = $2
+    RETURNING message_id
+)
+UPDATE messages SET modseq = nextval('messages_modseq_seq')
+WHERE id IN (SELECT message_id FROM removed);
+
+-- name: list-labels-by-messages
+SELECT ml.message_id, l.id, l.project_id, l.name, l.color, l.created_at, l.updated_at
+FROM message_labels ml
+INNER JOIN labels l ON l.id = ml.label_id
+WHERE ml.message_id = ANY($1::uuid[])
+ORDER BY l.name;
+
+--- ------------------------------------------
+-- Folders
+-- -------------------------------------------
+
+-- name: list-folders-by-inbox
+SELECT id, inbox_id, name, uid_validity, uid_next, subscribed, special_use, created_at, updated_at
+FROM folders
+WHERE inbox_id = $1
+ORDER BY name
+LIMIT $2 OFFSET $3;
+
+-- name: count-folders-by-inbox
+SELECT COUNT(*)
+FROM folders
+WHERE inbox_id = $1;
+
+-- name: get-folder
+SELECT id, inbox_id, name, uid_validity, uid_next, subscribed, special_use, created_at, updated_at
+FROM folders
+WHERE id = $1;
+
+-- name: get-folder-by-name
+SELECT id, inbox_id, name, uid_validity, uid_next, subscribed, special_use, created_at, updated_at
+FROM folders
+WHERE inbox_id = $1 AND name = $2;
+
+-- name: get-folder-by-special-use
+SELECT id, inbox_id, name, uid_validity, uid_next, subscribed, special_use, created_at, updated_at
+FROM folders
+WHERE inbox_id = $1 AND special_use = $2;
+
+-- name: create-folder
+INSERT INTO folders (inbox_id, name, subscribed, special_use, created_at, updated_at)
+VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING id, uid_validity, uid_next, created_at, updated_at;
+
+-- name: update-folder
+-- Renaming a folder renames its subfolders as well.
+WITH old AS (SELECT inbox_id, name FROM folders WHERE id = $1)
+UPDATE folders f
+SET name = $2 || SUBSTRING(f.name FROM LENGTH(old.name) + 1),
+    subscribed = CASE WHEN f.id = $1 THEN $3 ELSE f.subscribed END,
+    updated_at = CURRENT_TIMESTAMP
+FROM old
+WHERE f.inbox_id = old.inbox_id AND (f.id = $1 OR LEFT(f.name, LENGTH(old.name) + 1) = old.name || '/');
+
+-- name: delete-folder
+-- The messages of the folder go with it, and no longer count against the quotas.
+WITH removed AS (
+    SELECT m.inbox_id, i.project_id, COUNT(*) AS messages, SUM(m.size) AS bytes
+    FROM messages m
+    INNER JOIN inboxes i ON i.id = m.inbox_id
+    WHERE m.folder_id = $1
+    GROUP BY m.inbox_id, i.project_id
+), inbox_usage AS (
+    UPDATE inboxes i
+    SET message_count = GREATEST(0, i.message_count - r.messages),
+        storage_bytes = GREATEST(0, i.storage_bytes - r.bytes)
+    FROM removed r
+    WHERE i.id = r.inbox_id
+), project_usage AS (
+    UPDATE projects p
+    SET message_count = GREATEST(0, p.message_count - r.messages),
+        storage_bytes = GREATEST(0, p.storage_bytes - r.bytes)
+    FROM removed r
+    WHERE p.id = r.project_id
+)
+DELETE FROM folders WHERE id = $1;
+
+-- name: reserve-folder-uids
+-- Reserves $2 consecutive UIDs and returns the first one.
+UPDATE folders
+SET uid_next = uid_next + $2
+WHERE id = $1
+RETURNING uid_next - $2;
+
+-- name: reserve-inbox-uids
+-- Reserves $2 consecutive UIDs in the inbox itself and returns the first one.
+UPDATE inboxes
+SET uid_next = uid_next + $2
+WHERE id = $1
+RETURNING uid_next - $2;
+
+--- ------------------------------------------
+-- Sender addresses
+-- -------------------------------------------
+
+-- name: list-sender-addresses-by-project
+SELECT id, project_id, user_id, address, created_at, updated_at
+FROM sender_addresses
+WHERE project_id = $1
+ORDER BY address, id
+LIMIT $2 OFFSET $3;
+
+-- name: count-sender-addresses-by-project
+SELECT COUNT(*)
+FROM sender_addresses
+WHERE project_id = $1;
+
+-- name: get-sender-address
+SELECT id, project_id, user_id, address, created_at, updated_at
+FROM sender_addresses
+WHERE id = $1;
+
+-- name: create-sender-address
+INSERT INTO sender_addresses (project_id, user_id, address, created_at, updated_at)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING id, created_at, updated_at;
+
+-- name: delete-sender-address
+DELETE FROM sender_addresses WHERE id = $1;
+
+-- name: is-sender-allowed
+-- A user may send from the inboxes of their projects and from the sender
+-- addresses of their projects that are not restricted to another member.
+-- $2 holds the lower-cased candidate addresses, including "@domain".
+SELECT EXISTS (
+    SELECT 1
+    FROM inboxes i
+    INNER JOIN project_users pu ON pu.project_id = i.project_id
+    WHERE pu.user_id = $1 AND lower(i.email) = ANY($2::TEXT[])
+) OR EXISTS (
+    SELECT 1
+    FROM sender_addresses sa
+    INNER JOIN project_users pu ON pu.project_id = sa.project_id
+    WHERE pu.user_id = $1
+      AND (sa.user_id IS NULL OR sa.user_id = $1)
+      AND lower(sa.address) = ANY($2::TEXT[])
+);
+
+--- ------------------------------------------
+-- Outbound queue
+-- -------------------------------------------
+
+-- name: enqueue-outbound-message
+INSERT INTO outbound_messages (user_id, mail_from, recipients, raw, status, next_attempt_at, created_at, updated_at)
+VALUES ($1, $2, $3, $4, 'queued', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING id, status, next_attempt_at, created_at, updated_at;
+
+-- name: claim-outbound-messages
+-- Claims up to $1 due messages for delivery. A claimed message is leased for $2
+-- seconds, so another worker only picks it up again if this one dies.
+WITH due AS (
+    SELECT id
+    FROM outbound_messages
+    WHERE status = 'queued' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE outbound_messages o
+SET attempts = o.attempts + 1,
+    next_attempt_at = NOW() + make_interval(secs => $2),
+    updated_at = CURRENT_TIMESTAMP
+FROM due
+WHERE o.id = due.id
+RETURNING o.id, o.user_id, o.mail_from, o.recipients, o.raw, o.status, o.attempts, o.next_attempt_at, o.last_error, o.created_at, o.updated_at;
+
+-- name: update-outbound-message
+UPDATE outbound_messages
+SET status = $2, next_attempt_at = $3, last_error = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+--- ------------------------------------------
+-- ACME cache
+-- -------------------------------------------
+
+-- name: get-acme-cache-entry
+SELECT data FROM acme_cache WHERE key = $1;
+
+-- name: put-acme-cache-entry
+INSERT INTO acme_cache (key, data, created_at, updated_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+ON CONFLICT (key) DO UPDATE SET data = EXCLUDED.data, updated_at = CURRENT_TIMESTAMP;
+
+-- name: delete-acme-cache-entry
+DELETE FROM acme_cache WHERE key = $1;
+
+--- ------------------------------------------
+-- TLS reports
+-- -------------------------------------------
+
+-- name: list-tls-reports
+-- $1 filters on a policy domain, empty for every report.
+SELECT id, organization_name, report_id, contact_info, start_date, end_date, domains,
+       successful_sessions, failed_sessions, policies, created_at, updated_at
+FROM tls_reports
+WHERE $1 = '' OR $1 = ANY(domains)
+ORDER BY start_date DESC, id
+LIMIT $2 OFFSET $3;
+
+-- name: count-tls-reports
+SELECT COUNT(*) FROM tls_reports WHERE $1 = '' OR $1 = ANY(domains);
+
+-- name: get-tls-report
+SELECT id, organization_name, report_id, contact_info, start_date, end_date, domains,
+       successful_sessions, failed_sessions, policies, created_at, updated_at
+FROM tls_reports
+WHERE id = $1;
+
+-- name: create-tls-report
+-- Reports are identified by their organization and report ID, so a report
+-- delivered twice replaces the first copy.
+INSERT INTO tls_reports (organization_name, report_id, contact_info, start_date, end_date, domains,
+                         successful_sessions, failed_sessions, policies, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::jsonb, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+ON CONFLICT (organization_name, report_id) DO UPDATE
+SET contact_info = EXCLUDED.contact_info,
+    start_date = EXCLUDED.start_date,
+    end_date = EXCLUDED.end_date,
+    domains = EXCLUDED.domains,
+    successful_sessions = EXCLUDED.successful_sessions,
+    failed_sessions = EXCLUDED.failed_sessions,
+    policies = EXCLUDED.policies,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING id, created_at, updated_at;
+
+-- name: delete-tls-report
+DELETE FROM tls_reports WHERE id = $1;
+
+--- ------------------------------------------
+-- DMARC reports
+-- -------------------------------------------
+
+-- name: list-dmarc-reports
+-- Reports received by the inboxes of project $1, filtered on the policy domain
+-- ($2, empty for all) and on a date range ($3 and $4, NULL for no bound).
+-- message_count sums the records of each report.
+SELECT r.id, r.inbox_id, r.org_name, r.org_email, r.report_id, r.domain, r.date_begin, r.date_end,
+       r.policy_adkim, r.policy_aspf, r.policy_p, r.policy_sp, r.policy_pct,
+       COALESCE((SELECT SUM(rec.count) FROM dmarc_records rec WHERE rec.report_id = r.id), 0) AS message_count,
+       r.created_at, r.updated_at
+FROM dmarc_reports r
+INNER JOIN inboxes i ON r.inbox_id = i.id
+WHERE i.project_id = $1
+  AND ($2 = '' OR r.domain = $2)
+  AND ($3::timestamptz IS NULL OR r.date_end > $3)
+  AND ($4::timestamptz IS NULL OR r.date_begin < $4)
+ORDER BY r.date_begin DESC, r.id
+LIMIT $5 OFFSET $6;
+
+-- name: count-dmarc-reports
+SELECT COUNT(*)
+FROM dmarc_reports r
+INNER JOIN inboxes i ON r.inbox_id = i.id
+WHERE i.project_id = $1
+  AND ($2 = '' OR r.domain = $2)
+  AND ($3::timestamptz IS NULL OR r.date_end > $3)
+  AND ($4::timestamptz IS NULL OR r.date_begin < $4);
+
+-- name: get-dmarc-report
+SELECT r.id, r.inbox_id, r.org_name, r.org_email, r.report_id, r.domain, r.date_begin, r.date_end,
+       r.policy_adkim, r.policy_aspf, r.policy_p, r.policy_sp, r.policy_pct,
+       COALESCE((SELECT SUM(rec.count) FROM dmarc_records rec WHERE rec.report_id = r.id), 0) AS message_count,
+       r.created_at, r.updated_at
+FROM dmarc_reports r
+INNER JOIN inboxes i ON r.inbox_id = i.id
+WHERE r.id = $1 AND i.project_id = $2;
+
+-- name: list-dmarc-records
+SELECT id, report_id, source_ip, count, disposition, dkim, spf, header_from, envelope_from, auth_results
+FROM dmarc_records
+WHERE report_id = $1
+ORDER BY count DESC, source_ip, id;
+
+-- name: create-dmarc-report
+-- A report delivered twice to an inbox replaces the first copy; its records
+-- are deleted by the caller before the new ones are added.
+INSERT INTO dmarc_reports (inbox_id, org_name, org_email, report_id, domain, date_begin, date_end,
+                           policy_adkim, policy_aspf, policy_p, policy_sp, policy_pct, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+ON CONFLICT (inbox_id, org_name, report_id) DO UPDATE
+SET org_email = EXCLUDED.org_email,
+    domain = EXCLUDED.domain,
+    date_begin = EXCLUDED.date_begin,
+    date_end = EXCLUDED.date_end,
+    policy_adkim = EXCLUDED.policy_adkim,
+    policy_aspf = EXCLUDED.policy_aspf,
+    policy_p = EXCLUDED.policy_p,
+    policy_sp = EXCLUDED.policy_sp,
+    policy_pct = EXCLUDED.policy_pct,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING id, created_at, updated_at;
+
+-- name: delete-dmarc-records
+DELETE FROM dmarc_records WHERE report_id = $1;
+
+-- name: create-dmarc-record
+INSERT INTO dmarc_records (report_id, source_ip, count, disposition, dkim, spf, header_from, envelope_from, auth_results)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::jsonb)
+RETURNING id;
+
+-- name: delete-dmarc-report
+DELETE FROM dmarc_reports r
+USING inboxes i
+WHERE r.inbox_id = i.id AND r.id = $1 AND i.project_id = $2;
+
+-- name: summarize-dmarc-records
+-- Sums the records of project $1 by policy domain, source IP or day
+-- ($2 is "domain", "source" or "date"), filtered on the policy domain ($3),
+-- the source IP ($4) and the date range ($5 and $6) like list-dmarc-reports.
+-- DMARC passes when either DKIM or SPF passed aligned.
+SELECT CASE $2
+           WHEN 'source' THEN rec.source_ip
+           WHEN 'date' THEN to_char(r.date_begin AT TIME ZONE 'UTC', 'YYYY-MM-DD')
+           ELSE r.domain
+       END AS key,
+       SUM(rec.count) AS messages,
+       COALESCE(SUM(rec.count) FILTER (WHERE rec.dkim = 'pass'), 0) AS dkim_pass,
+       COALESCE(SUM(rec.count) FILTER (WHERE rec.spf = 'pass'), 0) AS spf_pass,
+       COALESCE(SUM(rec.count) FILTER (WHERE rec.dkim = 'pass' OR rec.spf = 'pass'), 0) AS dmarc_pass,
+       COALESCE(SUM(rec.count) FILTER (WHERE rec.disposition = 'quarantine'), 0) AS quarantined,
+       COALESCE(SUM(rec.count) FILTER (WHERE rec.disposition = 'reject'), 0) AS rejected
+FROM dmarc_records rec
+INNER JOIN dmarc_reports r ON rec.report_id = r.id
+INNER JOIN inboxes i ON r.inbox_id = i.id
+WHERE i.project_id = $1
+  AND ($3 = '' OR r.domain = $3)
+  AND ($4 = '' OR rec.source_ip = $4)
+  AND ($5::timestamptz IS NULL OR r.date_end > $5)
+  AND ($6::timestamptz IS NULL OR r.date_begin < $6)
+GROUP BY 1
+ORDER BY messages DESC, key
+LIMIT $7 OFFSET $8;
+
+-- name: count-dmarc-summary
+SELECT COUNT(DISTINCT CASE $2
+           WHEN 'source' THEN rec.source_ip
+           WHEN 'date' THEN to_char(r.date_begin AT TIME ZONE 'UTC', 'YYYY-MM-DD')
+           ELSE r.domain
+       END)
+FROM dmarc_records rec
+INNER JOIN dmarc_reports r ON rec.report_id = r.id
+INNER JOIN inboxes i ON r.inbox_id = i.id
+WHERE i.project_id = $1
+  AND ($3 = '' OR r.domain = $3)
+  AND ($4 = '' OR rec.source_ip = $4)
+  AND ($5::timestamptz IS NULL OR r.date_end > $5)
+  AND ($6::timestamptz IS NULL OR r.date_begin < $6);
+
+--- ------------------------------------------
+-- Greylist
+-- -------------------------------------------
+
+-- name: touch-greylist-triplet
+-- Records a delivery attempt. A triplet not seen for $4 seconds starts over.
+INSERT INTO greylist (network, sender, recipient, first_seen, last_seen)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+ON CONFLICT (network, sender, recipient) DO UPDATE SET
+    first_seen = CASE
+        WHEN greylist.last_seen < CURRENT_TIMESTAMP - make_interval(secs => $4) THEN CURRENT_TIMESTAMP
+        ELSE greylist.first_seen
+    END,
+    last_seen = CURRENT_TIMESTAMP
+RETURNING first_seen, last_seen;
+
+-- name: delete-expired-greylist-triplets
+DELETE FROM greylist WHERE last_seen < CURRENT_TIMESTAMP - make_interval(secs => $1);
+
+--- ------------------------------------------
+-- DKIM keys
+-- -------------------------------------------
+
+-- name: list-dkim-keys
+SELECT id, domain, selector, algorithm, public_key, created_at, updated_at
+FROM dkim_keys
+ORDER BY domain, created_at DESC, id
+LIMIT $1 OFFSET $2;
+
+-- name: count-dkim-keys
+SELECT COUNT(*) FROM dkim_keys;
+
+-- name: get-dkim-key
+SELECT id, domain, selector, algorithm, public_key, private_key, created_at, updated_at
+FROM dkim_keys
+WHERE id = $1;
+
+-- name: get-dkim-key-by-domain
+-- The newest key of a domain is the one that signs, so keys can be rotated by
+-- adding a key with a new selector.
+SELECT id, domain, selector, algorithm, public_key, private_key, created_at, updated_at
+FROM dkim_keys
+WHERE domain = lower($1)
+ORDER BY created_at DESC, id
+LIMIT 1;
+
+-- name: create-dkim-key
+INSERT INTO dkim_keys (domain, selector, algorithm, public_key, private_key, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING id, created_at, updated_at;
+
+-- name: delete-dkim-key
+DELETE FROM dkim_keys WHERE id = $1;
+
 --- ------------------------------------------
 -- Messages
 -- -------------------------------------------
 
 -- name: create-message
-INSERT INTO messages (inbox_id, sender, receiver, subject, body, is_read, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, false, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-RETURNING id, created_at, updated_at, uid;
+-- The UID is allocated from the folder the message is stored in, or from the inbox itself.
+-- A folder of another inbox allocates none, so the message is refused.
+-- The message counts against the quotas of its inbox and project.
+-- A message that does not continue a thread starts one under its own ID.
+WITH new_message AS (
+    SELECT gen_random_uuid() AS id
+), folder_uid AS (
+    UPDATE folders
+    SET uid_next = uid_next + 1
+    WHERE id = NULLIF($6, '')::UUID AND inbox_id = $1
+    RETURNING uid_next - 1 AS uid
+), inbox_uid AS (
+    UPDATE inboxes
+    SET uid_next = uid_next + CASE WHEN NULLIF($6, '') IS NULL THEN 1 ELSE 0 END,
+        message_count = message_count + 1, storage_bytes = storage_bytes + $19
+    WHERE id = $1
+    RETURNING CASE WHEN NULLIF($6, '') IS NULL THEN uid_next - 1 END AS uid
+), project_usage AS (
+    UPDATE projects
+    SET message_count = message_count + 1, storage_bytes = storage_bytes + $19
+    WHERE id = (SELECT project_id FROM inboxes WHERE id = $1)
+)
+INSERT INTO messages (id, inbox_id, folder_id, uid, sender, receiver, subject, body, raw,
+                      is_read, is_deleted, is_flagged, is_answered, is_draft, created_at, updated_at,
+                      thread_id, header_message_id, header_references, base_subject, dnsbl_hits, size)
+SELECT new_message.id, $1, NULLIF($6, '')::UUID,
+       COALESCE((SELECT uid FROM folder_uid), (SELECT uid FROM inbox_uid)),
+       $2, $3, $4, $5, $8, $7, $9, $10, $11, $12, COALESCE($13, CURRENT_TIMESTAMP), CURRENT_TIMESTAMP,
+       COALESCE(NULLIF($14, '')::UUID, new_message.id), $15, $16, $17, $18, $19
+FROM new_message
+RETURNING id, created_at, updated_at, uid, thread_id;
 
 -- name: get-message
-SELECT id, inbox_id, uid, sender, receiver, subject, body, is_read, created_at, updated_at
+SELECT id, inbox_id, folder_id, uid, sender, receiver, subject, body, is_read, is_deleted, is_flagged, is_answered, is_draft, modseq, created_at, updated_at,
+       thread_id, header_message_id, header_references, dnsbl_hits, size
 FROM messages
 WHERE id = $1;
 
 -- name: list-messages-by-inbox
-SELECT id, inbox_id, uid, sender, receiver, subject, body, is_read, created_at, updated_at
+SELECT id, inbox_id, folder_id, uid, sender, receiver, subject, body, is_read, is_deleted, is_flagged, is_answered, is_draft, modseq, created_at, updated_at,
+       thread_id, header_message_id, header_references, dnsbl_hits, size
 FROM messages
 WHERE inbox_id = $1
 ORDER BY uid
@@ -167,14 +680,35 @@ WHERE inbox_id = $1;
 
 -- name: update-message-read-status
 UPDATE messages
-SET is_read = $1, updated_at = CURRENT_TIMESTAMP
+SET is_read = $1, modseq = nextval('messages_modseq_seq'), updated_at = CURRENT_TIMESTAMP
 WHERE id = $2;
 
 -- name: delete-message
-DELETE FROM messages WHERE id = $1;
+-- The UID is remembered so that QRESYNC clients learn about the removal.
+-- The message no longer counts against the quotas of its inbox and project.
+WITH deleted AS (
+    DELETE FROM messages WHERE id = $1
+    RETURNING id, inbox_id, folder_id, uid, thread_id, size
+), inbox_usage AS (
+    UPDATE inboxes i
+    SET message_count = GREATEST(0, i.message_count - 1),
+        storage_bytes = GREATEST(0, i.storage_bytes - d.size)
+    FROM deleted d
+    WHERE i.id = d.inbox_id
+    RETURNING i.project_id, d.size
+), project_usage AS (
+    UPDATE projects p
+    SET message_count = GREATEST(0, p.message_count - 1),
+        storage_bytes = GREATEST(0, p.storage_bytes - u.size)
+    FROM inbox_usage u
+    WHERE p.id = u.project_id
+)
+INSERT INTO expunged_messages (message_id, inbox_id, folder_id, uid, thread_id)
+SELECT id, inbox_id, folder_id, uid, thread_id FROM deleted;
 
 -- name: list-messages-by-inbox-with-read-filter
-SELECT id, inbox_id, uid, sender, receiver, subject, body, is_read, created_at, updated_at
+SELECT id, inbox_id, folder_id, uid, sender, receiver, subject, body, is_read, is_deleted, is_flagged, is_answered, is_draft, modseq, created_at, updated_at,
+       thread_id, header_message_id, header_references, dnsbl_hits, size
 FROM messages
 WHERE inbox_id = $1 AND is_read = $2
 ORDER BY uid
@@ -185,13 +719,21 @@ SELECT COUNT(*)
 FROM messages
 WHERE inbox_id = $1 AND is_read = $2;
 
+-- name: list-recent-messages-by-inbox
+SELECT id, inbox_id, folder_id, uid, sender, receiver, subject, body, is_read, is_deleted, is_flagged, is_answered, is_draft, modseq, created_at, updated_at,
+       thread_id, header_message_id, header_references, dnsbl_hits, size
+FROM messages
+WHERE inbox_id = $1 AND is_deleted = false
+ORDER BY uid DESC
+LIMIT $2;
+
 --- ------------------------------------------
 -- Users
 -- -------------------------------------------
 
 -- name: list-users
 SELECT id, name, username, password, email, status, role,
-       loggedin_at, created_at, updated_at
+       loggedin_at, default_inbox_id, created_at, updated_at
 FROM users
 ORDER BY id
 LIMIT $1 OFFSET $2;
@@ -206,12 +748,12 @@ VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 RETURNING id, created_at, updated_at;
 
 -- name: get-user
-SELECT id, name, username, password, email, status, role, password_login, loggedin_at, created_at, updated_at
+SELECT id, name, username, password, email, status, role, password_login, loggedin_at, default_inbox_id, created_at, updated_at
 FROM users
 WHERE id = $1;
 
 -- name: get-user-by-email
-SELECT id, name, username, password, email, status, role, password_login, loggedin_at, created_at, updated_at
+SELECT id, name, username, password, email, status, role, password_login, loggedin_at, default_inbox_id, created_at, updated_at
 FROM users
 WHERE email = $1;
 
@@ -221,11 +763,15 @@ SET name = $1, username = $2, password = CASE WHEN $3 = '' THEN password ELSE $3
 WHERE id = $8
 RETURNING updated_at;
 
+-- name: update-user-default-inbox
+UPDATE users SET default_inbox_id = NULLIF($2, '')::UUID, updated_at = NOW()
+WHERE id = $1;
+
 -- name: delete-user
 DELETE FROM users WHERE id = $1;
 
 -- name: get-user-by-username
-SELECT id, name, username, password, email, status, role, password_login, loggedin_at, created_at, updated_at
+SELECT id, name, username, password, email, status, role, password_login, loggedin_at, default_inbox_id, created_at, updated_at
 FROM users
 WHERE username = $1;
 
@@ -293,59 +839,275 @@ UPDATE sessions SET data=$1  WHERE id=$2;
 
 -- name: update-message-deleted-status
 UPDATE messages
-SET is_deleted = $1, updated_at = CURRENT_TIMESTAMP
+SET is_deleted = $1, modseq = nextval('messages_modseq_seq'), updated_at = CURRENT_TIMESTAMP
 WHERE id = $2;
 
+-- name: update-message-flags
+-- Each flag is a pair of parameters: whether to update it and its new value.
+UPDATE messages
+SET is_read = CASE WHEN $3 THEN $4 ELSE is_read END,
+    is_answered = CASE WHEN $5 THEN $6 ELSE is_answered END,
+    is_flagged = CASE WHEN $7 THEN $8 ELSE is_flagged END,
+    is_deleted = CASE WHEN $9 THEN $10 ELSE is_deleted END,
+    is_draft = CASE WHEN $11 THEN $12 ELSE is_draft END,
+    modseq = nextval('messages_modseq_seq'),
+    updated_at = CURRENT_TIMESTAMP
+WHERE inbox_id = $1 AND folder_id IS NOT DISTINCT FROM NULLIF($13, '')::UUID AND uid = ANY($2::int[])
+  AND ($14::BIGINT = 0 OR modseq <= $14)
+RETURNING id;
+
+-- name: add-messages-labels
+INSERT INTO message_labels (message_id, label_id)
+SELECT m, l FROM UNNEST($1::uuid[]) m, UNNEST($2::uuid[]) l
+ON CONFLICT DO NOTHING;
+
+-- name: remove-messages-labels
+DELETE FROM message_labels
+WHERE message_id = ANY($1::uuid[]) AND label_id = ANY($2::uuid[]);
+
+-- name: remove-messages-labels-except
+DELETE FROM message_labels
+WHERE message_id = ANY($1::uuid[]) AND NOT (label_id = ANY($2::uuid[]));
+
 -- name: list-messages-by-inbox-with-filters
-SELECT id, inbox_id, uid, sender, receiver, subject, body, is_read, is_deleted, created_at, updated_at
+SELECT id, inbox_id, folder_id, uid, sender, receiver, subject, body, is_read, is_deleted, is_flagged, is_answered, is_draft, modseq, created_at, updated_at,
+       thread_id, header_message_id, header_references, dnsbl_hits, size
 FROM messages
 WHERE inbox_id = $1
   AND ($2::BOOLEAN IS NULL OR is_read = $2)
   AND ($3::BOOLEAN IS NULL OR is_deleted = $3)
+  AND ($4::UUID IS NULL OR EXISTS (
+    SELECT 1 FROM message_labels ml WHERE ml.message_id = messages.id AND ml.label_id = $4))
+  AND folder_id IS NOT DISTINCT FROM NULLIF($5, '')::UUID
 ORDER BY uid
-LIMIT $4 OFFSET $5;
+LIMIT $6 OFFSET $7;
 
 -- name: count-messages-by-inbox-with-filters
 SELECT COUNT(*)
 FROM messages
 WHERE inbox_id = $1
   AND ($2::BOOLEAN IS NULL OR is_read = $2)
-  AND ($3::BOOLEAN IS NULL OR is_deleted = $3);
+  AND ($3::BOOLEAN IS NULL OR is_deleted = $3)
+  AND ($4::UUID IS NULL OR EXISTS (
+    SELECT 1 FROM message_labels ml WHERE ml.message_id = messages.id AND ml.label_id = $4))
+  AND folder_id IS NOT DISTINCT FROM NULLIF($5, '')::UUID;
+
+-- name: copy-message
+-- Copies a message into another mailbox under a UID reserved in that mailbox.
+-- The copy counts against the quotas of the destination inbox and its project.
+WITH source AS (
+    SELECT size FROM messages WHERE id = $1
+), inbox_usage AS (
+    UPDATE inboxes i
+    SET message_count = i.message_count + 1, storage_bytes = i.storage_bytes + s.size
+    FROM source s
+    WHERE i.id = $2
+    RETURNING i.project_id, s.size
+), project_usage AS (
+    UPDATE projects p
+    SET message_count = p.message_count + 1, storage_bytes = p.storage_bytes + u.size
+    FROM inbox_usage u
+    WHERE p.id = u.project_id
+)
+INSERT INTO messages (inbox_id, folder_id, uid, sender, receiver, subject, body, raw,
+                      is_read, is_deleted, is_flagged, is_answered, is_draft, created_at, updated_at,
+                      thread_id, header_message_id, header_references, base_subject, dnsbl_hits, size)
+SELECT $2, NULLIF($3, '')::UUID, $4,
+       sender, receiver, subject, body, raw, is_read, is_deleted, is_flagged, is_answered, is_draft, created_at, CURRENT_TIMESTAMP,
+       thread_id, header_message_id, header_references, base_subject, dnsbl_hits, size
+FROM messages
+WHERE id = $1
+RETURNING id, uid;
+
+-- name: move-message
+-- The UID the message had in its old mailbox is remembered for QRESYNC clients.
+-- A message moved to another inbox counts against the quotas of that inbox
+-- and its project instead. Each project is updated once, with the sum of its
+-- changes, as a statement can't update a row twice.
+WITH expunged AS (
+    INSERT INTO expunged_messages (message_id, inbox_id, folder_id, uid, thread_id)
+    SELECT id, inbox_id, folder_id, uid, thread_id FROM messages WHERE id = $1
+), moved AS (
+    SELECT inbox_id, size FROM messages WHERE id = $1 AND inbox_id <> $2
+), inbox_usage AS (
+    UPDATE inboxes i
+    SET message_count = GREATEST(0, i.message_count + CASE WHEN i.id = $2 THEN 1 ELSE -1 END),
+        storage_bytes = GREATEST(0, i.storage_bytes + CASE WHEN i.id = $2 THEN m.size ELSE -m.size END)
+    FROM moved m
+    WHERE i.id = m.inbox_id OR i.id = $2
+    RETURNING i.project_id,
+              CASE WHEN i.id = $2 THEN 1 ELSE -1 END AS messages,
+              CASE WHEN i.id = $2 THEN m.size ELSE -m.size END AS bytes
+), project_usage AS (
+    UPDATE projects p
+    SET message_count = GREATEST(0, p.message_count + u.messages),
+        storage_bytes = GREATEST(0, p.storage_bytes + u.bytes)
+    FROM (SELECT project_id, SUM(messages) AS messages, SUM(bytes) AS bytes FROM inbox_usage GROUP BY project_id) u
+    WHERE p.id = u.project_id
+)
+UPDATE messages
+SET inbox_id = $2, folder_id = NULLIF($3, '')::UUID,
+    uid = $4,
+    modseq = nextval('messages_modseq_seq'),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING uid;
+
+-- name: copy-message-labels
+-- Labels are only copied when they belong to the project of the destination inbox.
+INSERT INTO message_labels (message_id, label_id)
+SELECT $1, ml.label_id
+FROM message_labels ml
+INNER JOIN labels l ON l.id = ml.label_id
+INNER JOIN inboxes i ON i.project_id = l.project_id
+WHERE ml.message_id = $2 AND i.id = $3
+ON CONFLICT DO NOTHING;
+
+-- name: remove-foreign-message-labels
+DELETE FROM message_labels ml
+USING labels l, inboxes i
+WHERE ml.label_id = l.id AND ml.message_id = $1 AND i.id = $2 AND l.project_id <> i.project_id;
 
 -- name: list-inboxes-by-user
-SELECT DISTINCT i.id, i.project_id, i.email, i.created_at, i.updated_at
+SELECT DISTINCT i.id, i.project_id, p.name AS project_name, i.email, i.dmarc_reports, i.uid_validity, i.uid_next,
+       i.max_messages, i.max_bytes, i.message_count, i.storage_bytes, i.created_at, i.updated_at
 FROM inboxes i
+INNER JOIN projects p ON i.project_id = p.id
 INNER JOIN project_users pu ON i.project_id = pu.project_id
 WHERE pu.user_id = $1
 ORDER BY i.email;
 
 -- name: get-inbox-by-email-and-user
-SELECT DISTINCT i.id, i.project_id, i.email, i.created_at, i.updated_at
+SELECT DISTINCT i.id, i.project_id, p.name AS project_name, i.email, i.dmarc_reports, i.uid_validity, i.uid_next,
+       i.max_messages, i.max_bytes, i.message_count, i.storage_bytes, i.created_at, i.updated_at
 FROM inboxes i
+INNER JOIN projects p ON i.project_id = p.id
 INNER JOIN project_users pu ON i.project_id = pu.project_id
 WHERE i.email = $1 AND pu.user_id = $2;
 
 -- name: get-messages-by-uids
-SELECT id, inbox_id, uid, sender, receiver, subject, body, is_read, is_deleted, created_at, updated_at
+SELECT id, inbox_id, folder_id, uid, sender, receiver, subject, body, is_read, is_deleted, is_flagged, is_answered, is_draft, modseq, created_at, updated_at,
+       thread_id, header_message_id, header_references, dnsbl_hits, size
 FROM messages
-WHERE inbox_id = $1 AND uid = ANY($2::int[])
+WHERE inbox_id = $1 AND folder_id IS NOT DISTINCT FROM NULLIF($2, '')::UUID AND uid = ANY($3::int[])
 ORDER BY uid;
 
 -- name: get-all-message-uids-for-inbox
 SELECT uid
 FROM messages
-WHERE inbox_id = $1 AND is_deleted = false
+WHERE inbox_id = $1 AND folder_id IS NOT DISTINCT FROM NULLIF($2, '')::UUID AND is_deleted = false
 ORDER BY uid;
 
 -- name: get-all-message-uids-for-inbox-including-deleted
 SELECT uid
 FROM messages
-WHERE inbox_id = $1
+WHERE inbox_id = $1 AND folder_id IS NOT DISTINCT FROM NULLIF($2, '')::UUID
 ORDER BY uid;
 
 -- name: get-max-message-uid
-SELECT COALESCE(MAX(uid), 0) FROM messages WHERE inbox_id = $1;
+SELECT COALESCE(MAX(uid), 0) FROM messages WHERE inbox_id = $1 AND folder_id IS NOT DISTINCT FROM NULLIF($2, '')::UUID;
+
+-- name: get-highest-modseq
+-- Removed messages count as well, so that removals are visible to CONDSTORE clients.
+SELECT COALESCE(GREATEST(
+    (SELECT MAX(modseq) FROM messages WHERE inbox_id = $1 AND folder_id IS NOT DISTINCT FROM NULLIF($2, '')::UUID),
+    (SELECT MAX(modseq) FROM expunged_messages WHERE inbox_id = $1 AND folder_id IS NOT DISTINCT FROM NULLIF($2, '')::UUID)
+), 1);
+
+-- name: list-expunged-message-uids
+SELECT uid
+FROM expunged_messages
+WHERE inbox_id = $1 AND folder_id IS NOT DISTINCT FROM NULLIF($2, '')::UUID AND modseq > $3
+ORDER BY uid;
+
+-- name: get-highest-modseq-for-inboxes
+-- The mod-sequence of the last change to any message of the inboxes, folders included.
+SELECT COALESCE(GREATEST(
+    (SELECT MAX(modseq) FROM messages WHERE inbox_id = ANY($1::UUID[])),
+    (SELECT MAX(modseq) FROM expunged_messages WHERE inbox_id = ANY($1::UUID[]))
+), 0);
+
+-- name: list-message-changes
+-- Messages of the inboxes created, updated or removed after a mod-sequence, oldest
+-- change first. Moved messages leave a removal behind but still exist, so they
+-- only count as updated.
+SELECT id, thread_id, modseq, created, destroyed FROM (
+    SELECT id, thread_id, modseq, created_modseq > $2 AS created, FALSE AS destroyed
+    FROM messages
+    WHERE inbox_id = ANY($1::UUID[]) AND modseq > $2
+    UNION ALL
+    SELECT e.message_id AS id, (array_agg(e.thread_id ORDER BY e.modseq DESC))[1] AS thread_id,
+           MAX(e.modseq) AS modseq, FALSE AS created, TRUE AS destroyed
+    FROM expunged_messages e
+    WHERE e.inbox_id = ANY($1::UUID[]) AND e.modseq > $2 AND e.message_id IS NOT NULL
+      AND NOT EXISTS (SELECT 1 FROM messages m WHERE m.id = e.message_id)
+    GROUP BY e.message_id
+) changes
+ORDER BY modseq
+LIMIT $3;
+
+-- name: count-messages-by-mailbox
+SELECT inbox_id, folder_id, COUNT(*) AS total, COUNT(*) FILTER (WHERE NOT is_read) AS unread,
+       COUNT(DISTINCT thread_id) AS threads,
+       COUNT(DISTINCT thread_id) FILTER (WHERE NOT is_read) AS unread_threads
+FROM messages
+WHERE inbox_id = ANY($1::UUID[])
+GROUP BY inbox_id, folder_id;
+
+-- name: list-messages-by-ids
+SELECT id, inbox_id, folder_id, uid, sender, receiver, subject, body, is_read, is_deleted, is_flagged, is_answered, is_draft, modseq, created_at, updated_at,
+       thread_id, header_message_id, header_references, dnsbl_hits, size
+FROM messages
+WHERE id = ANY($1::UUID[]);
+
+-- name: find-thread-by-message-ids
+-- The thread of the latest message of an inbox that is referenced by a new message,
+-- or that references it.
+SELECT thread_id
+FROM messages
+WHERE inbox_id = $1
+  AND ((header_message_id <> '' AND header_message_id = ANY($2::TEXT[]))
+       OR ($3::TEXT <> '' AND header_references @> ARRAY[$3::TEXT]))
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: find-thread-by-subject
+-- The thread of the latest message of an inbox with the same base subject received
+-- after a point in time.
+SELECT thread_id
+FROM messages
+WHERE inbox_id = $1 AND base_subject = $2 AND created_at >= $3
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: count-threads-by-inbox
+SELECT COUNT(DISTINCT thread_id) FROM messages WHERE inbox_id = $1;
+
+-- name: list-threads-by-inbox
+-- The subject of a thread is the one of its first message.
+SELECT thread_id AS id,
+       $1::UUID AS inbox_id,
+       (array_agg(subject ORDER BY created_at, id))[1] AS subject,
+       COUNT(*) AS message_count,
+       COUNT(*) FILTER (WHERE NOT is_read) AS unread_count,
+       MAX(created_at) AS last_activity
+FROM messages
+WHERE inbox_id = $1
+GROUP BY thread_id
+ORDER BY last_activity DESC, thread_id
+LIMIT $2 OFFSET $3;
+
+-- name: list-messages-by-threads
+SELECT id, inbox_id, folder_id, uid, sender, receiver, subject, body, is_read, is_deleted, is_flagged, is_answered, is_draft, modseq, created_at, updated_at,
+       thread_id, header_message_id, header_references, dnsbl_hits, size
+FROM messages
+WHERE thread_id = ANY($1::UUID[])
+ORDER BY created_at, id;
+
+-- name: list-raw-messages
+-- Raw messages are large, so they are only loaded for the messages that need them.
+SELECT id, raw FROM messages WHERE id = ANY($1::UUID[]) AND raw IS NOT NULL;
 
 -- name: get-message-id-from-uid
 -- Get the UUID of a message from its inbox-specific integer UID.
-SELECT id FROM messages WHERE inbox_id = $1 AND uid = $2;
+SELECT id FROM messages WHERE inbox_id = $1 AND folder_id IS NOT DISTINCT FROM NULLIF($2, '')::UUID AND uid = $3;
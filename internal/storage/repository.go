@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"inbox451/internal/models"
 
@@ -31,6 +32,7 @@ type Repository interface {
 	CreateInbox(ctx context.Context, inbox *models.Inbox) error
 	UpdateInbox(ctx context.Context, inbox *models.Inbox) error
 	DeleteInbox(ctx context.Context, id string) error
+	GetInboxQuotas(ctx context.Context, inboxID string) ([]*models.Quota, error)
 
 	// Rule operations
 	ListRulesByInbox(ctx context.Context, inboxID string, limit, offset int) ([]*models.ForwardRule, int, error)
@@ -39,25 +41,101 @@ type Repository interface {
 	UpdateRule(ctx context.Context, rule *models.ForwardRule) error
 	DeleteRule(ctx context.Context, id string) error
 
+	// Label operations
+	ListLabelsByProject(ctx context.Context, projectID string, limit, offset int) ([]*models.Label, int, error)
+	GetLabel(ctx context.Context, id string) (*models.Label, error)
+	GetLabelByName(ctx context.Context, projectID string, name string) (*models.Label, error)
+	CreateLabel(ctx context.Context, label *models.Label) error
+	UpdateLabel(ctx context.Context, label *models.Label) error
+	DeleteLabel(ctx context.Context, id string) error
+	AddMessageLabel(ctx context.Context, messageID string, labelID string) error
+	RemoveMessageLabel(ctx context.Context, messageID string, labelID string) error
+	ListLabelsByMessages(ctx context.Context, messageIDs []string) ([]*models.MessageLabel, error)
+
+	// Folder operations
+	ListFoldersByInbox(ctx context.Context, inboxID string, limit, offset int) ([]*models.Folder, int, error)
+	GetFolder(ctx context.Context, id string) (*models.Folder, error)
+	GetFolderByName(ctx context.Context, inboxID string, name string) (*models.Folder, error)
+	GetFolderBySpecialUse(ctx context.Context, inboxID string, specialUse string) (*models.Folder, error)
+	CreateFolder(ctx context.Context, folder *models.Folder) error
+	UpdateFolder(ctx context.Context, folder *models.Folder) error
+	DeleteFolder(ctx context.Context, id string) error
+
+	// Sender address operations
+	ListSenderAddressesByProject(ctx context.Context, projectID string, limit, offset int) ([]*models.SenderAddress, int, error)
+	GetSenderAddress(ctx context.Context, id string) (*models.SenderAddress, error)
+	CreateSenderAddress(ctx context.Context, sender *models.SenderAddress) error
+	DeleteSenderAddress(ctx context.Context, id string) error
+	IsSenderAllowed(ctx context.Context, userID string, addresses []string) (bool, error)
+
+	// Outbound queue operations
+	EnqueueOutboundMessage(ctx context.Context, message *models.OutboundMessage) error
+	ClaimOutboundMessages(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboundMessage, error)
+	UpdateOutboundMessage(ctx context.Context, message *models.OutboundMessage) error
+
+	// ACME cache operations
+	GetACMECacheEntry(ctx context.Context, key string) ([]byte, error)
+	PutACMECacheEntry(ctx context.Context, key string, data []byte) error
+	DeleteACMECacheEntry(ctx context.Context, key string) error
+
+	// TLS report operations
+	ListTLSReports(ctx context.Context, domain string, limit, offset int) ([]*models.TLSReport, int, error)
+	GetTLSReport(ctx context.Context, id string) (*models.TLSReport, error)
+	CreateTLSReport(ctx context.Context, report *models.TLSReport) error
+	DeleteTLSReport(ctx context.Context, id string) error
+
+	// DMARC report operations
+	ListDMARCReports(ctx context.Context, filter models.DMARCFilter, limit, offset int) ([]*models.DMARCReport, int, error)
+	GetDMARCReport(ctx context.Context, projectID, id string) (*models.DMARCReport, error)
+	CreateDMARCReport(ctx context.Context, report *models.DMARCReport) error
+	DeleteDMARCReport(ctx context.Context, projectID, id string) error
+	SummarizeDMARCRecords(ctx context.Context, filter models.DMARCFilter, limit, offset int) ([]*models.DMARCSummary, int, error)
+
+	// Greylist operations
+	TouchGreylistTriplet(ctx context.Context, triplet *models.GreylistTriplet, maxAge time.Duration) error
+	DeleteExpiredGreylistTriplets(ctx context.Context, maxAge time.Duration) (int64, error)
+
+	// DKIM key operations
+	ListDKIMKeys(ctx context.Context, limit, offset int) ([]*models.DKIMKey, int, error)
+	GetDKIMKey(ctx context.Context, id string) (*models.DKIMKey, error)
+	GetDKIMKeyByDomain(ctx context.Context, domain string) (*models.DKIMKey, error)
+	CreateDKIMKey(ctx context.Context, key *models.DKIMKey) error
+	DeleteDKIMKey(ctx context.Context, id string) error
+
 	// Message operations
 	ListRules(ctx context.Context, limit, offset int) ([]*models.ForwardRule, int, error)
 	GetMessage(ctx context.Context, id string) (*models.Message, error)
 	ListMessagesByInbox(ctx context.Context, inboxID string, limit, offset int) ([]*models.Message, int, error)
 	ListMessagesByInboxWithFilter(ctx context.Context, inboxID string, isRead *bool, limit, offset int) ([]*models.Message, int, error)
+	ListRecentMessagesByInbox(ctx context.Context, inboxID string, limit int) ([]*models.Message, error)
 	CreateMessage(ctx context.Context, message *models.Message) error
 	UpdateMessageReadStatus(ctx context.Context, messageID string, isRead bool) error
 	DeleteMessage(ctx context.Context, messageID string) error
 
 	// IMAP-related operations
 	UpdateMessageDeletedStatus(ctx context.Context, messageID string, isDeleted bool) error
+	CopyMessages(ctx context.Context, inboxID string, folderID string, uids []uint32, destInboxID string, destFolderID string) ([]uint32, error)
+	MoveMessages(ctx context.Context, inboxID string, folderID string, uids []uint32, destInboxID string, destFolderID string) ([]uint32, error)
+	UpdateMessageFlags(ctx context.Context, inboxID string, folderID string, uids []uint32, op models.FlagOperation, flags models.MessageFlags) error
 	ListMessagesByInboxWithFilters(ctx context.Context, inboxID string, filters models.MessageFilters, limit, offset int) ([]*models.Message, int, error)
 	ListInboxesByUser(ctx context.Context, userID string) ([]*models.Inbox, error)
 	GetInboxByEmailAndUser(ctx context.Context, email string, userID string) (*models.Inbox, error)
-	GetMessagesByUIDs(ctx context.Context, inboxID string, uids []uint32) ([]*models.Message, error)
-	GetAllMessageUIDsForInbox(ctx context.Context, inboxID string) ([]uint32, error)
-	GetAllMessageUIDsForInboxIncludingDeleted(ctx context.Context, inboxID string) ([]uint32, error)
-	GetMaxMessageUID(ctx context.Context, inboxID string) (uint32, error)
-	GetMessageIDFromUID(ctx context.Context, inboxID string, uid uint32) (string, error)
+	GetMessagesByUIDs(ctx context.Context, inboxID string, folderID string, uids []uint32) ([]*models.Message, error)
+	GetAllMessageUIDsForInbox(ctx context.Context, inboxID string, folderID string) ([]uint32, error)
+	GetAllMessageUIDsForInboxIncludingDeleted(ctx context.Context, inboxID string, folderID string) ([]uint32, error)
+	GetMaxMessageUID(ctx context.Context, inboxID string, folderID string) (uint32, error)
+	GetHighestModSeq(ctx context.Context, inboxID string, folderID string) (uint64, error)
+	ListExpungedMessageUIDs(ctx context.Context, inboxID string, folderID string, sinceModSeq uint64) ([]uint32, error)
+	GetHighestModSeqForInboxes(ctx context.Context, inboxIDs []string) (uint64, error)
+	ListMessageChanges(ctx context.Context, inboxIDs []string, sinceModSeq uint64, limit int) ([]*models.MessageChange, error)
+	CountMessagesByMailbox(ctx context.Context, inboxIDs []string) ([]*models.MailboxCount, error)
+	ListMessagesByIDs(ctx context.Context, messageIDs []string) ([]*models.Message, error)
+	ListRawMessages(ctx context.Context, messageIDs []string) ([]*models.Message, error)
+	FindThreadByMessageIDs(ctx context.Context, inboxID string, referencedIDs []string, messageID string) (string, error)
+	FindThreadBySubject(ctx context.Context, inboxID string, baseSubject string, since time.Time) (string, error)
+	ListThreadsByInbox(ctx context.Context, inboxID string, limit, offset int) ([]*models.Thread, int, error)
+	ListMessagesByThreads(ctx context.Context, threadIDs []string) ([]*models.Message, error)
+	GetMessageIDFromUID(ctx context.Context, inboxID string, folderID string, uid uint32) (string, error)
 
 	// User operations
 	ListUsers(ctx context.Context, limit, offset int) ([]*models.User, int, error)
@@ -66,6 +144,7 @@ type Repository interface {
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	CreateUser(ctx context.Context, user *models.User) error
 	UpdateUser(ctx context.Context, user *models.User) error
+	UpdateUserDefaultInbox(ctx context.Context, userID string, inboxID string) error
 	DeleteUser(ctx context.Context, userId string) error
 
 	// Tokens
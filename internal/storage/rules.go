@@ -49,12 +49,12 @@ func (r *repository) GetRule(ctx context.Context, id string) (*models.ForwardRul
 }
 
 func (r *repository) CreateRule(ctx context.Context, rule *models.ForwardRule) error {
-	return r.queries.CreateRule.QueryRowContext(ctx, rule.InboxID, rule.Sender, rule.Receiver, rule.Subject).
+	return r.queries.CreateRule.QueryRowContext(ctx, rule.InboxID, rule.Sender, rule.Receiver, rule.Subject, rule.ForwardTo).
 		Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
 }
 
 func (r *repository) UpdateRule(ctx context.Context, rule *models.ForwardRule) error {
-	result, err := r.queries.UpdateRule.ExecContext(ctx, rule.Sender, rule.Receiver, rule.Subject, rule.ID)
+	result, err := r.queries.UpdateRule.ExecContext(ctx, rule.Sender, rule.Receiver, rule.Subject, rule.ForwardTo, rule.ID)
 	if err != nil {
 		return handleDBError(err)
 	}
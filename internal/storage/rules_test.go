@@ -96,7 +96,7 @@ func TestRepository_CreateRule(t *testing.T) {
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("INSERT INTO forward_rules").
-					WithArgs(testInboxID1, "sender@example.com", "receiver@example.com", "Test Subject").
+					WithArgs(testInboxID1, "sender@example.com", "receiver@example.com", "Test Subject", "").
 					WillReturnRows(
 						sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
 							AddRow(testInboxID1, now, now),
@@ -114,7 +114,7 @@ func TestRepository_CreateRule(t *testing.T) {
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("INSERT INTO forward_rules").
-					WithArgs(testInboxID1, "sender@example.com", "receiver@example.com", "Test Subject").
+					WithArgs(testInboxID1, "sender@example.com", "receiver@example.com", "Test Subject", "").
 					WillReturnError(sql.ErrConnDone)
 			},
 			wantErr: true,
@@ -164,8 +164,8 @@ func TestRepository_GetRule(t *testing.T) {
 			id:   testRuleID1,
 			mockFn: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{
-					"id", "inbox_id", "sender", "receiver", "subject", "created_at", "updated_at",
-				}).AddRow(testRuleID1, testInboxID1, "sender@example.com", "receiver@example.com", "Test Subject", now, now)
+					"id", "inbox_id", "sender", "receiver", "subject", "forward_to", "created_at", "updated_at",
+				}).AddRow(testRuleID1, testInboxID1, "sender@example.com", "receiver@example.com", "Test Subject", "", now, now)
 
 				mock.ExpectQuery("SELECT (.+) FROM forward_rules").
 					WithArgs(testRuleID1).
@@ -244,7 +244,7 @@ func TestRepository_UpdateRule(t *testing.T) {
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectExec("UPDATE forward_rules").
-					WithArgs("updated@example.com", "newreceiver@example.com", "Updated Subject", testRuleID1).
+					WithArgs("updated@example.com", "newreceiver@example.com", "Updated Subject", "", testRuleID1).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 			wantErr: false,
@@ -260,7 +260,7 @@ func TestRepository_UpdateRule(t *testing.T) {
 			},
 			mockFn: func(mock sqlmock.Sqlmock) {
 				mock.ExpectExec("UPDATE forward_rules").
-					WithArgs("updated@example.com", "newreceiver@example.com", "Updated Subject", nonExistingRuleID).
+					WithArgs("updated@example.com", "newreceiver@example.com", "Updated Subject", "", nonExistingRuleID).
 					WillReturnResult(sqlmock.NewResult(0, 0))
 			},
 			wantErr: true,
@@ -365,10 +365,10 @@ func TestRepository_ListRules(t *testing.T) {
 					WillReturnRows(countRows)
 
 				rows := sqlmock.NewRows([]string{
-					"id", "inbox_id", "sender", "receiver", "subject", "created_at", "updated_at",
+					"id", "inbox_id", "sender", "receiver", "subject", "forward_to", "created_at", "updated_at",
 				}).
-					AddRow(testRuleID1, testInboxID1, "sender1@example.com", "receiver1@example.com", "Subject 1", now, now).
-					AddRow(testRuleID2, testInboxID1, "sender2@example.com", "receiver2@example.com", "Subject 2", now, now)
+					AddRow(testRuleID1, testInboxID1, "sender1@example.com", "receiver1@example.com", "Subject 1", "", now, now).
+					AddRow(testRuleID2, testInboxID1, "sender2@example.com", "receiver2@example.com", "Subject 2", "", now, now)
 
 				mock.ExpectQuery("SELECT (.+) FROM forward_rules").
 					WithArgs(10, 0).
@@ -468,10 +468,10 @@ func TestRepository_ListRulesByInbox(t *testing.T) {
 					WillReturnRows(countRows)
 
 				rows := sqlmock.NewRows([]string{
-					"id", "inbox_id", "sender", "receiver", "subject", "created_at", "updated_at",
+					"id", "inbox_id", "sender", "receiver", "subject", "forward_to", "created_at", "updated_at",
 				}).
-					AddRow(testRuleID1, testInboxID1, "sender1@example.com", "receiver1@example.com", "Subject 1", now, now).
-					AddRow(testRuleID2, testInboxID1, "sender2@example.com", "receiver2@example.com", "Subject 2", now, now)
+					AddRow(testRuleID1, testInboxID1, "sender1@example.com", "receiver1@example.com", "Subject 1", "", now, now).
+					AddRow(testRuleID2, testInboxID1, "sender2@example.com", "receiver2@example.com", "Subject 2", "", now, now)
 
 				mock.ExpectQuery("SELECT (.+) FROM forward_rules").
 					WithArgs(testInboxID1, 10, 0).
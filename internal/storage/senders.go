@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+
+	"inbox451/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (r *repository) ListSenderAddressesByProject(ctx context.Context, projectID string, limit, offset int) ([]*models.SenderAddress, int, error) {
+	var total int
+	err := r.queries.CountSenderAddressesByProject.GetContext(ctx, &total, projectID)
+	if err != nil {
+		return nil, 0, handleDBError(err)
+	}
+
+	senders := []*models.SenderAddress{}
+	if total > 0 {
+		err = r.queries.ListSenderAddressesByProject.SelectContext(ctx, &senders, projectID, limit, offset)
+		if err != nil {
+			return nil, 0, handleDBError(err)
+		}
+	}
+
+	return senders, total, nil
+}
+
+func (r *repository) GetSenderAddress(ctx context.Context, id string) (*models.SenderAddress, error) {
+	var sender models.SenderAddress
+	err := r.queries.GetSenderAddress.GetContext(ctx, &sender, id)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return &sender, nil
+}
+
+func (r *repository) CreateSenderAddress(ctx context.Context, sender *models.SenderAddress) error {
+	err := r.queries.CreateSenderAddress.QueryRowContext(ctx, sender.ProjectID, sender.UserID, sender.Address).
+		Scan(&sender.ID, &sender.CreatedAt, &sender.UpdatedAt)
+	return handleDBError(err)
+}
+
+func (r *repository) DeleteSenderAddress(ctx context.Context, id string) error {
+	result, err := r.queries.DeleteSenderAddress.ExecContext(ctx, id)
+	if err != nil {
+		return handleDBError(err)
+	}
+	return handleRowsAffected(result)
+}
+
+// IsSenderAllowed reports whether a user may submit mail from any of the given
+// lower-cased addresses
+func (r *repository) IsSenderAllowed(ctx context.Context, userID string, addresses []string) (bool, error) {
+	if len(addresses) == 0 {
+		return false, nil
+	}
+
+	var allowed bool
+	err := r.queries.IsSenderAllowed.GetContext(ctx, &allowed, userID, pq.Array(addresses))
+	if err != nil {
+		return false, handleDBError(err)
+	}
+	return allowed, nil
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"inbox451/internal/models"
+	"inbox451/internal/test"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	null "github.com/volatiletech/null/v9"
+)
+
+func setupSenderTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectPrepare("SELECT COUNT(.+) FROM sender_addresses")             // CountSenderAddressesByProject
+	mock.ExpectPrepare("SELECT (.+) FROM sender_addresses WHERE project_id") // ListSenderAddressesByProject
+	mock.ExpectPrepare("INSERT INTO sender_addresses")                       // CreateSenderAddress
+	mock.ExpectPrepare("SELECT EXISTS")                                      // IsSenderAllowed
+
+	countSenders, err := sqlxDB.Preparex("SELECT COUNT(*) FROM sender_addresses WHERE project_id = ?")
+	require.NoError(t, err)
+
+	listSenders, err := sqlxDB.Preparex("SELECT id, project_id, user_id, address, created_at, updated_at FROM sender_addresses WHERE project_id = ? LIMIT ? OFFSET ?")
+	require.NoError(t, err)
+
+	createSender, err := sqlxDB.Preparex("INSERT INTO sender_addresses (project_id, user_id, address) VALUES (?, ?, ?) RETURNING id, created_at, updated_at")
+	require.NoError(t, err)
+
+	isSenderAllowed, err := sqlxDB.Preparex("SELECT EXISTS (SELECT 1 FROM inboxes WHERE lower(email) = ANY(?)) OR EXISTS (SELECT 1 FROM sender_addresses WHERE user_id = ?)")
+	require.NoError(t, err)
+
+	queries := &Queries{
+		CountSenderAddressesByProject: countSenders,
+		ListSenderAddressesByProject:  listSenders,
+		CreateSenderAddress:           createSender,
+		IsSenderAllowed:               isSenderAllowed,
+	}
+
+	repo := &repository{
+		db:      sqlxDB,
+		queries: queries,
+	}
+
+	return repo, mock
+}
+
+func TestRepository_ListSenderAddressesByProject(t *testing.T) {
+	testProjectID := test.RandomTestUUID()
+	testSenderID := test.RandomTestUUID()
+
+	repo, mock := setupSenderTestDB(t)
+	defer repo.db.Close()
+
+	mock.ExpectQuery("SELECT COUNT(.+) FROM sender_addresses").
+		WithArgs(testProjectID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT (.+) FROM sender_addresses").
+		WithArgs(testProjectID, 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project_id", "user_id", "address", "created_at", "updated_at"}).
+			AddRow(testSenderID, testProjectID, nil, "@example.com", nil, nil))
+
+	senders, total, err := repo.ListSenderAddressesByProject(context.Background(), testProjectID, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, senders, 1)
+	assert.Equal(t, "@example.com", senders[0].Address)
+	assert.False(t, senders[0].UserID.Valid)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_CreateSenderAddress(t *testing.T) {
+	testProjectID := test.RandomTestUUID()
+	testUserID := test.RandomTestUUID()
+	testSenderID := test.RandomTestUUID()
+
+	repo, mock := setupSenderTestDB(t)
+	defer repo.db.Close()
+
+	mock.ExpectQuery("INSERT INTO sender_addresses").
+		WithArgs(testProjectID, testUserID, "alerts@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(testSenderID, nil, nil))
+
+	sender := &models.SenderAddress{
+		ProjectID: testProjectID,
+		UserID:    null.StringFrom(testUserID),
+		Address:   "alerts@example.com",
+	}
+	require.NoError(t, repo.CreateSenderAddress(context.Background(), sender))
+	assert.Equal(t, testSenderID, sender.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_IsSenderAllowed(t *testing.T) {
+	testUserID := test.RandomTestUUID()
+
+	tests := []struct {
+		name      string
+		addresses []string
+		mockFn    func(sqlmock.Sqlmock)
+		want      bool
+		wantErr   bool
+	}{
+		{
+			name:      "allowed",
+			addresses: []string{"support@example.com", "@example.com"},
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(testUserID, "{\"support@example.com\",\"@example.com\"}").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			want: true,
+		},
+		{
+			name:      "not allowed",
+			addresses: []string{"ceo@example.com", "@example.com"},
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(testUserID, "{\"ceo@example.com\",\"@example.com\"}").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			want: false,
+		},
+		{
+			name:   "no address",
+			mockFn: func(mock sqlmock.Sqlmock) {},
+			want:   false,
+		},
+		{
+			name:      "database error",
+			addresses: []string{"support@example.com"},
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(testUserID, "{\"support@example.com\"}").
+					WillReturnError(sql.ErrConnDone)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupSenderTestDB(t)
+			defer repo.db.Close()
+
+			tt.mockFn(mock)
+
+			got, err := repo.IsSenderAllowed(context.Background(), testUserID, tt.addresses)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"inbox451/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// FindThreadByMessageIDs returns the thread of the latest message of an inbox whose
+// Message-ID is one of referencedIDs, or that references messageID. It returns an
+// empty string when there is none.
+func (r *repository) FindThreadByMessageIDs(ctx context.Context, inboxID string, referencedIDs []string, messageID string) (string, error) {
+	if len(referencedIDs) == 0 && messageID == "" {
+		return "", nil
+	}
+	if referencedIDs == nil {
+		referencedIDs = []string{}
+	}
+
+	var threadID string
+	err := r.queries.FindThreadByMessageIDs.GetContext(ctx, &threadID, inboxID, pq.Array(referencedIDs), messageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return threadID, handleDBError(err)
+}
+
+// FindThreadBySubject returns the thread of the latest message of an inbox with the
+// given base subject received since the given time. It returns an empty string
+// when there is none.
+func (r *repository) FindThreadBySubject(ctx context.Context, inboxID string, baseSubject string, since time.Time) (string, error) {
+	var threadID string
+	err := r.queries.FindThreadBySubject.GetContext(ctx, &threadID, inboxID, baseSubject, since)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return threadID, handleDBError(err)
+}
+
+// ListThreadsByInbox returns the threads of an inbox, the most recently active first
+func (r *repository) ListThreadsByInbox(ctx context.Context, inboxID string, limit, offset int) ([]*models.Thread, int, error) {
+	var total int
+	err := r.queries.CountThreadsByInbox.GetContext(ctx, &total, inboxID)
+	if err != nil {
+		return nil, 0, handleDBError(err)
+	}
+
+	threads := []*models.Thread{}
+	if total > 0 {
+		err = r.queries.ListThreadsByInbox.SelectContext(ctx, &threads, inboxID, limit, offset)
+		if err != nil {
+			return nil, 0, handleDBError(err)
+		}
+	}
+
+	return threads, total, nil
+}
+
+// ListMessagesByThreads returns the messages of the given threads, oldest first
+func (r *repository) ListMessagesByThreads(ctx context.Context, threadIDs []string) ([]*models.Message, error) {
+	messages := []*models.Message{}
+	if len(threadIDs) == 0 {
+		return messages, nil
+	}
+
+	err := r.queries.ListMessagesByThreads.SelectContext(ctx, &messages, pq.Array(threadIDs))
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return messages, nil
+}
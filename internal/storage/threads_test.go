@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"inbox451/internal/test"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupThreadTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectPrepare("SELECT thread_id FROM messages WHERE inbox_id = (.+) AND header_message_id") // FindThreadByMessageIDs
+	mock.ExpectPrepare("SELECT thread_id FROM messages WHERE inbox_id = (.+) AND base_subject")      // FindThreadBySubject
+	mock.ExpectPrepare("SELECT COUNT(.+) FROM messages WHERE inbox_id")                              // CountThreadsByInbox
+	mock.ExpectPrepare("SELECT thread_id AS id(.+) FROM messages WHERE inbox_id")                    // ListThreadsByInbox
+
+	findThreadByMessageIDs, err := sqlxDB.Preparex("SELECT thread_id FROM messages WHERE inbox_id = ? AND header_message_id = ANY(?) OR header_references @> ARRAY[?]")
+	require.NoError(t, err)
+
+	findThreadBySubject, err := sqlxDB.Preparex("SELECT thread_id FROM messages WHERE inbox_id = ? AND base_subject = ? AND created_at >= ?")
+	require.NoError(t, err)
+
+	countThreads, err := sqlxDB.Preparex("SELECT COUNT(DISTINCT thread_id) FROM messages WHERE inbox_id = ?")
+	require.NoError(t, err)
+
+	listThreads, err := sqlxDB.Preparex("SELECT thread_id AS id, inbox_id, subject, message_count, unread_count, last_activity FROM messages WHERE inbox_id = ? LIMIT ? OFFSET ?")
+	require.NoError(t, err)
+
+	queries := &Queries{
+		FindThreadByMessageIDs: findThreadByMessageIDs,
+		FindThreadBySubject:    findThreadBySubject,
+		CountThreadsByInbox:    countThreads,
+		ListThreadsByInbox:     listThreads,
+	}
+
+	repo := &repository{
+		db:      sqlxDB,
+		queries: queries,
+	}
+
+	return repo, mock
+}
+
+func TestRepository_FindThreadByMessageIDs(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	testThreadID := test.RandomTestUUID()
+
+	tests := []struct {
+		name          string
+		referencedIDs []string
+		messageID     string
+		mockFn        func(sqlmock.Sqlmock)
+		want          string
+	}{
+		{
+			name:          "referenced message found",
+			referencedIDs: []string{"original@example.com"},
+			messageID:     "reply@example.com",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT thread_id FROM messages").
+					WithArgs(testInboxID, "{\"original@example.com\"}", "reply@example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"thread_id"}).AddRow(testThreadID))
+			},
+			want: testThreadID,
+		},
+		{
+			name:      "no related message",
+			messageID: "new@example.com",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT thread_id FROM messages").
+					WithArgs(testInboxID, "{}", "new@example.com").
+					WillReturnError(sql.ErrNoRows)
+			},
+			want: "",
+		},
+		{
+			name:   "nothing to look up",
+			mockFn: func(mock sqlmock.Sqlmock) {},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupThreadTestDB(t)
+			defer repo.db.Close()
+
+			tt.mockFn(mock)
+
+			got, err := repo.FindThreadByMessageIDs(context.Background(), testInboxID, tt.referencedIDs, tt.messageID)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_FindThreadBySubject(t *testing.T) {
+	testInboxID := test.RandomTestUUID()
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	repo, mock := setupThreadTestDB(t)
+	defer repo.db.Close()
+
+	mock.ExpectQuery("SELECT thread_id FROM messages").
+		WithArgs(testInboxID, "order confirmation", since).
+		WillReturnError(sql.ErrConnDone)
+
+	_, err := repo.FindThreadBySubject(context.Background(), testInboxID, "order confirmation", since)
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ListThreadsByInbox(t *testing.T) {
+	now := time.Now()
+	testInboxID := test.RandomTestUUID()
+	testThreadID := test.RandomTestUUID()
+
+	repo, mock := setupThreadTestDB(t)
+	defer repo.db.Close()
+
+	mock.ExpectQuery("SELECT COUNT(.+) FROM messages").
+		WithArgs(testInboxID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT thread_id AS id(.+) FROM messages").
+		WithArgs(testInboxID, 10, 0).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "inbox_id", "subject", "message_count", "unread_count", "last_activity"}).
+				AddRow(testThreadID, testInboxID, "Order confirmation", 3, 1, now),
+		)
+
+	threads, total, err := repo.ListThreadsByInbox(context.Background(), testInboxID, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, threads, 1)
+	assert.Equal(t, testThreadID, threads[0].ID)
+	assert.Equal(t, "Order confirmation", threads[0].Subject)
+	assert.Equal(t, 3, threads[0].MessageCount)
+	assert.Equal(t, 1, threads[0].UnreadCount)
+	assert.True(t, threads[0].LastActivity.Valid)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+
+	"inbox451/internal/models"
+)
+
+// ListTLSReports lists the newest reports first, only those covering domain
+// unless it is empty
+func (r *repository) ListTLSReports(ctx context.Context, domain string, limit, offset int) ([]*models.TLSReport, int, error) {
+	var total int
+	err := r.queries.CountTLSReports.GetContext(ctx, &total, domain)
+	if err != nil {
+		return nil, 0, handleDBError(err)
+	}
+
+	reports := []*models.TLSReport{}
+	if total > 0 {
+		err = r.queries.ListTLSReports.SelectContext(ctx, &reports, domain, limit, offset)
+		if err != nil {
+			return nil, 0, handleDBError(err)
+		}
+	}
+
+	return reports, total, nil
+}
+
+func (r *repository) GetTLSReport(ctx context.Context, id string) (*models.TLSReport, error) {
+	var report models.TLSReport
+	err := r.queries.GetTLSReport.GetContext(ctx, &report, id)
+	if err != nil {
+		return nil, handleDBError(err)
+	}
+	return &report, nil
+}
+
+// CreateTLSReport stores a report, replacing an earlier copy of it
+func (r *repository) CreateTLSReport(ctx context.Context, report *models.TLSReport) error {
+	err := r.queries.CreateTLSReport.QueryRowContext(ctx,
+		report.OrganizationName, report.ReportID, report.ContactInfo, report.StartDate, report.EndDate,
+		report.Domains, report.SuccessfulSessions, report.FailedSessions, string(report.Policies),
+	).Scan(&report.ID, &report.CreatedAt, &report.UpdatedAt)
+	return handleDBError(err)
+}
+
+func (r *repository) DeleteTLSReport(ctx context.Context, id string) error {
+	result, err := r.queries.DeleteTLSReport.ExecContext(ctx, id)
+	if err != nil {
+		return handleDBError(err)
+	}
+	return handleRowsAffected(result)
+}
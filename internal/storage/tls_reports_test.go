@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"inbox451/internal/models"
+	"inbox451/internal/test"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	null "github.com/volatiletech/null/v9"
+)
+
+func setupTLSReportTestDB(t *testing.T) (*repository, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	sqlxDB := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectPrepare("SELECT COUNT(.+) FROM tls_reports") // CountTLSReports
+	mock.ExpectPrepare("SELECT (.+) FROM tls_reports")      // ListTLSReports
+	mock.ExpectPrepare("INSERT INTO tls_reports")           // CreateTLSReport
+
+	count, err := sqlxDB.Preparex("SELECT COUNT(*) FROM tls_reports WHERE ? = '' OR ? = ANY(domains)")
+	require.NoError(t, err)
+
+	list, err := sqlxDB.Preparex("SELECT id, organization_name, report_id, contact_info, start_date, end_date, domains, successful_sessions, failed_sessions, policies, created_at, updated_at FROM tls_reports LIMIT ? OFFSET ?")
+	require.NoError(t, err)
+
+	create, err := sqlxDB.Preparex("INSERT INTO tls_reports (organization_name, report_id, contact_info, start_date, end_date, domains, successful_sessions, failed_sessions, policies) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, created_at, updated_at")
+	require.NoError(t, err)
+
+	queries := &Queries{
+		CountTLSReports: count,
+		ListTLSReports:  list,
+		CreateTLSReport: create,
+	}
+
+	repo := &repository{
+		db:      sqlxDB,
+		queries: queries,
+	}
+
+	return repo, mock
+}
+
+func TestRepository_ListTLSReports(t *testing.T) {
+	testReportID := test.RandomTestUUID()
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		mockFn    func(sqlmock.Sqlmock)
+		wantCount int
+		wantTotal int
+	}{
+		{
+			name: "reports of a domain",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT COUNT(.+) FROM tls_reports").
+					WithArgs("example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.+) FROM tls_reports").
+					WithArgs("example.com", 10, 0).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "organization_name", "report_id", "contact_info", "start_date", "end_date", "domains", "successful_sessions", "failed_sessions", "policies", "created_at", "updated_at"}).
+						AddRow(testReportID, "Example Org", "2026-10-01T00:00:00Z_example.com", "tlsrpt@example.org", start, start.Add(24*time.Hour), "{example.com}", 10, 2, []byte(`[]`), nil, nil))
+			},
+			wantCount: 1,
+			wantTotal: 1,
+		},
+		{
+			name: "no reports",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT COUNT(.+) FROM tls_reports").
+					WithArgs("example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupTLSReportTestDB(t)
+			defer repo.db.Close()
+
+			tt.mockFn(mock)
+
+			reports, total, err := repo.ListTLSReports(context.Background(), "example.com", 10, 0)
+			require.NoError(t, err)
+			assert.Len(t, reports, tt.wantCount)
+			assert.Equal(t, tt.wantTotal, total)
+			if tt.wantCount > 0 {
+				assert.Equal(t, pq.StringArray{"example.com"}, reports[0].Domains)
+				assert.Equal(t, int64(2), reports[0].FailedSessions)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_CreateTLSReport(t *testing.T) {
+	testReportID := test.RandomTestUUID()
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	repo, mock := setupTLSReportTestDB(t)
+	defer repo.db.Close()
+
+	report := &models.TLSReport{
+		OrganizationName:   "Example Org",
+		ReportID:           "2026-10-01T00:00:00Z_example.com",
+		StartDate:          null.TimeFrom(start),
+		EndDate:            null.TimeFrom(start.Add(24 * time.Hour)),
+		Domains:            pq.StringArray{"example.com"},
+		SuccessfulSessions: 10,
+		Policies:           json.RawMessage(`[]`),
+	}
+
+	mock.ExpectQuery("INSERT INTO tls_reports").
+		WithArgs(report.OrganizationName, report.ReportID, "", report.StartDate, report.EndDate, report.Domains, int64(10), int64(0), `[]`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(testReportID, nil, nil))
+
+	require.NoError(t, repo.CreateTLSReport(context.Background(), report))
+	assert.Equal(t, testReportID, report.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -85,6 +85,16 @@ func (r *repository) UpdateUser(ctx context.Context, user *models.User) error {
 		Scan(&user.UpdatedAt)
 }
 
+// UpdateUserDefaultInbox sets the inbox IMAP clients see as INBOX. An empty
+// inboxID clears it.
+func (r *repository) UpdateUserDefaultInbox(ctx context.Context, userID string, inboxID string) error {
+	result, err := r.queries.UpdateUserDefaultInbox.ExecContext(ctx, userID, inboxID)
+	if err != nil {
+		return handleDBError(err)
+	}
+	return handleRowsAffected(result)
+}
+
 func (r *repository) DeleteUser(ctx context.Context, id string) error {
 	result, err := r.queries.DeleteUser.ExecContext(ctx, id)
 	if err != nil {